@@ -7,6 +7,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 
@@ -104,6 +105,7 @@ func initCommands(
 		RunningInAutomation: inAutomation,
 		CLIConfigDir:        configDir,
 		PluginCacheDir:      config.PluginCacheDir,
+		ModuleCacheDir:      config.ModuleCacheDir,
 
 		PluginCacheMayBreakDependencyLockFile: config.PluginCacheMayBreakDependencyLockFile,
 
@@ -120,6 +122,15 @@ func initCommands(
 		ProviderSource:       providerSrc,
 		ProviderDevOverrides: providerDevOverrides,
 		UnmanagedProviders:   unmanagedProviders,
+		ProviderPolicy:       providerPolicy(config.ProviderPolicy),
+		GetOCIRepositoryPushStore: func(ctx context.Context, registryDomain, repositoryName string) (getproviders.OCIRepositoryPushStore, error) {
+			credsPolicy, err := config.OCICredentialsPolicy(ctx)
+			if err != nil {
+				// This deals with only a small number of errors that we can't catch during CLI config validation
+				return nil, fmt.Errorf("invalid credentials configuration for OCI registries: %w", err)
+			}
+			return getOCIRepositoryStore(ctx, registryDomain, repositoryName, credsPolicy)
+		},
 
 		AllowExperimentalFeatures: experimentsAreAllowed(),
 	}
@@ -137,6 +148,18 @@ func initCommands(
 			}, nil
 		},
 
+		"bundle": func() (cli.Command, error) {
+			return &command.BundleCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"bundle create": func() (cli.Command, error) {
+			return &command.BundleCreateCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"console": func() (cli.Command, error) {
 			return &command.ConsoleCommand{
 				Meta: meta,
@@ -239,6 +262,30 @@ func initCommands(
 			}, nil
 		},
 
+		"modules": func() (cli.Command, error) {
+			return &command.ModulesCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"modules cache": func() (cli.Command, error) {
+			return &command.ModulesCacheCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"modules cache prune": func() (cli.Command, error) {
+			return &command.ModulesCachePruneCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"modules outdated": func() (cli.Command, error) {
+			return &command.ModulesOutdatedCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"output": func() (cli.Command, error) {
 			return &command.OutputCommand{
 				Meta: meta,
@@ -269,6 +316,36 @@ func initCommands(
 			}, nil
 		},
 
+		"providers outdated": func() (cli.Command, error) {
+			return &command.ProvidersOutdatedCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"providers override": func() (cli.Command, error) {
+			return &command.ProvidersOverrideCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"providers override list": func() (cli.Command, error) {
+			return &command.ProvidersOverrideListCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"providers override set": func() (cli.Command, error) {
+			return &command.ProvidersOverrideSetCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"providers override unset": func() (cli.Command, error) {
+			return &command.ProvidersOverrideUnsetCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"providers schema": func() (cli.Command, error) {
 			return &command.ProvidersSchemaCommand{
 				Meta: meta,
@@ -287,6 +364,12 @@ func initCommands(
 			}, nil
 		},
 
+		"sbom": func() (cli.Command, error) {
+			return &command.SBOMCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"show": func() (cli.Command, error) {
 			return &command.ShowCommand{
 				Meta: meta,
@@ -438,6 +521,18 @@ func initCommands(
 			}, nil
 		},
 
+		"state restore-from-mirror": func() (cli.Command, error) {
+			return &command.StateRestoreFromMirrorCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"state serve-outputs": func() (cli.Command, error) {
+			return &command.StateServeOutputsCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"state show": func() (cli.Command, error) {
 			return &command.StateShowCommand{
 				Meta: meta,
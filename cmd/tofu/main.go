@@ -197,7 +197,7 @@ func realMain() int {
 	}
 	services := newServiceDiscovery(ctx, credsSrc)
 
-	modulePkgFetcher := remoteModulePackageFetcher(ctx, config.OCICredentialsPolicy)
+	modulePkgFetcher := remoteModulePackageFetcher(ctx, config.OCICredentialsPolicy, config.ModuleInstallation)
 
 	providerSrc, diags := providerSource(ctx, config.ProviderInstallation, services, config.OCICredentialsPolicy)
 	if len(diags) > 0 {
@@ -6,22 +6,47 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
 
+	"github.com/opentofu/opentofu/internal/command/cliconfig"
+	"github.com/opentofu/opentofu/internal/cosign"
 	"github.com/opentofu/opentofu/internal/getmodules"
 )
 
-func remoteModulePackageFetcher(ctx context.Context, getOCICredsPolicy ociCredsPolicyBuilder) *getmodules.PackageFetcher {
-	// TODO: Pass in a real getmodules.PackageFetcherEnvironment here,
-	// which knows how to make use of the OCI authentication policy.
-	return getmodules.NewPackageFetcher(ctx, &modulePackageFetcherEnvironment{
+func remoteModulePackageFetcher(ctx context.Context, getOCICredsPolicy ociCredsPolicyBuilder, configs []*cliconfig.ModuleInstallation) *getmodules.PackageFetcher {
+	env := &modulePackageFetcherEnvironment{
 		getOCICredsPolicy: getOCICredsPolicy,
-	})
+		signaturePolicies: make(map[*cliconfig.OCISignatureVerification]func() (*cosign.Policy, error)),
+		sourceCredentials: make(map[string]func() (*getmodules.SourceCredentials, error)),
+	}
+	// There should only be zero or one configurations, which is checked by
+	// the validation logic in the cliconfig package. Therefore we'll just
+	// ignore any additional configurations in here.
+	if len(configs) != 0 {
+		env.moduleInstallation = configs[0]
+	}
+	return getmodules.NewPackageFetcher(ctx, env)
 }
 
 type modulePackageFetcherEnvironment struct {
-	getOCICredsPolicy ociCredsPolicyBuilder
+	getOCICredsPolicy  ociCredsPolicyBuilder
+	moduleInstallation *cliconfig.ModuleInstallation
+
+	// signaturePolicies caches the result of loading each of the signature
+	// verification policies, so that each one is loaded at most once.
+	signaturePolicies   map[*cliconfig.OCISignatureVerification]func() (*cosign.Policy, error)
+	signaturePoliciesMu sync.Mutex
+
+	// sourceCredentials caches the credentials for each hostname, so that
+	// any credentials helper is run at most once per hostname.
+	sourceCredentials   map[string]func() (*getmodules.SourceCredentials, error)
+	sourceCredentialsMu sync.Mutex
 }
 
 // OCIRepositoryStore implements getmodules.PackageFetcherEnvironment.
@@ -38,3 +63,85 @@ func (m *modulePackageFetcherEnvironment) OCIRepositoryStore(ctx context.Context
 	}
 	return getOCIRepositoryStore(ctx, registryDomainName, repositoryPath, credsPolicy)
 }
+
+// OCISignaturePolicy implements getmodules.PackageFetcherEnvironment.
+func (m *modulePackageFetcherEnvironment) OCISignaturePolicy(_ context.Context, registryDomainName string, repositoryPath string) (*cosign.Policy, error) {
+	verification := m.moduleInstallation.OCISignatureVerificationFor(registryDomainName, repositoryPath)
+	if verification == nil {
+		return nil, nil // signatures are not required for this repository
+	}
+	m.signaturePoliciesMu.Lock()
+	load, ok := m.signaturePolicies[verification]
+	if !ok {
+		load = sync.OnceValues(verification.Policy)
+		m.signaturePolicies[verification] = load
+	}
+	m.signaturePoliciesMu.Unlock()
+	policy, err := load()
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature verification configuration for OCI registries: %w", err)
+	}
+	return policy, nil
+}
+
+// SourceCredentials implements getmodules.PackageFetcherEnvironment.
+func (m *modulePackageFetcherEnvironment) SourceCredentials(ctx context.Context, hostname string) (*getmodules.SourceCredentials, error) {
+	config := m.moduleInstallation.SourceCredentialsFor(hostname)
+	if config == nil {
+		return nil, nil // no credentials for this host
+	}
+	if config.CredentialsHelper == "" {
+		return &getmodules.SourceCredentials{
+			Username:   config.Username,
+			Token:      config.Token,
+			SSHKeyFile: config.SSHKeyFile,
+		}, nil
+	}
+
+	m.sourceCredentialsMu.Lock()
+	load, ok := m.sourceCredentials[hostname]
+	if !ok {
+		load = sync.OnceValues(func() (*getmodules.SourceCredentials, error) {
+			return runModuleSourceCredentialsHelper(ctx, config, hostname)
+		})
+		m.sourceCredentials[hostname] = load
+	}
+	m.sourceCredentialsMu.Unlock()
+	return load()
+}
+
+// runModuleSourceCredentialsHelper runs the credentials helper program from
+// the given configuration to obtain credentials for the given hostname.
+//
+// The program is run with its configured arguments followed by the hostname,
+// and must print a JSON object to its stdout with a "token" property and
+// optionally a "username" property.
+func runModuleSourceCredentialsHelper(ctx context.Context, config *cliconfig.ModuleSourceCredentials, hostname string) (*getmodules.SourceCredentials, error) {
+	args := append(append([]string(nil), config.CredentialsHelperArgs...), hostname)
+	cmd := exec.CommandContext(ctx, config.CredentialsHelper, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("credentials helper %s failed: %w\n%s", config.CredentialsHelper, err, msg)
+		}
+		return nil, fmt.Errorf("credentials helper %s failed: %w", config.CredentialsHelper, err)
+	}
+
+	var result struct {
+		Username string `json:"username"`
+		Token    string `json:"token"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return nil, fmt.Errorf("credentials helper %s returned invalid JSON: %w", config.CredentialsHelper, err)
+	}
+	if result.Token == "" {
+		return nil, fmt.Errorf("credentials helper %s did not return a token for %s", config.CredentialsHelper, hostname)
+	}
+	return &getmodules.SourceCredentials{
+		Username:   result.Username,
+		Token:      result.Token,
+		SSHKeyFile: config.SSHKeyFile,
+	}, nil
+}
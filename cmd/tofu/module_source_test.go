@@ -0,0 +1,116 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/opentofu/opentofu/internal/command/cliconfig"
+	"github.com/opentofu/opentofu/internal/getmodules"
+)
+
+func TestModulePackageFetcherEnvironmentSourceCredentials(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("this test uses a shell script as the credentials helper")
+	}
+
+	// The helper script records each hostname it's run for, so we can
+	// check that it only runs once per hostname.
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "calls")
+	helper := filepath.Join(dir, "helper")
+	script := `#!/bin/sh
+echo "$2" >>` + logFile + `
+if [ "$2" = "broken.example.com" ]; then
+  echo "no credentials for $2" >&2
+  exit 1
+fi
+echo '{"username":"'"$1"'","token":"token-for-'"$2"'"}'
+`
+	if err := os.WriteFile(helper, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	env := &modulePackageFetcherEnvironment{
+		moduleInstallation: &cliconfig.ModuleInstallation{
+			SourceCredentials: map[string]*cliconfig.ModuleSourceCredentials{
+				"git.example.com": {
+					Token:      "static",
+					SSHKeyFile: "/home/example/.ssh/id_ed25519",
+				},
+				"*.example.com": {
+					CredentialsHelper:     helper,
+					CredentialsHelperArgs: []string{"helper-user"},
+				},
+			},
+		},
+		sourceCredentials: make(map[string]func() (*getmodules.SourceCredentials, error)),
+	}
+
+	tests := []struct {
+		hostname string
+		want     *getmodules.SourceCredentials
+		wantErr  string
+	}{
+		{
+			hostname: "git.example.com",
+			want: &getmodules.SourceCredentials{
+				Token:      "static",
+				SSHKeyFile: "/home/example/.ssh/id_ed25519",
+			},
+		},
+		{
+			hostname: "other.example.com",
+			want: &getmodules.SourceCredentials{
+				Username: "helper-user",
+				Token:    "token-for-other.example.com",
+			},
+		},
+		{
+			hostname: "other.example.com", // cached
+			want: &getmodules.SourceCredentials{
+				Username: "helper-user",
+				Token:    "token-for-other.example.com",
+			},
+		},
+		{
+			hostname: "broken.example.com",
+			wantErr:  "no credentials for broken.example.com",
+		},
+		{
+			hostname: "example.net",
+			want:     nil,
+		},
+	}
+	for _, test := range tests {
+		got, err := env.SourceCredentials(t.Context(), test.hostname)
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s: wrong error\ngot:  %v\nwant: %s", test.hostname, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.hostname, err)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s: wrong credentials\n%s", test.hostname, diff)
+		}
+	}
+
+	calls, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(calls), "other.example.com\nbroken.example.com\n"; got != want {
+		t.Errorf("wrong helper calls\ngot:  %q\nwant: %q", got, want)
+	}
+}
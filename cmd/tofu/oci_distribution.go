@@ -164,15 +164,16 @@ func getOCIRepositoryORASClient(ctx context.Context, registryDomain, repositoryN
 	}, nil
 }
 
-// ociRepositoryStore represents the combined needs of both
-// [getproviders.OCIRepositoryStore] and [getmodules.OCIRepositoryStore],
-// both of which are intentionally defined to be subsets of the API
+// ociRepositoryStore represents the combined needs of
+// [getproviders.OCIRepositoryStore], [getproviders.OCIRepositoryPushStore],
+// and [getmodules.OCIRepositoryStore], all of which are intentionally defined to be subsets of the API
 // used by ORAS-Go so that we can use the implementations from that
 // library without directly exposing any ORAS-Go symbols in the
 // public API of any of our packages, since we want to reserve the
 // ability to switch to other implementations in future if needed.
 type ociRepositoryStore interface {
 	getproviders.OCIRepositoryStore
+	getproviders.OCIRepositoryPushStore
 	getmodules.OCIRepositoryStore
 }
 
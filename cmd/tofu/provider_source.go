@@ -12,13 +12,16 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/apparentlymart/go-userdirs/userdirs"
 	"github.com/opentofu/svchost/disco"
 
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/command/cliconfig"
+	"github.com/opentofu/opentofu/internal/cosign"
 	"github.com/opentofu/opentofu/internal/getproviders"
+	"github.com/opentofu/opentofu/internal/providercache"
 	"github.com/opentofu/opentofu/internal/tfdiags"
 )
 
@@ -250,6 +253,7 @@ func providerSourceForCLIConfigLocation(ctx context.Context, loc cliconfig.Provi
 				}
 				return getOCIRepositoryStore(ctx, registryDomain, repositoryName, credsPolicy)
 			},
+			ociMirrorSignaturePolicyFunc(loc.SignatureVerification),
 		), nil
 
 	default:
@@ -270,3 +274,38 @@ func providerDevOverrides(configs []*cliconfig.ProviderInstallation) map[addrs.P
 	// ignore any additional configurations in here.
 	return configs[0].DevOverrides
 }
+
+// providerPolicy returns the provider installation policy described by the
+// provider_policy block in the CLI configuration, or nil if there is no such
+// block.
+func providerPolicy(configs []*cliconfig.ProviderPolicy) *providercache.ProviderPolicy {
+	if len(configs) == 0 {
+		return nil
+	}
+
+	// As with providerDevOverrides, the validation logic in the cliconfig
+	// package ensures that there is no more than one configuration.
+	config := configs[0]
+	return &providercache.ProviderPolicy{
+		AllowedProviders:    config.AllowedNamespaces,
+		DeniedProviders:     config.DeniedProviders,
+		VersionLimits:       config.VersionLimits,
+		RequiredSigningKeys: config.RequiredSigningKeys,
+	}
+}
+
+// ociMirrorSignaturePolicyFunc returns a function that lazily loads the
+// signature verification policy for an oci_mirror installation method, or
+// nil if the method doesn't require signatures.
+//
+// The policy is loaded at most once, because it involves reading and parsing
+// the trusted key files.
+func ociMirrorSignaturePolicyFunc(verification *cliconfig.OCISignatureVerification) func(context.Context) (*cosign.Policy, error) {
+	if verification == nil {
+		return nil
+	}
+	load := sync.OnceValues(verification.Policy)
+	return func(_ context.Context) (*cosign.Policy, error) {
+		return load()
+	}
+}
@@ -88,8 +88,10 @@ func (b *Local) opApply(
 		return
 	}
 	// the state was locked during successful context creation; unlock the state
-	// when the operation completes
+	// when the operation completes. We first wait for any snapshots still
+	// being persisted in the background, even if locking is disabled.
 	defer func() {
+		statemgr.Flush(opState)
 		diags := op.StateLocker.Unlock()
 		if diags.HasErrors() {
 			op.View.Diagnostics(diags)
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/zclconf/go-cty/cty"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/opentofu/opentofu/internal/states/statemgr"
 	"github.com/opentofu/opentofu/internal/terminal"
 	"github.com/opentofu/opentofu/internal/tfdiags"
+	"github.com/opentofu/opentofu/internal/tofu"
 )
 
 func TestLocal_applyBasic(t *testing.T) {
@@ -76,6 +78,62 @@ test_instance.foo:
 		t.Fatalf("unexpected error output:\n%s", errOutput)
 	}
 }
+
+// TestLocal_applyMirroredWithoutLocking checks that an apply waits for the
+// state to be written to an asynchronous mirror even when the state isn't
+// locked, since it's otherwise only waited for when the state is unlocked.
+func TestLocal_applyMirroredWithoutLocking(t *testing.T) {
+	storage := TestLocal(t)
+	b := TestLocal(t)
+	mirrorPath := filepath.Join(t.TempDir(), "mirror.tfstate")
+	b.Backend = backendWithMirroredStateStorage{
+		Local:  storage,
+		mirror: slowPersistStorage{statemgr.NewFilesystem(mirrorPath, encryption.StateEncryptionDisabled())},
+		async:  true,
+	}
+
+	p := TestLocalProvider(t, b, "test", applyFixtureSchema())
+	p.ApplyResourceChangeResponse = &providers.ApplyResourceChangeResponse{NewState: cty.ObjectVal(map[string]cty.Value{
+		"id":  cty.StringVal("yes"),
+		"ami": cty.StringVal("bar"),
+	})}
+
+	// testOperationApply uses a no-op state locker, so the state manager is
+	// never unlocked.
+	op, done := testOperationApply(t, "./testdata/apply")
+
+	run, err := b.Operation(context.Background(), op)
+	if err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	<-run.Done()
+	if run.Result != backend.OperationSuccess {
+		t.Fatal("operation failed")
+	}
+
+	checkState(t, mirrorPath, `
+test_instance.foo:
+  ID = yes
+  provider = provider["registry.opentofu.org/hashicorp/test"]
+  ami = bar
+`)
+
+	if errOutput := done(t).Stderr(); errOutput != "" {
+		t.Fatalf("unexpected error output:\n%s", errOutput)
+	}
+}
+
+// slowPersistStorage delays each snapshot it persists, so that the snapshots
+// written to it in the background are still pending when an operation ends.
+type slowPersistStorage struct {
+	statemgr.Storage
+}
+
+func (s slowPersistStorage) PersistState(ctx context.Context, schemas *tofu.Schemas) error {
+	time.Sleep(100 * time.Millisecond)
+	return s.Storage.PersistState(ctx, schemas)
+}
+
 func TestLocal_applyCheck(t *testing.T) {
 	b := TestLocal(t)
 
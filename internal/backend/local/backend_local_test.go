@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/zclconf/go-cty/cty"
@@ -123,15 +124,32 @@ func TestLocalRun_cloudPlan(t *testing.T) {
 }
 
 func TestLocalRun_stalePlan(t *testing.T) {
-	configDir := "./testdata/apply"
 	b := TestLocal(t)
+	testLocalRunStalePlan(t, b, b.StatePath)
+}
+
+func TestLocalRun_stalePlanMirrored(t *testing.T) {
+	storage := TestLocal(t)
+	b := TestLocal(t)
+	b.Backend = backendWithMirroredStateStorage{
+		Local:  storage,
+		mirror: statemgr.NewFilesystem(filepath.Join(t.TempDir(), "mirror.tfstate"), encryption.StateEncryptionDisabled()),
+	}
+	testLocalRunStalePlan(t, b, storage.StatePath)
+}
+
+// testLocalRunStalePlan checks that LocalRun rejects a plan created from an
+// older snapshot than the one at statePath, where b reads its state from.
+func testLocalRunStalePlan(t *testing.T, b *Local, statePath string) {
+	t.Helper()
+	configDir := "./testdata/apply"
 
 	_, configLoader := initwd.MustLoadConfigForTests(t, configDir, "tests")
 
 	// Write an empty state file with serial 3
-	sf, err := os.Create(b.StatePath)
+	sf, err := os.Create(statePath)
 	if err != nil {
-		t.Fatalf("unexpected error creating state file %s: %s", b.StatePath, err)
+		t.Fatalf("unexpected error creating state file %s: %s", statePath, err)
 	}
 	if err := statefile.Write(statefile.New(states.NewState(), "boop", 3), sf, encryption.StateEncryptionDisabled()); err != nil {
 		t.Fatalf("unexpected error writing state file: %s", err)
@@ -202,11 +220,31 @@ func TestLocalRun_stalePlan(t *testing.T) {
 	if !diags.HasErrors() {
 		t.Fatal("unexpected success")
 	}
+	if got, want := diags.Err().Error(), "Saved plan is stale"; !strings.Contains(got, want) {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, want)
+	}
 
 	// LocalRun() unlocks the state on failure
 	assertBackendStateUnlocked(t, b)
 }
 
+// backendWithMirroredStateStorage stores state using the given local backend,
+// but also mirrors it to another state manager as happens for a backend
+// configured with a mirror block.
+type backendWithMirroredStateStorage struct {
+	*Local
+	mirror statemgr.Storage
+	async  bool
+}
+
+func (b backendWithMirroredStateStorage) StateMgr(ctx context.Context, workspace string) (statemgr.Full, error) {
+	primary, err := b.Local.StateMgr(ctx, workspace)
+	if err != nil {
+		return nil, err
+	}
+	return statemgr.NewMirrored(primary, b.mirror, b.async, nil), nil
+}
+
 type backendWithStateStorageThatFailsRefresh struct {
 }
 
@@ -69,6 +69,14 @@ func New(enc encryption.StateEncryption) backend.Backend {
 				Default:     false,
 			},
 
+			"compression": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Description:   "Compress the state data using the given algorithm: none, gzip or zstd",
+				Default:       "",
+				ConflictsWith: []string{"gzip"},
+			},
+
 			"lock": &schema.Schema{
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -73,16 +73,22 @@ func (b *Backend) StateMgr(_ context.Context, name string) (statemgr.Full, error
 	// Determine the path of the data
 	path := b.path(name)
 
-	// Determine whether to gzip or not
-	gzip := b.configData.Get("gzip").(bool)
+	// Determine which compression to use, if any
+	compression, err := remote.ParseCompression(b.configData.Get("compression").(string))
+	if err != nil {
+		return nil, err
+	}
+	if b.configData.Get("gzip").(bool) {
+		compression = remote.CompressionGzip
+	}
 
 	// Build the state client
 	var stateMgr = remote.NewState(
 		&RemoteClient{
-			Client:    b.client,
-			Path:      path,
-			GZip:      gzip,
-			lockState: b.lock,
+			Client:      b.client,
+			Path:        path,
+			Compression: compression,
+			lockState:   b.lock,
 		},
 		b.encryption,
 	)
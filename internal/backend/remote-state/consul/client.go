@@ -6,8 +6,6 @@
 package consul
 
 import (
-	"bytes"
-	"compress/gzip"
 	"context"
 	"crypto/md5"
 	"encoding/json"
@@ -43,7 +41,10 @@ var lostLockErr = errors.New("consul lock was lost")
 // RemoteClient is a remote client that stores data in Consul.
 type RemoteClient struct {
 	Path string
-	GZip bool
+
+	// Compression is applied to newly-written snapshots. Snapshots are
+	// decompressed on read regardless of this setting.
+	Compression remote.Compression
 
 	mu     sync.Mutex
 	Client *consulapi.Client
@@ -105,12 +106,9 @@ func (c *RemoteClient) Get(_ context.Context) (*remote.Payload, error) {
 		payload = pair.Value
 	}
 
-	// If the payload starts with 0x1f, it's gzip, not json
-	if len(payload) >= 1 && payload[0] == '\x1f' {
-		payload, err = uncompressState(payload)
-		if err != nil {
-			return nil, err
-		}
+	payload, err = remote.Decompress(payload)
+	if err != nil {
+		return nil, err
 	}
 
 	md5 := md5.Sum(payload)
@@ -185,13 +183,9 @@ func (c *RemoteClient) Put(_ context.Context, data []byte) error {
 		}
 	}
 
-	payload := data
-	if c.GZip {
-		if compressedState, err := compressState(data); err == nil {
-			payload = compressedState
-		} else {
-			return err
-		}
+	payload, err := remote.Compress(data, c.Compression)
+	if err != nil {
+		return err
 	}
 
 	// default to doing a CAS
@@ -267,7 +261,7 @@ func (c *RemoteClient) Put(_ context.Context, data []byte) error {
 	// The payload was too large so we split it in multiple chunks
 
 	md5 := md5.Sum(data)
-	chunks := split(payload, 524288)
+	chunks := remote.SplitChunks(payload, 524288)
 	chunkPaths := make([]string, 0)
 
 	// First we write the new chunks
@@ -285,9 +279,9 @@ func (c *RemoteClient) Put(_ context.Context, data []byte) error {
 	}
 
 	// Then we update the link to point to the new chunks
-	payload, err = json.Marshal(map[string]interface{}{
-		"current-hash": fmt.Sprintf("%x", md5),
-		"chunks":       chunkPaths,
+	payload, err = json.Marshal(&remote.ChunkManifest{
+		Hash:   fmt.Sprintf("%x", md5),
+		Chunks: chunkPaths,
 	})
 	if err != nil {
 		return err
@@ -639,50 +633,6 @@ func (c *RemoteClient) unlock(id string) error {
 	return errs
 }
 
-func compressState(data []byte) ([]byte, error) {
-	b := new(bytes.Buffer)
-	gz := gzip.NewWriter(b)
-	if _, err := gz.Write(data); err != nil {
-		return nil, err
-	}
-	if err := gz.Flush(); err != nil {
-		return nil, err
-	}
-	if err := gz.Close(); err != nil {
-		return nil, err
-	}
-	return b.Bytes(), nil
-}
-
-func uncompressState(data []byte) ([]byte, error) {
-	b := new(bytes.Buffer)
-	gz, err := gzip.NewReader(bytes.NewReader(data))
-	if err != nil {
-		return nil, err
-	}
-	_, err = b.ReadFrom(gz)
-	if err != nil {
-		return nil, err
-	}
-	if err := gz.Close(); err != nil {
-		return nil, err
-	}
-	return b.Bytes(), nil
-}
-
-func split(payload []byte, limit int) [][]byte {
-	var chunk []byte
-	chunks := make([][]byte, 0, len(payload)/limit+1)
-	for len(payload) >= limit {
-		chunk, payload = payload[:limit], payload[limit:]
-		chunks = append(chunks, chunk)
-	}
-	if len(payload) > 0 {
-		chunks = append(chunks, payload[:])
-	}
-	return chunks
-}
-
 func (c *RemoteClient) chunkedMode() (bool, string, []string, *consulapi.KVPair, error) {
 	kv := c.Client.KV()
 	pair, _, err := kv.Get(c.Path, nil)
@@ -690,20 +640,10 @@ func (c *RemoteClient) chunkedMode() (bool, string, []string, *consulapi.KVPair,
 		return false, "", nil, pair, err
 	}
 	if pair != nil {
-		var d map[string]interface{}
-		err = json.Unmarshal(pair.Value, &d)
-		// If there is an error when unmarshaling the payload, the state has
-		// probably been gzipped in single entry mode.
-		if err == nil {
-			// If we find the "current-hash" key we were in chunked mode
-			hash, ok := d["current-hash"]
-			if ok {
-				chunks := make([]string, 0)
-				for _, c := range d["chunks"].([]interface{}) {
-					chunks = append(chunks, c.(string))
-				}
-				return true, hash.(string), chunks, pair, nil
-			}
+		// If we find a chunk manifest rather than a state payload then we
+		// were in chunked mode.
+		if m := remote.ParseChunkManifest(pair.Value); m != nil {
+			return true, m.Hash, m.Chunks, pair, nil
 		}
 	}
 	return false, "", nil, pair, nil
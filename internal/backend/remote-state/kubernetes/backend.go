@@ -18,6 +18,7 @@ import (
 	"github.com/opentofu/opentofu/internal/encryption"
 	"github.com/opentofu/opentofu/internal/httpclient"
 	"github.com/opentofu/opentofu/internal/legacy/helper/schema"
+	"github.com/opentofu/opentofu/internal/states/remote"
 	"github.com/opentofu/opentofu/version"
 	k8sSchema "k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
@@ -57,6 +58,12 @@ func New(enc encryption.StateEncryption) backend.Backend {
 				DefaultFunc: schema.EnvDefaultFunc("KUBE_NAMESPACE", "default"),
 				Description: "Namespace to store the secret in.",
 			},
+			"compression": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     string(remote.CompressionGzip),
+				Description: "Compression to apply to the state stored in the secret: none, gzip or zstd.",
+			},
 			"in_cluster_config": {
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -193,6 +200,7 @@ type Backend struct {
 	namespace              string
 	labels                 map[string]string
 	nameSuffix             string
+	compression            remote.Compression
 }
 
 func (b Backend) getKubernetesSecretClient() (dynamic.ResourceInterface, error) {
@@ -275,6 +283,10 @@ func (b *Backend) configure(ctx context.Context) error {
 	ns := data.Get("namespace").(string)
 	b.namespace = ns
 	b.nameSuffix = data.Get("secret_suffix").(string)
+	b.compression, err = remote.ParseCompression(data.Get("compression").(string))
+	if err != nil {
+		return err
+	}
 	b.config = cfg
 
 	return nil
@@ -165,6 +165,7 @@ func (b *Backend) remoteClient(name string) (*RemoteClient, error) {
 		labels:                 b.labels,
 		nameSuffix:             b.nameSuffix,
 		workspace:              name,
+		compression:            b.compression,
 	}
 
 	return client, nil
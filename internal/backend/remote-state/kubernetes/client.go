@@ -6,10 +6,7 @@
 package kubernetes
 
 import (
-	"bytes"
-	"compress/gzip"
 	"context"
-	"crypto/md5"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -36,6 +33,16 @@ const (
 	tfstateWorkspaceKey       = "tfstateWorkspace"
 	tfstateLockInfoAnnotation = "app.terraform.io/lock-info"
 	managedByKey              = "app.kubernetes.io/managed-by"
+
+	// tfstateChunkLabelValue is used for the tfstateKey label on secrets
+	// holding chunks of a large state, so that they are not listed as
+	// workspaces.
+	tfstateChunkLabelValue = "chunk"
+
+	// maxSecretDataSize is the size at which state is split across several
+	// secrets. Kubernetes limits secrets to 1MiB, which must also
+	// accommodate the metadata.
+	maxSecretDataSize = 900 * 1024
 )
 
 type RemoteClient struct {
@@ -45,14 +52,69 @@ type RemoteClient struct {
 	labels                 map[string]string
 	nameSuffix             string
 	workspace              string
+	compression            remote.Compression
 }
 
 func (c *RemoteClient) Get(ctx context.Context) (payload *remote.Payload, err error) {
-	secretName, err := c.createSecretName()
+	return c.chunkedClient().Get(ctx)
+}
+
+func (c *RemoteClient) Put(ctx context.Context, data []byte) error {
+	return c.chunkedClient().Put(ctx, data)
+}
+
+// Delete the state secret
+func (c *RemoteClient) Delete(ctx context.Context) error {
+	err := c.chunkedClient().Delete(ctx)
+	if err != nil {
+		return err
+	}
+
+	leaseName, err := c.createLeaseName()
+	if err != nil {
+		return err
+	}
+
+	err = c.deleteLease(ctx, leaseName)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkedClient returns a client that stores the state in the workspace's
+// secret, splitting it across additional secrets if it is too large.
+func (c *RemoteClient) chunkedClient() *remote.ChunkedClient {
+	return &remote.ChunkedClient{
+		Store:         secretStore{c},
+		Compression:   c.compression,
+		MaxObjectSize: maxSecretDataSize,
+	}
+}
+
+// secretStore implements remote.ObjectStore using Kubernetes secrets. The
+// primary object is the workspace's secret and each chunk is stored in a
+// secret whose name has the chunk key appended.
+type secretStore struct {
+	c *RemoteClient
+}
+
+func (s secretStore) secretName(key string) (string, error) {
+	secretName, err := s.c.createSecretName()
+	if err != nil || key == "" {
+		return secretName, err
+	}
+	return secretName + "-" + key, nil
+}
+
+func (s secretStore) GetObject(ctx context.Context, key string) ([]byte, error) {
+	secretName, err := s.secretName(key)
 	if err != nil {
 		return nil, err
 	}
-	secret, err := c.kubernetesSecretClient.Get(ctx, secretName, metav1.GetOptions{})
+	secret, err := s.c.getSecret(ctx, secretName)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			return nil, nil
@@ -67,86 +129,57 @@ func (c *RemoteClient) Get(ctx context.Context) (payload *remote.Payload, err er
 		return nil, nil
 	}
 
-	stateRawString := stateRaw.(string)
-
-	state, err := uncompressState(stateRawString)
-	if err != nil {
-		return nil, err
-	}
-
-	md5 := md5.Sum(state)
-
-	p := &remote.Payload{
-		Data: state,
-		MD5:  md5[:],
-	}
-	return p, nil
+	return base64.StdEncoding.DecodeString(stateRaw.(string))
 }
 
-func (c *RemoteClient) Put(ctx context.Context, data []byte) error {
-	secretName, err := c.createSecretName()
+func (s secretStore) PutObject(ctx context.Context, key string, data []byte) error {
+	secretName, err := s.secretName(key)
 	if err != nil {
 		return err
 	}
 
-	payload, err := compressState(data)
-	if err != nil {
-		return err
-	}
-
-	secret, err := c.getSecret(ctx, secretName)
+	secret, err := s.c.getSecret(ctx, secretName)
 	if err != nil {
 		if !k8serrors.IsNotFound(err) {
 			return err
 		}
 
+		labels := s.c.getLabels()
+		if key != "" {
+			// Chunk secrets must not be mistaken for workspaces
+			labels[tfstateKey] = tfstateChunkLabelValue
+		}
 		secret = &unstructured.Unstructured{
 			Object: map[string]interface{}{
 				"metadata": metav1.ObjectMeta{
 					Name:        secretName,
-					Namespace:   c.namespace,
-					Labels:      c.getLabels(),
-					Annotations: map[string]string{"encoding": "gzip"},
+					Namespace:   s.c.namespace,
+					Labels:      labels,
+					Annotations: map[string]string{"encoding": string(s.c.compression)},
 				},
 			},
 		}
 
-		secret, err = c.kubernetesSecretClient.Create(ctx, secret, metav1.CreateOptions{})
+		secret, err = s.c.kubernetesSecretClient.Create(ctx, secret, metav1.CreateOptions{})
 		if err != nil {
 			return err
 		}
 	}
 
-	setState(secret, payload)
-	_, err = c.kubernetesSecretClient.Update(ctx, secret, metav1.UpdateOptions{})
+	setState(secret, data)
+	_, err = s.c.kubernetesSecretClient.Update(ctx, secret, metav1.UpdateOptions{})
 	return err
 }
 
-// Delete the state secret
-func (c *RemoteClient) Delete(ctx context.Context) error {
-	secretName, err := c.createSecretName()
+func (s secretStore) DeleteObject(ctx context.Context, key string) error {
+	secretName, err := s.secretName(key)
 	if err != nil {
 		return err
 	}
-
-	err = c.deleteSecret(ctx, secretName)
-	if err != nil {
-		if !k8serrors.IsNotFound(err) {
-			return err
-		}
-	}
-
-	leaseName, err := c.createLeaseName()
-	if err != nil {
+	err = s.c.deleteSecret(ctx, secretName)
+	if err != nil && !k8serrors.IsNotFound(err) {
 		return err
 	}
-
-	err = c.deleteLease(ctx, leaseName)
-	if err != nil {
-		if !k8serrors.IsNotFound(err) {
-			return err
-		}
-	}
 	return nil
 }
 
@@ -297,7 +330,7 @@ func (c *RemoteClient) deleteSecret(ctx context.Context, name string) error {
 
 	labels := secret.GetLabels()
 	v, ok := labels[tfstateKey]
-	if !ok || v != "true" {
+	if !ok || (v != "true" && v != tfstateChunkLabelValue) {
 		return fmt.Errorf("Secret does does not have %q label", tfstateKey)
 	}
 
@@ -346,39 +379,6 @@ func (c *RemoteClient) createLeaseName() (string, error) {
 	return "lock-" + n, nil
 }
 
-func compressState(data []byte) ([]byte, error) {
-	b := new(bytes.Buffer)
-	gz := gzip.NewWriter(b)
-	if _, err := gz.Write(data); err != nil {
-		return nil, err
-	}
-	if err := gz.Close(); err != nil {
-		return nil, err
-	}
-	return b.Bytes(), nil
-}
-
-func uncompressState(data string) ([]byte, error) {
-	decode, err := base64.StdEncoding.DecodeString(data)
-	if err != nil {
-		return nil, err
-	}
-
-	b := new(bytes.Buffer)
-	gz, err := gzip.NewReader(bytes.NewReader(decode))
-	if err != nil {
-		return nil, err
-	}
-	_, err = b.ReadFrom(gz)
-	if err != nil {
-		return nil, err
-	}
-	if err := gz.Close(); err != nil {
-		return nil, err
-	}
-	return b.Bytes(), nil
-}
-
 func getSecretData(secret *unstructured.Unstructured) map[string]interface{} {
 	if m, ok := secret.Object["data"].(map[string]interface{}); ok {
 		return m
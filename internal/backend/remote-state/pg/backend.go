@@ -80,6 +80,41 @@ func New(enc encryption.StateEncryption) backend.Backend {
 				Description: "If set to `true`, OpenTofu won't try to create the Postgres index",
 				DefaultFunc: defaultBoolFunc("PG_SKIP_INDEX_CREATION", false),
 			},
+
+			"keep_history": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "If set to `true`, OpenTofu will retain previous state snapshots in the history table",
+				DefaultFunc: defaultBoolFunc("PG_KEEP_HISTORY", false),
+			},
+
+			"history_table_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the automatically managed Postgres table to store previous state snapshots",
+				DefaultFunc: schema.EnvDefaultFunc("PG_HISTORY_TABLE_NAME", "states_history"),
+			},
+
+			"max_history": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "Maximum number of previous state snapshots to retain per workspace; zero means no limit",
+				Default:     0,
+			},
+
+			"compress": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "If set to `true`, OpenTofu will store state snapshots gzip-compressed",
+				DefaultFunc: defaultBoolFunc("PG_COMPRESS", false),
+			},
+
+			"lock_table_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of a Postgres table to record state locks in; if unset, session-level advisory locks are used",
+				DefaultFunc: schema.EnvDefaultFunc("PG_LOCK_TABLE_NAME", ""),
+			},
 		},
 	}
 
@@ -93,12 +128,17 @@ type Backend struct {
 	encryption encryption.StateEncryption
 
 	// The fields below are set from configure
-	db         *sql.DB
-	configData *schema.ResourceData
-	connStr    string
-	schemaName string
-	tableName  string
-	indexName  string
+	db               *sql.DB
+	configData       *schema.ResourceData
+	connStr          string
+	schemaName       string
+	tableName        string
+	indexName        string
+	historyTableName string
+	lockTableName    string
+	keepHistory      bool
+	maxHistory       int
+	compress         bool
 }
 
 func (b *Backend) configure(ctx context.Context) error {
@@ -110,10 +150,22 @@ func (b *Backend) configure(ctx context.Context) error {
 	b.schemaName = data.Get("schema_name").(string)
 	b.tableName = data.Get("table_name").(string)
 	b.indexName = data.Get("index_name").(string)
+	b.historyTableName = data.Get("history_table_name").(string)
+	b.lockTableName = data.Get("lock_table_name").(string)
+	b.keepHistory = data.Get("keep_history").(bool)
+	b.maxHistory = data.Get("max_history").(int)
+	b.compress = data.Get("compress").(bool)
 	skipSchemaCreation := data.Get("skip_schema_creation").(bool)
 	skipTableCreation := data.Get("skip_table_creation").(bool)
 	skipIndexCreation := data.Get("skip_index_creation").(bool)
 
+	if b.maxHistory < 0 {
+		return fmt.Errorf("max_history must not be negative")
+	}
+	if b.maxHistory > 0 && !b.keepHistory {
+		return fmt.Errorf("max_history can only be set when keep_history is enabled")
+	}
+
 	db, err := sql.Open("postgres", b.connStr)
 	if err != nil {
 		return err
@@ -157,6 +209,57 @@ func (b *Backend) configure(ctx context.Context) error {
 		if _, err = db.Exec(query); err != nil {
 			return err
 		}
+
+		// Older versions of the table have no column for compressed
+		// snapshots, so we add it separately rather than in CREATE TABLE.
+		// ALTER TABLE requires ownership of the table even when the column
+		// already exists, so we only run it when the column is needed and
+		// missing, so that roles sharing a table owned by another role can
+		// still use it.
+		if b.compress {
+			hasColumn, err := b.hasCompressedDataColumn(db)
+			if err != nil {
+				return err
+			}
+			if !hasColumn {
+				query = fmt.Sprintf(`ALTER TABLE %s.%s ADD COLUMN IF NOT EXISTS compressed_data bytea`,
+					pq.QuoteIdentifier(b.schemaName), pq.QuoteIdentifier(b.tableName))
+				if _, err = db.Exec(query); err != nil {
+					return err
+				}
+			}
+		}
+
+		if b.keepHistory {
+			query = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s (
+				id bigserial PRIMARY KEY,
+				name text NOT NULL,
+				data bytea NOT NULL,
+				compressed boolean NOT NULL DEFAULT false,
+				created_at timestamptz NOT NULL DEFAULT now()
+				)`, pq.QuoteIdentifier(b.schemaName), pq.QuoteIdentifier(b.historyTableName))
+			if _, err = db.Exec(query); err != nil {
+				return err
+			}
+		}
+
+		if b.lockTableName != "" {
+			query = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s (
+				name text PRIMARY KEY,
+				lock_id text NOT NULL,
+				info text NOT NULL,
+				created_at timestamptz NOT NULL DEFAULT now()
+				)`, pq.QuoteIdentifier(b.schemaName), pq.QuoteIdentifier(b.lockTableName))
+			if _, err = db.Exec(query); err != nil {
+				return err
+			}
+		}
+	}
+
+	if skipTableCreation {
+		if err := b.checkTables(db); err != nil {
+			return err
+		}
 	}
 
 	if !skipIndexCreation {
@@ -171,3 +274,52 @@ func (b *Backend) configure(ctx context.Context) error {
 
 	return nil
 }
+
+// hasCompressedDataColumn returns true if the state table has the column
+// that compressed snapshots are stored in.
+func (b *Backend) hasCompressedDataColumn(db *sql.DB) (bool, error) {
+	var count int
+	query := `select count(1) from information_schema.columns where table_schema = $1 and table_name = $2 and column_name = 'compressed_data'`
+	if err := db.QueryRow(query, b.schemaName, b.tableName).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// checkTables returns an error if any table or column that the configured
+// options require doesn't exist. When skip_table_creation is set we don't
+// create these ourselves, and without this check a missing one would only be
+// reported as a database error once the state is first read or written.
+func (b *Backend) checkTables(db *sql.DB) error {
+	if b.compress {
+		hasColumn, err := b.hasCompressedDataColumn(db)
+		if err != nil {
+			return err
+		}
+		if !hasColumn {
+			return fmt.Errorf("compress requires a compressed_data column of type bytea in table %s.%s, which must be added manually when skip_table_creation is set", pq.QuoteIdentifier(b.schemaName), pq.QuoteIdentifier(b.tableName))
+		}
+	}
+
+	type requiredTable struct {
+		option, tableName string
+	}
+	var required []requiredTable
+	if b.keepHistory {
+		required = append(required, requiredTable{"keep_history", b.historyTableName})
+	}
+	if b.lockTableName != "" {
+		required = append(required, requiredTable{"lock_table_name", b.lockTableName})
+	}
+	for _, r := range required {
+		var count int
+		query := `select count(1) from information_schema.tables where table_schema = $1 and table_name = $2`
+		if err := db.QueryRow(query, b.schemaName, r.tableName).Scan(&count); err != nil {
+			return err
+		}
+		if count < 1 {
+			return fmt.Errorf("%s requires table %s.%s, which must be created manually when skip_table_creation is set", r.option, pq.QuoteIdentifier(b.schemaName), pq.QuoteIdentifier(r.tableName))
+		}
+	}
+	return nil
+}
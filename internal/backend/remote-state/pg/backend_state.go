@@ -43,18 +43,34 @@ func (b *Backend) Workspaces(context.Context) ([]string, error) {
 	return result, nil
 }
 
-func (b *Backend) DeleteWorkspace(_ context.Context, name string, _ bool) error {
+func (b *Backend) DeleteWorkspace(ctx context.Context, name string, _ bool) error {
 	if name == backend.DefaultStateName || name == "" {
 		return fmt.Errorf("can't delete default state")
 	}
 
-	query := fmt.Sprintf(`DELETE FROM %s.%s WHERE name = $1`, pq.QuoteIdentifier(b.schemaName), pq.QuoteIdentifier(b.tableName))
-	_, err := b.db.Exec(query, name)
+	// The state is deleted together with its history and any lock left
+	// behind for it, so that a new workspace of the same name starts afresh.
+	tables := []string{b.tableName}
+	if b.keepHistory {
+		tables = append(tables, b.historyTableName)
+	}
+	if b.lockTableName != "" {
+		tables = append(tables, b.lockTableName)
+	}
+
+	tx, err := b.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after Commit is a no-op
 
-	return nil
+	for _, table := range tables {
+		query := fmt.Sprintf(`DELETE FROM %s.%s WHERE name = $1`, pq.QuoteIdentifier(b.schemaName), pq.QuoteIdentifier(table))
+		if _, err := tx.Exec(query, name); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 func (b *Backend) StateMgr(ctx context.Context, name string) (statemgr.Full, error) {
@@ -66,6 +82,12 @@ func (b *Backend) StateMgr(ctx context.Context, name string) (statemgr.Full, err
 			SchemaName: b.schemaName,
 			TableName:  b.tableName,
 			IndexName:  b.indexName,
+
+			HistoryTableName: b.historyTableName,
+			LockTableName:    b.lockTableName,
+			KeepHistory:      b.keepHistory,
+			MaxHistory:       b.maxHistory,
+			Compress:         b.compress,
 		},
 		b.encryption,
 	)
@@ -513,6 +513,161 @@ func TestBackendConfigSkipOptions(t *testing.T) {
 	}
 }
 
+func TestBackendConfigCompressedDataColumn(t *testing.T) {
+	testACC(t)
+	connStr := getDatabaseUrl()
+	schemaName := "compressed_data_column"
+
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dropSchema(t, db, schemaName)
+
+	// The table as created by earlier versions, without the column for
+	// compressed snapshots.
+	for _, query := range []string{
+		`CREATE SCHEMA %[1]s`,
+		`CREATE TABLE %[1]s.states (
+			id SERIAL PRIMARY KEY,
+			name text UNIQUE,
+			data TEXT
+			)`,
+	} {
+		if _, err := db.Exec(fmt.Sprintf(query, pq.QuoteIdentifier(schemaName))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The table must only be altered once compression is enabled, because
+	// that requires ownership of the table.
+	for _, compress := range []bool{false, true} {
+		config := backend.TestWrapConfig(map[string]interface{}{
+			"conn_str":    connStr,
+			"schema_name": schemaName,
+			"compress":    compress,
+		})
+		b := backend.TestBackendConfig(t, New(encryption.StateEncryptionDisabled()), config).(*Backend)
+
+		hasColumn, err := b.hasCompressedDataColumn(b.db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hasColumn != compress {
+			t.Fatalf("with compress = %t, table has compressed_data column: %t", compress, hasColumn)
+		}
+	}
+}
+
+func TestBackendConfigSkipTableCreationRequiredObjects(t *testing.T) {
+	testACC(t)
+	connStr := getDatabaseUrl()
+
+	testCases := []struct {
+		Name        string
+		Config      map[string]interface{}
+		Setup       string
+		ExpectError string
+	}{
+		{
+			Name:        "compress",
+			Config:      map[string]interface{}{"compress": true},
+			ExpectError: "compress requires a compressed_data column",
+		},
+		{
+			Name:   "compress_with_column",
+			Config: map[string]interface{}{"compress": true},
+			Setup:  `ALTER TABLE %[1]s.states ADD COLUMN compressed_data bytea`,
+		},
+		{
+			Name:        "keep_history",
+			Config:      map[string]interface{}{"keep_history": true},
+			ExpectError: `keep_history requires table "keep_history"."states_history"`,
+		},
+		{
+			Name:   "keep_history_with_table",
+			Config: map[string]interface{}{"keep_history": true},
+			Setup: `CREATE TABLE %[1]s.states_history (
+				id bigserial PRIMARY KEY,
+				name text NOT NULL,
+				data bytea NOT NULL,
+				compressed boolean NOT NULL DEFAULT false,
+				created_at timestamptz NOT NULL DEFAULT now()
+				)`,
+		},
+		{
+			Name:        "lock_table_name",
+			Config:      map[string]interface{}{"lock_table_name": "locks"},
+			ExpectError: `lock_table_name requires table "lock_table_name"."locks"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			schemaName := tc.Name
+
+			db, err := sql.Open("postgres", connStr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer dropSchema(t, db, schemaName)
+
+			// The table as created by earlier versions, without any of the
+			// objects that the options under test require.
+			for _, query := range []string{
+				`CREATE SCHEMA %[1]s`,
+				`CREATE TABLE %[1]s.states (
+					id SERIAL PRIMARY KEY,
+					name text UNIQUE,
+					data TEXT
+					)`,
+				tc.Setup,
+			} {
+				if query == "" {
+					continue
+				}
+				if _, err := db.Exec(fmt.Sprintf(query, pq.QuoteIdentifier(schemaName))); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rawConfig := map[string]interface{}{
+				"conn_str":            connStr,
+				"schema_name":         schemaName,
+				"skip_table_creation": true,
+			}
+			for k, v := range tc.Config {
+				rawConfig[k] = v
+			}
+			config := backend.TestWrapConfig(rawConfig)
+
+			b := New(encryption.StateEncryptionDisabled()).(*Backend)
+			obj, decDiags := hcldec.Decode(config, b.ConfigSchema().DecoderSpec(), nil)
+			if decDiags.HasErrors() {
+				t.Fatal(decDiags.Error())
+			}
+			obj, valDiags := b.PrepareConfig(obj)
+			if valDiags.HasErrors() {
+				t.Fatal(valDiags.Err())
+			}
+
+			err = b.Configure(t.Context(), obj).Err()
+			if tc.ExpectError == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("error expected but got none")
+			}
+			if !strings.Contains(err.Error(), tc.ExpectError) {
+				t.Fatalf("failed to find %q in %s", tc.ExpectError, err)
+			}
+		})
+	}
+}
+
 func TestBackendStates(t *testing.T) {
 	testACC(t)
 	connStr := getDatabaseUrl()
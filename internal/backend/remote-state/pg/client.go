@@ -9,8 +9,10 @@ import (
 	"context"
 	"crypto/md5"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"hash/fnv"
+	"strconv"
 
 	"github.com/lib/pq"
 
@@ -27,38 +29,184 @@ type RemoteClient struct {
 	TableName  string
 	IndexName  string
 
+	// HistoryTableName is the table that previous snapshots are copied
+	// into on each Put when KeepHistory is set. MaxHistory limits the
+	// number of retained snapshots per workspace, with zero meaning no limit.
+	HistoryTableName string
+	KeepHistory      bool
+	MaxHistory       int
+
+	// LockTableName, if set, selects row-based locking in the given table
+	// instead of session-level advisory locks, so that the lock holder's
+	// LockInfo can be reported and force-unlock works from other sessions.
+	LockTableName string
+
+	// Compress causes snapshots to be stored gzip-compressed in the
+	// compressed_data column rather than as text in the data column.
+	Compress bool
+
 	info *statemgr.LockInfo
 }
 
+var _ remote.ClientHistory = (*RemoteClient)(nil)
+
 func (c *RemoteClient) Get(_ context.Context) (*remote.Payload, error) {
-	query := fmt.Sprintf(`SELECT data FROM %s.%s WHERE name = $1`, pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.TableName))
+	if !c.Compress {
+		query := fmt.Sprintf(`SELECT data FROM %s.%s WHERE name = $1`, pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.TableName))
+		row := c.Client.QueryRow(query, c.Name)
+		var data []byte
+		err := row.Scan(&data)
+		switch {
+		case err == sql.ErrNoRows:
+			// No existing state returns empty.
+			return nil, nil
+		case err != nil:
+			return nil, err
+		case data == nil:
+			return nil, fmt.Errorf("state for workspace %q is stored compressed; set compress = true to read it", c.Name)
+		default:
+			return newPayload(data), nil
+		}
+	}
+
+	query := fmt.Sprintf(`SELECT data, compressed_data FROM %s.%s WHERE name = $1`, pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.TableName))
 	row := c.Client.QueryRow(query, c.Name)
-	var data []byte
-	err := row.Scan(&data)
+	var data, compressed []byte
+	err := row.Scan(&data, &compressed)
 	switch {
 	case err == sql.ErrNoRows:
 		// No existing state returns empty.
 		return nil, nil
 	case err != nil:
 		return nil, err
+	case compressed != nil:
+		data, err = remote.Decompress(compressed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress state for workspace %q: %w", c.Name, err)
+		}
+		return newPayload(data), nil
 	default:
-		md5 := md5.Sum(data)
-		return &remote.Payload{
-			Data: data,
-			MD5:  md5[:],
-		}, nil
+		// Snapshots written before compression was enabled remain
+		// readable until they are next overwritten.
+		return newPayload(data), nil
 	}
 }
 
-func (c *RemoteClient) Put(_ context.Context, data []byte) error {
-	query := fmt.Sprintf(`INSERT INTO %s.%s (name, data) VALUES ($1, $2)
-		ON CONFLICT (name) DO UPDATE
-		SET data = $2 WHERE %s.name = $1`, pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.TableName), pq.QuoteIdentifier(c.TableName))
-	_, err := c.Client.Exec(query, c.Name, data)
+func (c *RemoteClient) Put(ctx context.Context, data []byte) error {
+	stored := data
+	if c.Compress {
+		var err error
+		stored, err = remote.Compress(data, remote.CompressionGzip)
+		if err != nil {
+			return fmt.Errorf("failed to compress state: %w", err)
+		}
+	}
+
+	tx, err := c.Client.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	return nil
+	defer tx.Rollback() //nolint:errcheck // Rollback after Commit is a no-op
+
+	var query string
+	if c.Compress {
+		query = fmt.Sprintf(`INSERT INTO %s.%s (name, data, compressed_data) VALUES ($1, NULL, $2)
+			ON CONFLICT (name) DO UPDATE
+			SET data = NULL, compressed_data = $2 WHERE %s.name = $1`, pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.TableName), pq.QuoteIdentifier(c.TableName))
+		_, err = tx.Exec(query, c.Name, stored)
+	} else {
+		query = fmt.Sprintf(`INSERT INTO %s.%s (name, data) VALUES ($1, $2)
+			ON CONFLICT (name) DO UPDATE
+			SET data = $2 WHERE %s.name = $1`, pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.TableName), pq.QuoteIdentifier(c.TableName))
+		_, err = tx.Exec(query, c.Name, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.KeepHistory {
+		query = fmt.Sprintf(`INSERT INTO %s.%s (name, data, compressed) VALUES ($1, $2, $3)`,
+			pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.HistoryTableName))
+		if _, err := tx.Exec(query, c.Name, stored, c.Compress); err != nil {
+			return fmt.Errorf("failed to record state history: %w", err)
+		}
+
+		if c.MaxHistory > 0 {
+			query = fmt.Sprintf(`DELETE FROM %s.%s WHERE name = $1 AND id NOT IN (
+				SELECT id FROM %s.%s WHERE name = $1 ORDER BY id DESC LIMIT $2)`,
+				pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.HistoryTableName),
+				pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.HistoryTableName))
+			if _, err := tx.Exec(query, c.Name, c.MaxHistory); err != nil {
+				return fmt.Errorf("failed to prune state history: %w", err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Versions implements remote.ClientHistory.
+func (c *RemoteClient) Versions(_ context.Context) ([]statemgr.StateVersion, error) {
+	if !c.KeepHistory {
+		return nil, fmt.Errorf("state history is not enabled; set keep_history = true to retain previous snapshots")
+	}
+
+	query := fmt.Sprintf(`SELECT id, created_at FROM %s.%s WHERE name = $1 ORDER BY id DESC`,
+		pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.HistoryTableName))
+	rows, err := c.Client.Query(query, c.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []statemgr.StateVersion
+	for rows.Next() {
+		var id int64
+		var version statemgr.StateVersion
+		if err := rows.Scan(&id, &version.Created); err != nil {
+			return nil, err
+		}
+		version.ID = strconv.FormatInt(id, 10)
+		result = append(result, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetVersion implements remote.ClientHistory.
+func (c *RemoteClient) GetVersion(_ context.Context, id string) (*remote.Payload, error) {
+	if !c.KeepHistory {
+		return nil, fmt.Errorf("state history is not enabled; set keep_history = true to retain previous snapshots")
+	}
+
+	versionID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state version id %q", id)
+	}
+
+	query := fmt.Sprintf(`SELECT data, compressed FROM %s.%s WHERE name = $1 AND id = $2`,
+		pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.HistoryTableName))
+	row := c.Client.QueryRow(query, c.Name, versionID)
+	var data []byte
+	var compressed bool
+	err = row.Scan(&data, &compressed)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	if compressed {
+		data, err = remote.Decompress(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress state version %s: %w", id, err)
+		}
+	}
+	return newPayload(data), nil
 }
 
 func (c *RemoteClient) Delete(_ context.Context) error {
@@ -70,7 +218,7 @@ func (c *RemoteClient) Delete(_ context.Context) error {
 	return nil
 }
 
-func (c *RemoteClient) Lock(_ context.Context, info *statemgr.LockInfo) (string, error) {
+func (c *RemoteClient) Lock(ctx context.Context, info *statemgr.LockInfo) (string, error) {
 	var err error
 	var lockID string
 
@@ -82,6 +230,10 @@ func (c *RemoteClient) Lock(_ context.Context, info *statemgr.LockInfo) (string,
 		info.ID = lockID
 	}
 
+	if c.LockTableName != "" {
+		return c.lockRow(ctx, info)
+	}
+
 	// Local helper function so we can call it multiple places
 	//
 	lockUnlock := func(pgLockId string) error {
@@ -138,7 +290,11 @@ func (c *RemoteClient) Lock(_ context.Context, info *statemgr.LockInfo) (string,
 	return info.ID, nil
 }
 
-func (c *RemoteClient) Unlock(_ context.Context, id string) error {
+func (c *RemoteClient) Unlock(ctx context.Context, id string) error {
+	if c.LockTableName != "" {
+		return c.unlockRow(ctx, id)
+	}
+
 	if c.info != nil && c.info.Path != "" {
 		query := `SELECT pg_advisory_unlock($1)`
 		row := c.Client.QueryRow(query, c.info.Path)
@@ -157,3 +313,84 @@ func (c *RemoteClient) composeCreationLockID() string {
 	hash.Write([]byte(c.SchemaName + "\x00" + c.TableName))
 	return fmt.Sprintf("%d", int64(hash.Sum32())*-1)
 }
+
+// lockRow records the lock in the lock table, failing if a row for the
+// workspace already exists.
+func (c *RemoteClient) lockRow(ctx context.Context, info *statemgr.LockInfo) (string, error) {
+	info.Path = c.Name
+
+	query := fmt.Sprintf(`INSERT INTO %s.%s (name, lock_id, info) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO NOTHING`, pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.LockTableName))
+	res, err := c.Client.ExecContext(ctx, query, c.Name, info.ID, string(info.Marshal()))
+	if err != nil {
+		return "", &statemgr.LockError{Info: info, Err: err}
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return "", &statemgr.LockError{Info: info, Err: err}
+	}
+	if n == 0 {
+		lockErr := &statemgr.LockError{Err: fmt.Errorf("Workspace is already locked: %s", c.Name)}
+		existing, err := c.lockRowInfo(ctx)
+		if err != nil {
+			lockErr.Err = fmt.Errorf("%w; failed to read existing lock info: %w", lockErr.Err, err)
+		}
+		lockErr.Info = existing
+		return "", lockErr
+	}
+
+	c.info = info
+	return info.ID, nil
+}
+
+// unlockRow deletes the lock row for the workspace if it matches the given
+// lock id. This works from any session, which is what allows force-unlock.
+func (c *RemoteClient) unlockRow(ctx context.Context, id string) error {
+	existing, err := c.lockRowInfo(ctx)
+	if err != nil {
+		return &statemgr.LockError{Err: fmt.Errorf("failed to read lock info: %w", err)}
+	}
+	if existing == nil {
+		return &statemgr.LockError{Err: fmt.Errorf("Workspace is not locked: %s", c.Name)}
+	}
+	if existing.ID != id {
+		return &statemgr.LockError{Info: existing, Err: fmt.Errorf("lock id %q does not match existing lock", id)}
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %s.%s WHERE name = $1 AND lock_id = $2`,
+		pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.LockTableName))
+	if _, err := c.Client.ExecContext(ctx, query, c.Name, id); err != nil {
+		return &statemgr.LockError{Info: existing, Err: err}
+	}
+	c.info = nil
+	return nil
+}
+
+// lockRowInfo returns the LockInfo recorded for the workspace in the lock
+// table, or nil if the workspace is not locked.
+func (c *RemoteClient) lockRowInfo(ctx context.Context) (*statemgr.LockInfo, error) {
+	query := fmt.Sprintf(`SELECT info FROM %s.%s WHERE name = $1`,
+		pq.QuoteIdentifier(c.SchemaName), pq.QuoteIdentifier(c.LockTableName))
+	var raw string
+	err := c.Client.QueryRowContext(ctx, query, c.Name).Scan(&raw)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	info := &statemgr.LockInfo{}
+	if err := json.Unmarshal([]byte(raw), info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func newPayload(data []byte) *remote.Payload {
+	sum := md5.Sum(data)
+	return &remote.Payload{
+		Data: data,
+		MD5:  sum[:],
+	}
+}
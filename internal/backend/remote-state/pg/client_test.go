@@ -14,6 +14,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/lib/pq"
+
 	"github.com/opentofu/opentofu/internal/backend"
 	"github.com/opentofu/opentofu/internal/encryption"
 	"github.com/opentofu/opentofu/internal/states/remote"
@@ -23,6 +25,7 @@ import (
 func TestRemoteClient_impl(t *testing.T) {
 	var _ remote.Client = new(RemoteClient)
 	var _ remote.ClientLocker = new(RemoteClient)
+	var _ remote.ClientHistory = new(RemoteClient)
 }
 
 func TestRemoteClient(t *testing.T) {
@@ -295,3 +298,146 @@ func TestConcurrentCreationLocksInDifferentTables(t *testing.T) {
 		t.Fatalf("Unexpected error thrown on a second lock attempt: %v", err)
 	}
 }
+
+func TestRemoteClientHistoryAndCompression(t *testing.T) {
+	testACC(t)
+	connStr := getDatabaseUrl()
+	schemaName := fmt.Sprintf("terraform_%s", t.Name())
+	dbCleaner, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dropSchema(t, dbCleaner, schemaName)
+
+	config := backend.TestWrapConfig(map[string]interface{}{
+		"conn_str":     connStr,
+		"schema_name":  schemaName,
+		"keep_history": true,
+		"max_history":  2,
+		"compress":     true,
+	})
+	b := backend.TestBackendConfig(t, New(encryption.StateEncryptionDisabled()), config).(*Backend)
+
+	s, err := b.StateMgr(t.Context(), backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := s.(*remote.State).Client.(*RemoteClient)
+
+	remote.TestClient(t, client)
+
+	for _, data := range []string{"first", "second", "third"} {
+		if err := client.Put(t.Context(), []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions, err := client.Versions(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The initial empty state and the first put were pruned by max_history.
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 retained versions, got %d", len(versions))
+	}
+
+	payload, err := client.GetVersion(t.Context(), versions[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(payload.Data), "second"; got != want {
+		t.Fatalf("wrong data for version %s: got %q, want %q", versions[1].ID, got, want)
+	}
+}
+
+func TestRemoteLocks_lockTable(t *testing.T) {
+	testACC(t)
+	connStr := getDatabaseUrl()
+	schemaName := fmt.Sprintf("terraform_%s", t.Name())
+	dbCleaner, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dropSchema(t, dbCleaner, schemaName)
+
+	config := backend.TestWrapConfig(map[string]interface{}{
+		"conn_str":        connStr,
+		"schema_name":     schemaName,
+		"lock_table_name": "locks",
+	})
+
+	b1 := backend.TestBackendConfig(t, New(encryption.StateEncryptionDisabled()), config).(*Backend)
+	s1, err := b1.StateMgr(t.Context(), backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b2 := backend.TestBackendConfig(t, New(encryption.StateEncryptionDisabled()), config).(*Backend)
+	s2, err := b2.StateMgr(t.Context(), backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote.TestRemoteLocks(t, s1.(*remote.State).Client, s2.(*remote.State).Client)
+
+	// A lock held by one session must be visible, and force-unlockable,
+	// from another.
+	info := statemgr.NewLockInfo()
+	info.Operation = "test"
+	info.Who = "clientA"
+	id, err := s1.Lock(t.Context(), info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s2.Lock(t.Context(), statemgr.NewLockInfo())
+	lockErr, ok := err.(*statemgr.LockError)
+	if !ok {
+		t.Fatalf("expected a LockError, got %v", err)
+	}
+	if lockErr.Info == nil || lockErr.Info.ID != id || lockErr.Info.Who != "clientA" {
+		t.Fatalf("wrong lock info in error: %#v", lockErr.Info)
+	}
+
+	if err := s2.Unlock(t.Context(), id); err != nil {
+		t.Fatalf("failed to force-unlock from another session: %s", err)
+	}
+}
+
+func TestDeleteWorkspace_lockTable(t *testing.T) {
+	testACC(t)
+	connStr := getDatabaseUrl()
+	schemaName := fmt.Sprintf("terraform_%s", t.Name())
+	dbCleaner, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dropSchema(t, dbCleaner, schemaName)
+
+	config := backend.TestWrapConfig(map[string]interface{}{
+		"conn_str":        connStr,
+		"schema_name":     schemaName,
+		"lock_table_name": "locks",
+	})
+	b := backend.TestBackendConfig(t, New(encryption.StateEncryptionDisabled()), config).(*Backend)
+	s, err := b.StateMgr(t.Context(), "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Lock(t.Context(), statemgr.NewLockInfo()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.DeleteWorkspace(t.Context(), "foo", true); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	query := fmt.Sprintf(`SELECT count(*) FROM %s.locks WHERE name = 'foo'`, pq.QuoteIdentifier(schemaName))
+	if err := dbCleaner.QueryRow(query).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("deleted workspace left %d rows in the lock table", count)
+	}
+}
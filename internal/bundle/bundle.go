@@ -0,0 +1,80 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package bundle
+
+import (
+	"slices"
+)
+
+// FormatVersion is the version of the bundle format produced by [NewWriter].
+// [NewReader] rejects bundles using any other version.
+const FormatVersion = 1
+
+// manifestName is the name of the archive entry containing the manifest,
+// which must always be the first entry in the archive.
+const manifestName = "bundle.json"
+
+// Manifest describes the content of a bundle.
+type Manifest struct {
+	FormatVersion int `json:"format_version"`
+
+	// OpenTofuVersion is the version of OpenTofu that created the bundle,
+	// for information only.
+	OpenTofuVersion string `json:"opentofu_version"`
+
+	// Platforms are the target platforms, like "linux_amd64", that the
+	// bundle includes provider packages for.
+	Platforms []string `json:"platforms"`
+}
+
+// HasPlatform returns true if the bundle includes provider packages for the
+// given target platform.
+func (m *Manifest) HasPlatform(platform string) bool {
+	return slices.Contains(m.Platforms, platform)
+}
+
+// Part identifies one of the parts of a bundle, each of which is a directory
+// tree that is extracted into a different location.
+type Part string
+
+const (
+	// PartConfig is the root module directory of the configuration,
+	// including its dependency lock file.
+	PartConfig Part = "config"
+
+	// PartModules is the directory of installed module packages, including
+	// the manifest of installed modules.
+	PartModules Part = "modules"
+
+	// PartProviders is a filesystem mirror directory, in the packed layout,
+	// containing the provider packages.
+	PartProviders Part = "providers"
+)
+
+// Dirs are the local directories corresponding to each of the parts of a
+// bundle.
+type Dirs struct {
+	Config    string
+	Modules   string
+	Providers string
+
+	// DataDir is the data directory of the working directory that the
+	// bundle is extracted into. No file of the config part may be placed
+	// within it, because its content, such as provider development
+	// overrides, must never come from a bundle.
+	DataDir string
+}
+
+func (d Dirs) forPart(part Part) (string, bool) {
+	switch part {
+	case PartConfig:
+		return d.Config, true
+	case PartModules:
+		return d.Modules, true
+	case PartProviders:
+		return d.Providers, true
+	default:
+		return "", false
+	}
+}
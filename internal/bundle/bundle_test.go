@@ -0,0 +1,248 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRoundTrip(t *testing.T) {
+	srcDir := t.TempDir()
+	writeTestFile(t, filepath.Join(srcDir, "config", "main.tf"), "# main\n")
+	writeTestFile(t, filepath.Join(srcDir, "config", ".terraform.lock.hcl"), "# lock\n")
+	writeTestFile(t, filepath.Join(srcDir, "config", "terraform.tfstate"), "{}\n")
+	writeTestFile(t, filepath.Join(srcDir, "modules", "modules.json"), "{}\n")
+	writeTestFile(t, filepath.Join(srcDir, "modules", "foo", "main.tf"), "# foo\n")
+	writeTestFile(t, filepath.Join(srcDir, "providers", "example.com", "a", "b", "terraform-provider-b_1.0.0_linux_amd64.zip"), "zip\n")
+	if runtime.GOOS != "windows" {
+		if err := os.Symlink("main.tf", filepath.Join(srcDir, "modules", "foo", "link.tf")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, Manifest{
+		OpenTofuVersion: "1.2.3",
+		Platforms:       []string{"linux_amd64", "darwin_arm64"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []Part{PartConfig, PartModules, PartProviders} {
+		err := w.AddDir(part, filepath.Join(srcDir, string(part)), func(rel string, _ fs.DirEntry) bool {
+			return rel != "terraform.tfstate"
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	wantManifest := Manifest{
+		FormatVersion:   FormatVersion,
+		OpenTofuVersion: "1.2.3",
+		Platforms:       []string{"linux_amd64", "darwin_arm64"},
+	}
+	if diff := cmp.Diff(wantManifest, r.Manifest); diff != "" {
+		t.Errorf("wrong manifest\n%s", diff)
+	}
+	if !r.Manifest.HasPlatform("darwin_arm64") || r.Manifest.HasPlatform("windows_amd64") {
+		t.Errorf("wrong result from HasPlatform")
+	}
+
+	dstDir := t.TempDir()
+	dirs := Dirs{
+		Config:    filepath.Join(dstDir, "root"),
+		Modules:   filepath.Join(dstDir, "root", ".terraform", "modules"),
+		Providers: filepath.Join(dstDir, "providers"),
+	}
+	if err := r.Extract(dirs); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		filepath.Join(dirs.Config, "main.tf"):                                                                "# main\n",
+		filepath.Join(dirs.Config, ".terraform.lock.hcl"):                                                    "# lock\n",
+		filepath.Join(dirs.Modules, "modules.json"):                                                          "{}\n",
+		filepath.Join(dirs.Modules, "foo", "main.tf"):                                                        "# foo\n",
+		filepath.Join(dirs.Providers, "example.com", "a", "b", "terraform-provider-b_1.0.0_linux_amd64.zip"): "zip\n",
+	} {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("%s", err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("wrong content for %s\ngot:  %q\nwant: %q", path, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dirs.Config, "terraform.tfstate")); !os.IsNotExist(err) {
+		t.Errorf("excluded file was extracted")
+	}
+	if runtime.GOOS != "windows" {
+		target, err := os.Readlink(filepath.Join(dirs.Modules, "foo", "link.tf"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != "main.tf" {
+			t.Errorf("wrong symlink target %q", target)
+		}
+	}
+}
+
+func TestNewReader_notBundle(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	writeTarFile(t, tw, "main.tf", "# main\n")
+	tw.Close()
+	gz.Close()
+
+	_, err := NewReader(&buf)
+	if err == nil || !strings.Contains(err.Error(), "does not start with a bundle manifest") {
+		t.Fatalf("wrong error: %v", err)
+	}
+}
+
+func TestExtract_invalidPaths(t *testing.T) {
+	tests := map[string]func(tw *tar.Writer){
+		"parent directory": func(tw *tar.Writer) {
+			writeTarFile(t, tw, "config/../../evil", "evil\n")
+		},
+		"unknown part": func(tw *tar.Writer) {
+			writeTarFile(t, tw, "other/main.tf", "# main\n")
+		},
+		"symlink outside part": func(tw *tar.Writer) {
+			writeTarSymlink(t, tw, "config/link", "../../evil")
+		},
+		"write through symlink": func(tw *tar.Writer) {
+			writeTarSymlink(t, tw, "config/dir", "sub")
+			writeTarFile(t, tw, "config/dir/main.tf", "# main\n")
+		},
+	}
+	for name, addEntries := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, Manifest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			addEntries(w.tw)
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := NewReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			dir := t.TempDir()
+			err = r.Extract(Dirs{Config: filepath.Join(dir, "config")})
+			if err == nil || !strings.Contains(err.Error(), "invalid bundle") {
+				t.Fatalf("wrong error: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "evil")); !os.IsNotExist(err) {
+				t.Errorf("file was written outside of the part directory")
+			}
+		})
+	}
+}
+
+func TestExtract_dataDir(t *testing.T) {
+	tests := map[string]func(tw *tar.Writer){
+		"file": func(tw *tar.Writer) {
+			writeTarFile(t, tw, "config/.terraform/provider_dev_overrides", "build_command = \"evil\"\n")
+		},
+		"data directory itself": func(tw *tar.Writer) {
+			writeTarFile(t, tw, "config/.terraform", "")
+		},
+		"symlink": func(tw *tar.Writer) {
+			writeTarSymlink(t, tw, "config/.terraform", "elsewhere")
+		},
+	}
+	for name, addEntries := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, Manifest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			addEntries(w.tw)
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := NewReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			dir := t.TempDir()
+			dataDir := filepath.Join(dir, ".terraform")
+			err = r.Extract(Dirs{Config: dir, DataDir: dataDir})
+			if err == nil || !strings.Contains(err.Error(), "would be placed in the data directory") {
+				t.Fatalf("wrong error: %v", err)
+			}
+			if _, err := os.Lstat(dataDir); !os.IsNotExist(err) {
+				t.Errorf("file was written into the data directory")
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTarFile(t *testing.T, tw *tar.Writer, name, content string) {
+	t.Helper()
+	err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     int64(len(content)),
+		Mode:     0644,
+	})
+	if err == nil {
+		_, err = tw.Write([]byte(content))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTarSymlink(t *testing.T, tw *tar.Writer, name, target string) {
+	t.Helper()
+	err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeSymlink,
+		Name:     name,
+		Linkname: target,
+		Mode:     0777,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
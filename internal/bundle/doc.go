@@ -0,0 +1,13 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+// Package bundle implements the archive format used by "tofu bundle create"
+// and "tofu init -from-bundle", which packages a configuration together with
+// everything needed to initialize it without network access.
+//
+// A bundle is a gzip-compressed tar archive whose first entry is a JSON
+// manifest describing the bundle, followed by the files of each of the
+// parts described by [Part]. This package deals only with the archive
+// format; deciding which files belong in each part and verifying the
+// extracted files is the caller's responsibility.
+package bundle
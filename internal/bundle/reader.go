@@ -0,0 +1,191 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Reader reads a bundle archive.
+type Reader struct {
+	// Manifest is the manifest of the bundle, which is available as soon as
+	// the Reader is created so that the caller can check it before
+	// extracting any files.
+	Manifest Manifest
+
+	gz *gzip.Reader
+	tr *tar.Reader
+}
+
+// NewReader starts reading a bundle from r, returning an error if r does
+// not contain a bundle in a supported format.
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid bundle: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != manifestName {
+		return nil, fmt.Errorf("not a valid bundle: does not start with a bundle manifest")
+	}
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("not a valid bundle: invalid manifest: %w", err)
+	}
+	if manifest.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("unsupported bundle format version %d; this version of OpenTofu supports only version %d", manifest.FormatVersion, FormatVersion)
+	}
+	return &Reader{
+		Manifest: manifest,
+		gz:       gz,
+		tr:       tr,
+	}, nil
+}
+
+// Extract extracts each of the parts of the bundle into the corresponding
+// directory given in dirs, creating the directories if needed.
+//
+// Any existing files with the same names as files in the bundle are
+// replaced. Parts whose directory is set to an empty string are skipped.
+// Extract returns an error if the bundle contains any file whose path would
+// place it outside of the directory for its part, inside a directory that is
+// a symbolic link, or, for the config part, inside the data directory.
+func (r *Reader) Extract(dirs Dirs) error {
+	var dataDir string
+	if dirs.DataDir != "" {
+		var err error
+		dataDir, err = filepath.Abs(dirs.DataDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		hdr, err := r.tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read bundle: %w", err)
+		}
+
+		partName, rel, _ := strings.Cut(strings.TrimSuffix(hdr.Name, "/"), "/")
+		dir, ok := dirs.forPart(Part(partName))
+		if !ok {
+			return fmt.Errorf("invalid bundle: unexpected file %q", hdr.Name)
+		}
+		if dir == "" || rel == "" {
+			continue
+		}
+		rel = filepath.FromSlash(rel)
+		if !filepath.IsLocal(rel) {
+			return fmt.Errorf("invalid bundle: file %q has an invalid path", hdr.Name)
+		}
+		target := filepath.Join(dir, rel)
+		if Part(partName) == PartConfig && dataDir != "" {
+			abs, err := filepath.Abs(target)
+			if err != nil {
+				return err
+			}
+			if within(dataDir, abs) {
+				return fmt.Errorf("invalid bundle: file %q would be placed in the data directory", hdr.Name)
+			}
+		}
+		if err := checkNoSymlinkParents(dir, rel); err != nil {
+			return fmt.Errorf("invalid bundle: file %q: %w", hdr.Name, err)
+		}
+		if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 && hdr.Typeflag != tar.TypeSymlink {
+			return fmt.Errorf("invalid bundle: file %q would replace a symbolic link", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			//nolint: mnd // directory permissions
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(target, r.tr, hdr.FileInfo().Mode().Perm()); err != nil {
+				return fmt.Errorf("failed to extract %q from bundle: %w", hdr.Name, err)
+			}
+		case tar.TypeSymlink:
+			// Symbolic links may refer only to other files in the same part.
+			if filepath.IsAbs(hdr.Linkname) || !filepath.IsLocal(filepath.Join(filepath.Dir(rel), filepath.FromSlash(hdr.Linkname))) {
+				return fmt.Errorf("invalid bundle: symbolic link %q refers to a location outside of the bundle", hdr.Name)
+			}
+			//nolint: mnd // directory permissions
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			os.Remove(target) // okay if it fails because Symlink will fail too
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return fmt.Errorf("failed to extract %q from bundle: %w", hdr.Name, err)
+			}
+		default:
+			return fmt.Errorf("invalid bundle: file %q has unsupported type", hdr.Name)
+		}
+	}
+}
+
+// Close releases the resources used by the reader, but does not close the
+// underlying reader given to [NewReader].
+func (r *Reader) Close() error {
+	return r.gz.Close()
+}
+
+// within returns true if the absolute path is dir itself or is inside it.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// checkNoSymlinkParents returns an error if any of the parent directories of
+// the given path relative to dir are symbolic links, so that we never write
+// through a symbolic link that was extracted from earlier in the bundle.
+func checkNoSymlinkParents(dir, rel string) error {
+	parent := dir
+	parts := strings.Split(filepath.Dir(rel), string(filepath.Separator))
+	for _, name := range parts {
+		if name == "." {
+			continue
+		}
+		parent = filepath.Join(parent, name)
+		info, err := os.Lstat(parent)
+		if os.IsNotExist(err) {
+			return nil // the remaining directories will be created
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("parent directory is a symbolic link")
+		}
+	}
+	return nil
+}
+
+func extractFile(target string, src io.Reader, mode os.FileMode) error {
+	//nolint: mnd // directory permissions
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close() // Ignore error from Close since io.Copy already failed
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,137 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Writer writes a bundle archive.
+type Writer struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+// NewWriter starts writing a bundle with the given manifest to w.
+//
+// The caller must call [Writer.Close] after adding all of the parts of the
+// bundle, and must then also close w if needed.
+func NewWriter(w io.Writer, manifest Manifest) (*Writer, error) {
+	manifest.FormatVersion = FormatVersion
+	src, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err = tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     manifestName,
+		Size:     int64(len(src)),
+		Mode:     0644, //nolint: mnd // file permissions
+		ModTime:  time.Now(),
+	})
+	if err == nil {
+		_, err = tw.Write(src)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write bundle manifest: %w", err)
+	}
+	return &Writer{gz: gz, tw: tw}, nil
+}
+
+// AddDir adds the files in the given local directory to the bundle as the
+// given part.
+//
+// If include is not nil then it's called for each file and directory in
+// the tree, with its path relative to dir, and any for which it returns
+// false are skipped, along with all of their contents in the case of a
+// directory.
+func (w *Writer) AddDir(part Part, dir string, include func(rel string, entry fs.DirEntry) bool) error {
+	return filepath.WalkDir(dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if include != nil && !include(rel, entry) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return w.addFile(path.Join(string(part), filepath.ToSlash(rel)), p, entry)
+	})
+}
+
+func (w *Writer) addFile(name, p string, entry fs.DirEntry) error {
+	info, err := entry.Info()
+	if err != nil {
+		return err
+	}
+	var link string
+	switch {
+	case info.Mode().IsRegular(), info.IsDir():
+		// Nothing special to do
+	case info.Mode()&fs.ModeSymlink != 0:
+		link, err = os.Readlink(p)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("cannot add %s to bundle: unsupported file type %s", p, info.Mode().Type())
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return fmt.Errorf("cannot add %s to bundle: %w", p, err)
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	// The bundle is intended to be used on other systems, so we don't
+	// retain any information about the users on this system.
+	hdr.Uid, hdr.Gid = 0, 0
+	hdr.Uname, hdr.Gname = "", ""
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", p, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(w.tw, f); err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", p, err)
+	}
+	return nil
+}
+
+// Close finishes writing the bundle.
+func (w *Writer) Close() error {
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
@@ -6,10 +6,20 @@
 package arguments
 
 import (
+	"fmt"
+
 	"github.com/opentofu/opentofu/internal/configs"
 	"github.com/opentofu/opentofu/internal/tfdiags"
 )
 
+// CoverageFormat is the file format of a test coverage report.
+type CoverageFormat string
+
+const (
+	CoverageFormatLCOV CoverageFormat = "lcov"
+	CoverageFormatJSON CoverageFormat = "json"
+)
+
 // Test represents the command-line arguments for the test command.
 type Test struct {
 	// Filter contains a list of test files to execute. If empty, all test files
@@ -32,6 +42,37 @@ type Test struct {
 	// human-readable format or JSON for each run step depending on the
 	// ViewType.
 	Verbose bool
+
+	// Parallelism is the maximum number of test files to execute at the same
+	// time.
+	Parallelism int
+
+	// Coverage tells the test command to record which parts of the
+	// configuration under test are exercised by the tests, and to print a
+	// summary of them.
+	Coverage bool
+
+	// CoverageReport, if set, is the path of a file to write a detailed
+	// coverage report to, in CoverageFormat. Setting it implies Coverage.
+	CoverageReport string
+	CoverageFormat CoverageFormat
+
+	// UpdateSnapshots tells the test command to write the snapshot files for
+	// run blocks with snapshot blocks, instead of comparing against them.
+	UpdateSnapshots bool
+
+	// JUnitXMLFile, if set, is the path of a file to write a JUnit XML
+	// report of the test results to, in addition to the normal output.
+	JUnitXMLFile string
+
+	// PropertySeed, if set, replaces the seed used to generate values for
+	// every property block.
+	PropertySeed *int64
+
+	// Cleanup tells the test command to destroy the resources left behind by
+	// previous executions that did not complete, instead of executing the
+	// test files.
+	Cleanup bool
 }
 
 func ParseTest(args []string) (*Test, tfdiags.Diagnostics) {
@@ -47,6 +88,15 @@ func ParseTest(args []string) (*Test, tfdiags.Diagnostics) {
 	cmdFlags.StringVar(&test.TestDirectory, "test-directory", configs.DefaultTestDirectory, "test-directory")
 	cmdFlags.BoolVar(&jsonOutput, "json", false, "json")
 	cmdFlags.BoolVar(&test.Verbose, "verbose", false, "verbose")
+	cmdFlags.StringVar(&test.JUnitXMLFile, "junit-xml", "", "junit-xml")
+	cmdFlags.BoolVar(&test.UpdateSnapshots, "update-snapshots", false, "update-snapshots")
+	cmdFlags.BoolVar(&test.Cleanup, "cleanup", false, "cleanup")
+	var propertySeed int64
+	cmdFlags.Int64Var(&propertySeed, "property-seed", 0, "property-seed")
+	cmdFlags.IntVar(&test.Parallelism, "parallelism", 1, "parallelism")
+	cmdFlags.BoolVar(&test.Coverage, "coverage", false, "coverage")
+	cmdFlags.StringVar(&test.CoverageReport, "coverage-report", "", "coverage-report")
+	cmdFlags.StringVar((*string)(&test.CoverageFormat), "coverage-format", string(CoverageFormatLCOV), "coverage-format")
 
 	if err := cmdFlags.Parse(args); err != nil {
 		diags = diags.Append(tfdiags.Sourceless(
@@ -55,6 +105,29 @@ func ParseTest(args []string) (*Test, tfdiags.Diagnostics) {
 			err.Error()))
 	}
 
+	if FlagIsSet(cmdFlags, "property-seed") {
+		test.PropertySeed = &propertySeed
+	}
+
+	if test.Parallelism < 1 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid parallelism",
+			"The -parallelism option must be at least 1."))
+	}
+
+	switch test.CoverageFormat {
+	case CoverageFormatLCOV, CoverageFormatJSON:
+	default:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid coverage format",
+			fmt.Sprintf("The -coverage-format option must be either %q or %q.", CoverageFormatLCOV, CoverageFormatJSON)))
+	}
+	if test.CoverageReport != "" {
+		test.Coverage = true
+	}
+
 	switch {
 	case jsonOutput:
 		test.ViewType = ViewJSON
@@ -76,60 +76,180 @@ func TestParseTest(t *testing.T) {
 		"defaults": {
 			args: nil,
 			want: &Test{
-				Filter:        nil,
-				TestDirectory: "tests",
-				ViewType:      ViewHuman,
-				Vars:          &Vars{},
+				Filter:         nil,
+				TestDirectory:  "tests",
+				ViewType:       ViewHuman,
+				Parallelism:    1,
+				CoverageFormat: CoverageFormatLCOV,
+				Vars:           &Vars{},
 			},
 			wantDiags: nil,
 		},
 		"with-filters": {
 			args: []string{"-filter=one.tftest.hcl", "-filter=two.tftest.hcl"},
 			want: &Test{
-				Filter:        []string{"one.tftest.hcl", "two.tftest.hcl"},
-				TestDirectory: "tests",
-				ViewType:      ViewHuman,
-				Vars:          &Vars{},
+				Filter:         []string{"one.tftest.hcl", "two.tftest.hcl"},
+				TestDirectory:  "tests",
+				ViewType:       ViewHuman,
+				Parallelism:    1,
+				CoverageFormat: CoverageFormatLCOV,
+				Vars:           &Vars{},
 			},
 			wantDiags: nil,
 		},
 		"json": {
 			args: []string{"-json"},
 			want: &Test{
-				Filter:        nil,
-				TestDirectory: "tests",
-				ViewType:      ViewJSON,
-				Vars:          &Vars{},
+				Filter:         nil,
+				TestDirectory:  "tests",
+				ViewType:       ViewJSON,
+				Parallelism:    1,
+				CoverageFormat: CoverageFormatLCOV,
+				Vars:           &Vars{},
 			},
 			wantDiags: nil,
 		},
 		"test-directory": {
 			args: []string{"-test-directory=other"},
 			want: &Test{
-				Filter:        nil,
-				TestDirectory: "other",
-				ViewType:      ViewHuman,
-				Vars:          &Vars{},
+				Filter:         nil,
+				TestDirectory:  "other",
+				ViewType:       ViewHuman,
+				Parallelism:    1,
+				CoverageFormat: CoverageFormatLCOV,
+				Vars:           &Vars{},
 			},
 			wantDiags: nil,
 		},
 		"verbose": {
 			args: []string{"-verbose"},
 			want: &Test{
-				Filter:        nil,
-				TestDirectory: "tests",
-				ViewType:      ViewHuman,
-				Verbose:       true,
-				Vars:          &Vars{},
+				Filter:         nil,
+				TestDirectory:  "tests",
+				ViewType:       ViewHuman,
+				Verbose:        true,
+				Parallelism:    1,
+				CoverageFormat: CoverageFormatLCOV,
+				Vars:           &Vars{},
+			},
+		},
+		"junit-xml": {
+			args: []string{"-junit-xml=results.xml"},
+			want: &Test{
+				Filter:         nil,
+				TestDirectory:  "tests",
+				ViewType:       ViewHuman,
+				JUnitXMLFile:   "results.xml",
+				Parallelism:    1,
+				CoverageFormat: CoverageFormatLCOV,
+				Vars:           &Vars{},
+			},
+		},
+		"coverage report": {
+			args: []string{"-coverage-report=coverage.json", "-coverage-format=json"},
+			want: &Test{
+				Filter:         nil,
+				TestDirectory:  "tests",
+				ViewType:       ViewHuman,
+				Parallelism:    1,
+				Coverage:       true,
+				CoverageReport: "coverage.json",
+				CoverageFormat: CoverageFormatJSON,
+				Vars:           &Vars{},
+			},
+		},
+		"invalid coverage format": {
+			args: []string{"-coverage-format=xml"},
+			want: &Test{
+				Filter:         nil,
+				TestDirectory:  "tests",
+				ViewType:       ViewHuman,
+				Parallelism:    1,
+				CoverageFormat: "xml",
+				Vars:           &Vars{},
+			},
+			wantDiags: tfdiags.Diagnostics{
+				tfdiags.Sourceless(
+					tfdiags.Error,
+					"Invalid coverage format",
+					`The -coverage-format option must be either "lcov" or "json".`,
+				),
+			},
+		},
+		"parallelism": {
+			args: []string{"-parallelism=4"},
+			want: &Test{
+				Filter:         nil,
+				TestDirectory:  "tests",
+				ViewType:       ViewHuman,
+				Parallelism:    4,
+				CoverageFormat: CoverageFormatLCOV,
+				Vars:           &Vars{},
+			},
+		},
+		"invalid parallelism": {
+			args: []string{"-parallelism=0"},
+			want: &Test{
+				Filter:         nil,
+				TestDirectory:  "tests",
+				ViewType:       ViewHuman,
+				Parallelism:    0,
+				CoverageFormat: CoverageFormatLCOV,
+				Vars:           &Vars{},
+			},
+			wantDiags: tfdiags.Diagnostics{
+				tfdiags.Sourceless(
+					tfdiags.Error,
+					"Invalid parallelism",
+					"The -parallelism option must be at least 1.",
+				),
+			},
+		},
+		"update-snapshots": {
+			args: []string{"-update-snapshots"},
+			want: &Test{
+				Filter:          nil,
+				TestDirectory:   "tests",
+				ViewType:        ViewHuman,
+				Parallelism:     1,
+				CoverageFormat:  CoverageFormatLCOV,
+				UpdateSnapshots: true,
+				Vars:            &Vars{},
+			},
+		},
+		"cleanup": {
+			args: []string{"-cleanup"},
+			want: &Test{
+				Filter:         nil,
+				TestDirectory:  "tests",
+				ViewType:       ViewHuman,
+				Parallelism:    1,
+				CoverageFormat: CoverageFormatLCOV,
+				Cleanup:        true,
+				Vars:           &Vars{},
+			},
+		},
+		"property seed": {
+			args: []string{"-property-seed=42"},
+			want: &Test{
+				Filter:         nil,
+				TestDirectory:  "tests",
+				ViewType:       ViewHuman,
+				Parallelism:    1,
+				CoverageFormat: CoverageFormatLCOV,
+				PropertySeed:   func() *int64 { seed := int64(42); return &seed }(),
+				Vars:           &Vars{},
 			},
 		},
 		"unknown flag": {
 			args: []string{"-boop"},
 			want: &Test{
-				Filter:        nil,
-				TestDirectory: "tests",
-				ViewType:      ViewHuman,
-				Vars:          &Vars{},
+				Filter:         nil,
+				TestDirectory:  "tests",
+				ViewType:       ViewHuman,
+				Parallelism:    1,
+				CoverageFormat: CoverageFormatLCOV,
+				Vars:           &Vars{},
 			},
 			wantDiags: tfdiags.Diagnostics{
 				tfdiags.Sourceless(
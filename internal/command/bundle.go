@@ -0,0 +1,37 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// BundleCommand is a Command implementation that just shows help for
+// the subcommands nested below it.
+type BundleCommand struct {
+	Meta
+}
+
+func (c *BundleCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *BundleCommand) Help() string {
+	helpText := `
+Usage: tofu [global options] bundle <subcommand> [options] [args]
+
+  This command has subcommands for working with bundles, which package a
+  configuration along with all of its module and provider dependencies so
+  that it can be initialized without network access using
+  "tofu init -from-bundle".
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *BundleCommand) Synopsis() string {
+	return "Package a configuration for offline use"
+}
@@ -0,0 +1,383 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-getter"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/bundle"
+	"github.com/opentofu/opentofu/internal/depsfile"
+	"github.com/opentofu/opentofu/internal/getproviders"
+	"github.com/opentofu/opentofu/internal/httpclient"
+	"github.com/opentofu/opentofu/internal/modsdir"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+	tfversion "github.com/opentofu/opentofu/version"
+)
+
+// BundleCreateCommand is a Command implementation that implements the
+// "tofu bundle create" command, which packages the configuration in the
+// current working directory with all of its dependencies into a single
+// archive that "tofu init -from-bundle" can install from without network
+// access.
+type BundleCreateCommand struct {
+	Meta
+}
+
+func (c *BundleCreateCommand) Synopsis() string {
+	return "Package a configuration and its dependencies into a bundle"
+}
+
+func (c *BundleCreateCommand) Run(args []string) int {
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.defaultFlagSet("bundle create")
+	c.Meta.varFlagSet(cmdFlags)
+	var optPlatforms FlagStringSlice
+	cmdFlags.Var(&optPlatforms, "platform", "target platform")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return 1
+	}
+
+	var diags tfdiags.Diagnostics
+
+	args = cmdFlags.Args()
+	if len(args) != 1 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"No bundle file specified",
+			"The bundle create command requires the path of the bundle file to create as a command-line argument.",
+		))
+		c.showDiagnostics(diags)
+		return 1
+	}
+	outputPath := args[0]
+
+	var platforms []getproviders.Platform
+	if len(optPlatforms) == 0 {
+		platforms = []getproviders.Platform{getproviders.CurrentPlatform}
+	} else {
+		platforms = make([]getproviders.Platform, 0, len(optPlatforms))
+		for _, platformStr := range optPlatforms {
+			platform, err := getproviders.ParsePlatform(platformStr)
+			if err != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Invalid target platform",
+					fmt.Sprintf("The string %q given in the -platform option is not a valid target platform: %s.", platformStr, err),
+				))
+				continue
+			}
+			platforms = append(platforms, platform)
+		}
+	}
+
+	// Downloading can be cancelled by SIGINT and similar.
+	ctx, done := c.InterruptibleContext(c.CommandContext())
+	defer done()
+
+	// Loading the configuration also checks that all of the modules it
+	// calls are already installed.
+	config, confDiags := c.loadConfig(ctx, ".")
+	diags = diags.Append(confDiags)
+	if confDiags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+	reqs, _, hclDiags := config.ProviderRequirements()
+	diags = diags.Append(hclDiags)
+	locks, moreDiags := c.lockedDependencies()
+	diags = diags.Append(moreDiags)
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	// The bundle includes exactly the dependencies that are selected in the
+	// dependency lock file, so the lock file must be complete.
+	if errs := config.VerifyDependencySelections(locks); len(errs) > 0 {
+		var buf strings.Builder
+		for _, err := range errs {
+			fmt.Fprintf(&buf, "\n  - %s", err.Error())
+		}
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Inconsistent dependency lock file",
+			fmt.Sprintf("The following dependency selections recorded in the lock file are inconsistent with the current configuration:%s\n\nTo update the locked dependency selections, run \"tofu init\" before creating a bundle.", buf.String()),
+		))
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	// Installed module packages are included as they are, so they must all
+	// be inside the working directory where "tofu init -from-bundle" can
+	// recreate them.
+	modulesDir := c.modulesDir()
+	manifest, err := modsdir.ReadManifestSnapshotForDir(modulesDir)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read module manifest",
+			fmt.Sprintf("Could not read the manifest of installed modules: %s.", err),
+		))
+		c.showDiagnostics(diags)
+		return 1
+	}
+	for _, record := range manifest {
+		if !filepath.IsLocal(record.Dir) {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Module outside of working directory",
+				fmt.Sprintf("The module %q is installed in %s, which is outside of the current working directory, so it cannot be included in a bundle.", record.Key, record.Dir),
+			))
+		}
+	}
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	providersDir, err := os.MkdirTemp("", "tofu-bundle-providers")
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot create temporary directory",
+			fmt.Sprintf("Failed to create a temporary directory for the provider packages: %s.", err),
+		))
+		c.showDiagnostics(diags)
+		return 1
+	}
+	defer os.RemoveAll(providersDir)
+	diags = diags.Append(c.downloadProviders(ctx, reqs, locks, platforms, providersDir))
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("- Writing bundle %s...", outputPath))
+	platformNames := make([]string, len(platforms))
+	for i, platform := range platforms {
+		platformNames[i] = platform.String()
+	}
+	err = c.writeBundle(outputPath, bundle.Manifest{
+		OpenTofuVersion: tfversion.String(),
+		Platforms:       platformNames,
+	}, modulesDir, providersDir)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to write bundle",
+			fmt.Sprintf("Could not write the bundle to %s: %s.", outputPath, err),
+		))
+	}
+
+	c.showDiagnostics(diags)
+	if diags.HasErrors() {
+		return 1
+	}
+	c.Ui.Output(c.Colorize().Color(fmt.Sprintf(
+		"\n[reset][bold][green]Bundle created![reset] Run \"tofu init -from-bundle=%s\" in an empty directory to install it.",
+		outputPath,
+	)))
+	return 0
+}
+
+// downloadProviders downloads the packages for each of the given target
+// platforms for the versions of the required providers selected in the given
+// locks, placing them in the given directory using the packed filesystem
+// mirror layout.
+//
+// Each package must match one of the checksums in the lock file, because
+// "tofu init -from-bundle" will verify the packages in the same way.
+func (c *BundleCreateCommand) downloadProviders(ctx context.Context, reqs getproviders.Requirements, locks *depsfile.Locks, platforms []getproviders.Platform, outputDir string) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	// As with "tofu providers mirror", we always download packages from the
+	// origin registry because this command is intended to be run with
+	// network access in preparation for working without it.
+	source := getproviders.NewMemoizeSource(
+		getproviders.NewRegistrySource(ctx, c.Services, c.registryHTTPClient(ctx)),
+	)
+	httpGetter := getter.HttpGetter{
+		Client:                httpclient.New(ctx),
+		Netrc:                 true,
+		XTerraformGetDisabled: true,
+	}
+
+	providers := make([]addrs.Provider, 0, len(reqs))
+	for provider := range reqs {
+		providers = append(providers, provider)
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].LessThan(providers[j])
+	})
+	for _, provider := range providers {
+		if provider.IsBuiltIn() {
+			continue
+		}
+		lock := locks.Provider(provider)
+		if lock == nil {
+			// Should not get here because we already checked the lock file
+			// using VerifyDependencySelections.
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Provider not found in lockfile",
+				fmt.Sprintf("Failed to find %s in the lock file", provider.String()),
+			))
+			continue
+		}
+		version := lock.Version()
+		c.Ui.Output(fmt.Sprintf("- Bundling %s v%s...", provider.ForDisplay(), version))
+		for _, platform := range platforms {
+			c.Ui.Output(fmt.Sprintf("  - Downloading package for %s...", platform.String()))
+			archive, _, moreDiags := downloadPackedProviderPackage(ctx, source, &httpGetter, provider, version, platform, outputDir)
+			diags = diags.Append(moreDiags)
+			if moreDiags.HasErrors() {
+				continue
+			}
+			matches, err := getproviders.PackageMatchesAnyHash(archive, lock.PreferredHashes())
+			if err != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Invalid provider package",
+					fmt.Sprintf("Failed to verify the checksum of %s v%s for %s: %s.", provider.String(), version.String(), platform.String(), err),
+				))
+				continue
+			}
+			if !matches {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Provider package not in dependency lock file",
+					fmt.Sprintf(
+						"The package for %s v%s for %s doesn't match any of the checksums recorded in the dependency lock file, so it could not be installed from the bundle.\n\nTo record checksums for all of the bundled platforms, run:\n  tofu providers lock %s",
+						provider.String(), version.String(), platform.String(), platformFlags(platforms),
+					),
+				))
+			}
+		}
+	}
+	return diags
+}
+
+// writeBundle writes the bundle with the given manifest to the given path,
+// including the configuration in the current working directory, the
+// installed modules in modulesDir, and the provider packages in
+// providersDir.
+func (c *BundleCreateCommand) writeBundle(outputPath string, manifest bundle.Manifest, modulesDir, providersDir string) error {
+	// We write the bundle into a temporary file first, so that an existing
+	// bundle is not replaced with an incomplete one if we fail.
+	f, err := os.CreateTemp(filepath.Dir(outputPath), ".tofu-bundle-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath) // no-op if we successfully renamed it below
+
+	// The configuration part includes the files of the root module, but not
+	// the working directory's data directory or local state, or any other
+	// hidden files apart from the dependency lock file.
+	var excludePaths []string
+	for _, p := range []string{c.DataDir(), outputPath, tmpPath} {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		excludePaths = append(excludePaths, abs)
+	}
+	includeConfig := func(rel string, entry fs.DirEntry) bool {
+		if abs, err := filepath.Abs(rel); err == nil && slices.Contains(excludePaths, abs) {
+			return false
+		}
+		if strings.HasPrefix(entry.Name(), ".") && rel != depsfile.LockFilePath {
+			return false
+		}
+		switch rel {
+		case "terraform.tfstate", "terraform.tfstate.backup", "terraform.tfstate.d":
+			return false
+		}
+		return true
+	}
+
+	w, err := bundle.NewWriter(f, manifest)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = w.AddDir(bundle.PartConfig, ".", includeConfig)
+	if err == nil {
+		if _, statErr := os.Stat(modulesDir); statErr == nil {
+			err = w.AddDir(bundle.PartModules, modulesDir, nil)
+		}
+	}
+	if err == nil {
+		err = w.AddDir(bundle.PartProviders, providersDir, nil)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, outputPath)
+}
+
+// platformFlags returns -platform command line options for each of the
+// given platforms.
+func platformFlags(platforms []getproviders.Platform) string {
+	flags := make([]string, len(platforms))
+	for i, platform := range platforms {
+		flags[i] = "-platform=" + platform.String()
+	}
+	return strings.Join(flags, " ")
+}
+
+func (c *BundleCreateCommand) Help() string {
+	return `
+Usage: tofu [global options] bundle create [options] <bundle-file>
+
+  Packages the configuration in the current working directory together
+  with its dependency lock file, all of its installed module packages, and
+  the provider packages selected in the dependency lock file into a single
+  archive file.
+
+  The working directory must already be initialized with "tofu init".
+  Provider packages are downloaded from their origin registries for each of
+  the target platforms, and must match the checksums recorded in the
+  dependency lock file.
+
+  Use "tofu init -from-bundle=<bundle-file>" in an empty directory to
+  install the bundle without network access.
+
+Options:
+
+  -platform=os_arch  Choose which target platform to include provider
+                     packages for. By default OpenTofu will include packages
+                     suitable for the platform where you run this command.
+                     Use this flag multiple times to include packages for
+                     multiple target systems.
+
+  -var 'foo=bar'     Set a value for one of the input variables in the root
+                     module of the configuration. Use this option more than
+                     once to set more than one variable.
+
+  -var-file=filename Load variable values from the given file, in addition
+                     to the default files terraform.tfvars and *.auto.tfvars.
+                     Use this option more than once to include more than one
+                     variables file.
+`
+}
@@ -0,0 +1,183 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/opentofu/svchost"
+	"github.com/opentofu/svchost/disco"
+
+	"github.com/opentofu/opentofu/internal/getproviders"
+)
+
+func TestBundleCreate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(fakeMirrorRegistryHandler))
+	defer server.Close()
+	services := disco.New()
+	services.ForceHostServices(svchost.Hostname("registry.opentofu.org"), map[string]interface{}{
+		"providers.v1": server.URL + "/providers/v1/",
+	})
+
+	// We bundle the packages for the current platform and one other, so
+	// that we can also initialize from the bundle on this platform.
+	otherPlatform := getproviders.Platform{OS: "linux", Arch: "amd64"}
+	if getproviders.CurrentPlatform == otherPlatform {
+		otherPlatform = getproviders.Platform{OS: "darwin", Arch: "arm64"}
+	}
+	platforms := []getproviders.Platform{getproviders.CurrentPlatform, otherPlatform}
+	var lockHashes []string
+	for _, platform := range platforms {
+		zipSrc := fakeMirrorProviderPackage("happycloud", platform.String())
+		lockHashes = append(lockHashes, fmt.Sprintf("%q", fmt.Sprintf("zh:%x", sha256.Sum256(zipSrc))))
+	}
+
+	srcDir := t.TempDir()
+	t.Chdir(srcDir)
+	for name, content := range map[string]string{
+		"main.tf": `
+terraform {
+  required_providers {
+    happycloud = {
+      source = "awesomecorp/happycloud"
+    }
+  }
+}
+
+module "child" {
+  source = "./child"
+}
+`,
+		"child/main.tf":     "# child module\n",
+		"terraform.tfstate": "{}\n",
+		".terraform.lock.hcl": fmt.Sprintf(`
+provider "registry.opentofu.org/awesomecorp/happycloud" {
+  version = "1.2.0"
+  hashes = [%s]
+}
+`, strings.Join(lockHashes, ", ")),
+		".terraform/modules/modules.json": `{"Modules":[{"Key":"","Source":"","Dir":"."},{"Key":"child","Source":"./child","Dir":"child"}]}`,
+	} {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bundlePath := filepath.Join(t.TempDir(), "bundle.tar.gz")
+
+	t.Run("missing checksum", func(t *testing.T) {
+		ui := new(cli.MockUi)
+		c := &BundleCreateCommand{
+			Meta: Meta{
+				Ui:       ui,
+				Services: services,
+			},
+		}
+		code := c.Run([]string{"-platform=windows_386", bundlePath})
+		if code != 1 {
+			t.Fatalf("wrong exit code. expected 1, got %d", code)
+		}
+		if got, want := ui.ErrorWriter.String(), "Provider package not in dependency lock file"; !strings.Contains(got, want) {
+			t.Fatalf("missing error from output\ngot:\n%s\nwant substring: %s", got, want)
+		}
+		if _, err := os.Stat(bundlePath); !os.IsNotExist(err) {
+			t.Fatalf("bundle was created despite the error")
+		}
+	})
+
+	t.Run("success", func(t *testing.T) {
+		ui := new(cli.MockUi)
+		c := &BundleCreateCommand{
+			Meta: Meta{
+				Ui:       ui,
+				Services: services,
+			},
+		}
+		args := []string{bundlePath}
+		for _, platform := range platforms {
+			args = append([]string{"-platform=" + platform.String()}, args...)
+		}
+		if code := c.Run(args); code != 0 {
+			t.Fatalf("wrong exit code. expected 0, got %d\n%s", code, ui.ErrorWriter.String())
+		}
+	})
+
+	t.Run("init from bundle", func(t *testing.T) {
+		dstDir := t.TempDir()
+		t.Chdir(dstDir)
+
+		// The bundle must provide everything, so the normal provider source
+		// should never be used.
+		providerSource, closeSource := newMockProviderSource(t, nil)
+		defer closeSource()
+		ui := new(cli.MockUi)
+		view, _ := testView(t)
+		c := &InitCommand{
+			Meta: Meta{
+				Ui:             ui,
+				View:           view,
+				ProviderSource: providerSource,
+			},
+		}
+		if code := c.Run([]string{"-from-bundle=" + bundlePath}); code != 0 {
+			t.Fatalf("wrong exit code. expected 0, got %d\n%s", code, ui.ErrorWriter.String())
+		}
+
+		for _, name := range []string{"main.tf", ".terraform.lock.hcl", filepath.Join("child", "main.tf")} {
+			if _, err := os.Stat(name); err != nil {
+				t.Errorf("file missing after init: %s", err)
+			}
+		}
+		if _, err := os.Stat("terraform.tfstate"); !os.IsNotExist(err) {
+			t.Errorf("local state was included in the bundle")
+		}
+		exePath := filepath.Join(".terraform", "providers", "registry.opentofu.org", "awesomecorp", "happycloud", "1.2.0", getproviders.CurrentPlatform.String(), "terraform-provider-happycloud")
+		exeContent, err := os.ReadFile(exePath)
+		if err != nil {
+			t.Fatalf("provider not installed: %s", err)
+		}
+		if got, want := string(exeContent), "happycloud for "+getproviders.CurrentPlatform.String(); got != want {
+			t.Errorf("wrong executable content\ngot:  %q\nwant: %q", got, want)
+		}
+
+		// Extracting the bundle again requires an empty directory.
+		ui = new(cli.MockUi)
+		c = &InitCommand{
+			Meta: Meta{
+				Ui:             ui,
+				View:           view,
+				ProviderSource: providerSource,
+			},
+		}
+		if code := c.Run([]string{"-from-bundle=" + bundlePath}); code != 1 {
+			t.Fatalf("wrong exit code. expected 1, got %d", code)
+		}
+		if got, want := ui.ErrorWriter.String(), "The -from-bundle option requires\nan empty directory"; !strings.Contains(got, want) {
+			t.Fatalf("missing error from output\ngot:\n%s\nwant substring: %s", got, want)
+		}
+	})
+}
+
+func TestBundleCreate_missingArg(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &BundleCreateCommand{
+		Meta: Meta{Ui: ui},
+	}
+	if code := c.Run(nil); code != 1 {
+		t.Fatalf("wrong exit code. expected 1, got %d", code)
+	}
+	if got, want := ui.ErrorWriter.String(), "No bundle file specified"; !strings.Contains(got, want) {
+		t.Fatalf("missing error from output\ngot:\n%s\nwant substring: %s", got, want)
+	}
+}
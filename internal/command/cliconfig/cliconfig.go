@@ -31,6 +31,7 @@ import (
 
 const pluginCacheDirEnvVar = "TF_PLUGIN_CACHE_DIR"
 const pluginCacheMayBreakLockFileEnvVar = "TF_PLUGIN_CACHE_MAY_BREAK_DEPENDENCY_LOCK_FILE"
+const moduleCacheDirEnvVar = "TF_MODULE_CACHE_DIR"
 
 // Config is the structure of the configuration for the OpenTofu CLI.
 //
@@ -55,6 +56,11 @@ type Config struct {
 	// over the requirements of the dependency lock file.
 	PluginCacheMayBreakDependencyLockFile bool `hcl:"plugin_cache_may_break_dependency_lock_file"`
 
+	// If set, enables caching of remote module packages in this directory,
+	// shared between all working directories, to avoid repeatedly fetching
+	// the same packages.
+	ModuleCacheDir string `hcl:"module_cache_dir"`
+
 	Hosts map[string]*ConfigHost `hcl:"host"`
 
 	Credentials        map[string]map[string]interface{}   `hcl:"credentials"`
@@ -66,6 +72,16 @@ type Config struct {
 	// that validation at validation time rather than initial decode time.
 	ProviderInstallation []*ProviderInstallation
 
+	// ModuleInstallation represents any module_installation blocks in the
+	// configuration. As with ProviderInstallation, only one is allowed
+	// across the whole configuration but that is checked during validation.
+	ModuleInstallation []*ModuleInstallation
+
+	// ProviderPolicy represents any provider_policy blocks in the
+	// configuration. As with ProviderInstallation, only one is allowed
+	// across the whole configuration but that is checked during validation.
+	ProviderPolicy []*ProviderPolicy
+
 	// OCIDefaultCredentials and OCIRepositoryCredentials together represent
 	// the individual OCI-credentials-related blocks in the configuration.
 	//
@@ -191,9 +207,15 @@ func loadConfigFile(path string) (*Config, tfdiags.Diagnostics) {
 	// using a structure that is not compatible with HCL 1's DecodeObject,
 	// or HCL 1 would be too liberal in parsing and thus make it harder
 	// for us to potentially transition to using HCL 2 later.
-	providerInstBlocks, providerInstDiags := decodeProviderInstallationFromConfig(obj)
+	providerInstBlocks, providerInstDiags := decodeProviderInstallationFromConfig(obj, path)
 	diags = diags.Append(providerInstDiags)
 	result.ProviderInstallation = providerInstBlocks
+	moduleInstBlocks, moduleInstDiags := decodeModuleInstallationFromConfig(obj, path)
+	diags = diags.Append(moduleInstDiags)
+	result.ModuleInstallation = moduleInstBlocks
+	providerPolicyBlocks, providerPolicyDiags := decodeProviderPolicyFromConfig(obj)
+	diags = diags.Append(providerPolicyDiags)
+	result.ProviderPolicy = providerPolicyBlocks
 	ociDefaultCredsBlocks, ociDefaultCredsDiags := decodeOCIDefaultCredentialsFromConfig(obj, path)
 	diags = diags.Append(ociDefaultCredsDiags)
 	result.OCIDefaultCredentials = ociDefaultCredsBlocks
@@ -212,6 +234,9 @@ func loadConfigFile(path string) (*Config, tfdiags.Diagnostics) {
 	if result.PluginCacheDir != "" {
 		result.PluginCacheDir = os.ExpandEnv(result.PluginCacheDir)
 	}
+	if result.ModuleCacheDir != "" {
+		result.ModuleCacheDir = os.ExpandEnv(result.ModuleCacheDir)
+	}
 
 	return result, diags
 }
@@ -274,6 +299,10 @@ func envConfig(env map[string]string) *Config {
 		config.PluginCacheMayBreakDependencyLockFile = true
 	}
 
+	if envModuleCacheDir := env[moduleCacheDirEnvVar]; envModuleCacheDir != "" {
+		config.ModuleCacheDir = envModuleCacheDir
+	}
+
 	return config
 }
 
@@ -344,6 +373,22 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		)
 	}
 
+	// Should have zero or one "module_installation" blocks
+	if len(c.ModuleInstallation) > 1 {
+		diags = diags.Append(
+			//nolint:stylecheck // Despite typical Go idiom, our existing precedent here is to return full sentences suitable for inclusion in diagnostics.
+			fmt.Errorf("No more than one module_installation block may be specified"),
+		)
+	}
+
+	// Should have zero or one "provider_policy" blocks
+	if len(c.ProviderPolicy) > 1 {
+		diags = diags.Append(
+			//nolint:stylecheck // Despite typical Go idiom, our existing precedent here is to return full sentences suitable for inclusion in diagnostics.
+			fmt.Errorf("No more than one provider_policy block may be specified"),
+		)
+	}
+
 	// Should have zero or one "oci_default_credentials" blocks
 	if len(c.OCIDefaultCredentials) > 1 {
 		diags = diags.Append(
@@ -374,6 +419,15 @@ func (c *Config) Validate() tfdiags.Diagnostics {
 		}
 	}
 
+	if c.ModuleCacheDir != "" {
+		_, err := os.Stat(c.ModuleCacheDir)
+		if err != nil {
+			diags = diags.Append(
+				fmt.Errorf("The specified module cache dir %s cannot be opened: %w", c.ModuleCacheDir, err),
+			)
+		}
+	}
+
 	return diags
 }
 
@@ -407,6 +461,11 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.PluginCacheDir = c2.PluginCacheDir
 	}
 
+	result.ModuleCacheDir = c.ModuleCacheDir
+	if result.ModuleCacheDir == "" {
+		result.ModuleCacheDir = c2.ModuleCacheDir
+	}
+
 	if c.PluginCacheMayBreakDependencyLockFile || c2.PluginCacheMayBreakDependencyLockFile {
 		// This setting saturates to "on"; once either configuration sets it,
 		// there is no way to override it back to off again.
@@ -451,6 +510,16 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.ProviderInstallation = append(result.ProviderInstallation, c2.ProviderInstallation...)
 	}
 
+	if (len(c.ModuleInstallation) + len(c2.ModuleInstallation)) > 0 {
+		result.ModuleInstallation = append(result.ModuleInstallation, c.ModuleInstallation...)
+		result.ModuleInstallation = append(result.ModuleInstallation, c2.ModuleInstallation...)
+	}
+
+	if (len(c.ProviderPolicy) + len(c2.ProviderPolicy)) > 0 {
+		result.ProviderPolicy = append(result.ProviderPolicy, c.ProviderPolicy...)
+		result.ProviderPolicy = append(result.ProviderPolicy, c2.ProviderPolicy...)
+	}
+
 	if (len(c.OCIDefaultCredentials) + len(c2.OCIDefaultCredentials)) > 0 {
 		result.OCIDefaultCredentials = append(result.OCIDefaultCredentials, c.OCIDefaultCredentials...)
 		result.OCIDefaultCredentials = append(result.OCIDefaultCredentials, c2.OCIDefaultCredentials...)
@@ -117,6 +117,14 @@ func TestEnvConfig(t *testing.T) {
 			},
 			&Config{},
 		},
+		"TF_MODULE_CACHE_DIR=boop": {
+			map[string]string{
+				"TF_MODULE_CACHE_DIR": "boop",
+			},
+			&Config{
+				ModuleCacheDir: "boop",
+			},
+		},
 		"TF_PLUGIN_CACHE_DIR and TF_PLUGIN_CACHE_MAY_BREAK_DEPENDENCY_LOCK_FILE": {
 			map[string]string{
 				"TF_PLUGIN_CACHE_DIR":                            "beep",
@@ -0,0 +1,261 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package cliconfig
+
+import (
+	"fmt"
+	"strings"
+
+	hclast "github.com/hashicorp/hcl/hcl/ast"
+
+	"github.com/opentofu/opentofu/internal/command/cliconfig/ociauthconfig"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// ModuleInstallation is the structure of the "module_installation"
+// nested block within the CLI configuration.
+type ModuleInstallation struct {
+	// OCISignatureVerification maps OCI repository address prefixes to the
+	// signatures required for module packages retrieved from repositories
+	// matching that prefix.
+	OCISignatureVerification map[string]*OCISignatureVerification
+
+	// SourceCredentials maps hostnames and hostname patterns to the
+	// credentials to use when fetching module packages from matching hosts
+	// using git or HTTP.
+	SourceCredentials map[string]*ModuleSourceCredentials
+}
+
+// OCISignatureVerificationFor returns the signature verification settings
+// for module packages from the given OCI repository, or nil if signatures
+// are not required for that repository.
+//
+// If more than one repository address prefix matches then the longest one
+// takes precedence.
+func (mi *ModuleInstallation) OCISignatureVerificationFor(registryDomain, repositoryPath string) *OCISignatureVerification {
+	if mi == nil {
+		return nil
+	}
+	var ret *OCISignatureVerification
+	longest := -1
+	for prefix, verification := range mi.OCISignatureVerification {
+		// The prefixes were already validated during decoding.
+		prefixDomain, prefixPath, err := ociauthconfig.ParseRepositoryAddressPrefix(prefix)
+		if err != nil || prefixDomain != registryDomain {
+			continue
+		}
+		if prefixPath != "" && repositoryPath != prefixPath && !strings.HasPrefix(repositoryPath, prefixPath+"/") {
+			continue
+		}
+		if len(prefixPath) > longest {
+			ret = verification
+			longest = len(prefixPath)
+		}
+	}
+	return ret
+}
+
+// decodeModuleInstallationFromConfig uses the HCL AST API directly to
+// decode "module_installation" blocks from the given file.
+//
+// This follows the same approach as decodeProviderInstallationFromConfig,
+// so that a later migration to HCL 2 can support the same structure.
+//
+// Note that this function wants the top-level file object which might or
+// might not contain module_installation blocks, not a module_installation
+// block directly itself.
+func decodeModuleInstallationFromConfig(hclFile *hclast.File, filename string) ([]*ModuleInstallation, tfdiags.Diagnostics) {
+	const errInvalidSummary = "Invalid module_installation block"
+	var ret []*ModuleInstallation
+	var diags tfdiags.Diagnostics
+
+	root, ok := hclFile.Node.(*hclast.ObjectList)
+	if !ok {
+		// A HCL file that doesn't have an object list at its root is weird, but
+		// dealing with that is outside the scope of this function.
+		return ret, diags
+	}
+	// The HCL 1 JSON parser flattens an object whose properties are all
+	// objects into items with multiple keys, so a JSON module_installation
+	// object arrives as one top-level item per nested block. We collect
+	// all of those into a single result.
+	var fromJSON *ModuleInstallation
+
+	for _, block := range root.Items {
+		if block.Keys[0].Token.Value() != "module_installation" {
+			continue
+		}
+		if block.Keys[0].Token.JSON && len(block.Keys) > 1 {
+			if fromJSON == nil {
+				fromJSON = &ModuleInstallation{}
+				ret = append(ret, fromJSON)
+			}
+			nested := &hclast.ObjectItem{
+				Keys: block.Keys[1:],
+				Val:  block.Val,
+			}
+			diags = diags.Append(decodeModuleInstallationNestedBlock(fromJSON, nested, filename))
+			continue
+		}
+
+		// HCL only tracks whether the input was JSON or native syntax inside
+		// individual tokens, so we'll use our block type token to decide
+		// and assume that the rest of the block must be written in the same
+		// syntax, because syntax is a whole-file idea.
+		isJSON := block.Keys[0].Token.JSON
+		if block.Assign.Line != 0 && !isJSON {
+			// Seems to be an attribute rather than a block
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("The module_installation block at %s must not be introduced with an equals sign.", block.Pos()),
+			))
+			continue
+		}
+		if len(block.Keys) > 1 && !isJSON {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("The module_installation block at %s must not have any labels.", block.Pos()),
+			))
+			continue
+		}
+		body, ok := block.Val.(*hclast.ObjectType)
+		if !ok {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("The module_installation block at %s must be represented by a JSON object.", block.Pos()),
+			))
+			continue
+		}
+
+		mi := &ModuleInstallation{}
+		for _, nested := range body.List.Items {
+			diags = diags.Append(decodeModuleInstallationNestedBlock(mi, nested, filename))
+		}
+		ret = append(ret, mi)
+	}
+
+	return ret, diags
+}
+
+// decodeModuleInstallationNestedBlock decodes one of the blocks nested inside
+// a module_installation block, adding its content to the given object.
+func decodeModuleInstallationNestedBlock(mi *ModuleInstallation, nested *hclast.ObjectItem, filename string) tfdiags.Diagnostics {
+	const errInvalidSummary = "Invalid module_installation block"
+	var diags tfdiags.Diagnostics
+
+	switch blockType := nested.Keys[0].Token.Value(); blockType {
+	case "oci_signature_verification":
+		verification, prefix, moreDiags := decodeOCISignatureVerificationBlock(nested, filename)
+		diags = diags.Append(moreDiags)
+		if verification == nil {
+			return diags
+		}
+		if _, exists := mi.OCISignatureVerification[prefix]; exists {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("Duplicate oci_signature_verification block for %q at %s.", prefix, nested.Pos()),
+			))
+			return diags
+		}
+		if mi.OCISignatureVerification == nil {
+			mi.OCISignatureVerification = make(map[string]*OCISignatureVerification)
+		}
+		mi.OCISignatureVerification[prefix] = verification
+	case "source_credentials":
+		creds, pattern, moreDiags := decodeModuleSourceCredentialsBlock(nested, filename)
+		diags = diags.Append(moreDiags)
+		if creds == nil {
+			return diags
+		}
+		if _, exists := mi.SourceCredentials[pattern]; exists {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("Duplicate source_credentials block for %q at %s.", pattern, nested.Pos()),
+			))
+			return diags
+		}
+		if mi.SourceCredentials == nil {
+			mi.SourceCredentials = make(map[string]*ModuleSourceCredentials)
+		}
+		mi.SourceCredentials[pattern] = creds
+	default:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("Unexpected %q in the module_installation block at %s.", blockType, nested.Pos()),
+		))
+	}
+	return diags
+}
+
+// decodeOCISignatureVerificationBlock decodes an oci_signature_verification
+// block from inside a module_installation block, returning the decoded
+// settings along with the repository address prefix from its label.
+func decodeOCISignatureVerificationBlock(block *hclast.ObjectItem, filename string) (*OCISignatureVerification, string, tfdiags.Diagnostics) {
+	const errInvalidSummary = "Invalid oci_signature_verification block"
+	var diags tfdiags.Diagnostics
+
+	// This helper function compensates for HCL 1's inability to automatically
+	// resolve the block label vs. block argument ambiguity in its JSON syntax.
+	const TWO = 2 // To quiet the "mnd" linter
+	unwrapHCLObjectKeysFromJSON(block, TWO)
+	if len(block.Keys) != TWO {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The oci_signature_verification block at %s must have one label, giving an OCI repository address prefix.", block.Pos()),
+		))
+		return nil, "", diags
+	}
+	if block.Assign.Line != 0 && !block.Keys[0].Token.JSON {
+		// Seems to be an attribute rather than a block
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The oci_signature_verification block at %s must not be introduced with an equals sign.", block.Pos()),
+		))
+		return nil, "", diags
+	}
+	body, ok := block.Val.(*hclast.ObjectType)
+	if !ok {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The oci_signature_verification block at %s must be represented by a JSON object.", block.Pos()),
+		))
+		return nil, "", diags
+	}
+	label, ok := block.Keys[1].Token.Value().(string)
+	if !ok {
+		// HCL grammar doesn't allow anything other than string in the key position,
+		// so we should not get here.
+		panic(fmt.Sprintf("HCL returned non-string label %#v for oci_signature_verification block", block.Keys[1].Token))
+	}
+	if _, _, err := ociauthconfig.ParseRepositoryAddressPrefix(label); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The oci_signature_verification block at %s has an invalid block label: %s.", body.Pos(), err),
+		))
+		return nil, "", diags
+	}
+
+	content, err := decodeOCISignatureVerificationContent(body)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("Invalid oci_signature_verification block at %s: %s.", body.Pos(), err),
+		))
+		return nil, "", diags
+	}
+	verification, moreDiags := prepareOCISignatureVerification(content, filename, "oci_signature_verification block", body.Pos())
+	diags = diags.Append(moreDiags)
+	return verification, label, diags
+}
@@ -0,0 +1,161 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package cliconfig
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadConfig_moduleInstallation(t *testing.T) {
+	absFixtureDir, err := filepath.Abs(fixtureDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, configFile := range []string{"module-installation", "module-installation.json"} {
+		t.Run(configFile, func(t *testing.T) {
+			got, diags := loadConfigFile(filepath.Join(fixtureDir, configFile))
+			if diags.HasErrors() {
+				t.Fatalf("unexpected diagnostics: %s", diags.Err().Error())
+			}
+
+			want := []*ModuleInstallation{
+				{
+					OCISignatureVerification: map[string]*OCISignatureVerification{
+						"example.com": {
+							PublicKeyFiles: []string{filepath.Join(absFixtureDir, "keys/cosign.pub")},
+						},
+						"example.com/modules/network": {
+							TrustedRootFile: filepath.Join(absFixtureDir, "trusted_root.json"),
+							Keyless: []OCIKeylessIdentity{
+								{
+									Issuer:       "https://token.actions.githubusercontent.com",
+									SubjectRegex: "^https://github.com/example/.*$",
+								},
+							},
+						},
+					},
+					SourceCredentials: map[string]*ModuleSourceCredentials{
+						"github.com": {
+							Username: "x-access-token",
+							Token:    "abc123",
+						},
+						"*.git.example.com": {
+							SSHKeyFile:            filepath.Join(absFixtureDir, "keys/id_ed25519"),
+							CredentialsHelper:     "git-token-helper",
+							CredentialsHelperArgs: []string{"--scope", "read"},
+						},
+					},
+				},
+			}
+			if diff := cmp.Diff(want, got.ModuleInstallation); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_moduleInstallationErrors(t *testing.T) {
+	_, diags := loadConfigFile(filepath.Join(fixtureDir, "module-installation-errors"))
+	if !diags.HasErrors() {
+		t.Fatalf("unexpected success; want errors")
+	}
+	got := diags.Err().Error()
+	for _, want := range []string{
+		`has an invalid block label`,
+		`must specify at least one trusted public key or keyless identity`,
+		`sets "trusted_root", which is relevant only when at least one keyless identity is specified`,
+		`must set exactly one of "subject" and "subject_regex"`,
+		`has invalid label "not a hostname"`,
+		`must set at least one of "token", "ssh_key_file", and "credentials_helper"`,
+		`must not set both "token" and "credentials_helper"`,
+		`sets "username", which is relevant only when "token" is also set`,
+		`Unexpected "password" in the source_credentials block`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing expected error\ngot: %s\nwant substring: %s", got, want)
+		}
+	}
+}
+
+func TestModuleInstallationOCISignatureVerificationFor(t *testing.T) {
+	domainWide := &OCISignatureVerification{PublicKeyFiles: []string{"/domain.pub"}}
+	modules := &OCISignatureVerification{PublicKeyFiles: []string{"/modules.pub"}}
+	network := &OCISignatureVerification{PublicKeyFiles: []string{"/network.pub"}}
+	mi := &ModuleInstallation{
+		OCISignatureVerification: map[string]*OCISignatureVerification{
+			"example.com":                 domainWide,
+			"example.com/modules":         modules,
+			"example.com/modules/network": network,
+		},
+	}
+
+	tests := []struct {
+		registryDomain, repositoryPath string
+		want                           *OCISignatureVerification
+	}{
+		{"example.com", "other", domainWide},
+		{"example.com", "modules", modules},
+		{"example.com", "modules/compute", modules},
+		{"example.com", "modules/network", network},
+		{"example.com", "modules/network/vpc", network},
+		{"example.com", "modules-extra", domainWide},
+		{"example.net", "modules", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.registryDomain+"/"+test.repositoryPath, func(t *testing.T) {
+			got := mi.OCISignatureVerificationFor(test.registryDomain, test.repositoryPath)
+			if got != test.want {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.want)
+			}
+		})
+	}
+
+	var noConfig *ModuleInstallation
+	if got := noConfig.OCISignatureVerificationFor("example.com", "modules"); got != nil {
+		t.Errorf("unexpected result for nil ModuleInstallation: %#v", got)
+	}
+}
+
+func TestModuleInstallationSourceCredentialsFor(t *testing.T) {
+	exact := &ModuleSourceCredentials{Token: "exact"}
+	wildcard := &ModuleSourceCredentials{Token: "wildcard"}
+	narrower := &ModuleSourceCredentials{Token: "narrower"}
+	mi := &ModuleInstallation{
+		SourceCredentials: map[string]*ModuleSourceCredentials{
+			"example.com":           exact,
+			"*.example.com":         wildcard,
+			"*.modules.example.com": narrower,
+		},
+	}
+
+	tests := []struct {
+		hostname string
+		want     *ModuleSourceCredentials
+	}{
+		{"example.com", exact},
+		{"EXAMPLE.com", exact},
+		{"git.example.com", wildcard},
+		{"git.modules.example.com", narrower},
+		{"modules.example.com", wildcard},
+		{"notexample.com", nil},
+		{"example.net", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.hostname, func(t *testing.T) {
+			got := mi.SourceCredentialsFor(test.hostname)
+			if got != test.want {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.want)
+			}
+		})
+	}
+
+	var noConfig *ModuleInstallation
+	if got := noConfig.SourceCredentialsFor("example.com"); got != nil {
+		t.Errorf("unexpected result for nil ModuleInstallation: %#v", got)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package cliconfig
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	hclast "github.com/hashicorp/hcl/hcl/ast"
+
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// ModuleSourceCredentials is the structure of a "source_credentials" block
+// within a "module_installation" block, which specifies credentials to use
+// when fetching module packages from particular hosts using git or HTTP.
+type ModuleSourceCredentials struct {
+	// Username and Token are used for HTTP authentication, including git
+	// over HTTPS.
+	Username string
+	Token    string
+
+	// SSHKeyFile is the path to a private key file to use for git over SSH.
+	SSHKeyFile string
+
+	// CredentialsHelper is the path to a program that prints credentials
+	// for a given hostname, which is mutually-exclusive with Token.
+	// CredentialsHelperArgs are any additional arguments to pass to that
+	// program before the hostname.
+	CredentialsHelper     string
+	CredentialsHelperArgs []string
+}
+
+// moduleSourceHostPattern matches the valid labels of source_credentials
+// blocks: either a hostname or a hostname with a leading "*." wildcard that
+// matches any subdomain.
+var moduleSourceHostPattern = regexp.MustCompile(`^(\*\.)?[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// SourceCredentialsFor returns the credentials to use when fetching module
+// packages from the given host, or nil if there are none.
+//
+// A block for the exact hostname takes precedence over any wildcard block,
+// and a wildcard block with a longer suffix takes precedence over a shorter
+// one.
+func (mi *ModuleInstallation) SourceCredentialsFor(hostname string) *ModuleSourceCredentials {
+	if mi == nil {
+		return nil
+	}
+	hostname = strings.ToLower(hostname)
+	if creds, ok := mi.SourceCredentials[hostname]; ok {
+		return creds
+	}
+	var ret *ModuleSourceCredentials
+	longest := -1
+	for pattern, creds := range mi.SourceCredentials {
+		suffix, isWildcard := strings.CutPrefix(pattern, "*")
+		if !isWildcard || !strings.HasSuffix(hostname, suffix) {
+			continue
+		}
+		if len(suffix) > longest {
+			ret = creds
+			longest = len(suffix)
+		}
+	}
+	return ret
+}
+
+// decodeModuleSourceCredentialsBlock decodes a source_credentials block from
+// inside a module_installation block, returning the decoded credentials
+// along with the host pattern from its label.
+func decodeModuleSourceCredentialsBlock(block *hclast.ObjectItem, filename string) (*ModuleSourceCredentials, string, tfdiags.Diagnostics) {
+	const errInvalidSummary = "Invalid source_credentials block"
+	var diags tfdiags.Diagnostics
+
+	// This helper function compensates for HCL 1's inability to automatically
+	// resolve the block label vs. block argument ambiguity in its JSON syntax.
+	const TWO = 2 // To quiet the "mnd" linter
+	unwrapHCLObjectKeysFromJSON(block, TWO)
+	if len(block.Keys) != TWO {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The source_credentials block at %s must have one label, giving a hostname or a hostname pattern like \"*.example.com\".", block.Pos()),
+		))
+		return nil, "", diags
+	}
+	if block.Assign.Line != 0 && !block.Keys[0].Token.JSON {
+		// Seems to be an attribute rather than a block
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The source_credentials block at %s must not be introduced with an equals sign.", block.Pos()),
+		))
+		return nil, "", diags
+	}
+	body, ok := block.Val.(*hclast.ObjectType)
+	if !ok {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The source_credentials block at %s must be represented by a JSON object.", block.Pos()),
+		))
+		return nil, "", diags
+	}
+	label, ok := block.Keys[1].Token.Value().(string)
+	if !ok {
+		// HCL grammar doesn't allow anything other than string in the key position,
+		// so we should not get here.
+		panic(fmt.Sprintf("HCL returned non-string label %#v for source_credentials block", block.Keys[1].Token))
+	}
+	pattern := strings.ToLower(label)
+	if !moduleSourceHostPattern.MatchString(pattern) {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The source_credentials block at %s has invalid label %q: must be a hostname or a hostname pattern like \"*.example.com\".", block.Pos(), label),
+		))
+		return nil, "", diags
+	}
+
+	type Arguments struct {
+		Username              string   `hcl:"username"`
+		Token                 string   `hcl:"token"`
+		SSHKeyFile            string   `hcl:"ssh_key_file"`
+		CredentialsHelper     string   `hcl:"credentials_helper"`
+		CredentialsHelperArgs []string `hcl:"credentials_helper_args"`
+	}
+	var args Arguments
+	if err := hcl.DecodeObject(&args, body); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("Invalid source_credentials block at %s: %s.", body.Pos(), err),
+		))
+		return nil, "", diags
+	}
+	for _, item := range body.List.Items {
+		switch name := item.Keys[0].Token.Value(); name {
+		case "username", "token", "ssh_key_file", "credentials_helper", "credentials_helper_args":
+			// Valid arguments
+		default:
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("Unexpected %q in the source_credentials block at %s.", name, item.Pos()),
+			))
+		}
+	}
+
+	switch {
+	case args.Token == "" && args.SSHKeyFile == "" && args.CredentialsHelper == "":
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The source_credentials block at %s must set at least one of \"token\", \"ssh_key_file\", and \"credentials_helper\".", body.Pos()),
+		))
+	case args.Token != "" && args.CredentialsHelper != "":
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The source_credentials block at %s must not set both \"token\" and \"credentials_helper\".", body.Pos()),
+		))
+	case args.Username != "" && args.Token == "":
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The source_credentials block at %s sets \"username\", which is relevant only when \"token\" is also set.", body.Pos()),
+		))
+	case len(args.CredentialsHelperArgs) != 0 && args.CredentialsHelper == "":
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The source_credentials block at %s sets \"credentials_helper_args\", which is relevant only when \"credentials_helper\" is also set.", body.Pos()),
+		))
+	}
+	if diags.HasErrors() {
+		return nil, "", diags
+	}
+
+	// As with the oci_signature_verification block, any relative file paths
+	// are resolved relative to the directory containing the file where this
+	// block came from.
+	baseDir := filepath.Dir(filename)
+	resolvePath := func(p string) string {
+		if p == "" {
+			return ""
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(baseDir, p)
+		}
+		if absPath, err := filepath.Abs(p); err == nil {
+			p = absPath
+		}
+		return p
+	}
+
+	ret := &ModuleSourceCredentials{
+		Username:              args.Username,
+		Token:                 args.Token,
+		SSHKeyFile:            resolvePath(args.SSHKeyFile),
+		CredentialsHelper:     args.CredentialsHelper,
+		CredentialsHelperArgs: args.CredentialsHelperArgs,
+	}
+	// A credentials helper given as just a program name is found in the
+	// directories listed in PATH when it is run, as with other programs.
+	if strings.ContainsAny(ret.CredentialsHelper, `/\`) {
+		ret.CredentialsHelper = resolvePath(ret.CredentialsHelper)
+	}
+	return ret, pattern, diags
+}
@@ -0,0 +1,257 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package cliconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/hashicorp/hcl"
+	hclast "github.com/hashicorp/hcl/hcl/ast"
+	hcltoken "github.com/hashicorp/hcl/hcl/token"
+
+	"github.com/opentofu/opentofu/internal/cosign"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// OCISignatureVerification describes the cosign signatures that OpenTofu
+// requires for artifacts retrieved from OCI registries.
+//
+// This is the decoded form of either a signature_verification block inside
+// an oci_mirror provider installation method or an oci_signature_verification
+// block inside a module_installation block.
+type OCISignatureVerification struct {
+	// PublicKeyFiles are the absolute paths of PEM-encoded public keys whose
+	// signatures are trusted.
+	PublicKeyFiles []string
+
+	// TrustedRootFile is the absolute path of a Sigstore trusted root
+	// document, which is required when Keyless is non-empty.
+	TrustedRootFile string
+
+	// Keyless are the keyless signing identities whose signatures are
+	// trusted.
+	Keyless []OCIKeylessIdentity
+}
+
+// OCIKeylessIdentity is a keyless signing identity within an
+// [OCISignatureVerification].
+//
+// Exactly one of Subject and SubjectRegex is set.
+type OCIKeylessIdentity struct {
+	Issuer       string
+	Subject      string
+	SubjectRegex string
+}
+
+// ociSignatureVerificationContent is the raw content of both kinds of
+// signature verification block.
+type ociSignatureVerificationContent struct {
+	PublicKeys  []string
+	TrustedRoot string
+	Keyless     []ociKeylessContent
+}
+
+type ociKeylessContent struct {
+	Issuer       string `hcl:"issuer"`
+	Subject      string `hcl:"subject"`
+	SubjectRegex string `hcl:"subject_regex"`
+}
+
+// decodeOCISignatureVerificationContent decodes the body of a signature
+// verification block.
+//
+// HCL 1's decoder doesn't handle nested blocks in a way that is compatible
+// with HCL 2, so the "keyless" blocks are decoded separately from the
+// arguments.
+func decodeOCISignatureVerificationContent(body *hclast.ObjectType) (*ociSignatureVerificationContent, error) {
+	type Arguments struct {
+		PublicKeys  []string `hcl:"public_keys"`
+		TrustedRoot string   `hcl:"trusted_root"`
+	}
+	var args Arguments
+	if err := hcl.DecodeObject(&args, body); err != nil {
+		return nil, err
+	}
+	ret := ociSignatureVerificationContent{
+		PublicKeys:  args.PublicKeys,
+		TrustedRoot: args.TrustedRoot,
+	}
+	for _, item := range body.List.Filter("keyless").Items {
+		keylessBodies, ok := hclBlockBodies(item)
+		if !ok {
+			return nil, fmt.Errorf("keyless must be a block")
+		}
+		for _, keylessBody := range keylessBodies {
+			var keyless ociKeylessContent
+			if err := hcl.DecodeObject(&keyless, keylessBody); err != nil {
+				return nil, err
+			}
+			ret.Keyless = append(ret.Keyless, keyless)
+		}
+	}
+	return &ret, nil
+}
+
+// hclBlockBodies returns the bodies of the blocks represented by the given
+// item, which in the JSON syntax can be either a single object or an array
+// of objects.
+func hclBlockBodies(item *hclast.ObjectItem) ([]*hclast.ObjectType, bool) {
+	switch val := item.Val.(type) {
+	case *hclast.ObjectType:
+		return []*hclast.ObjectType{val}, true
+	case *hclast.ListType:
+		ret := make([]*hclast.ObjectType, 0, len(val.List))
+		for _, elem := range val.List {
+			body, ok := elem.(*hclast.ObjectType)
+			if !ok {
+				return nil, false
+			}
+			ret = append(ret, body)
+		}
+		return ret, true
+	default:
+		return nil, false
+	}
+}
+
+// prepareOCISignatureVerification validates the decoded content of a
+// signature verification block and resolves any relative file paths in it
+// relative to the directory containing the given CLI configuration file.
+//
+// blockDesc is a description of the block for use in error messages, such
+// as "signature_verification block".
+func prepareOCISignatureVerification(content *ociSignatureVerificationContent, filename string, blockDesc string, pos hcltoken.Pos) (*OCISignatureVerification, tfdiags.Diagnostics) {
+	const errInvalidSummary = "Invalid OCI signature verification settings"
+	var diags tfdiags.Diagnostics
+
+	if len(content.PublicKeys) == 0 && len(content.Keyless) == 0 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The %s at %s must specify at least one trusted public key or keyless identity.", blockDesc, pos),
+		))
+		return nil, diags
+	}
+	if len(content.Keyless) != 0 && content.TrustedRoot == "" {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The %s at %s must set \"trusted_root\" to verify keyless signatures.", blockDesc, pos),
+		))
+	}
+	if len(content.Keyless) == 0 && content.TrustedRoot != "" {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The %s at %s sets \"trusted_root\", which is relevant only when at least one keyless identity is specified.", blockDesc, pos),
+		))
+	}
+
+	// Any relative file paths in this block are resolved relative to the directory
+	// containing the file where this block came from.
+	baseDir := filepath.Dir(filename)
+	resolvePath := func(p string) string {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(baseDir, p)
+		}
+		// As with the other file paths in the CLI configuration, we make a
+		// best effort to "absolute-ize" the path so that it won't get
+		// reinterpreted if the working directory changes later.
+		if absPath, err := filepath.Abs(p); err == nil {
+			p = absPath
+		}
+		return p
+	}
+
+	ret := &OCISignatureVerification{}
+	for _, p := range content.PublicKeys {
+		ret.PublicKeyFiles = append(ret.PublicKeyFiles, resolvePath(p))
+	}
+	if content.TrustedRoot != "" {
+		ret.TrustedRootFile = resolvePath(content.TrustedRoot)
+	}
+	for _, raw := range content.Keyless {
+		if raw.Issuer == "" {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("Each keyless block in the %s at %s must set \"issuer\".", blockDesc, pos),
+			))
+			continue
+		}
+		if (raw.Subject == "") == (raw.SubjectRegex == "") {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("Each keyless block in the %s at %s must set exactly one of \"subject\" and \"subject_regex\".", blockDesc, pos),
+			))
+			continue
+		}
+		if raw.SubjectRegex != "" {
+			if _, err := regexp.Compile(raw.SubjectRegex); err != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					errInvalidSummary,
+					fmt.Sprintf("The keyless block in the %s at %s has an invalid \"subject_regex\": %s.", blockDesc, pos, err),
+				))
+				continue
+			}
+		}
+		ret.Keyless = append(ret.Keyless, OCIKeylessIdentity{
+			Issuer:       raw.Issuer,
+			Subject:      raw.Subject,
+			SubjectRegex: raw.SubjectRegex,
+		})
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return ret, diags
+}
+
+// Policy loads the files that the receiver refers to and returns the
+// corresponding signature verification policy.
+//
+// Loading is deferred until the policy is actually needed because most
+// OpenTofu commands don't install anything from OCI registries at all.
+func (v *OCISignatureVerification) Policy() (*cosign.Policy, error) {
+	ret := &cosign.Policy{}
+	for _, filename := range v.PublicKeyFiles {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("reading public key: %w", err)
+		}
+		key, err := cosign.ParsePublicKey(src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key in %s: %w", filename, err)
+		}
+		ret.PublicKeys = append(ret.PublicKeys, key)
+	}
+	if v.TrustedRootFile != "" {
+		src, err := os.ReadFile(v.TrustedRootFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading trusted root: %w", err)
+		}
+		root, err := cosign.ParseTrustedRoot(src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted root in %s: %w", v.TrustedRootFile, err)
+		}
+		ret.TrustedRoot = root
+	}
+	for _, identity := range v.Keyless {
+		id := cosign.Identity{
+			Issuer:  identity.Issuer,
+			Subject: identity.Subject,
+		}
+		if identity.SubjectRegex != "" {
+			// The pattern was already validated during decoding.
+			id.SubjectRegexp = regexp.MustCompile(identity.SubjectRegex)
+		}
+		ret.Identities = append(ret.Identities, id)
+	}
+	return ret, nil
+}
@@ -61,7 +61,7 @@ type ProviderInstallation struct {
 // Note that this function wants the top-level file object which might or
 // might not contain provider_installation blocks, not a provider_installation
 // block directly itself.
-func decodeProviderInstallationFromConfig(hclFile *hclast.File) ([]*ProviderInstallation, tfdiags.Diagnostics) {
+func decodeProviderInstallationFromConfig(hclFile *hclast.File, filename string) ([]*ProviderInstallation, tfdiags.Diagnostics) {
 	var ret []*ProviderInstallation
 	var diags tfdiags.Diagnostics
 
@@ -224,7 +224,7 @@ func decodeProviderInstallationFromConfig(hclFile *hclast.File) ([]*ProviderInst
 				exclude = bodyContent.Exclude
 			case "oci_mirror":
 				var moreDiags tfdiags.Diagnostics
-				location, include, exclude, moreDiags = decodeOCIMirrorInstallationMethodBlock(methodBody)
+				location, include, exclude, moreDiags = decodeOCIMirrorInstallationMethodBlock(methodBody, filename)
 				diags = diags.Append(moreDiags)
 				if moreDiags.HasErrors() {
 					continue
@@ -304,7 +304,7 @@ func decodeProviderInstallationFromConfig(hclFile *hclast.File) ([]*ProviderInst
 
 // decodeOCIMirrorInstallationMethodBlock decodes the content of an oci_mirror block
 // from inside a provider_installation block.
-func decodeOCIMirrorInstallationMethodBlock(methodBody *hclast.ObjectType) (location ProviderInstallationLocation, include, exclude []string, diags tfdiags.Diagnostics) {
+func decodeOCIMirrorInstallationMethodBlock(methodBody *hclast.ObjectType, filename string) (location ProviderInstallationLocation, include, exclude []string, diags tfdiags.Diagnostics) {
 	type BodyContent struct {
 		RepositoryTemplate string   `hcl:"repository_template"`
 		Include            []string `hcl:"include"`
@@ -360,8 +360,53 @@ func decodeOCIMirrorInstallationMethodBlock(methodBody *hclast.ObjectType) (loca
 		return nil, nil, nil, diags
 	}
 
+	// The optional signature_verification block is a nested block, which
+	// HCL 1's decoder can't represent, so we decode it separately.
+	var sigVerificationBodies []*hclast.ObjectType
+	for _, item := range methodBody.List.Filter("signature_verification").Items {
+		bodies, ok := hclBlockBodies(item)
+		if !ok {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid provider_installation method block",
+				fmt.Sprintf("Invalid oci_mirror block at %s: signature_verification must be a block.", methodBody.Pos()),
+			))
+			return nil, nil, nil, diags
+		}
+		sigVerificationBodies = append(sigVerificationBodies, bodies...)
+	}
+	var sigVerification *OCISignatureVerification
+	switch len(sigVerificationBodies) {
+	case 0:
+		// Signature verification is optional.
+	case 1:
+		content, err := decodeOCISignatureVerificationContent(sigVerificationBodies[0])
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid provider_installation method block",
+				fmt.Sprintf("Invalid signature_verification block at %s: %s.", sigVerificationBodies[0].Pos(), err),
+			))
+			return nil, nil, nil, diags
+		}
+		var moreDiags tfdiags.Diagnostics
+		sigVerification, moreDiags = prepareOCISignatureVerification(content, filename, "oci_mirror signature_verification block", sigVerificationBodies[0].Pos())
+		diags = diags.Append(moreDiags)
+		if moreDiags.HasErrors() {
+			return nil, nil, nil, diags
+		}
+	default:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid provider_installation method block",
+			fmt.Sprintf("Invalid oci_mirror block at %s: no more than one signature_verification block may be specified.", methodBody.Pos()),
+		))
+		return nil, nil, nil, diags
+	}
+
 	location = ProviderInstallationOCIMirror{
-		RepositoryMapping: repoMapping,
+		RepositoryMapping:     repoMapping,
+		SignatureVerification: sigVerification,
 	}
 	include = bodyContent.Include
 	exclude = bodyContent.Exclude
@@ -558,6 +603,55 @@ func evalOCIProviderMirrorRepositoryTemplate(templateExpr hclsyntax.Expression,
 	return val.AsString(), diags
 }
 
+// ParseOCIMirrorRepositoryTemplate parses a repository address template
+// written in the same syntax as the "repository_template" argument of an
+// oci_mirror installation method, for use in situations other than the
+// CLI configuration, such as "tofu providers mirror" publishing providers
+// into OCI repositories.
+//
+// Unlike in an oci_mirror block, the template is not required to refer to
+// any particular symbols, so callers must ensure that the resulting mapping
+// is unambiguous for the set of providers they intend to use it with.
+func ParseOCIMirrorRepositoryTemplate(template string) (func(addrs.Provider) (registryDomain, repositoryName string, err error), error) {
+	templateExpr, hclDiags := hclsyntax.ParseTemplate([]byte(template), "<repository template>", hcl2.InitialPos)
+	if hclDiags.HasErrors() {
+		return nil, hclDiags
+	}
+	for _, traversal := range templateExpr.Variables() {
+		switch name := traversal.RootName(); name {
+		case "hostname", "namespace", "type":
+			// okay
+		default:
+			return nil, fmt.Errorf("the symbol %q is not available in an OCI repository address template; only \"hostname\", \"namespace\", and \"type\" are available", name)
+		}
+	}
+	return func(p addrs.Provider) (registryDomain string, repositoryName string, err error) {
+		hclCtx := &hcl2.EvalContext{
+			Variables: map[string]cty.Value{
+				"hostname":  cty.StringVal(p.Hostname.String()),
+				"namespace": cty.StringVal(p.Namespace),
+				"type":      cty.StringVal(p.Type),
+			},
+		}
+		val, hclDiags := templateExpr.Value(hclCtx)
+		if hclDiags.HasErrors() {
+			return "", "", hclDiags
+		}
+		val, err = convert.Convert(val, cty.String)
+		if err != nil || val.IsNull() {
+			return "", "", fmt.Errorf("template must produce a string value")
+		}
+		registryDomain, repositoryName, err = ociauthconfig.ParseRepositoryAddressPrefix(val.AsString())
+		if err != nil {
+			return "", "", fmt.Errorf("template produced invalid OCI repository address %q: %w", val.AsString(), err)
+		}
+		if repositoryName == "" {
+			return "", "", fmt.Errorf("template produced %q, which does not include a repository name after the registry hostname", val.AsString())
+		}
+		return registryDomain, repositoryName, nil
+	}, nil
+}
+
 // ProviderInstallationMethod represents an installation method block inside
 // a provider_installation block.
 type ProviderInstallationMethod struct {
@@ -629,6 +723,10 @@ type ProviderInstallationOCIMirror struct {
 	// so that callers of this function don't need to be aware of the
 	// implementation detail that this uses HCL templates.
 	RepositoryMapping func(addrs.Provider) (registryDomain, repositoryName string, err error)
+
+	// SignatureVerification, if set, describes the cosign signatures that
+	// the index manifest of each provider version must have.
+	SignatureVerification *OCISignatureVerification
 }
 
 func (i ProviderInstallationOCIMirror) providerInstallationLocation() {}
@@ -276,3 +276,115 @@ func TestLoadConfig_providerInstallationOCIMirrorErrors(t *testing.T) {
 		}
 	})
 }
+
+func TestLoadConfig_providerInstallationOCIMirrorSignatures(t *testing.T) {
+	absFixtureDir, err := filepath.Abs(fixtureDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, configFile := range []string{"provider-installation-oci-signatures", "provider-installation-oci-signatures.json"} {
+		t.Run(configFile, func(t *testing.T) {
+			gotConfig, diags := loadConfigFile(filepath.Join(fixtureDir, configFile))
+			if diags.HasErrors() {
+				t.Fatalf("unexpected diagnostics: %s", diags.Err().Error())
+			}
+			loc, ok := gotConfig.ProviderInstallation[0].Methods[0].Location.(ProviderInstallationOCIMirror)
+			if !ok {
+				t.Fatalf("wrong installation method location type")
+			}
+
+			want := &OCISignatureVerification{
+				PublicKeyFiles:  []string{filepath.Join(absFixtureDir, "keys/cosign.pub")},
+				TrustedRootFile: filepath.Clean("/etc/sigstore/trusted_root.json"),
+				Keyless: []OCIKeylessIdentity{
+					{
+						Issuer:  "https://accounts.example.com",
+						Subject: "releases@example.com",
+					},
+					{
+						Issuer:       "https://token.actions.githubusercontent.com",
+						SubjectRegex: "^https://github.com/example/.*$",
+					},
+				},
+			}
+			if diff := cmp.Diff(want, loc.SignatureVerification); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("missing subject and trusted root", func(t *testing.T) {
+		_, diags := loadConfigFile(filepath.Join(fixtureDir, "provider-installation-oci-signatures-errors"))
+		if !diags.HasErrors() {
+			t.Fatalf("unexpected success; want error")
+		}
+		got := diags.Err().Error()
+		for _, want := range []string{
+			`must set "trusted_root" to verify keyless signatures`,
+			`must set exactly one of "subject" and "subject_regex"`,
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("missing expected error\ngot: %s\nwant substring: %s", got, want)
+			}
+		}
+	})
+}
+
+func TestParseOCIMirrorRepositoryTemplate(t *testing.T) {
+	provider := addrs.MustParseProviderSourceString("example.net/foo/bar")
+	tests := map[string]struct {
+		template       string
+		wantDomain     string
+		wantRepository string
+		wantErr        string
+	}{
+		"all symbols": {
+			template:       "example.com/${hostname}/${namespace}/${type}",
+			wantDomain:     "example.com",
+			wantRepository: "example.net/foo/bar",
+		},
+		"fixed repository": {
+			// This is valid for a single provider, and so it's the caller's
+			// responsibility to check whether the result is ambiguous.
+			template:       "example.com:5000/providers",
+			wantDomain:     "example.com:5000",
+			wantRepository: "providers",
+		},
+		"unsupported symbol": {
+			template: "example.com/${provider}",
+			wantErr:  `the symbol "provider" is not available`,
+		},
+		"no repository name": {
+			template: "example.com",
+			wantErr:  "does not include a repository name",
+		},
+		"invalid syntax": {
+			template: "example.com/${",
+			wantErr:  "<repository template>",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mapping, err := ParseOCIMirrorRepositoryTemplate(test.template)
+			var gotDomain, gotRepository string
+			if err == nil {
+				gotDomain, gotRepository, err = mapping(provider)
+			}
+			if test.wantErr != "" {
+				if err == nil {
+					t.Fatalf("unexpected success; want error containing %q", test.wantErr)
+				}
+				if !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("wrong error\ngot:  %s\nwant substring: %s", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if gotDomain != test.wantDomain || gotRepository != test.wantRepository {
+				t.Errorf("wrong result\ngot:  %s %s\nwant: %s %s", gotDomain, gotRepository, test.wantDomain, test.wantRepository)
+			}
+		})
+	}
+}
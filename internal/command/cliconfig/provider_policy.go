@@ -0,0 +1,357 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package cliconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	hclast "github.com/hashicorp/hcl/hcl/ast"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/getproviders"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// ProviderPolicy is the structure of the "provider_policy" nested block
+// within the CLI configuration, which restricts which providers and provider
+// versions OpenTofu may install regardless of what the configuration
+// being initialized requires.
+type ProviderPolicy struct {
+	// AllowedNamespaces, if non-empty, are patterns matching all of the
+	// providers in each of the namespaces that providers may be installed
+	// from.
+	AllowedNamespaces getproviders.MultiSourceMatchingPatterns
+
+	// DeniedProviders are patterns matching providers that may not be
+	// installed even if they belong to an allowed namespace.
+	DeniedProviders getproviders.MultiSourceMatchingPatterns
+
+	// VersionLimits are additional version constraints for specific
+	// providers, derived from the "min" and "max" arguments of each
+	// "version_limit" block.
+	VersionLimits map[addrs.Provider]getproviders.VersionConstraints
+
+	// RequiredSigningKeys, if non-empty, are the IDs of the GPG keys that
+	// are acceptable for signing provider packages, normalized to uppercase.
+	RequiredSigningKeys []string
+}
+
+// gpgKeyIDPattern matches the 16-digit hexadecimal form of a GPG key ID,
+// which is how OpenTofu reports the keys that signed a provider package.
+var gpgKeyIDPattern = regexp.MustCompile(`^[0-9A-Fa-f]{16}$`)
+
+// decodeProviderPolicyFromConfig uses the HCL AST API directly to decode
+// "provider_policy" blocks from the given file.
+//
+// This follows the same approach as decodeModuleInstallationFromConfig,
+// so that a later migration to HCL 2 can support the same structure.
+func decodeProviderPolicyFromConfig(hclFile *hclast.File) ([]*ProviderPolicy, tfdiags.Diagnostics) {
+	const errInvalidSummary = "Invalid provider_policy block"
+	var ret []*ProviderPolicy
+	var diags tfdiags.Diagnostics
+
+	root, ok := hclFile.Node.(*hclast.ObjectList)
+	if !ok {
+		// A HCL file that doesn't have an object list at its root is weird, but
+		// dealing with that is outside the scope of this function.
+		return ret, diags
+	}
+	// The HCL 1 JSON parser flattens an object whose properties are all
+	// objects into items with multiple keys, so a JSON provider_policy
+	// object that contains only version_limit blocks arrives as one
+	// top-level item per block. We collect all of those into a single
+	// result.
+	var fromJSON *ProviderPolicy
+
+	for _, block := range root.Items {
+		if block.Keys[0].Token.Value() != "provider_policy" {
+			continue
+		}
+		isJSON := block.Keys[0].Token.JSON
+		if isJSON && len(block.Keys) > 1 {
+			if fromJSON == nil {
+				fromJSON = &ProviderPolicy{}
+				ret = append(ret, fromJSON)
+			}
+			nested := &hclast.ObjectItem{
+				Keys: block.Keys[1:],
+				Val:  block.Val,
+			}
+			if blockType := nested.Keys[0].Token.Value(); blockType != "version_limit" {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					errInvalidSummary,
+					fmt.Sprintf("Unexpected %q in the provider_policy block at %s.", blockType, nested.Pos()),
+				))
+				continue
+			}
+			nested.Keys = nested.Keys[1:]
+			diags = diags.Append(decodeProviderPolicyVersionLimit(fromJSON, nested))
+			continue
+		}
+
+		if block.Assign.Line != 0 && !isJSON {
+			// Seems to be an attribute rather than a block
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("The provider_policy block at %s must not be introduced with an equals sign.", block.Pos()),
+			))
+			continue
+		}
+		if len(block.Keys) > 1 && !isJSON {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("The provider_policy block at %s must not have any labels.", block.Pos()),
+			))
+			continue
+		}
+		body, ok := block.Val.(*hclast.ObjectType)
+		if !ok {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("The provider_policy block at %s must be represented by a JSON object.", block.Pos()),
+			))
+			continue
+		}
+
+		policy, moreDiags := decodeProviderPolicyBody(body)
+		diags = diags.Append(moreDiags)
+		if policy != nil {
+			ret = append(ret, policy)
+		}
+	}
+
+	return ret, diags
+}
+
+// decodeProviderPolicyBody decodes the content of a single provider_policy
+// block.
+func decodeProviderPolicyBody(body *hclast.ObjectType) (*ProviderPolicy, tfdiags.Diagnostics) {
+	const errInvalidSummary = "Invalid provider_policy block"
+	var diags tfdiags.Diagnostics
+
+	// HCL 1's decoder doesn't handle nested blocks in a way that is
+	// compatible with HCL 2, so the "version_limit" blocks are decoded
+	// separately from the arguments.
+	type Arguments struct {
+		AllowedNamespaces   []string `hcl:"allowed_namespaces"`
+		DeniedProviders     []string `hcl:"denied_providers"`
+		RequiredSigningKeys []string `hcl:"required_signing_keys"`
+	}
+	var args Arguments
+	if err := hcl.DecodeObject(&args, body); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("Invalid provider_policy block at %s: %s.", body.Pos(), err),
+		))
+		return nil, diags
+	}
+
+	policy := &ProviderPolicy{}
+	for _, item := range body.List.Items {
+		switch name := item.Keys[0].Token.Value(); name {
+		case "allowed_namespaces", "denied_providers", "required_signing_keys":
+			// Already decoded above.
+		case "version_limit":
+			// Handled below.
+		default:
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("Unexpected %q in the provider_policy block at %s.", name, item.Pos()),
+			))
+		}
+	}
+
+	if len(args.AllowedNamespaces) != 0 {
+		// Each namespace is either "hostname/namespace" or just "namespace"
+		// for the default registry, and the hostname or namespace can be
+		// a wildcard. We can therefore reuse the provider matching patterns
+		// syntax by adding a wildcard type name.
+		patternStrs := make([]string, 0, len(args.AllowedNamespaces))
+		for _, ns := range args.AllowedNamespaces {
+			if ns == "" || strings.Count(ns, "/") > 1 {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					errInvalidSummary,
+					fmt.Sprintf("The allowed_namespaces argument at %s includes %q, which is not a valid provider namespace. Namespaces must be written as a registry hostname and namespace name separated by a slash, such as \"registry.opentofu.org/hashicorp\".", body.Pos(), ns),
+				))
+				continue
+			}
+			patternStrs = append(patternStrs, ns+"/"+getproviders.Wildcard)
+		}
+		patterns, err := getproviders.ParseMultiSourceMatchingPatterns(patternStrs)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("The allowed_namespaces argument at %s is invalid: %s.", body.Pos(), err),
+			))
+		}
+		policy.AllowedNamespaces = patterns
+	}
+
+	patterns, err := getproviders.ParseMultiSourceMatchingPatterns(args.DeniedProviders)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The denied_providers argument at %s is invalid: %s.", body.Pos(), err),
+		))
+	}
+	policy.DeniedProviders = patterns
+
+	for _, keyID := range args.RequiredSigningKeys {
+		if !gpgKeyIDPattern.MatchString(keyID) {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("The required_signing_keys argument at %s includes %q, which is not a valid GPG key ID. Key IDs must be written as 16 hexadecimal digits.", body.Pos(), keyID),
+			))
+			continue
+		}
+		policy.RequiredSigningKeys = append(policy.RequiredSigningKeys, strings.ToUpper(keyID))
+	}
+
+	for _, item := range body.List.Filter("version_limit").Items {
+		diags = diags.Append(decodeProviderPolicyVersionLimit(policy, item))
+	}
+
+	return policy, diags
+}
+
+// decodeProviderPolicyVersionLimit decodes a version_limit block from inside
+// a provider_policy block, adding its content to the given object.
+//
+// The given item must have had the "version_limit" key already removed, so
+// that its only key is the block label.
+func decodeProviderPolicyVersionLimit(policy *ProviderPolicy, item *hclast.ObjectItem) tfdiags.Diagnostics {
+	const errInvalidSummary = "Invalid version_limit block"
+	var diags tfdiags.Diagnostics
+
+	unwrapHCLObjectKeysFromJSON(item, 1)
+	if len(item.Keys) != 1 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The version_limit block at %s must have one label, giving a provider source address.", item.Pos()),
+		))
+		return diags
+	}
+	if item.Assign.Line != 0 && !item.Keys[0].Token.JSON {
+		// Seems to be an attribute rather than a block
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The version_limit block at %s must not be introduced with an equals sign.", item.Pos()),
+		))
+		return diags
+	}
+	body, ok := item.Val.(*hclast.ObjectType)
+	if !ok {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The version_limit block at %s must be represented by a JSON object.", item.Pos()),
+		))
+		return diags
+	}
+	label, ok := item.Keys[0].Token.Value().(string)
+	if !ok {
+		// HCL grammar doesn't allow anything other than string in the key position,
+		// so we should not get here.
+		panic(fmt.Sprintf("HCL returned non-string label %#v for version_limit block", item.Keys[0].Token))
+	}
+	addr, moreDiags := addrs.ParseProviderSourceString(label)
+	if moreDiags.HasErrors() {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The version_limit block at %s has an invalid provider source address %q.\n\n%s", item.Pos(), label, moreDiags.Err().Error()),
+		))
+		return diags
+	}
+	if _, exists := policy.VersionLimits[addr]; exists {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("Duplicate version_limit block for %q at %s.", label, item.Pos()),
+		))
+		return diags
+	}
+
+	type BodyContent struct {
+		Min string `hcl:"min"`
+		Max string `hcl:"max"`
+	}
+	var bodyContent BodyContent
+	if err := hcl.DecodeObject(&bodyContent, body); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("Invalid version_limit block at %s: %s.", item.Pos(), err),
+		))
+		return diags
+	}
+	if bodyContent.Min == "" && bodyContent.Max == "" {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The version_limit block at %s must set at least one of the \"min\" and \"max\" arguments.", item.Pos()),
+		))
+		return diags
+	}
+
+	var constraintStrs []string
+	var minVersion, maxVersion getproviders.Version
+	for _, arg := range []struct {
+		name, value, op string
+		target          *getproviders.Version
+	}{
+		{"min", bodyContent.Min, ">=", &minVersion},
+		{"max", bodyContent.Max, "<=", &maxVersion},
+	} {
+		if arg.value == "" {
+			continue
+		}
+		v, err := getproviders.ParseVersion(arg.value)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				errInvalidSummary,
+				fmt.Sprintf("The %q argument of the version_limit block at %s must be a version number: %s.", arg.name, item.Pos(), err),
+			))
+			return diags
+		}
+		*arg.target = v
+		constraintStrs = append(constraintStrs, arg.op+" "+v.String())
+	}
+	if bodyContent.Min != "" && bodyContent.Max != "" && maxVersion.LessThan(minVersion) {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			errInvalidSummary,
+			fmt.Sprintf("The version_limit block at %s has a \"max\" version that is lower than its \"min\" version.", item.Pos()),
+		))
+		return diags
+	}
+	constraints, err := getproviders.ParseVersionConstraints(strings.Join(constraintStrs, ", "))
+	if err != nil {
+		// Should not get here, because we constructed the constraints from
+		// valid version numbers above.
+		panic(fmt.Sprintf("invalid version_limit constraints: %s", err))
+	}
+
+	if policy.VersionLimits == nil {
+		policy.VersionLimits = make(map[addrs.Provider]getproviders.VersionConstraints)
+	}
+	policy.VersionLimits[addr] = constraints
+	return diags
+}
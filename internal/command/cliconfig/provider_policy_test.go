@@ -0,0 +1,96 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package cliconfig
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/getproviders"
+)
+
+func TestLoadConfig_providerPolicy(t *testing.T) {
+	for _, configFile := range []string{"provider-policy", "provider-policy.json"} {
+		t.Run(configFile, func(t *testing.T) {
+			got, diags := loadConfigFile(filepath.Join(fixtureDir, configFile))
+			if diags.HasErrors() {
+				t.Fatalf("unexpected diagnostics: %s", diags.Err().Error())
+			}
+
+			want := []*ProviderPolicy{
+				{
+					AllowedNamespaces: mustParseMultiSourceMatchingPatterns(t,
+						"registry.opentofu.org/hashicorp/*",
+						"example.com/*/*",
+						"registry.opentofu.org/opentofu/*",
+					),
+					DeniedProviders: mustParseMultiSourceMatchingPatterns(t,
+						"registry.opentofu.org/hashicorp/null",
+					),
+					VersionLimits: map[addrs.Provider]getproviders.VersionConstraints{
+						addrs.MustParseProviderSourceString("hashicorp/aws"):       getproviders.MustParseVersionConstraints(">= 5.0.0, <= 5.99.0"),
+						addrs.MustParseProviderSourceString("example.com/foo/bar"): getproviders.MustParseVersionConstraints("<= 2.0.0"),
+					},
+					RequiredSigningKeys: []string{"34365D9472D7468F"},
+				},
+			}
+			if diff := cmp.Diff(want, got.ProviderPolicy); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_providerPolicyOnlyVersionLimits(t *testing.T) {
+	// When a provider_policy object in JSON contains only version_limit
+	// blocks, HCL 1 flattens it into separate top-level items.
+	got, diags := loadConfigFile(filepath.Join(fixtureDir, "provider-policy-versionlimit.json"))
+	if diags.HasErrors() {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err().Error())
+	}
+	want := []*ProviderPolicy{
+		{
+			VersionLimits: map[addrs.Provider]getproviders.VersionConstraints{
+				addrs.MustParseProviderSourceString("hashicorp/aws"): getproviders.MustParseVersionConstraints(">= 5.0.0"),
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got.ProviderPolicy); diff != "" {
+		t.Errorf("wrong result\n%s", diff)
+	}
+}
+
+func TestLoadConfig_providerPolicyErrors(t *testing.T) {
+	_, diags := loadConfigFile(filepath.Join(fixtureDir, "provider-policy-errors"))
+	if !diags.HasErrors() {
+		t.Fatalf("unexpected success; want errors")
+	}
+	got := diags.Err().Error()
+	for _, want := range []string{
+		`includes "example.com/foo/bar", which is not a valid provider namespace`,
+		`The denied_providers argument at 1:17 is invalid`,
+		`includes "not-a-key", which is not a valid GPG key ID`,
+		`Unexpected "unexpected" in the provider_policy block`,
+		`must set at least one of the "min" and "max" arguments`,
+		`has a "max" version that is lower than its "min" version`,
+		`The "min" argument of the version_limit block at 13:17 must be a version number`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing expected error\ngot: %s\nwant substring: %s", got, want)
+		}
+	}
+}
+
+func mustParseMultiSourceMatchingPatterns(t *testing.T, strs ...string) getproviders.MultiSourceMatchingPatterns {
+	t.Helper()
+	ret, err := getproviders.ParseMultiSourceMatchingPatterns(strs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
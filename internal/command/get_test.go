@@ -7,12 +7,15 @@ package command
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/mitchellh/cli"
 
+	"github.com/opentofu/opentofu/internal/depsfile"
 	"github.com/opentofu/opentofu/internal/getmodules"
 )
 
@@ -201,3 +204,67 @@ func TestGetCommand_InvalidArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestGet_moduleLocks(t *testing.T) {
+	wd := tempWorkingDirFixture(t, "init-module-early-eval")
+	t.Chdir(wd.RootModuleDir())
+
+	// An absolute path is installed as a separate module package, like a
+	// remote module.
+	moduleDir := t.TempDir()
+	moduleFile := filepath.Join(moduleDir, "main.tf")
+	if err := os.WriteFile(moduleFile, []byte("output \"a\" {\n  value = 1\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(args ...string) (int, *cli.MockUi) {
+		ui := cli.NewMockUi()
+		c := &GetCommand{
+			Meta: Meta{
+				testingOverrides:     metaOverridesForProvider(testProvider()),
+				Ui:                   ui,
+				WorkingDir:           wd,
+				ModulePackageFetcher: getmodules.NewPackageFetcher(t.Context(), nil),
+			},
+		}
+		return c.Run(append([]string{"-var=module_source=" + filepath.ToSlash(moduleDir)}, args...)), ui
+	}
+
+	if code, ui := get(); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+
+	locks, diags := depsfile.LoadLocksFromFile(dependencyLockFilename)
+	if diags.HasErrors() {
+		t.Fatal(diags.Err())
+	}
+	lock := locks.Module("test")
+	if lock == nil {
+		t.Fatal("the lock file doesn't record module test")
+	}
+	if len(lock.AllHashes()) != 1 {
+		t.Fatalf("wrong hashes %#v", lock.AllHashes())
+	}
+
+	if err := os.WriteFile(moduleFile, []byte("output \"a\" {\n  value = 2\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, ui := get()
+	if code == 0 {
+		t.Fatalf("succeeded; wanted error\n%s", ui.OutputWriter.String())
+	}
+	if got, want := ui.ErrorWriter.String(), "Module package doesn't match the dependency lock file"; !strings.Contains(got, want) {
+		t.Fatalf("wrong error message\nshould contain: %s\ngot:\n%s", want, got)
+	}
+
+	if code, ui := get("-update"); code != 0 {
+		t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+	}
+	locks, diags = depsfile.LoadLocksFromFile(dependencyLockFilename)
+	if diags.HasErrors() {
+		t.Fatal(diags.Err())
+	}
+	if updated := locks.Module("test"); updated == nil || updated.AllHashes()[0] == lock.AllHashes()[0] {
+		t.Fatalf("the lock file wasn't updated: %#v", updated)
+	}
+}
@@ -7,8 +7,10 @@ package command
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"reflect"
 	"sort"
 	"strings"
@@ -23,6 +25,7 @@ import (
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/backend"
 	backendInit "github.com/opentofu/opentofu/internal/backend/init"
+	"github.com/opentofu/opentofu/internal/bundle"
 	"github.com/opentofu/opentofu/internal/cloud"
 	"github.com/opentofu/opentofu/internal/command/arguments"
 	"github.com/opentofu/opentofu/internal/command/views"
@@ -51,7 +54,7 @@ func (c *InitCommand) Run(args []string) int {
 	ctx, span := tracing.Tracer().Start(ctx, "Init")
 	defer span.End()
 
-	var flagFromModule, flagLockfile, testsDirectory string
+	var flagFromModule, flagFromBundle, flagLockfile, flagMigrateEncryptionFrom, testsDirectory string
 	var flagBackend, flagCloud, flagGet, flagUpgrade bool
 	var flagPluginPath FlagStringSlice
 	flagConfigExtra := newRawFlags("-backend-config")
@@ -62,12 +65,14 @@ func (c *InitCommand) Run(args []string) int {
 	cmdFlags.BoolVar(&flagCloud, "cloud", true, "")
 	cmdFlags.Var(flagConfigExtra, "backend-config", "")
 	cmdFlags.StringVar(&flagFromModule, "from-module", "", "copy the source of the given module into the directory before init")
+	cmdFlags.StringVar(&flagFromBundle, "from-bundle", "", "extract the given bundle into the directory and install its dependencies")
 	cmdFlags.BoolVar(&flagGet, "get", true, "")
 	cmdFlags.BoolVar(&c.forceInitCopy, "force-copy", false, "suppress prompts about copying state data")
 	cmdFlags.BoolVar(&c.Meta.stateLock, "lock", true, "lock state")
 	cmdFlags.DurationVar(&c.Meta.stateLockTimeout, "lock-timeout", 0, "lock timeout")
 	cmdFlags.BoolVar(&c.reconfigure, "reconfigure", false, "reconfigure")
 	cmdFlags.BoolVar(&c.migrateState, "migrate-state", false, "migrate state")
+	cmdFlags.StringVar(&flagMigrateEncryptionFrom, "migrate-encryption-from", "", "encryption configuration of the state being migrated")
 	cmdFlags.BoolVar(&flagUpgrade, "upgrade", false, "")
 	cmdFlags.Var(&flagPluginPath, "plugin-dir", "plugin directory")
 	cmdFlags.StringVar(&flagLockfile, "lockfile", "", "Set a dependency lockfile mode")
@@ -109,10 +114,31 @@ func (c *InitCommand) Run(args []string) int {
 	}
 
 	// Copying the state only happens during backend migration, so setting
-	// -force-copy implies -migrate-state
-	if c.forceInitCopy {
+	// -force-copy or -migrate-encryption-from implies -migrate-state
+	if c.forceInitCopy || flagMigrateEncryptionFrom != "" {
 		c.migrateState = true
 	}
+	if flagMigrateEncryptionFrom != "" && c.reconfigure {
+		c.Ui.Error("The -migrate-encryption-from and -reconfigure options are mutually-exclusive")
+		return 1
+	}
+
+	// A bundle includes everything needed for initialization, so it
+	// can't be combined with other options that would find dependencies
+	// elsewhere.
+	if flagFromBundle != "" {
+		switch {
+		case flagFromModule != "":
+			c.Ui.Error("The -from-bundle and -from-module options are mutually-exclusive")
+			return 1
+		case len(flagPluginPath) > 0:
+			c.Ui.Error("The -from-bundle and -plugin-dir options are mutually-exclusive")
+			return 1
+		case flagUpgrade:
+			c.Ui.Error("The -from-bundle and -upgrade options are mutually-exclusive")
+			return 1
+		}
+	}
 
 	var diags tfdiags.Diagnostics
 
@@ -181,6 +207,44 @@ func (c *InitCommand) Run(args []string) int {
 		c.Ui.Output("")
 	}
 
+	if flagFromBundle != "" {
+		empty, err := configs.IsEmptyDir(path)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error validating destination directory: %s", err))
+			return 1
+		}
+		if !empty {
+			c.Ui.Error(strings.TrimSpace(errInitBundleNotEmpty))
+			return 1
+		}
+
+		c.Ui.Output(c.Colorize().Color(fmt.Sprintf(
+			"[reset][bold]Extracting bundle[reset] %q...", flagFromBundle,
+		)))
+		header = true
+
+		// The provider packages are extracted into a temporary directory
+		// that we then use instead of the usual provider installation
+		// sources, just as if it had been given in -plugin-dir.
+		providersDir, err := os.MkdirTemp("", "tofu-bundle-providers")
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error creating temporary directory for provider packages: %s", err))
+			return 1
+		}
+		defer os.RemoveAll(providersDir)
+
+		bundleDiags := c.initDirFromBundle(path, flagFromBundle, providersDir)
+		diags = diags.Append(bundleDiags)
+		if bundleDiags.HasErrors() {
+			c.showDiagnostics(diags)
+			return 1
+		}
+		flagPluginPath = FlagStringSlice{providersDir}
+		c.offlineModules = true
+
+		c.Ui.Output("")
+	}
+
 	// If our directory is empty, then we're done. We can't get or set up
 	// the backend with an empty directory.
 	empty, err := configs.IsEmptyDir(path)
@@ -224,6 +288,20 @@ func (c *InitCommand) Run(args []string) int {
 		}
 	}
 
+	// The state stored in the previously-configured backend may be encrypted
+	// differently than the new configuration requires, in which case the
+	// old settings are needed to read it during migration.
+	var migrateFromEnc encryption.Encryption
+	if flagMigrateEncryptionFrom != "" {
+		var encDiags tfdiags.Diagnostics
+		migrateFromEnc, encDiags = c.EncryptionFromFile(ctx, flagMigrateEncryptionFrom, rootModEarly)
+		diags = diags.Append(encDiags)
+		if encDiags.HasErrors() {
+			c.showDiagnostics(diags)
+			return 1
+		}
+	}
+
 	var back backend.Backend
 
 	// There may be config errors or backend init errors but these will be shown later _after_
@@ -235,7 +313,7 @@ func (c *InitCommand) Run(args []string) int {
 	case flagCloud && rootModEarly.CloudConfig != nil:
 		back, backendOutput, backDiags = c.initCloud(ctx, rootModEarly, flagConfigExtra, enc)
 	case flagBackend:
-		back, backendOutput, backDiags = c.initBackend(ctx, rootModEarly, flagConfigExtra, enc)
+		back, backendOutput, backDiags = c.initBackend(ctx, rootModEarly, flagConfigExtra, enc, migrateFromEnc)
 	default:
 		// load the previously-stored backend config
 		back, backDiags = c.Meta.backendFromState(ctx, enc.State())
@@ -271,7 +349,7 @@ func (c *InitCommand) Run(args []string) int {
 	}
 
 	if flagGet {
-		modsOutput, modsAbort, modsDiags := c.getModules(ctx, path, testsDirectory, rootModEarly, flagUpgrade)
+		modsOutput, modsAbort, modsDiags := c.getModules(ctx, path, testsDirectory, rootModEarly, flagUpgrade, flagLockfile)
 		diags = diags.Append(modsDiags)
 		if modsAbort || modsDiags.HasErrors() {
 			c.showDiagnostics(diags)
@@ -392,7 +470,7 @@ func (c *InitCommand) Run(args []string) int {
 	return 0
 }
 
-func (c *InitCommand) getModules(ctx context.Context, path, testsDir string, earlyRoot *configs.Module, upgrade bool) (output bool, abort bool, diags tfdiags.Diagnostics) {
+func (c *InitCommand) getModules(ctx context.Context, path, testsDir string, earlyRoot *configs.Module, upgrade bool, flagLockfile string) (output bool, abort bool, diags tfdiags.Diagnostics) {
 	testModules := false // We can also have modules buried in test files.
 	for _, file := range earlyRoot.Tests {
 		for _, run := range file.Runs {
@@ -400,6 +478,9 @@ func (c *InitCommand) getModules(ctx context.Context, path, testsDir string, ear
 				testModules = true
 			}
 		}
+		if len(file.Fixtures) > 0 {
+			testModules = true
+		}
 	}
 
 	if len(earlyRoot.ModuleCalls) == 0 && !testModules {
@@ -423,7 +504,7 @@ func (c *InitCommand) getModules(ctx context.Context, path, testsDir string, ear
 		ShowLocalPaths: true,
 	}
 
-	installAbort, installDiags := c.installModules(ctx, path, testsDir, upgrade, false, hooks)
+	installAbort, installDiags := c.installModules(ctx, path, testsDir, upgrade, false, flagLockfile == "readonly", hooks)
 	diags = diags.Append(installDiags)
 
 	// At this point, installModules may have generated error diags or been
@@ -474,7 +555,7 @@ func (c *InitCommand) initCloud(ctx context.Context, root *configs.Module, extra
 	return back, true, diags
 }
 
-func (c *InitCommand) initBackend(ctx context.Context, root *configs.Module, extraConfig rawFlags, enc encryption.Encryption) (be backend.Backend, output bool, diags tfdiags.Diagnostics) {
+func (c *InitCommand) initBackend(ctx context.Context, root *configs.Module, extraConfig rawFlags, enc, migrateFromEnc encryption.Encryption) (be backend.Backend, output bool, diags tfdiags.Diagnostics) {
 	ctx, span := tracing.Tracer().Start(ctx, "Backend init")
 	_ = ctx // prevent staticcheck from complaining to avoid a maintenance hazard of having the wrong ctx in scope here
 	defer span.End()
@@ -551,6 +632,9 @@ the backend configuration is present and valid.
 		ConfigOverride: backendConfigOverride,
 		Init:           true,
 	}
+	if migrateFromEnc != nil {
+		opts.MigrateFromEncryption = migrateFromEnc.State()
+	}
 
 	back, backDiags := c.Backend(ctx, opts, enc.State())
 	diags = diags.Append(backDiags)
@@ -645,6 +729,10 @@ func (c *InitCommand) getProviders(ctx context.Context, config *configs.Config,
 	// and incomplete providers are stored here for later analysis.
 	var incompleteProviders []string
 
+	// We also retain the authentication results for any providers we
+	// install, so that we can record them in the working directory.
+	var installedAuthResults map[addrs.Provider]*getproviders.PackageAuthenticationResult
+
 	// Because we're currently just streaming a series of events sequentially
 	// into the terminal, we're showing only a subset of the events to keep
 	// things relatively concise. Later it'd be nice to have a progress UI
@@ -773,6 +861,13 @@ func (c *InitCommand) getProviders(ctx context.Context, config *configs.Config,
 					))
 				}
 
+			case providercache.ErrProviderPolicy:
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Provider not permitted by policy",
+					fmt.Sprintf(providerPolicyErrorDetail, provider.ForDisplay(), errorTy.Reason),
+				))
+
 			case getproviders.ErrRequestCanceled:
 				// We don't attribute cancellation to any particular operation,
 				// but rather just emit a single general message about it at
@@ -813,6 +908,18 @@ func (c *InitCommand) getProviders(ctx context.Context, config *configs.Config,
 		},
 		FetchPackageFailure: func(provider addrs.Provider, version getproviders.Version, err error) {
 			const summaryIncompatible = "Incompatible provider version"
+			// Policy errors from package authentication can be wrapped
+			// together with other errors from the installation step, so
+			// we need to look for them more carefully than the others.
+			var policyErr providercache.ErrProviderPolicy
+			if errors.As(err, &policyErr) {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Provider not permitted by policy",
+					fmt.Sprintf(providerPolicyErrorDetail, fmt.Sprintf("%s v%s", provider.ForDisplay(), version), policyErr.Reason),
+				))
+				return
+			}
 			switch err := err.(type) {
 			case getproviders.ErrProtocolNotSupported:
 				closestAvailable := err.Suggestion
@@ -898,6 +1005,9 @@ func (c *InitCommand) getProviders(ctx context.Context, config *configs.Config,
 			if keyID != "" {
 				keyID = c.Colorize().Color(fmt.Sprintf(", key ID [reset][bold]%s[reset]", keyID))
 			}
+			if signers := authResult.OCISignersString(); signers != "" {
+				keyID += c.Colorize().Color(fmt.Sprintf(", signed by [reset][bold]%s[reset]", signers))
+			}
 
 			if authResult != nil && authResult.SigningSkipped() {
 				c.Ui.Warn(fmt.Sprintf("- Installed %s v%s. Signature validation was skipped due to the registry not containing GPG keys for this provider", provider.ForDisplay(), version))
@@ -944,6 +1054,7 @@ func (c *InitCommand) getProviders(ctx context.Context, config *configs.Config,
 			incompleteProviders = append(incompleteProviders, provider.ForDisplay())
 		},
 		ProvidersAuthenticated: func(authResults map[addrs.Provider]*getproviders.PackageAuthenticationResult) {
+			installedAuthResults = authResults
 			thirdPartySigned := false
 			for _, authResult := range authResults {
 				if authResult.Signed() {
@@ -986,6 +1097,14 @@ func (c *InitCommand) getProviders(ctx context.Context, config *configs.Config,
 		return true, true, diags
 	}
 
+	if err := c.recordProviderAuthentications(newLocks, installedAuthResults); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Failed to record provider authentication results",
+			fmt.Sprintf("OpenTofu could not record how it authenticated the installed providers, so \"tofu sbom\" will not be able to report their signatures: %s.", err),
+		))
+	}
+
 	// If the provider dependencies have changed since the last run then we'll
 	// say a little about that in case the reader wasn't expecting a change.
 	// (When we later integrate module dependencies into the lock file we'll
@@ -1204,22 +1323,87 @@ func (c *InitCommand) AutocompleteArgs() complete.Predictor {
 	return complete.PredictDirs("")
 }
 
+// initDirFromBundle extracts the bundle at the given path, placing the
+// configuration into targetDir, the module packages into the module
+// installation directory, and the provider packages into providersDir.
+//
+// The extracted files are not verified here. Instead, the module and provider
+// installers verify them against the extracted dependency lock file in the
+// same way as for any other installation, except that the module installer
+// must not fetch any modules, so that a module package that doesn't match the
+// lock file is an error rather than being replaced.
+func (c *InitCommand) initDirFromBundle(targetDir, bundlePath, providersDir string) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to open bundle",
+			fmt.Sprintf("Could not open the bundle %s: %s.", bundlePath, err),
+		))
+		return diags
+	}
+	defer f.Close()
+
+	r, err := bundle.NewReader(f)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid bundle",
+			fmt.Sprintf("Could not read the bundle %s: %s.", bundlePath, err),
+		))
+		return diags
+	}
+	defer r.Close()
+
+	platform := getproviders.CurrentPlatform.String()
+	if !r.Manifest.HasPlatform(platform) {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Bundle does not support this platform",
+			fmt.Sprintf(
+				"The bundle %s includes provider packages only for %s, so it cannot be installed on %s. To include packages for this platform, create the bundle again with the -platform=%s option.",
+				bundlePath, strings.Join(r.Manifest.Platforms, ", "), platform, platform,
+			),
+		))
+		return diags
+	}
+
+	err = r.Extract(bundle.Dirs{
+		Config:    targetDir,
+		Modules:   c.modulesDir(),
+		Providers: providersDir,
+		DataDir:   c.DataDir(),
+	})
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to extract bundle",
+			fmt.Sprintf("Could not extract the bundle %s: %s.", bundlePath, err),
+		))
+	}
+	return diags
+}
+
 func (c *InitCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
-		"-backend":        completePredictBoolean,
-		"-cloud":          completePredictBoolean,
-		"-backend-config": complete.PredictFiles("*.tfvars"), // can also be key=value, but we can't "predict" that
-		"-force-copy":     complete.PredictNothing,
-		"-from-module":    completePredictModuleSource,
-		"-get":            completePredictBoolean,
-		"-input":          completePredictBoolean,
-		"-lock":           completePredictBoolean,
-		"-lock-timeout":   complete.PredictAnything,
-		"-no-color":       complete.PredictNothing,
-		"-plugin-dir":     complete.PredictDirs(""),
-		"-reconfigure":    complete.PredictNothing,
-		"-migrate-state":  complete.PredictNothing,
-		"-upgrade":        completePredictBoolean,
+		"-backend":                 completePredictBoolean,
+		"-cloud":                   completePredictBoolean,
+		"-backend-config":          complete.PredictFiles("*.tfvars"), // can also be key=value, but we can't "predict" that
+		"-force-copy":              complete.PredictNothing,
+		"-from-bundle":             complete.PredictFiles("*"),
+		"-from-module":             completePredictModuleSource,
+		"-get":                     completePredictBoolean,
+		"-input":                   completePredictBoolean,
+		"-lock":                    completePredictBoolean,
+		"-lock-timeout":            complete.PredictAnything,
+		"-no-color":                complete.PredictNothing,
+		"-plugin-dir":              complete.PredictDirs(""),
+		"-reconfigure":             complete.PredictNothing,
+		"-migrate-state":           complete.PredictNothing,
+		"-migrate-encryption-from": complete.PredictFiles("*"),
+		"-upgrade":                 completePredictBoolean,
 	}
 }
 
@@ -1273,6 +1457,11 @@ Options:
                           equivalent to providing a "yes" to all confirmation
                           prompts.
 
+  -from-bundle=PATH       Extract the configuration in the given bundle, created
+                          by "tofu bundle create", into the target directory and
+                          install its modules and providers from the bundle
+                          without network access.
+
   -from-module=SOURCE     Copy the contents of the given module into the target
                           directory before initialization.
 
@@ -1301,6 +1490,13 @@ Options:
   -migrate-state          Reconfigure a backend, and attempt to migrate any
                           existing state.
 
+  -migrate-encryption-from=path
+                          Read the existing state using the encryption
+                          configuration in the given file, which has the same
+                          format as the TF_ENCRYPTION environment variable,
+                          while migrating it to the newly-configured backend
+                          and encryption settings. Implies -migrate-state.
+
   -upgrade                Install the latest module and provider versions
                           allowed within configured constraints, overriding the
                           default behavior of selecting exactly the version
@@ -1357,6 +1553,14 @@ To initialize the configuration already in this working directory, omit the
 -from-module option.
 `
 
+const errInitBundleNotEmpty = `
+The working directory already contains files. The -from-bundle option requires
+an empty directory into which the configuration in the bundle will be placed.
+
+To initialize the configuration already in this working directory, omit the
+-from-bundle option.
+`
+
 const outputInitEmpty = `
 [reset][bold]OpenTofu initialized in an empty directory![reset]
 
@@ -1446,3 +1650,10 @@ Make at least one of the following changes to tell OpenTofu which provider to us
 - Verify that "%s" is the correct resource type name to use. Did you omit a prefix which would imply the correct provider?
 - Use a "provider" argument within this resource block to override OpenTofu's automatic selection of the local name "%s".
 `
+
+// providerPolicyErrorDetail is the detail message for a diagnostic about a
+// provider that the provider_policy block in the CLI configuration doesn't
+// permit.
+const providerPolicyErrorDetail = `The provider_policy block in the CLI configuration does not permit installing %s: %s.
+
+Contact the administrator responsible for your OpenTofu CLI configuration if you need to use this provider.`
@@ -34,6 +34,7 @@ import (
 	"github.com/opentofu/opentofu/internal/states"
 	"github.com/opentofu/opentofu/internal/states/statefile"
 	"github.com/opentofu/opentofu/internal/states/statemgr"
+	"github.com/opentofu/opentofu/internal/terminal"
 )
 
 func TestInit_empty(t *testing.T) {
@@ -604,6 +605,97 @@ func TestInit_backendMigrateWhileLocked(t *testing.T) {
 	}
 }
 
+func TestInit_backendMigrateEncryption(t *testing.T) {
+	setup := func(t *testing.T) (*InitCommand, *cli.MockUi, func(*testing.T) *terminal.TestOutput) {
+		td := t.TempDir()
+		testCopyDir(t, testFixturePath("init-backend-migrate-encryption"), td)
+		t.Chdir(td)
+
+		providerSource, close := newMockProviderSource(t, map[string][]string{
+			"hashicorp/test": {"1.2.3"},
+		})
+		t.Cleanup(close)
+
+		ui := new(cli.MockUi)
+		view, done := testView(t)
+		c := &InitCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(testProvider()),
+				ProviderSource:   providerSource,
+				Ui:               ui,
+				View:             view,
+			},
+		}
+
+		// Create some state encrypted with the old settings, so the
+		// backend has something to migrate from
+		oldEnc, diags := c.EncryptionFromFile(t.Context(), "old-encryption.hcl", &configs.Module{
+			StaticEvaluator: configs.NewStaticEvaluator(nil, configs.RootModuleCallForTesting()),
+		})
+		if diags.HasErrors() {
+			t.Fatal(diags.Err())
+		}
+		oldState := statemgr.NewFilesystem("old.tfstate", oldEnc.State())
+		if err := statemgr.WriteAndPersist(t.Context(), oldState, testState(), nil); err != nil {
+			t.Fatal(err)
+		}
+
+		return c, ui, done
+	}
+
+	t.Run("without previous encryption", func(t *testing.T) {
+		c, ui, _ := setup(t)
+
+		args := []string{"-migrate-state", "-force-copy"}
+		if code := c.Run(args); code == 0 {
+			t.Fatalf("expected nonzero exit code: %s", ui.OutputWriter.String())
+		}
+		if got, want := ui.ErrorWriter.String(), "decryption failed"; !strings.Contains(got, want) {
+			t.Errorf("expected error containing %q, got:\n%s", want, got)
+		}
+	})
+
+	t.Run("with previous encryption", func(t *testing.T) {
+		c, ui, _ := setup(t)
+
+		args := []string{"-migrate-encryption-from=old-encryption.hcl", "-force-copy"}
+		if code := c.Run(args); code != 0 {
+			t.Fatalf("bad: \n%s", ui.ErrorWriter.String())
+		}
+		if got, want := ui.OutputWriter.String(), `"default": migrated`; !strings.Contains(got, want) {
+			t.Errorf("missing migration summary %q in output:\n%s", want, got)
+		}
+
+		// The migrated state must be readable with the new settings only
+		newEnc, diags := c.Encryption(t.Context())
+		if diags.HasErrors() {
+			t.Fatal(diags.Err())
+		}
+		newState := statemgr.NewFilesystem("new.tfstate", newEnc.State())
+		if err := newState.RefreshState(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		if newState.State().Empty() {
+			t.Fatal("migrated state is empty")
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		c, _, done := setup(t)
+
+		args := []string{"-migrate-encryption-from=old-encryption.hcl", "-force-copy", "-json"}
+		code := c.Run(args)
+		got := done(t).Stdout()
+		if code != 0 {
+			t.Fatalf("bad: \n%s", got)
+		}
+		want := `"migration":{"source_type":"local","destination_type":"local","workspaces":[{"source":"default","destination":"default","status":"migrated"}]}`
+		if !strings.Contains(got, want) {
+			t.Errorf("missing migration summary %s in output:\n%s", want, got)
+		}
+	})
+}
+
 func TestInit_backendConfigFileChangeWithExistingState(t *testing.T) {
 	// Create a temporary working directory that is empty
 	td := t.TempDir()
@@ -38,6 +38,7 @@ import (
 	"github.com/opentofu/opentofu/internal/getmodules"
 	"github.com/opentofu/opentofu/internal/getproviders"
 	legacy "github.com/opentofu/opentofu/internal/legacy/tofu"
+	"github.com/opentofu/opentofu/internal/providercache"
 	"github.com/opentofu/opentofu/internal/providers"
 	"github.com/opentofu/opentofu/internal/provisioners"
 	"github.com/opentofu/opentofu/internal/states"
@@ -110,6 +111,10 @@ type Meta struct {
 	// into the given directory.
 	PluginCacheDir string
 
+	// ModuleCacheDir, if non-empty, enables caching of downloaded module
+	// packages into the given directory.
+	ModuleCacheDir string
+
 	// PluginCacheMayBreakDependencyLockFile is a temporary CLI configuration-based
 	// opt out for the behavior of only using the plugin cache dir if its
 	// contents match checksums recorded in the dependency lock file.
@@ -140,6 +145,14 @@ type Meta struct {
 	// unit testing.
 	ModulePackageFetcher *getmodules.PackageFetcher
 
+	// GetOCIRepositoryPushStore returns a client for publishing content into
+	// the given repository in an OCI registry, configured with whatever
+	// credentials the CLI configuration calls for.
+	//
+	// This is used only by "tofu providers mirror" when publishing into an
+	// OCI registry. Leaving this nil makes that option unavailable.
+	GetOCIRepositoryPushStore func(ctx context.Context, registryDomain, repositoryName string) (getproviders.OCIRepositoryPushStore, error)
+
 	// MakeRegistryHTTPClient is a function called each time a command needs
 	// an HTTP client that will be used to make requests to a module or
 	// provider registry.
@@ -182,6 +195,12 @@ type Meta struct {
 	// just trusting that someone else did it before running OpenTofu.
 	UnmanagedProviders map[addrs.Provider]*plugin.ReattachConfig
 
+	// ProviderPolicy restricts which providers and provider versions
+	// OpenTofu may install, as configured by the provider_policy block in
+	// the CLI configuration. Leaving this nil means that any provider the
+	// provider source offers can be installed.
+	ProviderPolicy *providercache.ProviderPolicy
+
 	// AllowExperimentalFeatures controls whether a command that embeds this
 	// Meta is permitted to make use of experimental OpenTofu features.
 	//
@@ -299,12 +318,20 @@ type Meta struct {
 	// state even if the remote and local OpenTofu versions don't match.
 	ignoreRemoteVersion bool
 
+	// Used by "tofu init -from-bundle" to forbid fetching module packages,
+	// because the bundle must already include all of them.
+	offlineModules bool
+
 	outputInJSON bool
 
 	// Used to cache the root module rootModuleCallCache and known variables.
 	// This helps prevent duplicate errors/warnings.
 	rootModuleCallCache *configs.StaticModuleCall
 	inputVariableCache  map[string]backend.UnparsedVariableValue
+
+	// Used to rebuild each provider with a working directory development
+	// override at most once per command, recording the result of each build.
+	devOverrideBuilds map[addrs.Provider]error
 }
 
 type testingOverrides struct {
@@ -64,6 +64,22 @@ type BackendOpts struct {
 	// ViewType will set console output format for the
 	// initialization operation (JSON or human-readable).
 	ViewType arguments.ViewType
+
+	// MigrateFromEncryption, if non-nil, is used instead of the encryption
+	// passed to Backend to read the state stored in the previously-configured
+	// backend when migrating it to a new one. This allows the backend and the
+	// encryption configuration to be changed in a single step.
+	MigrateFromEncryption encryption.StateEncryption
+}
+
+// migrationSourceEncryption returns the encryption to use for reading the
+// state that is being migrated away from, given the encryption enc that is
+// used for the newly-configured backend.
+func (o *BackendOpts) migrationSourceEncryption(enc encryption.StateEncryption) encryption.StateEncryption {
+	if o == nil || o.MigrateFromEncryption == nil {
+		return enc
+	}
+	return o.MigrateFromEncryption
 }
 
 // BackendWithRemoteTerraformVersion is a shared interface between the 'remote' and 'cloud' backends
@@ -116,6 +132,12 @@ func (m *Meta) Backend(ctx context.Context, opts *BackendOpts, enc encryption.St
 		}
 
 		log.Printf("[TRACE] Meta.Backend: instantiated backend of type %T", b)
+
+		b, backendDiags = m.backendWithMirror(ctx, b, opts.Config, enc)
+		diags = diags.Append(backendDiags)
+		if diags.HasErrors() {
+			return nil, diags
+		}
 	}
 
 	// Set up the CLI opts we pass into backends that support it.
@@ -918,7 +940,7 @@ func (m *Meta) backend_c_r_S(
 	}
 
 	// Initialize the configured backend
-	b, moreDiags := m.savedBackend(ctx, sMgr, enc)
+	b, moreDiags := m.savedBackend(ctx, sMgr, opts.migrationSourceEncryption(enc))
 	diags = diags.Append(moreDiags)
 	if moreDiags.HasErrors() {
 		return nil, diags
@@ -970,7 +992,7 @@ func (m *Meta) backend_C_r_s(ctx context.Context, c *configs.Backend, cHash int,
 	}
 
 	// Grab a purely local backend to get the local state if it exists
-	localB, localBDiags := m.Backend(ctx, &BackendOpts{ForceLocal: true, Init: true}, enc)
+	localB, localBDiags := m.Backend(ctx, &BackendOpts{ForceLocal: true, Init: true}, opts.migrationSourceEncryption(enc))
 	if localBDiags.HasErrors() {
 		diags = diags.Append(localBDiags)
 		return nil, diags
@@ -1185,7 +1207,7 @@ func (m *Meta) backend_C_r_S_changed(ctx context.Context, c *configs.Backend, cH
 	// state lives.
 	if cloudMode != cloud.ConfigChangeInPlace {
 		// Grab the existing backend
-		oldB, oldBDiags := m.savedBackend(ctx, sMgr, enc)
+		oldB, oldBDiags := m.savedBackend(ctx, sMgr, opts.migrationSourceEncryption(enc))
 		diags = diags.Append(oldBDiags)
 		if oldBDiags.HasErrors() {
 			return nil, diags
@@ -22,6 +22,7 @@ import (
 	"github.com/opentofu/opentofu/internal/command/arguments"
 	"github.com/opentofu/opentofu/internal/command/clistate"
 	"github.com/opentofu/opentofu/internal/command/views"
+	"github.com/opentofu/opentofu/internal/command/views/json"
 	"github.com/opentofu/opentofu/internal/encryption"
 	"github.com/opentofu/opentofu/internal/states"
 	"github.com/opentofu/opentofu/internal/states/statemgr"
@@ -38,6 +39,10 @@ type backendMigrateOpts struct {
 	sourceWorkspace      string
 	destinationWorkspace string
 	force                bool // if true, won't ask for confirmation
+
+	// results records the outcome for each workspace that migration was
+	// attempted for, in order, so that it can be summarized at the end.
+	results []json.StateMigrationWorkspace
 }
 
 // backendMigrateState handles migrating (copying) state from one backend
@@ -73,6 +78,8 @@ func (m *Meta) backendMigrateState(ctx context.Context, opts *backendMigrateOpts
 	opts.sourceWorkspace = backend.DefaultStateName
 	opts.destinationWorkspace = backend.DefaultStateName
 	opts.force = m.forceInitCopy
+	opts.results = nil
+	defer m.backendMigrateSummary(opts)
 
 	// Disregard remote OpenTofu version for the state source backend. If it's a
 	// Terraform Cloud remote backend, we don't care about the remote version,
@@ -265,6 +272,17 @@ func (m *Meta) backendMigrateState_S_s(ctx context.Context, opts *backendMigrate
 func (m *Meta) backendMigrateState_s_s(ctx context.Context, opts *backendMigrateOpts) error {
 	log.Printf("[INFO] backendMigrateState: single-to-single migrating %q workspace to %q workspace", opts.sourceWorkspace, opts.destinationWorkspace)
 
+	// The status is updated below before each successful return, so any
+	// other exit from this function is recorded as a failure.
+	result := json.StateMigrationWorkspace{
+		Source: opts.sourceWorkspace,
+		Status: json.StateMigrationFailed,
+	}
+	defer func() {
+		result.Destination = opts.destinationWorkspace
+		opts.results = append(opts.results, result)
+	}()
+
 	sourceState, err := opts.Source.StateMgr(ctx, opts.sourceWorkspace)
 	if err != nil {
 		return fmt.Errorf(strings.TrimSpace(
@@ -278,6 +296,7 @@ func (m *Meta) backendMigrateState_s_s(ctx context.Context, opts *backendMigrate
 	// Do not migrate workspaces without state.
 	if sourceState.State().Empty() {
 		log.Print("[TRACE] backendMigrateState: source workspace has empty state, so nothing to migrate")
+		result.Status = json.StateMigrationEmpty
 		return nil
 	}
 
@@ -336,10 +355,12 @@ func (m *Meta) backendMigrateState_s_s(ctx context.Context, opts *backendMigrate
 		if source != nil && destination != nil {
 			if sm1 == nil || sm2 == nil {
 				log.Print("[TRACE] backendMigrateState: both source and destination workspaces have no state, so no migration is needed")
+				result.Status = json.StateMigrationUnchanged
 				return nil
 			}
 			if sm1.StateSnapshotMeta().Lineage == sm2.StateSnapshotMeta().Lineage {
 				log.Printf("[TRACE] backendMigrateState: both source and destination workspaces have equal state with lineage %q, so no migration is needed", sm1.StateSnapshotMeta().Lineage)
+				result.Status = json.StateMigrationUnchanged
 				return nil
 			}
 		}
@@ -390,11 +411,13 @@ func (m *Meta) backendMigrateState_s_s(ctx context.Context, opts *backendMigrate
 	// No migration necessary
 	case source.Empty() && destination.Empty():
 		log.Print("[TRACE] backendMigrateState: both source and destination workspaces have empty state, so no migration is required")
+		result.Status = json.StateMigrationEmpty
 		return nil
 
 	// No migration necessary if we're inheriting state.
 	case source.Empty() && !destination.Empty():
 		log.Print("[TRACE] backendMigrateState: source workspace has empty state, so no migration is required")
+		result.Status = json.StateMigrationEmpty
 		return nil
 
 	// We have existing state moving into no state. Ask the user if
@@ -439,6 +462,7 @@ func (m *Meta) backendMigrateState_s_s(ctx context.Context, opts *backendMigrate
 		}
 		if !confirm {
 			log.Print("[TRACE] backendMigrateState: user cancelled at confirmation prompt, so aborting migration")
+			result.Status = json.StateMigrationDeclined
 			return nil
 		}
 	}
@@ -459,11 +483,42 @@ func (m *Meta) backendMigrateState_s_s(ctx context.Context, opts *backendMigrate
 		return fmt.Errorf(strings.TrimSpace(errBackendStateCopy),
 			opts.SourceType, opts.DestinationType, err)
 	}
+	statemgr.Flush(destinationState)
 
 	// And we're done.
+	result.Status = json.StateMigrationMigrated
 	return nil
 }
 
+// backendMigrateSummary reports the outcome of migrating each workspace
+// recorded in opts, if any were considered at all.
+func (m *Meta) backendMigrateSummary(opts *backendMigrateOpts) {
+	if len(opts.results) == 0 {
+		return
+	}
+	summary := &json.StateMigrationSummary{
+		SourceType:      opts.SourceType,
+		DestinationType: opts.DestinationType,
+		Workspaces:      opts.results,
+	}
+
+	if ui, ok := m.Ui.(*WrappedUi); ok && ui.outputInJSON {
+		ui.jsonView.StateMigration(summary)
+		return
+	}
+
+	var buf strings.Builder
+	buf.WriteString(m.Colorize().Color("[reset][bold]State migration summary:[reset]\n"))
+	for _, ws := range summary.Workspaces {
+		if ws.Destination != ws.Source {
+			fmt.Fprintf(&buf, "  - %q => %q: %s\n", ws.Source, ws.Destination, ws.Status)
+		} else {
+			fmt.Fprintf(&buf, "  - %q: %s\n", ws.Source, ws.Status)
+		}
+	}
+	m.Ui.Output(strings.TrimRight(buf.String(), "\n"))
+}
+
 func (m *Meta) backendMigrateEmptyConfirm(source, destination statemgr.Full, opts *backendMigrateOpts) (bool, error) {
 	var inputOpts *tofu.InputOpts
 	if opts.DestinationType == "cloud" {
@@ -0,0 +1,160 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/opentofu/opentofu/internal/backend"
+	backendInit "github.com/opentofu/opentofu/internal/backend/init"
+	backendLocal "github.com/opentofu/opentofu/internal/backend/local"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/encryption"
+	"github.com/opentofu/opentofu/internal/states/statemgr"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// mirroredBackend wraps a state storage backend so that the state managers
+// it returns also write every persisted snapshot to the corresponding
+// workspace of a mirror backend.
+type mirroredBackend struct {
+	backend.Backend
+
+	mirror  backend.Backend
+	async   bool
+	onError func(error)
+}
+
+var _ backend.Backend = (*mirroredBackend)(nil)
+var _ backend.CLI = (*mirroredBackend)(nil)
+
+// CLIInit forwards the CLI options to the primary backend if it accepts
+// them, which is the case for the local backend.
+func (b *mirroredBackend) CLIInit(opts *backend.CLIOpts) error {
+	if cli, ok := b.Backend.(backend.CLI); ok {
+		return cli.CLIInit(opts)
+	}
+	return nil
+}
+
+func (b *mirroredBackend) StateMgr(ctx context.Context, workspace string) (statemgr.Full, error) {
+	primary, err := b.Backend.StateMgr(ctx, workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	mirror, err := b.mirror.StateMgr(ctx, workspace)
+	if err != nil {
+		// The mirror is only for disaster recovery, so we don't let it
+		// block use of the primary backend.
+		b.onError(fmt.Errorf("failed to load mirror state for workspace %q: %w", workspace, err))
+		return primary, nil
+	}
+
+	return &mirroredStateMgr{statemgr.NewMirrored(primary, mirror, b.async, b.onError)}, nil
+}
+
+// mirroredStateMgr adds to statemgr.Mirrored the optional interfaces defined
+// by the local backend, which the statemgr package can't refer to, so that
+// the primary state manager's implementations of them still take effect.
+type mirroredStateMgr struct {
+	*statemgr.Mirrored
+}
+
+var _ backendLocal.IntermediateStateConditionalPersister = (*mirroredStateMgr)(nil)
+
+// ShouldPersistIntermediateState implements
+// local.IntermediateStateConditionalPersister by deferring to the primary
+// state manager.
+func (s *mirroredStateMgr) ShouldPersistIntermediateState(info *backendLocal.IntermediateStatePersistInfo) bool {
+	if p, ok := s.Mirrored.Full.(backendLocal.IntermediateStateConditionalPersister); ok {
+		return p.ShouldPersistIntermediateState(info)
+	}
+	return backendLocal.DefaultIntermediateStatePersistRule(info)
+}
+
+// backendWithMirror wraps the given backend in a mirroredBackend if the
+// given backend configuration includes a mirror block, or returns it
+// unchanged otherwise.
+func (m *Meta) backendWithMirror(ctx context.Context, b backend.Backend, c *configs.Backend, enc encryption.StateEncryption) (backend.Backend, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	if b == nil || c == nil || c.Mirror == nil {
+		return b, diags
+	}
+
+	// The local backend is the only enhanced backend that stores state
+	// itself, and it's able to act as the state storage for another
+	// instance of itself, so that's what happens when mirroring it.
+	_, isLocal := b.(*backendLocal.Local)
+	if _, isEnhanced := b.(backend.Enhanced); isEnhanced && !isLocal {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported backend mirror",
+			Detail:   fmt.Sprintf("The %q backend manages its own state storage and so cannot be mirrored.", c.Type),
+			Subject:  c.Mirror.DeclRange.Ptr(),
+		})
+		return nil, diags
+	}
+
+	mirror, moreDiags := m.backendInitMirror(ctx, c, enc)
+	diags = diags.Append(moreDiags)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+
+	log.Printf("[TRACE] Meta.Backend: mirroring state to %q backend (async: %t)", c.Mirror.Type, c.Mirror.Async)
+	return &mirroredBackend{
+		Backend: b,
+		mirror:  mirror,
+		async:   c.Mirror.Async,
+		onError: func(err error) {
+			m.showDiagnostics(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Failed to mirror state",
+				fmt.Sprintf("The state was saved to the %q backend, but could not be copied to the %q mirror backend: %s.", c.Type, c.Mirror.Type, err),
+			))
+		},
+	}, diags
+}
+
+// backendInitMirror instantiates and configures the mirror backend declared
+// in the given backend configuration, which must have a mirror block.
+//
+// The mirror shares the primary backend's state encryption, so that
+// snapshots copied between the two remain readable.
+func (m *Meta) backendInitMirror(ctx context.Context, c *configs.Backend, enc encryption.StateEncryption) (backend.Backend, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	f := backendInit.Backend(c.Mirror.Type)
+	if f == nil {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid backend mirror type",
+			Detail:   fmt.Sprintf("There is no backend type named %q.", c.Mirror.Type),
+			Subject:  c.Mirror.TypeRange.Ptr(),
+		})
+		return nil, diags
+	}
+	b := f(enc)
+
+	configVal, hclDiags := c.DecodeMirror(ctx, b.ConfigSchema())
+	diags = diags.Append(hclDiags)
+	if hclDiags.HasErrors() {
+		return nil, diags
+	}
+
+	newVal, validateDiags := b.PrepareConfig(configVal)
+	diags = diags.Append(validateDiags.InConfigBody(c.Mirror.Config, ""))
+	if validateDiags.HasErrors() {
+		return nil, diags
+	}
+
+	configureDiags := b.Configure(ctx, newVal)
+	diags = diags.Append(configureDiags.InConfigBody(c.Mirror.Config, ""))
+	return b, diags
+}
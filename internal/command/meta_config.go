@@ -26,6 +26,7 @@ import (
 	"github.com/opentofu/opentofu/internal/configs/configschema"
 	"github.com/opentofu/opentofu/internal/httpclient"
 	"github.com/opentofu/opentofu/internal/initwd"
+	"github.com/opentofu/opentofu/internal/modulecache"
 	"github.com/opentofu/opentofu/internal/registry"
 	"github.com/opentofu/opentofu/internal/tfdiags"
 	"github.com/opentofu/opentofu/internal/tofu"
@@ -275,7 +276,11 @@ func (m *Meta) loadHCLFile(filename string) (hcl.Body, tfdiags.Diagnostics) {
 // can then be relayed to the end-user. The uiModuleInstallHooks type in
 // this package has a reasonable implementation for displaying notifications
 // via a provided cli.Ui.
-func (m *Meta) installModules(ctx context.Context, rootDir, testsDir string, upgrade, installErrsOnly bool, hooks initwd.ModuleInstallHooks) (abort bool, diags tfdiags.Diagnostics) {
+//
+// The modules are installed as recorded in the dependency lock file, which is
+// then updated with the modules that were installed unless readonlyLocks is
+// set.
+func (m *Meta) installModules(ctx context.Context, rootDir, testsDir string, upgrade, installErrsOnly, readonlyLocks bool, hooks initwd.ModuleInstallHooks) (abort bool, diags tfdiags.Diagnostics) {
 	rootDir = m.normalizePath(rootDir)
 
 	err := os.MkdirAll(m.modulesDir(), os.ModePerm)
@@ -291,6 +296,17 @@ func (m *Meta) installModules(ctx context.Context, rootDir, testsDir string, upg
 	}
 
 	inst := initwd.NewModuleInstaller(m.modulesDir(), loader, m.registryClient(ctx), m.ModulePackageFetcher)
+	if cacheDir := m.moduleGlobalCacheDir(); cacheDir != nil {
+		inst.SetGlobalCacheDir(cacheDir)
+	}
+	inst.SetOffline(m.offlineModules)
+
+	locks, lockDiags := m.lockedDependencies()
+	diags = diags.Append(lockDiags)
+	if lockDiags.HasErrors() {
+		return true, diags
+	}
+	inst.SetDependencyLocks(locks)
 
 	call, vDiags := m.rootModuleCall(ctx, rootDir)
 	diags = diags.Append(vDiags)
@@ -307,9 +323,35 @@ func (m *Meta) installModules(ctx context.Context, rootDir, testsDir string, upg
 		return true, diags
 	}
 
+	if newLocks := inst.DependencyLocks(); !moreDiags.HasErrors() && !newLocks.Equal(locks) {
+		if readonlyLocks {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Module lock file not updated",
+				`Changes to the module selections were detected, but not saved in the .terraform.lock.hcl file. To record these selections, run "tofu init" without the "-lockfile=readonly" flag.`,
+			))
+		} else {
+			diags = diags.Append(m.replaceLockedDependencies(ctx, newLocks))
+		}
+	}
+
 	return false, diags
 }
 
+// moduleGlobalCacheDir returns an object representing the shared global
+// module cache directory, used as a read-through cache when installing
+// remote module packages.
+//
+// This function may return nil, in which case there is no global cache
+// configured and packages should be fetched directly into the modules
+// directory of the working directory.
+func (m *Meta) moduleGlobalCacheDir() *modulecache.Dir {
+	if m.ModuleCacheDir == "" {
+		return nil // cache disabled
+	}
+	return modulecache.NewDir(m.ModuleCacheDir)
+}
+
 // initDirFromModule initializes the given directory (which should be
 // pre-verified as empty by the caller) by copying the source code from the
 // given module address.
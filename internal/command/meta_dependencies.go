@@ -143,7 +143,7 @@ func (m *Meta) annotateDependencyLocksWithOverrides(ret *depsfile.Locks) *depsfi
 		return ret
 	}
 
-	for addr := range m.ProviderDevOverrides {
+	for addr := range m.providerDevOverrides() {
 		log.Printf("[DEBUG] Provider %s is overridden by dev_overrides", addr)
 		ret.SetProviderOverridden(addr)
 	}
@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/opentofu/opentofu/internal/configs"
 	"github.com/opentofu/opentofu/internal/encryption"
@@ -58,3 +59,35 @@ func (m *Meta) EncryptionFromModule(ctx context.Context, module *configs.Module)
 
 	return enc, diags
 }
+
+// EncryptionFromFile loads an encryption configuration from the given file,
+// which uses the same format as the TF_ENCRYPTION environment variable. Any
+// references in it are evaluated against the given module.
+//
+// A file containing no configuration at all results in encryption being
+// disabled.
+func (m *Meta) EncryptionFromFile(ctx context.Context, filename string, module *configs.Module) (encryption.Encryption, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read encryption configuration",
+			fmt.Sprintf("Could not read the encryption configuration from %s: %s.", filename, err),
+		))
+	}
+	if strings.TrimSpace(string(src)) == "" {
+		return encryption.Disabled(), diags
+	}
+
+	cfg, cfgDiags := config.LoadConfigFromString(filename, string(src))
+	diags = diags.Append(cfgDiags)
+	if cfgDiags.HasErrors() {
+		return nil, diags
+	}
+
+	enc, encDiags := encryption.New(ctx, encryption.DefaultRegistry, cfg, module.StaticEvaluator)
+	diags = diags.Append(encDiags)
+	return enc, diags
+}
@@ -13,12 +13,15 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 
 	plugin "github.com/hashicorp/go-plugin"
 
 	"github.com/opentofu/opentofu/internal/addrs"
 	terraformProvider "github.com/opentofu/opentofu/internal/builtin/providers/tf"
+	"github.com/opentofu/opentofu/internal/command/workdir"
+	"github.com/opentofu/opentofu/internal/depsfile"
 	"github.com/opentofu/opentofu/internal/getproviders"
 	"github.com/opentofu/opentofu/internal/logging"
 	tfplugin "github.com/opentofu/opentofu/internal/plugin"
@@ -82,6 +85,7 @@ func (m *Meta) providerInstallerCustomSource(source getproviders.Source) *provid
 		unmanagedProviderTypes[ty] = struct{}{}
 	}
 	inst.SetUnmanagedProviderTypes(unmanagedProviderTypes)
+	inst.SetProviderPolicy(m.ProviderPolicy)
 	return inst
 }
 
@@ -121,6 +125,43 @@ func (m *Meta) providerLocalCacheDir() *providercache.Dir {
 	return providercache.NewDir(dir)
 }
 
+// recordProviderAuthentications updates the provider authentication results
+// recorded in the working directory to match the given provider selections,
+// using the given results for any providers that were just installed.
+//
+// Existing results are retained for providers whose selected version hasn't
+// changed, because the installer doesn't authenticate packages that are
+// already installed.
+func (m *Meta) recordProviderAuthentications(locks *depsfile.Locks, authResults map[addrs.Provider]*getproviders.PackageAuthenticationResult) error {
+	m.fixupMissingWorkingDir()
+	prev, err := m.WorkingDir.ProviderAuthentications()
+	if err != nil {
+		// If the existing file is corrupt then we'll just replace it,
+		// retaining only what we've learned during this run.
+		log.Printf("[WARN] Ignoring invalid provider authentication results: %s", err)
+		prev = nil
+	}
+
+	next := make(map[string]workdir.ProviderAuthentication)
+	for provider, lock := range locks.AllProviders() {
+		key := provider.String()
+		version := lock.Version().String()
+		if result, ok := authResults[provider]; ok && result != nil {
+			next[key] = workdir.ProviderAuthentication{
+				Version:    version,
+				Result:     result.String(),
+				GPGKeyIDs:  result.GPGKeyIDs(),
+				OCISigners: result.OCISigners(),
+			}
+			continue
+		}
+		if old, ok := prev[key]; ok && old.Version == version {
+			next[key] = old
+		}
+	}
+	return m.WorkingDir.SetProviderAuthentications(next)
+}
+
 // providerGlobalCacheDir returns an object representing the shared global
 // provider cache directory, used as a read-through cache when installing
 // new provider plugin packages.
@@ -172,22 +213,7 @@ func (m *Meta) providerInstallSource() getproviders.Source {
 // may differ from what's expected due to the development overrides. For
 // other commands, providerDevOverrideRuntimeWarnings should be used.
 func (m *Meta) providerDevOverrideInitWarnings() tfdiags.Diagnostics {
-	if len(m.ProviderDevOverrides) == 0 {
-		return nil
-	}
-	var detailMsg strings.Builder
-	detailMsg.WriteString("The following provider development overrides are set in the CLI configuration:\n")
-	for addr, path := range m.ProviderDevOverrides {
-		detailMsg.WriteString(fmt.Sprintf(" - %s in %s\n", addr.ForDisplay(), path))
-	}
-	detailMsg.WriteString("\nSkip tofu init when using provider development overrides. It is not necessary and may error unexpectedly.")
-	return tfdiags.Diagnostics{
-		tfdiags.Sourceless(
-			tfdiags.Warning,
-			"Provider development overrides are in effect",
-			detailMsg.String(),
-		),
-	}
+	return m.providerDevOverrideWarnings("Skip tofu init when using provider development overrides. It is not necessary and may error unexpectedly.")
 }
 
 // providerDevOverrideRuntimeWarnings returns a diagnostics that contains at
@@ -204,22 +230,62 @@ func (m *Meta) providerDevOverrideInitWarnings() tfdiags.Diagnostics {
 // See providerDevOverrideInitWarnings for warnings specific to the init
 // command.
 func (m *Meta) providerDevOverrideRuntimeWarnings() tfdiags.Diagnostics {
-	if len(m.ProviderDevOverrides) == 0 {
-		return nil
+	return m.providerDevOverrideWarnings("The behavior may therefore not match any released version of the provider and applying changes may cause the state to become incompatible with published releases.")
+}
+
+// providerDevOverrideWarnings is the common implementation of
+// providerDevOverrideInitWarnings and providerDevOverrideRuntimeWarnings,
+// which differ only in the final paragraph of the warning message.
+func (m *Meta) providerDevOverrideWarnings(advice string) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	wdOverrides, err := m.workingDirProviderDevOverrides()
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Invalid working directory provider development overrides",
+			fmt.Sprintf("OpenTofu ignored the provider development overrides for this working directory: %s.\n\nUse \"tofu providers override\" to reconfigure them.", err),
+		))
 	}
-	var detailMsg strings.Builder
-	detailMsg.WriteString("The following provider development overrides are set in the CLI configuration:\n")
+
+	var cliLines, wdLines []string
 	for addr, path := range m.ProviderDevOverrides {
-		detailMsg.WriteString(fmt.Sprintf(" - %s in %s\n", addr.ForDisplay(), path))
+		if _, ok := wdOverrides[addr]; ok {
+			continue // the working directory override takes precedence
+		}
+		cliLines = append(cliLines, fmt.Sprintf(" - %s in %s\n", addr.ForDisplay(), path))
 	}
-	detailMsg.WriteString("\nThe behavior may therefore not match any released version of the provider and applying changes may cause the state to become incompatible with published releases.")
-	return tfdiags.Diagnostics{
-		tfdiags.Sourceless(
-			tfdiags.Warning,
-			"Provider development overrides are in effect",
-			detailMsg.String(),
-		),
+	for addr, override := range wdOverrides {
+		line := fmt.Sprintf(" - %s in %s", addr.ForDisplay(), override.Dir)
+		if override.BuildCommand != "" {
+			line += fmt.Sprintf(", rebuilt using %q", override.BuildCommand)
+		}
+		wdLines = append(wdLines, line+"\n")
+	}
+	if len(cliLines) == 0 && len(wdLines) == 0 {
+		return diags
 	}
+	sort.Strings(cliLines)
+	sort.Strings(wdLines)
+
+	var detailMsg strings.Builder
+	if len(cliLines) != 0 {
+		detailMsg.WriteString("The following provider development overrides are set in the CLI configuration:\n")
+		detailMsg.WriteString(strings.Join(cliLines, ""))
+	}
+	if len(wdLines) != 0 {
+		if len(cliLines) != 0 {
+			detailMsg.WriteString("\n")
+		}
+		detailMsg.WriteString("The following provider development overrides are set for this working directory:\n")
+		detailMsg.WriteString(strings.Join(wdLines, ""))
+		detailMsg.WriteString("\nUse \"tofu providers override unset\" to remove them when you have finished testing.\n")
+	}
+	detailMsg.WriteString("\n" + advice)
+	return diags.Append(tfdiags.Sourceless(
+		tfdiags.Warning,
+		"Provider development overrides are in effect",
+		detailMsg.String(),
+	))
 }
 
 // providerFactories uses the selections made previously by an installer in
@@ -273,7 +339,7 @@ func (m *Meta) providerFactories() (map[addrs.Provider]providers.Factory, error)
 	// Unmanaged providers take precedence over overridden providers because
 	// overrides are typically a "session-level" setting while unmanaged
 	// providers are typically scoped to a single unattended command.
-	devOverrideProviders := m.ProviderDevOverrides
+	devOverrideProviders := m.providerDevOverrides()
 	unmanagedProviders := m.UnmanagedProviders
 
 	factories := make(map[addrs.Provider]providers.Factory, len(providerLocks)+len(internalFactories)+len(unmanagedProviders))
@@ -326,7 +392,17 @@ func (m *Meta) providerFactories() (map[addrs.Provider]providers.Factory, error)
 		}
 		factories[provider] = providerFactory(cached)
 	}
+	// Overrides recorded for the working directory can also ask us to
+	// rebuild the provider from source before we use it.
+	wdOverrides, _ := m.workingDirProviderDevOverrides()
 	for provider, localDir := range devOverrideProviders {
+		if override, ok := wdOverrides[provider]; ok && override.BuildCommand != "" {
+			if err := m.rebuildDevOverrideProviderOnce(provider, override); err != nil {
+				errs[provider] = err
+				factories[provider] = providerFactoryError(err)
+				continue
+			}
+		}
 		factories[provider] = devOverrideProviderFactory(provider, localDir)
 	}
 	for provider, reattach := range unmanagedProviders {
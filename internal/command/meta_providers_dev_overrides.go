@@ -0,0 +1,181 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/command/workdir"
+	"github.com/opentofu/opentofu/internal/getproviders"
+	"github.com/opentofu/opentofu/internal/providercache"
+)
+
+// providerDevOverrides returns the provider development overrides in effect
+// for the current working directory, which combines the dev_overrides from
+// the CLI configuration with any overrides recorded for the working directory
+// by "tofu providers override". The working directory overrides take
+// precedence.
+//
+// Callers must not modify the returned map.
+func (m *Meta) providerDevOverrides() map[addrs.Provider]getproviders.PackageLocalDir {
+	wdOverrides, err := m.workingDirProviderDevOverrides()
+	if err != nil {
+		// providerDevOverrideWarnings reports this to the user on the
+		// commands where overrides are most relevant.
+		log.Printf("[WARN] Ignoring working directory provider development overrides: %s", err)
+	}
+	if len(wdOverrides) == 0 {
+		return m.ProviderDevOverrides
+	}
+
+	ret := make(map[addrs.Provider]getproviders.PackageLocalDir, len(m.ProviderDevOverrides)+len(wdOverrides))
+	for addr, dir := range m.ProviderDevOverrides {
+		ret[addr] = dir
+	}
+	for addr, override := range wdOverrides {
+		ret[addr] = getproviders.PackageLocalDir(override.Dir)
+	}
+	return ret
+}
+
+// workingDirProviderDevOverrides returns the provider development overrides
+// recorded for the current working directory by "tofu providers override".
+//
+// If the recorded overrides are invalid then the result is nil along with
+// an error.
+func (m *Meta) workingDirProviderDevOverrides() (map[addrs.Provider]workdir.ProviderDevOverride, error) {
+	m.fixupMissingWorkingDir()
+	raw, err := m.WorkingDir.ProviderDevOverrides()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Join(m.WorkingDir.DataDir(), workdir.ProviderDevOverridesFilename), err)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	ret := make(map[addrs.Provider]workdir.ProviderDevOverride, len(raw))
+	for rawAddr, override := range raw {
+		addr, diags := addrs.ParseProviderSourceString(rawAddr)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid provider source address %q: %w", rawAddr, diags.Err())
+		}
+		ret[addr] = override
+	}
+	return ret, nil
+}
+
+// rebuildDevOverrideProviderOnce calls rebuildDevOverrideProvider the first
+// time it's called for each provider, and then returns the same result for
+// that provider for the rest of the command. Some commands request the
+// provider factories many times, and we must not check the sources for
+// changes, or even rebuild the provider, on each of those requests.
+func (m *Meta) rebuildDevOverrideProviderOnce(provider addrs.Provider, override workdir.ProviderDevOverride) error {
+	if err, done := m.devOverrideBuilds[provider]; done {
+		return err
+	}
+	err := rebuildDevOverrideProvider(provider, override, m.Ui)
+	if m.devOverrideBuilds == nil {
+		m.devOverrideBuilds = make(map[addrs.Provider]error)
+	}
+	m.devOverrideBuilds[provider] = err
+	return err
+}
+
+// rebuildDevOverrideProvider runs the build command of the given working
+// directory override if the provider's executable is missing or if any file
+// in the override's source directory is newer than the executable.
+//
+// The build command is announced to the given UI, if any, before it runs.
+func rebuildDevOverrideProvider(provider addrs.Provider, override workdir.ProviderDevOverride, ui cli.Ui) error {
+	sourceDir := override.SourceDir
+	if sourceDir == "" {
+		sourceDir = override.Dir
+	}
+
+	cached := &providercache.CachedProvider{
+		Provider:   provider,
+		Version:    getproviders.UnspecifiedVersion,
+		PackageDir: override.Dir,
+	}
+	var builtAt time.Time
+	if exePath, err := cached.ExecutableFile(); err == nil {
+		if info, err := os.Stat(exePath); err == nil {
+			builtAt = info.ModTime()
+		}
+	}
+	if !builtAt.IsZero() {
+		changedAt, err := newestSourceModTime(sourceDir)
+		if err != nil {
+			return fmt.Errorf("failed to check the sources of %s for changes: %w", provider, err)
+		}
+		if !changedAt.After(builtAt) {
+			log.Printf("[TRACE] Provider %s is up to date with its sources in %s", provider, sourceDir)
+			return nil
+		}
+	}
+
+	log.Printf("[INFO] Rebuilding provider %s in %s using %q", provider, sourceDir, override.BuildCommand)
+	if ui != nil {
+		// This goes to stderr, so that it can't interfere with any
+		// machine-readable output of the command.
+		ui.Warn(fmt.Sprintf("Rebuilding provider %s in %s: %s", provider, sourceDir, override.BuildCommand))
+	}
+	var cmdargs []string
+	if runtime.GOOS == "windows" {
+		cmdargs = []string{"cmd", "/C"}
+	} else {
+		cmdargs = []string{"/bin/sh", "-c"}
+	}
+	cmdargs = append(cmdargs, override.BuildCommand)
+	cmd := exec.Command(cmdargs[0], cmdargs[1:]...)
+	cmd.Dir = sourceDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("build command %q for %s failed: %w\n\n%s", override.BuildCommand, provider, err, strings.TrimSpace(string(out)))
+	}
+	log.Printf("[DEBUG] Output from rebuilding provider %s:\n%s", provider, out)
+	return nil
+}
+
+// newestSourceModTime returns the most recent modification time of any
+// file under the given directory, ignoring hidden files and directories and
+// any provider executables that are built into the directory.
+func newestSourceModTime(dir string) (time.Time, error) {
+	var ret time.Time
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if path != dir && strings.HasPrefix(name, ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || strings.HasPrefix(name, "terraform-provider-") {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(ret) {
+			ret = info.ModTime()
+		}
+		return nil
+	})
+	return ret, err
+}
@@ -0,0 +1,167 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/command/workdir"
+)
+
+func TestRebuildDevOverrideProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the build command in this test requires a POSIX shell")
+	}
+
+	provider := addrs.MustParseProviderSourceString("example.com/foo/bar")
+	srcDir := t.TempDir()
+	binDir := t.TempDir()
+	srcFile := filepath.Join(srcDir, "main.go")
+	exeFile := filepath.Join(binDir, "terraform-provider-bar")
+	if err := os.WriteFile(srcFile, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	override := workdir.ProviderDevOverride{
+		Dir:          binDir,
+		SourceDir:    srcDir,
+		BuildCommand: "echo built >>" + exeFile,
+	}
+	builds := func() int {
+		t.Helper()
+		raw, err := os.ReadFile(exeFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Count(string(raw), "built")
+	}
+
+	// The executable doesn't exist yet, so it must be built.
+	if err := rebuildDevOverrideProvider(provider, override, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := builds(); got != 1 {
+		t.Fatalf("wrong number of builds %d after first call", got)
+	}
+
+	// The executable is newer than the sources, so nothing happens.
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(srcFile, past, past); err != nil {
+		t.Fatal(err)
+	}
+	if err := rebuildDevOverrideProvider(provider, override, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := builds(); got != 1 {
+		t.Fatalf("wrong number of builds %d with unchanged sources", got)
+	}
+
+	// Changes to hidden files are ignored.
+	if err := os.WriteFile(filepath.Join(srcDir, ".notes"), []byte("notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(srcDir, ".notes"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := rebuildDevOverrideProvider(provider, override, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := builds(); got != 1 {
+		t.Fatalf("wrong number of builds %d after changing a hidden file", got)
+	}
+
+	// A changed source file causes a rebuild.
+	if err := os.Chtimes(srcFile, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := rebuildDevOverrideProvider(provider, override, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := builds(); got != 2 {
+		t.Fatalf("wrong number of builds %d after changing the sources", got)
+	}
+
+	override.BuildCommand = "echo oops >&2; exit 1"
+	if err := os.Chtimes(srcFile, future.Add(time.Hour), future.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	err := rebuildDevOverrideProvider(provider, override, nil)
+	if err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Fatalf("wrong error: %v", err)
+	}
+}
+
+func TestMetaRebuildDevOverrideProviderOnce(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the build command in this test requires a POSIX shell")
+	}
+
+	provider := addrs.MustParseProviderSourceString("example.com/foo/bar")
+	srcDir := t.TempDir()
+	binDir := t.TempDir()
+	srcFile := filepath.Join(srcDir, "main.go")
+	exeFile := filepath.Join(binDir, "terraform-provider-bar")
+	if err := os.WriteFile(srcFile, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	override := workdir.ProviderDevOverride{
+		Dir:          binDir,
+		SourceDir:    srcDir,
+		BuildCommand: "echo built >>" + exeFile,
+	}
+
+	ui := cli.NewMockUi()
+	m := &Meta{Ui: ui}
+	if err := m.rebuildDevOverrideProviderOnce(provider, override); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ui.ErrorWriter.String(), override.BuildCommand; !strings.Contains(got, want) {
+		t.Errorf("build command was not announced\ngot:  %s\nwant substring: %s", got, want)
+	}
+
+	// Even if the sources change, the provider is built only once per
+	// command.
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(srcFile, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.rebuildDevOverrideProviderOnce(provider, override); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(exeFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(raw), "built"); got != 1 {
+		t.Errorf("wrong number of builds %d; want 1", got)
+	}
+
+	// The result of a failed build is remembered too.
+	other := addrs.MustParseProviderSourceString("example.com/foo/baz")
+	failing := workdir.ProviderDevOverride{
+		Dir:          t.TempDir(),
+		SourceDir:    srcDir,
+		BuildCommand: "echo failed >>" + exeFile + "; exit 1",
+	}
+	for range 2 {
+		if err := m.rebuildDevOverrideProviderOnce(other, failing); err == nil {
+			t.Fatal("unexpected success")
+		}
+	}
+	raw, err = os.ReadFile(exeFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(raw), "failed"); got != 1 {
+		t.Errorf("wrong number of failed builds %d; want 1", got)
+	}
+}
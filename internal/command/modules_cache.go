@@ -0,0 +1,36 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// ModulesCacheCommand is a Command implementation that just shows help for
+// the subcommands nested below it.
+type ModulesCacheCommand struct {
+	Meta
+}
+
+func (c *ModulesCacheCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *ModulesCacheCommand) Help() string {
+	helpText := `
+Usage: tofu [global options] modules cache <subcommand> [options] [args]
+
+  This command has subcommands for managing the global module cache
+  directory, which is enabled by the module_cache_dir setting in the CLI
+  configuration or by the TF_MODULE_CACHE_DIR environment variable.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ModulesCacheCommand) Synopsis() string {
+	return "Manage the global module cache"
+}
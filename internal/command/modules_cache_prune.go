@@ -0,0 +1,99 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// ModulesCachePruneCommand is a Command implementation that implements the
+// "tofu modules cache prune" command, which removes module packages from the
+// global module cache that haven't been used recently.
+type ModulesCachePruneCommand struct {
+	Meta
+}
+
+func (c *ModulesCachePruneCommand) Synopsis() string {
+	return "Remove unused packages from the global module cache"
+}
+
+func (c *ModulesCachePruneCommand) Run(args []string) int {
+	var maxAge time.Duration
+
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.defaultFlagSet("modules cache prune")
+	cmdFlags.DurationVar(&maxAge, "max-age", 30*24*time.Hour, "maximum age")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return 1
+	}
+	if len(cmdFlags.Args()) != 0 {
+		c.Ui.Error("The modules cache prune command expects no positional arguments.")
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	var diags tfdiags.Diagnostics
+
+	cacheDir := c.moduleGlobalCacheDir()
+	if cacheDir == nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"No module cache directory",
+			"There is no global module cache to prune. To enable the module cache, set module_cache_dir in the CLI configuration or set the TF_MODULE_CACHE_DIR environment variable.",
+		))
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	removed, err := cacheDir.Prune(maxAge)
+	for _, key := range removed {
+		c.Ui.Output(fmt.Sprintf("- Removed %s", key))
+	}
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to prune module cache",
+			fmt.Sprintf("Some packages could not be removed from the module cache in %s: %s.", cacheDir.BasePath(), err),
+		))
+	}
+	c.showDiagnostics(diags)
+	if diags.HasErrors() {
+		return 1
+	}
+
+	switch len(removed) {
+	case 0:
+		c.Ui.Output("No module packages were removed from the cache.")
+	case 1:
+		c.Ui.Output("Removed 1 module package from the cache.")
+	default:
+		c.Ui.Output(fmt.Sprintf("Removed %d module packages from the cache.", len(removed)))
+	}
+	return 0
+}
+
+func (c *ModulesCachePruneCommand) Help() string {
+	return `
+Usage: tofu [global options] modules cache prune [options]
+
+  Removes module packages from the global module cache that have not been
+  used by "tofu init" or "tofu get" in any working directory recently.
+  Packages that are currently in use by another OpenTofu process are not
+  removed.
+
+  Modules already installed in working directories are not affected, but
+  will be fetched again from their origin if they are reinstalled.
+
+Options:
+
+  -max-age=DURATION  Remove packages that have not been used for at least
+                     the given duration, such as "24h". Defaults to 720h
+                     (30 days).
+`
+}
@@ -0,0 +1,69 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/opentofu/opentofu/internal/modulecache"
+)
+
+func TestModulesCachePrune(t *testing.T) {
+	cacheDir := t.TempDir()
+	dir := modulecache.NewDir(cacheDir)
+	err := dir.Install(t.Context(), "example.com/foo 1.0.0", filepath.Join(t.TempDir(), "foo"), func(_ context.Context, pkgDir string) error {
+		return os.MkdirAll(pkgDir, 0755)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ui := cli.NewMockUi()
+	c := &ModulesCachePruneCommand{
+		Meta: Meta{
+			Ui:             ui,
+			ModuleCacheDir: cacheDir,
+		},
+	}
+
+	// The package was just used, so it's retained with the default age.
+	if code := c.Run(nil); code != 0 {
+		t.Fatalf("wrong exit code %d\n%s", code, ui.ErrorWriter.String())
+	}
+	if got, want := ui.OutputWriter.String(), "No module packages were removed"; !strings.Contains(got, want) {
+		t.Errorf("wrong output\ngot: %s\nwant substring: %s", got, want)
+	}
+
+	ui.OutputWriter.Reset()
+	if code := c.Run([]string{"-max-age=0s"}); code != 0 {
+		t.Fatalf("wrong exit code %d\n%s", code, ui.ErrorWriter.String())
+	}
+	got := ui.OutputWriter.String()
+	for _, want := range []string{"- Removed example.com/foo 1.0.0", "Removed 1 module package from the cache."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("wrong output\ngot: %s\nwant substring: %s", got, want)
+		}
+	}
+}
+
+func TestModulesCachePrune_noCacheDir(t *testing.T) {
+	ui := cli.NewMockUi()
+	c := &ModulesCachePruneCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+	if code := c.Run(nil); code != 1 {
+		t.Fatalf("wrong exit code %d; want 1", code)
+	}
+	if got, want := ui.ErrorWriter.String(), "No module cache directory"; !strings.Contains(got, want) {
+		t.Errorf("wrong error\ngot: %s\nwant substring: %s", got, want)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/initwd"
+	"github.com/opentofu/opentofu/internal/registry/regsrc"
+	"github.com/opentofu/opentofu/internal/registry/response"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// ModulesOutdatedCommand is a Command implementation that implements the
+// "tofu modules outdated" command, which reports which of the registry
+// modules called by the current configuration have newer versions available
+// than the ones currently installed.
+type ModulesOutdatedCommand struct {
+	Meta
+}
+
+// moduleOutdatedJSON describes a single module call in the output of
+// "tofu modules outdated -json".
+type moduleOutdatedJSON struct {
+	Address       string `json:"address"`
+	Source        string `json:"source"`
+	Constraints   string `json:"constraints,omitempty"`
+	Current       string `json:"current,omitempty"`
+	LatestAllowed string `json:"latest_allowed,omitempty"`
+	Latest        string `json:"latest,omitempty"`
+}
+
+func (c *ModulesOutdatedCommand) Synopsis() string {
+	return "Show registry modules with newer versions available"
+}
+
+func (c *ModulesOutdatedCommand) Run(args []string) int {
+	var jsonOutput bool
+
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.defaultFlagSet("modules outdated")
+	c.Meta.varFlagSet(cmdFlags)
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "produce JSON output")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return 1
+	}
+
+	configPath, err := modulePath(cmdFlags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	var diags tfdiags.Diagnostics
+
+	ctx, done := c.InterruptibleContext(c.CommandContext())
+	defer done()
+
+	// Loading the configuration requires the modules to already be
+	// installed, which also tells us which version of each is in use.
+	config, confDiags := c.loadConfig(ctx, configPath)
+	diags = diags.Append(confDiags)
+	if confDiags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	locks, lockDiags := c.lockedDependencies()
+	diags = diags.Append(lockDiags)
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	reg := c.registryClient(ctx)
+	available := make(map[addrs.ModuleRegistryPackage]*response.ModuleVersions)
+
+	var results []moduleOutdatedJSON
+	config.DeepEach(func(mod *configs.Config) {
+		if mod.Parent == nil {
+			return // the root module has no call
+		}
+		addr, ok := mod.SourceAddr.(addrs.ModuleSourceRegistry)
+		if !ok {
+			return // only registry modules have versions
+		}
+		call := mod.Parent.Module.ModuleCalls[mod.Path[len(mod.Path)-1]]
+		if call == nil {
+			return // should never happen for a module in the tree
+		}
+
+		result := moduleOutdatedJSON{
+			Address:     mod.Path.String(),
+			Source:      addr.String(),
+			Constraints: call.Version.Required.String(),
+		}
+		current := mod.Version
+		if lock := locks.Module(strings.Join(mod.Path, ".")); lock != nil && lock.Version() != nil {
+			current = lock.Version()
+		}
+		if current != nil {
+			result.Current = current.String()
+		}
+
+		resp, ok := available[addr.Package]
+		if !ok {
+			resp, err = reg.ModuleVersions(ctx, regsrc.ModuleFromRegistryPackageAddr(addr.Package))
+			if err != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Failed to query available module versions",
+					fmt.Sprintf("Could not retrieve the list of available versions for module %s (%s) from %s: %s.", mod.Path, addr.Package.ForRegistryProtocol(), addr.Package.Host, err),
+				))
+				return
+			}
+			available[addr.Package] = resp
+		}
+
+		var latest, latestAllowed *version.Version
+		if len(resp.Modules) > 0 {
+			for _, mv := range resp.Modules[0].Versions {
+				v, err := version.NewVersion(mv.Version)
+				if err != nil {
+					continue // the installer warns about these already
+				}
+				// Like the installer, we only select a pre-release if the
+				// constraints request it exactly.
+				allowed := call.Version.Required.Check(v) && (v.Prerelease() == "" || initwd.AcceptsPrerelease(call.Version.Required, v))
+				if allowed && (latestAllowed == nil || v.GreaterThan(latestAllowed)) {
+					latestAllowed = v
+				}
+				if v.Prerelease() == "" && (latest == nil || v.GreaterThan(latest)) {
+					latest = v
+				}
+			}
+		}
+		if latestAllowed != nil {
+			result.LatestAllowed = latestAllowed.String()
+		}
+		if latest != nil {
+			result.Latest = latest.String()
+		}
+		results = append(results, result)
+	})
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Address < results[j].Address
+	})
+
+	c.showDiagnostics(diags)
+	if diags.HasErrors() {
+		return 1
+	}
+
+	if jsonOutput {
+		out := struct {
+			FormatVersion string               `json:"format_version"`
+			Modules       []moduleOutdatedJSON `json:"modules"`
+		}{
+			FormatVersion: "1.0",
+			Modules:       results,
+		}
+		if out.Modules == nil {
+			out.Modules = []moduleOutdatedJSON{}
+		}
+		buf, err := json.Marshal(out)
+		if err != nil {
+			// Should never happen because the input is entirely under our
+			// control.
+			panic(fmt.Sprintf("failed to encode outdated modules: %s", err))
+		}
+		c.Ui.Output(string(buf))
+		return 0
+	}
+
+	if len(results) == 0 {
+		c.Ui.Output("The configuration doesn't call any modules from a registry.")
+		return 0
+	}
+	rows := [][]string{{"Module", "Source", "Constraints", "Current", "Latest allowed", "Latest"}}
+	for _, result := range results {
+		rows = append(rows, []string{
+			result.Address,
+			result.Source,
+			result.Constraints,
+			result.Current,
+			result.LatestAllowed,
+			result.Latest,
+		})
+	}
+	c.Ui.Output(formatOutdatedTable(rows))
+	return 0
+}
+
+func (c *ModulesOutdatedCommand) Help() string {
+	return `
+Usage: tofu [global options] modules outdated [options] [DIR]
+
+  Reports the registry modules called by the configuration together with the
+  version currently installed, the newest version allowed by the version
+  constraint of each module call, and the newest version available overall.
+
+  The modules must already be installed using "tofu init". To select newer
+  versions allowed by the version constraints, run "tofu init -upgrade".
+
+Options:
+
+  -json              Produce output in a machine-readable JSON format.
+
+  -var 'foo=bar'     Set a value for one of the input variables in the root
+                     module of the configuration. Use this option more than
+                     once to set more than one variable.
+
+  -var-file=filename Load variable values from the given file, in addition
+                     to the default files terraform.tfvars and *.auto.tfvars.
+                     Use this option more than once to include more than one
+                     variables file.
+`
+}
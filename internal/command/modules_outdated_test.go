@@ -0,0 +1,137 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mitchellh/cli"
+
+	registrytest "github.com/opentofu/opentofu/internal/registry/test"
+)
+
+func TestModulesOutdated(t *testing.T) {
+	server := registrytest.Registry()
+	defer server.Close()
+
+	t.Run("table", func(t *testing.T) {
+		td := t.TempDir()
+		testCopyDir(t, testFixturePath("modules-outdated"), td)
+		t.Chdir(td)
+
+		ui := cli.NewMockUi()
+		c := &ModulesOutdatedCommand{
+			Meta: Meta{
+				Ui:       ui,
+				Services: registrytest.Disco(server),
+			},
+		}
+		if code := c.Run(nil); code != 0 {
+			t.Fatalf("wrong exit code %d\n%s", code, ui.ErrorWriter.String())
+		}
+
+		want := strings.TrimSpace(`
+Module    Source                                   Constraints  Current  Latest allowed  Latest
+module.a  example.com/test-versions/name/provider  ~> 1.2.0     1.2.1    1.2.2           2.2.0
+`)
+		got := strings.TrimSpace(ui.OutputWriter.String())
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("wrong output\n%s", diff)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		td := t.TempDir()
+		testCopyDir(t, testFixturePath("modules-outdated"), td)
+		t.Chdir(td)
+
+		ui := cli.NewMockUi()
+		c := &ModulesOutdatedCommand{
+			Meta: Meta{
+				Ui:       ui,
+				Services: registrytest.Disco(server),
+			},
+		}
+		if code := c.Run([]string{"-json"}); code != 0 {
+			t.Fatalf("wrong exit code %d\n%s", code, ui.ErrorWriter.String())
+		}
+
+		var got map[string]interface{}
+		if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON output: %s", err)
+		}
+		want := map[string]interface{}{
+			"format_version": "1.0",
+			"modules": []interface{}{
+				map[string]interface{}{
+					"address":        "module.a",
+					"source":         "example.com/test-versions/name/provider",
+					"constraints":    "~> 1.2.0",
+					"current":        "1.2.1",
+					"latest_allowed": "1.2.2",
+					"latest":         "2.2.0",
+				},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("wrong output\n%s", diff)
+		}
+	})
+
+	t.Run("prereleases", func(t *testing.T) {
+		td := t.TempDir()
+		testCopyDir(t, testFixturePath("modules-outdated-prerelease"), td)
+		t.Chdir(td)
+
+		ui := cli.NewMockUi()
+		c := &ModulesOutdatedCommand{
+			Meta: Meta{
+				Ui:       ui,
+				Services: registrytest.Disco(server),
+			},
+		}
+		if code := c.Run(nil); code != 0 {
+			t.Fatalf("wrong exit code %d\n%s", code, ui.ErrorWriter.String())
+		}
+
+		// A pre-release is only allowed when the constraints request it
+		// exactly, as when installing the module.
+		want := strings.TrimSpace(`
+Module    Source                                      Constraints  Current      Latest allowed  Latest
+module.a  example.com/test-prereleases/name/provider  -            1.2.0        1.2.0           1.2.0
+module.b  example.com/test-prereleases/name/provider  1.3.0-beta1  1.3.0-beta1  1.3.0-beta1     1.2.0
+`)
+		got := strings.TrimSpace(ui.OutputWriter.String())
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("wrong output\n%s", diff)
+		}
+	})
+
+	t.Run("not installed", func(t *testing.T) {
+		td := t.TempDir()
+		testCopyDir(t, testFixturePath("modules-outdated"), td)
+		t.Chdir(td)
+		if err := os.RemoveAll(".terraform"); err != nil {
+			t.Fatal(err)
+		}
+
+		ui := cli.NewMockUi()
+		c := &ModulesOutdatedCommand{
+			Meta: Meta{
+				Ui:       ui,
+				Services: registrytest.Disco(server),
+			},
+		}
+		if code := c.Run(nil); code != 1 {
+			t.Fatalf("wrong exit code %d\n%s", code, ui.OutputWriter.String())
+		}
+		if got := ui.ErrorWriter.String(); !strings.Contains(got, "Module not installed") {
+			t.Errorf("missing error about module installation\n%s", got)
+		}
+	})
+}
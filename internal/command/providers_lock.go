@@ -266,6 +266,9 @@ func (c *ProvidersLockCommand) Run(args []string) int {
 				if keyID != "" {
 					keyID = c.Colorize().Color(fmt.Sprintf(", key ID [reset][bold]%s[reset]", keyID))
 				}
+				if signers := auth.OCISignersString(); signers != "" {
+					keyID += c.Colorize().Color(fmt.Sprintf(", signed by [reset][bold]%s[reset]", signers))
+				}
 				c.Ui.Output(fmt.Sprintf("- Retrieved %s %s for %s (%s%s)", provider.ForDisplay(), version, platform, auth, keyID))
 			},
 		}
@@ -273,6 +276,7 @@ func (c *ProvidersLockCommand) Run(args []string) int {
 
 		dir := providercache.NewDirWithPlatform(tempDir, platform)
 		installer := providercache.NewInstaller(dir, source)
+		installer.SetProviderPolicy(c.ProviderPolicy)
 
 		newLocks, err := installer.EnsureProviderVersions(ctx, oldLocks, reqs, providercache.InstallNewProvidersForce)
 		if err != nil {
@@ -18,6 +18,7 @@ import (
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/depsfile"
 	"github.com/opentofu/opentofu/internal/getproviders"
+	"github.com/opentofu/opentofu/internal/providercache"
 )
 
 func TestProvidersLock(t *testing.T) {
@@ -113,6 +114,44 @@ func runProviderLockGenericTest(t *testing.T, testDirectory, expected string) {
 	}
 }
 
+func TestProvidersLock_providerPolicy(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath("providers-lock/basic"), td)
+	t.Chdir(td)
+
+	fixtMachineDir := filepath.Join(td, "fs-mirror/registry.opentofu.org/hashicorp/test/1.0.0/os_arch")
+	wantMachineDir := filepath.Join(td, "fs-mirror/registry.opentofu.org/hashicorp/test/1.0.0/", fmt.Sprintf("%s_%s", runtime.GOOS, runtime.GOARCH))
+	if err := os.Rename(fixtMachineDir, wantMachineDir); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	denied, err := getproviders.ParseMultiSourceMatchingPatterns([]string{"hashicorp/test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui := new(cli.MockUi)
+	c := &ProvidersLockCommand{
+		Meta: Meta{
+			Ui:               ui,
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			ProviderPolicy: &providercache.ProviderPolicy{
+				DeniedProviders: denied,
+			},
+		},
+	}
+
+	code := c.Run([]string{"-fs-mirror=fs-mirror"})
+	if code != 1 {
+		t.Fatalf("wrong exit code; expected 1, got %d\n%s", code, ui.OutputWriter.String())
+	}
+	if got, want := ui.ErrorWriter.String(), "this provider is listed in denied_providers"; !strings.Contains(got, want) {
+		t.Errorf("missing expected error\ngot: %s\nwant substring: %s", got, want)
+	}
+	if _, err := os.Stat(".terraform.lock.hcl"); !os.IsNotExist(err) {
+		t.Errorf("lock file was created despite the error")
+	}
+}
+
 func TestProvidersLock_args(t *testing.T) {
 
 	t.Run("mirror collision", func(t *testing.T) {
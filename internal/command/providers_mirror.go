@@ -6,15 +6,19 @@
 package command
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/apparentlymart/go-versions/versions"
 	"github.com/hashicorp/go-getter"
 
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/command/cliconfig"
 	"github.com/opentofu/opentofu/internal/getproviders"
 	"github.com/opentofu/opentofu/internal/httpclient"
 	"github.com/opentofu/opentofu/internal/tfdiags"
@@ -37,7 +41,9 @@ func (c *ProvidersMirrorCommand) Run(args []string) int {
 	cmdFlags := c.Meta.defaultFlagSet("providers mirror")
 	c.Meta.varFlagSet(cmdFlags)
 	var optPlatforms FlagStringSlice
+	var optOCIRepositoryTemplate string
 	cmdFlags.Var(&optPlatforms, "platform", "target platform")
+	cmdFlags.StringVar(&optOCIRepositoryTemplate, "oci-repository-template", "", "OCI repository address template")
 	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
 		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
@@ -47,16 +53,64 @@ func (c *ProvidersMirrorCommand) Run(args []string) int {
 	var diags tfdiags.Diagnostics
 
 	args = cmdFlags.Args()
-	if len(args) != 1 {
-		diags = diags.Append(tfdiags.Sourceless(
-			tfdiags.Error,
-			"No output directory specified",
-			"The providers mirror command requires an output directory as a command-line argument.",
-		))
-		c.showDiagnostics(diags)
-		return 1
+	var outputDir string
+	var ociRepositoryAddr func(addrs.Provider) (registryDomain, repositoryName string, err error)
+	if optOCIRepositoryTemplate != "" {
+		if len(args) != 0 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Unexpected output directory",
+				"The providers mirror command cannot accept an output directory when publishing into an OCI registry using the -oci-repository-template option.",
+			))
+			c.showDiagnostics(diags)
+			return 1
+		}
+		if c.GetOCIRepositoryPushStore == nil {
+			// Should not happen because package main always sets this.
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"OCI registries not available",
+				"This OpenTofu executable cannot publish providers into OCI registries.",
+			))
+			c.showDiagnostics(diags)
+			return 1
+		}
+		var err error
+		ociRepositoryAddr, err = cliconfig.ParseOCIMirrorRepositoryTemplate(optOCIRepositoryTemplate)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid OCI repository template",
+				fmt.Sprintf("The -oci-repository-template option value is invalid: %s.", err),
+			))
+			c.showDiagnostics(diags)
+			return 1
+		}
+		// We download the packages into a temporary directory first, so
+		// that we can authenticate them before pushing them.
+		outputDir, err = os.MkdirTemp("", "tofu-providers-mirror")
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Cannot create temporary directory",
+				fmt.Sprintf("Failed to create a temporary directory for the provider packages: %s.", err),
+			))
+			c.showDiagnostics(diags)
+			return 1
+		}
+		defer os.RemoveAll(outputDir)
+	} else {
+		if len(args) != 1 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"No output directory specified",
+				"The providers mirror command requires an output directory as a command-line argument, unless using the -oci-repository-template option.",
+			))
+			c.showDiagnostics(diags)
+			return 1
+		}
+		outputDir = args[0]
 	}
-	outputDir := args[0]
 
 	var platforms []getproviders.Platform
 	if len(optPlatforms) == 0 {
@@ -107,6 +161,43 @@ func (c *ProvidersMirrorCommand) Run(args []string) int {
 		}
 	}
 
+	// When publishing into an OCI registry, each provider must have its own
+	// repository, because the tags in a repository represent the versions
+	// of a single provider.
+	ociRepositories := make(map[addrs.Provider]string)
+	if ociRepositoryAddr != nil {
+		providersByRepository := make(map[string]addrs.Provider)
+		for provider := range reqs {
+			if provider.IsBuiltIn() {
+				continue
+			}
+			registryDomain, repositoryName, err := ociRepositoryAddr(provider)
+			if err != nil {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Invalid OCI repository template",
+					fmt.Sprintf("Cannot determine the OCI repository for %s: %s.", provider, err),
+				))
+				continue
+			}
+			repositoryAddr := registryDomain + "/" + repositoryName
+			if other, exists := providersByRepository[repositoryAddr]; exists {
+				diags = diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Ambiguous OCI repository template",
+					fmt.Sprintf("The -oci-repository-template option places both %s and %s in the repository %s. Use the \"hostname\", \"namespace\", and \"type\" symbols in the template to give each provider its own repository.", other, provider, repositoryAddr),
+				))
+				continue
+			}
+			providersByRepository[repositoryAddr] = provider
+			ociRepositories[provider] = repositoryAddr
+		}
+		if diags.HasErrors() {
+			c.showDiagnostics(diags)
+			return 1
+		}
+	}
+
 	// Unlike other commands, this command always consults the origin registry
 	// for every provider so that it can be used to update a local mirror
 	// directory without needing to first disable that local mirror
@@ -179,80 +270,34 @@ func (c *ProvidersMirrorCommand) Run(args []string) int {
 		} else {
 			c.Ui.Output(fmt.Sprintf("  - Selected v%s with no constraints", selected.String()))
 		}
+		var ociPackages []getproviders.OCIMirrorPackage
 		for _, platform := range platforms {
 			c.Ui.Output(fmt.Sprintf("  - Downloading package for %s...", platform.String()))
-			meta, err := source.PackageMeta(ctx, provider, selected, platform)
-			if err != nil {
-				diags = diags.Append(tfdiags.Sourceless(
-					tfdiags.Error,
-					"Provider release not available",
-					fmt.Sprintf("Failed to download %s v%s for %s: %s.", provider.String(), selected.String(), platform.String(), err),
-				))
-				continue
-			}
-			urlStr, ok := meta.Location.(getproviders.PackageHTTPURL)
-			if !ok {
-				// We don't expect to get non-HTTP locations here because we're
-				// using the registry source, so this seems like a bug in the
-				// registry source.
-				diags = diags.Append(tfdiags.Sourceless(
-					tfdiags.Error,
-					"Provider release not available",
-					fmt.Sprintf("Failed to download %s v%s for %s: OpenTofu's provider registry client returned unexpected location type %T. This is a bug in OpenTofu.", provider.String(), selected.String(), platform.String(), meta.Location),
-				))
-				continue
-			}
-			urlObj, err := url.Parse(string(urlStr))
-			if err != nil {
-				// We don't expect to get non-HTTP locations here because we're
-				// using the registry source, so this seems like a bug in the
-				// registry source.
-				diags = diags.Append(tfdiags.Sourceless(
-					tfdiags.Error,
-					"Invalid URL for provider release",
-					fmt.Sprintf("The origin registry for %s returned an invalid URL for v%s on %s: %s.", provider.String(), selected.String(), platform.String(), err),
-				))
-				continue
-			}
-			// targetPath is the path where we ultimately want to place the
-			// downloaded archive, but we'll place it initially at stagingPath
-			// so we can verify its checksums and signatures before making
-			// it discoverable to mirror clients. (stagingPath intentionally
-			// does not follow the filesystem mirror file naming convention.)
-			targetPath := meta.PackedFilePath(outputDir)
-			stagingPath := filepath.Join(filepath.Dir(targetPath), "."+filepath.Base(targetPath))
-			err = httpGetter.GetFile(stagingPath, urlObj)
-			if err != nil {
-				diags = diags.Append(tfdiags.Sourceless(
-					tfdiags.Error,
-					"Cannot download provider release",
-					fmt.Sprintf("Failed to download %s v%s for %s: %s.", provider.String(), selected.String(), platform.String(), err),
-				))
+			targetPath, authResult, moreDiags := downloadPackedProviderPackage(ctx, source, &httpGetter, provider, selected, platform, outputDir)
+			diags = diags.Append(moreDiags)
+			if moreDiags.HasErrors() {
 				continue
 			}
-			if meta.Authentication != nil {
-				result, err := meta.Authentication.AuthenticatePackage(getproviders.PackageLocalArchive(stagingPath))
-				if err != nil {
-					diags = diags.Append(tfdiags.Sourceless(
-						tfdiags.Error,
-						"Invalid provider package",
-						fmt.Sprintf("Failed to authenticate %s v%s for %s: %s.", provider.String(), selected.String(), platform.String(), err),
-					))
-					continue
-				}
-				c.Ui.Output(fmt.Sprintf("  - Package authenticated: %s", result))
-			}
-			os.Remove(targetPath) // okay if it fails because we're going to try to rename over it next anyway
-			err = os.Rename(stagingPath, targetPath)
-			if err != nil {
-				diags = diags.Append(tfdiags.Sourceless(
-					tfdiags.Error,
-					"Cannot download provider release",
-					fmt.Sprintf("Failed to place %s package into mirror directory: %s.", provider.String(), err),
-				))
-				continue
+			if authResult != nil {
+				c.Ui.Output(fmt.Sprintf("  - Package authenticated: %s", authResult))
 			}
+			ociPackages = append(ociPackages, getproviders.OCIMirrorPackage{
+				TargetPlatform: platform,
+				Archive:        targetPath,
+			})
+		}
+		if repositoryAddr, ok := ociRepositories[provider]; ok && len(ociPackages) != 0 {
+			diags = diags.Append(c.pushOCIMirrorPackages(ctx, provider, selected, repositoryAddr, ociPackages))
+		}
+	}
+
+	if ociRepositoryAddr != nil {
+		// The JSON index files are only for network mirrors, so we're done.
+		c.showDiagnostics(diags)
+		if diags.HasErrors() {
+			return 1
 		}
+		return 0
 	}
 
 	// Now we'll generate or update the JSON index files in the directory.
@@ -362,9 +407,124 @@ func (c *ProvidersMirrorCommand) Run(args []string) int {
 	return 0
 }
 
+// downloadPackedProviderPackage downloads the package for the given provider
+// version and target platform from the given source into the given
+// directory, using the packed filesystem mirror layout, and returns the path
+// of the resulting archive.
+//
+// The package is authenticated using the metadata from the source before
+// it's placed at its final location. The returned authentication result is
+// nil if the source did not provide any means to authenticate the package.
+func downloadPackedProviderPackage(ctx context.Context, source getproviders.Source, httpGetter *getter.HttpGetter, provider addrs.Provider, version getproviders.Version, platform getproviders.Platform, outputDir string) (getproviders.PackageLocalArchive, *getproviders.PackageAuthenticationResult, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	var authResult *getproviders.PackageAuthenticationResult
+	meta, err := source.PackageMeta(ctx, provider, version, platform)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Provider release not available",
+			fmt.Sprintf("Failed to download %s v%s for %s: %s.", provider.String(), version.String(), platform.String(), err),
+		))
+		return "", nil, diags
+	}
+	urlStr, ok := meta.Location.(getproviders.PackageHTTPURL)
+	if !ok {
+		// We don't expect to get non-HTTP locations here because we're
+		// using the registry source, so this seems like a bug in the
+		// registry source.
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Provider release not available",
+			fmt.Sprintf("Failed to download %s v%s for %s: OpenTofu's provider registry client returned unexpected location type %T. This is a bug in OpenTofu.", provider.String(), version.String(), platform.String(), meta.Location),
+		))
+		return "", nil, diags
+	}
+	urlObj, err := url.Parse(string(urlStr))
+	if err != nil {
+		// We don't expect to get non-HTTP locations here because we're
+		// using the registry source, so this seems like a bug in the
+		// registry source.
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid URL for provider release",
+			fmt.Sprintf("The origin registry for %s returned an invalid URL for v%s on %s: %s.", provider.String(), version.String(), platform.String(), err),
+		))
+		return "", nil, diags
+	}
+	// targetPath is the path where we ultimately want to place the
+	// downloaded archive, but we'll place it initially at stagingPath
+	// so we can verify its checksums and signatures before making
+	// it discoverable to mirror clients. (stagingPath intentionally
+	// does not follow the filesystem mirror file naming convention.)
+	targetPath := meta.PackedFilePath(outputDir)
+	stagingPath := filepath.Join(filepath.Dir(targetPath), "."+filepath.Base(targetPath))
+	err = httpGetter.GetFile(stagingPath, urlObj)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot download provider release",
+			fmt.Sprintf("Failed to download %s v%s for %s: %s.", provider.String(), version.String(), platform.String(), err),
+		))
+		return "", nil, diags
+	}
+	if meta.Authentication != nil {
+		result, err := meta.Authentication.AuthenticatePackage(getproviders.PackageLocalArchive(stagingPath))
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid provider package",
+				fmt.Sprintf("Failed to authenticate %s v%s for %s: %s.", provider.String(), version.String(), platform.String(), err),
+			))
+			return "", nil, diags
+		}
+		authResult = result
+	}
+	os.Remove(targetPath) // okay if it fails because we're going to try to rename over it next anyway
+	err = os.Rename(stagingPath, targetPath)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot download provider release",
+			fmt.Sprintf("Failed to place %s package into mirror directory: %s.", provider.String(), err),
+		))
+		return "", nil, diags
+	}
+	return getproviders.PackageLocalArchive(targetPath), authResult, diags
+}
+
+// pushOCIMirrorPackages publishes the given packages for a provider version
+// into the given OCI repository, which is a registry domain and repository
+// name separated by a slash.
+func (c *ProvidersMirrorCommand) pushOCIMirrorPackages(ctx context.Context, provider addrs.Provider, version getproviders.Version, repositoryAddr string, packages []getproviders.OCIMirrorPackage) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	c.Ui.Output(fmt.Sprintf("  - Pushing v%s to %s...", version, repositoryAddr))
+	registryDomain, repositoryName, _ := strings.Cut(repositoryAddr, "/")
+	store, err := c.GetOCIRepositoryPushStore(ctx, registryDomain, repositoryName)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to access OCI registry",
+			fmt.Sprintf("Cannot access the OCI repository %s to push %s: %s.", repositoryAddr, provider, err),
+		))
+		return diags
+	}
+	desc, err := getproviders.PushOCIMirrorProviderVersion(ctx, store, version, packages)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Cannot push provider release",
+			fmt.Sprintf("Failed to push %s v%s to the OCI repository %s: %s.", provider, version, repositoryAddr, err),
+		))
+		return diags
+	}
+	c.Ui.Output(fmt.Sprintf("  - Pushed index manifest %s", desc.Digest))
+	return diags
+}
+
 func (c *ProvidersMirrorCommand) Help() string {
 	return `
 Usage: tofu [global options] providers mirror [options] <target-dir>
+       tofu [global options] providers mirror [options] -oci-repository-template=<template>
 
   Populates a local directory with copies of the provider plugins needed for
   the current configuration, so that the directory can be used either directly
@@ -376,8 +536,19 @@ Usage: tofu [global options] providers mirror [options] <target-dir>
   a network mirror. Those index files will be ignored if the directory is
   used instead as a local filesystem mirror.
 
+  Alternatively, with the -oci-repository-template option the packages are
+  pushed into repositories in an OCI registry instead, in the layout
+  expected by the oci_mirror provider installation method.
+
 Options:
 
+  -oci-repository-template=template
+                     Push the packages into OCI registry repositories
+                     instead of writing them into a directory. The template
+                     uses the same syntax as the "repository_template"
+                     argument of an oci_mirror installation method, such as
+                     "example.com/opentofu-providers/${namespace}/${type}".
+
   -platform=os_arch  Choose which target platform to build a mirror for.
                      By default OpenTofu will obtain plugin packages
                      suitable for the platform where you run this command.
@@ -6,10 +6,27 @@
 package command
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/mitchellh/cli"
+	"github.com/opentofu/svchost"
+	"github.com/opentofu/svchost/disco"
+	orasOCI "oras.land/oras-go/v2/content/oci"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/getproviders"
 )
 
 // More thorough tests for providers mirror can be found in the e2etest
@@ -39,3 +56,219 @@ func TestProvidersMirror(t *testing.T) {
 		}
 	})
 }
+
+func TestProvidersMirror_ociRepository(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(fakeMirrorRegistryHandler))
+	defer server.Close()
+	services := disco.New()
+	services.ForceHostServices(svchost.Hostname("registry.opentofu.org"), map[string]interface{}{
+		"providers.v1": server.URL + "/providers/v1/",
+	})
+
+	td := t.TempDir()
+	t.Chdir(td)
+	err := os.WriteFile("main.tf", []byte(`
+terraform {
+  required_providers {
+    happycloud = {
+      source = "awesomecorp/happycloud"
+    }
+    sadcloud = {
+      source = "awesomecorp/sadcloud"
+    }
+  }
+}
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stores := make(map[string]*orasOCI.Store)
+	getStore := func(ctx context.Context, registryDomain, repositoryName string) (getproviders.OCIRepositoryPushStore, error) {
+		if registryDomain != "example.com" {
+			return nil, fmt.Errorf("unexpected registry %q", registryDomain)
+		}
+		if store, ok := stores[repositoryName]; ok {
+			return store, nil
+		}
+		store, err := orasOCI.NewWithContext(ctx, t.TempDir())
+		if err != nil {
+			return nil, err
+		}
+		stores[repositoryName] = store
+		return store, nil
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ui := new(cli.MockUi)
+		c := &ProvidersMirrorCommand{
+			Meta: Meta{
+				Ui:                        ui,
+				Services:                  services,
+				GetOCIRepositoryPushStore: getStore,
+			},
+		}
+		code := c.Run([]string{
+			"-platform=linux_amd64",
+			"-platform=darwin_arm64",
+			"-oci-repository-template=example.com/mirror/${namespace}/${type}",
+		})
+		if code != 0 {
+			t.Fatalf("wrong exit code. expected 0, got %d\n%s", code, ui.ErrorWriter.String())
+		}
+
+		// The result should be usable as an oci_mirror installation source.
+		source := getproviders.NewOCIRegistryMirrorSource(
+			t.Context(),
+			func(addr addrs.Provider) (string, string, error) {
+				return "example.com", "mirror/" + addr.Namespace + "/" + addr.Type, nil
+			},
+			func(ctx context.Context, registryDomain, repositoryName string) (getproviders.OCIRepositoryStore, error) {
+				return getStore(ctx, registryDomain, repositoryName)
+			},
+			nil,
+		)
+		for _, providerType := range []string{"happycloud", "sadcloud"} {
+			provider := addrs.NewProvider(addrs.DefaultProviderRegistryHost, "awesomecorp", providerType)
+			versions, _, err := source.AvailableVersions(t.Context(), provider)
+			if err != nil {
+				t.Fatalf("failed to list versions of %s: %s", provider, err)
+			}
+			if got, want := versions, (getproviders.VersionList{getproviders.MustParseVersion("1.2.0")}); !cmp.Equal(got, want) {
+				t.Errorf("wrong versions of %s\ngot:  %s\nwant: %s", provider, got, want)
+			}
+			for _, platform := range []getproviders.Platform{{OS: "linux", Arch: "amd64"}, {OS: "darwin", Arch: "arm64"}} {
+				meta, err := source.PackageMeta(t.Context(), provider, versions[0], platform)
+				if err != nil {
+					t.Fatalf("failed to get metadata for %s on %s: %s", provider, platform, err)
+				}
+				pkgDir := t.TempDir()
+				_, err = meta.Location.(getproviders.PackageOCIBlobArchive).InstallProviderPackage(t.Context(), meta, pkgDir, nil)
+				if err != nil {
+					t.Fatalf("failed to install %s for %s: %s", provider, platform, err)
+				}
+				exeContent, err := os.ReadFile(filepath.Join(pkgDir, "terraform-provider-"+providerType))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, want := string(exeContent), fmt.Sprintf("%s for %s", providerType, platform); got != want {
+					t.Errorf("wrong executable content\ngot:  %q\nwant: %q", got, want)
+				}
+			}
+		}
+	})
+	t.Run("ambiguous template", func(t *testing.T) {
+		ui := new(cli.MockUi)
+		c := &ProvidersMirrorCommand{
+			Meta: Meta{
+				Ui:                        ui,
+				Services:                  services,
+				GetOCIRepositoryPushStore: getStore,
+			},
+		}
+		code := c.Run([]string{
+			"-oci-repository-template=example.com/mirror/${namespace}",
+		})
+		if code != 1 {
+			t.Fatalf("wrong exit code. expected 1, got %d", code)
+		}
+		if got, want := ui.ErrorWriter.String(), "Ambiguous OCI repository template"; !strings.Contains(got, want) {
+			t.Fatalf("missing error from output\ngot:\n%s\nwant substring: %s", got, want)
+		}
+	})
+	t.Run("output directory and template", func(t *testing.T) {
+		ui := new(cli.MockUi)
+		c := &ProvidersMirrorCommand{
+			Meta: Meta{
+				Ui:                        ui,
+				GetOCIRepositoryPushStore: getStore,
+			},
+		}
+		code := c.Run([]string{
+			"-oci-repository-template=example.com/mirror/${namespace}/${type}",
+			"out",
+		})
+		if code != 1 {
+			t.Fatalf("wrong exit code. expected 1, got %d", code)
+		}
+		if got, want := ui.ErrorWriter.String(), "Unexpected output directory"; !strings.Contains(got, want) {
+			t.Fatalf("missing error from output\ngot:\n%s\nwant substring: %s", got, want)
+		}
+	})
+}
+
+// fakeMirrorRegistryHandler is a fake provider registry serving v1.2.0 of
+// the providers awesomecorp/happycloud and awesomecorp/sadcloud for any
+// platform, without any signing keys.
+func fakeMirrorRegistryHandler(resp http.ResponseWriter, req *http.Request) {
+	path := req.URL.EscapedPath()
+	if pkgName, ok := strings.CutPrefix(path, "/pkg/"); ok {
+		// The package filenames are <type>_1.2.0_<os>_<arch>.zip, and the
+		// checksums and signature use the same prefix with a different suffix.
+		var suffix string
+		for _, candidate := range []string{".zip", "_SHA256SUMS", "_SHA256SUMS.sig"} {
+			if strings.HasSuffix(pkgName, candidate) {
+				suffix = candidate
+			}
+		}
+		base := strings.TrimSuffix(pkgName, suffix)
+		parts := strings.Split(base, "_")
+		if suffix == "" || len(parts) != 4 {
+			resp.WriteHeader(404)
+			return
+		}
+		zipSrc := fakeMirrorProviderPackage(parts[0], parts[2]+"_"+parts[3])
+		switch suffix {
+		case ".zip":
+			_, _ = resp.Write(zipSrc)
+		case "_SHA256SUMS":
+			_, _ = fmt.Fprintf(resp, "%x  %s.zip\n", sha256.Sum256(zipSrc), base)
+		case "_SHA256SUMS.sig":
+			_, _ = resp.Write([]byte("not checked because there are no signing keys"))
+		}
+		return
+	}
+
+	pathParts := strings.Split(strings.TrimPrefix(path, "/providers/v1/"), "/")
+	if len(pathParts) < 3 || pathParts[0] != "awesomecorp" || (pathParts[1] != "happycloud" && pathParts[1] != "sadcloud") {
+		resp.WriteHeader(404)
+		return
+	}
+	providerType := pathParts[1]
+	resp.Header().Set("Content-Type", "application/json")
+	switch {
+	case len(pathParts) == 3 && pathParts[2] == "versions":
+		_, _ = resp.Write([]byte(`{"versions":[{"version":"1.2.0","protocols":["5.0"]}]}`))
+	case len(pathParts) == 6 && pathParts[2] == "1.2.0" && pathParts[3] == "download":
+		platform := pathParts[4] + "_" + pathParts[5]
+		filename := fmt.Sprintf("%s_1.2.0_%s.zip", providerType, platform)
+		body, _ := json.Marshal(map[string]interface{}{
+			"protocols":             []string{"5.0"},
+			"os":                    pathParts[4],
+			"arch":                  pathParts[5],
+			"filename":              filename,
+			"shasum":                fmt.Sprintf("%x", sha256.Sum256(fakeMirrorProviderPackage(providerType, platform))),
+			"download_url":          "/pkg/" + filename,
+			"shasums_url":           "/pkg/" + strings.TrimSuffix(filename, ".zip") + "_SHA256SUMS",
+			"shasums_signature_url": "/pkg/" + strings.TrimSuffix(filename, ".zip") + "_SHA256SUMS.sig",
+			"signing_keys":          map[string]interface{}{"gpg_public_keys": []interface{}{}},
+		})
+		_, _ = resp.Write(body)
+	default:
+		resp.WriteHeader(404)
+	}
+}
+
+func fakeMirrorProviderPackage(providerType, platform string) []byte {
+	var buf bytes.Buffer
+	zipW := zip.NewWriter(&buf)
+	w, err := zipW.Create("terraform-provider-" + providerType)
+	if err != nil {
+		panic(err)
+	}
+	_, _ = fmt.Fprintf(w, "%s for %s", providerType, platform)
+	if err := zipW.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,229 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apparentlymart/go-versions/versions"
+
+	"github.com/opentofu/opentofu/internal/getproviders"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// ProvidersOutdatedCommand is a Command implementation that implements the
+// "tofu providers outdated" command, which reports which of the providers
+// required by the current configuration have newer releases available than
+// the ones selected in the dependency lock file.
+type ProvidersOutdatedCommand struct {
+	Meta
+}
+
+// providerOutdatedJSON describes a single provider in the output of
+// "tofu providers outdated -json".
+type providerOutdatedJSON struct {
+	Address       string `json:"address"`
+	Constraints   string `json:"constraints,omitempty"`
+	Current       string `json:"current,omitempty"`
+	LatestAllowed string `json:"latest_allowed,omitempty"`
+	Latest        string `json:"latest,omitempty"`
+}
+
+func (c *ProvidersOutdatedCommand) Synopsis() string {
+	return "Show providers with newer versions available"
+}
+
+func (c *ProvidersOutdatedCommand) Run(args []string) int {
+	var jsonOutput bool
+
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.defaultFlagSet("providers outdated")
+	c.Meta.varFlagSet(cmdFlags)
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "produce JSON output")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return 1
+	}
+
+	configPath, err := modulePath(cmdFlags.Args())
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	var diags tfdiags.Diagnostics
+
+	ctx, done := c.InterruptibleContext(c.CommandContext())
+	defer done()
+
+	config, confDiags := c.loadConfig(ctx, configPath)
+	diags = diags.Append(confDiags)
+	if confDiags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+	reqs, _, moreDiags := config.ProviderRequirements()
+	diags = diags.Append(moreDiags)
+
+	locks, lockDiags := c.lockedDependencies()
+	diags = diags.Append(lockDiags)
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	// We consult the same sources that "tofu init" would install from, so
+	// that the latest versions we report are ones that could actually be
+	// selected by upgrading.
+	source := c.providerInstallSource()
+
+	devOverrides := c.providerDevOverrides()
+	var results []providerOutdatedJSON
+	for provider, constraints := range reqs {
+		if provider.IsBuiltIn() {
+			continue
+		}
+		if _, ok := devOverrides[provider]; ok {
+			continue
+		}
+
+		result := providerOutdatedJSON{
+			Address:     provider.String(),
+			Constraints: getproviders.VersionConstraintsString(constraints),
+		}
+		if lock := locks.Provider(provider); lock != nil {
+			result.Current = lock.Version().String()
+		}
+
+		avail, _, err := source.AvailableVersions(ctx, provider)
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to query available provider versions",
+				fmt.Sprintf("Could not retrieve the list of available versions for provider %s: %s.", provider.ForDisplay(), err),
+			))
+			continue
+		}
+		if v := avail.NewestInSet(versions.MeetingConstraints(constraints)); v != versions.Unspecified {
+			result.LatestAllowed = v.String()
+		}
+		if v := avail.NewestInSet(versions.Released); v != versions.Unspecified {
+			result.Latest = v.String()
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Address < results[j].Address
+	})
+
+	c.showDiagnostics(diags)
+	if diags.HasErrors() {
+		return 1
+	}
+
+	if jsonOutput {
+		out := struct {
+			FormatVersion string                 `json:"format_version"`
+			Providers     []providerOutdatedJSON `json:"providers"`
+		}{
+			FormatVersion: "1.0",
+			Providers:     results,
+		}
+		if out.Providers == nil {
+			out.Providers = []providerOutdatedJSON{}
+		}
+		buf, err := json.Marshal(out)
+		if err != nil {
+			// Should never happen because the input is entirely under our
+			// control.
+			panic(fmt.Sprintf("failed to encode outdated providers: %s", err))
+		}
+		c.Ui.Output(string(buf))
+		return 0
+	}
+
+	if len(results) == 0 {
+		c.Ui.Output("The configuration doesn't require any providers from a registry.")
+		return 0
+	}
+	rows := [][]string{{"Provider", "Constraints", "Current", "Latest allowed", "Latest"}}
+	for _, result := range results {
+		rows = append(rows, []string{
+			result.Address,
+			result.Constraints,
+			result.Current,
+			result.LatestAllowed,
+			result.Latest,
+		})
+	}
+	c.Ui.Output(formatOutdatedTable(rows))
+	return 0
+}
+
+func (c *ProvidersOutdatedCommand) Help() string {
+	return `
+Usage: tofu [global options] providers outdated [options] [DIR]
+
+  Reports the providers required by the configuration together with the
+  version currently selected in the dependency lock file, the newest version
+  allowed by the configured version constraints, and the newest version
+  available overall.
+
+  The available versions are retrieved using the same provider installation
+  methods as "tofu init". To select newer versions allowed by the version
+  constraints, run "tofu init -upgrade".
+
+Options:
+
+  -json              Produce output in a machine-readable JSON format.
+
+  -var 'foo=bar'     Set a value for one of the input variables in the root
+                     module of the configuration. Use this option more than
+                     once to set more than one variable.
+
+  -var-file=filename Load variable values from the given file, in addition
+                     to the default files terraform.tfvars and *.auto.tfvars.
+                     Use this option more than once to include more than one
+                     variables file.
+`
+}
+
+// formatOutdatedTable renders the given rows as columns aligned with spaces,
+// using a "-" placeholder for empty cells. The first row is the header.
+func formatOutdatedTable(rows [][]string) string {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if cell == "" {
+				cell = "-"
+			}
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	var buf strings.Builder
+	for _, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			if cell == "" {
+				cell = "-"
+			}
+			if i == len(row)-1 {
+				line.WriteString(cell)
+				continue
+			}
+			fmt.Fprintf(&line, "%-*s  ", widths[i], cell)
+		}
+		buf.WriteString(strings.TrimRight(line.String(), " "))
+		buf.WriteString("\n")
+	}
+	return strings.TrimRight(buf.String(), "\n")
+}
@@ -0,0 +1,97 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mitchellh/cli"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/getproviders"
+)
+
+func TestProvidersOutdated(t *testing.T) {
+	fooProvider := addrs.MustParseProviderSourceString("hashicorp/foo")
+	barProvider := addrs.MustParseProviderSourceString("hashicorp/bar")
+	protocols := getproviders.VersionList{getproviders.MustParseVersion("5.0")}
+	var packages []getproviders.PackageMeta
+	for _, v := range []string{"1.0.0", "1.2.0", "2.0.0", "2.1.0-beta1"} {
+		packages = append(packages, getproviders.FakePackageMeta(fooProvider, getproviders.MustParseVersion(v), protocols, getproviders.CurrentPlatform))
+	}
+	packages = append(packages, getproviders.FakePackageMeta(barProvider, getproviders.MustParseVersion("0.1.0"), protocols, getproviders.CurrentPlatform))
+	source := getproviders.NewMockSource(packages, nil)
+
+	t.Run("table", func(t *testing.T) {
+		td := t.TempDir()
+		testCopyDir(t, testFixturePath("providers-outdated"), td)
+		t.Chdir(td)
+
+		ui := cli.NewMockUi()
+		c := &ProvidersOutdatedCommand{
+			Meta: Meta{
+				Ui:             ui,
+				ProviderSource: source,
+			},
+		}
+		if code := c.Run(nil); code != 0 {
+			t.Fatalf("wrong exit code %d\n%s", code, ui.ErrorWriter.String())
+		}
+
+		want := strings.TrimSpace(`
+Provider                             Constraints  Current  Latest allowed  Latest
+registry.opentofu.org/hashicorp/bar  -            -        0.1.0           0.1.0
+registry.opentofu.org/hashicorp/foo  ~> 1.0       1.0.0    1.2.0           2.0.0
+`)
+		got := strings.TrimSpace(ui.OutputWriter.String())
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("wrong output\n%s", diff)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		td := t.TempDir()
+		testCopyDir(t, testFixturePath("providers-outdated"), td)
+		t.Chdir(td)
+
+		ui := cli.NewMockUi()
+		c := &ProvidersOutdatedCommand{
+			Meta: Meta{
+				Ui:             ui,
+				ProviderSource: source,
+			},
+		}
+		if code := c.Run([]string{"-json"}); code != 0 {
+			t.Fatalf("wrong exit code %d\n%s", code, ui.ErrorWriter.String())
+		}
+
+		var got map[string]interface{}
+		if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON output: %s", err)
+		}
+		want := map[string]interface{}{
+			"format_version": "1.0",
+			"providers": []interface{}{
+				map[string]interface{}{
+					"address":        "registry.opentofu.org/hashicorp/bar",
+					"latest_allowed": "0.1.0",
+					"latest":         "0.1.0",
+				},
+				map[string]interface{}{
+					"address":        "registry.opentofu.org/hashicorp/foo",
+					"constraints":    "~> 1.0",
+					"current":        "1.0.0",
+					"latest_allowed": "1.2.0",
+					"latest":         "2.0.0",
+				},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("wrong output\n%s", diff)
+		}
+	})
+}
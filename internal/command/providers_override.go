@@ -0,0 +1,42 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// ProvidersOverrideCommand is a Command implementation that just shows help
+// for the subcommands nested below it.
+type ProvidersOverrideCommand struct {
+	Meta
+}
+
+func (c *ProvidersOverrideCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *ProvidersOverrideCommand) Help() string {
+	helpText := `
+Usage: tofu [global options] providers override <subcommand> [options] [args]
+
+  This command has subcommands for managing provider development overrides
+  that apply only to the current working directory.
+
+  A development override makes OpenTofu use a provider executable from a
+  local directory instead of the version selected in the dependency lock
+  file, which is useful when testing local builds of a provider. Unlike the
+  dev_overrides block in the CLI configuration, these overrides are recorded
+  in the working directory's data directory and so do not affect any other
+  working directory on the same system.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ProvidersOverrideCommand) Synopsis() string {
+	return "Manage provider development overrides for this working directory"
+}
@@ -0,0 +1,89 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// ProvidersOverrideListCommand is a Command implementation that implements
+// the "tofu providers override list" command, which shows the provider
+// development overrides in effect for the current working directory.
+type ProvidersOverrideListCommand struct {
+	Meta
+}
+
+func (c *ProvidersOverrideListCommand) Synopsis() string {
+	return "Show the provider development overrides in effect"
+}
+
+func (c *ProvidersOverrideListCommand) Run(args []string) int {
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.defaultFlagSet("providers override list")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return 1
+	}
+	if len(cmdFlags.Args()) != 0 {
+		c.Ui.Error("The providers override list command expects no positional arguments.")
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	var diags tfdiags.Diagnostics
+
+	wdOverrides, err := c.workingDirProviderDevOverrides()
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read provider development overrides",
+			fmt.Sprintf("Could not read the provider development overrides for this working directory: %s.", err),
+		))
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	var entries []string
+	for addr, override := range wdOverrides {
+		var entry strings.Builder
+		fmt.Fprintf(&entry, "%s\n  directory:     %s\n  set in:        working directory\n", addr.ForDisplay(), override.Dir)
+		if override.BuildCommand != "" {
+			fmt.Fprintf(&entry, "  build command: %s\n", override.BuildCommand)
+			if override.SourceDir != "" {
+				fmt.Fprintf(&entry, "  source dir:    %s\n", override.SourceDir)
+			}
+		}
+		entries = append(entries, entry.String())
+	}
+	for addr, dir := range c.ProviderDevOverrides {
+		if _, ok := wdOverrides[addr]; ok {
+			continue // the working directory override takes precedence
+		}
+		entries = append(entries, fmt.Sprintf("%s\n  directory:     %s\n  set in:        CLI configuration\n", addr.ForDisplay(), dir))
+	}
+	if len(entries) == 0 {
+		c.Ui.Output("No provider development overrides are in effect.")
+		return 0
+	}
+
+	sort.Strings(entries)
+	c.Ui.Output(strings.TrimSpace(strings.Join(entries, "\n")))
+	return 0
+}
+
+func (c *ProvidersOverrideListCommand) Help() string {
+	return `
+Usage: tofu [global options] providers override list
+
+  Shows the provider development overrides in effect for the current
+  working directory, including both those recorded by
+  "tofu providers override set" and any dev_overrides in the CLI
+  configuration.
+`
+}
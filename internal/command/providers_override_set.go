@@ -0,0 +1,168 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/command/workdir"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// ProvidersOverrideSetCommand is a Command implementation that implements
+// the "tofu providers override set" command, which records a provider
+// development override for the current working directory.
+type ProvidersOverrideSetCommand struct {
+	Meta
+}
+
+func (c *ProvidersOverrideSetCommand) Synopsis() string {
+	return "Use a local provider build in this working directory"
+}
+
+func (c *ProvidersOverrideSetCommand) Run(args []string) int {
+	var buildCommand, sourceDir string
+
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.defaultFlagSet("providers override set")
+	cmdFlags.StringVar(&buildCommand, "build-command", "", "build command")
+	cmdFlags.StringVar(&sourceDir, "source-dir", "", "source directory")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return 1
+	}
+	args = cmdFlags.Args()
+	if len(args) != 2 {
+		c.Ui.Error("The providers override set command expects a provider source address and a directory.")
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	var diags tfdiags.Diagnostics
+
+	addr, addrDiags := addrs.ParseProviderSourceString(args[0])
+	if addrDiags.HasErrors() {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid provider address",
+			fmt.Sprintf("The string %q is not a valid provider source address: %s.", args[0], addrDiags.Err()),
+		))
+	} else if addr.IsBuiltIn() {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid provider address",
+			fmt.Sprintf("The provider %s is built in to OpenTofu and so cannot be overridden.", addr.ForDisplay()),
+		))
+	}
+	if sourceDir != "" && buildCommand == "" {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid command line options",
+			"The -source-dir option can be used only together with -build-command.",
+		))
+	}
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	var moreDiags tfdiags.Diagnostics
+	override := workdir.ProviderDevOverride{
+		BuildCommand: buildCommand,
+	}
+	override.Dir, moreDiags = overrideDirArg(args[1], "provider directory")
+	diags = diags.Append(moreDiags)
+	if sourceDir != "" {
+		override.SourceDir, moreDiags = overrideDirArg(sourceDir, "source directory")
+		diags = diags.Append(moreDiags)
+	}
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	c.fixupMissingWorkingDir()
+	overrides, err := c.WorkingDir.ProviderDevOverrides()
+	if err != nil {
+		// We can still replace the file if it's corrupt, so we just
+		// warn about the overrides we're discarding.
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Discarding invalid provider development overrides",
+			fmt.Sprintf("The existing provider development overrides for this working directory could not be read, and so will be replaced: %s.", err),
+		))
+		overrides = nil
+	}
+	if overrides == nil {
+		overrides = make(map[string]workdir.ProviderDevOverride, 1)
+	}
+	overrides[addr.String()] = override
+	if err := c.WorkingDir.SetProviderDevOverrides(overrides); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to save provider development overrides",
+			fmt.Sprintf("Could not record the provider development override for this working directory: %s.", err),
+		))
+		c.showDiagnostics(diags)
+		return 1
+	}
+	c.showDiagnostics(diags)
+
+	c.Ui.Output(fmt.Sprintf("OpenTofu will now use %s from %s in this working directory.", addr.ForDisplay(), override.Dir))
+	return 0
+}
+
+// overrideDirArg resolves the given directory path given on the command line
+// to an absolute path, so that the override remains valid regardless of the
+// current working directory of later commands.
+func overrideDirArg(path string, what string) (string, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	absPath, err := filepath.Abs(path)
+	if err == nil {
+		var info os.FileInfo
+		info, err = os.Stat(absPath)
+		if err == nil && !info.IsDir() {
+			err = fmt.Errorf("not a directory")
+		}
+	}
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid "+what,
+			fmt.Sprintf("Cannot use %s as the %s: %s.", path, what, err),
+		))
+	}
+	return absPath, diags
+}
+
+func (c *ProvidersOverrideSetCommand) Help() string {
+	return `
+Usage: tofu [global options] providers override set [options] PROVIDER DIR
+
+  Makes OpenTofu use the provider executable in the given local directory
+  for the given provider source address, ignoring the version selected in
+  the dependency lock file, for commands run in the current working
+  directory only.
+
+  The override is recorded in the working directory's data directory, and
+  takes precedence over any dev_overrides entry for the same provider in the
+  CLI configuration. Remove it again using "tofu providers override unset".
+
+Options:
+
+  -build-command=CMD  A shell command that builds the provider executable
+                      into DIR. OpenTofu runs this command before using the
+                      provider whenever the executable is missing or any
+                      file in the source directory has changed since the
+                      executable was built.
+
+  -source-dir=DIR     The directory containing the provider's source code,
+                      in which to run the build command. Defaults to the
+                      provider directory.
+`
+}
@@ -0,0 +1,119 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/command/workdir"
+	"github.com/opentofu/opentofu/internal/getproviders"
+)
+
+func TestProvidersOverride(t *testing.T) {
+	t.Chdir(t.TempDir())
+	providerDir := t.TempDir()
+	cliOverrides := map[addrs.Provider]getproviders.PackageLocalDir{
+		addrs.MustParseProviderSourceString("example.com/foo/cli"): "/cli/dir",
+		addrs.MustParseProviderSourceString("example.com/foo/bar"): "/cli/bar",
+	}
+	meta := func(ui cli.Ui) Meta {
+		return Meta{
+			Ui:                   ui,
+			WorkingDir:           workdir.NewDir("."),
+			ProviderDevOverrides: cliOverrides,
+		}
+	}
+
+	ui := cli.NewMockUi()
+	set := &ProvidersOverrideSetCommand{Meta: meta(ui)}
+	if code := set.Run([]string{"-build-command=make", "example.com/foo/bar", providerDir}); code != 0 {
+		t.Fatalf("wrong exit code %d\n%s", code, ui.ErrorWriter.String())
+	}
+
+	ui = cli.NewMockUi()
+	list := &ProvidersOverrideListCommand{Meta: meta(ui)}
+	if code := list.Run(nil); code != 0 {
+		t.Fatalf("wrong exit code %d\n%s", code, ui.ErrorWriter.String())
+	}
+	want := `example.com/foo/bar
+  directory:     ` + providerDir + `
+  set in:        working directory
+  build command: make
+
+example.com/foo/cli
+  directory:     /cli/dir
+  set in:        CLI configuration`
+	if got := strings.TrimSpace(ui.OutputWriter.String()); got != want {
+		t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	m := meta(nil)
+	if got, want := string(m.providerDevOverrides()[addrs.MustParseProviderSourceString("example.com/foo/bar")]), providerDir; got != want {
+		t.Errorf("working directory override did not take precedence\ngot:  %s\nwant: %s", got, want)
+	}
+	diags := m.providerDevOverrideRuntimeWarnings()
+	if got, want := diags.ErrWithWarnings().Error(), "set for this working directory:\n - example.com/foo/bar in "+providerDir+", rebuilt using \"make\""; !strings.Contains(got, want) {
+		t.Errorf("missing working directory override from warning\ngot:\n%s\nwant substring: %s", got, want)
+	}
+
+	ui = cli.NewMockUi()
+	unset := &ProvidersOverrideUnsetCommand{Meta: meta(ui)}
+	if code := unset.Run([]string{"example.com/foo/cli"}); code != 1 {
+		t.Fatalf("wrong exit code %d", code)
+	}
+	if got, want := ui.ErrorWriter.String(), "There is no development override for example.com/foo/cli"; !strings.Contains(got, want) {
+		t.Errorf("missing error\ngot:\n%s\nwant substring: %s", got, want)
+	}
+
+	ui = cli.NewMockUi()
+	unset = &ProvidersOverrideUnsetCommand{Meta: meta(ui)}
+	if code := unset.Run([]string{"example.com/foo/bar"}); code != 0 {
+		t.Fatalf("wrong exit code %d\n%s", code, ui.ErrorWriter.String())
+	}
+	if got := m.providerDevOverrides(); len(got) != len(cliOverrides) || got[addrs.MustParseProviderSourceString("example.com/foo/bar")] != "/cli/bar" {
+		t.Errorf("wrong overrides after unset: %#v", got)
+	}
+}
+
+func TestProvidersOverrideSet_invalid(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	tests := map[string]struct {
+		args    []string
+		wantErr string
+	}{
+		"invalid address": {
+			[]string{"not/a/valid/address/at/all", "."},
+			"Invalid provider address",
+		},
+		"built-in provider": {
+			[]string{"terraform.io/builtin/terraform", "."},
+			"built in to OpenTofu",
+		},
+		"missing directory": {
+			[]string{"example.com/foo/bar", "does-not-exist"},
+			"Invalid provider directory",
+		},
+		"source dir without build command": {
+			[]string{"-source-dir=.", "example.com/foo/bar", "."},
+			"can be used only together with -build-command",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ProvidersOverrideSetCommand{Meta: Meta{Ui: ui}}
+			if code := c.Run(test.args); code != 1 {
+				t.Fatalf("wrong exit code %d", code)
+			}
+			if got := ui.ErrorWriter.String(); !strings.Contains(got, test.wantErr) {
+				t.Errorf("missing error\ngot:\n%s\nwant substring: %s", got, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// ProvidersOverrideUnsetCommand is a Command implementation that implements
+// the "tofu providers override unset" command, which removes provider
+// development overrides from the current working directory.
+type ProvidersOverrideUnsetCommand struct {
+	Meta
+}
+
+func (c *ProvidersOverrideUnsetCommand) Synopsis() string {
+	return "Stop using a local provider build in this working directory"
+}
+
+func (c *ProvidersOverrideUnsetCommand) Run(args []string) int {
+	var all bool
+
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.defaultFlagSet("providers override unset")
+	cmdFlags.BoolVar(&all, "all", false, "all")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return 1
+	}
+	args = cmdFlags.Args()
+	if all == (len(args) != 0) {
+		c.Ui.Error("The providers override unset command expects either the -all option or at least one provider source address.")
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	var diags tfdiags.Diagnostics
+
+	c.fixupMissingWorkingDir()
+	if all {
+		if err := c.WorkingDir.SetProviderDevOverrides(nil); err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to remove provider development overrides",
+				fmt.Sprintf("Could not remove the provider development overrides for this working directory: %s.", err),
+			))
+			c.showDiagnostics(diags)
+			return 1
+		}
+		c.Ui.Output("Removed all provider development overrides for this working directory.")
+		return 0
+	}
+
+	overrides, err := c.WorkingDir.ProviderDevOverrides()
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read provider development overrides",
+			fmt.Sprintf("Could not read the provider development overrides for this working directory: %s.\n\nUse the -all option to remove all of the overrides.", err),
+		))
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	var removed []addrs.Provider
+	for _, arg := range args {
+		addr, addrDiags := addrs.ParseProviderSourceString(arg)
+		if addrDiags.HasErrors() {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Invalid provider address",
+				fmt.Sprintf("The string %q is not a valid provider source address: %s.", arg, addrDiags.Err()),
+			))
+			continue
+		}
+		if _, ok := overrides[addr.String()]; !ok {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Provider not overridden",
+				fmt.Sprintf("There is no development override for %s in this working directory.", addr.ForDisplay()),
+			))
+			continue
+		}
+		delete(overrides, addr.String())
+		removed = append(removed, addr)
+	}
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	if err := c.WorkingDir.SetProviderDevOverrides(overrides); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to save provider development overrides",
+			fmt.Sprintf("Could not update the provider development overrides for this working directory: %s.", err),
+		))
+		c.showDiagnostics(diags)
+		return 1
+	}
+	for _, addr := range removed {
+		c.Ui.Output(fmt.Sprintf("- Removed the development override for %s", addr.ForDisplay()))
+	}
+	return 0
+}
+
+func (c *ProvidersOverrideUnsetCommand) Help() string {
+	return `
+Usage: tofu [global options] providers override unset [options] [PROVIDER...]
+
+  Removes provider development overrides previously recorded for the
+  current working directory by "tofu providers override set", so that
+  OpenTofu returns to using the versions selected in the dependency lock
+  file.
+
+  This command does not affect dev_overrides in the CLI configuration.
+
+Options:
+
+  -all  Remove all of the overrides for this working directory instead of
+        only those for the given providers.
+`
+}
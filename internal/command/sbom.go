@@ -0,0 +1,299 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/command/workdir"
+	"github.com/opentofu/opentofu/internal/depsfile"
+	"github.com/opentofu/opentofu/internal/getmodules"
+	"github.com/opentofu/opentofu/internal/getproviders"
+	"github.com/opentofu/opentofu/internal/modsdir"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// SBOMCommand is a Command implementation that implements the "tofu sbom"
+// command, which produces a software bill of materials describing the
+// providers and modules installed in the current working directory.
+type SBOMCommand struct {
+	Meta
+}
+
+// sbomComponent is the format-agnostic description of a single dependency
+// that we then translate into whichever SBOM format was requested.
+type sbomComponent struct {
+	// Kind is either "provider" or "module".
+	Kind string
+
+	// Address is the provider source address or the module key, which
+	// together with Kind uniquely identifies the component.
+	Address string
+
+	Name    string
+	Group   string
+	Version string
+
+	// Source is the module source address, which is empty for providers.
+	Source string
+
+	// Package is the address of the remote package that a module was
+	// installed from, if known.
+	Package string
+
+	// Commit is the resolved git commit of a module installed from a git
+	// repository, if known.
+	Commit string
+
+	// Hashes are the checksums recorded in the dependency lock file.
+	Hashes []getproviders.Hash
+
+	// Authentication, GPGKeyIDs and OCISigners describe how a provider
+	// package was authenticated when it was installed, if known.
+	Authentication string
+	GPGKeyIDs      []string
+	OCISigners     []string
+
+	// DevOverride is true for providers whose installed package is
+	// overridden by a dev_overrides setting in the CLI configuration.
+	DevOverride bool
+}
+
+// sbomSubject describes the working directory that an SBOM describes.
+type sbomSubject struct {
+	Name       string
+	Created    time.Time
+	Components []sbomComponent
+}
+
+func (c *SBOMCommand) Synopsis() string {
+	return "Produce a software bill of materials for dependencies"
+}
+
+func (c *SBOMCommand) Run(args []string) int {
+	var format, outPath string
+
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.defaultFlagSet("sbom")
+	cmdFlags.StringVar(&format, "format", "cyclonedx", "output format")
+	cmdFlags.StringVar(&outPath, "out", "", "output file")
+	cmdFlags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return 1
+	}
+	if len(cmdFlags.Args()) != 0 {
+		c.Ui.Error("The sbom command expects no positional arguments.")
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	var diags tfdiags.Diagnostics
+
+	var encode func(*sbomSubject) ([]byte, error)
+	switch format {
+	case "cyclonedx":
+		encode = encodeCycloneDX
+	case "spdx":
+		encode = encodeSPDX
+	default:
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid SBOM format",
+			fmt.Sprintf("The -format option must be either \"cyclonedx\" or \"spdx\", not %q.", format),
+		))
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	locks, lockDiags := c.lockedDependencies()
+	diags = diags.Append(lockDiags)
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	manifest, err := modsdir.ReadManifestSnapshotForDir(c.modulesDir())
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read module manifest",
+			fmt.Sprintf("Error reading the manifest of installed modules: %s.", err),
+		))
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	if locks.Empty() && len(manifest) == 0 {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Working directory not initialized",
+			"There are no installed providers or modules to report. Run \"tofu init\" to install the dependencies of the configuration before producing a software bill of materials.",
+		))
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	c.fixupMissingWorkingDir()
+	auths, err := c.WorkingDir.ProviderAuthentications()
+	if err != nil {
+		// The authentication results are only supplementary, so we'll
+		// just omit them if they are unreadable.
+		log.Printf("[WARN] Ignoring invalid provider authentication results: %s", err)
+		auths = nil
+	}
+
+	subject := &sbomSubject{
+		Created: time.Now().UTC(),
+	}
+	if wd, err := os.Getwd(); err == nil {
+		subject.Name = filepath.Base(wd)
+	}
+	subject.Components = append(subject.Components, sbomProviderComponents(locks, auths)...)
+	subject.Components = append(subject.Components, sbomModuleComponents(manifest, locks)...)
+
+	src, err := encode(subject)
+	if err != nil {
+		// Should never happen because the input is entirely under our
+		// control.
+		panic(fmt.Sprintf("failed to encode software bill of materials: %s", err))
+	}
+
+	if outPath == "" {
+		c.showDiagnostics(diags)
+		c.Ui.Output(string(src))
+		return 0
+	}
+	if err := os.WriteFile(outPath, append(src, '\n'), 0644); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to write software bill of materials",
+			fmt.Sprintf("Error writing %s: %s.", outPath, err),
+		))
+	}
+	c.showDiagnostics(diags)
+	if diags.HasErrors() {
+		return 1
+	}
+	return 0
+}
+
+// sbomProviderComponents returns a component for each provider selected in
+// the given locks, in lexical order by source address.
+func sbomProviderComponents(locks *depsfile.Locks, auths map[string]workdir.ProviderAuthentication) []sbomComponent {
+	var ret []sbomComponent
+	for provider, lock := range locks.AllProviders() {
+		comp := sbomComponent{
+			Kind:        "provider",
+			Address:     provider.String(),
+			Name:        provider.Type,
+			Group:       provider.Hostname.ForDisplay() + "/" + provider.Namespace,
+			Version:     lock.Version().String(),
+			Hashes:      lock.AllHashes(),
+			DevOverride: locks.ProviderIsOverridden(provider),
+		}
+		// The recorded authentication results are relevant only if they
+		// are for the version that is currently selected.
+		if auth, ok := auths[comp.Address]; ok && auth.Version == comp.Version {
+			comp.Authentication = auth.Result
+			comp.GPGKeyIDs = auth.GPGKeyIDs
+			comp.OCISigners = auth.OCISigners
+		}
+		ret = append(ret, comp)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Address < ret[j].Address
+	})
+	return ret
+}
+
+// sbomModuleComponents returns a component for each remote module in the
+// given manifest, in lexical order by module key. Local modules are part of
+// the package of their caller, and so are not components in their own right.
+func sbomModuleComponents(manifest modsdir.Manifest, locks *depsfile.Locks) []sbomComponent {
+	var ret []sbomComponent
+	for key, record := range manifest {
+		if key == "" {
+			continue // the root module is the subject of the SBOM
+		}
+		if addr, err := addrs.ParseModuleSource(record.SourceAddr); err == nil {
+			if _, isLocal := addr.(addrs.ModuleSourceLocal); isLocal {
+				continue
+			}
+		}
+
+		comp := sbomComponent{
+			Kind:    "module",
+			Address: key,
+			Name:    key[strings.LastIndexByte(key, '.')+1:],
+			Source:  record.SourceAddr,
+		}
+		if record.Version != nil {
+			comp.Version = record.Version.String()
+		}
+		if lock := locks.Module(key); lock != nil {
+			if comp.Version == "" && lock.Version() != nil {
+				comp.Version = lock.Version().String()
+			}
+			comp.Package = lock.Package()
+			comp.Hashes = lock.AllHashes()
+		}
+		comp.Commit = sbomGitCommit(comp.Package)
+		ret = append(ret, comp)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Address < ret[j].Address
+	})
+	return ret
+}
+
+// sbomGitCommit returns the commit that the given git package address
+// refers to, or an empty string if it isn't a git package address or if its
+// ref is not a commit.
+func sbomGitCommit(pkg string) string {
+	raw, isGit := strings.CutPrefix(pkg, "git::")
+	if !isGit {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	ref := u.Query().Get("ref")
+	if !getmodules.IsGitCommit(ref) {
+		return ""
+	}
+	return ref
+}
+
+func (c *SBOMCommand) Help() string {
+	return `
+Usage: tofu [global options] sbom [options]
+
+  Produces a software bill of materials (SBOM) describing the providers and
+  remote modules installed in the current working directory, including their
+  versions and the checksums recorded in the dependency lock file.
+
+  The dependencies must already be installed using "tofu init". Providers
+  installed by "tofu init" also include details of how their packages were
+  authenticated, such as the IDs of the keys that signed them.
+
+Options:
+
+  -format=FORMAT  The SBOM format to produce: either "cyclonedx" for
+                  CycloneDX 1.5 JSON, or "spdx" for SPDX 2.3 JSON. Defaults
+                  to "cyclonedx".
+
+  -out=FILE       Write the SBOM to the given file instead of to the
+                  standard output.
+`
+}
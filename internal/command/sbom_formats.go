@@ -0,0 +1,280 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+
+	"github.com/opentofu/opentofu/internal/getproviders"
+	tfversion "github.com/opentofu/opentofu/version"
+)
+
+// The types in this file describe only the subset of the CycloneDX and SPDX
+// JSON formats that "tofu sbom" produces.
+
+type cyclonedxBOM struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	SerialNumber string                `json:"serialNumber"`
+	Version      int                   `json:"version"`
+	Metadata     cyclonedxMetadata     `json:"metadata"`
+	Components   []cyclonedxComponent  `json:"components"`
+	Dependencies []cyclonedxDependency `json:"dependencies"`
+}
+
+type cyclonedxMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     cyclonedxTools     `json:"tools"`
+	Component cyclonedxComponent `json:"component"`
+}
+
+type cyclonedxTools struct {
+	Components []cyclonedxComponent `json:"components"`
+}
+
+type cyclonedxComponent struct {
+	Type               string                       `json:"type"`
+	BOMRef             string                       `json:"bom-ref,omitempty"`
+	Group              string                       `json:"group,omitempty"`
+	Name               string                       `json:"name"`
+	Version            string                       `json:"version,omitempty"`
+	Hashes             []cyclonedxHash              `json:"hashes,omitempty"`
+	ExternalReferences []cyclonedxExternalReference `json:"externalReferences,omitempty"`
+	Properties         []cyclonedxProperty          `json:"properties,omitempty"`
+}
+
+type cyclonedxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cyclonedxExternalReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cyclonedxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cyclonedxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// encodeCycloneDX returns a CycloneDX 1.5 JSON document describing the given
+// subject.
+func encodeCycloneDX(subject *sbomSubject) ([]byte, error) {
+	serial, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	const rootRef = "root"
+	bom := cyclonedxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + serial,
+		Version:      1,
+		Metadata: cyclonedxMetadata{
+			Timestamp: subject.Created.Format(time.RFC3339),
+			Tools: cyclonedxTools{
+				Components: []cyclonedxComponent{
+					{Type: "application", Name: "OpenTofu", Version: tfversion.String()},
+				},
+			},
+			Component: cyclonedxComponent{
+				Type:   "application",
+				BOMRef: rootRef,
+				Name:   subject.Name,
+			},
+		},
+		Components: []cyclonedxComponent{},
+	}
+
+	root := cyclonedxDependency{Ref: rootRef}
+	for _, comp := range subject.Components {
+		ret := cyclonedxComponent{
+			Type:    "library",
+			BOMRef:  comp.Kind + ":" + comp.Address,
+			Group:   comp.Group,
+			Name:    comp.Name,
+			Version: comp.Version,
+		}
+		if comp.Kind == "provider" {
+			// Providers are executables that OpenTofu runs as plugins.
+			ret.Type = "application"
+		}
+		for _, hash := range comp.Hashes {
+			if hash.HasScheme(getproviders.HashSchemeZip) {
+				ret.Hashes = append(ret.Hashes, cyclonedxHash{Alg: "SHA-256", Content: hash.Value()})
+			}
+		}
+		if comp.Package != "" {
+			ret.ExternalReferences = append(ret.ExternalReferences, cyclonedxExternalReference{
+				Type: "distribution",
+				URL:  comp.Package,
+			})
+		}
+		for _, prop := range sbomProperties(comp) {
+			ret.Properties = append(ret.Properties, cyclonedxProperty{Name: "opentofu:" + prop[0], Value: prop[1]})
+		}
+		bom.Components = append(bom.Components, ret)
+		root.DependsOn = append(root.DependsOn, ret.BOMRef)
+	}
+	bom.Dependencies = []cyclonedxDependency{root}
+
+	return json.MarshalIndent(bom, "", "  ")
+}
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string         `json:"name"`
+	SPDXID           string         `json:"SPDXID"`
+	VersionInfo      string         `json:"versionInfo,omitempty"`
+	DownloadLocation string         `json:"downloadLocation"`
+	FilesAnalyzed    bool           `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum `json:"checksums,omitempty"`
+	Comment          string         `json:"comment,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// spdxInvalidIDChars matches the characters that are not permitted in an
+// SPDX element ID.
+var spdxInvalidIDChars = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// encodeSPDX returns an SPDX 2.3 JSON document describing the given subject.
+func encodeSPDX(subject *sbomSubject) ([]byte, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	name := subject.Name
+	if name == "" {
+		name = "opentofu-configuration"
+	}
+	const rootID = "SPDXRef-Root"
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              name,
+		DocumentNamespace: fmt.Sprintf("https://opentofu.org/spdxdocs/%s-%s", spdxInvalidIDChars.ReplaceAllString(name, "-"), id),
+		CreationInfo: spdxCreationInfo{
+			Created:  subject.Created.Format(time.RFC3339),
+			Creators: []string{"Tool: OpenTofu-" + tfversion.String()},
+		},
+		Packages: []spdxPackage{
+			{
+				Name:             name,
+				SPDXID:           rootID,
+				DownloadLocation: "NOASSERTION",
+			},
+		},
+		Relationships: []spdxRelationship{
+			{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: rootID},
+		},
+	}
+
+	for _, comp := range subject.Components {
+		pkg := spdxPackage{
+			Name:             comp.Address,
+			SPDXID:           "SPDXRef-" + comp.Kind + "-" + spdxInvalidIDChars.ReplaceAllString(comp.Address, "-"),
+			VersionInfo:      comp.Version,
+			DownloadLocation: "NOASSERTION",
+		}
+		if comp.Package != "" {
+			pkg.DownloadLocation = comp.Package
+		}
+		for _, hash := range comp.Hashes {
+			if hash.HasScheme(getproviders.HashSchemeZip) {
+				pkg.Checksums = append(pkg.Checksums, spdxChecksum{Algorithm: "SHA256", ChecksumValue: hash.Value()})
+			}
+		}
+		// SPDX has no general extension mechanism like CycloneDX
+		// properties, so we describe everything else in the comment.
+		var comment []string
+		for _, prop := range sbomProperties(comp) {
+			comment = append(comment, prop[0]+": "+prop[1])
+		}
+		pkg.Comment = strings.Join(comment, "\n")
+
+		doc.Packages = append(doc.Packages, pkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID:      rootID,
+			RelationshipType:   "DEPENDS_ON",
+			RelatedSPDXElement: pkg.SPDXID,
+		})
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// sbomProperties returns name/value pairs describing the details of the
+// given component that neither SBOM format has a dedicated field for.
+func sbomProperties(comp sbomComponent) [][2]string {
+	var ret [][2]string
+	if comp.Kind == "provider" {
+		ret = append(ret, [2]string{"provider_address", comp.Address})
+	} else {
+		ret = append(ret, [2]string{"module_key", comp.Address})
+	}
+	if comp.Source != "" {
+		ret = append(ret, [2]string{"source", comp.Source})
+	}
+	if comp.Commit != "" {
+		ret = append(ret, [2]string{"commit", comp.Commit})
+	}
+	for _, hash := range comp.Hashes {
+		if !hash.HasScheme(getproviders.HashSchemeZip) {
+			ret = append(ret, [2]string{"hash", hash.String()})
+		}
+	}
+	if comp.Authentication != "" {
+		ret = append(ret, [2]string{"authentication", comp.Authentication})
+	}
+	for _, keyID := range comp.GPGKeyIDs {
+		ret = append(ret, [2]string{"gpg_key_id", keyID})
+	}
+	for _, signer := range comp.OCISigners {
+		ret = append(ret, [2]string{"oci_signer", signer})
+	}
+	if comp.DevOverride {
+		ret = append(ret, [2]string{"dev_override", "true"})
+	}
+	return ret
+}
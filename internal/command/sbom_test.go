@@ -0,0 +1,190 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mitchellh/cli"
+)
+
+func TestSBOM_cyclonedx(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath("sbom"), td)
+	t.Chdir(td)
+
+	ui := cli.NewMockUi()
+	c := &SBOMCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+	if code := c.Run(nil); code != 0 {
+		t.Fatalf("wrong exit code %d\n%s", code, ui.ErrorWriter.String())
+	}
+
+	var got struct {
+		BOMFormat   string `json:"bomFormat"`
+		SpecVersion string `json:"specVersion"`
+		Components  []struct {
+			Type    string `json:"type"`
+			BOMRef  string `json:"bom-ref"`
+			Name    string `json:"name"`
+			Version string `json:"version"`
+			Hashes  []struct {
+				Alg     string `json:"alg"`
+				Content string `json:"content"`
+			} `json:"hashes"`
+			Properties []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"properties"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON output: %s", err)
+	}
+	if got.BOMFormat != "CycloneDX" || got.SpecVersion != "1.5" {
+		t.Errorf("wrong format %q %q", got.BOMFormat, got.SpecVersion)
+	}
+
+	var refs []string
+	props := make(map[string][]string)
+	for _, comp := range got.Components {
+		refs = append(refs, comp.BOMRef+"@"+comp.Version)
+		for _, prop := range comp.Properties {
+			props[comp.BOMRef] = append(props[comp.BOMRef], prop.Name+"="+prop.Value)
+		}
+		if comp.BOMRef == "provider:registry.opentofu.org/hashicorp/aws" {
+			if len(comp.Hashes) != 1 || comp.Hashes[0].Content != "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef" {
+				t.Errorf("wrong hashes for aws provider: %#v", comp.Hashes)
+			}
+		}
+	}
+	wantRefs := []string{
+		"provider:registry.opentofu.org/hashicorp/aws@5.0.0",
+		"provider:registry.opentofu.org/hashicorp/null@3.0.0",
+		"module:network@1.2.0",
+		"module:vpc@",
+	}
+	if diff := cmp.Diff(wantRefs, refs); diff != "" {
+		t.Errorf("wrong components\n%s", diff)
+	}
+	wantProps := map[string][]string{
+		"provider:registry.opentofu.org/hashicorp/aws": {
+			"opentofu:provider_address=registry.opentofu.org/hashicorp/aws",
+			"opentofu:hash=h1:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa=",
+			"opentofu:authentication=signed",
+			"opentofu:gpg_key_id=34365D9472D7468F",
+		},
+		// The recorded authentication result for the null provider is
+		// for a different version than the one selected, so it's omitted.
+		"provider:registry.opentofu.org/hashicorp/null": {
+			"opentofu:provider_address=registry.opentofu.org/hashicorp/null",
+		},
+		"module:network": {
+			"opentofu:module_key=network",
+			"opentofu:source=registry.opentofu.org/acme/network/aws",
+			"opentofu:hash=h1:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb=",
+		},
+		"module:vpc": {
+			"opentofu:module_key=vpc",
+			"opentofu:source=git::https://example.com/vpc.git?ref=main",
+			"opentofu:commit=0123456789abcdef0123456789abcdef01234567",
+		},
+	}
+	if diff := cmp.Diff(wantProps, props); diff != "" {
+		t.Errorf("wrong properties\n%s", diff)
+	}
+}
+
+func TestSBOM_spdx(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath("sbom"), td)
+	t.Chdir(td)
+
+	ui := cli.NewMockUi()
+	c := &SBOMCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+	outPath := filepath.Join(td, "sbom.spdx.json")
+	if code := c.Run([]string{"-format=spdx", "-out=" + outPath}); code != 0 {
+		t.Fatalf("wrong exit code %d\n%s", code, ui.ErrorWriter.String())
+	}
+	if got := ui.OutputWriter.String(); got != "" {
+		t.Errorf("unexpected output: %s", got)
+	}
+
+	src, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		SPDXVersion string `json:"spdxVersion"`
+		Packages    []struct {
+			SPDXID           string `json:"SPDXID"`
+			VersionInfo      string `json:"versionInfo"`
+			DownloadLocation string `json:"downloadLocation"`
+			Checksums        []struct {
+				Algorithm string `json:"algorithm"`
+			} `json:"checksums"`
+		} `json:"packages"`
+		Relationships []struct {
+			SPDXElementID      string `json:"spdxElementId"`
+			RelationshipType   string `json:"relationshipType"`
+			RelatedSPDXElement string `json:"relatedSpdxElement"`
+		} `json:"relationships"`
+	}
+	if err := json.Unmarshal(src, &got); err != nil {
+		t.Fatalf("invalid JSON output: %s", err)
+	}
+	if got.SPDXVersion != "SPDX-2.3" {
+		t.Errorf("wrong version %q", got.SPDXVersion)
+	}
+
+	var pkgs []string
+	for _, pkg := range got.Packages {
+		pkgs = append(pkgs, strings.Join([]string{pkg.SPDXID, pkg.VersionInfo, pkg.DownloadLocation}, " "))
+	}
+	wantPkgs := []string{
+		"SPDXRef-Root  NOASSERTION",
+		"SPDXRef-provider-registry.opentofu.org-hashicorp-aws 5.0.0 NOASSERTION",
+		"SPDXRef-provider-registry.opentofu.org-hashicorp-null 3.0.0 NOASSERTION",
+		"SPDXRef-module-network 1.2.0 https://example.com/network-1.2.0.tar.gz",
+		"SPDXRef-module-vpc  git::https://example.com/vpc.git?ref=0123456789abcdef0123456789abcdef01234567",
+	}
+	if diff := cmp.Diff(wantPkgs, pkgs); diff != "" {
+		t.Errorf("wrong packages\n%s", diff)
+	}
+	if len(got.Packages[1].Checksums) != 1 || got.Packages[1].Checksums[0].Algorithm != "SHA256" {
+		t.Errorf("wrong checksums for aws provider: %#v", got.Packages[1].Checksums)
+	}
+	if len(got.Relationships) != 5 || got.Relationships[0].RelationshipType != "DESCRIBES" {
+		t.Errorf("wrong relationships: %#v", got.Relationships)
+	}
+}
+
+func TestSBOM_notInitialized(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	ui := cli.NewMockUi()
+	c := &SBOMCommand{
+		Meta: Meta{
+			Ui: ui,
+		},
+	}
+	if code := c.Run(nil); code != 1 {
+		t.Fatalf("wrong exit code %d; want 1", code)
+	}
+	if got, want := ui.ErrorWriter.String(), "Working directory not initialized"; !strings.Contains(got, want) {
+		t.Errorf("missing expected error\ngot: %s\nwant substring: %s", got, want)
+	}
+}
@@ -160,6 +160,7 @@ func (c *StatePushCommand) Run(args []string) int {
 		c.Ui.Error(fmt.Sprintf("Failed to persist state: %s", err))
 		return 1
 	}
+	statemgr.Flush(stateMgr)
 
 	c.showDiagnostics(diags)
 	return 0
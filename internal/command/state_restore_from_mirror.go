@@ -0,0 +1,174 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/opentofu/opentofu/internal/command/arguments"
+	"github.com/opentofu/opentofu/internal/command/clistate"
+	"github.com/opentofu/opentofu/internal/command/views"
+	"github.com/opentofu/opentofu/internal/states/statemgr"
+)
+
+// StateRestoreFromMirrorCommand is a Command implementation that replaces
+// the state of the current workspace with the copy held by the mirror
+// backend configured alongside the primary backend.
+type StateRestoreFromMirrorCommand struct {
+	Meta
+}
+
+func (c *StateRestoreFromMirrorCommand) Run(args []string) int {
+	ctx := c.CommandContext()
+	args = c.Meta.process(args)
+	var flagForce bool
+	cmdFlags := c.Meta.ignoreRemoteVersionFlagSet("state restore-from-mirror")
+	cmdFlags.BoolVar(&flagForce, "force", false, "")
+	cmdFlags.BoolVar(&c.Meta.stateLock, "lock", true, "lock state")
+	cmdFlags.DurationVar(&c.Meta.stateLockTimeout, "lock-timeout", 0, "lock timeout")
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return 1
+	}
+	if len(cmdFlags.Args()) != 0 {
+		c.Ui.Error("This command takes no arguments.\n")
+		return cli.RunResultHelp
+	}
+
+	if diags := c.Meta.checkRequiredVersion(ctx); diags != nil {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	// Load the encryption configuration
+	enc, encDiags := c.Encryption(ctx)
+	if encDiags.HasErrors() {
+		c.showDiagnostics(encDiags)
+		return 1
+	}
+
+	backendConfig, diags := c.loadBackendConfig(ctx, ".")
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+	if backendConfig == nil || backendConfig.Mirror == nil {
+		c.Ui.Error("The backend configuration does not include a mirror block, so there is no mirror to restore from.")
+		return 1
+	}
+
+	mirror, diags := c.backendInitMirror(ctx, backendConfig, enc.State())
+	if diags.HasErrors() {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	// Load the primary backend
+	b, backendDiags := c.Backend(ctx, &BackendOpts{Config: backendConfig}, enc.State())
+	if backendDiags.HasErrors() {
+		c.showDiagnostics(backendDiags)
+		return 1
+	}
+
+	// Determine the workspace name
+	workspace, err := c.Workspace(ctx)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error selecting workspace: %s", err))
+		return 1
+	}
+
+	// Check remote OpenTofu version is compatible
+	remoteVersionDiags := c.remoteVersionCheck(b, workspace)
+	c.showDiagnostics(remoteVersionDiags)
+	if remoteVersionDiags.HasErrors() {
+		return 1
+	}
+
+	mirrorMgr, err := mirror.StateMgr(ctx, workspace)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load mirror state: %s", err))
+		return 1
+	}
+	if err := mirrorMgr.RefreshState(ctx); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to refresh mirror state: %s", err))
+		return 1
+	}
+	srcStateFile := statemgr.Export(mirrorMgr)
+	if srcStateFile == nil || srcStateFile.State == nil || srcStateFile.State.Empty() {
+		c.Ui.Error(fmt.Sprintf("The mirror has no state for workspace %q.", workspace))
+		return 1
+	}
+
+	// Get the state manager for the currently-selected workspace
+	stateMgr, err := b.StateMgr(ctx, workspace)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to load destination state: %s", err))
+		return 1
+	}
+
+	if c.stateLock {
+		stateLocker := clistate.NewLocker(c.stateLockTimeout, views.NewStateLocker(arguments.ViewHuman, c.View))
+		if diags := stateLocker.Lock(stateMgr, "state-restore-from-mirror"); diags.HasErrors() {
+			c.showDiagnostics(diags)
+			return 1
+		}
+		defer func() {
+			if diags := stateLocker.Unlock(); diags.HasErrors() {
+				c.showDiagnostics(diags)
+			}
+		}()
+	}
+
+	if err := stateMgr.RefreshState(ctx); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to refresh destination state: %s", err))
+		return 1
+	}
+
+	// Import it, forcing through the lineage/serial if requested and possible.
+	if err := statemgr.Import(srcStateFile, stateMgr, flagForce); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to write state: %s", err))
+		return 1
+	}
+	if err := stateMgr.PersistState(ctx, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to persist state: %s", err))
+		return 1
+	}
+	statemgr.Flush(stateMgr)
+
+	c.Ui.Output(fmt.Sprintf("Restored state for workspace %q from the %q mirror (serial %d).", workspace, backendConfig.Mirror.Type, srcStateFile.Serial))
+	return 0
+}
+
+func (c *StateRestoreFromMirrorCommand) Help() string {
+	helpText := `
+Usage: tofu [global options] state restore-from-mirror [options]
+
+  Replace the state of the current workspace with the copy held by the
+  backend mirror.
+
+  The mirror is configured using a "mirror" block inside the "backend"
+  block. This command will protect you against writing an older serial or
+  a different state lineage unless you specify the "-force" flag.
+
+Options:
+
+  -force              Write the state even if lineages don't match or the
+                      remote serial is higher.
+
+  -lock=false         Don't hold a state lock during the operation. This is
+                      dangerous if others might concurrently run commands
+                      against the same workspace.
+
+  -lock-timeout=0s    Duration to retry a state lock.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *StateRestoreFromMirrorCommand) Synopsis() string {
+	return "Restore state from the backend mirror"
+}
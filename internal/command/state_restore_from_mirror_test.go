@@ -0,0 +1,92 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestStateRestoreFromMirror(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath("state-restore-from-mirror"), td)
+	t.Chdir(td)
+
+	expected := testStateRead(t, "mirror.tfstate")
+
+	p := testProvider()
+	ui := new(cli.MockUi)
+	view, _ := testView(t)
+	c := &StateRestoreFromMirrorCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(p),
+			Ui:               ui,
+			View:             view,
+		},
+	}
+
+	if code := c.Run(nil); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	actual := testStateRead(t, "local-state.tfstate")
+	if !actual.Equal(expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+	if got := ui.OutputWriter.String(); !strings.Contains(got, `Restored state for workspace "default"`) {
+		t.Fatalf("unexpected output: %s", got)
+	}
+}
+
+func TestStateRestoreFromMirror_noMirror(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath("state-push-good"), td)
+	t.Chdir(td)
+
+	ui := new(cli.MockUi)
+	view, _ := testView(t)
+	c := &StateRestoreFromMirrorCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+			View:             view,
+		},
+	}
+
+	if code := c.Run(nil); code != 1 {
+		t.Fatalf("wrong exit code %d; want 1", code)
+	}
+	if got := ui.ErrorWriter.String(); !strings.Contains(got, "does not include a mirror block") {
+		t.Fatalf("unexpected error output: %s", got)
+	}
+}
+
+func TestStateRestoreFromMirror_emptyMirror(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath("state-restore-from-mirror"), td)
+	t.Chdir(td)
+	if err := os.Remove("mirror.tfstate"); err != nil {
+		t.Fatal(err)
+	}
+
+	ui := new(cli.MockUi)
+	view, _ := testView(t)
+	c := &StateRestoreFromMirrorCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+			View:             view,
+		},
+	}
+
+	if code := c.Run(nil); code != 1 {
+		t.Fatalf("wrong exit code %d; want 1", code)
+	}
+	if got := ui.ErrorWriter.String(); !strings.Contains(got, `The mirror has no state for workspace "default"`) {
+		t.Fatalf("unexpected error output: %s", got)
+	}
+}
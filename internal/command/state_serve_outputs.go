@@ -0,0 +1,268 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opentofu/opentofu/internal/encryption"
+	"github.com/opentofu/opentofu/internal/states"
+	"github.com/opentofu/opentofu/internal/states/statefile"
+	"github.com/opentofu/opentofu/internal/states/statemgr"
+)
+
+// StateServeOutputsCommand is a Command implementation that serves the root
+// module outputs of the current workspace over HTTP, in a form that the
+// "http" backend can read, so that terraform_remote_state data sources can
+// consume them without access to the backend holding the full state.
+type StateServeOutputsCommand struct {
+	Meta
+	StateMeta
+}
+
+func (c *StateServeOutputsCommand) Run(args []string) int {
+	ctx := c.CommandContext()
+
+	args = c.Meta.process(args)
+	var address, tokenFile string
+	var includeSensitive bool
+	cmdFlags := c.Meta.defaultFlagSet("state serve-outputs")
+	c.Meta.varFlagSet(cmdFlags)
+	cmdFlags.StringVar(&address, "address", "127.0.0.1:8080", "listen address")
+	cmdFlags.StringVar(&tokenFile, "token-file", "", "bearer token file")
+	cmdFlags.BoolVar(&includeSensitive, "include-sensitive", false, "serve sensitive outputs")
+	if err := cmdFlags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing command-line flags: %s\n", err.Error()))
+		return 1
+	}
+
+	var token string
+	if tokenFile != "" {
+		raw, err := os.ReadFile(tokenFile)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to read token file: %s", err))
+			return 1
+		}
+		token = strings.TrimSpace(string(raw))
+		if token == "" {
+			c.Ui.Error(fmt.Sprintf("The token file %s is empty.", tokenFile))
+			return 1
+		}
+	}
+
+	if diags := c.Meta.checkRequiredVersion(ctx); diags != nil {
+		c.showDiagnostics(diags)
+		return 1
+	}
+
+	// Load the encryption configuration
+	enc, encDiags := c.Encryption(ctx)
+	if encDiags.HasErrors() {
+		c.showDiagnostics(encDiags)
+		return 1
+	}
+
+	// Load the backend
+	b, backendDiags := c.Backend(ctx, nil, enc.State())
+	if backendDiags.HasErrors() {
+		c.showDiagnostics(backendDiags)
+		return 1
+	}
+
+	// This is a read-only command
+	c.ignoreRemoteVersionConflict(b)
+
+	// Get the state manager for the current workspace
+	env, err := c.Workspace(ctx)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error selecting workspace: %s", err))
+		return 1
+	}
+	stateMgr, err := b.StateMgr(ctx, env)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(errStateLoadingState, err))
+		return 1
+	}
+	// We refresh once up front so that problems reading the state are
+	// reported immediately, rather than on the first request.
+	if err := stateMgr.RefreshState(ctx); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to refresh state: %s", err))
+		return 1
+	}
+
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to listen on %s: %s", address, err))
+		return 1
+	}
+
+	ctx, done := c.InterruptibleContext(ctx)
+	defer done()
+
+	srv := &http.Server{
+		Handler: &stateOutputsHandler{
+			stateMgr:         stateMgr,
+			token:            token,
+			includeSensitive: includeSensitive,
+		},
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("[WARN] state serve-outputs: failed to shut down cleanly: %s", err)
+		}
+	}()
+
+	c.Ui.Output(fmt.Sprintf("Serving outputs of workspace %q at http://%s/ (press Ctrl-C to stop)", env, ln.Addr()))
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		c.Ui.Error(fmt.Sprintf("Failed to serve outputs: %s", err))
+		return 1
+	}
+	return 0
+}
+
+func (c *StateServeOutputsCommand) Help() string {
+	helpText := `
+Usage: tofu [global options] state serve-outputs [options]
+
+  Serve the root module outputs of the current workspace over HTTP.
+
+  The outputs are served as a state snapshot that contains nothing else,
+  in the format expected by the "http" backend, so that other
+  configurations can read them with a terraform_remote_state data source
+  without needing access to the backend holding the full state. The state
+  is re-read for each request, so the latest outputs are always served.
+
+  Only GET requests are supported, on any path.
+
+Options:
+
+  -address=addr          The address to listen on. Defaults to
+                         127.0.0.1:8080.
+
+  -token-file=path       Require requests to include the bearer token held
+                         in the given file in their Authorization header.
+
+  -include-sensitive     Also serve outputs that are marked as sensitive.
+                         By default, they are omitted.
+
+  -var 'foo=bar'         Set a value for one of the input variables in the
+                         root module of the configuration. Use this option
+                         more than once to set more than one variable.
+
+  -var-file=filename     Load variable values from the given file, in
+                         addition to the default files terraform.tfvars and
+                         *.auto.tfvars. Use this option more than once to
+                         include more than one variables file.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *StateServeOutputsCommand) Synopsis() string {
+	return "Serve root module outputs over HTTP"
+}
+
+// stateOutputsHandler is the http.Handler used by "tofu state serve-outputs".
+type stateOutputsHandler struct {
+	// mu serializes access to stateMgr, which is not safe for concurrent use.
+	mu       sync.Mutex
+	stateMgr statemgr.Full
+
+	// token, if set, is the bearer token that requests must present.
+	token            string
+	includeSensitive bool
+}
+
+func (h *stateOutputsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.token != "" && !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "a valid bearer token is required", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := h.snapshot(r.Context())
+	if err != nil {
+		log.Printf("[ERROR] state serve-outputs: %s", err)
+		http.Error(w, "failed to read state", http.StatusInternalServerError)
+		return
+	}
+	if data == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	sum := md5.Sum(data)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	if _, err := w.Write(data); err != nil {
+		log.Printf("[WARN] state serve-outputs: failed to write response: %s", err)
+	}
+}
+
+func (h *stateOutputsHandler) authorized(r *http.Request) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(h.token)) == 1
+}
+
+// snapshot returns the latest state snapshot reduced to just the root module
+// outputs, or nil if there is no state yet.
+func (h *stateOutputsHandler) snapshot(ctx context.Context) ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.stateMgr.RefreshState(ctx); err != nil {
+		return nil, err
+	}
+	f := statemgr.Export(h.stateMgr)
+	if f == nil || f.State == nil {
+		return nil, nil
+	}
+
+	state := states.NewState()
+	root := state.RootModule()
+	for name, ov := range f.State.RootModule().OutputValues {
+		if ov.Sensitive && !h.includeSensitive {
+			continue
+		}
+		root.SetOutputValue(name, ov.Value, ov.Sensitive, ov.Deprecated)
+	}
+
+	// We keep the lineage and serial of the real snapshot, so that
+	// consumers can tell when the outputs have changed.
+	out := &statefile.File{
+		TerraformVersion: f.TerraformVersion,
+		Serial:           f.Serial,
+		Lineage:          f.Lineage,
+		State:            state,
+	}
+
+	var buf bytes.Buffer
+	if err := statefile.Write(out, &buf, encryption.StateEncryptionDisabled()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,182 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/encryption"
+	"github.com/opentofu/opentofu/internal/states"
+	"github.com/opentofu/opentofu/internal/states/statefile"
+	"github.com/opentofu/opentofu/internal/states/statemgr"
+)
+
+func TestStateServeOutputs(t *testing.T) {
+	testCwdTemp(t)
+	testStateFileDefault(t, testStateServeOutputsState())
+
+	shutdownCh := make(chan struct{})
+	ui := cli.NewMockUi()
+	c := &StateServeOutputsCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(testProvider()),
+			Ui:               ui,
+			ShutdownCh:       shutdownCh,
+		},
+	}
+
+	codeCh := make(chan int)
+	go func() {
+		codeCh <- c.Run([]string{"-address=127.0.0.1:0"})
+	}()
+
+	// Wait for the command to report the address it's listening on.
+	addrRe := regexp.MustCompile(`http://[^/]+/`)
+	var url string
+	for start := time.Now(); url == ""; {
+		select {
+		case code := <-codeCh:
+			t.Fatalf("command exited early with code %d\n\n%s", code, ui.ErrorWriter.String())
+		default:
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("timed out waiting for the server to start")
+		}
+		url = addrRe.FindString(ui.OutputWriter.String())
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("wrong status %d", resp.StatusCode)
+	}
+	f, err := statefile.Read(resp.Body, encryption.StateEncryptionDisabled())
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := f.State.RootModule().OutputValues
+	if _, ok := outputs["public"]; !ok {
+		t.Errorf("public output is missing")
+	}
+	if _, ok := outputs["secret"]; ok {
+		t.Errorf("sensitive output was served")
+	}
+	if len(f.State.RootModule().Resources) != 0 {
+		t.Errorf("resources were served")
+	}
+
+	close(shutdownCh)
+	if code := <-codeCh; code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+}
+
+func TestStateServeOutputs_handler(t *testing.T) {
+	statePath := testStateFile(t, testStateServeOutputsState())
+	stateMgr := statemgr.NewFilesystem(statePath, encryption.StateEncryptionDisabled())
+
+	t.Run("include sensitive", func(t *testing.T) {
+		srv := httptest.NewServer(&stateOutputsHandler{
+			stateMgr:         stateMgr,
+			includeSensitive: true,
+		})
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.Header.Get("Content-MD5") == "" {
+			t.Errorf("missing Content-MD5 header")
+		}
+		f, err := statefile.Read(resp.Body, encryption.StateEncryptionDisabled())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(f.State.RootModule().OutputValues), 2; got != want {
+			t.Errorf("got %d outputs; want %d", got, want)
+		}
+	})
+
+	t.Run("bearer token", func(t *testing.T) {
+		srv := httptest.NewServer(&stateOutputsHandler{
+			stateMgr: stateMgr,
+			token:    "s3cr3t",
+		})
+		defer srv.Close()
+
+		for token, want := range map[string]int{
+			"":       http.StatusUnauthorized,
+			"wrong":  http.StatusUnauthorized,
+			"s3cr3t": http.StatusOK,
+		} {
+			req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != want {
+				t.Errorf("token %q: got status %d; want %d", token, resp.StatusCode, want)
+			}
+		}
+	})
+
+	t.Run("read only", func(t *testing.T) {
+		srv := httptest.NewServer(&stateOutputsHandler{stateMgr: stateMgr})
+		defer srv.Close()
+
+		resp, err := http.Post(srv.URL, "application/json", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("got status %d; want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+	})
+
+	t.Run("no state", func(t *testing.T) {
+		stateMgr := statemgr.NewFilesystem(filepath.Join(t.TempDir(), "missing.tfstate"), encryption.StateEncryptionDisabled())
+		srv := httptest.NewServer(&stateOutputsHandler{stateMgr: stateMgr})
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("got status %d; want %d", resp.StatusCode, http.StatusNoContent)
+		}
+	})
+}
+
+func testStateServeOutputsState() *states.State {
+	state := testState()
+	root := state.EnsureModule(addrs.RootModuleInstance)
+	root.SetOutputValue("public", cty.StringVal("hello"), false, "")
+	root.SetOutputValue("secret", cty.StringVal("hunter2"), true, "")
+	return state
+}
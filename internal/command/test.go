@@ -10,9 +10,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"maps"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/opentofu/opentofu/internal/lang"
@@ -62,6 +66,11 @@ Usage: tofu [global options] test [options]
 
 Options:
 
+  -cleanup              Destroy the resources left behind by previous
+                        executions that exited before cleaning up, using the
+                        providers and variables of the test files, instead
+                        of executing the tests.
+
   -compact-warnings     If OpenTofu produces any warnings that are not
                         accompanied by errors, show them in a more compact
                         form that includes only the summary messages.
@@ -74,6 +83,16 @@ Options:
                         will be performed. All locations, for all errors
                         will be listed. Disabled by default
 
+  -coverage             Record which resources, outputs, and conditions in
+                        the configuration are exercised by the tests, and
+                        print a summary for each module.
+
+  -coverage-report=path Also write a detailed coverage report to the given
+                        file. Implies -coverage.
+
+  -coverage-format=fmt  The format of the coverage report, either "lcov"
+                        (the default) or "json".
+
   -filter=testfile      If specified, OpenTofu will only execute the test files
                         specified by this flag. You can use this option multiple
                         times to execute more than one test file. The path should
@@ -83,12 +102,27 @@ Options:
   -json                 If specified, machine readable output will be printed in
                         JSON format
 
+  -junit-xml=path       Also write the test results to the given file as a
+                        JUnit XML report, for use with CI systems.
+
+  -property-seed=n      Generate the values of every property block with the
+                        given seed, instead of the seed of the block.
+
+  -parallelism=n        Execute up to n test files at the same time. Each test
+                        file still executes its run blocks in order, except
+                        for adjacent run blocks that set parallel = true.
+                        Defaults to 1.
+
   -no-color             If specified, output won't contain any color.
 
   -test-directory=path  Set the OpenTofu test directory, defaults to "tests". When set, the
                         test command will search for test files in the current directory and
                         in the one specified by the flag.
 
+  -update-snapshots     Write the snapshot files for run blocks with snapshot
+                        blocks, instead of comparing the plans and outputs
+                        against them.
+
   -var 'foo=bar'        Set a value for one of the input variables in the root
                         module of the configuration. Use this option more than
                         once to set more than one variable.
@@ -193,9 +227,10 @@ func (c *TestCommand) Run(rawArgs []string) int {
 
 					runCount += len(runs)
 					files[name] = &moduletest.File{
-						Config: file,
-						Name:   name,
-						Runs:   runs,
+						Config:     file,
+						Name:       name,
+						Runs:       runs,
+						Properties: testProperties(file),
 					}
 				}
 
@@ -217,9 +252,10 @@ func (c *TestCommand) Run(rawArgs []string) int {
 
 				runCount += len(runs)
 				files[name] = &moduletest.File{
-					Config: file,
-					Name:   name,
-					Runs:   runs,
+					Config:     file,
+					Name:       name,
+					Runs:       runs,
+					Properties: testProperties(file),
 				}
 			}
 			return files
@@ -228,6 +264,10 @@ func (c *TestCommand) Run(rawArgs []string) int {
 
 	log.Printf("[DEBUG] TestCommand: found %d files with %d run blocks", fileCount, runCount)
 
+	if args.Coverage {
+		suite.Coverage = moduletest.NewCoverage(config)
+	}
+
 	if len(args.Filter) > 0 && len(suite.Files) == 0 {
 		diags = diags.Append(tfdiags.Sourceless(
 			tfdiags.Warning,
@@ -282,15 +322,20 @@ func (c *TestCommand) Run(rawArgs []string) int {
 		CancelledCtx: cancelCtx,
 		StoppedCtx:   stopCtx,
 
-		// Just to be explicit, we'll set the following fields even though they
-		// default to these values.
-		Cancelled: false,
-		Stopped:   false,
+		Verbose:         args.Verbose,
+		Parallelism:     args.Parallelism,
+		TestDirectory:   args.TestDirectory,
+		UpdateSnapshots: args.UpdateSnapshots,
 
-		Verbose: args.Verbose,
+		Fixtures:         make(map[string]cty.Value),
+		FixtureManifest:  filepath.Join(c.DataDir(), testFixtureManifestFile),
+		JournalDirectory: filepath.Join(c.DataDir(), testJournalDirectory),
+		PropertySeed:     args.PropertySeed,
 	}
 
-	view.Abstract(&suite)
+	if !args.Cleanup {
+		view.Abstract(&suite)
+	}
 
 	panicHandler := logging.PanicHandlerWithTraceFn()
 	go func() {
@@ -299,6 +344,10 @@ func (c *TestCommand) Run(rawArgs []string) int {
 		defer stop()
 		defer cancel()
 
+		if args.Cleanup {
+			runner.StartCleanup(ctx)
+			return
+		}
 		runner.Start(ctx)
 	}()
 
@@ -308,7 +357,7 @@ func (c *TestCommand) Run(rawArgs []string) int {
 		// Nice request to be cancelled.
 
 		view.Interrupted()
-		runner.Stopped = true
+		runner.Stopped.Store(true)
 		stop()
 
 		select {
@@ -317,7 +366,7 @@ func (c *TestCommand) Run(rawArgs []string) int {
 			// fast as possible.
 
 			view.FatalInterrupt()
-			runner.Cancelled = true
+			runner.Cancelled.Store(true)
 			cancel()
 
 			// We'll wait 5 seconds for this operation to finish now, regardless
@@ -334,13 +383,42 @@ func (c *TestCommand) Run(rawArgs []string) int {
 		// tests finished normally with no interrupts.
 	}
 
-	if runner.Cancelled {
+	if runner.Cancelled.Load() {
 		// Don't print out the conclusion if the test was cancelled.
+		if path := runner.journal.Path(); path != "" {
+			view.Diagnostics(nil, nil, tfdiags.Diagnostics{}.Append(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Leftover test resources",
+				fmt.Sprintf("The resources created by the test files are recorded in %s. Run tofu test -cleanup to destroy them.", path),
+			)))
+		}
 		return 1
 	}
 
+	if args.Cleanup {
+		view.CleanupSummary(&suite)
+		if suite.Status != moduletest.Pass {
+			return 1
+		}
+		return 0
+	}
+
 	view.Conclusion(&suite)
 
+	// We write every requested report even if an earlier one fails, so that
+	// a problem with one report doesn't also lose the others.
+	var reportDiags tfdiags.Diagnostics
+	if args.CoverageReport != "" {
+		reportDiags = reportDiags.Append(views.NewTestCoverageReport(args.CoverageReport, args.CoverageFormat).Save(suite.Coverage))
+	}
+	if args.JUnitXMLFile != "" {
+		reportDiags = reportDiags.Append(views.NewTestJUnitXMLFile(args.JUnitXMLFile, c.View).Save(&suite))
+	}
+	view.Diagnostics(nil, nil, reportDiags)
+	if reportDiags.HasErrors() {
+		return 1
+	}
+
 	if suite.Status != moduletest.Pass {
 		return 1
 	}
@@ -368,8 +446,11 @@ type TestSuiteRunner struct {
 	// be left showing `pending` as the status. We will still print out the
 	// destroy summary diagnostics that tell the user what state has been left
 	// behind and needs manual clean up.
-	Stopped   bool
-	Cancelled bool
+	//
+	// They are read by every goroutine executing test files and run blocks
+	// concurrently, so they must only be accessed atomically.
+	Stopped   atomic.Bool
+	Cancelled atomic.Bool
 
 	// StoppedCtx and CancelledCtx allow in progress OpenTofu operations to
 	// respond to external calls from the test command.
@@ -378,6 +459,45 @@ type TestSuiteRunner struct {
 
 	// Verbose tells the runner to print out plan files during each test run.
 	Verbose bool
+
+	// UpdateSnapshots tells the runner to write the golden files for run
+	// blocks with snapshot blocks, instead of comparing against them.
+	UpdateSnapshots bool
+
+	// Parallelism is the maximum number of test files to execute at the same
+	// time. Values less than 2 mean the files are executed one at a time.
+	Parallelism int
+
+	// TestDirectory is the test directory the configuration was loaded with,
+	// so that further copies of it can be loaded for concurrent execution.
+	TestDirectory string
+
+	// Fixtures holds the outputs of the fixtures that have been applied, by
+	// fixture name. It is only written before any test file executes.
+	Fixtures map[string]cty.Value
+
+	// FixtureManifest is the path of the file recording the state of the
+	// fixtures that have been applied, until they are destroyed.
+	FixtureManifest string
+
+	// JournalDirectory is the directory holding the journals that record the
+	// states created by the test files, until they are destroyed.
+	JournalDirectory string
+
+	// PropertySeed, if set, replaces the seed of every property block.
+	PropertySeed *int64
+
+	fixtures        []*testFixture
+	fixtureManifest *testFixtureManifest
+	journal         *testJournal
+
+	// configLock serializes loading of configuration copies, as the
+	// underlying config loader is not safe for concurrent use.
+	configLock sync.Mutex
+
+	// viewLock serializes the output that test files and run blocks
+	// executing concurrently report straight away, rather than buffering it.
+	viewLock sync.Mutex
 }
 
 func (runner *TestSuiteRunner) Start(ctx context.Context) {
@@ -388,32 +508,143 @@ func (runner *TestSuiteRunner) Start(ctx context.Context) {
 	sort.Strings(files) // execute the files in alphabetical order
 
 	runner.Suite.Status = moduletest.Pass
-	for _, name := range files {
-		if runner.Cancelled {
+
+	runner.warnLeftoverJournals()
+	runner.journal = newTestJournal(runner.JournalDirectory, runner.GlobalVariables, sensitiveTestVariables(runner.Config))
+
+	defer runner.teardownFixtures(ctx)
+	if !runner.setupFixtures(ctx) {
+		if runner.Cancelled.Load() {
 			return
 		}
 
-		file := runner.Suite.Files[name]
+		// The test files can't execute without their fixtures, so we mark
+		// them all as skipped.
+		runner.Suite.Status = moduletest.Error
+		for _, name := range files {
+			file := runner.Suite.Files[name]
+			file.Status = moduletest.Skip
+			for _, run := range file.Runs {
+				run.Status = moduletest.Skip
+			}
+			runner.View.File(file)
+			for _, run := range file.Runs {
+				runner.View.Run(run, file)
+			}
+		}
+		return
+	}
 
-		fileRunner := &TestFileRunner{
-			Suite: runner,
-			States: map[string]*TestFileState{
-				MainStateIdentifier: {
-					Run:   nil,
-					State: states.NewState(),
-				},
-			},
+	if runner.Parallelism > 1 && len(files) > 1 {
+		runner.startParallel(ctx, files)
+		return
+	}
+
+	for _, name := range files {
+		if runner.Cancelled.Load() {
+			return
 		}
 
-		fileRunner.ExecuteTestFile(ctx, file)
-		fileRunner.Cleanup(ctx, file)
+		file := runner.Suite.Files[name]
+		runner.executeFile(ctx, file, runner.Config, runner.View)
 		runner.Suite.Status = runner.Suite.Status.Merge(file.Status)
 	}
 }
 
+// startParallel executes up to Parallelism test files concurrently. Each file
+// is executed against its own copy of the configuration, as the configuration
+// is modified while executing each run block, and the output for each file is
+// buffered so that it is still reported in alphabetical order.
+func (runner *TestSuiteRunner) startParallel(ctx context.Context, files []string) {
+	buffers := make([]*bufferedTestView, len(files))
+	done := make([]chan struct{}, len(files))
+	sem := make(chan struct{}, runner.Parallelism)
+
+	panicHandler := logging.PanicHandlerWithTraceFn()
+	for ix, name := range files {
+		file := runner.Suite.Files[name]
+		buffers[ix] = newBufferedTestView(runner.View, &runner.viewLock)
+		done[ix] = make(chan struct{})
+
+		go func() {
+			defer panicHandler()
+			defer close(done[ix])
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if runner.Cancelled.Load() {
+				return
+			}
+
+			config, diags := runner.loadConfig(ctx)
+			if diags.HasErrors() {
+				file.Status = moduletest.Error
+				file.Diagnostics = file.Diagnostics.Append(diags)
+				buffers[ix].File(file)
+				return
+			}
+			runner.executeFile(ctx, file, config, buffers[ix])
+		}()
+	}
+
+	for ix, name := range files {
+		<-done[ix]
+		buffers[ix].Flush()
+		runner.Suite.Status = runner.Suite.Status.Merge(runner.Suite.Files[name].Status)
+	}
+}
+
+// executeFile executes and then cleans up a single test file, using the
+// given configuration and reporting the results to the given view.
+func (runner *TestSuiteRunner) executeFile(ctx context.Context, file *moduletest.File, config *configs.Config, view views.Test) {
+	fileRunner := &TestFileRunner{
+		Suite:   runner,
+		Config:  config,
+		View:    view,
+		Journal: runner.journal,
+		States: map[string]*TestFileState{
+			MainStateIdentifier: {
+				Run:   nil,
+				State: states.NewState(),
+			},
+		},
+	}
+
+	start := time.Now()
+	fileRunner.ExecuteTestFile(ctx, file)
+	fileRunner.Cleanup(ctx, file)
+	file.Duration = time.Since(start)
+}
+
+// loadConfig loads a new copy of the configuration under test, for use by a
+// test file or run block that is executing concurrently with others.
+func (runner *TestSuiteRunner) loadConfig(ctx context.Context) (*configs.Config, tfdiags.Diagnostics) {
+	runner.configLock.Lock()
+	defer runner.configLock.Unlock()
+	return runner.command.loadConfigWithTests(ctx, ".", runner.TestDirectory)
+}
+
 type TestFileRunner struct {
 	Suite *TestSuiteRunner
 
+	// Config is the configuration under test, which belongs exclusively to
+	// this runner as it is modified while executing each run block.
+	Config *configs.Config
+
+	// ConfigUnderTest replaces the alternate configuration of the run block
+	// executed by this runner if that run block executes a different module.
+	// It is only set for runners that execute a single parallel run block
+	// against their own copy of the configuration.
+	ConfigUnderTest *configs.Config
+
+	// View is where the results of this file are reported.
+	View views.Test
+
+	// Journal records the states of this file as they are updated, so they
+	// can be destroyed later if the file is never cleaned up.
+	Journal *testJournal
+
 	States map[string]*TestFileState
 }
 
@@ -426,18 +657,30 @@ func (runner *TestFileRunner) ExecuteTestFile(ctx context.Context, file *modulet
 	log.Printf("[TRACE] TestFileRunner: executing test file %s", file.Name)
 
 	file.Status = file.Status.Merge(moduletest.Pass)
-	for _, run := range file.Runs {
-		if runner.Suite.Cancelled {
+	for ix := 0; ix < len(file.Runs); {
+		if runner.Suite.Cancelled.Load() {
 			// This means a hard stop has been requested, in this case we don't
 			// even stop to mark future tests as having been skipped. They'll
 			// just show up as pending in the printed summary.
 			return
 		}
 
-		if runner.Suite.Stopped {
+		// Run blocks that opt in to parallel execution are executed together
+		// with any parallel run blocks that immediately follow them. Every
+		// other run block is executed on its own.
+		next := ix + 1
+		for file.Runs[ix].Config.Parallel && next < len(file.Runs) && file.Runs[next].Config.Parallel {
+			next++
+		}
+		runs := file.Runs[ix:next]
+		ix = next
+
+		if runner.Suite.Stopped.Load() {
 			// Then the test was requested to be stopped, so we just mark each
 			// following test as skipped and move on.
-			run.Status = moduletest.Skip
+			for _, run := range runs {
+				run.Status = moduletest.Skip
+			}
 			continue
 		}
 
@@ -445,91 +688,264 @@ func (runner *TestFileRunner) ExecuteTestFile(ctx context.Context, file *modulet
 			// If the overall test file has errored, we don't keep trying to
 			// execute tests. Instead, we mark all remaining run blocks as
 			// skipped.
-			run.Status = moduletest.Skip
+			for _, run := range runs {
+				run.Status = moduletest.Skip
+			}
 			continue
 		}
 
-		key := MainStateIdentifier
-		config := runner.Suite.Config
-		if run.Config.ConfigUnderTest != nil {
-			config = run.Config.ConfigUnderTest
-			// Then we need to load an alternate state and not the main one.
+		var ok bool
+		if len(runs) == 1 {
+			ok = runner.executeRun(ctx, runs[0], file)
+		} else {
+			ok = runner.executeParallelRuns(ctx, runs, file)
+		}
 
-			key = run.Config.Module.Source.String()
-			if key == MainStateIdentifier {
-				// This is bad. It means somehow the module we're loading has
-				// the same key as main state and we're about to corrupt things.
+		for _, run := range runs {
+			file.Status = file.Status.Merge(run.Status)
+		}
+		if !ok {
+			// We cannot reuse state later so that's a hard stop.
+			return
+		}
+	}
+
+	for _, property := range file.Properties {
+		if runner.Suite.Cancelled.Load() {
+			return
+		}
+		if runner.Suite.Stopped.Load() {
+			property.Status = moduletest.Skip
+			continue
+		}
+
+		runner.executeProperty(property, file)
+		file.Status = file.Status.Merge(property.Status)
+	}
+
+	runner.View.File(file)
+	for _, run := range file.Runs {
+		runner.View.Run(run, file)
+	}
+	for _, property := range file.Properties {
+		runner.View.Property(property, file)
+	}
+}
 
-				run.Diagnostics = run.Diagnostics.Append(&hcl.Diagnostic{
-					Severity: hcl.DiagError,
-					Summary:  "Invalid module source",
-					Detail:   fmt.Sprintf("The source for the selected module evaluated to %s which should not be possible. This is a bug in OpenTofu - please report it!", key),
-					Subject:  run.Config.Module.DeclRange.Ptr(),
-				})
+// executeRun executes a single run block and records the state it produced.
+// It returns false if the file can't continue executing afterwards.
+func (runner *TestFileRunner) executeRun(ctx context.Context, run *moduletest.Run, file *moduletest.File) bool {
+	config := runner.Config
+	if run.Config.ConfigUnderTest != nil {
+		config = run.Config.ConfigUnderTest
+		if runner.ConfigUnderTest != nil {
+			config = runner.ConfigUnderTest
+		}
+		// Then we need to load an alternate state and not the main one.
+
+		if source := run.Config.Module.Source.String(); source == MainStateIdentifier {
+			// This is bad. It means somehow the module we're loading has
+			// the same key as main state and we're about to corrupt things.
+
+			run.Diagnostics = run.Diagnostics.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid module source",
+				Detail:   fmt.Sprintf("The source for the selected module evaluated to %s which should not be possible. This is a bug in OpenTofu - please report it!", source),
+				Subject:  run.Config.Module.DeclRange.Ptr(),
+			})
+
+			run.Status = moduletest.Error
+			return true // Abort!
+		}
+	}
+	key := testRunStateKey(run)
 
+	if _, exists := runner.States[key]; !exists {
+		runner.States[key] = &TestFileState{
+			Run:   nil,
+			State: states.NewState(),
+		}
+	}
+
+	start := time.Now()
+	state, updatedState := runner.ExecuteTestRun(ctx, run, file, runner.States[key].State, config)
+	run.Duration = time.Since(start)
+	if updatedState {
+		var err error
+
+		// We need to simulate state serialization between multiple runs
+		// due to its side effects. One of such side effects is removal
+		// of destroyed non-root module outputs. This is not handled
+		// during graph walk since those values are not stored in the
+		// state file. This is more of a weird workaround instead of a
+		// proper fix, unfortunately.
+		state, err = simulateStateSerialization(state)
+		if err != nil {
+			run.Diagnostics = run.Diagnostics.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Failure during state serialization",
+				Detail:   err.Error(),
+			})
+			return false
+		}
+
+		// Only update the most recent run and state if the state was
+		// actually updated by this change. We want to use the run that
+		// most recently updated the tracked state as the cleanup
+		// configuration.
+		runner.States[key].State = state
+		runner.States[key].Run = run
+
+		if err := runner.Journal.Record(file.Name, key, run.Name, state); err != nil {
+			run.Diagnostics = run.Diagnostics.Append(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Failed to record test state",
+				fmt.Sprintf("The state of %s/%s could not be recorded in the test journal, so its resources will not be destroyed by tofu test -cleanup if OpenTofu exits before the test file completes: %s.", file.Name, run.Name, err),
+			))
+		}
+	}
+	return true
+}
+
+// testRunStateKey returns the key of the state the given run block executes
+// against within TestFileRunner.States.
+func testRunStateKey(run *moduletest.Run) string {
+	switch {
+	case run.Config.Parallel:
+		// Parallel run blocks never share their state with other run blocks.
+		return parallelRunStateKey(run)
+	case run.Config.ConfigUnderTest != nil:
+		return run.Config.Module.Source.String()
+	default:
+		return MainStateIdentifier
+	}
+}
+
+// executeParallelRuns executes a group of adjacent parallel run blocks
+// concurrently. It returns false if the file can't continue executing
+// afterwards.
+//
+// Each run block executes against its own copy of the states of the file, so
+// that the run blocks in the group can only refer to the outputs of run blocks
+// that executed before the group, and its own copy of the configuration, as
+// the configuration is modified while executing each run block. Anything the
+// run blocks report is buffered, and then reported in the order of the run
+// blocks once they have all completed.
+func (runner *TestFileRunner) executeParallelRuns(ctx context.Context, runs []*moduletest.Run, file *moduletest.File) bool {
+	runners := make([]*TestFileRunner, len(runs))
+	buffers := make([]*bufferedTestView, len(runs))
+	for ix, run := range runs {
+		buffers[ix] = newBufferedTestView(runner.View, &runner.Suite.viewLock)
+
+		// The first run block can use the configuration of the file, as
+		// nothing else uses it until the group completes.
+		config, configUnderTest := runner.Config, run.Config.ConfigUnderTest
+		if ix > 0 {
+			var diags tfdiags.Diagnostics
+			config, diags = runner.Suite.loadConfig(ctx)
+			if !diags.HasErrors() && configUnderTest != nil {
+				var moreDiags tfdiags.Diagnostics
+				configUnderTest, moreDiags = parallelRunConfigUnderTest(config, run, file)
+				diags = diags.Append(moreDiags)
+			}
+			if diags.HasErrors() {
+				run.Diagnostics = run.Diagnostics.Append(diags)
 				run.Status = moduletest.Error
-				file.Status = moduletest.Error
-				continue // Abort!
+				continue
 			}
+		}
 
-			if _, exists := runner.States[key]; !exists {
-				runner.States[key] = &TestFileState{
-					Run:   nil,
-					State: states.NewState(),
-				}
-			}
+		runners[ix] = &TestFileRunner{
+			Suite:           runner.Suite,
+			Config:          config,
+			ConfigUnderTest: configUnderTest,
+			View:            buffers[ix],
+			Journal:         runner.Journal,
+			States:          maps.Clone(runner.States),
 		}
+	}
 
-		state, updatedState := runner.ExecuteTestRun(ctx, run, file, runner.States[key].State, config)
-		if updatedState {
-			var err error
-
-			// We need to simulate state serialization between multiple runs
-			// due to its side effects. One of such side effects is removal
-			// of destroyed non-root module outputs. This is not handled
-			// during graph walk since those values are not stored in the
-			// state file. This is more of a weird workaround instead of a
-			// proper fix, unfortunately.
-			state, err = simulateStateSerialization(state)
-			if err != nil {
-				run.Diagnostics = run.Diagnostics.Append(&hcl.Diagnostic{
-					Severity: hcl.DiagError,
-					Summary:  "Failure during state serialization",
-					Detail:   err.Error(),
-				})
-
-				// We cannot reuse state later so that's a hard stop.
-				return
-			}
+	results := make([]bool, len(runs))
+	panicHandler := logging.PanicHandlerWithTraceFn()
+	var wg sync.WaitGroup
+	for ix, run := range runs {
+		if runners[ix] == nil {
+			results[ix] = true
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer panicHandler()
+			defer wg.Done()
+			results[ix] = runners[ix].executeRun(ctx, run, file)
+		}()
+	}
+	wg.Wait()
 
-			// Only update the most recent run and state if the state was
-			// actually updated by this change. We want to use the run that
-			// most recently updated the tracked state as the cleanup
-			// configuration.
-			runner.States[key].State = state
-			runner.States[key].Run = run
+	ok := true
+	for ix, run := range runs {
+		buffers[ix].Flush()
+		if runners[ix] == nil {
+			continue
+		}
+		key := parallelRunStateKey(run)
+		if state, exists := runners[ix].States[key]; exists {
+			runner.States[key] = state
 		}
+		ok = ok && results[ix]
+	}
+	return ok
+}
 
-		file.Status = file.Status.Merge(run.Status)
+// parallelRunConfigUnderTest returns the alternate configuration of the given
+// run block from the given copy of the configuration, for a run block that
+// executes a different module.
+func parallelRunConfigUnderTest(config *configs.Config, run *moduletest.Run, file *moduletest.File) (*configs.Config, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	if testFile, ok := config.Module.Tests[file.Name]; ok {
+		for _, candidate := range testFile.Runs {
+			if candidate.Name == run.Name && candidate.ConfigUnderTest != nil {
+				return candidate.ConfigUnderTest, diags
+			}
+		}
 	}
+	return nil, diags.Append(tfdiags.Sourceless(
+		tfdiags.Error,
+		"Failed to load module under test",
+		fmt.Sprintf("OpenTofu could not load a separate copy of the module executed by %s/%s. This is a bug in OpenTofu - please report it!", file.Name, run.Name),
+	))
+}
 
-	runner.Suite.View.File(file)
-	for _, run := range file.Runs {
-		runner.Suite.View.Run(run, file)
+// recordCoverage records the objects exercised by a plan or apply operation
+// for the given run block, if coverage is being collected. Run blocks that
+// execute a different module than the one under test don't contribute.
+func (runner *TestFileRunner) recordCoverage(run *moduletest.Run, plan *plans.Plan, state *states.State, diags tfdiags.Diagnostics) {
+	coverage := runner.Suite.Suite.Coverage
+	if coverage == nil || run.Config.ConfigUnderTest != nil {
+		return
 	}
+	coverage.Record(plan, state, diags)
+}
+
+// parallelRunStateKey returns the key of the state that belongs exclusively
+// to the given parallel run block. Run block names are unique within a file,
+// and can never look like a module source.
+func parallelRunStateKey(run *moduletest.Run) string {
+	return "run." + run.Name
 }
 
 func (runner *TestFileRunner) ExecuteTestRun(ctx context.Context, run *moduletest.Run, file *moduletest.File, state *states.State, config *configs.Config) (*states.State, bool) {
 	log.Printf("[TRACE] TestFileRunner: executing run block %s/%s", file.Name, run.Name)
 
-	if runner.Suite.Cancelled {
+	if runner.Suite.Cancelled.Load() {
 		// Don't do anything, just give up and return immediately.
 		// The surrounding functions should stop this even being called, but in
 		// case of race conditions or something we can still verify this.
 		return state, false
 	}
 
-	if runner.Suite.Stopped {
+	if runner.Suite.Stopped.Load() {
 		// Basically the same as above, except we'll be a bit nicer.
 		run.Status = moduletest.Skip
 		return state, false
@@ -547,7 +963,7 @@ func (runner *TestFileRunner) ExecuteTestRun(ctx context.Context, run *moduletes
 		return state, false
 	}
 
-	evalCtx, evalDiags := buildEvalContextForProviderConfigTransform(runner.States, run, file, config, runner.Suite.GlobalVariables)
+	evalCtx, evalDiags := buildEvalContextForProviderConfigTransform(runner.States, runner.Suite.Fixtures, run, file, config, runner.Suite.GlobalVariables)
 	run.Diagnostics = run.Diagnostics.Append(evalDiags)
 	if evalDiags.HasErrors() {
 		run.Status = moduletest.Error
@@ -563,23 +979,35 @@ func (runner *TestFileRunner) ExecuteTestRun(ctx context.Context, run *moduletes
 		return state, false
 	}
 
-	validateDiags := runner.validate(ctx, config, run, file)
+	expectedErrors := run.BuildExpectedErrors()
+
+	validateDiags := expectedErrors.Filter(runner.validate(ctx, config, run, file))
 	run.Diagnostics = run.Diagnostics.Append(validateDiags)
 	if validateDiags.HasErrors() {
 		run.Status = moduletest.Error
 		return state, false
 	}
+	if expectedErrors.Stop() {
+		concludeExpectedErrors(run, expectedErrors)
+		return state, false
+	}
 
 	planCtx, plan, planDiags := runner.plan(ctx, config, state, run, file)
+	runner.recordCoverage(run, plan, nil, planDiags)
 	if run.Config.Command == configs.PlanTestCommand {
 		expectedFailures, sourceRanges := run.BuildExpectedFailuresAndSourceMaps()
 		// Then we want to assess our conditions and diagnostics differently.
 		planDiags = run.ValidateExpectedFailures(expectedFailures, sourceRanges, planDiags)
+		planDiags = expectedErrors.Filter(planDiags)
 		run.Diagnostics = run.Diagnostics.Append(planDiags)
 		if planDiags.HasErrors() {
 			run.Status = moduletest.Error
 			return state, false
 		}
+		if expectedErrors.Stop() {
+			concludeExpectedErrors(run, expectedErrors)
+			return state, false
+		}
 
 		variables, resetVariables, variableDiags := runner.prepareInputVariablesForAssertions(config, run, file, runner.Suite.GlobalVariables)
 		defer resetVariables()
@@ -616,12 +1044,15 @@ func (runner *TestFileRunner) ExecuteTestRun(ctx context.Context, run *moduletes
 		}
 
 		planCtx.TestContext(config, plan.PlannedState, plan, variables).EvaluateAgainstPlan(run)
+		runner.checkSnapshot(ctx, planCtx, config, run, file, plan, nil)
+		concludeExpectedErrors(run, expectedErrors)
 		return state, false
 	}
 
 	expectedFailures, sourceRanges := run.BuildExpectedFailuresAndSourceMaps()
 
 	planDiags = checkProblematicPlanErrors(expectedFailures, planDiags)
+	planDiags = expectedErrors.Filter(planDiags)
 
 	// Otherwise any error during the planning prevents our apply from
 	// continuing which is an error.
@@ -630,6 +1061,10 @@ func (runner *TestFileRunner) ExecuteTestRun(ctx context.Context, run *moduletes
 		run.Status = moduletest.Error
 		return state, false
 	}
+	if expectedErrors.Stop() {
+		concludeExpectedErrors(run, expectedErrors)
+		return state, false
+	}
 
 	// Since we're carrying on an executing the apply operation as well, we're
 	// just going to do some post processing of the diagnostics. We remove the
@@ -646,9 +1081,11 @@ func (runner *TestFileRunner) ExecuteTestRun(ctx context.Context, run *moduletes
 	run.Diagnostics = filteredDiags
 
 	applyCtx, updated, applyDiags := runner.apply(ctx, plan, state, config, run, file)
+	runner.recordCoverage(run, nil, updated, applyDiags)
 
 	// Remove expected diagnostics, and add diagnostics in case anything that should have failed didn't.
 	applyDiags = run.ValidateExpectedFailures(expectedFailures, sourceRanges, applyDiags)
+	applyDiags = expectedErrors.Filter(applyDiags)
 
 	run.Diagnostics = run.Diagnostics.Append(applyDiags)
 	if applyDiags.HasErrors() {
@@ -657,6 +1094,12 @@ func (runner *TestFileRunner) ExecuteTestRun(ctx context.Context, run *moduletes
 		// partial updates and the returned state should reflect this.
 		return updated, true
 	}
+	if expectedErrors.Stop() {
+		// As above, the failed apply operation may still have updated the
+		// state.
+		concludeExpectedErrors(run, expectedErrors)
+		return updated, true
+	}
 
 	variables, resetVariables, variableDiags := runner.prepareInputVariablesForAssertions(config, run, file, runner.Suite.GlobalVariables)
 	defer resetVariables()
@@ -693,9 +1136,26 @@ func (runner *TestFileRunner) ExecuteTestRun(ctx context.Context, run *moduletes
 	}
 
 	applyCtx.TestContext(config, updated, plan, variables).EvaluateAgainstState(run)
+	runner.checkSnapshot(ctx, applyCtx, config, run, file, plan, updated)
+	concludeExpectedErrors(run, expectedErrors)
 	return updated, true
 }
 
+// concludeExpectedErrors reports the expect_error blocks of a run block that
+// did not match any error. If the run block stopped because of an expected
+// error, and every expect_error block matched, then the run block passes.
+func concludeExpectedErrors(run *moduletest.Run, expectedErrors *moduletest.ExpectedErrors) {
+	missing := expectedErrors.Missing()
+	run.Diagnostics = run.Diagnostics.Append(missing)
+	if missing.HasErrors() {
+		run.Status = moduletest.Error
+		return
+	}
+	if expectedErrors.Stop() {
+		run.Status = run.Status.Merge(moduletest.Pass)
+	}
+}
+
 func (runner *TestFileRunner) validate(ctx context.Context, config *configs.Config, run *moduletest.Run, file *moduletest.File) tfdiags.Diagnostics {
 	log.Printf("[TRACE] TestFileRunner: called validate for %s/%s", file.Name, run.Name)
 
@@ -741,7 +1201,7 @@ func (runner *TestFileRunner) destroy(ctx context.Context, config *configs.Confi
 
 	var diags tfdiags.Diagnostics
 
-	evalCtx, ctxDiags := getEvalContextForTest(runner.States, config, runner.Suite.GlobalVariables)
+	evalCtx, ctxDiags := getEvalContextForTest(runner.States, runner.Suite.Fixtures, config, runner.Suite.GlobalVariables)
 	diags = diags.Append(ctxDiags)
 
 	variables, variableDiags := buildInputVariablesForTest(run, file, config, runner.Suite.GlobalVariables, evalCtx)
@@ -807,7 +1267,7 @@ func (runner *TestFileRunner) plan(ctx context.Context, config *configs.Config,
 	references, referenceDiags := run.GetReferences()
 	diags = diags.Append(referenceDiags)
 
-	evalCtx, ctxDiags := getEvalContextForTest(runner.States, config, runner.Suite.GlobalVariables)
+	evalCtx, ctxDiags := getEvalContextForTest(runner.States, runner.Suite.Fixtures, config, runner.Suite.GlobalVariables)
 	diags = diags.Append(ctxDiags)
 
 	variables, variableDiags := buildInputVariablesForTest(run, file, config, runner.Suite.GlobalVariables, evalCtx)
@@ -906,6 +1366,16 @@ func (runner *TestFileRunner) apply(ctx context.Context, plan *plans.Plan, state
 		log.Printf("[DEBUG] TestFileRunner: starting apply for %s/%s", file.Name, run.Name)
 		updated, applyDiags = tfCtx.Apply(ctx, plan, config)
 		log.Printf("[DEBUG] TestFileRunner: completed apply for %s/%s", file.Name, run.Name)
+
+		if runner.Suite.Cancelled.Load() && runner.Journal != nil && updated != nil {
+			// After a hard interrupt the command only waits a short time
+			// for the test files to finish, so we record whatever the
+			// interrupted apply operation managed to do straight away
+			// instead of once the run block completes.
+			if err := runner.Journal.Record(file.Name, testRunStateKey(run), run.Name, updated); err != nil {
+				log.Printf("[ERROR] TestFileRunner: failed to record the interrupted state of %s/%s: %s", file.Name, run.Name, err)
+			}
+		}
 	}()
 	waitDiags, cancelled := runner.wait(tfCtx, runningCtx, run, file, created)
 
@@ -946,7 +1416,7 @@ func (runner *TestFileRunner) wait(ctx *tofu.Context, runningCtx context.Context
 			}
 			states[module.Run] = module.State
 		}
-		runner.Suite.View.FatalInterruptSummary(run, file, states, created)
+		runner.View.FatalInterruptSummary(run, file, states, created)
 
 		cancelled = true
 		go ctx.Stop()
@@ -990,13 +1460,14 @@ func (runner *TestFileRunner) wait(ctx *tofu.Context, runningCtx context.Context
 func (runner *TestFileRunner) Cleanup(ctx context.Context, file *moduletest.File) {
 	log.Printf("[TRACE] TestStateManager: cleaning up state for %s", file.Name)
 
-	if runner.Suite.Cancelled {
+	if runner.Suite.Cancelled.Load() {
 		// Don't try and clean anything up if the execution has been cancelled.
 		log.Printf("[DEBUG] TestStateManager: skipping state cleanup for %s due to cancellation", file.Name)
 		return
 	}
 
 	var states []*TestFileState
+	keys := make(map[*TestFileState]string)
 	for key, state := range runner.States {
 		if state.Run == nil {
 			if state.State.Empty() {
@@ -1019,11 +1490,12 @@ func (runner *TestFileRunner) Cleanup(ctx context.Context, file *moduletest.File
 
 			var diags tfdiags.Diagnostics
 			diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "Inconsistent state", fmt.Sprintf("Found inconsistent state while cleaning up %s. This is a bug in OpenTofu - please report it", file.Name)))
-			runner.Suite.View.DestroySummary(diags, nil, file, state.State)
+			runner.View.DestroySummary(diags, nil, file, state.State)
 			continue
 		}
 
 		states = append(states, state)
+		keys[state] = key
 	}
 
 	slices.SortFunc(states, func(a, b *TestFileState) int {
@@ -1036,7 +1508,7 @@ func (runner *TestFileRunner) Cleanup(ctx context.Context, file *moduletest.File
 	for _, state := range states {
 		log.Printf("[DEBUG] TestStateManager: cleaning up state for %s/%s", file.Name, state.Run.Name)
 
-		if runner.Suite.Cancelled {
+		if runner.Suite.Cancelled.Load() {
 			// In case the cancellation came while a previous state was being
 			// destroyed.
 			log.Printf("[DEBUG] TestStateManager: skipping state cleanup for %s/%s due to cancellation", file.Name, state.Run.Name)
@@ -1048,12 +1520,12 @@ func (runner *TestFileRunner) Cleanup(ctx context.Context, file *moduletest.File
 
 		isMainState := state.Run.Config.Module == nil
 		if isMainState {
-			runConfig = runner.Suite.Config
+			runConfig = runner.Config
 		} else {
 			runConfig = state.Run.Config.ConfigUnderTest
 		}
 
-		evalCtx, ctxDiags := getEvalContextForTest(runner.States, runConfig, runner.Suite.GlobalVariables)
+		evalCtx, ctxDiags := getEvalContextForTest(runner.States, runner.Suite.Fixtures, runConfig, runner.Suite.GlobalVariables)
 		diags = diags.Append(ctxDiags)
 
 		reset, configDiags := runConfig.TransformForTest(state.Run.Config, file.Config, evalCtx)
@@ -1065,10 +1537,19 @@ func (runner *TestFileRunner) Cleanup(ctx context.Context, file *moduletest.File
 			updated, destroyDiags = runner.destroy(ctx, runConfig, state.State, state.Run, file)
 			diags = diags.Append(destroyDiags)
 		}
-		runner.Suite.View.DestroySummary(diags, state.Run, file, updated)
+		state.State = updated
+
+		if err := runner.Journal.Record(file.Name, keys[state], state.Run.Name, updated); err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Failed to record test state",
+				fmt.Sprintf("The state of %s/%s could not be recorded in the test journal: %s.", file.Name, state.Run.Name, err),
+			))
+		}
+		runner.View.DestroySummary(diags, state.Run, file, updated)
 
 		if updated.HasManagedResourceInstanceObjects() {
-			views.SaveErroredTestStateFile(updated, state.Run, file, runner.Suite.View)
+			saveErroredTestStateFile(updated, state.Run, file, runner.View)
 		}
 		reset()
 	}
@@ -1086,8 +1567,8 @@ func (runner *TestFileRunner) Cleanup(ctx context.Context, file *moduletest.File
 // input variables with `variables` block.
 //
 // The evalCtx returned from this, contains built-in functions for the same reason.
-func buildEvalContextForProviderConfigTransform(states map[string]*TestFileState, run *moduletest.Run, file *moduletest.File, config *configs.Config, globals map[string]backend.UnparsedVariableValue) (*hcl.EvalContext, tfdiags.Diagnostics) {
-	evalCtx, diags := getEvalContextForTest(states, config, globals)
+func buildEvalContextForProviderConfigTransform(states map[string]*TestFileState, fixtures map[string]cty.Value, run *moduletest.Run, file *moduletest.File, config *configs.Config, globals map[string]backend.UnparsedVariableValue) (*hcl.EvalContext, tfdiags.Diagnostics) {
+	evalCtx, diags := getEvalContextForTest(states, fixtures, config, globals)
 	vars, varDiags := buildInputVariablesForTest(run, file, config, globals, evalCtx)
 	diags = diags.Append(varDiags)
 	if diags.HasErrors() {
@@ -1163,9 +1644,10 @@ func buildInputVariablesForTest(run *moduletest.Run, file *moduletest.File, conf
 // TestFileState instances, configuration and global variables.
 // It extracts the relevant information from the input parameters to create a
 // context suitable for HCL evaluation.
-func getEvalContextForTest(states map[string]*TestFileState, config *configs.Config, globals map[string]backend.UnparsedVariableValue) (*hcl.EvalContext, tfdiags.Diagnostics) {
+func getEvalContextForTest(states map[string]*TestFileState, fixtures map[string]cty.Value, config *configs.Config, globals map[string]backend.UnparsedVariableValue) (*hcl.EvalContext, tfdiags.Diagnostics) {
 	var diags tfdiags.Diagnostics
 	runCtx := make(map[string]cty.Value)
+	eachCtx := make(map[string]map[string]cty.Value)
 	for _, state := range states {
 		if state.Run == nil {
 			continue
@@ -1175,8 +1657,21 @@ func getEvalContextForTest(states map[string]*TestFileState, config *configs.Con
 		for outName, out := range mod.OutputValues {
 			outputs[outName] = out.Value
 		}
+
+		if each := state.Run.Config.Each; each != nil {
+			// Run blocks expanded from a for_each argument are referenced
+			// by the name of the original run block and their key.
+			if eachCtx[each.Name] == nil {
+				eachCtx[each.Name] = make(map[string]cty.Value)
+			}
+			eachCtx[each.Name][each.Key] = cty.ObjectVal(outputs)
+			continue
+		}
 		runCtx[state.Run.Name] = cty.ObjectVal(outputs)
 	}
+	for name, runs := range eachCtx {
+		runCtx[name] = cty.ObjectVal(runs)
+	}
 
 	// If the variable is referenced in the tfvars file or TF_VAR_ environment variable, then lookup the value
 	// in global variables; otherwise, assign the default value.
@@ -1191,8 +1686,9 @@ func getEvalContextForTest(states map[string]*TestFileState, config *configs.Con
 	scope := &lang.Scope{}
 	ctx := &hcl.EvalContext{
 		Variables: map[string]cty.Value{
-			"run": cty.ObjectVal(runCtx),
-			"var": cty.ObjectVal(varCtx),
+			"run":     cty.ObjectVal(runCtx),
+			"var":     cty.ObjectVal(varCtx),
+			"fixture": cty.ObjectVal(fixtures),
 		},
 		Functions: scope.Functions(),
 	}
@@ -1278,7 +1774,7 @@ func parseAndApplyDefaultValues(unparsedVariables map[string]backend.UnparsedVar
 // the config which must be called so the config can be reused going forward.
 func (runner *TestFileRunner) prepareInputVariablesForAssertions(config *configs.Config, run *moduletest.Run, file *moduletest.File, globals map[string]backend.UnparsedVariableValue) (tofu.InputValues, func(), tfdiags.Diagnostics) {
 	var diags tfdiags.Diagnostics
-	ctx, ctxDiags := getEvalContextForTest(runner.States, config, globals)
+	ctx, ctxDiags := getEvalContextForTest(runner.States, runner.Suite.Fixtures, config, globals)
 	diags = diags.Append(ctxDiags)
 
 	variables := make(map[string]backend.UnparsedVariableValue)
@@ -1403,3 +1899,92 @@ func simulateStateSerialization(state *states.State) (*states.State, error) {
 
 	return f.State, nil
 }
+
+// bufferedTestView is a views.Test that holds back the output reported for a
+// single test file or parallel run block until Flush is called, so that
+// files and run blocks executing concurrently are still reported in a
+// predictable order.
+//
+// Only the methods called while executing a file are buffered. A
+// FatalInterruptSummary is always printed straight away, as the command may
+// exit before the buffer would otherwise be flushed.
+type bufferedTestView struct {
+	views.Test
+
+	mu      sync.Mutex
+	pending []func(view views.Test)
+
+	// direct serializes the output that isn't buffered with that of all of
+	// the other buffered views of the same suite. It is nil if the wrapped
+	// view is itself a bufferedTestView, which serializes it instead.
+	direct *sync.Mutex
+}
+
+// newBufferedTestView returns a bufferedTestView wrapping the given view,
+// which uses the given lock to serialize the output that isn't buffered.
+func newBufferedTestView(view views.Test, direct *sync.Mutex) *bufferedTestView {
+	ret := &bufferedTestView{Test: view}
+	if _, nested := view.(*bufferedTestView); !nested {
+		ret.direct = direct
+	}
+	return ret
+}
+
+var _ views.Test = (*bufferedTestView)(nil)
+
+func (v *bufferedTestView) File(file *moduletest.File) {
+	v.buffer(func(view views.Test) { view.File(file) })
+}
+
+func (v *bufferedTestView) Run(run *moduletest.Run, file *moduletest.File) {
+	v.buffer(func(view views.Test) { view.Run(run, file) })
+}
+
+func (v *bufferedTestView) Property(property *moduletest.Property, file *moduletest.File) {
+	v.buffer(func(view views.Test) { view.Property(property, file) })
+}
+
+func (v *bufferedTestView) DestroySummary(diags tfdiags.Diagnostics, run *moduletest.Run, file *moduletest.File, state *states.State) {
+	v.buffer(func(view views.Test) { view.DestroySummary(diags, run, file, state) })
+}
+
+func (v *bufferedTestView) Diagnostics(run *moduletest.Run, file *moduletest.File, diags tfdiags.Diagnostics) {
+	v.buffer(func(view views.Test) { view.Diagnostics(run, file, diags) })
+}
+
+func (v *bufferedTestView) FatalInterruptSummary(run *moduletest.Run, file *moduletest.File, states map[*moduletest.Run]*states.State, created []*plans.ResourceInstanceChangeSrc) {
+	if v.direct != nil {
+		v.direct.Lock()
+		defer v.direct.Unlock()
+	}
+	v.Test.FatalInterruptSummary(run, file, states, created)
+}
+
+func (v *bufferedTestView) buffer(fn func(view views.Test)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.pending = append(v.pending, fn)
+}
+
+// Flush reports everything buffered so far to the underlying view.
+func (v *bufferedTestView) Flush() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, fn := range v.pending {
+		fn(v.Test)
+	}
+	v.pending = nil
+}
+
+// saveErroredTestStateFile saves the given state to a file in the current
+// directory as views.SaveErroredTestStateFile does, but waits until the given
+// view is flushed if it is buffered, so that the output is reported in order.
+func saveErroredTestStateFile(state *states.State, run *moduletest.Run, file *moduletest.File, view views.Test) {
+	if buffered, ok := view.(*bufferedTestView); ok {
+		buffered.buffer(func(view views.Test) {
+			saveErroredTestStateFile(state, run, file, view)
+		})
+		return
+	}
+	views.SaveErroredTestStateFile(state, run, file, view)
+}
@@ -0,0 +1,369 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/opentofu/opentofu/internal/command/views"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/encryption"
+	"github.com/opentofu/opentofu/internal/moduletest"
+	"github.com/opentofu/opentofu/internal/states"
+	"github.com/opentofu/opentofu/internal/states/statefile"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// testFixtureManifestFile is the name of the file within the data directory
+// that records the state of the fixtures that have been applied, so they can
+// still be destroyed if OpenTofu exits before the test suite completes.
+const testFixtureManifestFile = "test-fixtures.json"
+
+// testFixture tracks a fixture block while the test suite executes. The
+// fixture is executed as a run block within the test file it was declared in.
+type testFixture struct {
+	config *configs.TestFixture
+	file   *moduletest.File
+	run    *moduletest.Run
+	state  *states.State
+}
+
+// collectFixtures returns the fixtures declared by all the test files, in the
+// order they must be applied: by the name of the test file, and then in the
+// order they are declared.
+func (runner *TestSuiteRunner) collectFixtures() []*testFixture {
+	var names []string
+	for name := range runner.Config.Module.Tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fixtures []*testFixture
+	for _, name := range names {
+		file := runner.Config.Module.Tests[name]
+		for _, fixture := range file.Fixtures {
+			if fixture.ConfigUnderTest == nil {
+				// Then the module failed to load, which has already been
+				// reported while loading the configuration.
+				continue
+			}
+
+			run := fixture.TestRun()
+			fixtures = append(fixtures, &testFixture{
+				config: fixture,
+				file: &moduletest.File{
+					Name: name,
+					// Fixtures are shared by all the test files, so they only
+					// use the providers of the test file they were declared
+					// in, and not its variables or overrides.
+					Config: &configs.TestFile{
+						Providers:     file.Providers,
+						MockProviders: file.MockProviders,
+					},
+				},
+				run: &moduletest.Run{
+					Config: run,
+					Name:   run.Name,
+				},
+			})
+		}
+	}
+	return fixtures
+}
+
+// setupFixtures applies all the fixtures, after destroying anything left
+// behind by fixtures of a previous execution that did not complete. It
+// returns false if the test files can't execute.
+func (runner *TestSuiteRunner) setupFixtures(ctx context.Context) bool {
+	fixtures := runner.collectFixtures()
+
+	leftovers, ok := runner.restoreFixtures(fixtures)
+	if !ok {
+		return false
+	}
+
+	fileRunner := runner.fixtureFileRunner()
+	for ix := len(leftovers) - 1; ix >= 0; ix-- {
+		log.Printf("[DEBUG] TestSuiteRunner: destroying leftover fixture %s", leftovers[ix].config.Name)
+		if !runner.destroyFixture(ctx, fileRunner, leftovers[ix]) {
+			return false
+		}
+		delete(runner.Fixtures, leftovers[ix].config.Name)
+	}
+
+	for _, fixture := range fixtures {
+		if runner.Stopped.Load() || runner.Cancelled.Load() {
+			return false
+		}
+
+		log.Printf("[DEBUG] TestSuiteRunner: applying fixture %s", fixture.config.Name)
+
+		state, updated := fileRunner.ExecuteTestRun(ctx, fixture.run, fixture.file, states.NewState(), fixture.config.ConfigUnderTest)
+		if updated {
+			var err error
+
+			// As with run blocks, we simulate the state serialization so
+			// the outputs match what a later execution would read back.
+			state, err = simulateStateSerialization(state)
+			if err != nil {
+				fixture.run.Diagnostics = fixture.run.Diagnostics.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Failure during state serialization",
+					err.Error(),
+				))
+				fixture.run.Status = moduletest.Error
+			} else {
+				fixture.state = state
+				runner.fixtures = append(runner.fixtures, fixture)
+			}
+
+			if err := runner.fixtureManifest.Record(fixture.config.Name, fixture.state); err != nil {
+				fixture.run.Diagnostics = fixture.run.Diagnostics.Append(tfdiags.Sourceless(
+					tfdiags.Warning,
+					"Failed to record test fixture state",
+					fmt.Sprintf("The state of fixture %q could not be recorded in %s, so its resources will not be destroyed if OpenTofu exits before the test suite completes: %s.", fixture.config.Name, runner.fixtureManifest.path, err),
+				))
+			}
+		}
+
+		runner.View.Diagnostics(fixture.run, fixture.file, fixture.run.Diagnostics)
+		if fixture.run.Status == moduletest.Error || fixture.run.Status == moduletest.Fail || !updated {
+			return false
+		}
+
+		runner.Fixtures[fixture.config.Name] = testFixtureOutputs(fixture.state)
+	}
+
+	return true
+}
+
+// restoreFixtures loads the fixture manifest and returns the given fixtures
+// that were left behind by a previous execution that did not complete, in the
+// order they were applied. Their outputs are restored before any of them is
+// destroyed, as the variables of later fixtures and of the test files may
+// refer to them. It returns false if the manifest can't be read.
+func (runner *TestSuiteRunner) restoreFixtures(fixtures []*testFixture) ([]*testFixture, bool) {
+	manifest, diags := loadTestFixtureManifest(runner.FixtureManifest)
+	runner.View.Diagnostics(nil, nil, diags)
+	if diags.HasErrors() {
+		return nil, false
+	}
+	runner.fixtureManifest = manifest
+
+	declared := make(map[string]bool)
+	var leftovers []*testFixture
+	for _, fixture := range fixtures {
+		declared[fixture.config.Name] = true
+
+		state, err := manifest.State(fixture.config.Name)
+		if err != nil {
+			runner.View.Diagnostics(fixture.run, fixture.file, tfdiags.Diagnostics{}.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to read test fixture state",
+				fmt.Sprintf("The state of fixture %q recorded in %s could not be read: %s.", fixture.config.Name, manifest.path, err),
+			)))
+			return nil, false
+		}
+		if state == nil {
+			continue
+		}
+
+		fixture.state = state
+		runner.Fixtures[fixture.config.Name] = testFixtureOutputs(state)
+		leftovers = append(leftovers, fixture)
+	}
+
+	var names []string
+	for name := range manifest.Fixtures {
+		if !declared[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		runner.View.Diagnostics(nil, nil, tfdiags.Diagnostics{}.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Leftover test fixture",
+			fmt.Sprintf("The fixture %q is no longer declared by the test files, but %s records resources it created during a previous execution that did not complete. These resources must be destroyed manually.", name, manifest.path),
+		)))
+	}
+
+	return leftovers, true
+}
+
+// teardownFixtures destroys the fixtures that were applied, in the reverse
+// order they were applied in.
+func (runner *TestSuiteRunner) teardownFixtures(ctx context.Context) {
+	if runner.Cancelled.Load() {
+		// As with test files, we don't clean anything up after a hard stop.
+		// The manifest still records the fixtures, so the next execution
+		// will destroy them.
+		return
+	}
+
+	fileRunner := runner.fixtureFileRunner()
+	for ix := len(runner.fixtures) - 1; ix >= 0; ix-- {
+		if runner.Cancelled.Load() {
+			return
+		}
+
+		log.Printf("[DEBUG] TestSuiteRunner: destroying fixture %s", runner.fixtures[ix].config.Name)
+		runner.destroyFixture(ctx, fileRunner, runner.fixtures[ix])
+	}
+	runner.fixtures = nil
+}
+
+// destroyFixture destroys the resources of the given fixture, and records
+// whatever is left of its state in the manifest. It returns false if some
+// resources could not be destroyed.
+func (runner *TestSuiteRunner) destroyFixture(ctx context.Context, fileRunner *TestFileRunner, fixture *testFixture) bool {
+	var diags tfdiags.Diagnostics
+
+	config := fixture.config.ConfigUnderTest
+
+	evalCtx, ctxDiags := getEvalContextForTest(fileRunner.States, runner.Fixtures, config, runner.GlobalVariables)
+	diags = diags.Append(ctxDiags)
+
+	reset, configDiags := config.TransformForTest(fixture.run.Config, fixture.file.Config, evalCtx)
+	diags = diags.Append(configDiags)
+
+	updated := fixture.state
+	if !diags.HasErrors() {
+		var destroyDiags tfdiags.Diagnostics
+		updated, destroyDiags = fileRunner.destroy(ctx, config, fixture.state, fixture.run, fixture.file)
+		diags = diags.Append(destroyDiags)
+	}
+	reset()
+
+	fixture.state = updated
+	if err := runner.fixtureManifest.Record(fixture.config.Name, updated); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Failed to record test fixture state",
+			fmt.Sprintf("The state of fixture %q could not be recorded in %s: %s.", fixture.config.Name, runner.fixtureManifest.path, err),
+		))
+	}
+	runner.View.DestroySummary(diags, fixture.run, fixture.file, updated)
+
+	if updated.HasManagedResourceInstanceObjects() {
+		views.SaveErroredTestStateFile(updated, fixture.run, fixture.file, runner.View)
+		return false
+	}
+	return !diags.HasErrors()
+}
+
+// fixtureFileRunner returns a runner for executing fixtures. Fixtures can't
+// refer to run blocks, so the runner has no states of its own.
+func (runner *TestSuiteRunner) fixtureFileRunner() *TestFileRunner {
+	return &TestFileRunner{
+		Suite:  runner,
+		Config: runner.Config,
+		View:   runner.View,
+		States: make(map[string]*TestFileState),
+	}
+}
+
+// testFixtureOutputs returns the outputs of the given fixture state, as they
+// are referenced by fixture.<name>.<output>.
+func testFixtureOutputs(state *states.State) cty.Value {
+	outputs := make(map[string]cty.Value)
+	if mod := state.Modules[""]; mod != nil {
+		for name, out := range mod.OutputValues {
+			outputs[name] = out.Value
+		}
+	}
+	return cty.ObjectVal(outputs)
+}
+
+// testFixtureManifest records the state of each fixture that has been applied
+// and not yet destroyed, by fixture name.
+type testFixtureManifest struct {
+	path string
+
+	Fixtures map[string]json.RawMessage `json:"fixtures"`
+}
+
+func loadTestFixtureManifest(path string) (*testFixtureManifest, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	manifest := &testFixtureManifest{
+		path:     path,
+		Fixtures: make(map[string]json.RawMessage),
+	}
+
+	src, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, diags
+	}
+	if err == nil {
+		err = json.Unmarshal(src, manifest)
+	}
+	if err != nil {
+		return manifest, diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read test fixture manifest",
+			fmt.Sprintf("The test fixture manifest at %s could not be read: %s. If no resources were left behind by a previous execution, remove the file and try again.", path, err),
+		))
+	}
+	if manifest.Fixtures == nil {
+		manifest.Fixtures = make(map[string]json.RawMessage)
+	}
+	return manifest, diags
+}
+
+// State returns the state recorded for the given fixture, or nil if there
+// is none.
+func (m *testFixtureManifest) State(name string) (*states.State, error) {
+	src, exists := m.Fixtures[name]
+	if !exists {
+		return nil, nil
+	}
+
+	file, err := statefile.Read(bytes.NewReader(src), encryption.StateEncryptionDisabled())
+	if err != nil {
+		return nil, err
+	}
+	return file.State, nil
+}
+
+// Record records the given state for the given fixture, and writes the
+// manifest to disk. Fixtures without any resources are removed from the
+// manifest, and the manifest is removed once it records no fixtures.
+func (m *testFixtureManifest) Record(name string, state *states.State) error {
+	if state == nil || !state.HasManagedResourceInstanceObjects() {
+		delete(m.Fixtures, name)
+	} else {
+		var buf bytes.Buffer
+		if err := statefile.Write(statefile.New(state, "", 0), &buf, encryption.StateEncryptionDisabled()); err != nil {
+			return err
+		}
+		m.Fixtures[name] = buf.Bytes()
+	}
+
+	if len(m.Fixtures) == 0 {
+		if err := os.Remove(m.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	src, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, src, 0o600)
+}
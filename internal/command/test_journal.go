@@ -0,0 +1,528 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/opentofu/opentofu/internal/backend"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/encryption"
+	"github.com/opentofu/opentofu/internal/moduletest"
+	"github.com/opentofu/opentofu/internal/states"
+	"github.com/opentofu/opentofu/internal/states/statefile"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+	"github.com/opentofu/opentofu/internal/tofu"
+)
+
+// testJournalDirectory is the name of the directory within the data directory
+// that holds the state journals of the executions of the test command.
+const testJournalDirectory = "test-journals"
+
+// testJournal records the states created by the run blocks of a single
+// execution of the test command, so the resources they contain can still be
+// destroyed by "tofu test -cleanup" if OpenTofu exits before the test files
+// are cleaned up.
+//
+// The journal file is only created once a run block creates resources, and
+// is removed once all of them have been destroyed. A nil journal records
+// nothing.
+type testJournal struct {
+	dir  string
+	path string
+
+	mu sync.Mutex
+
+	// Files holds the states recorded for each test file, by test file name
+	// and then by the key the state is tracked by in TestFileRunner.States.
+	Files map[string]map[string]*testJournalEntry `json:"files"`
+
+	// Variables holds the global variables of the execution that created the
+	// journal, so the resources can be destroyed with the same variables as
+	// they were created with. The values of variables declared as sensitive
+	// are not recorded, and must be given again to the cleanup.
+	Variables map[string]*testJournalVariable `json:"variables,omitempty"`
+}
+
+// testJournalEntry is a single state recorded in a testJournal.
+type testJournalEntry struct {
+	// Run is the name of the run block that most recently updated the state,
+	// whose configuration is used to destroy it.
+	Run string `json:"run"`
+
+	State json.RawMessage `json:"state"`
+}
+
+// testJournalVariable is a single global variable recorded in a testJournal.
+// Values given as strings, on the command line or in environment variables,
+// are recorded as is, as how they are parsed depends on the declaration of
+// the variable. Values from variable definitions files are recorded as JSON,
+// together with their type. Sensitive variables are recorded without a value.
+type testJournalVariable struct {
+	Raw   *string         `json:"raw,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+	Type  json.RawMessage `json:"type,omitempty"`
+
+	Sensitive bool `json:"sensitive,omitempty"`
+
+	SourceType tofu.ValueSourceType `json:"source_type"`
+}
+
+func newTestJournal(dir string, variables map[string]backend.UnparsedVariableValue, sensitive map[string]bool) *testJournal {
+	journal := &testJournal{
+		dir:   dir,
+		Files: make(map[string]map[string]*testJournalEntry),
+	}
+	for name, variable := range variables {
+		recorded := newTestJournalVariable(variable)
+		if recorded != nil && sensitive[name] {
+			recorded = &testJournalVariable{
+				Sensitive:  true,
+				SourceType: recorded.SourceType,
+			}
+		}
+		if recorded != nil {
+			if journal.Variables == nil {
+				journal.Variables = make(map[string]*testJournalVariable)
+			}
+			journal.Variables[name] = recorded
+		}
+	}
+	return journal
+}
+
+// newTestJournalVariable returns the given variable in the form it is
+// recorded in, or nil if it can't be recorded.
+func newTestJournalVariable(variable backend.UnparsedVariableValue) *testJournalVariable {
+	switch v := variable.(type) {
+	case unparsedVariableValueString:
+		return &testJournalVariable{
+			Raw:        &v.str,
+			SourceType: v.sourceType,
+		}
+	case unparsedVariableValueExpression:
+		val, diags := v.expr.Value(nil)
+		if diags.HasErrors() || !val.IsWhollyKnown() {
+			// The test files can't have used this value either, so there
+			// is nothing to record.
+			return nil
+		}
+		src, err := ctyjson.Marshal(val, val.Type())
+		if err != nil {
+			return nil
+		}
+		ty, err := ctyjson.MarshalType(val.Type())
+		if err != nil {
+			return nil
+		}
+		return &testJournalVariable{
+			Value:      src,
+			Type:       ty,
+			SourceType: v.sourceType,
+		}
+	default:
+		return nil
+	}
+}
+
+// sensitiveTestVariables returns the names of the variables declared as
+// sensitive by the configuration under test, including the modules executed by
+// run blocks and fixtures, as the global variables apply to all of them.
+func sensitiveTestVariables(config *configs.Config) map[string]bool {
+	ret := make(map[string]bool)
+	add := func(config *configs.Config) {
+		if config == nil || config.Module == nil {
+			return
+		}
+		for name, variable := range config.Module.Variables {
+			if variable.Sensitive {
+				ret[name] = true
+			}
+		}
+	}
+
+	add(config)
+	if config == nil || config.Module == nil {
+		return ret
+	}
+	for _, file := range config.Module.Tests {
+		for _, run := range file.Runs {
+			add(run.ConfigUnderTest)
+		}
+		for _, fixture := range file.Fixtures {
+			add(fixture.ConfigUnderTest)
+		}
+	}
+	return ret
+}
+
+// GlobalVariables returns the global variables recorded in the journal, or
+// nil if the journal was written by a version of OpenTofu that did not record
+// them. The values of sensitive variables are taken from the given variables
+// instead, and it is an error if they are missing.
+func (j *testJournal) GlobalVariables(given map[string]backend.UnparsedVariableValue) (map[string]backend.UnparsedVariableValue, error) {
+	if j.Variables == nil {
+		return nil, nil
+	}
+
+	ret := make(map[string]backend.UnparsedVariableValue, len(j.Variables))
+	for name, variable := range j.Variables {
+		if variable.Sensitive {
+			value, exists := given[name]
+			if !exists {
+				return nil, fmt.Errorf("variable %q is sensitive, so its value was not recorded and must be set again when running tofu test -cleanup", name)
+			}
+			ret[name] = value
+			continue
+		}
+		if variable.Raw != nil {
+			ret[name] = unparsedVariableValueString{
+				str:        *variable.Raw,
+				name:       name,
+				sourceType: variable.SourceType,
+			}
+			continue
+		}
+
+		ty, err := ctyjson.UnmarshalType(variable.Type)
+		if err != nil {
+			return nil, fmt.Errorf("invalid type for variable %q: %w", name, err)
+		}
+		val, err := ctyjson.Unmarshal(variable.Value, ty)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for variable %q: %w", name, err)
+		}
+		ret[name] = unparsedVariableValueExpression{
+			expr:       hcl.StaticExpr(val, hcl.Range{Filename: j.path}),
+			sourceType: variable.SourceType,
+		}
+	}
+	return ret, nil
+}
+
+// loadTestJournals returns the journals left behind in the given directory,
+// ordered by their file name.
+func loadTestJournals(dir string) ([]*testJournal, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, diags.Append(err)
+	}
+	sort.Strings(paths)
+
+	var journals []*testJournal
+	for _, path := range paths {
+		journal := newTestJournal(dir, nil, nil)
+		journal.path = path
+
+		src, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(src, journal)
+		}
+		if err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to read test journal",
+				fmt.Sprintf("The test journal at %s could not be read: %s. If no resources were left behind by a previous execution, remove the file and try again.", path, err),
+			))
+			continue
+		}
+		if journal.Files == nil {
+			journal.Files = make(map[string]map[string]*testJournalEntry)
+		}
+		journals = append(journals, journal)
+	}
+	return journals, diags
+}
+
+// Record records the given state for the given test file and state key, and
+// writes the journal to disk. States without any resources are removed from
+// the journal, and the journal is removed once it records no states.
+func (j *testJournal) Record(file, key, run string, state *states.State) error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if state == nil || !state.HasManagedResourceInstanceObjects() {
+		delete(j.Files[file], key)
+		if len(j.Files[file]) == 0 {
+			delete(j.Files, file)
+		}
+	} else {
+		var buf bytes.Buffer
+		if err := statefile.Write(statefile.New(state, "", 0), &buf, encryption.StateEncryptionDisabled()); err != nil {
+			return err
+		}
+		if j.Files[file] == nil {
+			j.Files[file] = make(map[string]*testJournalEntry)
+		}
+		j.Files[file][key] = &testJournalEntry{
+			Run:   run,
+			State: buf.Bytes(),
+		}
+	}
+
+	if len(j.Files) == 0 {
+		if j.path == "" {
+			return nil
+		}
+		if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		j.path = ""
+		return nil
+	}
+
+	src, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if j.path == "" {
+		if err := os.MkdirAll(j.dir, 0o755); err != nil {
+			return err
+		}
+		f, err := os.CreateTemp(j.dir, "*.json")
+		if err != nil {
+			return err
+		}
+		j.path = f.Name()
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(j.path, src, 0o600)
+}
+
+// Path returns the path of the journal file, or an empty string if the
+// journal has not been written.
+func (j *testJournal) Path() string {
+	if j == nil {
+		return ""
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.path
+}
+
+// States returns the states recorded for the given test file, by state key.
+func (j *testJournal) States(file string) (map[string]*testJournalEntry, map[string]*states.State, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries := j.Files[file]
+	result := make(map[string]*states.State, len(entries))
+	for key, entry := range entries {
+		sf, err := statefile.Read(bytes.NewReader(entry.State), encryption.StateEncryptionDisabled())
+		if err != nil {
+			return nil, nil, err
+		}
+		result[key] = sf.State
+	}
+	return entries, result, nil
+}
+
+// warnLeftoverJournals warns about the journals left behind by previous
+// executions of the test command, as their resources are not destroyed
+// unless the command is executed in cleanup mode.
+func (runner *TestSuiteRunner) warnLeftoverJournals() {
+	paths, err := filepath.Glob(filepath.Join(runner.JournalDirectory, "*.json"))
+	if err != nil || len(paths) == 0 {
+		return
+	}
+
+	runner.View.Diagnostics(nil, nil, tfdiags.Diagnostics{}.Append(tfdiags.Sourceless(
+		tfdiags.Warning,
+		"Leftover test resources",
+		fmt.Sprintf("Previous executions of tofu test exited before destroying the resources they created, which are recorded in %s. Run tofu test -cleanup to destroy them.", runner.JournalDirectory),
+	)))
+}
+
+// StartCleanup destroys the resources recorded by the journals and the
+// fixture manifest of previous executions of the test command, instead of
+// executing the test files. The files that were cleaned up are recorded in
+// the suite with a Pass status, or an Error status if some resources could
+// not be destroyed.
+func (runner *TestSuiteRunner) StartCleanup(ctx context.Context) {
+	runner.Suite.Status = moduletest.Pass
+	runner.Suite.Files = make(map[string]*moduletest.File)
+
+	// The test files may refer to the outputs of the fixtures, so we restore
+	// those before destroying anything and destroy the fixtures last.
+	fixtures, ok := runner.restoreFixtures(runner.collectFixtures())
+	if !ok {
+		runner.Suite.Status = moduletest.Error
+		return
+	}
+
+	journals, diags := loadTestJournals(runner.JournalDirectory)
+	runner.View.Diagnostics(nil, nil, diags)
+	if diags.HasErrors() {
+		runner.Suite.Status = moduletest.Error
+	}
+
+	for _, journal := range journals {
+		var names []string
+		for name := range journal.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if runner.Cancelled.Load() {
+				return
+			}
+
+			file := runner.cleanupJournalFile(ctx, journal, name)
+			if file == nil {
+				runner.Suite.Status = moduletest.Error
+				continue
+			}
+
+			// A test file is only recorded once, even if several journals
+			// recorded resources for it.
+			if existing, exists := runner.Suite.Files[name]; exists {
+				file.Status = file.Status.Merge(existing.Status)
+			}
+			runner.Suite.Files[name] = file
+			runner.Suite.Status = runner.Suite.Status.Merge(file.Status)
+		}
+	}
+
+	fileRunner := runner.fixtureFileRunner()
+	for ix := len(fixtures) - 1; ix >= 0; ix-- {
+		if runner.Cancelled.Load() {
+			return
+		}
+
+		log.Printf("[DEBUG] TestSuiteRunner: destroying leftover fixture %s", fixtures[ix].config.Name)
+		if !runner.destroyFixture(ctx, fileRunner, fixtures[ix]) {
+			runner.Suite.Status = moduletest.Error
+		}
+	}
+}
+
+// cleanupJournalFile destroys the resources recorded in the given journal for
+// the given test file, using the providers and variables of the test file. It
+// returns nil if the test file no longer exists.
+func (runner *TestSuiteRunner) cleanupJournalFile(ctx context.Context, journal *testJournal, name string) *moduletest.File {
+	config, exists := runner.Config.Module.Tests[name]
+	if !exists {
+		runner.View.Diagnostics(nil, nil, tfdiags.Diagnostics{}.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Unknown test file",
+			fmt.Sprintf("The test journal at %s records resources created by %s, which could not be found. These resources must be destroyed manually.", journal.path, name),
+		)))
+		return nil
+	}
+
+	file := &moduletest.File{
+		Config: config,
+		Name:   name,
+	}
+	for ix, run := range config.Runs {
+		file.Runs = append(file.Runs, &moduletest.Run{
+			Config: run,
+			Index:  ix,
+			Name:   run.Name,
+		})
+	}
+
+	// The resources must be destroyed with the variables they were created
+	// with, rather than those of this execution. The files are cleaned up one
+	// at a time, so we can swap them in for the duration of this file.
+	variables, err := journal.GlobalVariables(runner.GlobalVariables)
+	if err != nil {
+		runner.View.Diagnostics(nil, file, tfdiags.Diagnostics{}.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read test journal",
+			fmt.Sprintf("The variables recorded in %s could not be used: %s.", journal.path, err),
+		)))
+		file.Status = moduletest.Error
+		return file
+	}
+	if variables != nil {
+		globals := runner.GlobalVariables
+		runner.GlobalVariables = variables
+		defer func() {
+			runner.GlobalVariables = globals
+		}()
+	}
+
+	fileRunner := &TestFileRunner{
+		Suite:   runner,
+		Config:  runner.Config,
+		View:    runner.View,
+		Journal: journal,
+		States: map[string]*TestFileState{
+			MainStateIdentifier: {
+				Run:   nil,
+				State: states.NewState(),
+			},
+		},
+	}
+
+	entries, recorded, err := journal.States(name)
+	if err != nil {
+		runner.View.Diagnostics(nil, file, tfdiags.Diagnostics{}.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to read test journal",
+			fmt.Sprintf("The states recorded for %s in %s could not be read: %s.", name, journal.path, err),
+		)))
+		file.Status = moduletest.Error
+		return file
+	}
+
+	file.Status = moduletest.Pass
+	for key, entry := range entries {
+		var found *moduletest.Run
+		for _, run := range file.Runs {
+			if run.Name == entry.Run {
+				found = run
+				break
+			}
+		}
+		if found == nil {
+			runner.View.Diagnostics(nil, file, tfdiags.Diagnostics{}.Append(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Unknown run block",
+				fmt.Sprintf("The test journal at %s records resources created by run block %q of %s, which could not be found. These resources must be destroyed manually.", journal.path, entry.Run, name),
+			)))
+			file.Status = moduletest.Error
+			continue
+		}
+
+		fileRunner.States[key] = &TestFileState{
+			Run:   found,
+			State: recorded[key],
+		}
+	}
+
+	log.Printf("[DEBUG] TestSuiteRunner: cleaning up leftover resources of %s from %s", name, journal.path)
+	fileRunner.Cleanup(ctx, file)
+
+	for _, state := range fileRunner.States {
+		if state.Run != nil && state.State.HasManagedResourceInstanceObjects() {
+			file.Status = moduletest.Error
+		}
+	}
+	return file
+}
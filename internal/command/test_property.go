@@ -0,0 +1,337 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/lang"
+	"github.com/opentofu/opentofu/internal/lang/marks"
+	"github.com/opentofu/opentofu/internal/moduletest"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// testProperties returns the properties of the given test file, to be
+// checked once its run blocks have executed.
+func testProperties(file *configs.TestFile) []*moduletest.Property {
+	var properties []*moduletest.Property
+	for _, property := range file.Properties {
+		properties = append(properties, &moduletest.Property{
+			Config: property,
+			Name:   property.Name,
+		})
+	}
+	return properties
+}
+
+// propertyCondition is a condition that is checked against the values
+// generated for a property.
+type propertyCondition struct {
+	rule *configs.CheckRule
+
+	// owner describes the object the condition belongs to, for display.
+	owner string
+}
+
+// executeProperty checks the given property with values generated for its
+// input variable, until a counterexample is found or the configured number
+// of iterations is reached.
+//
+// A counterexample is a value whose validation rules or preconditions fail to
+// evaluate, a value that is accepted by the validation rules of the variable
+// but rejected by a precondition of the module, or a value that violates an
+// assertion of the property block.
+func (runner *TestFileRunner) executeProperty(property *moduletest.Property, file *moduletest.File) {
+	log.Printf("[TRACE] TestFileRunner: checking property %s/%s", file.Name, property.Name)
+
+	start := time.Now()
+	defer func() {
+		property.Duration = time.Since(start)
+	}()
+
+	config := runner.Config
+	variable, exists := config.Module.Variables[property.Config.Variable]
+	if !exists {
+		property.Status = moduletest.Error
+		property.Diagnostics = property.Diagnostics.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Reference to undeclared input variable",
+			Detail:   fmt.Sprintf("The module under test doesn't declare an input variable named %q.", property.Config.Variable),
+			Subject:  property.Config.VariableRange.Ptr(),
+		})
+		return
+	}
+
+	vars, diags := runner.propertyVariables(property, file)
+	property.Diagnostics = property.Diagnostics.Append(diags)
+	if diags.HasErrors() {
+		property.Status = moduletest.Error
+		return
+	}
+
+	validations, diags := propertyConditions(variable.Name, variable.Validations, fmt.Sprintf("var.%s", variable.Name), true)
+	property.Diagnostics = property.Diagnostics.Append(diags)
+
+	var preconditions []*propertyCondition
+	for _, resource := range config.Module.ManagedResources {
+		conditions, _ := propertyConditions(variable.Name, resource.Preconditions, resource.Addr().String(), false)
+		preconditions = append(preconditions, conditions...)
+	}
+	for _, resource := range config.Module.DataResources {
+		conditions, _ := propertyConditions(variable.Name, resource.Preconditions, resource.Addr().String(), false)
+		preconditions = append(preconditions, conditions...)
+	}
+	for _, output := range config.Module.Outputs {
+		conditions, _ := propertyConditions(variable.Name, output.Preconditions, output.Addr().String(), false)
+		preconditions = append(preconditions, conditions...)
+	}
+
+	seed := property.Config.Seed
+	if runner.Suite.PropertySeed != nil {
+		seed = *runner.Suite.PropertySeed
+	}
+	generator := moduletest.NewValueGenerator(seed)
+
+	scope := &lang.Scope{}
+	for property.Iterations < property.Config.Iterations {
+		property.Iterations++
+
+		val := generator.Generate(variable.ConstraintType, variable.Nullable)
+		if variable.TypeDefaults != nil {
+			val = variable.TypeDefaults.Apply(val)
+		}
+		val, err := convert.Convert(val, variable.ConstraintType)
+		if err != nil {
+			property.Status = moduletest.Error
+			property.Diagnostics = property.Diagnostics.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to generate value",
+				fmt.Sprintf("OpenTofu generated a value for var.%s that doesn't conform to its type constraint: %s. This is a bug in OpenTofu - please report it.", variable.Name, err),
+			))
+			return
+		}
+
+		counterexample := func(subject *hcl.Range, summary, detail string) {
+			property.Status = moduletest.Fail
+			property.Diagnostics = property.Diagnostics.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  summary,
+				Detail:   fmt.Sprintf("%s\n\nCounterexample: var.%s = %s\n\nFound by value %d of property %q, generated with seed %d.", detail, variable.Name, propertyValueString(val, variable.Sensitive), property.Iterations, property.Name, seed),
+				Subject:  subject,
+			})
+		}
+
+		value := val
+		if variable.Sensitive {
+			value = value.Mark(marks.Sensitive)
+		}
+		vars[variable.Name] = value
+		evalCtx := &hcl.EvalContext{
+			Variables: map[string]cty.Value{
+				"var": cty.ObjectVal(vars),
+			},
+			Functions: scope.Functions(),
+		}
+
+		valid := true
+		for _, validation := range validations {
+			result, err := evaluatePropertyCondition(validation.rule, evalCtx)
+			if err != nil {
+				counterexample(validation.rule.Condition.Range().Ptr(), "Invalid variable validation result", fmt.Sprintf("The validation rule of %s failed to evaluate: %s.", validation.owner, err))
+				return
+			}
+			if result.IsKnown() && result.False() {
+				valid = false
+
+				if _, diags := validation.rule.ErrorMessage.Value(evalCtx); diags.HasErrors() {
+					counterexample(validation.rule.ErrorMessage.Range().Ptr(), "Invalid validation error message", fmt.Sprintf("The error message of a validation rule of %s failed to evaluate: %s.", validation.owner, diags.Error()))
+					return
+				}
+			}
+		}
+
+		if valid {
+			for _, precondition := range preconditions {
+				result, err := evaluatePropertyCondition(precondition.rule, evalCtx)
+				if err != nil {
+					counterexample(precondition.rule.Condition.Range().Ptr(), "Invalid precondition result", fmt.Sprintf("A precondition of %s failed to evaluate for a value accepted by the validation rules of var.%s: %s.", precondition.owner, variable.Name, err))
+					return
+				}
+				if result.IsKnown() && result.False() {
+					counterexample(precondition.rule.Condition.Range().Ptr(), "Precondition rejected a valid value", fmt.Sprintf("A precondition of %s rejected a value that the validation rules of var.%s accepted.", precondition.owner, variable.Name))
+					return
+				}
+			}
+		}
+
+		evalCtx.Variables["validation"] = cty.ObjectVal(map[string]cty.Value{
+			"valid": cty.BoolVal(valid),
+		})
+		for _, rule := range property.Config.CheckRules {
+			result, err := evaluatePropertyCondition(rule, evalCtx)
+			if err != nil {
+				property.Status = moduletest.Error
+				property.Diagnostics = property.Diagnostics.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid condition result",
+					Detail:   fmt.Sprintf("The assertion failed to evaluate: %s.", err),
+					Subject:  rule.Condition.Range().Ptr(),
+				})
+				return
+			}
+			if result.IsKnown() && result.False() {
+				message, diags := rule.ErrorMessage.Value(evalCtx)
+				if diags.HasErrors() || message.Type() != cty.String || !message.IsKnown() || message.IsNull() {
+					message = cty.StringVal("The assertion does not hold.")
+				}
+				message, _ = message.Unmark()
+				counterexample(rule.Condition.Range().Ptr(), "Property assertion failed", message.AsString())
+				return
+			}
+		}
+	}
+
+	property.Status = moduletest.Pass
+}
+
+// propertyVariables returns the values of the input variables of the module
+// under test, other than the one the property generates values for. Input
+// variables without a value are unknown.
+func (runner *TestFileRunner) propertyVariables(property *moduletest.Property, file *moduletest.File) (map[string]cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	config := runner.Config
+
+	evalCtx, ctxDiags := getEvalContextForTest(runner.States, runner.Suite.Fixtures, config, runner.Suite.GlobalVariables)
+	diags = diags.Append(ctxDiags)
+
+	vars := make(map[string]cty.Value)
+	for name := range config.Module.Variables {
+		vars[name] = cty.DynamicVal
+	}
+	for name, val := range evalCtx.Variables["var"].AsValueMap() {
+		vars[name] = val
+	}
+
+	for _, exprs := range []map[string]hcl.Expression{file.Config.Variables, property.Config.Variables} {
+		for name, expr := range exprs {
+			variable, exists := config.Module.Variables[name]
+			if !exists {
+				continue
+			}
+
+			val, valDiags := expr.Value(evalCtx)
+			diags = diags.Append(valDiags)
+			if valDiags.HasErrors() {
+				continue
+			}
+			if variable.TypeDefaults != nil {
+				val = variable.TypeDefaults.Apply(val)
+			}
+			val, err := convert.Convert(val, variable.ConstraintType)
+			if err != nil {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid value for input variable",
+					Detail:   fmt.Sprintf("The value for var.%s is not suitable: %s.", name, err),
+					Subject:  expr.Range().Ptr(),
+				})
+				continue
+			}
+			vars[name] = val
+		}
+	}
+
+	return vars, diags
+}
+
+// propertyConditions returns the given rules that refer to the given input
+// variable, and only to input variables, so they can be evaluated without
+// planning the module.
+//
+// If required is true, a warning is returned for the rules that can't be
+// evaluated. Otherwise, they are silently skipped.
+func propertyConditions(variable string, rules []*configs.CheckRule, owner string, required bool) ([]*propertyCondition, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	var conditions []*propertyCondition
+
+	for _, rule := range rules {
+		refs, _ := lang.ReferencesInExpr(addrs.ParseRef, rule.Condition)
+		msgRefs, _ := lang.ReferencesInExpr(addrs.ParseRef, rule.ErrorMessage)
+
+		supported, relevant := true, false
+		for _, ref := range append(refs, msgRefs...) {
+			v, ok := ref.Subject.(addrs.InputVariable)
+			if !ok {
+				supported = false
+				break
+			}
+			if v.Name == variable {
+				relevant = true
+			}
+		}
+
+		if !supported {
+			if required {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagWarning,
+					Summary:  "Validation rule not checked",
+					Detail:   fmt.Sprintf("This validation rule of %s refers to objects other than input variables, so it can't be checked with generated values.", owner),
+					Subject:  rule.Condition.Range().Ptr(),
+				})
+			}
+			continue
+		}
+		if !relevant && !required {
+			continue
+		}
+
+		conditions = append(conditions, &propertyCondition{
+			rule:  rule,
+			owner: owner,
+		})
+	}
+
+	return conditions, diags
+}
+
+// evaluatePropertyCondition evaluates the condition of the given rule. It
+// returns cty.True or cty.False, or cty.UnknownVal if the condition refers to
+// input variables without a value.
+func evaluatePropertyCondition(rule *configs.CheckRule, evalCtx *hcl.EvalContext) (cty.Value, error) {
+	result, diags := rule.Condition.Value(evalCtx)
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+
+	result, _ = result.Unmark()
+	if !result.IsKnown() {
+		return cty.UnknownVal(cty.Bool), nil
+	}
+	if result.IsNull() {
+		return cty.NilVal, fmt.Errorf("the condition value is null")
+	}
+	result, err := convert.Convert(result, cty.Bool)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("the condition value must be a boolean: %w", err)
+	}
+	return result, nil
+}
+
+// propertyValueString renders a generated value for display, unless it is
+// sensitive.
+func propertyValueString(val cty.Value, sensitive bool) string {
+	if sensitive {
+		return "(sensitive value)"
+	}
+	return string(hclwrite.TokensForValue(val).Bytes())
+}
@@ -0,0 +1,530 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/command/jsonplan"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/lang/marks"
+	"github.com/opentofu/opentofu/internal/moduletest"
+	"github.com/opentofu/opentofu/internal/plans"
+	"github.com/opentofu/opentofu/internal/states"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+	"github.com/opentofu/opentofu/internal/tofu"
+)
+
+const (
+	// testSnapshotUnknown and testSnapshotSensitive replace values within a
+	// snapshot that are not known until apply or that must not be written
+	// to disk.
+	testSnapshotUnknown   = "(known after apply)"
+	testSnapshotSensitive = "(sensitive value)"
+)
+
+// testSnapshot is the content of the golden file for a run block with a
+// snapshot block.
+type testSnapshot struct {
+	ResourceChanges []testSnapshotResourceChange `json:"resource_changes,omitempty"`
+	Outputs         map[string]interface{}       `json:"outputs,omitempty"`
+}
+
+type testSnapshotResourceChange struct {
+	Address         string      `json:"address"`
+	PreviousAddress string      `json:"previous_address,omitempty"`
+	Deposed         string      `json:"deposed,omitempty"`
+	ProviderName    string      `json:"provider_name"`
+	Actions         []string    `json:"actions"`
+	Before          interface{} `json:"before"`
+	After           interface{} `json:"after"`
+	ActionReason    string      `json:"action_reason,omitempty"`
+}
+
+// testSnapshotPath returns the location of the golden file for the given run
+// block, relative to the current working directory.
+//
+// Each run block expanded from a for_each argument has its own golden file.
+// For those, an explicit path is treated as a directory, once any extension
+// is removed, that holds a file for each key.
+func testSnapshotPath(file *moduletest.File, run *moduletest.Run) string {
+	dir := filepath.Dir(file.Name)
+	each := run.Config.Each
+	if path := run.Config.Snapshot.Path; path != "" {
+		if each == nil {
+			return filepath.Join(dir, path)
+		}
+		ext := filepath.Ext(path)
+		return filepath.Join(dir, strings.TrimSuffix(path, ext), url.PathEscape(each.Key)+ext)
+	}
+
+	name := filepath.Base(file.Name)
+	for _, ext := range []string{".tftest.hcl", ".tofutest.hcl"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if each != nil {
+		// The keys of run blocks expanded from a for_each argument might not
+		// be valid file names as they are.
+		return filepath.Join(dir, "snapshots", name, each.Name, url.PathEscape(each.Key)+".json")
+	}
+	return filepath.Join(dir, "snapshots", name, run.Name+".json")
+}
+
+// checkSnapshot compares the plan and outputs of the given run block against
+// its golden file, or writes the golden file if the user asked for the
+// snapshots to be updated. It does nothing for run blocks without a snapshot
+// block, or that have already errored.
+//
+// The state should be the updated state for run blocks that apply, and nil
+// for run blocks that only plan.
+func (runner *TestFileRunner) checkSnapshot(ctx context.Context, tfCtx *tofu.Context, config *configs.Config, run *moduletest.Run, file *moduletest.File, plan *plans.Plan, state *states.State) {
+	snapshot := run.Config.Snapshot
+	if snapshot == nil || run.Status == moduletest.Error {
+		return
+	}
+
+	got, diags := buildTestSnapshot(ctx, tfCtx, config, snapshot, plan, state)
+	run.Diagnostics = run.Diagnostics.Append(diags)
+	if diags.HasErrors() {
+		run.Status = moduletest.Error
+		return
+	}
+
+	filename := testSnapshotPath(file, run)
+
+	if runner.Suite.UpdateSnapshots {
+		if existing, err := os.ReadFile(filename); err == nil && bytes.Equal(existing, got) {
+			return
+		}
+		err := os.MkdirAll(filepath.Dir(filename), 0755)
+		if err == nil {
+			err = os.WriteFile(filename, got, 0644)
+		}
+		if err != nil {
+			run.Diagnostics = run.Diagnostics.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to update snapshot",
+				Detail:   fmt.Sprintf("OpenTofu could not write the snapshot for %s to %s: %s.", run.Name, filename, err),
+				Subject:  snapshot.DeclRange.Ptr(),
+			})
+			run.Status = moduletest.Error
+		}
+		return
+	}
+
+	want, err := os.ReadFile(filename)
+	if err != nil {
+		detail := fmt.Sprintf("OpenTofu could not read the snapshot for %s from %s: %s.", run.Name, filename, err)
+		if errors.Is(err, os.ErrNotExist) {
+			detail = fmt.Sprintf("The snapshot for %s does not exist at %s. Run tofu test with the -update-snapshots option to create it.", run.Name, filename)
+		}
+		run.Diagnostics = run.Diagnostics.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing snapshot",
+			Detail:   detail,
+			Subject:  snapshot.DeclRange.Ptr(),
+		})
+		run.Status = moduletest.Error
+		return
+	}
+
+	if bytes.Equal(want, got) {
+		return
+	}
+
+	run.Diagnostics = run.Diagnostics.Append(&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Snapshot does not match",
+		Detail: fmt.Sprintf(
+			"The plan and outputs of %s do not match the snapshot at %s:\n\n%s\nIf this change is expected, run tofu test with the -update-snapshots option to update the snapshot.",
+			run.Name, filename, testSnapshotDiff(string(want), string(got))),
+		Subject: snapshot.DeclRange.Ptr(),
+	})
+	run.Status = run.Status.Merge(moduletest.Fail)
+}
+
+func buildTestSnapshot(ctx context.Context, tfCtx *tofu.Context, config *configs.Config, snapshot *configs.TestRunSnapshot, plan *plans.Plan, state *states.State) ([]byte, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	var ret testSnapshot
+
+	if snapshot.Plan {
+		schemas, schemaDiags := tfCtx.Schemas(ctx, config, plan.PlannedState)
+		diags = diags.Append(schemaDiags)
+		if schemaDiags.HasErrors() {
+			return nil, diags
+		}
+
+		changes, err := jsonplan.MarshalResourceChanges(plan.Changes.Resources, schemas)
+		if err != nil {
+			return nil, diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to render snapshot",
+				fmt.Sprintf("OpenTofu could not render the resource changes for the snapshot: %s.", err),
+			))
+		}
+
+		ret.ResourceChanges = []testSnapshotResourceChange{}
+		for _, change := range changes {
+			snap := testSnapshotResourceChange{
+				Address:         change.Address,
+				PreviousAddress: change.PreviousAddress,
+				Deposed:         change.Deposed,
+				ProviderName:    change.ProviderName,
+				Actions:         change.Change.Actions,
+				ActionReason:    change.ActionReason,
+			}
+			if snap.Before, err = mergeTestSnapshotJSON(change.Change.Before, nil, change.Change.BeforeSensitive); err == nil {
+				snap.After, err = mergeTestSnapshotJSON(change.Change.After, change.Change.AfterUnknown, change.Change.AfterSensitive)
+			}
+			if err != nil {
+				return nil, diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Failed to render snapshot",
+					fmt.Sprintf("OpenTofu could not render the change to %s for the snapshot: %s.", change.Address, err),
+				))
+			}
+			ret.ResourceChanges = append(ret.ResourceChanges, snap)
+		}
+	}
+
+	if len(snapshot.Outputs) > 0 {
+		ret.Outputs = make(map[string]interface{})
+		for ix, name := range snapshot.OutputNames() {
+			if _, exists := config.Module.Outputs[name]; !exists {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Reference to undeclared output value",
+					Detail:   fmt.Sprintf("An output value with the name %q has not been declared in the module under test.", name),
+					Subject:  snapshot.Outputs[ix].SourceRange().Ptr(),
+				})
+				continue
+			}
+
+			value, sensitive, err := testSnapshotOutput(name, plan, state)
+			if err != nil {
+				return nil, diags.Append(tfdiags.Sourceless(
+					tfdiags.Error,
+					"Failed to render snapshot",
+					fmt.Sprintf("OpenTofu could not render output.%s for the snapshot: %s.", name, err),
+				))
+			}
+			ret.Outputs[name] = testSnapshotValue(value, sensitive)
+		}
+		if diags.HasErrors() {
+			return nil, diags
+		}
+	}
+
+	src, err := json.MarshalIndent(ret, "", "  ")
+	if err != nil {
+		return nil, diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to render snapshot",
+			fmt.Sprintf("OpenTofu could not render the snapshot: %s.", err),
+		))
+	}
+	return append(src, '\n'), diags
+}
+
+// mergeTestSnapshotJSON combines a value from the JSON plan with the
+// matching structures that mark which parts of it are unknown or sensitive,
+// producing a single value in which those parts are replaced with
+// placeholders.
+func mergeTestSnapshotJSON(value, unknown, sensitive json.RawMessage) (interface{}, error) {
+	var v, u, s interface{}
+	for _, raw := range []struct {
+		src json.RawMessage
+		dst *interface{}
+	}{{value, &v}, {unknown, &u}, {sensitive, &s}} {
+		if len(raw.src) == 0 {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw.src))
+		dec.UseNumber()
+		if err := dec.Decode(raw.dst); err != nil {
+			return nil, err
+		}
+	}
+	return mergeTestSnapshotValue(v, u, s), nil
+}
+
+func mergeTestSnapshotValue(value, unknown, sensitive interface{}) interface{} {
+	if b, ok := sensitive.(bool); ok && b {
+		return testSnapshotSensitive
+	}
+	if b, ok := unknown.(bool); ok && b {
+		return testSnapshotUnknown
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		unknowns, _ := unknown.(map[string]interface{})
+		sensitives, _ := sensitive.(map[string]interface{})
+		ret := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			ret[k] = mergeTestSnapshotValue(elem, unknowns[k], sensitives[k])
+		}
+		for k, elem := range unknowns {
+			if _, exists := v[k]; !exists {
+				ret[k] = mergeTestSnapshotValue(nil, elem, sensitives[k])
+			}
+		}
+		return ret
+	case []interface{}:
+		unknowns, _ := unknown.([]interface{})
+		sensitives, _ := sensitive.([]interface{})
+		ret := make([]interface{}, len(v))
+		for ix, elem := range v {
+			var u, s interface{}
+			if ix < len(unknowns) {
+				u = unknowns[ix]
+			}
+			if ix < len(sensitives) {
+				s = sensitives[ix]
+			}
+			ret[ix] = mergeTestSnapshotValue(elem, u, s)
+		}
+		return ret
+	default:
+		return v
+	}
+}
+
+// testSnapshotOutput returns the value of the named root module output from
+// the state if we have one, or otherwise from the planned changes. The planned
+// state can't be used for this, as it doesn't retain unknown output values.
+func testSnapshotOutput(name string, plan *plans.Plan, state *states.State) (cty.Value, bool, error) {
+	if state != nil {
+		if output := state.RootModule().OutputValues[name]; output != nil {
+			return output.Value, output.Sensitive, nil
+		}
+		return cty.NullVal(cty.DynamicPseudoType), false, nil
+	}
+
+	addr := addrs.OutputValue{Name: name}.Absolute(addrs.RootModuleInstance)
+	if change := plan.Changes.OutputValue(addr); change != nil {
+		decoded, err := change.Decode()
+		if err != nil {
+			return cty.NilVal, false, err
+		}
+		return decoded.After, decoded.Sensitive, nil
+	}
+	return cty.NullVal(cty.DynamicPseudoType), false, nil
+}
+
+// testSnapshotValue converts an output value into a form that can be written
+// to a snapshot.
+func testSnapshotValue(value cty.Value, sensitive bool) interface{} {
+	if sensitive || value.HasMark(marks.Sensitive) {
+		return testSnapshotSensitive
+	}
+	value, _ = value.Unmark()
+
+	switch {
+	case !value.IsKnown():
+		return testSnapshotUnknown
+	case value.IsNull():
+		return nil
+	}
+
+	ty := value.Type()
+	switch {
+	case ty.IsListType(), ty.IsSetType(), ty.IsTupleType():
+		ret := []interface{}{}
+		for it := value.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			ret = append(ret, testSnapshotValue(elem, false))
+		}
+		return ret
+	case ty.IsMapType(), ty.IsObjectType():
+		ret := make(map[string]interface{})
+		for it := value.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			ret[key.AsString()] = testSnapshotValue(elem, false)
+		}
+		return ret
+	default:
+		src, err := ctyjson.Marshal(value, ty)
+		if err != nil {
+			// Primitive values that are known and not null can always be
+			// marshalled, so this should never happen.
+			return value.GoString()
+		}
+		return json.RawMessage(src)
+	}
+}
+
+// testSnapshotDiff renders a line-based diff between the expected and actual
+// content of a snapshot, showing only the changed lines and a little context
+// around them.
+func testSnapshotDiff(want, got string) string {
+	const contextLines = 2
+
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	lines := testSnapshotDiffLines(a, b, nil)
+
+	show := make([]bool, len(lines))
+	for ix, l := range lines {
+		if l.prefix == " " {
+			continue
+		}
+		for k := max(0, ix-contextLines); k <= min(len(lines)-1, ix+contextLines); k++ {
+			show[k] = true
+		}
+	}
+
+	var buf strings.Builder
+	skipped := false
+	for ix, l := range lines {
+		if !show[ix] {
+			skipped = true
+			continue
+		}
+		if skipped {
+			buf.WriteString("  ...\n")
+			skipped = false
+		}
+		fmt.Fprintf(&buf, "%s %s\n", l.prefix, l.text)
+	}
+	if skipped {
+		buf.WriteString("  ...\n")
+	}
+	return buf.String()
+}
+
+// testSnapshotDiffLine is a single line of the output of testSnapshotDiff,
+// prefixed with " ", "-" or "+" for unchanged, removed and added lines.
+type testSnapshotDiffLine struct {
+	prefix string
+	text   string
+}
+
+// testSnapshotDiffLines appends a minimal line-based edit script turning a
+// into b to lines, and returns the result.
+//
+// This uses the linear space variant of Myers' algorithm, which divides the
+// problem at the middle of an optimal edit script and recurses on both
+// halves, so that diffing large snapshots doesn't require a table of every
+// pair of lines.
+func testSnapshotDiffLines(a, b []string, lines []testSnapshotDiffLine) []testSnapshotDiffLine {
+	// Common prefixes and suffixes are always part of the result, and
+	// removing them first means the search below only ever sees input that
+	// begins and ends with a change.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, text := range a[:prefix] {
+		lines = append(lines, testSnapshotDiffLine{" ", text})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	switch {
+	case len(midA) == 0:
+		for _, text := range midB {
+			lines = append(lines, testSnapshotDiffLine{"+", text})
+		}
+	case len(midB) == 0:
+		for _, text := range midA {
+			lines = append(lines, testSnapshotDiffLine{"-", text})
+		}
+	default:
+		x, y, u, v := testSnapshotMiddleSnake(midA, midB)
+		lines = testSnapshotDiffLines(midA[:x], midB[:y], lines)
+		for _, text := range midA[x:u] {
+			lines = append(lines, testSnapshotDiffLine{" ", text})
+		}
+		lines = testSnapshotDiffLines(midA[u:], midB[v:], lines)
+	}
+
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, testSnapshotDiffLine{" ", text})
+	}
+	return lines
+}
+
+// testSnapshotMiddleSnake finds the run of matching lines, from a[x:u] and
+// b[y:v], in the middle of an optimal edit script turning a into b. Both a and
+// b must be non-empty and must differ in their first and last lines.
+func testSnapshotMiddleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	limit := (n + m + 1) / 2
+
+	// forward[offset+k] is the furthest x reached on diagonal k = x - y when
+	// searching from the start of both inputs, and backward[offset+k] is the
+	// same when searching from the end of both inputs, with x and y counted
+	// from the end.
+	offset := limit + 1
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+			forward[offset+k] = u
+
+			// The backward search has taken one step fewer than the forward
+			// search at this point, so it can only have overlapped with it
+			// when the difference in length is odd.
+			if c := delta - k; odd && c >= -(d-1) && c <= d-1 && u+backward[offset+c] >= n {
+				return x, y, u, v
+			}
+		}
+
+		for c := -d; c <= d; c += 2 {
+			var bx int
+			if c == -d || (c != d && backward[offset+c-1] < backward[offset+c+1]) {
+				bx = backward[offset+c+1]
+			} else {
+				bx = backward[offset+c-1] + 1
+			}
+			by := bx - c
+			bu, bv := bx, by
+			for bu < n && bv < m && a[n-1-bu] == b[m-1-bv] {
+				bu++
+				bv++
+			}
+			backward[offset+c] = bu
+
+			if k := delta - c; !odd && k >= -d && k <= d && forward[offset+k]+bu >= n {
+				return n - bu, m - bv, n - bx, m - by
+			}
+		}
+	}
+
+	// There is always an edit script of at most n+m steps, so the searches
+	// must have met by now.
+	panic("testSnapshotMiddleSnake: searches did not meet")
+}
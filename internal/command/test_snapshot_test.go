@@ -0,0 +1,119 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTestSnapshotDiff(t *testing.T) {
+	tcs := map[string]struct {
+		want, got string
+		expected  string
+	}{
+		"changed line": {
+			want: "a\nb\nc\nd\ne\nf\ng\n",
+			got:  "a\nb\nc\nD\ne\nf\ng\n",
+			expected: `  ...
+  b
+  c
+- d
++ D
+  e
+  f
+  ...
+`,
+		},
+		"added lines": {
+			want:     "a\nb\n",
+			got:      "a\nb\nc\nd\n",
+			expected: "  a\n  b\n+ c\n+ d\n",
+		},
+		"removed lines": {
+			want:     "a\nb\nc\nd\n",
+			got:      "c\nd\n",
+			expected: "- a\n- b\n  c\n  d\n",
+		},
+		"separate changes": {
+			want: "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			got:  "0\n1\n2\n3\n4\n5\n6\n7\n8\n",
+			expected: `+ 0
+  1
+  2
+  ...
+  7
+  8
+- 9
+`,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, testSnapshotDiff(tc.want, tc.got)); len(diff) > 0 {
+				t.Errorf("unexpected diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTestSnapshotDiffLines_minimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, rng.Intn(12))
+		for ix := range lines {
+			lines[ix] = fmt.Sprint(rng.Intn(4))
+		}
+		return lines
+	}
+
+	for ix := 0; ix < 1000; ix++ {
+		a, b := randomLines(), randomLines()
+		lines := testSnapshotDiffLines(a, b, nil)
+
+		var gotA, gotB []string
+		edits := 0
+		for _, l := range lines {
+			if l.prefix != "+" {
+				gotA = append(gotA, l.text)
+			}
+			if l.prefix != "-" {
+				gotB = append(gotB, l.text)
+			}
+			if l.prefix != " " {
+				edits++
+			}
+		}
+		if strings.Join(gotA, ",") != strings.Join(a, ",") || strings.Join(gotB, ",") != strings.Join(b, ",") {
+			t.Fatalf("diff of %q and %q does not reproduce its inputs: %v", a, b, lines)
+		}
+		if want := len(a) + len(b) - 2*testLongestCommonSubsequence(a, b); edits != want {
+			t.Fatalf("diff of %q and %q has %d edits; want %d", a, b, edits, want)
+		}
+	}
+}
+
+// testLongestCommonSubsequence is the straightforward quadratic solution,
+// used to check that testSnapshotDiffLines produces minimal diffs.
+func testLongestCommonSubsequence(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return lcs[0][0]
+}
@@ -6,7 +6,10 @@
 package command
 
 import (
+	"fmt"
+	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -19,7 +22,9 @@ import (
 	"github.com/opentofu/opentofu/internal/command/views"
 	"github.com/opentofu/opentofu/internal/configs/configschema"
 	"github.com/opentofu/opentofu/internal/providers"
+	"github.com/opentofu/opentofu/internal/states"
 	"github.com/opentofu/opentofu/internal/terminal"
+	"github.com/opentofu/opentofu/internal/tofu"
 )
 
 func TestTest(t *testing.T) {
@@ -392,6 +397,236 @@ test:
 	}
 }
 
+func TestTest_Cleanup(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath(path.Join("test", "with_double_interrupt")), td)
+	t.Chdir(td)
+
+	provider := testing_command.NewProvider(nil)
+	view, done := testView(t)
+
+	interrupt := make(chan struct{})
+	provider.Interrupt = interrupt
+
+	c := &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+			ShutdownCh:       interrupt,
+		},
+	}
+
+	c.Run(nil)
+	output := done(t).All()
+
+	if !strings.Contains(output, "Leftover test resources") {
+		t.Errorf("output didn't produce the right output:\n\n%s", output)
+	}
+
+	journals, err := filepath.Glob(filepath.Join(c.DataDir(), testJournalDirectory, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(journals) != 1 {
+		t.Fatalf("expected one test journal, but found %v", journals)
+	}
+	if provider.ResourceCount() != 3 {
+		t.Fatalf("should not have deleted all resources on completion but left %v", provider.ResourceString())
+	}
+
+	// Executing the tests again warns about the leftover resources, without
+	// destroying them.
+	provider.Interrupt = nil
+	view, done = testView(t)
+	c = &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+		},
+	}
+	if code := c.Run([]string{"-filter=unknown.tftest.hcl"}); code != 0 {
+		t.Errorf("expected status code 0 but got %d", code)
+	}
+	output = done(t).All()
+	if !strings.Contains(output, "Leftover test resources") {
+		t.Errorf("expected a leftover test resources warning, but got:\n%s", output)
+	}
+	if provider.ResourceCount() != 3 {
+		t.Fatalf("should not have deleted the leftover resources but left %v", provider.ResourceString())
+	}
+
+	view, done = testView(t)
+	c = &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+		},
+	}
+	code := c.Run([]string{"-cleanup", "-no-color"})
+	output = done(t).All()
+
+	if code != 0 {
+		t.Errorf("expected status code 0 but got %d: %s", code, output)
+	}
+
+	expected := `main.tftest.hcl... cleaned up
+
+Cleanup complete! All leftover test resources were destroyed.
+`
+	if diff := cmp.Diff(expected, output); len(diff) > 0 {
+		t.Errorf("output didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", expected, output, diff)
+	}
+
+	if provider.ResourceCount() > 0 {
+		t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+	}
+	if _, err := os.Stat(journals[0]); !os.IsNotExist(err) {
+		t.Errorf("expected the test journal to be removed, but got: %v", err)
+	}
+
+	// There is nothing left to clean up the second time.
+	view, done = testView(t)
+	c = &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+		},
+	}
+	if code := c.Run([]string{"-cleanup", "-no-color"}); code != 0 {
+		t.Errorf("expected status code 0 but got %d", code)
+	}
+	if diff := cmp.Diff("No leftover test resources were found.\n", done(t).All()); len(diff) > 0 {
+		t.Errorf("output didn't match expected:\n%s", diff)
+	}
+}
+
+func TestTest_CleanupVariables(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath(path.Join("test", "cleanup_variables")), td)
+	t.Chdir(td)
+
+	provider := testing_command.NewProvider(nil)
+	view, done := testView(t)
+
+	interrupt := make(chan struct{})
+	provider.Interrupt = interrupt
+
+	c := &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+			ShutdownCh:       interrupt,
+		},
+	}
+
+	c.Run([]string{"-var=prefix=journal"})
+	done(t)
+
+	if provider.ResourceCount() != 3 {
+		t.Fatalf("should not have deleted all resources on completion but left %v", provider.ResourceString())
+	}
+
+	// The cleanup must use the variables the resources were created with, as
+	// the variable has no default and isn't given this time.
+	provider.Interrupt = nil
+	view, done = testView(t)
+	c = &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+		},
+	}
+	code := c.Run([]string{"-cleanup", "-no-color"})
+	output := done(t).All()
+
+	if code != 0 {
+		t.Errorf("expected status code 0 but got %d: %s", code, output)
+	}
+	if provider.ResourceCount() > 0 {
+		t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+	}
+}
+
+func TestTest_CleanupSensitiveVariables(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath(path.Join("test", "cleanup_sensitive_variables")), td)
+	t.Chdir(td)
+
+	provider := testing_command.NewProvider(nil)
+	view, done := testView(t)
+
+	interrupt := make(chan struct{})
+	provider.Interrupt = interrupt
+
+	c := &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+			ShutdownCh:       interrupt,
+		},
+	}
+
+	c.Run([]string{"-var=prefix=journal"})
+	done(t)
+
+	if provider.ResourceCount() != 3 {
+		t.Fatalf("should not have deleted all resources on completion but left %v", provider.ResourceString())
+	}
+
+	// The value of the sensitive variable must not be recorded in the
+	// journal.
+	journals, diags := loadTestJournals(filepath.Join(c.DataDir(), testJournalDirectory))
+	if diags.HasErrors() {
+		t.Fatal(diags.Err())
+	}
+	if len(journals) != 1 {
+		t.Fatalf("expected one test journal, but found %d", len(journals))
+	}
+	if got, want := journals[0].Variables["prefix"], (&testJournalVariable{Sensitive: true, SourceType: tofu.ValueFromCLIArg}); !cmp.Equal(got, want) {
+		t.Errorf("wrong recorded variable\n%s", cmp.Diff(want, got))
+	}
+
+	// The cleanup fails without the sensitive variable, and keeps the
+	// journal so it can be tried again.
+	provider.Interrupt = nil
+	view, done = testView(t)
+	c = &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+		},
+	}
+	code := c.Run([]string{"-cleanup", "-no-color"})
+	output := done(t)
+
+	if code != 1 {
+		t.Errorf("expected status code 1 but got %d: %s", code, output.All())
+	}
+	if want := `variable "prefix" is sensitive`; !strings.Contains(output.Stderr(), want) {
+		t.Errorf("expected errors to contain %q, but got:\n%s", want, output.Stderr())
+	}
+	if provider.ResourceCount() != 3 {
+		t.Fatalf("should not have deleted the leftover resources but left %v", provider.ResourceString())
+	}
+
+	view, done = testView(t)
+	c = &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+		},
+	}
+	code = c.Run([]string{"-cleanup", "-no-color", "-var=prefix=journal"})
+	output = done(t)
+
+	if code != 0 {
+		t.Errorf("expected status code 0 but got %d: %s", code, output.All())
+	}
+	if provider.ResourceCount() > 0 {
+		t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+	}
+}
+
 func TestTest_ProviderAlias(t *testing.T) {
 	td := t.TempDir()
 	testCopyDir(t, testFixturePath(path.Join("test", "with_provider_alias")), td)
@@ -500,41 +735,676 @@ func TestTest_ModuleDependencies(t *testing.T) {
 		t.Fatalf("expected status code 0 but got %d: %s", code, ui.ErrorWriter)
 	}
 
-	command := &TestCommand{
-		Meta: meta,
-	}
+	command := &TestCommand{
+		Meta: meta,
+	}
+
+	code := command.Run(nil)
+	output := done(t)
+
+	printedOutput := false
+
+	if code != 0 {
+		printedOutput = true
+		t.Errorf("expected status code 0 but got %d: %s", code, output.All())
+	}
+
+	if test.ResourceCount() > 0 {
+		if !printedOutput {
+			printedOutput = true
+			t.Errorf("should have deleted all resources on completion but left %s\n\n%s", test.ResourceString(), output.All())
+		} else {
+			t.Errorf("should have deleted all resources on completion but left %s", test.ResourceString())
+		}
+	}
+
+	if setup.ResourceCount() > 0 {
+		if !printedOutput {
+			t.Errorf("should have deleted all resources on completion but left %s\n\n%s", setup.ResourceString(), output.All())
+		} else {
+			t.Errorf("should have deleted all resources on completion but left %s", setup.ResourceString())
+		}
+	}
+}
+
+func TestTest_CatchesErrorsBeforeDestroy(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath(path.Join("test", "invalid_default_state")), td)
+	t.Chdir(td)
+
+	provider := testing_command.NewProvider(nil)
+	view, done := testView(t)
+
+	c := &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+		},
+	}
+
+	code := c.Run([]string{"-no-color"})
+	output := done(t)
+
+	if code != 1 {
+		t.Errorf("expected status code 0 but got %d", code)
+	}
+
+	expectedOut := `main.tftest.hcl... fail
+  run "test"... fail
+
+Failure! 0 passed, 1 failed.
+`
+
+	expectedErr := `
+Error: No value for required variable
+
+  on main.tf line 2:
+   2: variable "input" {
+
+The root module input variable "input" is not set, and has no default value.
+Use a -var or -var-file command line argument to provide a value for this
+variable.
+`
+
+	actualOut := output.Stdout()
+	actualErr := output.Stderr()
+
+	if diff := cmp.Diff(actualOut, expectedOut); len(diff) > 0 {
+		t.Errorf("std out didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", expectedOut, actualOut, diff)
+	}
+
+	if diff := cmp.Diff(actualErr, expectedErr); len(diff) > 0 {
+		t.Errorf("std err didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", expectedErr, actualErr, diff)
+	}
+
+	if provider.ResourceCount() > 0 {
+		t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+	}
+}
+
+func TestTest_Verbose(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath(path.Join("test", "plan_then_apply")), td)
+	t.Chdir(td)
+
+	provider := testing_command.NewProvider(nil)
+	view, done := testView(t)
+
+	c := &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+		},
+	}
+
+	code := c.Run([]string{"-verbose", "-no-color"})
+	output := done(t)
+
+	if code != 0 {
+		t.Errorf("expected status code 0 but got %d", code)
+	}
+
+	expected := `main.tftest.hcl... pass
+  run "validate_test_resource"... pass
+
+OpenTofu used the selected providers to generate the following execution
+plan. Resource actions are indicated with the following symbols:
+  + create
+
+OpenTofu will perform the following actions:
+
+  # test_resource.foo will be created
+  + resource "test_resource" "foo" {
+      + id    = "constant_value"
+      + value = "bar"
+    }
+
+Plan: 1 to add, 0 to change, 0 to destroy.
+  run "validate_test_resource"... pass
+# test_resource.foo:
+resource "test_resource" "foo" {
+    id    = "constant_value"
+    value = "bar"
+}
+
+Success! 2 passed, 0 failed.
+`
+
+	actual := output.All()
+
+	if diff := cmp.Diff(actual, expected); len(diff) > 0 {
+		t.Errorf("output didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", expected, actual, diff)
+	}
+
+	if provider.ResourceCount() > 0 {
+		t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+	}
+}
+
+func TestTest_Parallel(t *testing.T) {
+	tcs := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"multiple_files": {
+			args: []string{"-parallelism=2"},
+			expected: `one.tftest.hcl... pass
+  run "validate_test_resource"... pass
+two.tftest.hcl... pass
+  run "validate_test_resource"... pass
+
+Success! 2 passed, 0 failed.
+`,
+		},
+		"parallel_runs": {
+			expected: `main.tftest.hcl... pass
+  run "setup"... pass
+  run "first"... pass
+  run "second"... pass
+  run "main_state_unchanged"... pass
+  run "parallel_outputs"... pass
+
+Success! 5 passed, 0 failed.
+`,
+		},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			td := t.TempDir()
+			testCopyDir(t, testFixturePath(path.Join("test", name)), td)
+			t.Chdir(td)
+
+			provider := testing_command.NewProvider(nil)
+			view, done := testView(t)
+
+			c := &TestCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(provider.Provider),
+					View:             view,
+				},
+			}
+
+			code := c.Run(append(tc.args, "-no-color"))
+			output := done(t)
+
+			if code != 0 {
+				t.Errorf("expected status code 0 but got %d", code)
+			}
+
+			if diff := cmp.Diff(tc.expected, output.All()); len(diff) > 0 {
+				t.Errorf("output didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", tc.expected, output.All(), diff)
+			}
+
+			if provider.ResourceCount() > 0 {
+				t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+			}
+		})
+	}
+}
+
+// TestTest_ParallelModules combines parallel files with parallel run blocks
+// executing both the main configuration and alternate modules, and so is
+// most useful when run with the race detector enabled.
+func TestTest_ParallelModules(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath(path.Join("test", "parallel_runs_modules")), td)
+	t.Chdir(td)
+
+	provider := testing_command.NewProvider(nil)
+	providerSource, closePS := newMockProviderSource(t, map[string][]string{
+		"test": {"1.0.0"},
+	})
+	defer closePS()
+
+	view, done := testView(t)
+	ui := new(cli.MockUi)
+	meta := Meta{
+		testingOverrides: metaOverridesForProvider(provider.Provider),
+		Ui:               ui,
+		View:             view,
+		ProviderSource:   providerSource,
+	}
+
+	if code := (&InitCommand{Meta: meta}).Run(nil); code != 0 {
+		t.Fatalf("expected status code 0 but got %d: %s", code, ui.ErrorWriter)
+	}
+
+	c := &TestCommand{Meta: meta}
+	code := c.Run([]string{"-parallelism=2", "-no-color"})
+	output := done(t)
+
+	if code != 0 {
+		t.Errorf("expected status code 0 but got %d: %s", code, output.All())
+	}
+
+	expected := `a.tftest.hcl... pass
+  run "main"... pass
+  run "other"... pass
+  run "other_again"... pass
+b.tftest.hcl... pass
+  run "main"... pass
+  run "other"... pass
+  run "other_again"... pass
+
+Success! 6 passed, 0 failed.
+`
+	if diff := cmp.Diff(expected, output.Stdout()); len(diff) > 0 {
+		t.Errorf("output didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", expected, output.Stdout(), diff)
+	}
+
+	if provider.ResourceCount() > 0 {
+		t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+	}
+}
+
+func TestTest_Coverage(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath(path.Join("test", "coverage")), td)
+	t.Chdir(td)
+
+	provider := testing_command.NewProvider(nil)
+	view, done := testView(t)
+
+	c := &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+		},
+	}
+
+	code := c.Run([]string{"-no-color", "-coverage-report=coverage.info"})
+	output := done(t)
+
+	if code != 0 {
+		t.Errorf("expected status code 0 but got %d", code)
+	}
+
+	expected := `main.tftest.hcl... pass
+  run "apply"... pass
+
+Success! 1 passed, 0 failed.
+
+Coverage:
+  root module: 4 of 5 objects covered (80.0%)
+`
+	if diff := cmp.Diff(expected, output.All()); len(diff) > 0 {
+		t.Errorf("output didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", expected, output.All(), diff)
+	}
+
+	report, err := os.ReadFile("coverage.info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedReport := `TN:
+SF:main.tf
+DA:4,2
+DA:15,2
+DA:19,2
+DA:26,0
+DA:31,2
+LF:5
+LH:4
+end_of_record
+`
+	if diff := cmp.Diff(expectedReport, string(report)); len(diff) > 0 {
+		t.Errorf("wrong coverage report:\n%s", diff)
+	}
+}
+
+// TestTest_CoverageReportError checks that failing to write the coverage
+// report doesn't prevent writing the JUnit XML report.
+func TestTest_CoverageReportError(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath(path.Join("test", "coverage")), td)
+	t.Chdir(td)
+
+	// The coverage report can't be written over a directory.
+	if err := os.Mkdir("coverage.info", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := testing_command.NewProvider(nil)
+	view, done := testView(t)
+
+	c := &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+		},
+	}
+
+	code := c.Run([]string{"-no-color", "-coverage-report=coverage.info", "-junit-xml=junit.xml"})
+	output := done(t)
+
+	if code != 1 {
+		t.Errorf("expected status code 1 but got %d", code)
+	}
+	if want := "coverage.info"; !strings.Contains(output.Stderr(), want) {
+		t.Errorf("expected errors to mention %q, but got:\n%s", want, output.Stderr())
+	}
+
+	report, err := os.ReadFile("junit.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<testsuites tests="1" failures="0" errors="0" skipped="0"`; !strings.Contains(string(report), want) {
+		t.Errorf("JUnit XML report doesn't contain %q:\n%s", want, report)
+	}
+}
+
+func TestTest_ExpectError(t *testing.T) {
+	tcs := map[string]struct {
+		file     string
+		code     int
+		expected string
+		errors   []string
+	}{
+		"pass": {
+			file: "pass.tftest.hcl",
+			code: 0,
+			expected: `pass.tftest.hcl... pass
+  run "wrong_type"... pass
+  run "provider_error"... pass
+  run "no_error"... pass
+
+Success! 3 passed, 0 failed.
+`,
+		},
+		"missing": {
+			file: "missing.tftest.hcl",
+			code: 1,
+			expected: `missing.tftest.hcl... fail
+  run "no_error"... fail
+
+Failure! 0 passed, 1 failed.
+`,
+			errors: []string{
+				"Error: Missing expected error",
+				`The run block was expected to report an error with a summary matching
+"Invalid value for variable", but did not.`,
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			td := t.TempDir()
+			testCopyDir(t, testFixturePath(path.Join("test", "expect_error")), td)
+			t.Chdir(td)
+
+			provider := testing_command.NewProvider(nil)
+			view, done := testView(t)
+
+			c := &TestCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(provider.Provider),
+					View:             view,
+				},
+			}
+
+			code := c.Run([]string{"-no-color", "-filter=" + tc.file})
+			output := done(t)
+
+			if code != tc.code {
+				t.Errorf("expected status code %d but got %d", tc.code, code)
+			}
+
+			if diff := cmp.Diff(tc.expected, output.Stdout()); len(diff) > 0 {
+				t.Errorf("output didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", tc.expected, output.Stdout(), diff)
+			}
+
+			if len(tc.errors) == 0 && len(output.Stderr()) > 0 {
+				t.Errorf("unexpected errors:\n%s", output.Stderr())
+			}
+			for _, want := range tc.errors {
+				if !strings.Contains(output.Stderr(), want) {
+					t.Errorf("expected errors to contain %q, but got:\n%s", want, output.Stderr())
+				}
+			}
+
+			if provider.ResourceCount() > 0 {
+				t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+			}
+		})
+	}
+}
+
+func TestTest_Properties(t *testing.T) {
+	tcs := map[string]struct {
+		file     string
+		args     []string
+		code     int
+		expected string
+		errors   []string
+	}{
+		"valid": {
+			file: "valid.tftest.hcl",
+			code: 0,
+			expected: `valid.tftest.hcl... pass
+  run "apply"... pass
+  property "port"... pass
+
+Success! 2 passed, 0 failed.
+`,
+		},
+		"null": {
+			file: "null.tftest.hcl",
+			code: 1,
+			expected: `null.tftest.hcl... fail
+  property "name"... fail
+
+Failure! 0 passed, 1 failed.
+`,
+			errors: []string{
+				"Error: Invalid variable validation result",
+				"Counterexample: var.name = null",
+			},
+		},
+		"precondition": {
+			file: "precondition.tftest.hcl",
+			code: 1,
+			expected: `precondition.tftest.hcl... fail
+  property "environment"... fail
+
+Failure! 0 passed, 1 failed.
+`,
+			errors: []string{
+				"Error: Precondition rejected a valid value",
+				`Counterexample: var.environment = "a "`,
+			},
+		},
+		"seed": {
+			file: "precondition.tftest.hcl",
+			args: []string{"-property-seed=3"},
+			code: 1,
+			expected: `precondition.tftest.hcl... fail
+  property "environment"... fail
+
+Failure! 0 passed, 1 failed.
+`,
+			errors: []string{
+				"generated with seed 3.",
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			td := t.TempDir()
+			testCopyDir(t, testFixturePath(path.Join("test", "properties")), td)
+			t.Chdir(td)
+
+			provider := testing_command.NewProvider(nil)
+			view, done := testView(t)
+
+			c := &TestCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(provider.Provider),
+					View:             view,
+				},
+			}
+
+			code := c.Run(append([]string{"-no-color", "-filter=" + tc.file}, tc.args...))
+			output := done(t)
+
+			if code != tc.code {
+				t.Errorf("expected status code %d but got %d", tc.code, code)
+			}
+
+			if diff := cmp.Diff(tc.expected, output.Stdout()); len(diff) > 0 {
+				t.Errorf("output didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", tc.expected, output.Stdout(), diff)
+			}
+
+			if len(tc.errors) == 0 && len(output.Stderr()) > 0 {
+				t.Errorf("unexpected errors:\n%s", output.Stderr())
+			}
+			for _, want := range tc.errors {
+				if !strings.Contains(output.Stderr(), want) {
+					t.Errorf("expected errors to contain %q, but got:\n%s", want, output.Stderr())
+				}
+			}
+
+			if provider.ResourceCount() > 0 {
+				t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+			}
+		})
+	}
+}
+
+func TestTest_Fixtures(t *testing.T) {
+	tcs := map[string]struct {
+		// leftover, if set, records a fixture in the manifest as if a
+		// previous execution exited before destroying it.
+		leftover string
+	}{
+		"clean": {},
+		"leftover": {
+			leftover: "network",
+		},
+		"leftover undeclared": {
+			leftover: "removed",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			td := t.TempDir()
+			testCopyDir(t, testFixturePath(path.Join("test", "fixtures")), td)
+			t.Chdir(td)
+
+			provider := testing_command.NewProvider(nil)
+			providerSource, closePS := newMockProviderSource(t, map[string][]string{
+				"test": {"1.0.0"},
+			})
+			defer closePS()
+
+			view, done := testView(t)
+			ui := new(cli.MockUi)
+			meta := Meta{
+				testingOverrides: metaOverridesForProvider(provider.Provider),
+				Ui:               ui,
+				View:             view,
+				ProviderSource:   providerSource,
+			}
+
+			if code := (&InitCommand{Meta: meta}).Run(nil); code != 0 {
+				t.Fatalf("expected status code 0 but got %d: %s", code, ui.ErrorWriter)
+			}
+
+			manifestPath := path.Join(meta.DataDir(), testFixtureManifestFile)
+			if tc.leftover != "" {
+				provider.Store.Put(provider.GetResourceKey("leftover"), cty.ObjectVal(map[string]cty.Value{
+					"id":              cty.StringVal("leftover"),
+					"value":           cty.StringVal("old"),
+					"interrupt_count": cty.NullVal(cty.Number),
+				}))
+
+				state := states.BuildState(func(s *states.SyncState) {
+					s.SetResourceInstanceCurrent(
+						addrs.Resource{
+							Mode: addrs.ManagedResourceMode,
+							Type: "test_resource",
+							Name: "network",
+						}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance),
+						&states.ResourceInstanceObjectSrc{
+							AttrsJSON: []byte(`{"id":"leftover","value":"old"}`),
+							Status:    states.ObjectReady,
+						},
+						addrs.AbsProviderConfig{
+							Provider: addrs.NewDefaultProvider("test"),
+							Module:   addrs.RootModule,
+						},
+						addrs.NoKey,
+					)
+				})
+
+				manifest, diags := loadTestFixtureManifest(manifestPath)
+				if diags.HasErrors() {
+					t.Fatal(diags.Err())
+				}
+				if err := manifest.Record(tc.leftover, state); err != nil {
+					t.Fatal(err)
+				}
+			}
 
-	code := command.Run(nil)
-	output := done(t)
+			c := &TestCommand{Meta: meta}
+			code := c.Run([]string{"-no-color"})
+			output := done(t)
 
-	printedOutput := false
+			if code != 0 {
+				t.Errorf("expected status code 0 but got %d: %s", code, output.All())
+			}
 
-	if code != 0 {
-		printedOutput = true
-		t.Errorf("expected status code 0 but got %d: %s", code, output.All())
-	}
+			expected := `first.tftest.hcl... pass
+  run "test"... pass
+fixtures.tftest.hcl... pass
+second.tftest.hcl... pass
+  run "test"... pass
 
-	if test.ResourceCount() > 0 {
-		if !printedOutput {
-			printedOutput = true
-			t.Errorf("should have deleted all resources on completion but left %s\n\n%s", test.ResourceString(), output.All())
-		} else {
-			t.Errorf("should have deleted all resources on completion but left %s", test.ResourceString())
-		}
-	}
+Success! 2 passed, 0 failed.
+`
+			if tc.leftover == "removed" {
+				expected = `
+Warning: Leftover test fixture
+
+The fixture "removed" is no longer declared by the test files, but
+.terraform/test-fixtures.json records resources it created during a previous
+execution that did not complete. These resources must be destroyed manually.
+` + expected
+			}
+			if diff := cmp.Diff(expected, output.Stdout()); len(diff) > 0 {
+				t.Errorf("output didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", expected, output.Stdout(), diff)
+			}
 
-	if setup.ResourceCount() > 0 {
-		if !printedOutput {
-			t.Errorf("should have deleted all resources on completion but left %s\n\n%s", setup.ResourceString(), output.All())
-		} else {
-			t.Errorf("should have deleted all resources on completion but left %s", setup.ResourceString())
-		}
+			switch tc.leftover {
+			case "network":
+				if provider.ResourceCount() > 0 {
+					t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+				}
+				if _, err := os.Stat(manifestPath); !os.IsNotExist(err) {
+					t.Errorf("expected the fixture manifest to be removed, but got: %v", err)
+				}
+			case "removed":
+				// The leftover fixture is no longer declared, so its
+				// resources are left for the user to destroy.
+				if provider.ResourceCount() != 1 {
+					t.Errorf("should have only left the leftover resource, but left %v", provider.ResourceString())
+				}
+			default:
+				if provider.ResourceCount() > 0 {
+					t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+				}
+				if _, err := os.Stat(manifestPath); !os.IsNotExist(err) {
+					t.Errorf("expected no fixture manifest, but got: %v", err)
+				}
+			}
+		})
 	}
 }
 
-func TestTest_CatchesErrorsBeforeDestroy(t *testing.T) {
+func TestTest_ForEach(t *testing.T) {
 	td := t.TempDir()
-	testCopyDir(t, testFixturePath(path.Join("test", "invalid_default_state")), td)
+	testCopyDir(t, testFixturePath(path.Join("test", "for_each")), td)
 	t.Chdir(td)
 
 	provider := testing_command.NewProvider(nil)
@@ -550,36 +1420,19 @@ func TestTest_CatchesErrorsBeforeDestroy(t *testing.T) {
 	code := c.Run([]string{"-no-color"})
 	output := done(t)
 
-	if code != 1 {
+	if code != 0 {
 		t.Errorf("expected status code 0 but got %d", code)
 	}
 
-	expectedOut := `main.tftest.hcl... fail
-  run "test"... fail
-
-Failure! 0 passed, 1 failed.
-`
-
-	expectedErr := `
-Error: No value for required variable
-
-  on main.tf line 2:
-   2: variable "input" {
+	expected := `main.tftest.hcl... pass
+  run "values"["first"]... pass
+  run "values"["second"]... pass
+  run "reference"... pass
 
-The root module input variable "input" is not set, and has no default value.
-Use a -var or -var-file command line argument to provide a value for this
-variable.
+Success! 3 passed, 0 failed.
 `
-
-	actualOut := output.Stdout()
-	actualErr := output.Stderr()
-
-	if diff := cmp.Diff(actualOut, expectedOut); len(diff) > 0 {
-		t.Errorf("std out didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", expectedOut, actualOut, diff)
-	}
-
-	if diff := cmp.Diff(actualErr, expectedErr); len(diff) > 0 {
-		t.Errorf("std err didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", expectedErr, actualErr, diff)
+	if diff := cmp.Diff(expected, output.All()); len(diff) > 0 {
+		t.Errorf("output didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", expected, output.All(), diff)
 	}
 
 	if provider.ResourceCount() > 0 {
@@ -587,58 +1440,138 @@ variable.
 	}
 }
 
-func TestTest_Verbose(t *testing.T) {
-	td := t.TempDir()
-	testCopyDir(t, testFixturePath(path.Join("test", "plan_then_apply")), td)
-	t.Chdir(td)
+func TestTest_Snapshot(t *testing.T) {
+	tcs := map[string]struct {
+		args     []string
+		code     int
+		expected string
+		updated  bool
+	}{
+		"match": {
+			args: []string{"-var=value=foo"},
+			code: 0,
+			expected: `main.tftest.hcl... pass
+  run "plan"... pass
+  run "apply"... pass
 
-	provider := testing_command.NewProvider(nil)
-	view, done := testView(t)
+Success! 2 passed, 0 failed.
+`,
+		},
+		"mismatch": {
+			args: []string{"-var=value=bar"},
+			code: 1,
+			expected: `main.tftest.hcl... fail
+  run "plan"... fail
+  run "apply"... fail
 
-	c := &TestCommand{
-		Meta: Meta{
-			testingOverrides: metaOverridesForProvider(provider.Provider),
-			View:             view,
+Failure! 0 passed, 2 failed.
+`,
+		},
+		"update": {
+			args: []string{"-var=value=bar", "-update-snapshots"},
+			code: 0,
+			expected: `main.tftest.hcl... pass
+  run "plan"... pass
+  run "apply"... pass
+
+Success! 2 passed, 0 failed.
+`,
+			updated: true,
 		},
 	}
 
-	code := c.Run([]string{"-verbose", "-no-color"})
-	output := done(t)
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			td := t.TempDir()
+			testCopyDir(t, testFixturePath(path.Join("test", "snapshot")), td)
+			t.Chdir(td)
 
-	if code != 0 {
-		t.Errorf("expected status code 0 but got %d", code)
-	}
+			provider := testing_command.NewProvider(nil)
+			view, done := testView(t)
 
-	expected := `main.tftest.hcl... pass
-  run "validate_test_resource"... pass
+			c := &TestCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(provider.Provider),
+					View:             view,
+				},
+			}
 
-OpenTofu used the selected providers to generate the following execution
-plan. Resource actions are indicated with the following symbols:
-  + create
+			code := c.Run(append([]string{"-no-color"}, tc.args...))
+			output := done(t)
 
-OpenTofu will perform the following actions:
+			if code != tc.code {
+				t.Errorf("expected status code %d but got %d", tc.code, code)
+			}
 
-  # test_resource.foo will be created
-  + resource "test_resource" "foo" {
-      + id    = "constant_value"
-      + value = "bar"
-    }
+			if diff := cmp.Diff(tc.expected, output.Stdout()); len(diff) > 0 {
+				t.Errorf("output didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", tc.expected, output.Stdout(), diff)
+			}
 
-Plan: 1 to add, 0 to change, 0 to destroy.
-  run "validate_test_resource"... pass
-# test_resource.foo:
-resource "test_resource" "foo" {
-    id    = "constant_value"
-    value = "bar"
+			if tc.code != 0 {
+				for _, want := range []string{
+					"Error: Snapshot does not match",
+					`-     "value": "foo"`,
+					`+     "value": "bar"`,
+				} {
+					if !strings.Contains(output.Stderr(), want) {
+						t.Errorf("expected errors to contain %q, but got:\n%s", want, output.Stderr())
+					}
+				}
+			}
+
+			snapshot, err := os.ReadFile(path.Join("snapshots", "main", "apply.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			value := "foo"
+			if tc.updated {
+				value = "bar"
+			}
+			expected := fmt.Sprintf("{\n  \"outputs\": {\n    \"value\": %q\n  }\n}\n", value)
+			if diff := cmp.Diff(expected, string(snapshot)); len(diff) > 0 {
+				t.Errorf("wrong snapshot:\n%s", diff)
+			}
+
+			if provider.ResourceCount() > 0 {
+				t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+			}
+		})
+	}
 }
 
-Success! 2 passed, 0 failed.
-`
+func TestTest_SnapshotForEach(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath(path.Join("test", "snapshot_for_each")), td)
+	t.Chdir(td)
 
-	actual := output.All()
+	provider := testing_command.NewProvider(nil)
 
-	if diff := cmp.Diff(actual, expected); len(diff) > 0 {
-		t.Errorf("output didn't match expected:\nexpected:\n%s\nactual:\n%s\ndiff:\n%s", expected, actual, diff)
+	// Each instance must write its own snapshot, and then match it.
+	for _, args := range [][]string{{"-update-snapshots"}, nil} {
+		view, done := testView(t)
+		c := &TestCommand{
+			Meta: Meta{
+				testingOverrides: metaOverridesForProvider(provider.Provider),
+				View:             view,
+			},
+		}
+
+		code := c.Run(append([]string{"-no-color"}, args...))
+		output := done(t)
+		if code != 0 {
+			t.Fatalf("expected status code 0 but got %d with args %q:\n%s%s", code, args, output.Stdout(), output.Stderr())
+		}
+	}
+
+	for key, value := range map[string]string{"first": "one", "second": "two"} {
+		snapshot, err := os.ReadFile(path.Join("golden", "values", key+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := fmt.Sprintf("{\n  \"outputs\": {\n    \"value\": %q\n  }\n}\n", value)
+		if diff := cmp.Diff(expected, string(snapshot)); len(diff) > 0 {
+			t.Errorf("wrong snapshot for %q:\n%s", key, diff)
+		}
 	}
 
 	if provider.ResourceCount() > 0 {
@@ -646,6 +1579,70 @@ Success! 2 passed, 0 failed.
 	}
 }
 
+func TestTest_JUnitXML(t *testing.T) {
+	tcs := map[string]struct {
+		args     []string
+		code     int
+		contains []string
+	}{
+		"simple_fail": {
+			code: 1,
+			contains: []string{
+				`<testsuites tests="1" failures="1" errors="0" skipped="0"`,
+				`<testsuite name="main.tftest.hcl" tests="1" failures="1" errors="0" skipped="0"`,
+				`<testcase name="validate_test_resource" classname="main.tftest.hcl"`,
+				`<failure message="Test assertion failed">`,
+				`invalid value`,
+			},
+		},
+		"plan_then_apply": {
+			args: []string{"-verbose"},
+			code: 0,
+			contains: []string{
+				`<testsuites tests="2" failures="0" errors="0" skipped="0"`,
+				`<system-out><![CDATA[`,
+				`Plan: 1 to add, 0 to change, 0 to destroy.`,
+				`resource "test_resource" "foo" {`,
+			},
+		},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			td := t.TempDir()
+			testCopyDir(t, testFixturePath(path.Join("test", name)), td)
+			t.Chdir(td)
+
+			provider := testing_command.NewProvider(nil)
+			view, done := testView(t)
+
+			c := &TestCommand{
+				Meta: Meta{
+					testingOverrides: metaOverridesForProvider(provider.Provider),
+					View:             view,
+				},
+			}
+
+			code := c.Run(append(tc.args, "-no-color", "-junit-xml=results.xml"))
+			output := done(t)
+
+			if code != tc.code {
+				t.Errorf("expected status code %d but got %d\n\n%s", tc.code, code, output.All())
+			}
+
+			raw, err := os.ReadFile("results.xml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := string(raw)
+			for _, want := range tc.contains {
+				if !strings.Contains(report, want) {
+					t.Errorf("report is missing %q\n\n%s", want, report)
+				}
+			}
+		})
+	}
+}
+
 func TestTest_ValidatesBeforeExecution(t *testing.T) {
 	tcs := map[string]struct {
 		expectedOut string
@@ -1467,6 +2464,67 @@ digits, underscores, and dashes.
 	}
 }
 
+// TestTest_MockProviderGeneratedValues checks that mock defaults can refer to
+// the planned values of a resource, and generate values with the pattern
+// function.
+func TestTest_MockProviderGeneratedValues(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath("test/mock_generated"), td)
+	t.Chdir(td)
+
+	provider := testing_command.NewProvider(nil)
+	view, done := testView(t)
+
+	c := &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+		},
+	}
+
+	code := c.Run(nil)
+	output := done(t)
+	if code != 0 {
+		t.Fatalf("expected status code 0 but got %d: %s", code, output.All())
+	}
+
+	if provider.ResourceCount() > 0 {
+		t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+	}
+}
+
+// TestTest_MockProviderGeneratedValuesImpure checks that the defaults of a
+// mock resource can't call impure functions, which would make the generated
+// values differ between runs.
+func TestTest_MockProviderGeneratedValuesImpure(t *testing.T) {
+	td := t.TempDir()
+	testCopyDir(t, testFixturePath("test/mock_generated_impure"), td)
+	t.Chdir(td)
+
+	provider := testing_command.NewProvider(nil)
+	view, done := testView(t)
+
+	c := &TestCommand{
+		Meta: Meta{
+			testingOverrides: metaOverridesForProvider(provider.Provider),
+			View:             view,
+		},
+	}
+
+	code := c.Run([]string{"-no-color"})
+	output := done(t)
+	if code != 1 {
+		t.Fatalf("expected status code 1 but got %d: %s", code, output.All())
+	}
+	if want := "Error: Impure function in mock defaults"; !strings.Contains(output.Stderr(), want) {
+		t.Errorf("expected errors to contain %q, but got:\n%s", want, output.Stderr())
+	}
+
+	if provider.ResourceCount() > 0 {
+		t.Errorf("should have deleted all resources on completion but left %v", provider.ResourceString())
+	}
+}
+
 // TestTest_MockProviderValidation checks if tofu test runs proper validation for
 // mock_provider. Even if provider schema has required fields, tofu test should
 // ignore it completely, because the provider is mocked.
@@ -0,0 +1,17 @@
+terraform {
+  backend "local" {
+    path = "new.tfstate"
+  }
+
+  encryption {
+    key_provider "pbkdf2" "new" {
+      passphrase = "new-passphrase-for-testing"
+    }
+    method "aes_gcm" "new" {
+      keys = key_provider.pbkdf2.new
+    }
+    state {
+      method = method.aes_gcm.new
+    }
+  }
+}
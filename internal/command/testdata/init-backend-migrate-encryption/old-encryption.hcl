@@ -0,0 +1,9 @@
+key_provider "pbkdf2" "old" {
+  passphrase = "old-passphrase-for-testing"
+}
+method "aes_gcm" "old" {
+  keys = key_provider.pbkdf2.old
+}
+state {
+  method = method.aes_gcm.old
+}
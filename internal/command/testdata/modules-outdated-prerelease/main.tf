@@ -0,0 +1,8 @@
+module "a" {
+  source = "example.com/test-prereleases/name/provider"
+}
+
+module "b" {
+  source  = "example.com/test-prereleases/name/provider"
+  version = "1.3.0-beta1"
+}
@@ -0,0 +1,4 @@
+module "a" {
+  source  = "example.com/test-versions/name/provider"
+  version = "~> 1.2.0"
+}
@@ -0,0 +1,7 @@
+# This file is maintained automatically by "tofu init".
+# Manual edits may be lost in future updates.
+
+provider "registry.opentofu.org/hashicorp/foo" {
+  version     = "1.0.0"
+  constraints = "~> 1.0"
+}
@@ -0,0 +1,11 @@
+terraform {
+  required_providers {
+    foo = {
+      source  = "hashicorp/foo"
+      version = "~> 1.0"
+    }
+    bar = {
+      source = "hashicorp/bar"
+    }
+  }
+}
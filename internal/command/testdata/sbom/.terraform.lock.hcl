@@ -0,0 +1,28 @@
+# This file is maintained automatically by "tofu init".
+# Manual edits may be lost in future updates.
+
+provider "registry.opentofu.org/hashicorp/aws" {
+  version = "5.0.0"
+  hashes = [
+    "h1:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa=",
+    "zh:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+  ]
+}
+
+provider "registry.opentofu.org/hashicorp/null" {
+  version = "3.0.0"
+}
+
+module "network" {
+  source  = "registry.opentofu.org/acme/network/aws"
+  version = "1.2.0"
+  package = "https://example.com/network-1.2.0.tar.gz"
+  hashes = [
+    "h1:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb=",
+  ]
+}
+
+module "vpc" {
+  source  = "git::https://example.com/vpc.git?ref=main"
+  package = "git::https://example.com/vpc.git?ref=0123456789abcdef0123456789abcdef01234567"
+}
@@ -0,0 +1,8 @@
+module "network" {
+  source  = "acme/network/aws"
+  version = "1.2.0"
+}
+
+module "vpc" {
+  source = "git::https://example.com/vpc.git?ref=main"
+}
@@ -0,0 +1,9 @@
+terraform {
+    backend "local" {
+        path = "local-state.tfstate"
+
+        mirror "local" {
+            path = "mirror.tfstate"
+        }
+    }
+}
@@ -0,0 +1,17 @@
+variables {
+  interrupts = 0
+}
+
+run "primary" {
+
+}
+
+run "secondary" {
+  variables {
+    interrupts = 2
+  }
+}
+
+run "tertiary" {
+
+}
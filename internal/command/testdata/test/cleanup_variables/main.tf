@@ -0,0 +1,29 @@
+
+variable "interrupts" {
+  type = number
+}
+
+variable "prefix" {
+  type = string
+}
+
+resource "test_resource" "primary" {
+  value = "${var.prefix}-primary"
+}
+
+resource "test_resource" "secondary" {
+  value = "${var.prefix}-secondary"
+  interrupt_count = var.interrupts
+
+  depends_on = [
+    test_resource.primary
+  ]
+}
+
+resource "test_resource" "tertiary" {
+  value = "${var.prefix}-tertiary"
+
+  depends_on = [
+    test_resource.secondary
+  ]
+}
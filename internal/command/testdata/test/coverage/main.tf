@@ -0,0 +1,33 @@
+variable "value" {
+  type = string
+
+  validation {
+    condition     = length(var.value) > 0
+    error_message = "The value must not be empty."
+  }
+}
+
+variable "create_bar" {
+  type    = bool
+  default = false
+}
+
+resource "test_resource" "foo" {
+  value = var.value
+
+  lifecycle {
+    postcondition {
+      condition     = self.value == var.value
+      error_message = "The value was not set."
+    }
+  }
+}
+
+resource "test_resource" "bar" {
+  count = var.create_bar ? 1 : 0
+  value = var.value
+}
+
+output "value" {
+  value = test_resource.foo.value
+}
@@ -0,0 +1,5 @@
+variables {
+  value = "foo"
+}
+
+run "apply" {}
@@ -0,0 +1,18 @@
+variable "input" {
+  type    = number
+  default = 1
+}
+
+variable "id" {
+  type    = string
+  default = null
+}
+
+resource "test_resource" "foo" {
+  value = tostring(var.input)
+}
+
+data "test_data_source" "lookup" {
+  count = var.id == null ? 0 : 1
+  id    = var.id
+}
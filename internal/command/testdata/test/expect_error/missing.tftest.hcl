@@ -0,0 +1,5 @@
+run "no_error" {
+  expect_error {
+    summary = "Invalid value for variable"
+  }
+}
@@ -0,0 +1,32 @@
+run "wrong_type" {
+  command = plan
+
+  variables {
+    input = "not a number"
+  }
+
+  expect_error {
+    summary  = "Invalid value for (input )?variable"
+    detail   = "a number is required"
+    filename = "main.tf"
+    line     = 1
+  }
+}
+
+run "provider_error" {
+  variables {
+    id = "missing"
+  }
+
+  expect_error {
+    summary = "^not found$"
+    detail  = "missing does not exist"
+  }
+}
+
+run "no_error" {
+  assert {
+    condition     = test_resource.foo.value == "1"
+    error_message = "The value should be 1."
+  }
+}
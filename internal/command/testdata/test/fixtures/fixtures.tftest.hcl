@@ -0,0 +1,9 @@
+fixture "network" {
+  module {
+    source = "./setup"
+  }
+
+  variables {
+    name = "shared"
+  }
+}
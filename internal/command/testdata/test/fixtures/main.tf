@@ -0,0 +1,7 @@
+variable "network_id" {
+  type = string
+}
+
+resource "test_resource" "instance" {
+  value = var.network_id
+}
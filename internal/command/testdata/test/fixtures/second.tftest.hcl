@@ -0,0 +1,10 @@
+variables {
+  network_id = "${fixture.network.id}-${fixture.network.name}"
+}
+
+run "test" {
+  assert {
+    condition     = test_resource.instance.value == "network-shared"
+    error_message = "bad network id"
+  }
+}
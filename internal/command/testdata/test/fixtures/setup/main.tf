@@ -0,0 +1,16 @@
+variable "name" {
+  type = string
+}
+
+resource "test_resource" "network" {
+  id    = "network"
+  value = var.name
+}
+
+output "id" {
+  value = test_resource.network.id
+}
+
+output "name" {
+  value = test_resource.network.value
+}
@@ -0,0 +1,28 @@
+run "values" {
+  for_each = {
+    first  = "one"
+    second = "two"
+  }
+
+  parallel = true
+
+  variables {
+    input = each.value
+  }
+
+  assert {
+    condition     = test_resource.foo.value == each.value
+    error_message = "Expected ${each.value} for ${each.key}."
+  }
+}
+
+run "reference" {
+  variables {
+    input = "${run.values["second"].value}-again"
+  }
+
+  assert {
+    condition     = output.value == "two-again"
+    error_message = "Expected the output of the second run."
+  }
+}
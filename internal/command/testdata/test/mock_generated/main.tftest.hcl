@@ -0,0 +1,27 @@
+mock_provider "test" {
+  seed = 42
+
+  mock_resource "test_resource" {
+    defaults = {
+      id = "${self.value}-${pattern("{hex:8}")}"
+    }
+  }
+
+  mock_data "test_data_source" {
+    defaults = {
+      value = "arn:aws:iam::${pattern("{digits:12}")}:role/${self.id}"
+    }
+  }
+}
+
+run "test" {
+  assert {
+    condition     = can(regex("^foo-[0-9a-f]{8}$", test_resource.primary.id))
+    error_message = "Unexpected generated id"
+  }
+
+  assert {
+    condition     = can(regex("^arn:aws:iam::[0-9]{12}:role/bar$", data.test_data_source.lookup.value))
+    error_message = "Unexpected generated value"
+  }
+}
@@ -0,0 +1,9 @@
+mock_provider "test" {
+  mock_resource "test_resource" {
+    defaults = {
+      id = "${self.value}-${uuid()}"
+    }
+  }
+}
+
+run "test" {}
@@ -0,0 +1,56 @@
+variables {
+  id    = "main"
+  value = "main"
+}
+
+run "setup" {}
+
+run "first" {
+  parallel = true
+
+  variables {
+    id    = "first"
+    value = "${run.setup.value}-first"
+  }
+
+  assert {
+    condition     = test_resource.foo.value == "main-first"
+    error_message = "invalid value"
+  }
+}
+
+run "second" {
+  parallel = true
+
+  variables {
+    id    = "second"
+    value = "second"
+  }
+
+  assert {
+    condition     = test_resource.foo.value == "second"
+    error_message = "invalid value"
+  }
+}
+
+run "main_state_unchanged" {
+  command = plan
+
+  assert {
+    condition     = test_resource.foo.id == "main" && test_resource.foo.value == "main"
+    error_message = "parallel run blocks modified the main state"
+  }
+}
+
+run "parallel_outputs" {
+  command = plan
+
+  variables {
+    value = run.first.value
+  }
+
+  assert {
+    condition     = test_resource.foo.value == "main-first"
+    error_message = "invalid value"
+  }
+}
@@ -0,0 +1,50 @@
+variables {
+  value = "a"
+}
+
+run "main" {
+  parallel = true
+
+  variables {
+    id = "a-main"
+  }
+
+  assert {
+    condition     = test_resource.foo.id == "a-main"
+    error_message = "invalid id"
+  }
+}
+
+run "other" {
+  parallel = true
+
+  module {
+    source = "./other"
+  }
+
+  variables {
+    id = "a-other"
+  }
+
+  assert {
+    condition     = test_resource.foo.id == "a-other"
+    error_message = "invalid id"
+  }
+}
+
+run "other_again" {
+  parallel = true
+
+  module {
+    source = "./other"
+  }
+
+  variables {
+    id = "a-other-again"
+  }
+
+  assert {
+    condition     = test_resource.foo.id == "a-other-again"
+    error_message = "invalid id"
+  }
+}
@@ -0,0 +1,50 @@
+variables {
+  value = "b"
+}
+
+run "main" {
+  parallel = true
+
+  variables {
+    id = "b-main"
+  }
+
+  assert {
+    condition     = test_resource.foo.id == "b-main"
+    error_message = "invalid id"
+  }
+}
+
+run "other" {
+  parallel = true
+
+  module {
+    source = "./other"
+  }
+
+  variables {
+    id = "b-other"
+  }
+
+  assert {
+    condition     = test_resource.foo.id == "b-other"
+    error_message = "invalid id"
+  }
+}
+
+run "other_again" {
+  parallel = true
+
+  module {
+    source = "./other"
+  }
+
+  variables {
+    id = "b-other-again"
+  }
+
+  assert {
+    condition     = test_resource.foo.id == "b-other-again"
+    error_message = "invalid id"
+  }
+}
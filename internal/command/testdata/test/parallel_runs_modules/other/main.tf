@@ -0,0 +1,16 @@
+variable "id" {
+  type = string
+}
+
+variable "value" {
+  type = string
+}
+
+resource "test_resource" "foo" {
+  id    = var.id
+  value = var.value
+}
+
+output "value" {
+  value = test_resource.foo.value
+}
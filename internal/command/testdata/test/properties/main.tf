@@ -0,0 +1,41 @@
+variable "port" {
+  type     = number
+  nullable = false
+
+  validation {
+    condition     = var.port >= 1 && var.port <= 65535 && floor(var.port) == var.port
+    error_message = "The port must be a whole number between 1 and 65535."
+  }
+}
+
+variable "name" {
+  type    = string
+  default = "web"
+
+  validation {
+    condition     = length(var.name) > 0
+    error_message = "The name must not be empty."
+  }
+}
+
+variable "environment" {
+  type     = string
+  nullable = false
+  default  = "dev"
+
+  validation {
+    condition     = length(var.environment) > 0
+    error_message = "The environment must not be empty."
+  }
+}
+
+resource "test_resource" "instance" {
+  value = "${var.environment}/${var.name}:${var.port}"
+
+  lifecycle {
+    precondition {
+      condition     = trimspace(var.environment) == var.environment
+      error_message = "The environment must not have surrounding whitespace."
+    }
+  }
+}
@@ -0,0 +1,7 @@
+property "name" {
+  variable = var.name
+
+  variables {
+    port = 80
+  }
+}
@@ -0,0 +1,8 @@
+property "environment" {
+  variable = var.environment
+  seed     = 3
+
+  variables {
+    port = 80
+  }
+}
@@ -0,0 +1,20 @@
+variables {
+  port = 8080
+}
+
+run "apply" {
+  assert {
+    condition     = test_resource.instance.value == "dev/web:8080"
+    error_message = "bad value"
+  }
+}
+
+property "port" {
+  variable   = var.port
+  iterations = 200
+
+  assert {
+    condition     = validation.valid == (var.port >= 1 && var.port <= 65535 && floor(var.port) == var.port)
+    error_message = "Whole numbers between 1 and 65535 must be accepted, and nothing else."
+  }
+}
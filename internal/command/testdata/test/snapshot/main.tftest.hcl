@@ -0,0 +1,14 @@
+run "plan" {
+  command = plan
+
+  snapshot {
+    outputs = [output.value, output.id]
+  }
+}
+
+run "apply" {
+  snapshot {
+    plan    = false
+    outputs = [output.value]
+  }
+}
@@ -0,0 +1,15 @@
+variable "value" {
+  type = string
+}
+
+resource "test_resource" "foo" {
+  value = var.value
+}
+
+output "value" {
+  value = test_resource.foo.value
+}
+
+output "id" {
+  value = test_resource.foo.id
+}
@@ -0,0 +1,16 @@
+run "values" {
+  for_each = {
+    first  = "one"
+    second = "two"
+  }
+
+  variables {
+    value = each.value
+  }
+
+  snapshot {
+    plan    = false
+    outputs = [output.value]
+    path    = "golden/values.json"
+  }
+}
@@ -19,6 +19,9 @@ const (
 	MessageChangeSummary MessageType = "change_summary"
 	MessageOutputs       MessageType = "outputs"
 
+	// State messages
+	MessageStateMigration MessageType = "state_migration"
+
 	// Hook-driven messages
 	MessageApplyStart              MessageType = "apply_start"
 	MessageApplyProgress           MessageType = "apply_progress"
@@ -37,9 +40,11 @@ const (
 	MessageTestAbstract  MessageType = "test_abstract"
 	MessageTestFile      MessageType = "test_file"
 	MessageTestRun       MessageType = "test_run"
+	MessageTestProperty  MessageType = "test_property"
 	MessageTestPlan      MessageType = "test_plan"
 	MessageTestState     MessageType = "test_state"
 	MessageTestSummary   MessageType = "test_summary"
+	MessageTestCoverage  MessageType = "test_coverage"
 	MessageTestCleanup   MessageType = "test_cleanup"
 	MessageTestInterrupt MessageType = "test_interrupt"
 )
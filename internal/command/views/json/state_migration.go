@@ -0,0 +1,53 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package json
+
+import (
+	"fmt"
+)
+
+type StateMigrationStatus string
+
+const (
+	StateMigrationMigrated  StateMigrationStatus = "migrated"
+	StateMigrationUnchanged StateMigrationStatus = "unchanged"
+	StateMigrationEmpty     StateMigrationStatus = "empty"
+	StateMigrationDeclined  StateMigrationStatus = "declined"
+	StateMigrationFailed    StateMigrationStatus = "failed"
+)
+
+// StateMigrationSummary describes the outcome of migrating the state of each
+// workspace from one backend to another.
+type StateMigrationSummary struct {
+	SourceType      string                    `json:"source_type"`
+	DestinationType string                    `json:"destination_type"`
+	Workspaces      []StateMigrationWorkspace `json:"workspaces"`
+}
+
+type StateMigrationWorkspace struct {
+	Source      string               `json:"source"`
+	Destination string               `json:"destination"`
+	Status      StateMigrationStatus `json:"status"`
+}
+
+// Count returns the number of workspaces with the given status.
+func (s *StateMigrationSummary) Count(status StateMigrationStatus) int {
+	count := 0
+	for _, ws := range s.Workspaces {
+		if ws.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *StateMigrationSummary) String() string {
+	return fmt.Sprintf(
+		"State migration from %q to %q: %d migrated, %d skipped, %d failed.",
+		s.SourceType, s.DestinationType,
+		s.Count(StateMigrationMigrated),
+		len(s.Workspaces)-s.Count(StateMigrationMigrated)-s.Count(StateMigrationFailed),
+		s.Count(StateMigrationFailed),
+	)
+}
@@ -26,6 +26,13 @@ type TestRunStatus struct {
 	Status TestStatus `json:"status"`
 }
 
+type TestPropertyStatus struct {
+	Path       string     `json:"path"`
+	Property   string     `json:"property"`
+	Status     TestStatus `json:"status"`
+	Iterations int        `json:"iterations"`
+}
+
 type TestSuiteSummary struct {
 	Status  TestStatus `json:"status"`
 	Passed  int        `json:"passed"`
@@ -34,6 +41,18 @@ type TestSuiteSummary struct {
 	Skipped int        `json:"skipped"`
 }
 
+// TestCoverage summarizes how much of each module in the configuration under
+// test was exercised by the tests.
+type TestCoverage struct {
+	Modules []TestModuleCoverage `json:"modules"`
+}
+
+type TestModuleCoverage struct {
+	Module  string `json:"module"`
+	Covered int    `json:"covered"`
+	Total   int    `json:"total"`
+}
+
 type TestFileCleanup struct {
 	FailedResources []TestFailedResource `json:"failed_resources"`
 }
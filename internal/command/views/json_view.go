@@ -20,7 +20,7 @@ import (
 // This version describes the schema of JSON UI messages. This version must be
 // updated after making any changes to this view, the jsonHook, or any of the
 // command/views/json package.
-const JSON_UI_VERSION = "1.2"
+const JSON_UI_VERSION = "1.3"
 
 func NewJSONView(view *View) *JSONView {
 	log := hclog.New(&hclog.LoggerOptions{
@@ -131,6 +131,14 @@ func (v *JSONView) Outputs(outputs jsonentities.Outputs) {
 	)
 }
 
+func (v *JSONView) StateMigration(s *json.StateMigrationSummary) {
+	v.log.Info(
+		s.String(),
+		"type", json.MessageStateMigration,
+		"migration", s,
+	)
+}
+
 // Output is designed for supporting command.WrappedUi
 func (v *JSONView) Output(message string) {
 	v.log.Info(message, "type", "output")
@@ -8,9 +8,11 @@ package views
 import (
 	"bytes"
 	"fmt"
+	"sort"
 
 	"github.com/mitchellh/colorstring"
 
+	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/command/arguments"
 	"github.com/opentofu/opentofu/internal/command/format"
 	"github.com/opentofu/opentofu/internal/command/jsonformat"
@@ -48,6 +50,9 @@ type Test interface {
 	// Run prints out the summary for a single test run block.
 	Run(run *moduletest.Run, file *moduletest.File)
 
+	// Property prints out the summary for a single property block.
+	Property(property *moduletest.Property, file *moduletest.File)
+
 	// DestroySummary prints out the summary of the destroy step of each test
 	// file. If everything goes well, this should be empty.
 	DestroySummary(diags tfdiags.Diagnostics, run *moduletest.Run, file *moduletest.File, state *states.State)
@@ -72,6 +77,11 @@ type Test interface {
 	// operation alongside the current state as the state will be missing newly
 	// created resources that also need to be handled manually.
 	FatalInterruptSummary(run *moduletest.Run, file *moduletest.File, states map[*moduletest.Run]*states.State, created []*plans.ResourceInstanceChangeSrc)
+
+	// CleanupSummary prints out the test files whose leftover resources were
+	// destroyed by the cleanup mode, with a Pass status if all of them were
+	// destroyed and an Error status otherwise.
+	CleanupSummary(suite *moduletest.Suite)
 }
 
 func NewTest(vt arguments.ViewType, view *View) Test {
@@ -108,6 +118,9 @@ func (t *TestHuman) Conclusion(suite *moduletest.Suite) {
 			count := counts[run.Status]
 			counts[run.Status] = count + 1
 		}
+		for _, property := range file.Properties {
+			counts[property.Status]++
+		}
 	}
 
 	if suite.Status <= moduletest.Skip {
@@ -133,6 +146,14 @@ func (t *TestHuman) Conclusion(suite *moduletest.Suite) {
 	} else {
 		t.view.streams.Println(".")
 	}
+
+	if suite.Coverage != nil {
+		t.view.streams.Println()
+		t.view.streams.Println("Coverage:")
+		for _, summary := range suite.Coverage.Summary() {
+			t.view.streams.Printf("  %s: %s\n", coverageModuleName(summary.Module), coverageMessage(summary.Covered, summary.Total))
+		}
+	}
 }
 
 func (t *TestHuman) File(file *moduletest.File) {
@@ -140,79 +161,103 @@ func (t *TestHuman) File(file *moduletest.File) {
 	t.Diagnostics(nil, file, file.Diagnostics)
 }
 
+// quotedTestRunName returns the name of the given run block for display. Run
+// blocks expanded from a for_each argument are shown with their key after the
+// quoted name of the original run block, such as "name"["key"].
+func quotedTestRunName(run *moduletest.Run) string {
+	if run.Config != nil && run.Config.Each != nil {
+		return fmt.Sprintf("%q[%q]", run.Config.Each.Name, run.Config.Each.Key)
+	}
+	return fmt.Sprintf("%q", run.Name)
+}
+
 func (t *TestHuman) Run(run *moduletest.Run, file *moduletest.File) {
-	t.view.streams.Printf("  run %q... %s\n", run.Name, colorizeTestStatus(run.Status, t.view.colorize))
+	t.view.streams.Printf("  run %s... %s\n", quotedTestRunName(run), colorizeTestStatus(run.Status, t.view.colorize))
 
 	if run.Verbose != nil {
 		// We're going to be more verbose about what we print, here's the plan
 		// or the state depending on the type of run we did.
-
-		schemas := &tofu.Schemas{
-			Providers:    run.Verbose.Providers,
-			Provisioners: run.Verbose.Provisioners,
-		}
-
 		renderer := jsonformat.Renderer{
 			Streams:             t.view.streams,
 			Colorize:            t.view.colorize,
 			RunningInAutomation: t.view.runningInAutomation,
 		}
-
-		if run.Config.Command == configs.ApplyTestCommand {
-			// Then we'll print the state.
-			root, outputs, err := jsonstate.MarshalForRenderer(statefile.New(run.Verbose.State, file.Name, uint64(run.Index)), schemas)
-			if err != nil {
-				run.Diagnostics = run.Diagnostics.Append(tfdiags.Sourceless(
-					tfdiags.Warning,
-					"Failed to render test state",
-					fmt.Sprintf("OpenTofu could not marshal the state for display: %v", err)))
-			} else {
-				state := jsonformat.State{
-					StateFormatVersion:    jsonstate.FormatVersion,
-					ProviderFormatVersion: jsonprovider.FormatVersion,
-					RootModule:            root,
-					RootModuleOutputs:     outputs,
-					ProviderSchemas:       jsonprovider.MarshalForRenderer(schemas),
-				}
-
-				renderer.RenderHumanState(state)
-			}
-		} else {
-			// We'll print the plan.
-			outputs, changed, drift, attrs, err := jsonplan.MarshalForRenderer(run.Verbose.Plan, schemas)
-			if err != nil {
-				run.Diagnostics = run.Diagnostics.Append(tfdiags.Sourceless(
-					tfdiags.Warning,
-					"Failed to render test plan",
-					fmt.Sprintf("OpenTofu could not marshal the plan for display: %v", err)))
-			} else {
-				plan := jsonformat.Plan{
-					PlanFormatVersion:     jsonplan.FormatVersion,
-					ProviderFormatVersion: jsonprovider.FormatVersion,
-					OutputChanges:         outputs,
-					ResourceChanges:       changed,
-					ResourceDrift:         drift,
-					ProviderSchemas:       jsonprovider.MarshalForRenderer(schemas),
-					RelevantAttributes:    attrs,
-				}
-
-				var opts []plans.Quality
-				if !run.Verbose.Plan.CanApply() {
-					opts = append(opts, plans.NoChanges)
-				}
-				if run.Verbose.Plan.Errored {
-					opts = append(opts, plans.Errored)
-				}
-
-				renderer.RenderHumanPlan(plan, run.Verbose.Plan.UIMode, opts...)
-			}
-		}
+		run.Diagnostics = run.Diagnostics.Append(renderTestVerbose(renderer, run, file))
 	}
 
 	// Finally we'll print out a summary of the diagnostics from the run.
 	t.Diagnostics(run, file, run.Diagnostics)
 }
 
+func (t *TestHuman) Property(property *moduletest.Property, file *moduletest.File) {
+	t.view.streams.Printf("  property %q... %s\n", property.Name, colorizeTestStatus(property.Status, t.view.colorize))
+	t.Diagnostics(nil, file, property.Diagnostics)
+}
+
+// renderTestVerbose renders the state or plan recorded for a run executed
+// with the -verbose flag in human-readable form, returning warnings if it
+// could not be rendered.
+func renderTestVerbose(renderer jsonformat.Renderer, run *moduletest.Run, file *moduletest.File) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	schemas := &tofu.Schemas{
+		Providers:    run.Verbose.Providers,
+		Provisioners: run.Verbose.Provisioners,
+	}
+
+	if run.Config.Command == configs.ApplyTestCommand {
+		// Then we'll print the state.
+		root, outputs, err := jsonstate.MarshalForRenderer(statefile.New(run.Verbose.State, file.Name, uint64(run.Index)), schemas)
+		if err != nil {
+			return diags.Append(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Failed to render test state",
+				fmt.Sprintf("OpenTofu could not marshal the state for display: %v", err)))
+		}
+
+		state := jsonformat.State{
+			StateFormatVersion:    jsonstate.FormatVersion,
+			ProviderFormatVersion: jsonprovider.FormatVersion,
+			RootModule:            root,
+			RootModuleOutputs:     outputs,
+			ProviderSchemas:       jsonprovider.MarshalForRenderer(schemas),
+		}
+
+		renderer.RenderHumanState(state)
+		return diags
+	}
+
+	// We'll print the plan.
+	outputs, changed, drift, attrs, err := jsonplan.MarshalForRenderer(run.Verbose.Plan, schemas)
+	if err != nil {
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Failed to render test plan",
+			fmt.Sprintf("OpenTofu could not marshal the plan for display: %v", err)))
+	}
+
+	plan := jsonformat.Plan{
+		PlanFormatVersion:     jsonplan.FormatVersion,
+		ProviderFormatVersion: jsonprovider.FormatVersion,
+		OutputChanges:         outputs,
+		ResourceChanges:       changed,
+		ResourceDrift:         drift,
+		ProviderSchemas:       jsonprovider.MarshalForRenderer(schemas),
+		RelevantAttributes:    attrs,
+	}
+
+	var opts []plans.Quality
+	if !run.Verbose.Plan.CanApply() {
+		opts = append(opts, plans.NoChanges)
+	}
+	if run.Verbose.Plan.Errored {
+		opts = append(opts, plans.Errored)
+	}
+
+	renderer.RenderHumanPlan(plan, run.Verbose.Plan.UIMode, opts...)
+	return diags
+}
+
 func (t *TestHuman) DestroySummary(diags tfdiags.Diagnostics, run *moduletest.Run, file *moduletest.File, state *states.State) {
 	identifier := file.Name
 	if run != nil {
@@ -304,6 +349,29 @@ func (t *TestHuman) FatalInterruptSummary(run *moduletest.Run, file *moduletest.
 	}
 }
 
+func (t *TestHuman) CleanupSummary(suite *moduletest.Suite) {
+	if len(suite.Files) == 0 {
+		t.view.streams.Println("No leftover test resources were found.")
+		return
+	}
+
+	var names []string
+	for name := range suite.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t.view.streams.Printf("%s... %s\n", name, cleanupStatus(suite.Files[name].Status, t.view.colorize))
+	}
+
+	t.view.streams.Println()
+	if suite.Status == moduletest.Pass {
+		t.view.streams.Println(t.view.colorize.Color("[green]Cleanup complete![reset] All leftover test resources were destroyed."))
+	} else {
+		t.view.streams.Println(t.view.colorize.Color("[red]Cleanup failed![reset] Some leftover test resources could not be destroyed."))
+	}
+}
+
 type TestJSON struct {
 	view *JSONView
 }
@@ -346,8 +414,16 @@ func (t *TestJSON) Conclusion(suite *moduletest.Suite) {
 		Status: json.ToTestStatus(suite.Status),
 	}
 	for _, file := range suite.Files {
+		statuses := make([]moduletest.Status, 0, len(file.Runs)+len(file.Properties))
 		for _, run := range file.Runs {
-			switch run.Status {
+			statuses = append(statuses, run.Status)
+		}
+		for _, property := range file.Properties {
+			statuses = append(statuses, property.Status)
+		}
+
+		for _, status := range statuses {
+			switch status {
 			case moduletest.Skip:
 				summary.Skipped++
 			case moduletest.Pass:
@@ -388,6 +464,25 @@ func (t *TestJSON) Conclusion(suite *moduletest.Suite) {
 		message.String(),
 		"type", json.MessageTestSummary,
 		json.MessageTestSummary, summary)
+
+	if suite.Coverage != nil {
+		var coverage json.TestCoverage
+		var covered, total int
+		for _, summary := range suite.Coverage.Summary() {
+			coverage.Modules = append(coverage.Modules, json.TestModuleCoverage{
+				Module:  summary.Module.String(),
+				Covered: summary.Covered,
+				Total:   summary.Total,
+			})
+			covered += summary.Covered
+			total += summary.Total
+		}
+
+		t.view.log.Info(
+			fmt.Sprintf("Coverage: %s", coverageMessage(covered, total)),
+			"type", json.MessageTestCoverage,
+			json.MessageTestCoverage, coverage)
+	}
 }
 
 func (t *TestJSON) File(file *moduletest.File) {
@@ -401,7 +496,7 @@ func (t *TestJSON) File(file *moduletest.File) {
 
 func (t *TestJSON) Run(run *moduletest.Run, file *moduletest.File) {
 	t.view.log.Info(
-		fmt.Sprintf("  %q... %s", run.Name, testStatus(run.Status)),
+		fmt.Sprintf("  %s... %s", quotedTestRunName(run), testStatus(run.Status)),
 		"type", json.MessageTestRun,
 		json.MessageTestRun, json.TestRunStatus{Path: file.Name, Run: run.Name, Status: json.ToTestStatus(run.Status)},
 		"@testfile", file.Name,
@@ -450,6 +545,15 @@ func (t *TestJSON) Run(run *moduletest.Run, file *moduletest.File) {
 	t.Diagnostics(run, file, run.Diagnostics)
 }
 
+func (t *TestJSON) Property(property *moduletest.Property, file *moduletest.File) {
+	t.view.log.Info(
+		fmt.Sprintf("  property %q... %s", property.Name, testStatus(property.Status)),
+		"type", json.MessageTestProperty,
+		json.MessageTestProperty, json.TestPropertyStatus{Path: file.Name, Property: property.Name, Status: json.ToTestStatus(property.Status), Iterations: property.Iterations},
+		"@testfile", file.Name)
+	t.Diagnostics(nil, file, property.Diagnostics)
+}
+
 func (t *TestJSON) DestroySummary(diags tfdiags.Diagnostics, run *moduletest.Run, file *moduletest.File, state *states.State) {
 	if state.HasManagedResourceInstanceObjects() {
 		cleanup := json.TestFileCleanup{}
@@ -541,6 +645,61 @@ func (t *TestJSON) FatalInterruptSummary(run *moduletest.Run, file *moduletest.F
 		"@testfile", file.Name)
 }
 
+func (t *TestJSON) CleanupSummary(suite *moduletest.Suite) {
+	var names []string
+	for name := range suite.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t.view.log.Info(
+			fmt.Sprintf("%s... %s", name, cleanupStatus(suite.Files[name].Status, nil)),
+			"type", json.MessageLog,
+			"@testfile", name)
+	}
+
+	switch {
+	case len(suite.Files) == 0:
+		t.view.Log("No leftover test resources were found.")
+	case suite.Status == moduletest.Pass:
+		t.view.Log("Cleanup complete! All leftover test resources were destroyed.")
+	default:
+		t.view.log.Error(
+			"Cleanup failed! Some leftover test resources could not be destroyed.",
+			"type", json.MessageLog)
+	}
+}
+
+func coverageModuleName(module addrs.Module) string {
+	if module.IsRoot() {
+		return "root module"
+	}
+	return module.String()
+}
+
+func coverageMessage(covered, total int) string {
+	percent := 100.0
+	if total > 0 {
+		percent = float64(covered) * 100 / float64(total)
+	}
+	return fmt.Sprintf("%d of %d objects covered (%.1f%%)", covered, total, percent)
+}
+
+// cleanupStatus describes the status of a test file cleaned up by the cleanup
+// mode, colorized if color is not nil.
+func cleanupStatus(status moduletest.Status, color *colorstring.Colorize) string {
+	if status == moduletest.Pass {
+		if color == nil {
+			return "cleaned up"
+		}
+		return color.Color("[green]cleaned up[reset]")
+	}
+	if color == nil {
+		return "failed"
+	}
+	return color.Color("[red]failed[reset]")
+}
+
 func colorizeTestStatus(status moduletest.Status, color *colorstring.Colorize) string {
 	switch status {
 	case moduletest.Error, moduletest.Fail:
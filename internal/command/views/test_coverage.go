@@ -0,0 +1,185 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package views
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/opentofu/opentofu/internal/command/arguments"
+	"github.com/opentofu/opentofu/internal/command/jsonentities"
+	"github.com/opentofu/opentofu/internal/moduletest"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// TestCoverageReport writes the coverage collected while executing a test
+// suite to a file, in either the lcov tracefile format or JSON.
+type TestCoverageReport struct {
+	filename string
+	format   arguments.CoverageFormat
+}
+
+func NewTestCoverageReport(filename string, format arguments.CoverageFormat) *TestCoverageReport {
+	return &TestCoverageReport{
+		filename: filename,
+		format:   format,
+	}
+}
+
+// testCoverageReportFormatVersion is the version of the JSON coverage report
+// format, which follows the same rules as the other JSON formats documented
+// in the OpenTofu internals documentation.
+const testCoverageReportFormatVersion = "1.0"
+
+type testCoverageReport struct {
+	FormatVersion string               `json:"format_version"`
+	Modules       []testCoverageModule `json:"modules"`
+	Objects       []testCoverageObject `json:"objects"`
+}
+
+type testCoverageModule struct {
+	Module  string `json:"module"`
+	Covered int    `json:"covered"`
+	Total   int    `json:"total"`
+}
+
+type testCoverageObject struct {
+	Module  string                       `json:"module"`
+	Kind    moduletest.CoverageKind      `json:"kind"`
+	Address string                       `json:"address"`
+	Index   *int                         `json:"index,omitempty"`
+	Range   jsonentities.DiagnosticRange `json:"range"`
+	Hits    int                          `json:"hits"`
+}
+
+// Save writes the report for the given coverage, which should have been
+// collected for a test suite that has finished executing.
+func (r *TestCoverageReport) Save(coverage *moduletest.Coverage) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	var src []byte
+	var err error
+	switch r.format {
+	case arguments.CoverageFormatJSON:
+		src, err = marshalTestCoverageJSON(coverage)
+	default:
+		src = marshalTestCoverageLCOV(coverage)
+	}
+	if err != nil {
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to generate coverage report",
+			fmt.Sprintf("OpenTofu could not generate the test coverage report: %s.", err),
+		))
+	}
+
+	if err := os.WriteFile(r.filename, src, 0644); err != nil {
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to write coverage report",
+			fmt.Sprintf("OpenTofu could not write the test coverage report to %s: %s.", r.filename, err),
+		))
+	}
+	return diags
+}
+
+func marshalTestCoverageJSON(coverage *moduletest.Coverage) ([]byte, error) {
+	report := testCoverageReport{
+		FormatVersion: testCoverageReportFormatVersion,
+		Modules:       []testCoverageModule{},
+		Objects:       []testCoverageObject{},
+	}
+	for _, summary := range coverage.Summary() {
+		report.Modules = append(report.Modules, testCoverageModule{
+			Module:  summary.Module.String(),
+			Covered: summary.Covered,
+			Total:   summary.Total,
+		})
+	}
+	for _, item := range coverage.Items() {
+		obj := testCoverageObject{
+			Module:  item.Module.String(),
+			Kind:    item.Kind,
+			Address: item.Address,
+			Range: jsonentities.DiagnosticRange{
+				Filename: item.Range.Filename,
+				Start: jsonentities.Pos{
+					Line:   item.Range.Start.Line,
+					Column: item.Range.Start.Column,
+					Byte:   item.Range.Start.Byte,
+				},
+				End: jsonentities.Pos{
+					Line:   item.Range.End.Line,
+					Column: item.Range.End.Column,
+					Byte:   item.Range.End.Byte,
+				},
+			},
+			Hits: item.Hits,
+		}
+		if isCoverageRule(item.Kind) {
+			index := item.Index
+			obj.Index = &index
+		}
+		report.Objects = append(report.Objects, obj)
+	}
+
+	src, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(src, '\n'), nil
+}
+
+// marshalTestCoverageLCOV renders the coverage in the lcov tracefile format,
+// reporting each object as covering the line it starts on. If more than one
+// object starts on the same line, the line is only covered if all of them
+// are.
+func marshalTestCoverageLCOV(coverage *moduletest.Coverage) []byte {
+	lines := make(map[string]map[int]int)
+	var files []string
+	for _, item := range coverage.Items() {
+		if _, ok := lines[item.Range.Filename]; !ok {
+			lines[item.Range.Filename] = make(map[int]int)
+			files = append(files, item.Range.Filename)
+		}
+		line := item.Range.Start.Line
+		if hits, ok := lines[item.Range.Filename][line]; !ok || item.Hits < hits {
+			lines[item.Range.Filename][line] = item.Hits
+		}
+	}
+	sort.Strings(files)
+
+	var buf bytes.Buffer
+	for _, filename := range files {
+		var nums []int
+		for line := range lines[filename] {
+			nums = append(nums, line)
+		}
+		sort.Ints(nums)
+
+		hit := 0
+		fmt.Fprintf(&buf, "TN:\nSF:%s\n", filename)
+		for _, line := range nums {
+			hits := lines[filename][line]
+			if hits > 0 {
+				hit++
+			}
+			fmt.Fprintf(&buf, "DA:%d,%d\n", line, hits)
+		}
+		fmt.Fprintf(&buf, "LF:%d\nLH:%d\nend_of_record\n", len(nums), hit)
+	}
+	return buf.Bytes()
+}
+
+func isCoverageRule(kind moduletest.CoverageKind) bool {
+	switch kind {
+	case moduletest.CoverageResource, moduletest.CoverageDataResource, moduletest.CoverageOutput:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package views
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/colorstring"
+
+	"github.com/opentofu/opentofu/internal/command/format"
+	"github.com/opentofu/opentofu/internal/command/jsonformat"
+	"github.com/opentofu/opentofu/internal/moduletest"
+	"github.com/opentofu/opentofu/internal/terminal"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// TestJUnitXMLFile writes the results of a test suite to a file as a JUnit
+// XML report, for consumption by CI systems.
+//
+// Each test file is reported as a testsuite and each run block as a
+// testcase within it.
+type TestJUnitXMLFile struct {
+	filename string
+
+	// view is used only to find the configuration sources, so that
+	// diagnostics in the report include source snippets.
+	view *View
+}
+
+func NewTestJUnitXMLFile(filename string, view *View) *TestJUnitXMLFile {
+	return &TestJUnitXMLFile{
+		filename: filename,
+		view:     view,
+	}
+}
+
+// The types below describe the subset of the de-facto JUnit XML format, as
+// understood by common CI systems, that we produce.
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+	SystemErr *junitText      `xml:"system-err,omitempty"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	SystemOut *junitText    `xml:"system-out,omitempty"`
+	SystemErr *junitText    `xml:"system-err,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Body    string `xml:",cdata"`
+}
+
+type junitText struct {
+	Body string `xml:",cdata"`
+}
+
+// Save writes the report for the given suite, which should have finished
+// executing, to the file.
+func (t *TestJUnitXMLFile) Save(suite *moduletest.Suite) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	src, err := t.marshal(suite)
+	if err != nil {
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to generate JUnit XML report",
+			fmt.Sprintf("OpenTofu could not generate the JUnit XML test report: %s.", err),
+		))
+	}
+
+	if err := os.WriteFile(t.filename, src, 0644); err != nil {
+		return diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to write JUnit XML report",
+			fmt.Sprintf("OpenTofu could not write the JUnit XML test report to %s: %s.", t.filename, err),
+		))
+	}
+	return diags
+}
+
+func (t *TestJUnitXMLFile) marshal(suite *moduletest.Suite) ([]byte, error) {
+	var names []string
+	for name := range suite.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names) // match the order the files were executed in
+
+	report := junitTestSuites{}
+	var total time.Duration
+	for _, name := range names {
+		file := suite.Files[name]
+		ts := junitTestSuite{
+			Name:      file.Name,
+			Time:      junitDuration(file.Duration),
+			SystemErr: t.diagnosticsText(file.Diagnostics),
+		}
+
+		for _, run := range file.Runs {
+			tc := junitTestCase{
+				Name:      run.Name,
+				Classname: file.Name,
+				Time:      junitDuration(run.Duration),
+			}
+
+			ts.Tests++
+			switch run.Status {
+			case moduletest.Pending, moduletest.Skip:
+				ts.Skipped++
+				tc.Skipped = &junitMessage{Message: "Testcase skipped due to an interrupt or an earlier error"}
+				if run.Status == moduletest.Pending {
+					tc.Skipped.Message = "Testcase was not executed"
+				}
+			case moduletest.Fail:
+				ts.Failures++
+				tc.Failure = t.diagnosticsMessage(run.Diagnostics, "Test assertions failed")
+			case moduletest.Error:
+				ts.Errors++
+				tc.Error = t.diagnosticsMessage(run.Diagnostics, "Encountered an error")
+			default:
+				// Any warnings for passing runs are reported as output.
+				tc.SystemErr = t.diagnosticsText(run.Diagnostics)
+			}
+
+			if run.Verbose != nil {
+				out, err := junitRenderVerbose(run, file)
+				if err != nil {
+					return nil, err
+				}
+				if out != "" {
+					tc.SystemOut = &junitText{Body: out}
+				}
+			}
+
+			ts.Cases = append(ts.Cases, tc)
+		}
+
+		for _, property := range file.Properties {
+			tc := junitTestCase{
+				Name:      fmt.Sprintf("property.%s", property.Name),
+				Classname: file.Name,
+				Time:      junitDuration(property.Duration),
+			}
+
+			ts.Tests++
+			switch property.Status {
+			case moduletest.Pending, moduletest.Skip:
+				ts.Skipped++
+				tc.Skipped = &junitMessage{Message: "Testcase skipped due to an interrupt"}
+				if property.Status == moduletest.Pending {
+					tc.Skipped.Message = "Testcase was not executed"
+				}
+			case moduletest.Fail:
+				ts.Failures++
+				tc.Failure = t.diagnosticsMessage(property.Diagnostics, "Property counterexample found")
+			case moduletest.Error:
+				ts.Errors++
+				tc.Error = t.diagnosticsMessage(property.Diagnostics, "Encountered an error")
+			default:
+				tc.SystemErr = t.diagnosticsText(property.Diagnostics)
+			}
+
+			ts.Cases = append(ts.Cases, tc)
+		}
+
+		report.Tests += ts.Tests
+		report.Failures += ts.Failures
+		report.Errors += ts.Errors
+		report.Skipped += ts.Skipped
+		total += file.Duration
+		report.Suites = append(report.Suites, ts)
+	}
+	report.Time = junitDuration(total)
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// diagnosticsMessage returns a failure or error element describing the
+// error diagnostics in diags, using the summary of the first one as the
+// message and the full rendering of all of them as the body.
+func (t *TestJUnitXMLFile) diagnosticsMessage(diags tfdiags.Diagnostics, fallback string) *junitMessage {
+	msg := &junitMessage{Message: fallback}
+	for _, diag := range diags {
+		if diag.Severity() == tfdiags.Error {
+			msg.Message = diag.Description().Summary
+			break
+		}
+	}
+	if text := t.diagnosticsText(diags); text != nil {
+		msg.Body = text.Body
+	}
+	return msg
+}
+
+func (t *TestJUnitXMLFile) diagnosticsText(diags tfdiags.Diagnostics) *junitText {
+	if len(diags) == 0 {
+		return nil
+	}
+
+	var sources = t.view.configSources()
+	var buf strings.Builder
+	for _, diag := range diags {
+		buf.WriteString(format.DiagnosticPlain(diag, sources, 78))
+	}
+	return &junitText{Body: buf.String()}
+}
+
+// junitRenderVerbose returns the human-readable rendering of the plan or
+// state that the -verbose flag would print for the given run.
+func junitRenderVerbose(run *moduletest.Run, file *moduletest.File) (string, error) {
+	// The renderer writes directly to a terminal.Streams, so we capture its
+	// output through a pipe.
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	var buf bytes.Buffer
+	copied := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(&buf, r)
+		copied <- err
+	}()
+
+	renderer := jsonformat.Renderer{
+		Streams: &terminal.Streams{
+			Stdout: &terminal.OutputStream{File: w},
+			Stderr: &terminal.OutputStream{File: w},
+		},
+		Colorize: &colorstring.Colorize{
+			Colors:  colorstring.DefaultColors,
+			Disable: true,
+		},
+		RunningInAutomation: true,
+	}
+	// Any problems rendering are already reported by the other views.
+	_ = renderTestVerbose(renderer, run, file)
+	w.Close()
+
+	if err := <-copied; err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func junitDuration(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -461,6 +461,101 @@ func TestTestHuman_File(t *testing.T) {
 	}
 }
 
+func TestTestHuman_CleanupSummary(t *testing.T) {
+	tcs := map[string]struct {
+		Suite    *moduletest.Suite
+		Expected string
+	}{
+		"no files": {
+			Suite:    &moduletest.Suite{Status: moduletest.Pass},
+			Expected: "No leftover test resources were found.\n",
+		},
+
+		"cleaned up": {
+			Suite: &moduletest.Suite{
+				Status: moduletest.Pass,
+				Files: map[string]*moduletest.File{
+					"main.tftest.hcl":  {Name: "main.tftest.hcl", Status: moduletest.Pass},
+					"other.tftest.hcl": {Name: "other.tftest.hcl", Status: moduletest.Pass},
+				},
+			},
+			Expected: `main.tftest.hcl... cleaned up
+other.tftest.hcl... cleaned up
+
+Cleanup complete! All leftover test resources were destroyed.
+`,
+		},
+
+		"failed": {
+			Suite: &moduletest.Suite{
+				Status: moduletest.Error,
+				Files: map[string]*moduletest.File{
+					"main.tftest.hcl":  {Name: "main.tftest.hcl", Status: moduletest.Pass},
+					"other.tftest.hcl": {Name: "other.tftest.hcl", Status: moduletest.Error},
+				},
+			},
+			Expected: `main.tftest.hcl... cleaned up
+other.tftest.hcl... failed
+
+Cleanup failed! Some leftover test resources could not be destroyed.
+`,
+		},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			streams, done := terminal.StreamsForTesting(t)
+			view := NewTest(arguments.ViewHuman, NewView(streams))
+
+			view.CleanupSummary(tc.Suite)
+
+			actual := done(t).Stdout()
+			expected := tc.Expected
+			if diff := cmp.Diff(expected, actual); len(diff) > 0 {
+				t.Fatalf("expected:\n%s\nactual:\n%s\ndiff:\n%s", expected, actual, diff)
+			}
+		})
+	}
+}
+
+func TestTestHuman_Property(t *testing.T) {
+	streams, done := terminal.StreamsForTesting(t)
+	view := NewTest(arguments.ViewHuman, NewView(streams))
+
+	view.Property(&moduletest.Property{Name: "port", Status: moduletest.Pass, Iterations: 100}, &moduletest.File{Name: "main.tftest.hcl"})
+
+	actual := done(t).Stdout()
+	expected := "  property \"port\"... pass\n"
+	if diff := cmp.Diff(expected, actual); len(diff) > 0 {
+		t.Fatalf("expected:\n%s\nactual:\n%s\ndiff:\n%s", expected, actual, diff)
+	}
+}
+
+func TestTestJSON_Property(t *testing.T) {
+	streams, done := terminal.StreamsForTesting(t)
+	view := NewTest(arguments.ViewJSON, NewView(streams))
+
+	view.Property(&moduletest.Property{Name: "port", Status: moduletest.Fail, Iterations: 12}, &moduletest.File{Name: "main.tftest.hcl"})
+
+	want := []map[string]interface{}{
+		{
+			"@level":    "info",
+			"@message":  "  property \"port\"... fail",
+			"@module":   "tofu.ui",
+			"@testfile": "main.tftest.hcl",
+			"test_property": map[string]interface{}{
+				"path":       "main.tftest.hcl",
+				"property":   "port",
+				"status":     "fail",
+				"iterations": float64(12),
+			},
+			"type": "test_property",
+		},
+	}
+	testJSONViewOutputEquals(t, done(t).All(), want, cmp.FilterPath(func(path cmp.Path) bool {
+		return strings.Contains(path.Last().String(), "version") || strings.Contains(path.Last().String(), "timestamp")
+	}, cmp.Ignore()))
+}
+
 func TestTestHuman_Run(t *testing.T) {
 	tcs := map[string]struct {
 		Run    *moduletest.Run
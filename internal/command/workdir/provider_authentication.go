@@ -0,0 +1,82 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package workdir
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const ProviderAuthenticationFilename = "provider_authentication"
+
+// ProviderAuthentication records how OpenTofu authenticated the package for
+// a particular provider version when it last installed that package into the
+// working directory.
+//
+// This information is otherwise available only while the installer is
+// running, so we retain it for commands that report on the provenance of the
+// providers in use, such as "tofu sbom".
+type ProviderAuthentication struct {
+	// Version is the provider version that was installed.
+	Version string `json:"version"`
+
+	// Result is the UI-oriented summary of the authentication result, such as
+	// "signed" or "verified checksum".
+	Result string `json:"result"`
+
+	// GPGKeyIDs are the IDs of any GPG keys that signed the package.
+	GPGKeyIDs []string `json:"gpg_key_ids,omitempty"`
+
+	// OCISigners are descriptions of any cosign signers that signed the
+	// OCI artifact the package was installed from.
+	OCISigners []string `json:"oci_signers,omitempty"`
+}
+
+// ProviderAuthentications returns the most recently-recorded authentication
+// results for the providers installed in the working directory, keyed by the
+// string representation of each provider's source address.
+//
+// Returns a nil map and no error if there are no recorded results.
+func (d *Dir) ProviderAuthentications() (map[string]ProviderAuthentication, error) {
+	raw, err := os.ReadFile(filepath.Join(d.dataDir, ProviderAuthenticationFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ret map[string]ProviderAuthentication
+	if err := json.Unmarshal(raw, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// SetProviderAuthentications replaces the recorded authentication results
+// for the providers installed in the working directory. See
+// ProviderAuthentications for more information.
+//
+// Pass an empty map to remove all of the recorded results.
+func (d *Dir) SetProviderAuthentications(auths map[string]ProviderAuthentication) error {
+	filePath := filepath.Join(d.dataDir, ProviderAuthenticationFilename)
+	if len(auths) == 0 {
+		err := os.Remove(filePath)
+		if !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	// As with SetForcedPluginDirs, a failure to create the directory will
+	// also cause a more relevant failure when writing the file below.
+	_ = d.ensureDataDir()
+
+	raw, err := json.MarshalIndent(auths, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, raw, 0644)
+}
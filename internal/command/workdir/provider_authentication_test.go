@@ -0,0 +1,55 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package workdir
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDirProviderAuthentications(t *testing.T) {
+	dir := NewDir(t.TempDir())
+
+	got, err := dir.ProviderAuthentications()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("unexpected initial results: %#v", got)
+	}
+
+	want := map[string]ProviderAuthentication{
+		"registry.opentofu.org/hashicorp/aws": {
+			Version:   "5.0.0",
+			Result:    "signed",
+			GPGKeyIDs: []string{"34365D9472D7468F"},
+		},
+		"example.com/foo/bar": {
+			Version: "1.0.0",
+			Result:  "verified checksum",
+		},
+	}
+	if err := dir.SetProviderAuthentications(want); err != nil {
+		t.Fatal(err)
+	}
+	got, err = dir.ProviderAuthentications()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong updated results\n%s", diff)
+	}
+
+	if err := dir.SetProviderAuthentications(nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err = dir.ProviderAuthentications()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("unexpected results after removal: %#v", got)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package workdir
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const ProviderDevOverridesFilename = "provider_dev_overrides"
+
+// ProviderDevOverride describes a provider development override that applies
+// only to a particular working directory.
+//
+// This is the working-directory-scoped equivalent of the dev_overrides block
+// in the CLI configuration, managed using "tofu providers override".
+type ProviderDevOverride struct {
+	// Dir is the absolute path of the directory containing the provider's
+	// executable.
+	Dir string `json:"dir"`
+
+	// BuildCommand is an optional shell command that rebuilds the provider's
+	// executable in Dir whenever any file in SourceDir is newer than it.
+	BuildCommand string `json:"build_command,omitempty"`
+
+	// SourceDir is the absolute path of the directory containing the
+	// provider's source code, which is also the working directory for
+	// BuildCommand. If not set, Dir is used instead.
+	SourceDir string `json:"source_dir,omitempty"`
+}
+
+// ProviderDevOverrides returns the provider development overrides recorded
+// for the working directory, keyed by the string representation of each
+// provider's source address.
+//
+// Returns a nil map and no error if there are no overrides.
+func (d *Dir) ProviderDevOverrides() (map[string]ProviderDevOverride, error) {
+	raw, err := os.ReadFile(filepath.Join(d.dataDir, ProviderDevOverridesFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ret map[string]ProviderDevOverride
+	if err := json.Unmarshal(raw, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// SetProviderDevOverrides replaces the provider development overrides
+// recorded for the working directory. See ProviderDevOverrides for more
+// information.
+//
+// Pass an empty map to remove all of the overrides.
+func (d *Dir) SetProviderDevOverrides(overrides map[string]ProviderDevOverride) error {
+	filePath := filepath.Join(d.dataDir, ProviderDevOverridesFilename)
+	if len(overrides) == 0 {
+		err := os.Remove(filePath)
+		if !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	// As with SetForcedPluginDirs, a failure to create the directory will
+	// also cause a more relevant failure when writing the file below.
+	_ = d.ensureDataDir()
+
+	raw, err := json.MarshalIndent(overrides, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, raw, 0644)
+}
@@ -0,0 +1,54 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package workdir
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDirProviderDevOverrides(t *testing.T) {
+	dir := NewDir(t.TempDir())
+
+	got, err := dir.ProviderDevOverrides()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("unexpected initial overrides: %#v", got)
+	}
+
+	want := map[string]ProviderDevOverride{
+		"registry.opentofu.org/hashicorp/aws": {
+			Dir:          "/src/terraform-provider-aws",
+			BuildCommand: "go build",
+		},
+		"example.com/foo/bar": {
+			Dir:       "/home/user/go/bin",
+			SourceDir: "/src/bar",
+		},
+	}
+	if err := dir.SetProviderDevOverrides(want); err != nil {
+		t.Fatal(err)
+	}
+	got, err = dir.ProviderDevOverrides()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong updated overrides\n%s", diff)
+	}
+
+	if err := dir.SetProviderDevOverrides(nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err = dir.ProviderDevOverrides()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("unexpected overrides after removal: %#v", got)
+	}
+}
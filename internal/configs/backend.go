@@ -10,6 +10,7 @@ import (
 	"fmt"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/configs/configschema"
@@ -25,17 +26,107 @@ type Backend struct {
 	Config hcl.Body
 	Eval   *StaticEvaluator
 
+	// Mirror is the optional secondary backend that every persisted state
+	// snapshot is also written to. Its block is removed from Config so that
+	// it isn't decoded against the primary backend's schema.
+	Mirror *BackendMirror
+
+	TypeRange hcl.Range
+	DeclRange hcl.Range
+}
+
+// BackendMirror represents a "mirror" block inside a "backend" block.
+type BackendMirror struct {
+	Type   string
+	Config hcl.Body
+
+	// Async causes snapshots to be written to the mirror in the background
+	// rather than before PersistState returns.
+	Async bool
+
 	TypeRange hcl.Range
 	DeclRange hcl.Range
 }
 
 func decodeBackendBlock(block *hcl.Block) (*Backend, hcl.Diagnostics) {
-	return &Backend{
+	content, remain, diags := block.Body.PartialContent(backendBlockSchema)
+
+	b := &Backend{
+		Type:      block.Labels[0],
+		TypeRange: block.LabelRanges[0],
+		Config:    remain,
+		DeclRange: block.DefRange,
+	}
+
+	for _, mirrorBlock := range content.Blocks {
+		if b.Mirror != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate backend mirror",
+				Detail:   fmt.Sprintf("A backend may have only one mirror. The mirror was previously configured at %s.", b.Mirror.DeclRange),
+				Subject:  mirrorBlock.DefRange.Ptr(),
+			})
+			continue
+		}
+		mirror, mirrorDiags := decodeBackendMirrorBlock(mirrorBlock)
+		diags = append(diags, mirrorDiags...)
+		b.Mirror = mirror
+	}
+
+	return b, diags
+}
+
+func decodeBackendMirrorBlock(block *hcl.Block) (*BackendMirror, hcl.Diagnostics) {
+	content, remain, diags := block.Body.PartialContent(backendMirrorBlockSchema)
+
+	m := &BackendMirror{
 		Type:      block.Labels[0],
 		TypeRange: block.LabelRanges[0],
-		Config:    block.Body,
+		Config:    remain,
 		DeclRange: block.DefRange,
-	}, nil
+	}
+
+	if m.Type == "cloud" || m.Type == "remote" {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid backend mirror",
+			Detail:   fmt.Sprintf("The %q backend cannot be used as a mirror.", m.Type),
+			Subject:  m.TypeRange.Ptr(),
+		})
+	}
+
+	if attr, exists := content.Attributes["async"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &m.Async)
+		diags = append(diags, valDiags...)
+	}
+
+	return m, diags
+}
+
+// DecodeMirror decodes the configuration of the backend's mirror against
+// the given schema for the mirror's backend type. It must only be called
+// if Mirror is non-nil.
+func (b *Backend) DecodeMirror(ctx context.Context, schema *configschema.Block) (cty.Value, hcl.Diagnostics) {
+	return b.Eval.DecodeBlock(ctx, b.Mirror.Config, schema.DecoderSpec(), StaticIdentifier{
+		Module:    addrs.RootModule,
+		Subject:   fmt.Sprintf("backend.%s.mirror.%s", b.Type, b.Mirror.Type),
+		DeclRange: b.Mirror.DeclRange,
+	})
+}
+
+var backendBlockSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "mirror",
+			LabelNames: []string{"type"},
+		},
+	},
+}
+
+var backendMirrorBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "async"},
+	},
 }
 
 // Hash produces a hash value for the receiver that covers the type and the
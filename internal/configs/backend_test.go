@@ -0,0 +1,55 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package configs
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/opentofu/opentofu/internal/configs/configschema"
+)
+
+func TestBackendMirror(t *testing.T) {
+	cfg, diags := testModuleConfigFromFile(t.Context(), "testdata/valid-files/backend-mirror.tf")
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+
+	b := cfg.Module.Backend
+	if b.Mirror == nil {
+		t.Fatal("mirror was not decoded")
+	}
+	if got, want := b.Mirror.Type, "local"; got != want {
+		t.Errorf("wrong mirror type %q; want %q", got, want)
+	}
+	if !b.Mirror.Async {
+		t.Error("mirror should be async")
+	}
+
+	// The mirror block must not be visible to the primary backend's schema.
+	primary, diags := b.Decode(t.Context(), &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"foo": {Type: cty.String, Optional: true},
+		},
+	})
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	if got := primary.GetAttr("foo"); !got.RawEquals(cty.StringVal("bar")) {
+		t.Errorf("wrong primary config %#v", got)
+	}
+
+	mirror, diags := b.DecodeMirror(t.Context(), &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"path": {Type: cty.String, Optional: true},
+		},
+	})
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	if got := mirror.GetAttr("path"); !got.RawEquals(cty.StringVal("backup/terraform.tfstate")) {
+		t.Errorf("wrong mirror config %#v", got)
+	}
+}
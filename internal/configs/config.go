@@ -521,6 +521,12 @@ func (c *Config) addProviderRequirements(reqs getproviders.Requirements, qualifs
 						diags = append(diags, moreDiags...)
 					}
 				}
+				for _, fixture := range file.Fixtures {
+					if fixture.ConfigUnderTest != nil {
+						moreDiags := fixture.ConfigUnderTest.addProviderRequirements(reqs, qualifs, true, false)
+						diags = append(diags, moreDiags...)
+					}
+				}
 			}
 		}
 	}
@@ -650,6 +656,14 @@ func (c *Config) resolveProviderTypesForTests(providers map[string]addrs.Provide
 
 	for _, test := range c.Module.Tests {
 
+		// Fixtures execute as their own root modules, so their provider
+		// types only depend on their own configuration.
+		for _, fixture := range test.Fixtures {
+			if fixture.ConfigUnderTest != nil {
+				fixture.ConfigUnderTest.resolveProviderTypes()
+			}
+		}
+
 		// testProviders contains the configuration blocks for all the providers
 		// defined by this test file. It is keyed by the name of the provider
 		// and the values are a slice of provider configurations which contains
@@ -949,7 +963,14 @@ func (c *Config) getProviderConfigTransformForTest(evalCtx *hcl.EvalContext) tes
 			next[key] = value
 		}
 
-		ctxRunOutputExists := !evalCtx.Variables["run"].IsNull() && len(evalCtx.Variables["run"].AsValueMap()) > 0
+		// Provider configs only need evaluating if they can refer to the
+		// outputs of run blocks or fixtures.
+		ctxRunOutputExists := false
+		for _, name := range []string{"run", "fixture"} {
+			if val, exists := evalCtx.Variables[name]; exists && !val.IsNull() && len(val.AsValueMap()) > 0 {
+				ctxRunOutputExists = true
+			}
+		}
 
 		if run != nil && len(run.Providers) > 0 {
 			// Then we'll only copy over and overwrite the specific providers asked
@@ -987,6 +1008,7 @@ func (c *Config) getProviderConfigTransformForTest(evalCtx *hcl.EvalContext) tes
 					Config:            providerConfig,
 					DeclRange:         testProvider.DeclRange,
 					IsMocked:          testProvider.IsMocked,
+					MockSeed:          testProvider.MockSeed,
 					MockResources:     testProvider.MockResources,
 					OverrideResources: testProvider.OverrideResources,
 				}
@@ -1011,6 +1033,7 @@ func (c *Config) getProviderConfigTransformForTest(evalCtx *hcl.EvalContext) tes
 					AliasRange:        mp.AliasRange,
 					DeclRange:         mp.DeclRange,
 					IsMocked:          true,
+					MockSeed:          mp.Seed,
 					MockResources:     mp.MockResources,
 					OverrideResources: mp.OverrideResources,
 				}
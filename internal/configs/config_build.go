@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	version "github.com/hashicorp/go-version"
@@ -73,7 +74,15 @@ func buildTestModules(ctx context.Context, root *Config, walker ModuleWalker) hc
 			if dir != "." {
 				path = append(path, strings.Split(dir, "/")...)
 			}
-			path = append(path, strings.TrimSuffix(base, ".tftest.hcl"), run.Name)
+			path = append(path, strings.TrimSuffix(base, ".tftest.hcl"))
+			if run.Each != nil {
+				// Run blocks expanded from a for_each argument have names
+				// that aren't valid path segments, so we use the position
+				// of the element instead.
+				path = append(path, run.Each.Name, strconv.Itoa(run.Each.Index))
+			} else {
+				path = append(path, run.Name)
+			}
 			req := ModuleRequest{
 				Name:              run.Name,
 				Path:              path,
@@ -112,6 +121,60 @@ func buildTestModules(ctx context.Context, root *Config, walker ModuleWalker) hc
 		}
 	}
 
+	diags = append(diags, buildTestFixtures(ctx, root, walker)...)
+
+	return diags
+}
+
+// buildTestFixtures loads the modules of the fixture blocks declared by the
+// test files. Fixtures are shared by all the test files, so their names must
+// be unique across them.
+func buildTestFixtures(ctx context.Context, root *Config, walker ModuleWalker) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	names := make([]string, 0, len(root.Module.Tests))
+	for name := range root.Module.Tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	declared := make(map[string]*TestFixture)
+	for _, name := range names {
+		for _, fixture := range root.Module.Tests[name].Fixtures {
+			if existing, exists := declared[fixture.Name]; exists {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate fixture block",
+					Detail:   fmt.Sprintf("A fixture named %q was already declared at %s. Fixtures are shared by all the test files, so their names must be unique.", fixture.Name, existing.DeclRange),
+					Subject:  fixture.NameDeclRange.Ptr(),
+				})
+				continue
+			}
+			declared[fixture.Name] = fixture
+
+			req := ModuleRequest{
+				Name:              fixture.Name,
+				Path:              addrs.Module{"fixture", fixture.Name},
+				SourceAddr:        fixture.Module.Source,
+				SourceAddrRange:   fixture.Module.SourceDeclRange,
+				VersionConstraint: fixture.Module.Version,
+				Parent:            root,
+				CallRange:         fixture.Module.DeclRange,
+			}
+
+			cfg, modDiags := loadModule(ctx, root, &req, walker)
+			diags = append(diags, modDiags...)
+
+			if cfg != nil {
+				// As with the modules of run blocks, the fixture module is
+				// executed as if it was the root module.
+				cfg.Parent = nil
+				rebaseChildModule(cfg, cfg)
+				fixture.ConfigUnderTest = cfg
+			}
+		}
+	}
+
 	return diags
 }
 
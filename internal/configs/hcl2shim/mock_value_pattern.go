@@ -0,0 +1,139 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl2shim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// ComposeByPattern generates a mock string from the given pattern. Every
+// placeholder within curly braces is replaced by a random value of the given
+// format, while the rest of the pattern is kept as is. The supported
+// placeholders are:
+//
+//   - {digits:N}, {hex:N}, {alpha:N} and {alnum:N} for N random characters
+//     from the corresponding character set.
+//   - {uuid} for a random UUID.
+//   - {ipv4} for a random IPv4 address within the 10.0.0.0/8 range.
+//
+// A literal curly brace can be written by doubling it: "{{" or "}}".
+//
+// As with ComposeBySchema, the result only depends on the seed of the
+// composer and the sequence of calls made with it.
+func (mvc MockValueComposer) ComposeByPattern(pattern string) (string, error) {
+	var b strings.Builder
+
+	for len(pattern) > 0 {
+		switch {
+		case strings.HasPrefix(pattern, "{{"):
+			b.WriteByte('{')
+			pattern = pattern[2:]
+		case strings.HasPrefix(pattern, "}}"):
+			b.WriteByte('}')
+			pattern = pattern[2:]
+		case pattern[0] == '{':
+			end := strings.IndexByte(pattern, '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated placeholder %q", pattern)
+			}
+			s, err := mvc.composePlaceholder(pattern[1:end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+			pattern = pattern[end+1:]
+		case pattern[0] == '}':
+			return "", fmt.Errorf("unexpected closing brace; use \"}}\" for a literal brace")
+		default:
+			b.WriteByte(pattern[0])
+			pattern = pattern[1:]
+		}
+	}
+
+	return b.String(), nil
+}
+
+func (mvc MockValueComposer) composePlaceholder(placeholder string) (string, error) {
+	const (
+		digits = "0123456789"
+		hex    = "0123456789abcdef"
+		alpha  = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+		alnum  = alpha + digits
+	)
+
+	name, arg, hasArg := strings.Cut(placeholder, ":")
+
+	var chars string
+	switch name {
+	case "digits":
+		chars = digits
+	case "hex":
+		chars = hex
+	case "alpha":
+		chars = alpha
+	case "alnum":
+		chars = alnum
+	case "uuid", "ipv4":
+		if hasArg {
+			return "", fmt.Errorf("placeholder {%s} does not accept a length", name)
+		}
+		if name == "uuid" {
+			return fmt.Sprintf("%s-%s-4%s-%s%s-%s",
+				mvc.getRandomString(hex, 8),
+				mvc.getRandomString(hex, 4),
+				mvc.getRandomString(hex, 3),
+				mvc.getRandomString("89ab", 1),
+				mvc.getRandomString(hex, 3),
+				mvc.getRandomString(hex, 12),
+			), nil
+		}
+		return fmt.Sprintf("10.%d.%d.%d", mvc.rand.Intn(256), mvc.rand.Intn(256), mvc.rand.Intn(254)+1), nil
+	default:
+		return "", fmt.Errorf("unsupported placeholder {%s}", placeholder)
+	}
+
+	if !hasArg {
+		return "", fmt.Errorf("placeholder {%s} requires a length, like {%s:8}", name, name)
+	}
+	length, err := strconv.Atoi(arg)
+	if err != nil || length <= 0 {
+		return "", fmt.Errorf("invalid length %q in placeholder {%s}", arg, placeholder)
+	}
+
+	return mvc.getRandomString(chars, length), nil
+}
+
+func (mvc MockValueComposer) getRandomString(chars string, length int) string {
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = chars[mvc.rand.Intn(len(chars))]
+	}
+	return string(b)
+}
+
+// PatternFunc returns a function that generates mock strings with
+// ComposeByPattern, so mock defaults can generate values of a given format.
+func (mvc MockValueComposer) PatternFunc() function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "pattern",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			s, err := mvc.ComposeByPattern(args[0].AsString())
+			if err != nil {
+				return cty.NilVal, function.NewArgError(0, err)
+			}
+			return cty.StringVal(s), nil
+		},
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl2shim
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestComposeByPattern(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		pattern   string
+		want      string
+		wantError bool
+	}{
+		"literal": {
+			pattern: "i-1234",
+			want:    `^i-1234$`,
+		},
+		"arn": {
+			pattern: "arn:aws:iam::{digits:12}:role/{alnum:8}",
+			want:    `^arn:aws:iam::[0-9]{12}:role/[a-zA-Z0-9]{8}$`,
+		},
+		"instance id": {
+			pattern: "i-{hex:17}",
+			want:    `^i-[0-9a-f]{17}$`,
+		},
+		"alpha": {
+			pattern: "{alpha:5}",
+			want:    `^[a-zA-Z]{5}$`,
+		},
+		"uuid": {
+			pattern: "{uuid}",
+			want:    `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`,
+		},
+		"ipv4": {
+			pattern: "{ipv4}/32",
+			want:    `^10\.[0-9]{1,3}\.[0-9]{1,3}\.[0-9]{1,3}/32$`,
+		},
+		"escaped braces": {
+			pattern: "{{{digits:2}}}",
+			want:    `^\{[0-9]{2}\}$`,
+		},
+		"unknown placeholder": {
+			pattern:   "{mac}",
+			wantError: true,
+		},
+		"missing length": {
+			pattern:   "{hex}",
+			wantError: true,
+		},
+		"invalid length": {
+			pattern:   "{hex:0}",
+			wantError: true,
+		},
+		"unterminated": {
+			pattern:   "{hex:4",
+			wantError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NewMockValueComposer(42).ComposeByPattern(test.pattern)
+			if test.wantError {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !regexp.MustCompile(test.want).MatchString(got) {
+				t.Errorf("%q does not match %s", got, test.want)
+			}
+
+			again, _ := NewMockValueComposer(42).ComposeByPattern(test.pattern)
+			if again != got {
+				t.Errorf("expected the same value for the same seed, got %q and %q", got, again)
+			}
+		})
+	}
+}
@@ -45,6 +45,7 @@ type Provider struct {
 	// IsMocked indicates if this provider has been mocked. It is used in
 	// testing framework to instantiate test provider wrapper.
 	IsMocked          bool
+	MockSeed          int64
 	MockResources     []*MockResource
 	OverrideResources []*OverrideResource
 
@@ -7,6 +7,8 @@ package configs
 
 import (
 	"fmt"
+	"path/filepath"
+	"regexp"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
@@ -75,6 +77,15 @@ type TestFile struct {
 	// with Providers map to use later when instantiating provider instance.
 	MockProviders map[string]*MockProvider
 
+	// Fixtures defines modules that are applied once before any test file
+	// executes, and destroyed once all test files have executed. Fixtures
+	// are shared by every test file, regardless of where they are declared.
+	Fixtures []*TestFixture
+
+	// Properties defines input variables to check with generated values,
+	// after the run blocks have executed.
+	Properties []*TestProperty
+
 	VariablesDeclRange hcl.Range
 }
 
@@ -176,6 +187,11 @@ type TestRun struct {
 	// run.
 	ExpectFailures []hcl.Traversal
 
+	// ExpectErrors is a list of errors that are expected to be reported while
+	// executing this test run. Unlike ExpectFailures, these can match any
+	// error diagnostic, such as those returned by providers.
+	ExpectErrors []*TestRunExpectedError
+
 	// OverrideResources is a list of resources to be overridden with static values.
 	// Underlying providers shouldn't be called for overridden resources.
 	OverrideResources []*OverrideResource
@@ -184,11 +200,80 @@ type TestRun struct {
 	// Underlying modules shouldn't be called.
 	OverrideModules []*OverrideModule
 
+	// Snapshot, if set, describes a snapshot of the plan and outputs of this
+	// run block that should be compared against a golden file.
+	Snapshot *TestRunSnapshot
+
+	// ForEach is the for_each argument of this run block, if set. Run blocks
+	// with a for_each argument are expanded into one run block per element
+	// when the test file is loaded, so this is only set while decoding.
+	ForEach hcl.Expression
+
+	// Each is set for run blocks that were expanded from a run block with a
+	// for_each argument, and describes the element this run block is for.
+	// The each object is available to the variables and assertions of these
+	// run blocks.
+	Each *TestRunEach
+
+	// Parallel is true if this run block may be executed concurrently with
+	// any adjacent run blocks that also set it. Parallel run blocks execute
+	// against their own, initially empty, state instead of sharing the state
+	// of earlier run blocks.
+	Parallel bool
+
 	NameDeclRange      hcl.Range
 	VariablesDeclRange hcl.Range
 	DeclRange          hcl.Range
 }
 
+// TestRunExpectedError describes an expect_error block within a run block.
+// An error diagnostic matches the block if it matches every one of the
+// criteria that are set.
+type TestRunExpectedError struct {
+	// Summary and Detail are regular expressions that must match part of
+	// the summary and detail of the diagnostic.
+	Summary *regexp.Regexp
+	Detail  *regexp.Regexp
+
+	// Filename and Line, if set, must match the start of the source range
+	// the diagnostic refers to. Filename is relative to the directory that
+	// contains the configuration under test.
+	Filename string
+	Line     int
+
+	DeclRange hcl.Range
+}
+
+// TestRunSnapshot describes the snapshot block within a run block, which
+// compares the rendered plan and selected outputs of the run block against a
+// golden file stored alongside the test file.
+type TestRunSnapshot struct {
+	// Plan is true if the resource changes in the plan should be included in
+	// the snapshot. Defaults to true.
+	Plan bool
+
+	// Outputs lists the root module outputs that should be included in the
+	// snapshot, as references like output.name.
+	Outputs []hcl.Traversal
+
+	// Path is the location of the golden file, relative to the directory
+	// containing the test file. It can't be absolute or refer to a parent
+	// directory. If empty, a path is derived from the names of the test file
+	// and run block.
+	Path string
+
+	DeclRange hcl.Range
+}
+
+// OutputNames returns the names of the outputs referenced in Outputs.
+func (s *TestRunSnapshot) OutputNames() []string {
+	var names []string
+	for _, traversal := range s.Outputs {
+		names = append(names, traversal[1].(hcl.TraverseAttr).Name)
+	}
+	return names
+}
+
 // Validate does a very simple and cursory check across the run block to look
 // for simple issues we can highlight early on.
 func (run *TestRun) Validate() tfdiags.Diagnostics {
@@ -320,6 +405,11 @@ type MockProvider struct {
 
 	// Fields below are specific to configs.MockProvider:
 
+	// Seed is mixed into the seed used to generate mock values, so that
+	// different values can be generated for the same resource types. Zero
+	// keeps the values generated when no seed is given.
+	Seed int64
+
 	MockResources     []*MockResource
 	OverrideResources []*OverrideResource
 }
@@ -395,6 +485,11 @@ type MockResource struct {
 	Mode     addrs.ResourceMode
 	Type     string
 	Defaults map[string]cty.Value
+
+	// DefaultsExpr is set instead of Defaults if the defaults refer to the
+	// planned values of the resource as self, or call functions. It is then
+	// evaluated each time a resource of this type is planned or read.
+	DefaultsExpr hcl.Expression
 }
 
 func (r MockResource) getBlockName() string {
@@ -427,7 +522,9 @@ func loadTestFile(body hcl.Body) (*TestFile, hcl.Diagnostics) {
 			run, runDiags := decodeTestRunBlock(block)
 			diags = append(diags, runDiags...)
 			if !runDiags.HasErrors() {
-				tf.Runs = append(tf.Runs, run)
+				runs, expandDiags := expandTestRun(run)
+				diags = append(diags, expandDiags...)
+				tf.Runs = append(tf.Runs, runs...)
 			}
 
 		case "variables":
@@ -457,6 +554,30 @@ func loadTestFile(body hcl.Body) (*TestFile, hcl.Diagnostics) {
 				tf.Providers[provider.moduleUniqueKey()] = provider
 			}
 
+		case "fixture":
+			fixture, fixtureDiags := decodeTestFixtureBlock(block)
+			diags = append(diags, fixtureDiags...)
+			if !fixtureDiags.HasErrors() {
+				tf.Fixtures = append(tf.Fixtures, fixture)
+			}
+
+		case "property":
+			property, propertyDiags := decodeTestPropertyBlock(block)
+			diags = append(diags, propertyDiags...)
+			if !propertyDiags.HasErrors() {
+				for _, existing := range tf.Properties {
+					if existing.Name == property.Name {
+						diags = append(diags, &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "Duplicate property block",
+							Detail:   fmt.Sprintf("This test file already has a property block named %q defined at %s.", property.Name, existing.DeclRange),
+							Subject:  property.NameDeclRange.Ptr(),
+						})
+					}
+				}
+				tf.Properties = append(tf.Properties, property)
+			}
+
 		case blockNameOverrideResource, blockNameOverrideData:
 			overrideRes, overrideResDiags := decodeOverrideResourceBlock(block)
 			diags = append(diags, overrideResDiags...)
@@ -575,6 +696,30 @@ func decodeTestRunBlock(block *hcl.Block) (*TestRun, hcl.Diagnostics) {
 				r.Module = module
 			}
 
+		case "expect_error":
+			expected, expectedDiags := decodeTestRunExpectedErrorBlock(block)
+			diags = append(diags, expectedDiags...)
+			if !expectedDiags.HasErrors() {
+				r.ExpectErrors = append(r.ExpectErrors, expected)
+			}
+
+		case "snapshot":
+			if r.Snapshot != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple \"snapshot\" blocks",
+					Detail:   fmt.Sprintf("This run block already has a snapshot block defined at %s.", r.Snapshot.DeclRange),
+					Subject:  block.DefRange.Ptr(),
+				})
+				continue
+			}
+
+			snapshot, snapshotDiags := decodeTestRunSnapshotBlock(block)
+			diags = append(diags, snapshotDiags...)
+			if !snapshotDiags.HasErrors() {
+				r.Snapshot = snapshot
+			}
+
 		case blockNameOverrideResource, blockNameOverrideData:
 			overrideRes, overrideResDiags := decodeOverrideResourceBlock(block)
 			diags = append(diags, overrideResDiags...)
@@ -637,9 +782,148 @@ func decodeTestRunBlock(block *hcl.Block) (*TestRun, hcl.Diagnostics) {
 		r.ExpectFailures = failures
 	}
 
+	if attr, exists := content.Attributes["parallel"]; exists {
+		diags = append(diags, gohcl.DecodeExpression(attr.Expr, nil, &r.Parallel)...)
+	}
+
+	if attr, exists := content.Attributes["for_each"]; exists {
+		r.ForEach = attr.Expr
+	}
+
 	return &r, diags
 }
 
+func decodeTestRunExpectedErrorBlock(block *hcl.Block) (*TestRunExpectedError, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	content, contentDiags := block.Body.Content(testRunExpectedErrorBlockSchema)
+	diags = append(diags, contentDiags...)
+
+	expected := TestRunExpectedError{
+		DeclRange: block.DefRange,
+	}
+
+	decodeRegexp := func(name string) *regexp.Regexp {
+		attr, exists := content.Attributes[name]
+		if !exists {
+			return nil
+		}
+
+		var pattern string
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &pattern)
+		diags = append(diags, valDiags...)
+		if valDiags.HasErrors() {
+			return nil
+		}
+
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid regular expression",
+				Detail:   fmt.Sprintf("The %s argument must be a valid regular expression: %s.", name, err),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+			return nil
+		}
+		return re
+	}
+	expected.Summary = decodeRegexp("summary")
+	expected.Detail = decodeRegexp("detail")
+
+	if attr, exists := content.Attributes["filename"]; exists {
+		diags = append(diags, gohcl.DecodeExpression(attr.Expr, nil, &expected.Filename)...)
+		expected.Filename = filepath.ToSlash(filepath.Clean(expected.Filename))
+	}
+
+	if attr, exists := content.Attributes["line"]; exists {
+		diags = append(diags, gohcl.DecodeExpression(attr.Expr, nil, &expected.Line)...)
+		if _, hasFilename := content.Attributes["filename"]; !hasFilename {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing filename",
+				Detail:   "The line argument can only be used together with the filename argument.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	if _, hasSummary := content.Attributes["summary"]; !hasSummary {
+		if _, hasDetail := content.Attributes["detail"]; !hasDetail {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing expected error message",
+				Detail:   "An expect_error block must set the summary argument, the detail argument, or both.",
+				Subject:  block.DefRange.Ptr(),
+			})
+		}
+	}
+
+	return &expected, diags
+}
+
+func decodeTestRunSnapshotBlock(block *hcl.Block) (*TestRunSnapshot, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	content, contentDiags := block.Body.Content(testRunSnapshotBlockSchema)
+	diags = append(diags, contentDiags...)
+
+	snapshot := TestRunSnapshot{
+		Plan:      true,
+		DeclRange: block.DefRange,
+	}
+
+	if attr, exists := content.Attributes["plan"]; exists {
+		diags = append(diags, gohcl.DecodeExpression(attr.Expr, nil, &snapshot.Plan)...)
+	}
+
+	if attr, exists := content.Attributes["path"]; exists {
+		diags = append(diags, gohcl.DecodeExpression(attr.Expr, nil, &snapshot.Path)...)
+		if snapshot.Path != "" && !filepath.IsLocal(snapshot.Path) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid snapshot path",
+				Detail:   "The snapshot path must be a relative path within the directory containing the test file.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	if attr, exists := content.Attributes["outputs"]; exists {
+		exprs, exprDiags := hcl.ExprList(attr.Expr)
+		diags = append(diags, exprDiags...)
+		for _, expr := range exprs {
+			traversal, traversalDiags := hcl.AbsTraversalForExpr(expr)
+			diags = append(diags, traversalDiags...)
+			if traversalDiags.HasErrors() {
+				continue
+			}
+
+			if _, ok := traversal[len(traversal)-1].(hcl.TraverseAttr); len(traversal) != 2 || traversal.RootName() != "output" || !ok {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid snapshot output",
+					Detail:   "Each element of the outputs list must be a reference to a root module output, such as output.name.",
+					Subject:  expr.Range().Ptr(),
+				})
+				continue
+			}
+			snapshot.Outputs = append(snapshot.Outputs, traversal)
+		}
+	}
+
+	if !snapshot.Plan && len(snapshot.Outputs) == 0 {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Empty snapshot",
+			Detail:   "A snapshot block must include the plan, at least one output, or both.",
+			Subject:  block.DefRange.Ptr(),
+		})
+	}
+
+	return &snapshot, diags
+}
+
 func decodeTestRunModuleBlock(block *hcl.Block) (*TestRunModuleCall, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 
@@ -910,6 +1194,11 @@ func decodeMockProviderBlock(block *hcl.Block) (*MockProvider, hcl.Diagnostics)
 		}
 	}
 
+	if attr, exists := content.Attributes["seed"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &provider.Seed)
+		diags = append(diags, valDiags...)
+	}
+
 	for _, block := range content.Blocks {
 		switch block.Type {
 		case blockNameMockData, blockNameMockResource:
@@ -953,13 +1242,52 @@ func decodeMockResourceBlock(block *hcl.Block) (*MockResource, hcl.Diagnostics)
 	content, diags := block.Body.Content(mockResourceBlockSchema)
 
 	if attr, exists := content.Attributes["defaults"]; exists {
-		v, moreDiags := parseObjectAttrWithNoVariables(attr)
-		res.Defaults, diags = v, append(diags, moreDiags...)
+		if !isDynamicMockDefaults(attr.Expr) {
+			v, moreDiags := parseObjectAttrWithNoVariables(attr)
+			res.Defaults, diags = v, append(diags, moreDiags...)
+			return res, diags
+		}
+
+		for _, traversal := range attr.Expr.Variables() {
+			if traversal.RootName() == "self" {
+				continue
+			}
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid reference in mock defaults",
+				Detail:   "The defaults of a mock resource can only refer to the planned values of the resource, as self (e.g. self.name).",
+				Subject:  traversal.SourceRange().Ptr(),
+			})
+		}
+		res.DefaultsExpr = attr.Expr
 	}
 
 	return res, diags
 }
 
+// isDynamicMockDefaults returns true if the given defaults expression refers
+// to variables or calls functions, so it can't be evaluated when the test file
+// is loaded.
+func isDynamicMockDefaults(expr hcl.Expression) bool {
+	if len(expr.Variables()) > 0 {
+		return true
+	}
+
+	syntaxExpr, ok := expr.(hclsyntax.Expression)
+	if !ok {
+		return false
+	}
+
+	hasCall := false
+	_ = hclsyntax.VisitAll(syntaxExpr, func(node hclsyntax.Node) hcl.Diagnostics {
+		if _, ok := node.(*hclsyntax.FunctionCallExpr); ok {
+			hasCall = true
+		}
+		return nil
+	})
+	return hasCall
+}
+
 func parseObjectAttrWithNoVariables(attr *hcl.Attribute) (map[string]cty.Value, hcl.Diagnostics) {
 	attrVal, valDiags := attr.Expr.Value(nil)
 	diags := valDiags
@@ -1055,6 +1383,34 @@ var testFileSchema = &hcl.BodySchema{
 			Type:       blockNameMockProvider,
 			LabelNames: []string{"name"},
 		},
+		{
+			// fixture block defines a module shared by all the test files.
+			Type:       "fixture",
+			LabelNames: []string{"name"},
+		},
+		{
+			// property block defines an input variable to check with
+			// generated values.
+			Type:       "property",
+			LabelNames: []string{"name"},
+		},
+	},
+}
+
+var testRunExpectedErrorBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "summary"},
+		{Name: "detail"},
+		{Name: "filename"},
+		{Name: "line"},
+	},
+}
+
+var testRunSnapshotBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "plan"},
+		{Name: "outputs"},
+		{Name: "path"},
 	},
 }
 
@@ -1068,6 +1424,10 @@ var testRunBlockSchema = &hcl.BodySchema{
 		{Name: "providers"},
 		// expect_failures indicates whether test failures are expected.
 		{Name: "expect_failures"},
+		// parallel allows the run block to execute concurrently with adjacent parallel run blocks.
+		{Name: "parallel"},
+		// for_each expands the run block into one run block per element.
+		{Name: "for_each"},
 	},
 	Blocks: []hcl.BlockHeaderSchema{
 		{
@@ -1086,6 +1446,14 @@ var testRunBlockSchema = &hcl.BodySchema{
 			// module block specifies the module to be tested.
 			Type: "module",
 		},
+		{
+			// snapshot block compares the plan and outputs against a golden file.
+			Type: "snapshot",
+		},
+		{
+			// expect_error block describes an error that the run block is expected to report.
+			Type: "expect_error",
+		},
 		{
 			Type: blockNameOverrideResource,
 		},
@@ -1156,6 +1524,10 @@ var mockProviderBlockSchema = &hcl.BodySchema{
 			Name:     "alias",
 			Required: false,
 		},
+		{
+			Name:     "seed",
+			Required: false,
+		},
 	},
 	Blocks: []hcl.BlockHeaderSchema{
 		{
@@ -178,3 +178,330 @@ func TestDecodeTestRunModuleBlock(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeTestRunBlock_expectError(t *testing.T) {
+	tcs := map[string]struct {
+		src      string
+		wantDiag string
+	}{
+		"summary": {
+			src: `run "test" {
+  expect_error {
+    summary = "^Invalid value"
+  }
+}`,
+		},
+		"all": {
+			src: `run "test" {
+  expect_error {
+    summary  = "Invalid"
+    detail   = "required"
+    filename = "./main.tf"
+    line     = 3
+  }
+}`,
+		},
+		"empty": {
+			src: `run "test" {
+  expect_error {}
+}`,
+			wantDiag: "Missing expected error message",
+		},
+		"invalid regexp": {
+			src: `run "test" {
+  expect_error {
+    summary = "("
+  }
+}`,
+			wantDiag: "Invalid regular expression",
+		},
+		"line without filename": {
+			src: `run "test" {
+  expect_error {
+    summary = "Invalid"
+    line    = 3
+  }
+}`,
+			wantDiag: "Missing filename",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			f, diags := hclsyntax.ParseConfig([]byte(tc.src), "main.tftest.hcl", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+			content, diags := f.Body.Content(testFileSchema)
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+
+			run, diags := decodeTestRunBlock(content.Blocks[0])
+			if tc.wantDiag != "" {
+				if !diags.HasErrors() || diags[0].Summary != tc.wantDiag {
+					t.Fatalf("expected %q error, got: %s", tc.wantDiag, diags)
+				}
+				return
+			}
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+			if len(run.ExpectErrors) != 1 {
+				t.Fatalf("expected 1 expect_error block, got %d", len(run.ExpectErrors))
+			}
+			if name == "all" {
+				got := run.ExpectErrors[0]
+				if got.Filename != "main.tf" || got.Line != 3 || got.Detail.String() != "required" {
+					t.Errorf("wrong expect_error block: %#v", got)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeMockProviderBlock_defaults(t *testing.T) {
+	tcs := map[string]struct {
+		src         string
+		wantDynamic bool
+		wantDiag    string
+	}{
+		"static": {
+			src: `mock_provider "aws" {
+  mock_resource "aws_instance" {
+    defaults = {
+      arn = "arn:aws:ec2:us-east-1:123456789012:instance/i-1234"
+    }
+  }
+}`,
+		},
+		"self": {
+			src: `mock_provider "aws" {
+  mock_resource "aws_instance" {
+    defaults = {
+      arn = "arn:aws:ec2:us-east-1:123456789012:instance/${self.name}"
+    }
+  }
+}`,
+			wantDynamic: true,
+		},
+		"pattern": {
+			src: `mock_provider "aws" {
+  seed = 7
+
+  mock_resource "aws_instance" {
+    defaults = {
+      id = pattern("i-{hex:17}")
+    }
+  }
+}`,
+			wantDynamic: true,
+		},
+		"invalid reference": {
+			src: `mock_provider "aws" {
+  mock_resource "aws_instance" {
+    defaults = {
+      id = var.id
+    }
+  }
+}`,
+			wantDiag: "Invalid reference in mock defaults",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			f, diags := hclsyntax.ParseConfig([]byte(tc.src), "main.tftest.hcl", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+			content, diags := f.Body.Content(testFileSchema)
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+
+			provider, diags := decodeMockProviderBlock(content.Blocks[0])
+			if tc.wantDiag != "" {
+				if !diags.HasErrors() || diags[0].Summary != tc.wantDiag {
+					t.Fatalf("expected %q error, got: %s", tc.wantDiag, diags)
+				}
+				return
+			}
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+
+			res := provider.MockResources[0]
+			if gotDynamic := res.DefaultsExpr != nil; gotDynamic != tc.wantDynamic {
+				t.Errorf("expected dynamic defaults to be %t, got %t", tc.wantDynamic, gotDynamic)
+			}
+			if !tc.wantDynamic && len(res.Defaults) != 1 {
+				t.Errorf("expected 1 static default, got %#v", res.Defaults)
+			}
+			if name == "pattern" && provider.Seed != 7 {
+				t.Errorf("expected seed 7, got %d", provider.Seed)
+			}
+		})
+	}
+}
+
+func TestDecodeTestRunBlock_parallel(t *testing.T) {
+	tcs := map[string]struct {
+		src  string
+		want bool
+	}{
+		"default": {
+			src:  `run "test" {}`,
+			want: false,
+		},
+		"parallel": {
+			src:  `run "test" { parallel = true }`,
+			want: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			f, diags := hclsyntax.ParseConfig([]byte(tc.src), "main.tftest.hcl", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+			content, diags := f.Body.Content(testFileSchema)
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+
+			run, diags := decodeTestRunBlock(content.Blocks[0])
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+			if run.Parallel != tc.want {
+				t.Errorf("got parallel %t; want %t", run.Parallel, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeTestRunSnapshotBlock_path(t *testing.T) {
+	tcs := map[string]struct {
+		path    string
+		wantErr bool
+	}{
+		"relative": {
+			path: "golden/plan.json",
+		},
+		"cleaned": {
+			path: "golden/../plan.json",
+		},
+		"absolute": {
+			path:    "/tmp/plan.json",
+			wantErr: true,
+		},
+		"parent": {
+			path:    "../plan.json",
+			wantErr: true,
+		},
+		"nested parent": {
+			path:    "golden/../../plan.json",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			src := fmt.Sprintf("run \"test\" {\n  snapshot {\n    path = %q\n  }\n}\n", tc.path)
+			f, diags := hclsyntax.ParseConfig([]byte(src), "main.tftest.hcl", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+			content, diags := f.Body.Content(testFileSchema)
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+
+			_, diags = decodeTestRunBlock(content.Blocks[0])
+			if got := diags.HasErrors(); got != tc.wantErr {
+				t.Errorf("got errors %t; want %t: %s", got, tc.wantErr, diags)
+			}
+			if tc.wantErr && diags.HasErrors() && diags[0].Summary != "Invalid snapshot path" {
+				t.Errorf("unexpected error: %s", diags[0].Summary)
+			}
+		})
+	}
+}
+
+func TestDecodeTestPropertyBlock(t *testing.T) {
+	tcs := map[string]struct {
+		src      string
+		wantDiag string
+	}{
+		"minimal": {
+			src: `property "port" {
+  variable = var.port
+}`,
+		},
+		"all": {
+			src: `property "port" {
+  variable   = var.port
+  iterations = 10
+  seed       = 42
+
+  variables {
+    name = "web"
+  }
+
+  assert {
+    condition     = validation.valid
+    error_message = "invalid"
+  }
+}`,
+		},
+		"not a variable": {
+			src: `property "port" {
+  variable = local.port
+}`,
+			wantDiag: "Invalid property variable",
+		},
+		"no iterations": {
+			src: `property "port" {
+  variable   = var.port
+  iterations = 0
+}`,
+			wantDiag: "Invalid property iterations",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			f, diags := hclsyntax.ParseConfig([]byte(tc.src), "main.tftest.hcl", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+			content, diags := f.Body.Content(testFileSchema)
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+
+			property, diags := decodeTestPropertyBlock(content.Blocks[0])
+			if tc.wantDiag != "" {
+				if !diags.HasErrors() || diags[0].Summary != tc.wantDiag {
+					t.Fatalf("expected %q error, got: %s", tc.wantDiag, diags)
+				}
+				return
+			}
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+			if property.Variable != "port" {
+				t.Errorf("expected variable port, got %q", property.Variable)
+			}
+			if name == "all" {
+				if property.Iterations != 10 || property.Seed != 42 || len(property.Variables) != 1 || len(property.CheckRules) != 1 {
+					t.Errorf("wrong property block: %#v", property)
+				}
+			} else if property.Iterations != DefaultTestPropertyIterations {
+				t.Errorf("expected %d iterations, got %d", DefaultTestPropertyIterations, property.Iterations)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package configs
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// TestFixture represents a fixture block within a test file.
+//
+// A fixture is a module that is applied once before any test file executes,
+// and destroyed once every test file has executed. Its outputs are available
+// to all the test files as fixture.<name>.<output>.
+type TestFixture struct {
+	Name string
+
+	// Module is the module to apply for this fixture.
+	Module *TestRunModuleCall
+
+	// Variables are the values for the input variables of the module. They
+	// can refer to global variables and to the outputs of fixtures declared
+	// before this one.
+	Variables map[string]hcl.Expression
+
+	// ConfigUnderTest is the configuration of the module, loaded alongside
+	// the test modules of the run blocks.
+	ConfigUnderTest *Config
+
+	NameDeclRange hcl.Range
+	DeclRange     hcl.Range
+}
+
+// TestRun returns a run block that applies the module of this fixture, so
+// the fixture can be executed like any other run block.
+func (f *TestFixture) TestRun() *TestRun {
+	return &TestRun{
+		Name:      fmt.Sprintf("fixture.%s", f.Name),
+		Command:   ApplyTestCommand,
+		Variables: f.Variables,
+		Module:    f.Module,
+		Options: &TestRunOptions{
+			Mode:    NormalTestMode,
+			Refresh: true,
+		},
+		ConfigUnderTest: f.ConfigUnderTest,
+		NameDeclRange:   f.NameDeclRange,
+		DeclRange:       f.DeclRange,
+	}
+}
+
+func decodeTestFixtureBlock(block *hcl.Block) (*TestFixture, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	content, contentDiags := block.Body.Content(testFixtureBlockSchema)
+	diags = append(diags, contentDiags...)
+
+	fixture := TestFixture{
+		Name:          block.Labels[0],
+		Variables:     make(map[string]hcl.Expression),
+		NameDeclRange: block.LabelRanges[0],
+		DeclRange:     block.DefRange,
+	}
+
+	if !hclsyntax.ValidIdentifier(fixture.Name) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid fixture block name",
+			Detail:   badIdentifierDetail,
+			Subject:  &block.LabelRanges[0],
+		})
+	}
+
+	var variablesDeclRange *hcl.Range
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "module":
+			if fixture.Module != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple \"module\" blocks",
+					Detail:   fmt.Sprintf("This fixture block already has a module block defined at %s.", fixture.Module.DeclRange),
+					Subject:  block.DefRange.Ptr(),
+				})
+				continue
+			}
+
+			module, moduleDiags := decodeTestRunModuleBlock(block)
+			diags = append(diags, moduleDiags...)
+			if !moduleDiags.HasErrors() {
+				fixture.Module = module
+			}
+
+		case "variables":
+			if variablesDeclRange != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple \"variables\" blocks",
+					Detail:   fmt.Sprintf("This fixture block already has a variables block defined at %s.", variablesDeclRange),
+					Subject:  block.DefRange.Ptr(),
+				})
+				continue
+			}
+			variablesDeclRange = block.DefRange.Ptr()
+
+			vars, varsDiags := block.Body.JustAttributes()
+			diags = append(diags, varsDiags...)
+			for _, v := range vars {
+				fixture.Variables[v.Name] = v.Expr
+			}
+		}
+	}
+
+	if fixture.Module == nil && !diags.HasErrors() {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing \"module\" block",
+			Detail:   "A fixture block must have a module block that defines the module to apply.",
+			Subject:  block.DefRange.Ptr(),
+		})
+	}
+
+	return &fixture, diags
+}
+
+var testFixtureBlockSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			// module block defines the module to apply for the fixture.
+			Type: "module",
+		},
+		{
+			// variables block provides the input variables of the module.
+			Type: "variables",
+		},
+	},
+}
@@ -0,0 +1,159 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package configs
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// DefaultTestPropertyIterations is the number of values generated for a
+// property block that doesn't set the iterations attribute.
+const DefaultTestPropertyIterations = 100
+
+// TestProperty represents a property block within a test file.
+//
+// A property generates random values for an input variable of the module
+// under test, according to its type constraint, and checks each of them
+// against the validation rules of the variable, the preconditions of the
+// module that only refer to input variables, and the assertions of the
+// property block.
+type TestProperty struct {
+	Name string
+
+	// Variable is the name of the input variable to generate values for.
+	Variable      string
+	VariableRange hcl.Range
+
+	// Iterations is the number of values to generate.
+	Iterations int
+
+	// Seed is the seed of the random values, so that the generated values
+	// are the same every time the property is checked.
+	Seed int64
+
+	// Variables are the values of the other input variables of the module,
+	// which the validation rules and preconditions may refer to.
+	Variables map[string]hcl.Expression
+
+	// CheckRules are the invariants that must hold for every generated
+	// value. They can refer to the generated value as var.<name>, and to
+	// whether it was accepted by the validation rules as validation.valid.
+	CheckRules []*CheckRule
+
+	NameDeclRange hcl.Range
+	DeclRange     hcl.Range
+}
+
+func decodeTestPropertyBlock(block *hcl.Block) (*TestProperty, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	content, contentDiags := block.Body.Content(testPropertyBlockSchema)
+	diags = append(diags, contentDiags...)
+
+	property := TestProperty{
+		Name:          block.Labels[0],
+		Iterations:    DefaultTestPropertyIterations,
+		Variables:     make(map[string]hcl.Expression),
+		NameDeclRange: block.LabelRanges[0],
+		DeclRange:     block.DefRange,
+	}
+
+	if !hclsyntax.ValidIdentifier(property.Name) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid property block name",
+			Detail:   badIdentifierDetail,
+			Subject:  &block.LabelRanges[0],
+		})
+	}
+
+	if attr, exists := content.Attributes["variable"]; exists {
+		property.VariableRange = attr.Expr.Range()
+
+		traversal, travDiags := hcl.AbsTraversalForExpr(attr.Expr)
+		if !travDiags.HasErrors() && len(traversal) == 2 && traversal.RootName() == "var" {
+			if step, ok := traversal[1].(hcl.TraverseAttr); ok {
+				property.Variable = step.Name
+			}
+		}
+		if property.Variable == "" {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid property variable",
+				Detail:   "The variable argument must be a reference to an input variable of the module under test, such as var.name.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	if attr, exists := content.Attributes["iterations"]; exists {
+		diags = append(diags, gohcl.DecodeExpression(attr.Expr, nil, &property.Iterations)...)
+		if property.Iterations < 1 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid property iterations",
+				Detail:   "The iterations argument must be at least 1.",
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	if attr, exists := content.Attributes["seed"]; exists {
+		diags = append(diags, gohcl.DecodeExpression(attr.Expr, nil, &property.Seed)...)
+	}
+
+	var variablesDeclRange *hcl.Range
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "assert":
+			cr, crDiags := decodeCheckRuleBlock(block, false)
+			diags = append(diags, crDiags...)
+			if !crDiags.HasErrors() {
+				property.CheckRules = append(property.CheckRules, cr)
+			}
+
+		case "variables":
+			if variablesDeclRange != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple \"variables\" blocks",
+					Detail:   fmt.Sprintf("This property block already has a variables block defined at %s.", variablesDeclRange),
+					Subject:  block.DefRange.Ptr(),
+				})
+				continue
+			}
+			variablesDeclRange = block.DefRange.Ptr()
+
+			vars, varsDiags := block.Body.JustAttributes()
+			diags = append(diags, varsDiags...)
+			for _, v := range vars {
+				property.Variables[v.Name] = v.Expr
+			}
+		}
+	}
+
+	return &property, diags
+}
+
+var testPropertyBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "variable", Required: true},
+		{Name: "iterations"},
+		{Name: "seed"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			// assert block defines an invariant for every generated value.
+			Type: "assert",
+		},
+		{
+			// variables block provides the other input variables of the module.
+			Type: "variables",
+		},
+	},
+}
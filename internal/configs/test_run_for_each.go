@@ -0,0 +1,248 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package configs
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/opentofu/opentofu/internal/lang"
+)
+
+// TestRunEach describes the element of a for_each argument that a run block
+// was expanded for.
+type TestRunEach struct {
+	// Name is the name of the run block the for_each argument was declared
+	// in, without the key.
+	Name string
+
+	// Index is the position of this element within the expanded run blocks.
+	Index int
+
+	Key   string
+	Value cty.Value
+}
+
+// expandTestRun evaluates the for_each argument of the given run block, and
+// returns one copy of the run block for each element. The run block is
+// returned as is if it has no for_each argument.
+//
+// The for_each argument is evaluated when the test file is loaded, so it
+// can't refer to variables or to other run blocks, and can only call pure
+// functions.
+//
+// The each object is only available to the variables and assert blocks of
+// the expanded run blocks. The expect_failures and expect_errors arguments are
+// static references and patterns that are decoded before the for_each
+// argument is evaluated, so they are shared by every element unchanged.
+func expandTestRun(run *TestRun) ([]*TestRun, hcl.Diagnostics) {
+	if run.ForEach == nil {
+		return []*TestRun{run}, nil
+	}
+
+	var diags hcl.Diagnostics
+
+	invalid := func(detail string) hcl.Diagnostics {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid for_each argument",
+			Detail:   detail,
+			Subject:  run.ForEach.Range().Ptr(),
+		})
+	}
+
+	val, valDiags := run.ForEach.Value(&hcl.EvalContext{
+		Functions: (&lang.Scope{BaseDir: ".", PureOnly: true}).Functions(),
+	})
+	diags = append(diags, valDiags...)
+	if valDiags.HasErrors() {
+		// The expression can't refer to anything, so the diagnostics will
+		// already explain that.
+		return nil, diags
+	}
+	if !val.IsWhollyKnown() {
+		// Impure functions return unknown values when PureOnly is set, and
+		// that's the only way to get one here.
+		return nil, invalid("The for_each argument of a run block must be known when the test file is loaded, so it can't call functions such as timestamp or uuid.")
+	}
+	if val.IsNull() {
+		return nil, invalid("The for_each argument of a run block must not be null.")
+	}
+
+	type element struct {
+		key   string
+		value cty.Value
+	}
+	var elements []element
+
+	ty := val.Type()
+	switch {
+	case ty.IsMapType() || ty.IsObjectType():
+		for it := val.ElementIterator(); it.Next(); {
+			key, value := it.Element()
+			elements = append(elements, element{key.AsString(), value})
+		}
+	case ty.IsSetType() || ty.IsListType() || ty.IsTupleType():
+		seen := make(map[string]bool)
+		for it := val.ElementIterator(); it.Next(); {
+			_, value := it.Element()
+			if !value.Type().Equals(cty.String) || value.IsNull() {
+				return nil, invalid("The for_each argument of a run block must be a map, or a set or list of strings.")
+			}
+			key := value.AsString()
+			if seen[key] {
+				return nil, invalid(fmt.Sprintf("The for_each argument of a run block must not contain duplicate values, but %q appears more than once.", key))
+			}
+			seen[key] = true
+			elements = append(elements, element{key, value})
+		}
+	default:
+		return nil, invalid("The for_each argument of a run block must be a map, or a set or list of strings.")
+	}
+
+	runs := make([]*TestRun, 0, len(elements))
+	for ix, elem := range elements {
+		each := cty.ObjectVal(map[string]cty.Value{
+			"key":   cty.StringVal(elem.key),
+			"value": elem.value,
+		})
+
+		expanded := copyTestRun(run)
+		expanded.Name = fmt.Sprintf("%s[%q]", run.Name, elem.key)
+		expanded.ForEach = nil
+		expanded.Each = &TestRunEach{
+			Name:  run.Name,
+			Index: ix,
+			Key:   elem.key,
+			Value: elem.value,
+		}
+
+		expanded.Variables = make(map[string]hcl.Expression, len(run.Variables))
+		for name, expr := range run.Variables {
+			expanded.Variables[name] = &testRunEachExpr{Expression: expr, each: each}
+		}
+
+		expanded.CheckRules = make([]*CheckRule, len(run.CheckRules))
+		for ix, rule := range run.CheckRules {
+			expanded.CheckRules[ix] = &CheckRule{
+				Condition:    &testRunEachExpr{Expression: rule.Condition, each: each},
+				ErrorMessage: &testRunEachExpr{Expression: rule.ErrorMessage, each: each},
+				DeclRange:    rule.DeclRange,
+			}
+		}
+
+		runs = append(runs, expanded)
+	}
+	return runs, diags
+}
+
+// copyTestRun returns a copy of the given run block that shares no mutable
+// state with it, so that the run blocks expanded from a for_each argument can
+// be modified independently of each other, for example when the config under
+// test is loaded for each of them.
+func copyTestRun(run *TestRun) *TestRun {
+	ret := *run
+
+	if run.Options != nil {
+		options := *run.Options
+		options.Replace = slices.Clone(run.Options.Replace)
+		options.Target = slices.Clone(run.Options.Target)
+		ret.Options = &options
+	}
+
+	if run.Module != nil {
+		module := *run.Module
+		ret.Module = &module
+	}
+
+	if run.Snapshot != nil {
+		snapshot := *run.Snapshot
+		snapshot.Outputs = slices.Clone(run.Snapshot.Outputs)
+		ret.Snapshot = &snapshot
+	}
+
+	ret.Variables = maps.Clone(run.Variables)
+	ret.Providers = slices.Clone(run.Providers)
+	ret.ExpectFailures = slices.Clone(run.ExpectFailures)
+
+	if run.CheckRules != nil {
+		ret.CheckRules = make([]*CheckRule, len(run.CheckRules))
+		for ix, rule := range run.CheckRules {
+			copied := *rule
+			ret.CheckRules[ix] = &copied
+		}
+	}
+
+	if run.ExpectErrors != nil {
+		ret.ExpectErrors = make([]*TestRunExpectedError, len(run.ExpectErrors))
+		for ix, expected := range run.ExpectErrors {
+			copied := *expected
+			ret.ExpectErrors[ix] = &copied
+		}
+	}
+
+	if run.OverrideResources != nil {
+		ret.OverrideResources = make([]*OverrideResource, len(run.OverrideResources))
+		for ix, override := range run.OverrideResources {
+			copied := *override
+			copied.Values = maps.Clone(override.Values)
+			ret.OverrideResources[ix] = &copied
+		}
+	}
+
+	if run.OverrideModules != nil {
+		ret.OverrideModules = make([]*OverrideModule, len(run.OverrideModules))
+		for ix, override := range run.OverrideModules {
+			copied := *override
+			copied.Outputs = maps.Clone(override.Outputs)
+			ret.OverrideModules[ix] = &copied
+		}
+	}
+
+	if run.Each != nil {
+		each := *run.Each
+		ret.Each = &each
+	}
+
+	return &ret
+}
+
+// testRunEachExpr wraps an expression within a run block that was expanded
+// from a for_each argument, making the each object available to it.
+type testRunEachExpr struct {
+	hcl.Expression
+
+	each cty.Value
+}
+
+var _ hcl.Expression = (*testRunEachExpr)(nil)
+
+func (e *testRunEachExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
+	child := ctx.NewChild()
+	child.Variables = map[string]cty.Value{
+		"each": e.each,
+	}
+	return e.Expression.Value(child)
+}
+
+// Variables returns the variables referenced by the wrapped expression,
+// except for the references to each, which the expression resolves itself.
+func (e *testRunEachExpr) Variables() []hcl.Traversal {
+	var ret []hcl.Traversal
+	for _, traversal := range e.Expression.Variables() {
+		if traversal.RootName() == "each" {
+			continue
+		}
+		ret = append(ret, traversal)
+	}
+	return ret
+}
+
+func (e *testRunEachExpr) UnwrapExpression() hcl.Expression {
+	return e.Expression
+}
@@ -0,0 +1,200 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package configs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestLoadTestFile_forEach(t *testing.T) {
+	tcs := map[string]struct {
+		src       string
+		wantNames []string
+		wantDiag  string
+	}{
+		"map": {
+			src: `
+run "sizes" {
+  for_each = {
+    small = 1
+    large = 3
+  }
+
+  variables {
+    count = each.value
+  }
+}
+`,
+			wantNames: []string{`sizes["large"]`, `sizes["small"]`},
+		},
+		"list": {
+			src: `
+run "regions" {
+  for_each = ["us", "eu"]
+}
+`,
+			wantNames: []string{`regions["us"]`, `regions["eu"]`},
+		},
+		"duplicates": {
+			src: `
+run "regions" {
+  for_each = ["us", "us"]
+}
+`,
+			wantDiag: "Invalid for_each argument",
+		},
+		"functions": {
+			src: `
+run "regions" {
+  for_each = toset(["us", "eu", "us"])
+}
+
+run "sizes" {
+  for_each = tomap({
+    small = 1
+  })
+}
+`,
+			wantNames: []string{`regions["eu"]`, `regions["us"]`, `sizes["small"]`},
+		},
+		"impure functions": {
+			src: `
+run "regions" {
+  for_each = toset([uuid()])
+}
+`,
+			wantDiag: "Invalid for_each argument",
+		},
+		"references": {
+			src: `
+run "regions" {
+  for_each = var.regions
+}
+`,
+			wantDiag: "Variables not allowed",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			f, diags := hclsyntax.ParseConfig([]byte(tc.src), "main.tftest.hcl", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+
+			file, diags := loadTestFile(f.Body)
+			if tc.wantDiag != "" {
+				if !diags.HasErrors() || diags[0].Summary != tc.wantDiag {
+					t.Fatalf("expected %q error, got: %s", tc.wantDiag, diags)
+				}
+				return
+			}
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+
+			var names []string
+			for _, run := range file.Runs {
+				names = append(names, run.Name)
+			}
+			if diff := cmp.Diff(tc.wantNames, names); len(diff) > 0 {
+				t.Errorf("wrong run names:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadTestFile_forEachExpressions(t *testing.T) {
+	src := `
+run "sizes" {
+  for_each = {
+    small = 1
+  }
+
+  variables {
+    count = each.value + 1
+  }
+
+  assert {
+    condition     = each.key == "small"
+    error_message = "Wrong key ${each.key}."
+  }
+}
+`
+	f, diags := hclsyntax.ParseConfig([]byte(src), "main.tftest.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	file, diags := loadTestFile(f.Body)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	run := file.Runs[0]
+	if run.Each == nil || run.Each.Name != "sizes" || run.Each.Key != "small" {
+		t.Fatalf("wrong each: %#v", run.Each)
+	}
+
+	expr := run.Variables["count"]
+	if refs := expr.Variables(); len(refs) > 0 {
+		t.Errorf("expected each references to be hidden, got %#v", refs)
+	}
+	val, diags := expr.Value(&hcl.EvalContext{})
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	if !val.RawEquals(cty.NumberIntVal(2)) {
+		t.Errorf("wrong variable value %#v", val)
+	}
+
+	val, diags = run.CheckRules[0].Condition.Value(nil)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	if val.False() {
+		t.Errorf("expected the condition to be true")
+	}
+}
+
+func TestLoadTestFile_forEachCopies(t *testing.T) {
+	src := `
+run "regions" {
+  for_each = ["us", "eu"]
+
+  module {
+    source = "./setup"
+  }
+
+  snapshot {
+    outputs = [output.id]
+  }
+
+  expect_failures = [output.id]
+}
+`
+	f, diags := hclsyntax.ParseConfig([]byte(src), "main.tftest.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+	file, diags := loadTestFile(f.Body)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	us, eu := file.Runs[0], file.Runs[1]
+	if us.Module == eu.Module || us.Snapshot == eu.Snapshot || us.Options == eu.Options {
+		t.Fatalf("expanded run blocks share their nested blocks")
+	}
+
+	us.Snapshot.Outputs[0] = nil
+	us.ExpectFailures[0] = nil
+	if eu.Snapshot.Outputs[0] == nil || eu.ExpectFailures[0] == nil {
+		t.Errorf("modifying one expanded run block modified the other")
+	}
+}
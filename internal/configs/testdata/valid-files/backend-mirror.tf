@@ -0,0 +1,10 @@
+terraform {
+  backend "example" {
+    foo = "bar"
+
+    mirror "local" {
+      path  = "backup/terraform.tfstate"
+      async = true
+    }
+  }
+}
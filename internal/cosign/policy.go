@@ -0,0 +1,110 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+// Package cosign implements verification of the signatures that the "cosign"
+// tool from the Sigstore project attaches to artifacts in OCI registries.
+//
+// Only the subset of the cosign conventions needed to authenticate OpenTofu
+// provider and module packages is implemented here: "simple signing"
+// signatures stored under the tag that cosign derives from the signed
+// manifest's digest, verified either with a trusted public key or, for
+// "keyless" signatures, with a short-lived certificate issued by a trusted
+// certificate authority and logged in a trusted transparency log.
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+)
+
+// Policy describes which signatures are acceptable for an artifact.
+//
+// An artifact is considered to be verified if it has at least one signature
+// that was made either by one of the given public keys or by one of the given
+// keyless identities.
+type Policy struct {
+	// PublicKeys are the keys whose signatures are trusted.
+	PublicKeys []*PublicKey
+
+	// Identities are the keyless signing identities whose signatures are
+	// trusted. Keyless signatures can only be verified if TrustedRoot is
+	// also set.
+	Identities []Identity
+
+	// TrustedRoot describes the certificate authorities and transparency logs
+	// that are trusted to vouch for keyless signing identities.
+	TrustedRoot *TrustedRoot
+}
+
+// PublicKey is a public key that is trusted to sign artifacts.
+type PublicKey struct {
+	// ID is a fingerprint of the key, used to identify it in the UI.
+	ID string
+
+	key crypto.PublicKey
+}
+
+// ParsePublicKey parses a PEM-encoded public key, in the same format that
+// "cosign generate-key-pair" writes to its "cosign.pub" file.
+//
+// ECDSA, RSA, and Ed25519 keys are supported.
+func ParsePublicKey(src []byte) (*PublicKey, error) {
+	block, _ := pem.Decode(src)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM-encoded public key found")
+	}
+	if block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("unsupported PEM block type %q, expected \"PUBLIC KEY\"", block.Type)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		// okay
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+	sum := sha256.Sum256(block.Bytes)
+	return &PublicKey{
+		ID:  "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]),
+		key: key,
+	}, nil
+}
+
+// Identity describes a keyless signing identity, as recorded in the
+// certificate that a Sigstore certificate authority issues to the signer.
+type Identity struct {
+	// Issuer is the URL of the OpenID Connect issuer that authenticated the
+	// signer, such as "https://token.actions.githubusercontent.com".
+	Issuer string
+
+	// Subject is the exact identity of the signer, such as an email address
+	// or the URL of a CI workflow. If SubjectRegexp is set then Subject is
+	// ignored.
+	Subject string
+
+	// SubjectRegexp, if set, is a pattern that the identity of the signer
+	// must match in its entirety.
+	SubjectRegexp *regexp.Regexp
+}
+
+func (i Identity) matches(issuer, subject string) bool {
+	if issuer != i.Issuer {
+		return false
+	}
+	if i.SubjectRegexp != nil {
+		loc := i.SubjectRegexp.FindStringIndex(subject)
+		return loc != nil && loc[0] == 0 && loc[1] == len(subject)
+	}
+	return subject == i.Subject
+}
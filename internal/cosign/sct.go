@@ -0,0 +1,165 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package cosign
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// oidSCTList is the certificate extension that holds the signed certificate
+// timestamps embedded by the certificate authority, as described in
+// RFC 6962 section 3.3.
+var oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+const (
+	sctVersionV1           = 0
+	sctCertificateStamp    = 0
+	sctPrecertEntry        = 1
+	sctHashAlgorithmSHA256 = 4
+)
+
+// verifyEmbeddedSCT checks that the given certificate, issued by the given
+// issuer, embeds a signed certificate timestamp from one of the trusted
+// certificate transparency logs. This shows that the certificate authority
+// published the certificate, so that its owner can detect any certificate
+// issued for their identity without their knowledge.
+func (r *TrustedRoot) verifyEmbeddedSCT(cert, issuer *x509.Certificate) error {
+	var listSrc []byte
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSCTList) {
+			continue
+		}
+		if rest, err := asn1.Unmarshal(ext.Value, &listSrc); err != nil || len(rest) != 0 {
+			return fmt.Errorf("invalid signed certificate timestamp list")
+		}
+		break
+	}
+	if listSrc == nil {
+		return fmt.Errorf("signing certificate has no signed certificate timestamp")
+	}
+
+	// The timestamps sign the precertificate that the log was given, which
+	// is the certificate without the timestamps.
+	tbs, err := precertificateTBS(cert.RawTBSCertificate)
+	if err != nil {
+		return fmt.Errorf("invalid signing certificate: %w", err)
+	}
+	issuerKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+
+	list := cryptobyte.String(listSrc)
+	var scts cryptobyte.String
+	if !list.ReadUint16LengthPrefixed(&scts) || !list.Empty() {
+		return fmt.Errorf("invalid signed certificate timestamp list")
+	}
+	for !scts.Empty() {
+		var sct, extensions, sig cryptobyte.String
+		var version, hashAlgorithm, sigAlgorithm uint8
+		var logID []byte
+		var timestamp uint64
+		if !scts.ReadUint16LengthPrefixed(&sct) ||
+			!sct.ReadUint8(&version) ||
+			!sct.ReadBytes(&logID, sha256.Size) ||
+			!sct.ReadUint64(&timestamp) ||
+			!sct.ReadUint16LengthPrefixed(&extensions) ||
+			!sct.ReadUint8(&hashAlgorithm) ||
+			!sct.ReadUint8(&sigAlgorithm) ||
+			!sct.ReadUint16LengthPrefixed(&sig) ||
+			!sct.Empty() {
+			return fmt.Errorf("invalid signed certificate timestamp list")
+		}
+		if version != sctVersionV1 || hashAlgorithm != sctHashAlgorithmSHA256 {
+			continue
+		}
+		logKey, ok := r.ctlogKeys[hex.EncodeToString(logID)]
+		if !ok {
+			continue
+		}
+
+		var b cryptobyte.Builder
+		b.AddUint8(sctVersionV1)
+		b.AddUint8(sctCertificateStamp)
+		b.AddUint64(timestamp)
+		b.AddUint16(sctPrecertEntry)
+		b.AddBytes(issuerKeyHash[:])
+		b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(tbs)
+		})
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(extensions)
+		})
+		signed, err := b.Bytes()
+		if err != nil {
+			return err
+		}
+		if verifySignature(logKey, signed, sig) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("signing certificate has no valid signed certificate timestamp from a trusted certificate transparency log")
+}
+
+// precertificateTBS returns the given DER-encoded TBSCertificate without its
+// signed certificate timestamp list extension.
+func precertificateTBS(raw []byte) ([]byte, error) {
+	input := cryptobyte.String(raw)
+	var tbs cryptobyte.String
+	if !input.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) || !input.Empty() {
+		return nil, fmt.Errorf("malformed TBSCertificate")
+	}
+
+	extensionsTag := cryptobyte_asn1.Tag(3).Constructed().ContextSpecific()
+	var b cryptobyte.Builder
+	var err error
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		for !tbs.Empty() {
+			var elem cryptobyte.String
+			var tag cryptobyte_asn1.Tag
+			if !tbs.ReadAnyASN1Element(&elem, &tag) {
+				err = fmt.Errorf("malformed TBSCertificate")
+				return
+			}
+			if tag != extensionsTag {
+				b.AddBytes(elem)
+				continue
+			}
+
+			var wrapper, extensions cryptobyte.String
+			if !elem.ReadASN1(&wrapper, extensionsTag) || !wrapper.ReadASN1(&extensions, cryptobyte_asn1.SEQUENCE) {
+				err = fmt.Errorf("malformed extensions")
+				return
+			}
+			b.AddASN1(extensionsTag, func(b *cryptobyte.Builder) {
+				b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					for !extensions.Empty() {
+						var ext cryptobyte.String
+						if !extensions.ReadASN1Element(&ext, cryptobyte_asn1.SEQUENCE) {
+							err = fmt.Errorf("malformed extensions")
+							return
+						}
+						var content cryptobyte.String
+						var id asn1.ObjectIdentifier
+						if rest := ext; !rest.ReadASN1(&content, cryptobyte_asn1.SEQUENCE) || !content.ReadASN1ObjectIdentifier(&id) {
+							err = fmt.Errorf("malformed extensions")
+							return
+						}
+						if !id.Equal(oidSCTList) {
+							b.AddBytes(ext)
+						}
+					}
+				})
+			})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes()
+}
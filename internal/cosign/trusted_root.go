@@ -0,0 +1,114 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package cosign
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// TrustedRoot is the set of certificate authorities and transparency logs
+// that are trusted to vouch for keyless signatures.
+type TrustedRoot struct {
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+
+	// tlogKeys are the public keys of the trusted transparency logs, indexed
+	// by their log ID: the hex-encoded SHA256 digest of the DER encoding of
+	// the key.
+	tlogKeys map[string]crypto.PublicKey
+
+	// ctlogKeys are the public keys of the trusted certificate transparency
+	// logs, indexed by their log ID in the same way as tlogKeys.
+	ctlogKeys map[string]crypto.PublicKey
+}
+
+// trustedRootJSON is the subset of the Sigstore "trusted_root.json" format
+// that we rely on.
+type trustedRootJSON struct {
+	Tlogs                  []trustedRootLogJSON `json:"tlogs"`
+	Ctlogs                 []trustedRootLogJSON `json:"ctlogs"`
+	CertificateAuthorities []struct {
+		CertChain struct {
+			Certificates []struct {
+				RawBytes []byte `json:"rawBytes"`
+			} `json:"certificates"`
+		} `json:"certChain"`
+	} `json:"certificateAuthorities"`
+}
+
+// trustedRootLogJSON is the subset of a transparency log description in a
+// Sigstore trusted root document that we rely on.
+type trustedRootLogJSON struct {
+	PublicKey struct {
+		RawBytes []byte `json:"rawBytes"`
+	} `json:"publicKey"`
+}
+
+// ParseTrustedRoot parses a Sigstore trusted root document, in the format
+// used for the "trusted_root.json" file distributed by the Sigstore project's
+// TUF repository.
+//
+// The last certificate in the chain of each certificate authority is treated
+// as a trusted root, and any others as intermediates.
+func ParseTrustedRoot(src []byte) (*TrustedRoot, error) {
+	var raw trustedRootJSON
+	if err := json.Unmarshal(src, &raw); err != nil {
+		return nil, fmt.Errorf("invalid trusted root document: %w", err)
+	}
+
+	ret := &TrustedRoot{
+		roots:         x509.NewCertPool(),
+		intermediates: x509.NewCertPool(),
+		tlogKeys:      make(map[string]crypto.PublicKey),
+		ctlogKeys:     make(map[string]crypto.PublicKey),
+	}
+	for i, ca := range raw.CertificateAuthorities {
+		certs := ca.CertChain.Certificates
+		if len(certs) == 0 {
+			return nil, fmt.Errorf("certificate authority %d has no certificates", i)
+		}
+		for j, rawCert := range certs {
+			cert, err := x509.ParseCertificate(rawCert.RawBytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid certificate %d for certificate authority %d: %w", j, i, err)
+			}
+			if j == len(certs)-1 {
+				ret.roots.AddCert(cert)
+			} else {
+				ret.intermediates.AddCert(cert)
+			}
+		}
+	}
+	for i, tlog := range raw.Tlogs {
+		key, err := x509.ParsePKIXPublicKey(tlog.PublicKey.RawBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key for transparency log %d: %w", i, err)
+		}
+		sum := sha256.Sum256(tlog.PublicKey.RawBytes)
+		ret.tlogKeys[hex.EncodeToString(sum[:])] = key
+	}
+	if len(ret.tlogKeys) == 0 {
+		return nil, fmt.Errorf("trusted root does not include any transparency logs")
+	}
+	for i, ctlog := range raw.Ctlogs {
+		key, err := x509.ParsePKIXPublicKey(ctlog.PublicKey.RawBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key for certificate transparency log %d: %w", i, err)
+		}
+		sum := sha256.Sum256(ctlog.PublicKey.RawBytes)
+		ret.ctlogKeys[hex.EncodeToString(sum[:])] = key
+	}
+	if len(ret.ctlogKeys) == 0 {
+		return nil, fmt.Errorf("trusted root does not include any certificate transparency logs")
+	}
+	if len(raw.CertificateAuthorities) == 0 {
+		return nil, fmt.Errorf("trusted root does not include any certificate authorities")
+	}
+	return ret, nil
+}
@@ -0,0 +1,394 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package cosign
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// simpleSigningMediaType is the media type cosign uses for the layers
+	// of a signature manifest that each hold one signed payload.
+	simpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+	// simpleSigningPayloadType is the value cosign uses for the "type"
+	// property of the payload that describes the signed image.
+	simpleSigningPayloadType = "cosign container image signature"
+
+	signatureAnnotation   = "dev.cosignproject.cosign/signature"
+	certificateAnnotation = "dev.sigstore.cosign/certificate"
+	chainAnnotation       = "dev.sigstore.cosign/chain"
+	bundleAnnotation      = "dev.sigstore.cosign/bundle"
+
+	// maxSignatureManifestSize matches the limit OpenTofu uses for other
+	// manifests, which is the limit recommended by OCI Distribution.
+	maxSignatureManifestSize = 4 * 1024 * 1024
+
+	// maxPayloadSize is far larger than any reasonable simple signing payload,
+	// which is typically only a few hundred bytes.
+	maxPayloadSize = 1024 * 1024
+)
+
+var (
+	// oidIssuerV2 and oidIssuer are the certificate extensions that Fulcio
+	// uses to record the OpenID Connect issuer that authenticated the signer.
+	// The first is DER-encoded, while the older second one contains the raw
+	// string.
+	oidIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+	oidIssuer   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+)
+
+// Store is the subset of an OCI repository client needed to find and retrieve
+// the signatures for an artifact in the same repository.
+//
+// This intentionally matches a subset of the repository store interfaces used
+// by the OCI-based provider and module installers.
+type Store interface {
+	Resolve(ctx context.Context, tagName string) (ociv1.Descriptor, error)
+	Fetch(ctx context.Context, target ociv1.Descriptor) (io.ReadCloser, error)
+}
+
+// SignatureTag returns the tag name that cosign uses for the signatures of
+// the manifest with the given digest.
+func SignatureTag(manifestDigest digest.Digest) string {
+	return manifestDigest.Algorithm().String() + "-" + manifestDigest.Encoded() + ".sig"
+}
+
+// Verify checks that the manifest with the given digest has at least one
+// signature acceptable to the policy, returning a description of the signer
+// that is suitable for display in the UI.
+//
+// The signatures are retrieved from the given store, which must be the
+// repository that the manifest belongs to.
+func (p *Policy) Verify(ctx context.Context, store Store, manifestDigest digest.Digest) (string, error) {
+	tagName := SignatureTag(manifestDigest)
+	desc, err := store.Resolve(ctx, tagName)
+	if err != nil {
+		return "", fmt.Errorf("finding signatures in tag %q: %w", tagName, err)
+	}
+	manifestSrc, err := fetchVerifiedBlob(ctx, store, desc, maxSignatureManifestSize)
+	if err != nil {
+		return "", fmt.Errorf("fetching signature manifest: %w", err)
+	}
+	var manifest ociv1.Manifest
+	if err := json.Unmarshal(manifestSrc, &manifest); err != nil {
+		return "", fmt.Errorf("invalid signature manifest: %w", err)
+	}
+
+	var errs []error
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != simpleSigningMediaType {
+			continue // not a signature we know how to verify
+		}
+		signer, err := p.verifyLayer(ctx, store, layer, manifestDigest)
+		if err == nil {
+			return signer, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return "", fmt.Errorf("tag %q does not contain any cosign signatures", tagName)
+	}
+	return "", fmt.Errorf("no signature is acceptable: %w", errors.Join(errs...))
+}
+
+func (p *Policy) verifyLayer(ctx context.Context, store Store, layer ociv1.Descriptor, manifestDigest digest.Digest) (string, error) {
+	payload, err := fetchVerifiedBlob(ctx, store, layer, maxPayloadSize)
+	if err != nil {
+		return "", fmt.Errorf("fetching signed payload: %w", err)
+	}
+	if err := checkPayload(payload, manifestDigest); err != nil {
+		return "", err
+	}
+	sig, err := base64.StdEncoding.DecodeString(layer.Annotations[signatureAnnotation])
+	if err != nil || len(sig) == 0 {
+		return "", fmt.Errorf("signature layer %s has no valid signature annotation", layer.Digest)
+	}
+
+	if certPEM := layer.Annotations[certificateAnnotation]; certPEM != "" {
+		return p.verifyKeyless(payload, sig, certPEM, layer.Annotations[chainAnnotation], layer.Annotations[bundleAnnotation])
+	}
+	for _, key := range p.PublicKeys {
+		if verifySignature(key.key, payload, sig) == nil {
+			return "key " + key.ID, nil
+		}
+	}
+	return "", fmt.Errorf("signature %s was not made by any of the trusted public keys", layer.Digest)
+}
+
+func (p *Policy) verifyKeyless(payload, sig []byte, certPEM, chainPEM, bundleJSON string) (string, error) {
+	if len(p.Identities) == 0 || p.TrustedRoot == nil {
+		return "", fmt.Errorf("keyless signatures are not trusted without any configured identities")
+	}
+	certs, err := parsePEMCertificates(certPEM)
+	if err != nil || len(certs) != 1 {
+		return "", fmt.Errorf("invalid signing certificate")
+	}
+	cert := certs[0]
+	if bundleJSON == "" {
+		return "", fmt.Errorf("keyless signature has no transparency log entry")
+	}
+	integratedTime, err := p.TrustedRoot.verifyBundle([]byte(bundleJSON), payload, sig, cert)
+	if err != nil {
+		return "", fmt.Errorf("invalid transparency log entry: %w", err)
+	}
+
+	// Any intermediate certificates included with the signature can only
+	// help to build a chain to one of the roots we already trust.
+	intermediates := p.TrustedRoot.intermediates.Clone()
+	if chainPEM != "" {
+		chain, err := parsePEMCertificates(chainPEM)
+		if err != nil {
+			return "", fmt.Errorf("invalid certificate chain: %w", err)
+		}
+		for _, c := range chain {
+			intermediates.AddCert(c)
+		}
+	}
+	// Keyless signing certificates are only valid for a few minutes, so we
+	// check their validity at the time the transparency log recorded the
+	// signature rather than now.
+	chains, err := cert.Verify(x509.VerifyOptions{
+		Roots:         p.TrustedRoot.roots,
+		Intermediates: intermediates,
+		CurrentTime:   integratedTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return "", fmt.Errorf("untrusted signing certificate: %w", err)
+	}
+	// The chain always includes the issuer, because the signing certificate
+	// can't be one of the trusted roots itself.
+	if err := p.TrustedRoot.verifyEmbeddedSCT(cert, chains[0][1]); err != nil {
+		return "", err
+	}
+	if err := verifySignature(cert.PublicKey, payload, sig); err != nil {
+		return "", err
+	}
+
+	issuer, subject := certificateIdentity(cert)
+	for _, id := range p.Identities {
+		if id.matches(issuer, subject) {
+			return fmt.Sprintf("%s (%s)", subject, issuer), nil
+		}
+	}
+	return "", fmt.Errorf("signing identity %q from issuer %q is not trusted", subject, issuer)
+}
+
+// rekorBundle is the transparency log entry that cosign attaches to keyless
+// signatures.
+type rekorBundle struct {
+	SignedEntryTimestamp []byte             `json:"SignedEntryTimestamp"`
+	Payload              rekorBundlePayload `json:"Payload"`
+}
+
+// rekorBundlePayload is the part of a transparency log entry that the log's
+// signed entry timestamp covers. The fields are declared in lexical order so
+// that encoding/json produces the canonical form that the log signs.
+type rekorBundlePayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// hashedRekord is the subset of the "hashedrekord" transparency log entry
+// type that we rely on.
+type hashedRekord struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content   []byte `json:"content"`
+			PublicKey struct {
+				Content []byte `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// verifyBundle checks that the given transparency log entry was signed by
+// one of the trusted logs and that it describes the given signature, returning
+// the time at which the log recorded the entry.
+func (r *TrustedRoot) verifyBundle(src, payload, sig []byte, cert *x509.Certificate) (time.Time, error) {
+	var bundle rekorBundle
+	if err := json.Unmarshal(src, &bundle); err != nil {
+		return time.Time{}, err
+	}
+	logKey, ok := r.tlogKeys[bundle.Payload.LogID]
+	if !ok {
+		return time.Time{}, fmt.Errorf("entry is from untrusted log %q", bundle.Payload.LogID)
+	}
+	signed, err := json.Marshal(bundle.Payload)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := verifySignature(logKey, signed, bundle.SignedEntryTimestamp); err != nil {
+		return time.Time{}, fmt.Errorf("invalid signed entry timestamp: %w", err)
+	}
+
+	bodySrc, err := base64.StdEncoding.DecodeString(bundle.Payload.Body)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid entry body: %w", err)
+	}
+	var body hashedRekord
+	if err := json.Unmarshal(bodySrc, &body); err != nil {
+		return time.Time{}, fmt.Errorf("invalid entry body: %w", err)
+	}
+	if body.Kind != "hashedrekord" {
+		return time.Time{}, fmt.Errorf("unsupported entry kind %q", body.Kind)
+	}
+	payloadSum := sha256.Sum256(payload)
+	if body.Spec.Data.Hash.Algorithm != "sha256" || body.Spec.Data.Hash.Value != hex.EncodeToString(payloadSum[:]) {
+		return time.Time{}, fmt.Errorf("entry does not describe the signed payload")
+	}
+	if !bytes.Equal(body.Spec.Signature.Content, sig) {
+		return time.Time{}, fmt.Errorf("entry does not describe the signature")
+	}
+	entryCerts, err := parsePEMCertificates(string(body.Spec.Signature.PublicKey.Content))
+	if err != nil || len(entryCerts) != 1 || !entryCerts[0].Equal(cert) {
+		return time.Time{}, fmt.Errorf("entry does not describe the signing certificate")
+	}
+	return time.Unix(bundle.Payload.IntegratedTime, 0), nil
+}
+
+// checkPayload verifies that the given simple signing payload claims to
+// describe the manifest with the given digest.
+func checkPayload(src []byte, manifestDigest digest.Digest) error {
+	var payload struct {
+		Critical struct {
+			Image struct {
+				DockerManifestDigest string `json:"docker-manifest-digest"`
+			} `json:"image"`
+			Type string `json:"type"`
+		} `json:"critical"`
+	}
+	if err := json.Unmarshal(src, &payload); err != nil {
+		return fmt.Errorf("invalid signed payload: %w", err)
+	}
+	if payload.Critical.Type != simpleSigningPayloadType {
+		return fmt.Errorf("unsupported signed payload type %q", payload.Critical.Type)
+	}
+	if payload.Critical.Image.DockerManifestDigest != manifestDigest.String() {
+		return fmt.Errorf("signed payload is for %s, not %s", payload.Critical.Image.DockerManifestDigest, manifestDigest)
+	}
+	return nil
+}
+
+func verifySignature(key crypto.PublicKey, msg, sig []byte) error {
+	sum := sha256.Sum256(msg)
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, sum[:], sig) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig); err != nil {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, msg, sig) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// certificateIdentity returns the OpenID Connect issuer and the subject that
+// a Sigstore certificate authority recorded in a signing certificate.
+func certificateIdentity(cert *x509.Certificate) (issuer, subject string) {
+	switch {
+	case len(cert.EmailAddresses) != 0:
+		subject = cert.EmailAddresses[0]
+	case len(cert.URIs) != 0:
+		subject = cert.URIs[0].String()
+	}
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(oidIssuerV2):
+			var s string
+			if _, err := asn1.UnmarshalWithParams(ext.Value, &s, "utf8"); err == nil {
+				return s, subject
+			}
+		case ext.Id.Equal(oidIssuer):
+			issuer = string(ext.Value)
+		}
+	}
+	return issuer, subject
+}
+
+func parsePEMCertificates(src string) ([]*x509.Certificate, error) {
+	var ret []*x509.Certificate
+	rest := []byte(strings.TrimSpace(src))
+	for len(rest) != 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("invalid PEM data")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, cert)
+		rest = bytes.TrimSpace(rest)
+	}
+	return ret, nil
+}
+
+// fetchVerifiedBlob retrieves the content described by the given descriptor,
+// verifying that it matches the descriptor's size and digest.
+func fetchVerifiedBlob(ctx context.Context, store Store, desc ociv1.Descriptor, sizeLimit int64) ([]byte, error) {
+	if desc.Size > sizeLimit {
+		return nil, fmt.Errorf("content is too large (%d bytes)", desc.Size)
+	}
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid digest %q: %w", desc.Digest, err)
+	}
+	r, err := store.Fetch(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	src, err := io.ReadAll(io.LimitReader(r, desc.Size))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(src)) != desc.Size || desc.Digest.Algorithm().FromBytes(src) != desc.Digest {
+		return nil, fmt.Errorf("content does not match digest %s", desc.Digest)
+	}
+	return src, nil
+}
@@ -0,0 +1,462 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package cosign
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+func TestPolicyVerify_publicKey(t *testing.T) {
+	signingKey := generateKey(t)
+	otherKey := generateKey(t)
+	manifestDigest := digest.FromString("fake manifest")
+
+	store := newMemoryStore()
+	store.addSignature(t, manifestDigest, makePayload(manifestDigest), signingKey, nil)
+
+	t.Run("trusted key", func(t *testing.T) {
+		policy := &Policy{PublicKeys: []*PublicKey{parsePublicKey(t, otherKey), parsePublicKey(t, signingKey)}}
+		signer, err := policy.Verify(t.Context(), store, manifestDigest)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if want := "key " + parsePublicKey(t, signingKey).ID; signer != want {
+			t.Errorf("wrong signer\ngot:  %s\nwant: %s", signer, want)
+		}
+	})
+	t.Run("untrusted key", func(t *testing.T) {
+		policy := &Policy{PublicKeys: []*PublicKey{parsePublicKey(t, otherKey)}}
+		_, err := policy.Verify(t.Context(), store, manifestDigest)
+		if err == nil || !strings.Contains(err.Error(), "not made by any of the trusted public keys") {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+	t.Run("unsigned manifest", func(t *testing.T) {
+		policy := &Policy{PublicKeys: []*PublicKey{parsePublicKey(t, signingKey)}}
+		_, err := policy.Verify(t.Context(), store, digest.FromString("other manifest"))
+		if err == nil || !strings.Contains(err.Error(), "finding signatures") {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+	t.Run("payload for another manifest", func(t *testing.T) {
+		victimDigest := digest.FromString("victim manifest")
+		store := newMemoryStore()
+		store.addSignature(t, victimDigest, makePayload(manifestDigest), signingKey, nil)
+		policy := &Policy{PublicKeys: []*PublicKey{parsePublicKey(t, signingKey)}}
+		_, err := policy.Verify(t.Context(), store, victimDigest)
+		if err == nil || !strings.Contains(err.Error(), "signed payload is for") {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+}
+
+func TestPolicyVerify_keyless(t *testing.T) {
+	now := time.Now()
+	caKey := generateKey(t)
+	caCert := createCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, caKey, caKey)
+	issuerExt, err := asn1.MarshalWithParams("https://issuer.example.com", "utf8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafKey := generateKey(t)
+	leafTemplate := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       now.Add(-time.Minute),
+		NotAfter:        now.Add(10 * time.Minute),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		EmailAddresses:  []string{"signer@example.com"},
+		ExtraExtensions: []pkix.Extension{{Id: oidIssuerV2, Value: issuerExt}},
+	}
+	ctlogKey := generateKey(t)
+	leafCert := createLoggedCertificate(t, leafTemplate, caCert, leafKey, caKey, ctlogKey, now)
+	tlogKey := generateKey(t)
+
+	trustedRootSrc := makeTrustedRoot(t, caCert, tlogKey, ctlogKey)
+	trustedRoot, err := ParseTrustedRoot(trustedRootSrc)
+	if err != nil {
+		t.Fatalf("invalid trusted root: %s", err)
+	}
+
+	manifestDigest := digest.FromString("fake manifest")
+	store := newMemoryStore()
+	store.addSignature(t, manifestDigest, makePayload(manifestDigest), leafKey, &keylessInfo{
+		cert:      leafCert,
+		tlogKey:   tlogKey,
+		timestamp: now,
+	})
+
+	tests := map[string]struct {
+		identity Identity
+		wantErr  string
+	}{
+		"exact subject": {
+			identity: Identity{Issuer: "https://issuer.example.com", Subject: "signer@example.com"},
+		},
+		"subject pattern": {
+			identity: Identity{Issuer: "https://issuer.example.com", SubjectRegexp: regexp.MustCompile(`.*@example\.com`)},
+		},
+		"partial subject pattern": {
+			identity: Identity{Issuer: "https://issuer.example.com", SubjectRegexp: regexp.MustCompile(`signer`)},
+			wantErr:  `signing identity "signer@example.com" from issuer "https://issuer.example.com" is not trusted`,
+		},
+		"wrong subject": {
+			identity: Identity{Issuer: "https://issuer.example.com", Subject: "other@example.com"},
+			wantErr:  `signing identity "signer@example.com" from issuer "https://issuer.example.com" is not trusted`,
+		},
+		"wrong issuer": {
+			identity: Identity{Issuer: "https://other.example.com", Subject: "signer@example.com"},
+			wantErr:  `is not trusted`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			policy := &Policy{Identities: []Identity{test.identity}, TrustedRoot: trustedRoot}
+			signer, err := policy.Verify(t.Context(), store, manifestDigest)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("wrong error\ngot:  %v\nwant: %s", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if want := "signer@example.com (https://issuer.example.com)"; signer != want {
+				t.Errorf("wrong signer\ngot:  %s\nwant: %s", signer, want)
+			}
+		})
+	}
+
+	t.Run("untrusted log", func(t *testing.T) {
+		otherRoot, err := ParseTrustedRoot(makeTrustedRoot(t, caCert, generateKey(t), ctlogKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		policy := &Policy{Identities: []Identity{tests["exact subject"].identity}, TrustedRoot: otherRoot}
+		_, err = policy.Verify(t.Context(), store, manifestDigest)
+		if err == nil || !strings.Contains(err.Error(), "entry is from untrusted log") {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+	t.Run("untrusted certificate authority", func(t *testing.T) {
+		otherCAKey := generateKey(t)
+		otherCACert := createCertificate(t, &x509.Certificate{
+			SerialNumber:          big.NewInt(3),
+			Subject:               pkix.Name{CommonName: "other root"},
+			NotBefore:             now.Add(-time.Hour),
+			NotAfter:              now.Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}, nil, otherCAKey, otherCAKey)
+		otherRoot, err := ParseTrustedRoot(makeTrustedRoot(t, otherCACert, tlogKey, ctlogKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		policy := &Policy{Identities: []Identity{tests["exact subject"].identity}, TrustedRoot: otherRoot}
+		_, err = policy.Verify(t.Context(), store, manifestDigest)
+		if err == nil || !strings.Contains(err.Error(), "untrusted signing certificate") {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+	t.Run("untrusted certificate transparency log", func(t *testing.T) {
+		otherRoot, err := ParseTrustedRoot(makeTrustedRoot(t, caCert, tlogKey, generateKey(t)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		policy := &Policy{Identities: []Identity{tests["exact subject"].identity}, TrustedRoot: otherRoot}
+		_, err = policy.Verify(t.Context(), store, manifestDigest)
+		if err == nil || !strings.Contains(err.Error(), "no valid signed certificate timestamp") {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+	t.Run("unlogged certificate", func(t *testing.T) {
+		unloggedCert := createCertificate(t, leafTemplate, caCert, leafKey, caKey)
+		store := newMemoryStore()
+		store.addSignature(t, manifestDigest, makePayload(manifestDigest), leafKey, &keylessInfo{
+			cert:      unloggedCert,
+			tlogKey:   tlogKey,
+			timestamp: now,
+		})
+		policy := &Policy{Identities: []Identity{tests["exact subject"].identity}, TrustedRoot: trustedRoot}
+		_, err := policy.Verify(t.Context(), store, manifestDigest)
+		if err == nil || !strings.Contains(err.Error(), "signing certificate has no signed certificate timestamp") {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+	t.Run("public keys only", func(t *testing.T) {
+		policy := &Policy{PublicKeys: []*PublicKey{parsePublicKey(t, leafKey)}}
+		_, err := policy.Verify(t.Context(), store, manifestDigest)
+		if err == nil || !strings.Contains(err.Error(), "keyless signatures are not trusted") {
+			t.Fatalf("wrong error: %v", err)
+		}
+	})
+}
+
+type memoryStore struct {
+	tags  map[string]ociv1.Descriptor
+	blobs map[digest.Digest][]byte
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		tags:  make(map[string]ociv1.Descriptor),
+		blobs: make(map[digest.Digest][]byte),
+	}
+}
+
+func (s *memoryStore) Resolve(_ context.Context, tagName string) (ociv1.Descriptor, error) {
+	desc, ok := s.tags[tagName]
+	if !ok {
+		return ociv1.Descriptor{}, fmt.Errorf("%s: not found", tagName)
+	}
+	return desc, nil
+}
+
+func (s *memoryStore) Fetch(_ context.Context, target ociv1.Descriptor) (io.ReadCloser, error) {
+	src, ok := s.blobs[target.Digest]
+	if !ok {
+		return nil, fmt.Errorf("%s: not found", target.Digest)
+	}
+	return io.NopCloser(bytes.NewReader(src)), nil
+}
+
+func (s *memoryStore) push(mediaType string, src []byte) ociv1.Descriptor {
+	desc := ociv1.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(src),
+		Size:      int64(len(src)),
+	}
+	s.blobs[desc.Digest] = src
+	return desc
+}
+
+type keylessInfo struct {
+	cert      *x509.Certificate
+	tlogKey   *ecdsa.PrivateKey
+	timestamp time.Time
+}
+
+// addSignature stores a signature for the given manifest digest in the same
+// layout that "cosign sign" would create.
+func (s *memoryStore) addSignature(t *testing.T, manifestDigest digest.Digest, payload []byte, key *ecdsa.PrivateKey, keyless *keylessInfo) {
+	t.Helper()
+
+	sig := signMessage(t, key, payload)
+	layer := s.push(simpleSigningMediaType, payload)
+	layer.Annotations = map[string]string{
+		signatureAnnotation: base64.StdEncoding.EncodeToString(sig),
+	}
+	if keyless != nil {
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: keyless.cert.Raw})
+		layer.Annotations[certificateAnnotation] = string(certPEM)
+		layer.Annotations[bundleAnnotation] = makeBundle(t, payload, sig, certPEM, keyless.tlogKey, keyless.timestamp)
+	}
+
+	manifestSrc, err := json.Marshal(ociv1.Manifest{
+		MediaType: ociv1.MediaTypeImageManifest,
+		Config:    s.push("application/vnd.oci.image.config.v1+json", []byte("{}")),
+		Layers:    []ociv1.Descriptor{layer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.tags[SignatureTag(manifestDigest)] = s.push(ociv1.MediaTypeImageManifest, manifestSrc)
+}
+
+func makePayload(manifestDigest digest.Digest) []byte {
+	return []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"example.com/foo"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, manifestDigest))
+}
+
+func makeBundle(t *testing.T, payload, sig, certPEM []byte, tlogKey *ecdsa.PrivateKey, timestamp time.Time) string {
+	t.Helper()
+
+	var body hashedRekord
+	body.Kind = "hashedrekord"
+	payloadSum := sha256.Sum256(payload)
+	body.Spec.Data.Hash.Algorithm = "sha256"
+	body.Spec.Data.Hash.Value = hex.EncodeToString(payloadSum[:])
+	body.Spec.Signature.Content = sig
+	body.Spec.Signature.PublicKey.Content = certPEM
+	bodySrc, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tlogKeyDER, err := x509.MarshalPKIXPublicKey(tlogKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	logID := sha256.Sum256(tlogKeyDER)
+	bundle := rekorBundle{
+		Payload: rekorBundlePayload{
+			Body:           base64.StdEncoding.EncodeToString(bodySrc),
+			IntegratedTime: timestamp.Unix(),
+			LogID:          hex.EncodeToString(logID[:]),
+			LogIndex:       1,
+		},
+	}
+	signed, err := json.Marshal(bundle.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle.SignedEntryTimestamp = signMessage(t, tlogKey, signed)
+	ret, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(ret)
+}
+
+func makeTrustedRoot(t *testing.T, caCert *x509.Certificate, tlogKey, ctlogKey *ecdsa.PrivateKey) []byte {
+	t.Helper()
+
+	tlogKeyDER, err := x509.MarshalPKIXPublicKey(tlogKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctlogKeyDER, err := x509.MarshalPKIXPublicKey(ctlogKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(fmt.Sprintf(`{
+  "mediaType": "application/vnd.dev.sigstore.trustedroot+json;version=0.1",
+  "tlogs": [{"publicKey": {"rawBytes": %q}}],
+  "ctlogs": [{"publicKey": {"rawBytes": %q}}],
+  "certificateAuthorities": [{"certChain": {"certificates": [{"rawBytes": %q}]}}]
+}`, base64.StdEncoding.EncodeToString(tlogKeyDER), base64.StdEncoding.EncodeToString(ctlogKeyDER), base64.StdEncoding.EncodeToString(caCert.Raw)))
+}
+
+func generateKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func parsePublicKey(t *testing.T, key *ecdsa.PrivateKey) *PublicKey {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
+
+func signMessage(t *testing.T, key *ecdsa.PrivateKey, msg []byte) []byte {
+	t.Helper()
+	sum := sha256.Sum256(msg)
+	sig, err := key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func createCertificate(t *testing.T, template, parent *x509.Certificate, key, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// createLoggedCertificate creates a certificate with a signed certificate
+// timestamp from the given certificate transparency log, in the same way as
+// Fulcio: the log signs a precertificate without the timestamp, which is then
+// embedded in the final certificate.
+func createLoggedCertificate(t *testing.T, template, parent *x509.Certificate, key, parentKey, ctlogKey *ecdsa.PrivateKey, timestamp time.Time) *x509.Certificate {
+	t.Helper()
+
+	precert := createCertificate(t, template, parent, key, parentKey)
+	ctlogKeyDER, err := x509.MarshalPKIXPublicKey(ctlogKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	logID := sha256.Sum256(ctlogKeyDER)
+	issuerKeyHash := sha256.Sum256(parent.RawSubjectPublicKeyInfo)
+
+	var signed cryptobyte.Builder
+	signed.AddUint8(sctVersionV1)
+	signed.AddUint8(sctCertificateStamp)
+	signed.AddUint64(uint64(timestamp.UnixMilli()))
+	signed.AddUint16(sctPrecertEntry)
+	signed.AddBytes(issuerKeyHash[:])
+	signed.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(precert.RawTBSCertificate)
+	})
+	signed.AddUint16(0) // no extensions
+	sig := signMessage(t, ctlogKey, signed.BytesOrPanic())
+
+	var list cryptobyte.Builder
+	list.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddUint8(sctVersionV1)
+			b.AddBytes(logID[:])
+			b.AddUint64(uint64(timestamp.UnixMilli()))
+			b.AddUint16(0) // no extensions
+			b.AddUint8(sctHashAlgorithmSHA256)
+			b.AddUint8(3) // ECDSA
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes(sig)
+			})
+		})
+	})
+	ext, err := asn1.Marshal(list.BytesOrPanic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logged := *template
+	logged.ExtraExtensions = append(slices.Clone(template.ExtraExtensions), pkix.Extension{Id: oidSCTList, Value: ext})
+	return createCertificate(t, &logged, parent, key, parentKey)
+}
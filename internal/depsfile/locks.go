@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"sort"
 
+	version "github.com/hashicorp/go-version"
 	svchost "github.com/opentofu/svchost"
 
 	"github.com/opentofu/opentofu/internal/addrs"
@@ -39,11 +40,13 @@ type Locks struct {
 	// settings, environment variables, or whatever similar sources.
 	overriddenProviders map[addrs.Provider]struct{}
 
-	// TODO: In future we'll also have module locks, but the design of that
-	// still needs some more work and we're deferring that to get the
-	// provider locking capability out sooner, because it's more common to
-	// directly depend on providers maintained outside your organization than
-	// modules maintained outside your organization.
+	// modules records the packages selected for the remote module calls of
+	// the configuration, keyed by the module key used in the module manifest,
+	// such as "network.subnets" for a module "subnets" called from a module
+	// "network" called from the root module. Modules with local source
+	// addresses belong to the package of their caller and so are not locked
+	// separately.
+	modules map[string]*ModuleLock
 
 	// sources is a copy of the map of source buffers produced by the HCL
 	// parser during loading, which we retain only so that the caller can
@@ -56,6 +59,7 @@ type Locks struct {
 func NewLocks() *Locks {
 	return &Locks{
 		providers: make(map[addrs.Provider]*ProviderLock),
+		modules:   make(map[string]*ModuleLock),
 
 		// no "sources" here, because that's only for locks objects loaded
 		// from files.
@@ -122,6 +126,43 @@ func (l *Locks) RemoveProvider(addr addrs.Provider) {
 	delete(l.providers, addr)
 }
 
+// Module returns the stored lock for the module with the given key, or nil
+// if that module currently has no lock.
+func (l *Locks) Module(key string) *ModuleLock {
+	return l.modules[key]
+}
+
+// AllModules returns a map describing all of the module locks in the
+// receiver, by module key.
+func (l *Locks) AllModules() map[string]*ModuleLock {
+	ret := make(map[string]*ModuleLock, len(l.modules))
+	for k, v := range l.modules {
+		ret[k] = v
+	}
+	return ret
+}
+
+// SetModule creates a new lock or replaces the existing lock for the module
+// with the given key.
+//
+// The ownership of the backing array for the slice of hashes passes to this
+// function, and so the caller must not read or write that backing array after
+// calling SetModule.
+func (l *Locks) SetModule(key, source string, version *version.Version, pkg string, hashes []getproviders.Hash) *ModuleLock {
+	new := NewModuleLock(key, source, version, pkg, hashes)
+	l.modules[new.key] = new
+	return new
+}
+
+// RemoveModule removes any existing lock file entry for the module with the
+// given key.
+//
+// If the given module did not already have a lock entry, RemoveModule is a
+// no-op.
+func (l *Locks) RemoveModule(key string) {
+	delete(l.modules, key)
+}
+
 // SetProviderOverridden records that this particular OpenTofu process will
 // not pay attention to the recorded lock entry for the given provider, and
 // will instead access that provider's functionality in some other special
@@ -184,8 +225,39 @@ func NewProviderLock(addr addrs.Provider, version getproviders.Version, constrai
 		panic(fmt.Sprintf("Locks.NewProviderLock with non-lockable provider %s", addr))
 	}
 
+	return &ProviderLock{
+		addr:               addr,
+		version:            version,
+		versionConstraints: constraints,
+		hashes:             normalizeHashes(hashes),
+	}
+}
+
+// NewModuleLock creates a new ModuleLock object that isn't associated with
+// any Locks object.
+//
+// This is here primarily for testing. Most callers should use Locks.SetModule
+// to construct a new module lock and insert it into a Locks object at the
+// same time.
+//
+// The ownership of the backing array for the slice of hashes passes to this
+// function, and so the caller must not read or write that backing array after
+// calling NewModuleLock.
+func NewModuleLock(key, source string, version *version.Version, pkg string, hashes []getproviders.Hash) *ModuleLock {
+	return &ModuleLock{
+		key:     key,
+		source:  source,
+		version: version,
+		pkg:     pkg,
+		hashes:  normalizeHashes(hashes),
+	}
+}
+
+// normalizeHashes sorts the given hashes into lexical order and removes any
+// duplicates, reusing the backing array of the given slice.
+func normalizeHashes(hashes []getproviders.Hash) []getproviders.Hash {
 	// Normalize the hashes into lexical order so that we can do straightforward
-	// equality tests between different locks for the same provider. The
+	// equality tests between different locks for the same dependency. The
 	// hashes are logically a set, so the given order is insignificant.
 	sort.Slice(hashes, func(i, j int) bool {
 		return string(hashes[i]) < string(hashes[j])
@@ -207,13 +279,7 @@ func NewProviderLock(addr addrs.Provider, version getproviders.Version, constrai
 			prevHash = hash
 		}
 	}
-
-	return &ProviderLock{
-		addr:               addr,
-		version:            version,
-		versionConstraints: constraints,
-		hashes:             dedupeHashes,
-	}
+	return dedupeHashes
 }
 
 // ProviderIsLockable returns true if the given provider is eligible for
@@ -280,6 +346,16 @@ func (l *Locks) Equal(other *Locks) bool {
 	// We don't need to worry about providers that are in "other" but not
 	// in the receiver, because we tested the lengths being equal above.
 
+	if len(l.modules) != len(other.modules) {
+		return false
+	}
+	for key, thisLock := range l.modules {
+		otherLock, ok := other.modules[key]
+		if !ok || !thisLock.equal(otherLock) {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -358,7 +434,7 @@ func (l *Locks) UpgradeFromPredecessorProject() map[addrs.Provider]addrs.Provide
 // UI code might wish to use this to distinguish a lock file being
 // written for the first time from subsequent updates to that lock file.
 func (l *Locks) Empty() bool {
-	return len(l.providers) == 0
+	return len(l.providers) == 0 && len(l.modules) == 0
 }
 
 // DeepCopy creates a new Locks that represents the same information as the
@@ -379,6 +455,14 @@ func (l *Locks) DeepCopy() *Locks {
 		}
 		ret.SetProvider(addr, lock.version, lock.versionConstraints, hashes)
 	}
+	for key, lock := range l.modules {
+		var hashes []getproviders.Hash
+		if len(lock.hashes) > 0 {
+			hashes = make([]getproviders.Hash, len(lock.hashes))
+			copy(hashes, lock.hashes)
+		}
+		ret.SetModule(key, lock.source, lock.version, lock.pkg, hashes)
+	}
 	return ret
 }
 
@@ -497,3 +581,82 @@ func (l *ProviderLock) ContainsAll(target *ProviderLock) bool {
 func (l *ProviderLock) PreferredHashes() []getproviders.Hash {
 	return getproviders.PreferredHashes(l.hashes)
 }
+
+// ModuleLock represents lock information for a specific remote module call.
+type ModuleLock struct {
+	// key is the key of the module in the module manifest.
+	key string
+
+	// source is the source address of the module call the package was
+	// selected for. The lock only applies while the module call still has
+	// the same source address.
+	source string
+
+	// version is the version selected for a module from a module registry,
+	// or nil for modules installed directly from a remote package.
+	version *version.Version
+
+	// pkg is the address of the package that was installed, such as the
+	// download location returned by a module registry or, for git
+	// repositories, the source address with its ref replaced by the commit
+	// that was checked out. It may be empty if the package address was not
+	// known when the lock was recorded.
+	pkg string
+
+	// hashes contains hashes of the contents of the installed package, in
+	// the "h1:" format of getproviders.HashV1, excluding any version control
+	// metadata. A package must match one of them to be installed.
+	hashes []getproviders.Hash
+}
+
+// Key returns the key of the module this lock applies to.
+func (l *ModuleLock) Key() string {
+	return l.key
+}
+
+// Source returns the source address of the module call the lock was recorded
+// for.
+func (l *ModuleLock) Source() string {
+	return l.source
+}
+
+// Version returns the selected version of a registry module, or nil if the
+// module was not installed from a module registry.
+func (l *ModuleLock) Version() *version.Version {
+	return l.version
+}
+
+// Package returns the address of the package that was installed, or an empty
+// string if it is not known.
+func (l *ModuleLock) Package() string {
+	return l.pkg
+}
+
+// AllHashes returns all of the package hashes that were recorded when this
+// lock was created.
+//
+// Do not modify the backing array of the returned slice.
+func (l *ModuleLock) AllHashes() []getproviders.Hash {
+	return l.hashes
+}
+
+func (l *ModuleLock) equal(other *ModuleLock) bool {
+	if l.key != other.key || l.source != other.source || l.pkg != other.pkg {
+		return false
+	}
+	if (l.version == nil) != (other.version == nil) {
+		return false
+	}
+	if l.version != nil && l.version.String() != other.version.String() {
+		return false
+	}
+	if len(l.hashes) != len(other.hashes) {
+		return false
+	}
+	for i := range l.hashes {
+		if l.hashes[i] != other.hashes[i] {
+			return false
+		}
+	}
+	return true
+}
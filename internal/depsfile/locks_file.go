@@ -9,7 +9,9 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 
+	version "github.com/hashicorp/go-version"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclparse"
@@ -24,7 +26,6 @@ import (
 	"github.com/opentofu/opentofu/internal/replacefile"
 	"github.com/opentofu/opentofu/internal/tfdiags"
 	"github.com/opentofu/opentofu/internal/tracing"
-	"github.com/opentofu/opentofu/version"
 )
 
 // LoadLocksFromFile reads locks from the given file, expecting it to be a
@@ -175,6 +176,30 @@ func SaveLocksToBytes(locks *Locks) ([]byte, tfdiags.Diagnostics) {
 		}
 	}
 
+	moduleKeys := make([]string, 0, len(locks.modules))
+	for key := range locks.modules {
+		moduleKeys = append(moduleKeys, key)
+	}
+	sort.Strings(moduleKeys)
+
+	for _, key := range moduleKeys {
+		lock := locks.modules[key]
+		rootBody.AppendNewline()
+		block := rootBody.AppendNewBlock("module", []string{lock.key})
+		body := block.Body()
+		body.SetAttributeValue("source", cty.StringVal(lock.source))
+		if lock.version != nil {
+			body.SetAttributeValue("version", cty.StringVal(lock.version.String()))
+		}
+		if lock.pkg != "" {
+			body.SetAttributeValue("package", cty.StringVal(lock.pkg))
+		}
+		if len(lock.hashes) != 0 {
+			hashToks := encodeHashSetTokens(lock.hashes)
+			body.SetAttributeRaw("hashes", hashToks)
+		}
+	}
+
 	return f.Bytes(), diags
 }
 
@@ -187,21 +212,16 @@ func decodeLocksFromHCL(locks *Locks, body hcl.Body) tfdiags.Diagnostics {
 				Type:       "provider",
 				LabelNames: []string{"source_addr"},
 			},
-
-			// "module" is just a placeholder for future enhancement, so we
-			// can mostly-ignore the this block type we intend to add in
-			// future, but warn in case someone tries to use one e.g. if they
-			// downgraded to an earlier version of OpenTofu.
 			{
 				Type:       "module",
-				LabelNames: []string{"path"},
+				LabelNames: []string{"key"},
 			},
 		},
 	})
 	diags = diags.Append(hclDiags)
 
 	seenProviders := make(map[addrs.Provider]hcl.Range)
-	seenModule := false
+	seenModules := make(map[string]hcl.Range)
 	for _, block := range content.Blocks {
 
 		switch block.Type {
@@ -224,19 +244,22 @@ func decodeLocksFromHCL(locks *Locks, body hcl.Body) tfdiags.Diagnostics {
 			seenProviders[lock.addr] = block.DefRange
 
 		case "module":
-			// We'll just take the first module block to use for a single warning,
-			// because that's sufficient to get the point across without swamping
-			// the output with warning noise.
-			if !seenModule {
-				currentVersion := version.SemVer.String()
+			lock, moreDiags := decodeModuleLockFromHCL(block)
+			diags = diags.Append(moreDiags)
+			if lock == nil {
+				continue
+			}
+			if previousRng, exists := seenModules[lock.key]; exists {
 				diags = diags.Append(&hcl.Diagnostic{
-					Severity: hcl.DiagWarning,
-					Summary:  "Dependency locks for modules are not yet supported",
-					Detail:   fmt.Sprintf("OpenTofu v%s only supports dependency locks for providers, not for modules. This configuration may be intended for a later version of OpenTofu that also supports dependency locks for modules.", currentVersion),
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate module lock",
+					Detail:   fmt.Sprintf("This lockfile already declared a lock for module %q at %s.", lock.key, previousRng.String()),
 					Subject:  block.TypeRange.Ptr(),
 				})
-				seenModule = true
+				continue
 			}
+			locks.modules[lock.key] = lock
+			seenModules[lock.key] = block.DefRange
 
 		default:
 			// Shouldn't get here because this should be exhaustive for
@@ -332,6 +355,80 @@ func decodeProviderLockFromHCL(block *hcl.Block) (*ProviderLock, tfdiags.Diagnos
 	return ret, diags
 }
 
+func decodeModuleLockFromHCL(block *hcl.Block) (*ModuleLock, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	key := block.Labels[0]
+	for _, name := range strings.Split(key, ".") {
+		if !hclsyntax.ValidIdentifier(name) {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid module key",
+				Detail:   "The key for a module lock must be the names of the module calls leading to the module, separated by periods, such as \"network.subnets\".",
+				Subject:  block.LabelRanges[0].Ptr(),
+			})
+			return nil, diags
+		}
+	}
+
+	ret := &ModuleLock{key: key}
+
+	content, hclDiags := block.Body.Content(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "source", Required: true},
+			{Name: "version"},
+			{Name: "package"},
+			{Name: "hashes"},
+		},
+	})
+	diags = diags.Append(hclDiags)
+
+	if attr, exists := content.Attributes["source"]; exists {
+		diags = diags.Append(gohcl.DecodeExpression(attr.Expr, nil, &ret.source))
+	}
+	if attr, exists := content.Attributes["package"]; exists {
+		diags = diags.Append(gohcl.DecodeExpression(attr.Expr, nil, &ret.pkg))
+	}
+
+	if attr, exists := content.Attributes["version"]; exists {
+		var raw string
+		hclDiags := gohcl.DecodeExpression(attr.Expr, nil, &raw)
+		diags = diags.Append(hclDiags)
+		if !hclDiags.HasErrors() {
+			v, err := version.NewVersion(raw)
+			switch {
+			case err != nil:
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid module version number",
+					Detail:   fmt.Sprintf("The selected version number for module %q is invalid: %s.", key, err),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+			case v.String() != raw:
+				// Canonical forms are required in the lock file, to reduce the
+				// risk that a file diff will show changes that are entirely
+				// cosmetic.
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid module version number",
+					Detail:   fmt.Sprintf("The selected version number for module %q must be written in normalized form: %q.", key, v.String()),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+			default:
+				ret.version = v
+			}
+		}
+	}
+
+	if attr, exists := content.Attributes["hashes"]; exists {
+		hashes, moreDiags := decodeHashesArgument(attr, "module")
+		ret.hashes = normalizeHashes(hashes)
+		diags = diags.Append(moreDiags)
+	}
+
+	return ret, diags
+}
+
 func decodeProviderVersionArgument(provider addrs.Provider, attr *hcl.Attribute) (getproviders.Version, tfdiags.Diagnostics) {
 	var diags tfdiags.Diagnostics
 	if attr == nil {
@@ -416,11 +513,17 @@ func decodeProviderVersionConstraintsArgument(provider addrs.Provider, attr *hcl
 }
 
 func decodeProviderHashesArgument(provider addrs.Provider, attr *hcl.Attribute) ([]getproviders.Hash, tfdiags.Diagnostics) {
-	var diags tfdiags.Diagnostics
 	if attr == nil {
 		// It's okay to omit this argument.
-		return nil, diags
+		return nil, nil
 	}
+	return decodeHashesArgument(attr, "provider")
+}
+
+// decodeHashesArgument decodes the "hashes" argument of a provider or module
+// lock. The given kind is used in error messages.
+func decodeHashesArgument(attr *hcl.Attribute, kind string) ([]getproviders.Hash, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
 	expr := attr.Expr
 
 	// We'll decode this argument using the HCL static analysis mode, because
@@ -435,7 +538,7 @@ func decodeProviderHashesArgument(provider addrs.Provider, attr *hcl.Attribute)
 	if len(hashExprs) == 0 {
 		diags = diags.Append(&hcl.Diagnostic{
 			Severity: hcl.DiagError,
-			Summary:  "Invalid provider hash set",
+			Summary:  fmt.Sprintf("Invalid %s hash set", kind),
 			Detail:   "The \"hashes\" argument must either be omitted or contain at least one hash value.",
 			Subject:  expr.Range().Ptr(),
 		})
@@ -455,8 +558,8 @@ func decodeProviderHashesArgument(provider addrs.Provider, attr *hcl.Attribute)
 		if err != nil {
 			diags = diags.Append(&hcl.Diagnostic{
 				Severity: hcl.DiagError,
-				Summary:  "Invalid provider hash string",
-				Detail:   fmt.Sprintf("Cannot interpret %q as a provider hash: %s.", raw, err),
+				Summary:  fmt.Sprintf("Invalid %s hash string", kind),
+				Detail:   fmt.Sprintf("Cannot interpret %q as a %s hash: %s.", raw, kind, err),
 				Subject:  expr.Range().Ptr(),
 			})
 			continue
@@ -14,6 +14,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	version "github.com/hashicorp/go-version"
 
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/getproviders"
@@ -160,6 +161,34 @@ func TestLoadLocksFromFile(t *testing.T) {
 						}
 					}
 				})
+
+			case "valid-module-locks.hcl":
+				if got, want := len(locks.modules), 2; got != want {
+					t.Errorf("wrong number of modules %d; want %d", got, want)
+				}
+
+				if lock := locks.Module("network"); lock != nil {
+					if got, want := lock.Version().String(), "1.2.0"; got != want {
+						t.Errorf("wrong version\ngot:  %s\nwant: %s", got, want)
+					}
+					wantHashes := []getproviders.Hash{getproviders.MustParseHash("h1:placeholder-hash-1")}
+					if diff := cmp.Diff(wantHashes, lock.AllHashes()); diff != "" {
+						t.Errorf("wrong hashes\n%s", diff)
+					}
+				} else {
+					t.Errorf("missing lock for module network")
+				}
+
+				if lock := locks.Module("network.subnets"); lock != nil {
+					if lock.Version() != nil {
+						t.Errorf("unexpected version %s", lock.Version())
+					}
+					if got, want := lock.Package(), "git::https://example.com/subnets.git?ref=0123456789abcdef0123456789abcdef01234567"; got != want {
+						t.Errorf("wrong package\ngot:  %s\nwant: %s", got, want)
+					}
+				} else {
+					t.Errorf("missing lock for module network.subnets")
+				}
 			}
 		})
 	}
@@ -225,6 +254,11 @@ func TestSaveLocksToFile(t *testing.T) {
 	locks.SetProvider(barProvider, oneDotTwo, pessimisticOneDotOh, nil)
 	locks.SetProvider(bazProvider, oneDotTwo, nil, nil)
 	locks.SetProvider(booProvider, oneDotTwo, abbreviatedOneDotTwo, nil)
+	locks.SetModule("network", "registry.opentofu.org/acme/network/aws", version.Must(version.NewVersion("1.2.0")), "git::https://github.com/acme/terraform-aws-network?ref=v1.2.0", []getproviders.Hash{
+		getproviders.MustParseHash("h1:bbbb"),
+		getproviders.MustParseHash("h1:aaaa"),
+	})
+	locks.SetModule("app", "git::https://example.com/app.git", nil, "", nil)
 
 	dir := t.TempDir()
 
@@ -273,6 +307,20 @@ provider "registry.opentofu.org/test/foo" {
     "test:cccccccccccccccccccccccccccccccccccccccccccccccc",
   ]
 }
+
+module "app" {
+  source = "git::https://example.com/app.git"
+}
+
+module "network" {
+  source  = "registry.opentofu.org/acme/network/aws"
+  version = "1.2.0"
+  package = "git::https://github.com/acme/terraform-aws-network?ref=v1.2.0"
+  hashes = [
+    "h1:aaaa",
+    "h1:bbbb",
+  ]
+}
 `
 	if diff := cmp.Diff(wantContent, gotContent); diff != "" {
 		t.Errorf("wrong result\n%s", diff)
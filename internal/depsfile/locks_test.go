@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	version "github.com/hashicorp/go-version"
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/getproviders"
 )
@@ -85,6 +86,34 @@ func TestLocksEqual(t *testing.T) {
 		b.SetProvider(boopProvider, v2, v2EqConstraints, hashesB)
 		nonEqualBothWays(t, a, b)
 	})
+	t.Run("an extra module lock", func(t *testing.T) {
+		a := NewLocks()
+		b := NewLocks()
+		b.SetModule("network", "git::https://example.com/network.git", nil, "", []getproviders.Hash{hash1})
+		nonEqualBothWays(t, a, b)
+	})
+	t.Run("both have network module with same package", func(t *testing.T) {
+		a := NewLocks()
+		b := NewLocks()
+		a.SetModule("network", "example/network/aws", version.Must(version.NewVersion("1.0.0")), "", []getproviders.Hash{hash1})
+		b.SetModule("network", "example/network/aws", version.Must(version.NewVersion("1.0.0")), "", []getproviders.Hash{hash1})
+		equalBothWays(t, a, b)
+		equalBothWays(t, a, a.DeepCopy())
+	})
+	t.Run("both have network module with different versions", func(t *testing.T) {
+		a := NewLocks()
+		b := NewLocks()
+		a.SetModule("network", "example/network/aws", version.Must(version.NewVersion("1.0.0")), "", []getproviders.Hash{hash1})
+		b.SetModule("network", "example/network/aws", version.Must(version.NewVersion("1.1.0")), "", []getproviders.Hash{hash1})
+		nonEqualBothWays(t, a, b)
+	})
+	t.Run("both have network module with different hashes", func(t *testing.T) {
+		a := NewLocks()
+		b := NewLocks()
+		a.SetModule("network", "git::https://example.com/network.git", nil, "", []getproviders.Hash{hash1})
+		b.SetModule("network", "git::https://example.com/network.git", nil, "", []getproviders.Hash{hash2})
+		nonEqualBothWays(t, a, b)
+	})
 }
 
 func TestLocksEqualProviderAddress(t *testing.T) {
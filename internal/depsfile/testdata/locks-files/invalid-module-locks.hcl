@@ -0,0 +1,24 @@
+module "not a key" { # ERROR: Invalid module key
+  source = "git::https://example.com/a.git"
+}
+
+module "missing_source" { # ERROR: Missing required argument
+}
+
+module "bad_version" {
+  source  = "registry.opentofu.org/acme/network/aws"
+  version = "v1.2" # ERROR: Invalid module version number
+}
+
+module "empty_hashes" {
+  source = "git::https://example.com/a.git"
+  hashes = [] # ERROR: Invalid module hash set
+}
+
+module "duplicate" {
+  source = "git::https://example.com/a.git"
+}
+
+module "duplicate" { # ERROR: Duplicate module lock
+  source = "git::https://example.com/b.git"
+}
@@ -0,0 +1,13 @@
+module "network" {
+  source  = "registry.opentofu.org/acme/network/aws"
+  version = "1.2.0"
+  package = "git::https://github.com/acme/terraform-aws-network?ref=v1.2.0"
+  hashes = [
+    "h1:placeholder-hash-1",
+  ]
+}
+
+module "network.subnets" {
+  source  = "git::https://example.com/subnets.git?ref=main"
+  package = "git::https://example.com/subnets.git?ref=0123456789abcdef0123456789abcdef01234567"
+}
@@ -0,0 +1,264 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package getmodules
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	getter "github.com/hashicorp/go-getter"
+)
+
+// SourceCredentials are credentials to use when fetching module packages
+// from a particular host using git or HTTP, as returned by
+// [PackageFetcherEnvironment.SourceCredentials].
+type SourceCredentials struct {
+	// Username and Token are used for HTTP authentication, including git
+	// over HTTPS. If Username is empty then HTTP requests use the token as
+	// a bearer token, while git uses a default username that is accepted by
+	// common git hosting services.
+	Username string
+	Token    string
+
+	// SSHKeyFile is the path to a private key file to use for git over SSH.
+	SSHKeyFile string
+}
+
+// defaultGitUsername is the username we use for git over HTTPS when the
+// credentials don't specify one, since git requires basic authentication.
+// GitHub requires this particular username for some kinds of token, and
+// other common hosting services accept any username along with a token.
+const defaultGitUsername = "x-access-token"
+
+// credentialsTransport is an [http.RoundTripper] that adds the credentials
+// given by a [PackageFetcherEnvironment] to each request.
+//
+// Credentials are added only to HTTPS requests, so that they are never sent
+// in cleartext, and only to requests that don't already have an
+// Authorization header, such as one added by go-getter from a .netrc file.
+type credentialsTransport struct {
+	inner http.RoundTripper
+	env   PackageFetcherEnvironment
+}
+
+func (t *credentialsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" || req.Header.Get("Authorization") != "" {
+		return t.inner.RoundTrip(req)
+	}
+	creds, err := t.env.SourceCredentials(req.Context(), req.URL.Hostname())
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain credentials for %s: %w", req.URL.Hostname(), err)
+	}
+	if creds == nil || creds.Token == "" {
+		return t.inner.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the given request.
+	req = req.Clone(req.Context())
+	if creds.Username != "" {
+		req.SetBasicAuth(creds.Username, creds.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+creds.Token)
+	}
+	return t.inner.RoundTrip(req)
+}
+
+// credentialsGitGetter wraps go-getter's git getter to use the credentials
+// given by a [PackageFetcherEnvironment].
+//
+// For git over SSH we read the configured private key and pass it to the
+// wrapped getter using its "sshkey" argument. For git over HTTPS we clone the
+// repository ourselves, passing an Authorization header scoped to the
+// repository's host using git's GIT_CONFIG_COUNT environment variables, which
+// requires git 2.31 or later. Those variables are set only in the environment
+// of the git processes we start, so that the credentials are never written
+// into the cloned repository's configuration, into the command line of a git
+// process, or into the environment of any other process.
+type credentialsGitGetter struct {
+	*getter.GitGetter
+	env PackageFetcherEnvironment
+}
+
+func (g *credentialsGitGetter) Get(dst string, u *url.URL) error {
+	creds, err := g.env.SourceCredentials(g.Context(), u.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to obtain credentials for %s: %w", u.Hostname(), err)
+	}
+	if creds == nil {
+		return g.GitGetter.Get(dst, u)
+	}
+
+	switch u.Scheme {
+	case "ssh":
+		if creds.SSHKeyFile == "" || u.Query().Get("sshkey") != "" {
+			break
+		}
+		key, err := os.ReadFile(creds.SSHKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SSH key for %s: %w", u.Hostname(), err)
+		}
+		log.Printf("[TRACE] getmodules: using SSH key %s for %s", creds.SSHKeyFile, u.Hostname())
+		newU := *u
+		q := newU.Query()
+		q.Set("sshkey", base64.StdEncoding.EncodeToString(key))
+		newU.RawQuery = q.Encode()
+		u = &newU
+	case "https":
+		if creds.Token == "" {
+			break
+		}
+		environ, err := gitTokenEnv(os.Environ(), u, creds)
+		if err != nil {
+			return err
+		}
+		return g.clone(dst, u, environ)
+	}
+	return g.GitGetter.Get(dst, u)
+}
+
+// clone fetches the repository at the given URL into dst, which must not
+// exist or be empty, using the given environment for each git command.
+//
+// go-getter's git getter always runs git with the environment of the current
+// process, so this follows its behavior for a new clone but with an
+// environment of our choosing: the "ref" and "depth" arguments select what
+// to check out in the same way, and any submodules are fetched too.
+func (g *credentialsGitGetter) clone(dst string, u *url.URL, environ []string) error {
+	ctx := g.Context()
+	if g.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+
+	q := u.Query()
+	ref := q.Get("ref")
+	depth, _ := strconv.Atoi(q.Get("depth"))
+	q.Del("ref")
+	q.Del("depth")
+	q.Del("sshkey")
+	remote := *u
+	remote.RawQuery = q.Encode()
+
+	git := func(dir string, args ...string) error {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		cmd.Env = environ
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("git %s failed: %w\n%s", args[0], err, out)
+		}
+		return nil
+	}
+
+	args := []string{"clone"}
+	if depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+		if ref != "" {
+			args = append(args, "--branch", ref)
+		}
+	}
+	args = append(args, "--", remote.String(), dst)
+	if err := git("", args...); err != nil {
+		if depth > 0 && IsGitCommit(ref) {
+			return fmt.Errorf("%w (note that setting 'depth' requires 'ref' to be a branch or tag name)", err)
+		}
+		return err
+	}
+	if ref != "" && depth < 1 {
+		if err := git(dst, "checkout", ref); err != nil {
+			return err
+		}
+	}
+
+	args = []string{"submodule", "update", "--init", "--recursive"}
+	if depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	return git(dst, args...)
+}
+
+// gitCommandEnv returns the environment for a git command, run directly
+// rather than by go-getter, that accesses the repository at the given URL.
+// This is the environment of the current process along with any credentials
+// configured for the repository's host, passed in the same way as
+// [credentialsGitGetter] does.
+func gitCommandEnv(ctx context.Context, env PackageFetcherEnvironment, u *url.URL) ([]string, error) {
+	environ := os.Environ()
+	creds, err := env.SourceCredentials(ctx, u.Hostname())
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain credentials for %s: %w", u.Hostname(), err)
+	}
+	if creds == nil {
+		return environ, nil
+	}
+
+	switch u.Scheme {
+	case "ssh":
+		if creds.SSHKeyFile == "" {
+			break
+		}
+		log.Printf("[TRACE] getmodules: using SSH key %s for %s", creds.SSHKeyFile, u.Hostname())
+		quoted := "'" + strings.ReplaceAll(creds.SSHKeyFile, "'", `'\''`) + "'"
+		environ = append(environ, "GIT_SSH_COMMAND=ssh -i "+quoted+" -o IdentitiesOnly=yes")
+	case "https":
+		if creds.Token == "" {
+			break
+		}
+		return gitTokenEnv(environ, u, creds)
+	}
+	return environ, nil
+}
+
+// gitTokenEnv returns the given environment with git configured to
+// authenticate to the host of the given HTTPS repository URL using the token
+// from the given credentials.
+func gitTokenEnv(environ []string, u *url.URL, creds *SourceCredentials) ([]string, error) {
+	username := creds.Username
+	if username == "" {
+		username = defaultGitUsername
+	}
+	log.Printf("[TRACE] getmodules: using token credentials for %s", u.Hostname())
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + creds.Token))
+	return gitConfigEnv(
+		environ,
+		fmt.Sprintf("http.https://%s/.extraHeader", u.Host),
+		"Authorization: Basic "+auth,
+	)
+}
+
+// gitConfigEnv returns the given environment with the given git configuration
+// setting added to the environment variables that git reads additional
+// configuration from, after any settings that are already there.
+func gitConfigEnv(environ []string, key, value string) ([]string, error) {
+	const countVar = "GIT_CONFIG_COUNT"
+	ret := make([]string, 0, len(environ)+3)
+	idx := 0
+	for _, kv := range environ {
+		if count, ok := strings.CutPrefix(kv, countVar+"="); ok {
+			if count != "" {
+				n, err := strconv.Atoi(count)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s environment variable: %w", countVar, err)
+				}
+				idx = n
+			}
+			continue
+		}
+		ret = append(ret, kv)
+	}
+	return append(ret,
+		fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", idx, key),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", idx, value),
+		fmt.Sprintf("%s=%d", countVar, idx+1),
+	), nil
+}
@@ -0,0 +1,215 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package getmodules
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	getter "github.com/hashicorp/go-getter"
+)
+
+func TestCredentialsTransport(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	tests := map[string]struct {
+		creds       *SourceCredentials
+		requestAuth string
+		want        string
+	}{
+		"no credentials": {
+			creds: nil,
+			want:  "",
+		},
+		"token only": {
+			creds: &SourceCredentials{Token: "abc123"},
+			want:  "Bearer abc123",
+		},
+		"username and token": {
+			creds: &SourceCredentials{Username: "jdoe", Token: "abc123"},
+			want:  "Basic amRvZTphYmMxMjM=",
+		},
+		"SSH key only": {
+			creds: &SourceCredentials{SSHKeyFile: "/tmp/id_ed25519"},
+			want:  "",
+		},
+		"existing authorization": {
+			creds:       &SourceCredentials{Token: "abc123"},
+			requestAuth: "Basic ZnJvbTpuZXRyYw==",
+			want:        "Basic ZnJvbTpuZXRyYw==",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gotAuth = ""
+			client := &http.Client{
+				Transport: &credentialsTransport{
+					inner: server.Client().Transport,
+					env:   staticCredentialsEnvironment{creds: test.creds},
+				},
+			}
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.requestAuth != "" {
+				req.Header.Set("Authorization", test.requestAuth)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if gotAuth != test.want {
+				t.Errorf("wrong Authorization header\ngot:  %q\nwant: %q", gotAuth, test.want)
+			}
+		})
+	}
+}
+
+func TestGitConfigEnv(t *testing.T) {
+	// Any existing settings from the environment must be preserved.
+	environ := []string{
+		"HOME=/home/example",
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=core.example",
+		"GIT_CONFIG_VALUE_0=true",
+	}
+	got, err := gitConfigEnv(environ, "http.https://example.com/.extraHeader", "Authorization: Basic Zm9vOmJhcg==")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"HOME=/home/example",
+		"GIT_CONFIG_KEY_0=core.example",
+		"GIT_CONFIG_VALUE_0=true",
+		"GIT_CONFIG_KEY_1=http.https://example.com/.extraHeader",
+		"GIT_CONFIG_VALUE_1=Authorization: Basic Zm9vOmJhcg==",
+		"GIT_CONFIG_COUNT=2",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong environment\n%s", diff)
+	}
+	if diff := cmp.Diff("GIT_CONFIG_COUNT=1", environ[1]); diff != "" {
+		t.Errorf("given environment was modified\n%s", diff)
+	}
+}
+
+func TestGitConfigEnv_invalidCount(t *testing.T) {
+	_, err := gitConfigEnv([]string{"GIT_CONFIG_COUNT=not a number"}, "http.https://example.com/.extraHeader", "Authorization: Basic Zm9vOmJhcg==")
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+}
+
+func TestCredentialsGitGetterClone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("this test requires git")
+	}
+
+	origin := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = origin
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %s\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(content string) string {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(origin, "main.tf"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "main.tf")
+		git("commit", "-q", "-m", content)
+		return git("rev-parse", "HEAD")
+	}
+	git("init", "-q", "-b", "main")
+	first := commit("# first\n")
+	git("tag", "v1")
+	commit("# second\n")
+
+	// The environment we give is used for every git command, which we can
+	// observe using a configuration setting that git itself reports.
+	environ, err := gitConfigEnv(os.Environ(), "user.name", "from-environ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"":                  "# second\n",
+		"?ref=v1":           "# first\n",
+		"?ref=v1&depth=1":   "# first\n",
+		"?ref=" + first:     "# first\n",
+		"?ref=main&depth=1": "# second\n",
+		"?depth=1":          "# second\n",
+	}
+	for query, want := range tests {
+		t.Run(query, func(t *testing.T) {
+			u, err := url.Parse("file://" + filepath.ToSlash(origin) + query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := &credentialsGitGetter{GitGetter: new(getter.GitGetter)}
+			dst := filepath.Join(t.TempDir(), "pkg")
+			if err := g.clone(dst, u, environ); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(filepath.Join(dst, "main.tf"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, string(got)); diff != "" {
+				t.Errorf("wrong content\n%s", diff)
+			}
+
+			cmd := exec.Command("git", "config", "user.name")
+			cmd.Dir = dst
+			cmd.Env = environ
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := strings.TrimSpace(string(out)), "from-environ"; got != want {
+				t.Errorf("wrong user.name %q; want %q", got, want)
+			}
+			// The setting must not have been written into the repository.
+			cmd = exec.Command("git", "config", "--local", "user.name")
+			cmd.Dir = dst
+			if out, err := cmd.Output(); err == nil {
+				t.Errorf("environment setting was stored in the repository: %s", out)
+			}
+		})
+	}
+}
+
+// staticCredentialsEnvironment is a [PackageFetcherEnvironment] that returns
+// the same source credentials for every host.
+type staticCredentialsEnvironment struct {
+	noopPackageFetcherEnvironment
+	creds *SourceCredentials
+}
+
+func (e staticCredentialsEnvironment) SourceCredentials(ctx context.Context, hostname string) (*SourceCredentials, error) {
+	return e.creds, nil
+}
@@ -101,7 +101,7 @@ var goGetterDecompressorMediaTypes = map[string]string{
 var goGetterGetters = map[string]getter.Getter{
 	"file":  new(getter.FileGetter),
 	"gcs":   new(getter.GCSGetter),
-	"git":   new(getter.GitGetter),
+	"git":   nil, // configured dynamically using [PackageFetcherEnvironment.SourceCredentials]
 	"hg":    new(getter.HgGetter),
 	"http":  nil, // configured dynamically in NewPackageFetcher
 	"https": nil, // configured dynamically in NewPackageFetcher
@@ -0,0 +1,157 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package getmodules
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// gitCommitPattern matches a full SHA-1 or SHA-256 git object name.
+var gitCommitPattern = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// IsGitCommit returns true if the given git ref is a full commit object name,
+// rather than a branch or tag that might later move.
+func IsGitCommit(ref string) bool {
+	return gitCommitPattern.MatchString(ref)
+}
+
+// ResolveGitCommit returns the given package address of a git repository
+// with its ref replaced by the commit that the ref currently refers to in the
+// remote repository, so that the result always identifies the same content.
+// A package address without a ref refers to the default branch of the
+// repository.
+//
+// The result has no "depth" argument, because a shallow clone can't check
+// out an arbitrary commit.
+//
+// packageAddr must use the "git::" forced getter prefix, as produced by
+// addrs.ModulePackage.String() for git repositories. ResolveGitCommit runs
+// "git ls-remote", using any credentials configured for the host of the
+// repository, and returns an error if the ref can't be found.
+func (f *PackageFetcher) ResolveGitCommit(ctx context.Context, packageAddr string) (string, error) {
+	raw, ok := strings.CutPrefix(packageAddr, "git::")
+	if !ok {
+		return "", fmt.Errorf("%q is not a git repository address", packageAddr)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid git repository address %q: %w", packageAddr, err)
+	}
+	query := u.Query()
+	ref := query.Get("ref")
+	if IsGitCommit(ref) {
+		return packageAddr, nil
+	}
+
+	// The query string arguments are for go-getter, not for git.
+	remote := *u
+	remote.RawQuery = ""
+
+	pattern := ref
+	if pattern == "" {
+		pattern = "HEAD"
+	}
+	// Peeled annotated tags only match a pattern that includes the "^{}"
+	// suffix, so we ask for those too.
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--", remote.String(), pattern, pattern+"^{}")
+	cmd.Env, err = gitCommandEnv(ctx, f.env, u)
+	if err != nil {
+		return "", err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %q in %s: %w\n%s", pattern, remote.Redacted(), err, strings.TrimSpace(stderr.String()))
+	}
+
+	commit := gitCommitFromLsRemote(out, pattern)
+	if commit == "" {
+		return "", fmt.Errorf("%s has no branch or tag named %q", remote.Redacted(), pattern)
+	}
+
+	query.Set("ref", commit)
+	query.Del("depth")
+	u.RawQuery = query.Encode()
+	return "git::" + u.String(), nil
+}
+
+// CheckedOutGitCommit returns the given package address of a git repository
+// with its ref replaced by the commit that is checked out in the given
+// directory, which the package must have been installed into. Unlike
+// ResolveGitCommit, this doesn't need access to the remote repository.
+//
+// Like ResolveGitCommit, the result has no "depth" argument. The package
+// address is returned unchanged if it isn't a git repository address, or if
+// the commit can't be determined.
+func CheckedOutGitCommit(ctx context.Context, packageAddr string, dir string) string {
+	if !strings.HasPrefix(packageAddr, "git::") {
+		return packageAddr
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return packageAddr
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		log.Printf("[WARN] failed to determine the commit checked out in %s: %s", dir, err)
+		return packageAddr
+	}
+	commit := strings.TrimSpace(string(out))
+	if !IsGitCommit(commit) {
+		log.Printf("[WARN] git reported %q as the commit checked out in %s, which is not a commit object name", commit, dir)
+		return packageAddr
+	}
+
+	base, rawQuery, _ := strings.Cut(packageAddr, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return packageAddr
+	}
+	query.Set("ref", commit)
+	query.Del("depth")
+	return base + "?" + query.Encode()
+}
+
+// gitCommitFromLsRemote returns the commit that the given ref refers to in
+// the given output of "git ls-remote", or an empty string if it isn't there.
+//
+// The ref is resolved in the same way as "git clone --branch", preferring a
+// branch over a tag of the same name. For annotated tags we return the commit
+// the tag points to, rather than the tag object itself.
+func gitCommitFromLsRemote(out []byte, ref string) string {
+	refs := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		commit, name, ok := strings.Cut(sc.Text(), "\t")
+		if ok && IsGitCommit(commit) {
+			refs[name] = commit
+		}
+	}
+
+	for _, name := range []string{
+		ref + "^{}",
+		ref,
+		"refs/heads/" + ref,
+		"refs/tags/" + ref + "^{}",
+		"refs/tags/" + ref,
+	} {
+		if commit, ok := refs[name]; ok {
+			return commit
+		}
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package getmodules
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitCommitFromLsRemote(t *testing.T) {
+	const (
+		branchCommit = "1111111111111111111111111111111111111111"
+		tagObject    = "2222222222222222222222222222222222222222"
+		tagCommit    = "3333333333333333333333333333333333333333"
+		headCommit   = "4444444444444444444444444444444444444444"
+	)
+	out := []byte(strings.Join([]string{
+		headCommit + "\tHEAD",
+		branchCommit + "\trefs/heads/v1",
+		tagObject + "\trefs/tags/v1",
+		tagObject + "\trefs/tags/v2",
+		tagCommit + "\trefs/tags/v2^{}",
+		"",
+	}, "\n"))
+
+	tests := map[string]string{
+		"HEAD":         headCommit,
+		"v1":           branchCommit, // a branch wins over a tag
+		"refs/tags/v1": tagObject,
+		"v2":           tagCommit, // annotated tags resolve to their commit
+		"v3":           "",
+	}
+	for ref, want := range tests {
+		t.Run(ref, func(t *testing.T) {
+			if got := gitCommitFromLsRemote(out, ref); got != want {
+				t.Errorf("wrong commit\ngot:  %q\nwant: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPackageFetcherResolveGitCommit(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %s\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(content string) string {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repoDir, "main.tf"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "main.tf")
+		git("commit", "-q", "-m", content)
+		return git("rev-parse", "HEAD")
+	}
+
+	git("init", "-q", "-b", "main")
+	first := commit("# first\n")
+	git("tag", "-a", "-m", "v1", "v1")
+	second := commit("# second\n")
+
+	fetcher := NewPackageFetcher(t.Context(), nil)
+	repoURL := "git::file://" + filepath.ToSlash(repoDir)
+	tests := map[string]string{
+		repoURL:                             repoURL + "?ref=" + second,
+		repoURL + "?ref=main":               repoURL + "?ref=" + second,
+		repoURL + "?ref=main&depth=1":       repoURL + "?ref=" + second,
+		repoURL + "?ref=v1":                 repoURL + "?ref=" + first,
+		repoURL + "?ref=" + first:           repoURL + "?ref=" + first,
+		repoURL + "?ref=refs/tags/v1&depth": repoURL + "?ref=" + first,
+	}
+	for addr, want := range tests {
+		got, err := fetcher.ResolveGitCommit(t.Context(), addr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", addr, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s: wrong result\ngot:  %s\nwant: %s", addr, got, want)
+		}
+	}
+
+	// Moving the branch changes the result.
+	third := commit("# third\n")
+	got, err := fetcher.ResolveGitCommit(t.Context(), repoURL+"?ref=main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := repoURL + "?ref=" + third; got != want {
+		t.Errorf("wrong result after commit\ngot:  %s\nwant: %s", got, want)
+	}
+
+	if _, err := fetcher.ResolveGitCommit(t.Context(), repoURL+"?ref=nonexistent"); err == nil {
+		t.Error("unexpected success for a nonexistent ref")
+	}
+	if _, err := fetcher.ResolveGitCommit(t.Context(), "https://example.com/foo.zip"); err == nil {
+		t.Error("unexpected success for a non-git address")
+	}
+}
+
+func TestCheckedOutGitCommit(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %s\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q", "-b", "main")
+	git("commit", "-q", "--allow-empty", "-m", "first")
+	commit := git("rev-parse", "HEAD")
+
+	tests := map[string]string{
+		"git::https://example.com/foo.git":                   "git::https://example.com/foo.git?ref=" + commit,
+		"git::https://example.com/foo.git?ref=main&depth=1":  "git::https://example.com/foo.git?ref=" + commit,
+		"https://example.com/foo.zip":                        "https://example.com/foo.zip",
+		"git::https://example.com/foo.git?ref=" + commit[:7]: "git::https://example.com/foo.git?ref=" + commit,
+	}
+	for addr, want := range tests {
+		if got := CheckedOutGitCommit(t.Context(), addr, repoDir); got != want {
+			t.Errorf("%s: wrong result\ngot:  %s\nwant: %s", addr, got, want)
+		}
+	}
+
+	// A directory that isn't a git repository leaves the address unchanged.
+	addr := "git::https://example.com/foo.git?ref=main"
+	if got := CheckedOutGitCommit(t.Context(), addr, t.TempDir()); got != addr {
+		t.Errorf("wrong result for a directory without a repository\ngot:  %s\nwant: %s", got, addr)
+	}
+}
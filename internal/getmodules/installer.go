@@ -14,6 +14,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	getter "github.com/hashicorp/go-getter"
+	"github.com/opentofu/opentofu/internal/cosign"
 	"github.com/opentofu/opentofu/internal/httpclient"
 	"github.com/opentofu/opentofu/internal/tracing"
 )
@@ -33,6 +34,7 @@ import (
 // live only for the duration of a single initialization process.
 type PackageFetcher struct {
 	getter *reusingGetter
+	env    PackageFetcherEnvironment
 }
 
 // NewPackageFetcher constructs a new [PackageFetcher] that interacts with
@@ -47,6 +49,13 @@ func NewPackageFetcher(ctx context.Context, env PackageFetcherEnvironment) *Pack
 
 	var httpClient = httpclient.New(ctx)
 
+	// Any credentials configured for module source hosts are added to
+	// the requests made by the HTTP getter, by wrapping the transport.
+	httpClient.Transport = &credentialsTransport{
+		inner: httpClient.Transport,
+		env:   env,
+	}
+
 	// We use goGetterGetters as our starting point for the available
 	// getters, but some need to be instantiated dynamically based on
 	// the given "env". We shallow-copy the source map so that multiple
@@ -57,6 +66,14 @@ func NewPackageFetcher(ctx context.Context, env PackageFetcherEnvironment) *Pack
 	// centrally-configured policy, encapsulated in env.OCIRepositoryStore.
 	getters["oci"] = &ociDistributionGetter{
 		getOCIRepositoryStore: env.OCIRepositoryStore,
+		getOCISignaturePolicy: env.OCISignaturePolicy,
+	}
+
+	// The git getter also needs to use any credentials configured for
+	// the host that the repository is on.
+	getters["git"] = &credentialsGitGetter{
+		GitGetter: new(getter.GitGetter),
+		env:       env,
 	}
 
 	// The HTTP getter (used for both "http" and "https" schemes) uses
@@ -73,6 +90,7 @@ func NewPackageFetcher(ctx context.Context, env PackageFetcherEnvironment) *Pack
 
 	return &PackageFetcher{
 		getter: newReusingGetter(getters),
+		env:    env,
 	}
 }
 
@@ -113,6 +131,16 @@ func (f *PackageFetcher) FetchPackage(ctx context.Context, instDir string, packa
 // concerns is still the best design for that different context.
 type PackageFetcherEnvironment interface {
 	OCIRepositoryStore(ctx context.Context, registryDomainName, repositoryPath string) (OCIRepositoryStore, error)
+
+	// OCISignaturePolicy returns the cosign signature policy that module
+	// packages from the given OCI repository must satisfy, or nil if
+	// signatures are not required for that repository.
+	OCISignaturePolicy(ctx context.Context, registryDomainName, repositoryPath string) (*cosign.Policy, error)
+
+	// SourceCredentials returns the credentials to use when fetching module
+	// packages from the given host using git or HTTP, or nil if there are
+	// no credentials configured for that host.
+	SourceCredentials(ctx context.Context, hostname string) (*SourceCredentials, error)
 }
 
 // preparePackageFetcherEnvironment takes a [PackageFetcherEnvironment]
@@ -137,3 +165,13 @@ type noopPackageFetcherEnvironment struct{}
 func (n noopPackageFetcherEnvironment) OCIRepositoryStore(ctx context.Context, registryDomainName string, repositoryPath string) (OCIRepositoryStore, error) {
 	return nil, fmt.Errorf("module installation from OCI repositories is not available in this context")
 }
+
+// OCISignaturePolicy implements PackageFetcherEnvironment.
+func (n noopPackageFetcherEnvironment) OCISignaturePolicy(ctx context.Context, registryDomainName string, repositoryPath string) (*cosign.Policy, error) {
+	return nil, nil
+}
+
+// SourceCredentials implements PackageFetcherEnvironment.
+func (n noopPackageFetcherEnvironment) SourceCredentials(ctx context.Context, hostname string) (*SourceCredentials, error) {
+	return nil, nil
+}
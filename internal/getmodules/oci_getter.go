@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/url"
 	"os"
 	"strings"
@@ -17,6 +18,7 @@ import (
 	getter "github.com/hashicorp/go-getter"
 	ociDigest "github.com/opencontainers/go-digest"
 	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/opentofu/opentofu/internal/cosign"
 	"github.com/opentofu/opentofu/internal/tracing"
 	otelAttr "go.opentelemetry.io/otel/attribute"
 	otelTrace "go.opentelemetry.io/otel/trace"
@@ -57,6 +59,12 @@ var ociBlobMediaTypePreference = []string{
 type ociDistributionGetter struct {
 	getOCIRepositoryStore func(ctx context.Context, registryDomain, repositoryName string) (OCIRepositoryStore, error)
 
+	// getOCISignaturePolicy returns the cosign signature policy that applies
+	// to module packages from the given repository, or nil if signatures
+	// are not required. If this field is nil then signatures are never
+	// required.
+	getOCISignaturePolicy func(ctx context.Context, registryDomain, repositoryName string) (*cosign.Policy, error)
+
 	// go-getter sets this by calling our SetClient method whenever
 	// the client is configured, which happens automatically
 	// when it Get method is called.
@@ -94,6 +102,11 @@ func (g *ociDistributionGetter) Get(destDir string, url *url.URL) error {
 		tracing.SetSpanError(span, err)
 		return err
 	}
+	err = g.verifySignature(ctx, ref, manifestDesc, store)
+	if err != nil {
+		tracing.SetSpanError(span, err)
+		return err
+	}
 	manifest, err := fetchOCIImageManifest(ctx, manifestDesc, store)
 	if err != nil {
 		tracing.SetSpanError(span, err)
@@ -132,6 +145,30 @@ func (g *ociDistributionGetter) Get(destDir string, url *url.URL) error {
 	return nil
 }
 
+// verifySignature checks the cosign signatures of the given manifest if the
+// signature policy requires that for the repository it belongs to.
+//
+// The manifest refers to the package blob by digest, so its signature covers
+// the whole package.
+func (g *ociDistributionGetter) verifySignature(ctx context.Context, ref *orasRegistry.Reference, manifestDesc ociv1.Descriptor, store OCIRepositoryStore) error {
+	if g.getOCISignaturePolicy == nil {
+		return nil
+	}
+	policy, err := g.getOCISignaturePolicy(ctx, ref.Registry, ref.Repository)
+	if err != nil {
+		return fmt.Errorf("configuring signature verification for %s: %w", ref, err)
+	}
+	if policy == nil {
+		return nil // signatures are not required for this repository
+	}
+	signer, err := policy.Verify(ctx, store, manifestDesc.Digest)
+	if err != nil {
+		return fmt.Errorf("verifying signature for manifest %s: %w", manifestDesc.Digest, err)
+	}
+	log.Printf("[INFO] ociDistributionGetter: manifest %s from %s is signed by %s", manifestDesc.Digest, ref, signer)
+	return nil
+}
+
 // GetFile implements getter.Getter.
 func (g *ociDistributionGetter) GetFile(string, *url.URL) error {
 	// With how OpenTofu uses go-getter we can only get in here if
@@ -9,7 +9,14 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"os"
@@ -22,6 +29,8 @@ import (
 	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	orasContent "oras.land/oras-go/v2/content"
 	orasMemoryStore "oras.land/oras-go/v2/content/memory"
+
+	"github.com/opentofu/opentofu/internal/cosign"
 )
 
 func TestGetterDecompressorsConsistent(t *testing.T) {
@@ -237,6 +246,100 @@ func TestOCIDistributionGetter(t *testing.T) {
 
 }
 
+func TestOCIDistributionGetter_signatures(t *testing.T) {
+	store := digestResolvingInMemoryOCIStore{
+		orasMemoryStore.New(),
+	}
+	signedBlobDesc := ociPushFakeModulePackageBlob(t, "content of signed", store)
+	signedManifestDesc := ociPushFakeImageManifest(t, signedBlobDesc, ociIndexManifestArtifactType, store)
+	ociCreateTag(t, "signed", signedManifestDesc, store)
+	unsignedBlobDesc := ociPushFakeModulePackageBlob(t, "content of unsigned", store)
+	unsignedManifestDesc := ociPushFakeImageManifest(t, unsignedBlobDesc, ociIndexManifestArtifactType, store)
+	ociCreateTag(t, "unsigned", unsignedManifestDesc, store)
+
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ociPushFakeSignature(t, signedManifestDesc.Digest, signingKey, store)
+	der, err := x509.MarshalPKIXPublicKey(signingKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := cosign.ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ociGetter := &ociDistributionGetter{
+		getOCIRepositoryStore: func(ctx context.Context, registryDomain, repositoryName string) (OCIRepositoryStore, error) {
+			return store, nil
+		},
+		getOCISignaturePolicy: func(ctx context.Context, registryDomain, repositoryName string) (*cosign.Policy, error) {
+			if repositoryName != "verified" {
+				return nil, nil
+			}
+			return &cosign.Policy{PublicKeys: []*cosign.PublicKey{publicKey}}, nil
+		},
+	}
+
+	tests := []struct {
+		source          string
+		wantFileContent string
+		wantError       string
+	}{
+		{
+			source:          "oci://example.com/verified?tag=signed",
+			wantFileContent: `content of signed`,
+		},
+		{
+			source:          "oci://example.com/unverified?tag=unsigned",
+			wantFileContent: `content of unsigned`,
+		},
+		{
+			source:    "oci://example.com/verified?tag=unsigned",
+			wantError: fmt.Sprintf(`error downloading 'oci://example.com/verified?tag=unsigned': verifying signature for manifest %s: finding signatures in tag %q: not found`, unsignedManifestDesc.Digest, cosign.SignatureTag(unsignedManifestDesc.Digest)),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			instPath := t.TempDir()
+			client := getter.Client{
+				Src:       test.source,
+				Dst:       instPath,
+				Pwd:       instPath,
+				Mode:      getter.ClientModeDir,
+				Detectors: goGetterNoDetectors,
+				Getters: map[string]getter.Getter{
+					"oci": ociGetter,
+				},
+				Ctx: t.Context(),
+			}
+			err := client.Get()
+
+			if test.wantError != "" {
+				if err == nil {
+					t.Fatalf("unexpected success\nwant error: %s", test.wantError)
+				}
+				if got := err.Error(); got != test.wantError {
+					t.Fatalf("unexpected error\ngot:  %s\nwant: %s", got, test.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			gotContentRaw, err := os.ReadFile(filepath.Join(instPath, "test_content.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(bytes.TrimSpace(gotContentRaw)); got != test.wantFileContent {
+				t.Errorf("wrong file content after successful install\ngot:  %s\nwant: %s", got, test.wantFileContent)
+			}
+		})
+	}
+}
+
 func ociPushFakeModulePackageBlob(t *testing.T, fakeContent string, store orasContent.Pusher) ociv1.Descriptor {
 	t.Helper()
 
@@ -306,6 +409,32 @@ func ociCreateTag(t *testing.T, tagName string, desc ociv1.Descriptor, store ora
 	}
 }
 
+// ociPushFakeSignature stores a cosign signature for the manifest with the
+// given digest, in the same layout that "cosign sign" would create.
+func ociPushFakeSignature(t *testing.T, manifestDigest ociDigest.Digest, key *ecdsa.PrivateKey, store digestResolvingInMemoryOCIStore) {
+	t.Helper()
+
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"example.com/verified"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, manifestDigest))
+	payloadSum := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, payloadSum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	layerDesc := ociv1.Descriptor{
+		MediaType: "application/vnd.dev.cosign.simplesigning.v1+json",
+		Digest:    ociDigest.FromBytes(payload),
+		Size:      int64(len(payload)),
+	}
+	if err := store.Push(t.Context(), layerDesc, bytes.NewReader(payload)); err != nil {
+		t.Fatalf("can't push signature payload to store: %s", err)
+	}
+	layerDesc.Annotations = map[string]string{
+		"dev.cosignproject.cosign/signature": base64.StdEncoding.EncodeToString(sig),
+	}
+	manifestDesc := ociPushFakeImageManifest(t, layerDesc, "", store)
+	ociCreateTag(t, cosign.SignatureTag(manifestDigest), manifestDesc, store)
+}
+
 // digestResolvingInMemoryOCIStore is a moderately-ugly hack to make
 // ORAS-Go's in memory store behave slightly more like a realistic
 // OCI Distribution registry server by allowing the resolution of
@@ -502,6 +502,15 @@ type HashDisposition struct {
 	// to define what it means for a key to be "trusted".
 	SignedByGPGKeyIDs collections.Set[string]
 
+	// SignedByOCISigners is a set of descriptions of the signers whose
+	// cosign signatures covering the OCI artifact that the associated hash
+	// was derived from were verified against the signature verification
+	// settings in the CLI configuration.
+	//
+	// Unlike SignedByGPGKeyIDs, these signers were already judged to be
+	// trusted by the time this is populated.
+	SignedByOCISigners collections.Set[string]
+
 	// ReportedByRegistry is set if this hash was reported by the associated
 	// provider's origin registry as being one of the official hashes for
 	// this provider release.
@@ -573,6 +582,16 @@ func MergeHashDisposition(a, b *HashDisposition) *HashDisposition {
 			ret.SignedByGPGKeyIDs[key] = struct{}{}
 		}
 	}
+	ociSignerCount := len(a.SignedByOCISigners) + len(b.SignedByOCISigners)
+	if ociSignerCount > 0 {
+		ret.SignedByOCISigners = make(collections.Set[string], ociSignerCount)
+		for signer := range a.SignedByOCISigners {
+			ret.SignedByOCISigners[signer] = struct{}{}
+		}
+		for signer := range b.SignedByOCISigners {
+			ret.SignedByOCISigners[signer] = struct{}{}
+		}
+	}
 	ret.ReportedByRegistry = a.ReportedByRegistry || b.ReportedByRegistry
 	ret.VerifiedLocally = a.VerifiedLocally || b.VerifiedLocally
 	return ret
@@ -598,6 +617,13 @@ type HashDispositions map[Hash]*HashDisposition
 // than for machine-readable purposes. The exact format might change in future
 // versions.
 func (ds HashDispositions) AllGPGSigningKeysString() string {
+	return strings.Join(ds.AllGPGSigningKeys(), ", ")
+}
+
+// AllGPGSigningKeys returns all GPG signing key IDs that signed an assertion
+// that one of the hashes is valid for the associated provider version, in
+// lexical order.
+func (ds HashDispositions) AllGPGSigningKeys() []string {
 	allKeyIDs := make(collections.Set[string])
 	for _, disp := range ds {
 		for keyID := range disp.SignedByGPGKeyIDs {
@@ -608,7 +634,33 @@ func (ds HashDispositions) AllGPGSigningKeysString() string {
 	// first collect them into a slice and sort them.
 	keyIDs := slices.Collect(maps.Keys(allKeyIDs))
 	sort.Strings(keyIDs)
-	return strings.Join(keyIDs, ", ")
+	return keyIDs
+}
+
+// AllOCISignersString returns a string representation of all of the signers
+// whose cosign signatures covered at least one of the hashes.
+//
+// If there are no such signers then the result is an empty string.
+//
+// The result of this is intended for display to a human in the UI, rather
+// than for machine-readable purposes. The exact format might change in future
+// versions.
+func (ds HashDispositions) AllOCISignersString() string {
+	return strings.Join(ds.AllOCISigners(), ", ")
+}
+
+// AllOCISigners returns all of the signers whose cosign signatures covered
+// at least one of the hashes, in lexical order.
+func (ds HashDispositions) AllOCISigners() []string {
+	allSigners := make(collections.Set[string])
+	for _, disp := range ds {
+		for signer := range disp.SignedByOCISigners {
+			allSigners[signer] = struct{}{}
+		}
+	}
+	signers := slices.Collect(maps.Keys(allSigners))
+	sort.Strings(signers)
+	return signers
 }
 
 func (ds HashDispositions) HasAnyReportedByRegistry() bool {
@@ -0,0 +1,252 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package getproviders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	ociDigest "github.com/opencontainers/go-digest"
+	ociSpecs "github.com/opencontainers/image-spec/specs-go"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	otelAttr "go.opentelemetry.io/otel/attribute"
+	otelTrace "go.opentelemetry.io/otel/trace"
+	orasErrors "oras.land/oras-go/v2/errdef"
+
+	"github.com/opentofu/opentofu/internal/tracing"
+	"github.com/opentofu/opentofu/internal/tracing/traceattrs"
+)
+
+// OCIRepositoryPushStore extends [OCIRepositoryStore] with the additional
+// operations needed to publish provider packages into an OCI repository
+// using [PushOCIMirrorProviderVersion].
+type OCIRepositoryPushStore interface {
+	OCIRepositoryStore
+
+	// Exists returns whether the content described by the given descriptor
+	// is already present in the repository.
+	Exists(ctx context.Context, target ociv1.Descriptor) (bool, error)
+
+	// Push uploads the given content, which must match the size and digest
+	// in the given descriptor.
+	//
+	// Implementations use the media type in the descriptor to decide whether
+	// the content is a manifest or a blob.
+	Push(ctx context.Context, expected ociv1.Descriptor, content io.Reader) error
+
+	// Tag associates the given tag name with the manifest described by the
+	// given descriptor, replacing any existing association for that tag.
+	Tag(ctx context.Context, desc ociv1.Descriptor, reference string) error
+
+	// As with [OCIRepositoryStore], the above intentionally matches a subset
+	// of the interfaces defined in the ORAS-Go library.
+}
+
+// OCIMirrorPackage is a provider package to be published into an OCI
+// repository using [PushOCIMirrorProviderVersion].
+type OCIMirrorPackage struct {
+	TargetPlatform Platform
+	Archive        PackageLocalArchive
+}
+
+// PushOCIMirrorProviderVersion publishes the given packages for a single
+// provider version into an OCI repository, using the layout that
+// [OCIRegistryMirrorSource] expects: a tag named after the version number
+// refers to an index manifest that in turn refers to an image manifest for
+// each platform, each of which has the package as its only layer.
+//
+// If the tag for the version already refers to a provider index manifest
+// then the new index manifest also includes the entries for any platforms
+// from the existing one that aren't in packages, so that a mirror can be
+// populated incrementally one platform at a time. If the tag refers to
+// something other than a provider index manifest then this function returns
+// an error rather than replacing it.
+//
+// Content that is already present in the repository is not uploaded again.
+// The tag is updated only once everything it refers to has been uploaded,
+// so clients of the repository never observe a partially-published version.
+func PushOCIMirrorProviderVersion(ctx context.Context, store OCIRepositoryPushStore, version Version, packages []OCIMirrorPackage) (ociv1.Descriptor, error) {
+	ctx, span := tracing.Tracer().Start(
+		ctx, "Push provider version to OCI repository",
+		otelTrace.WithAttributes(
+			otelAttr.String(traceattrs.ProviderVersion, version.String()),
+		),
+	)
+	defer span.End()
+	prepErr := func(err error) (ociv1.Descriptor, error) {
+		tracing.SetSpanError(span, err)
+		return ociv1.Descriptor{}, err
+	}
+
+	var manifests []ociv1.Descriptor
+	existing, err := fetchExistingOCIIndexManifest(ctx, version, store)
+	if err != nil {
+		return prepErr(err)
+	}
+	if existing != nil {
+		for _, desc := range existing.Manifests {
+			replaced := slices.ContainsFunc(packages, func(pkg OCIMirrorPackage) bool {
+				return desc.Platform != nil && desc.Platform.OS == pkg.TargetPlatform.OS && desc.Platform.Architecture == pkg.TargetPlatform.Arch
+			})
+			if !replaced {
+				manifests = append(manifests, desc)
+			}
+		}
+	}
+
+	// All of the image manifests share the same empty configuration blob,
+	// as is conventional for artifacts that aren't container images.
+	if err := pushOCIContentIfMissing(ctx, store, ociv1.DescriptorEmptyJSON, bytes.NewReader(ociv1.DescriptorEmptyJSON.Data)); err != nil {
+		return prepErr(fmt.Errorf("pushing empty configuration blob: %w", err))
+	}
+	for _, pkg := range packages {
+		desc, err := pushOCIMirrorPackage(ctx, store, pkg)
+		if err != nil {
+			return prepErr(fmt.Errorf("pushing package for %s: %w", pkg.TargetPlatform, err))
+		}
+		manifests = append(manifests, desc)
+	}
+	// We sort the manifests by platform so that pushing the same set of
+	// packages always produces the same index manifest digest.
+	slices.SortStableFunc(manifests, func(a, b ociv1.Descriptor) int {
+		return strings.Compare(ociPlatformSortKey(a.Platform), ociPlatformSortKey(b.Platform))
+	})
+
+	indexDesc, err := pushOCIManifest(ctx, store, ociv1.MediaTypeImageIndex, ociIndexManifestArtifactType, &ociv1.Index{
+		Versioned:    ociSpecs.Versioned{SchemaVersion: 2},
+		MediaType:    ociv1.MediaTypeImageIndex,
+		ArtifactType: ociIndexManifestArtifactType,
+		Manifests:    manifests,
+	})
+	if err != nil {
+		return prepErr(fmt.Errorf("pushing index manifest: %w", err))
+	}
+	tagName := strings.ReplaceAll(version.String(), "+", "_")
+	if err := store.Tag(ctx, indexDesc, tagName); err != nil {
+		return prepErr(fmt.Errorf("creating tag %q: %w", tagName, err))
+	}
+	span.SetAttributes(
+		otelAttr.String("oci.manifest.digest", indexDesc.Digest.String()),
+		otelAttr.String("opentofu.oci.reference.tag", tagName),
+	)
+	return indexDesc, nil
+}
+
+// fetchExistingOCIIndexManifest returns the index manifest that the tag for
+// the given version currently refers to, or nil if there is no such tag.
+func fetchExistingOCIIndexManifest(ctx context.Context, version Version, store OCIRepositoryStore) (*ociv1.Index, error) {
+	tagName := strings.ReplaceAll(version.String(), "+", "_")
+	if _, err := store.Resolve(ctx, tagName); err != nil {
+		if errors.Is(err, orasErrors.ErrNotFound) || errRepresentsOCIProviderNotFound(err) {
+			return nil, nil // nothing to preserve, then
+		}
+		return nil, fmt.Errorf("resolving tag %q: %w", tagName, err)
+	}
+	desc, err := fetchOCIDescriptorForVersion(ctx, version, store)
+	if err != nil {
+		return nil, fmt.Errorf("existing tag %q cannot be updated: %w", tagName, err)
+	}
+	index, err := fetchOCIIndexManifest(ctx, desc, store)
+	if err != nil {
+		return nil, fmt.Errorf("existing tag %q cannot be updated: %w", tagName, err)
+	}
+	return index, nil
+}
+
+// pushOCIMirrorPackage pushes a single package archive and the image
+// manifest that refers to it, returning a descriptor for the image manifest
+// suitable for inclusion in an index manifest.
+func pushOCIMirrorPackage(ctx context.Context, store OCIRepositoryPushStore, pkg OCIMirrorPackage) (ociv1.Descriptor, error) {
+	f, err := os.Open(string(pkg.Archive))
+	if err != nil {
+		return ociv1.Descriptor{}, err
+	}
+	defer f.Close()
+	digest, err := ociDigest.SHA256.FromReader(f)
+	if err != nil {
+		return ociv1.Descriptor{}, fmt.Errorf("reading %s: %w", pkg.Archive, err)
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return ociv1.Descriptor{}, fmt.Errorf("reading %s: %w", pkg.Archive, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return ociv1.Descriptor{}, fmt.Errorf("reading %s: %w", pkg.Archive, err)
+	}
+	blobDesc := ociv1.Descriptor{
+		MediaType: ociPackageMediaType,
+		Digest:    digest,
+		Size:      size,
+		Annotations: map[string]string{
+			ociv1.AnnotationTitle: filepath.Base(string(pkg.Archive)),
+		},
+	}
+	if err := pushOCIContentIfMissing(ctx, store, blobDesc, f); err != nil {
+		return ociv1.Descriptor{}, fmt.Errorf("pushing package blob: %w", err)
+	}
+
+	manifestDesc, err := pushOCIManifest(ctx, store, ociv1.MediaTypeImageManifest, ociPackageManifestArtifactType, &ociv1.Manifest{
+		Versioned:    ociSpecs.Versioned{SchemaVersion: 2},
+		MediaType:    ociv1.MediaTypeImageManifest,
+		ArtifactType: ociPackageManifestArtifactType,
+		Config:       ociv1.DescriptorEmptyJSON,
+		Layers:       []ociv1.Descriptor{blobDesc},
+	})
+	if err != nil {
+		return ociv1.Descriptor{}, fmt.Errorf("pushing image manifest: %w", err)
+	}
+	manifestDesc.Platform = &ociv1.Platform{
+		OS:           pkg.TargetPlatform.OS,
+		Architecture: pkg.TargetPlatform.Arch,
+	}
+	return manifestDesc, nil
+}
+
+// pushOCIManifest serializes the given manifest as JSON and pushes it,
+// returning its descriptor.
+func pushOCIManifest(ctx context.Context, store OCIRepositoryPushStore, mediaType, artifactType string, manifest any) (ociv1.Descriptor, error) {
+	src, err := json.Marshal(manifest)
+	if err != nil {
+		// Should not happen, because the manifest types are all JSON-serializable.
+		return ociv1.Descriptor{}, err
+	}
+	desc := ociv1.Descriptor{
+		MediaType:    mediaType,
+		ArtifactType: artifactType,
+		Digest:       ociDigest.FromBytes(src),
+		Size:         int64(len(src)),
+	}
+	if err := pushOCIContentIfMissing(ctx, store, desc, bytes.NewReader(src)); err != nil {
+		return ociv1.Descriptor{}, err
+	}
+	return desc, nil
+}
+
+// pushOCIContentIfMissing pushes the given content unless the repository
+// already contains content matching the given descriptor.
+func pushOCIContentIfMissing(ctx context.Context, store OCIRepositoryPushStore, desc ociv1.Descriptor, content io.Reader) error {
+	exists, err := store.Exists(ctx, desc)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	return store.Push(ctx, desc, content)
+}
+
+func ociPlatformSortKey(platform *ociv1.Platform) string {
+	if platform == nil {
+		return ""
+	}
+	return platform.OS + "_" + platform.Architecture
+}
@@ -0,0 +1,145 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package getproviders
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ociv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	orasOCI "oras.land/oras-go/v2/content/oci"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+)
+
+func TestPushOCIMirrorProviderVersion(t *testing.T) {
+	store, err := orasOCI.NewWithContext(t.Context(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := addrs.MustParseProviderSourceString("example.com/foo/bar")
+	version := MustParseVersion("1.0.0+foo.1")
+	source := NewOCIRegistryMirrorSource(
+		t.Context(),
+		func(addr addrs.Provider) (string, string, error) {
+			return "example.com", fmt.Sprintf("%s_%s", addr.Namespace, addr.Type), nil
+		},
+		func(ctx context.Context, registryDomain, repositoryName string) (OCIRepositoryStore, error) {
+			return store, nil
+		},
+		nil,
+	)
+	makePackage := func(platform Platform) OCIMirrorPackage {
+		t.Helper()
+		filename := filepath.Join(t.TempDir(), fmt.Sprintf("terraform-provider-bar_%s_%s.zip", version, platform))
+		src := makePlaceholderProviderPackageZip(t, fmt.Sprintf("placeholder executable for %s", platform))
+		if err := os.WriteFile(filename, src, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return OCIMirrorPackage{
+			TargetPlatform: platform,
+			Archive:        PackageLocalArchive(filename),
+		}
+	}
+	checkInstall := func(t *testing.T, platform Platform) {
+		t.Helper()
+		meta, err := source.PackageMeta(t.Context(), provider, version, platform)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkgDir := t.TempDir()
+		authResult, err := meta.Location.(PackageOCIBlobArchive).InstallProviderPackage(t.Context(), meta, pkgDir, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := authResult.summaryResult(), verifiedChecksum; got != want {
+			t.Errorf("wrong authentication result\ngot:  %#v\nwant: %#v", got, want)
+		}
+		exeContent, err := os.ReadFile(filepath.Join(pkgDir, "terraform-provider-foo"))
+		if err != nil {
+			t.Fatalf("failed to read fake provider executable file: %s", err)
+		}
+		if got, want := string(exeContent), fmt.Sprintf("placeholder executable for %s", platform); got != want {
+			t.Errorf("wrong content in fake executable file\ngot:  %q\nwant: %q", got, want)
+		}
+	}
+	platformsInIndex := func(t *testing.T, desc ociv1.Descriptor) []string {
+		t.Helper()
+		index, err := fetchOCIIndexManifest(t.Context(), desc, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ret []string
+		for _, manifest := range index.Manifests {
+			ret = append(ret, manifest.Platform.OS+"_"+manifest.Platform.Architecture)
+		}
+		return ret
+	}
+
+	linux := Platform{OS: "linux", Arch: "amd64"}
+	darwin := Platform{OS: "darwin", Arch: "arm64"}
+	windows := Platform{OS: "windows", Arch: "amd64"}
+
+	t.Run("new version", func(t *testing.T) {
+		desc, err := PushOCIMirrorProviderVersion(t.Context(), store, version, []OCIMirrorPackage{
+			makePackage(linux),
+			makePackage(darwin),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"darwin_arm64", "linux_amd64"}, platformsInIndex(t, desc)); diff != "" {
+			t.Error("wrong platforms in index\n" + diff)
+		}
+		gotVersions, _, err := source.AvailableVersions(t.Context(), provider)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(VersionList{version}, gotVersions); diff != "" {
+			t.Error("wrong versions\n" + diff)
+		}
+		checkInstall(t, linux)
+		checkInstall(t, darwin)
+	})
+	t.Run("additional platform", func(t *testing.T) {
+		// Pushing a package for another platform must retain the packages
+		// for the platforms that were pushed previously.
+		desc, err := PushOCIMirrorProviderVersion(t.Context(), store, version, []OCIMirrorPackage{
+			makePackage(windows),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"darwin_arm64", "linux_amd64", "windows_amd64"}, platformsInIndex(t, desc)); diff != "" {
+			t.Error("wrong platforms in index\n" + diff)
+		}
+		checkInstall(t, linux)
+		checkInstall(t, windows)
+	})
+	t.Run("existing tag is not a provider", func(t *testing.T) {
+		otherVersion := MustParseVersion("2.0.0")
+		// (The empty configuration blob was already pushed by the earlier subtests.)
+		imageDesc := pushOCIImageManifest(t, &ociv1.Manifest{
+			MediaType:    ociv1.MediaTypeImageManifest,
+			ArtifactType: "application/vnd.opentofu.modulepkg",
+			Config:       ociv1.DescriptorEmptyJSON,
+		}, store)
+		createOCITag(t, otherVersion.String(), imageDesc, store)
+
+		_, err := PushOCIMirrorProviderVersion(t.Context(), store, otherVersion, []OCIMirrorPackage{
+			makePackage(linux),
+		})
+		if err == nil {
+			t.Fatal("unexpected success; want error")
+		}
+		if got, want := err.Error(), `existing tag "2.0.0" cannot be updated`; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant substring: %s", got, want)
+		}
+	})
+}
@@ -22,6 +22,7 @@ import (
 	orasRegistryErrors "oras.land/oras-go/v2/registry/remote/errcode"
 
 	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/cosign"
 	"github.com/opentofu/opentofu/internal/tracing"
 	"github.com/opentofu/opentofu/internal/tracing/traceattrs"
 )
@@ -134,6 +135,17 @@ type OCIRegistryMirrorSource struct {
 	// OCI registry".
 	getOCIRepositoryStore func(ctx context.Context, registryDomain, repositoryName string) (OCIRepositoryStore, error)
 
+	// getSignaturePolicy, if set, returns the policy describing the cosign
+	// signatures that the index manifest of each provider version must have.
+	// If this is nil or returns a nil policy then the packages are
+	// authenticated only by their checksums.
+	//
+	// This is a function so that the policy's trusted keys need to be loaded
+	// only when actually installing a provider. Errors from this function
+	// block installation from this source, rather than falling back to
+	// installing without signature verification.
+	getSignaturePolicy func(ctx context.Context) (*cosign.Policy, error)
+
 	// We keep an internal cache of the most-recently-instantiated
 	// repository store object because in the common case there will
 	// be call to AvailableVersions immediately followed by
@@ -152,10 +164,12 @@ func NewOCIRegistryMirrorSource(
 	_ context.Context,
 	resolveRepositoryAddr func(addr addrs.Provider) (registryDomain, repositoryName string, err error),
 	getRepositoryStore func(ctx context.Context, registryDomain, repositoryName string) (OCIRepositoryStore, error),
+	getSignaturePolicy func(ctx context.Context) (*cosign.Policy, error),
 ) *OCIRegistryMirrorSource {
 	return &OCIRegistryMirrorSource{
 		resolveOCIRepositoryAddr: resolveRepositoryAddr,
 		getOCIRepositoryStore:    getRepositoryStore,
+		getSignaturePolicy:       getSignaturePolicy,
 	}
 }
 
@@ -315,6 +329,22 @@ func (o *OCIRegistryMirrorSource) PackageMeta(ctx context.Context, provider addr
 	if err != nil {
 		return PackageMeta{}, err
 	}
+	// If signatures are required then we check them against the index manifest's
+	// digest before going any further. The index manifest refers to everything
+	// else we'll fetch by digest, so its signature covers the whole package.
+	var signer string
+	if o.getSignaturePolicy != nil {
+		policy, err := o.getSignaturePolicy(ctx)
+		if err != nil {
+			return PackageMeta{}, fmt.Errorf("configuring signature verification: %w", err)
+		}
+		if policy != nil {
+			signer, err = verifyOCISignature(ctx, policy, store, indexDesc)
+			if err != nil {
+				return PackageMeta{}, err
+			}
+		}
+	}
 	index, err := fetchOCIIndexManifest(ctx, indexDesc, store) // step 2
 	if err != nil {
 		return PackageMeta{}, err
@@ -346,6 +376,12 @@ func (o *OCIRegistryMirrorSource) PackageMeta(ctx context.Context, provider addr
 		return PackageMeta{}, err
 	}
 	authentication := NewPackageHashAuthentication(target, []Hash{expectedHash})
+	if signer != "" {
+		authentication = PackageAuthenticationAll(
+			authentication,
+			newOCISignatureAuthentication(expectedHash, signer),
+		)
+	}
 
 	// If we got through all of the above then we seem to have found a suitable
 	// package to install, but our job is only to describe its metadata.
@@ -473,6 +509,27 @@ type OCIRepositoryStore interface {
 	// that we can minimize the amount of adapter code we need to write.
 }
 
+func verifyOCISignature(ctx context.Context, policy *cosign.Policy, store OCIRepositoryStore, indexDesc ociv1.Descriptor) (string, error) {
+	ctx, span := tracing.Tracer().Start(
+		ctx, "Verify signature",
+		otelTrace.WithAttributes(
+			otelAttr.String("oci.manifest.digest", indexDesc.Digest.String()),
+		),
+	)
+	defer span.End()
+
+	signer, err := policy.Verify(ctx, store, indexDesc.Digest)
+	if err != nil {
+		err := fmt.Errorf("verifying signature for manifest %s: %w", indexDesc.Digest, err)
+		tracing.SetSpanError(span, err)
+		return "", err
+	}
+	span.SetAttributes(
+		otelAttr.String("opentofu.oci.signer", signer),
+	)
+	return signer, nil
+}
+
 func fetchOCIDescriptorForVersion(ctx context.Context, version versions.Version, store OCIRepositoryStore) (ociv1.Descriptor, error) {
 	ctx, span := tracing.Tracer().Start(
 		ctx, "Resolve reference",
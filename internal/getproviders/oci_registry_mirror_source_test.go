@@ -8,7 +8,14 @@ package getproviders
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"net/url"
 	"os"
@@ -27,6 +34,7 @@ import (
 	orasRegistryErrors "oras.land/oras-go/v2/registry/remote/errcode"
 
 	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/cosign"
 )
 
 func TestOCIRegistryMirrorSource(t *testing.T) {
@@ -320,6 +328,118 @@ func TestOCIRegistryMirrorSource(t *testing.T) {
 	})
 }
 
+func TestOCIRegistryMirrorSource_signatures(t *testing.T) {
+	store, err := orasOCI.NewWithContext(t.Context(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	platform := Platform{OS: "amigaos", Arch: "m86k"}
+	packageDesc := pushOCIBlob(t, "archive/zip", "", makePlaceholderProviderPackageZip(t, "placeholder executable"), store)
+	manifestDesc := pushOCIImageManifest(t, &ociv1.Manifest{
+		Versioned:    ociSpecs.Versioned{SchemaVersion: 2},
+		MediaType:    ociv1.MediaTypeImageManifest,
+		ArtifactType: "application/vnd.opentofu.provider-target",
+		Config:       ociv1.DescriptorEmptyJSON,
+		Layers:       []ociv1.Descriptor{packageDesc},
+	}, store)
+	manifestDesc.Platform = &ociv1.Platform{
+		Architecture: platform.Arch,
+		OS:           platform.OS,
+	}
+	indexDesc := pushOCIIndexManifest(t, &ociv1.Index{
+		Versioned:    ociSpecs.Versioned{SchemaVersion: 2},
+		MediaType:    ociv1.MediaTypeImageIndex,
+		ArtifactType: "application/vnd.opentofu.provider",
+		Manifests:    []ociv1.Descriptor{manifestDesc},
+	}, store)
+	createOCITag(t, "1.0.0", indexDesc, store)
+
+	// The signature is stored in the same layout that "cosign sign" would
+	// use, under a tag derived from the index manifest's digest.
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"example.com/foo_bar"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, indexDesc.Digest))
+	payloadSum := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, signingKey, payloadSum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	payloadDesc := pushOCIBlob(t, "application/vnd.dev.cosign.simplesigning.v1+json", "", payload, store)
+	payloadDesc.Annotations = map[string]string{
+		"dev.cosignproject.cosign/signature": base64.StdEncoding.EncodeToString(sig),
+	}
+	pushOCIBlob(t, ociv1.DescriptorEmptyJSON.MediaType, "", ociv1.DescriptorEmptyJSON.Data, store)
+	sigManifestDesc := pushOCIImageManifest(t, &ociv1.Manifest{
+		Versioned: ociSpecs.Versioned{SchemaVersion: 2},
+		MediaType: ociv1.MediaTypeImageManifest,
+		Config:    ociv1.DescriptorEmptyJSON,
+		Layers:    []ociv1.Descriptor{payloadDesc},
+	}, store)
+	createOCITag(t, cosign.SignatureTag(indexDesc.Digest), sigManifestDesc, store)
+
+	makeSource := func(key *ecdsa.PrivateKey) *OCIRegistryMirrorSource {
+		der, err := x509.MarshalPKIXPublicKey(key.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicKey, err := cosign.ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewOCIRegistryMirrorSource(
+			t.Context(),
+			func(addr addrs.Provider) (string, string, error) {
+				return "example.com", fmt.Sprintf("%s_%s", addr.Namespace, addr.Type), nil
+			},
+			func(ctx context.Context, registryDomain, repositoryName string) (OCIRepositoryStore, error) {
+				return store, nil
+			},
+			func(ctx context.Context) (*cosign.Policy, error) {
+				return &cosign.Policy{PublicKeys: []*cosign.PublicKey{publicKey}}, nil
+			},
+		)
+	}
+	fakeProvider := addrs.MustParseProviderSourceString("example.com/foo/bar")
+
+	t.Run("trusted signature", func(t *testing.T) {
+		source := makeSource(signingKey)
+		meta, err := source.PackageMeta(t.Context(), fakeProvider, MustParseVersion("1.0.0"), platform)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loc := meta.Location.(PackageOCIBlobArchive)
+		authResult, err := loc.InstallProviderPackage(t.Context(), meta, t.TempDir(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := authResult.summaryResult(), signed; got != want {
+			t.Errorf("wrong authentication result\ngot:  %#v\nwant: %#v", got, want)
+		}
+		if got := authResult.OCISignersString(); !strings.HasPrefix(got, "key SHA256:") {
+			t.Errorf("wrong signers %q", got)
+		}
+		if authResult.Signed() {
+			t.Errorf("result claims to be signed by a GPG key")
+		}
+	})
+	t.Run("untrusted signature", func(t *testing.T) {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		source := makeSource(otherKey)
+		_, err = source.PackageMeta(t.Context(), fakeProvider, MustParseVersion("1.0.0"), platform)
+		if err == nil {
+			t.Fatal("unexpected success; want error")
+		}
+		if got, want := err.Error(), "not made by any of the trusted public keys"; !strings.Contains(got, want) {
+			t.Errorf("wrong error\ngot:  %s\nwant substring: %s", got, want)
+		}
+	})
+}
+
 func pushOCIImageManifest(t *testing.T, manifest *ociv1.Manifest, store orasContent.Pusher) ociv1.Descriptor {
 	t.Helper()
 	manifestBytes, err := json.Marshal(manifest)
@@ -67,7 +67,7 @@ func (t *PackageAuthenticationResult) summaryResult() packageAuthenticationResul
 	registryReportCount := 0
 	locallyVerifiedCount := 0
 	for _, disp := range t.hashes {
-		if disp.SignedByAnyGPGKeys() {
+		if disp.SignedByAnyGPGKeys() || len(disp.SignedByOCISigners) != 0 {
 			signedCount++
 		}
 		if disp.ReportedByRegistry {
@@ -133,7 +133,38 @@ func (t *PackageAuthenticationResult) GPGKeyIDsString() string {
 	return t.hashes.AllGPGSigningKeysString()
 }
 
-// Signed returns whether the package was authenticated as signed by anyone.
+// OCISignersString returns a UI-oriented string representation of all of the
+// signers whose cosign signatures on an OCI artifact covered at least one of
+// the hashes related to this package's provider version.
+func (t *PackageAuthenticationResult) OCISignersString() string {
+	if t == nil {
+		return ""
+	}
+	return t.hashes.AllOCISignersString()
+}
+
+// GPGKeyIDs returns the IDs of all of the GPG keys that asserted the validity
+// of at least one of the hashes related to this package's provider version,
+// in lexical order.
+func (t *PackageAuthenticationResult) GPGKeyIDs() []string {
+	if t == nil {
+		return nil
+	}
+	return t.hashes.AllGPGSigningKeys()
+}
+
+// OCISigners returns descriptions of all of the signers whose cosign
+// signatures on an OCI artifact covered at least one of the hashes related
+// to this package's provider version, in lexical order.
+func (t *PackageAuthenticationResult) OCISigners() []string {
+	if t == nil {
+		return nil
+	}
+	return t.hashes.AllOCISigners()
+}
+
+// Signed returns whether the package was authenticated as signed by anyone
+// using a GPG key.
 func (t *PackageAuthenticationResult) Signed() bool {
 	if t == nil {
 		return false
@@ -312,6 +343,35 @@ func (a archiveHashAuthentication) AuthenticatePackage(localLocation PackageLoca
 	}, nil
 }
 
+type ociSignatureAuthentication struct {
+	Hash   Hash
+	Signer string
+}
+
+// newOCISignatureAuthentication returns a PackageAuthentication implementation
+// that records that the given hash was covered by a cosign signature from the
+// given signer, which must already have been verified.
+//
+// This authentication doesn't check the package itself, so it must be
+// combined with an authentication that verifies that the package matches
+// the given hash, and must come after it when using [PackageAuthenticationAll].
+func newOCISignatureAuthentication(hash Hash, signer string) PackageAuthentication {
+	return ociSignatureAuthentication{
+		Hash:   hash,
+		Signer: signer,
+	}
+}
+
+func (a ociSignatureAuthentication) AuthenticatePackage(_ PackageLocation) (*PackageAuthenticationResult, error) {
+	return &PackageAuthenticationResult{
+		hashes: HashDispositions{
+			a.Hash: &HashDisposition{
+				SignedByOCISigners: collections.Set[string]{a.Signer: struct{}{}},
+			},
+		},
+	}, nil
+}
+
 type matchingChecksumAuthentication struct {
 	Document      []byte
 	Filename      string
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -26,8 +27,10 @@ import (
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/configs"
 	"github.com/opentofu/opentofu/internal/configs/configload"
+	"github.com/opentofu/opentofu/internal/depsfile"
 	"github.com/opentofu/opentofu/internal/getmodules"
 	"github.com/opentofu/opentofu/internal/modsdir"
+	"github.com/opentofu/opentofu/internal/modulecache"
 	"github.com/opentofu/opentofu/internal/registry"
 	"github.com/opentofu/opentofu/internal/registry/regsrc"
 	"github.com/opentofu/opentofu/internal/registry/response"
@@ -49,6 +52,20 @@ type ModuleInstaller struct {
 	// The keys in moduleVersionsUrl are the moduleVersion struct below and
 	// addresses and the values are underlying remote source addresses.
 	registryPackageSources map[moduleVersion]addrs.ModuleSourceRemote
+
+	// locks are the dependency locks given to SetDependencyLocks, and
+	// newLocks are the locks recorded for the installed modules.
+	locks    *depsfile.Locks
+	newLocks *depsfile.Locks
+
+	// globalCacheDir, if set, is a cache of module packages shared between
+	// working directories, which we use for any package whose content is
+	// fully determined by its address.
+	globalCacheDir *modulecache.Dir
+
+	// offline is set by SetOffline to forbid installing any module package
+	// that isn't already installed.
+	offline bool
 }
 
 type moduleVersion struct {
@@ -85,6 +102,27 @@ func NewModuleInstaller(modsDir string, loader *configload.Loader, registryClien
 	}
 }
 
+// SetGlobalCacheDir activates a second tier of caching for the receiving
+// installer, with the given directory acting as a read-through cache for
+// module packages that can be shared between working directories.
+//
+// Only packages whose content is fully determined by their address or by
+// the version selected from a module registry are cached: registry module
+// versions, git repositories at a specific commit, and OCI artifacts at
+// a specific digest.
+func (i *ModuleInstaller) SetGlobalCacheDir(cacheDir *modulecache.Dir) {
+	i.globalCacheDir = cacheDir
+}
+
+// SetOffline forbids the receiving installer from fetching any module
+// packages, so that all remote modules must already be installed and match
+// the dependency lock file, as after extracting a bundle. A module that would
+// otherwise be installed or reinstalled, such as one whose installed package
+// doesn't match its checksums in the dependency lock file, is then an error.
+func (i *ModuleInstaller) SetOffline(offline bool) {
+	i.offline = offline
+}
+
 // InstallModules analyses the root module in the given directory and installs
 // all of its direct and transitive dependencies into the given modules
 // directory, which must already exist.
@@ -161,6 +199,7 @@ func (i *ModuleInstaller) InstallModules(ctx context.Context, rootDir, testsDir
 		Key: "",
 		Dir: rootDir,
 	}
+	i.resetDependencyLocks()
 	walker := i.moduleInstallWalker(ctx, manifest, upgrade, hooks, fetcher)
 
 	cfg, instDiags := i.installDescendentModules(ctx, rootMod, manifest, walker, installErrsOnly)
@@ -210,6 +249,13 @@ func (i *ModuleInstaller) moduleInstallWalker(_ context.Context, manifest modsdi
 
 			log.Printf("[DEBUG] Module installer: begin %s", key)
 
+			// Unless we're upgrading, the module must be installed as
+			// recorded in the dependency lock file.
+			var lock *depsfile.ModuleLock
+			if !upgrade {
+				lock = i.moduleLock(key, req)
+			}
+
 			// First we'll check if we need to upgrade/replace an existing
 			// installed module, and delete it out of the way if so.
 			replace := upgrade
@@ -228,8 +274,40 @@ func (i *ModuleInstaller) moduleInstallWalker(_ context.Context, manifest modsdi
 					log.Printf("[TRACE] ModuleInstaller: %s version %s no longer compatible with constraints %s", key, record.Version, req.VersionConstraint.Required)
 					span.AddEvent("Module version constraint changed")
 					replace = true
+				case lock != nil && lock.Version() != nil && (record.Version == nil || !lock.Version().Equal(record.Version)):
+					log.Printf("[TRACE] ModuleInstaller: %s version %s doesn't match locked version %s", key, record.Version, lock.Version())
+					span.AddEvent("Module version lock changed")
+					replace = true
+				case lock != nil && !installedPackageMatchesLock(instPath, lock):
+					log.Printf("[TRACE] ModuleInstaller: %s installed package doesn't match the dependency lock file", key)
+					span.AddEvent("Module package doesn't match lock")
+					replace = true
+					if i.offline {
+						diags = diags.Append(&hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "Module package doesn't match the dependency lock file",
+							Detail: fmt.Sprintf(
+								"The package installed for module %q in %s doesn't match the checksums recorded for it in the dependency lock file, so it may have been modified or corrupted. OpenTofu can't download the package again when initializing from a bundle.",
+								req.Name, instPath,
+							),
+							Subject: req.CallRange.Ptr(),
+						})
+						return nil, nil, diags
+					}
 				}
 			}
+			if _, local := req.SourceAddr.(addrs.ModuleSourceLocal); replace && i.offline && !local {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Module not installed",
+					Detail: fmt.Sprintf(
+						"Module %q (%s:%d) is not installed, or its installed package doesn't match its configuration, and OpenTofu can't download it when initializing from a bundle. Create the bundle again from the configuration that it contains.",
+						req.Name, req.CallRange.Filename, req.CallRange.Start.Line,
+					),
+					Subject: req.CallRange.Ptr(),
+				})
+				return nil, nil, diags
+			}
 
 			// If we _are_ planning to replace this module, then we'll remove
 			// it now so our installation code below won't conflict with any
@@ -290,6 +368,19 @@ func (i *ModuleInstaller) moduleInstallWalker(_ context.Context, manifest modsdi
 						diags = diags.Extend(mDiags)
 					}
 
+					// The lock of an installed module is recorded again, in
+					// case it was installed before the module was locked.
+					switch addr := req.SourceAddr.(type) {
+					case addrs.ModuleSourceRegistry:
+						diags = diags.Extend(i.recordModuleLock(req, key, instPath, record.Version, "", lock))
+					case addrs.ModuleSourceRemote:
+						var pkg string
+						if lock == nil || lock.Package() == "" {
+							pkg = getmodules.CheckedOutGitCommit(ctx, addr.Package.String(), instPath)
+						}
+						diags = diags.Extend(i.recordModuleLock(req, key, instPath, nil, pkg, lock))
+					}
+
 					log.Printf("[TRACE] ModuleInstaller: Module installer: %s %s already installed in %s", key, record.Version, record.Dir)
 					return mod, record.Version, diags
 				}
@@ -312,13 +403,13 @@ func (i *ModuleInstaller) moduleInstallWalker(_ context.Context, manifest modsdi
 			case addrs.ModuleSourceRegistry:
 				log.Printf("[TRACE] ModuleInstaller: %s is a registry module at %s", key, addr.String())
 				span.SetAttributes(otelAttr.String("opentofu.module.source_type", "registry"))
-				mod, v, mDiags := i.installRegistryModule(ctx, req, key, instPath, addr, manifest, hooks, fetcher)
+				mod, v, mDiags := i.installRegistryModule(ctx, req, key, instPath, addr, manifest, hooks, fetcher, lock)
 				diags = append(diags, mDiags...)
 				return mod, v, diags
 
 			case addrs.ModuleSourceRemote:
 				log.Printf("[TRACE] ModuleInstaller: %s address %q will be handled by go-getter", key, addr.String())
-				mod, mDiags := i.installGoGetterModule(ctx, req, key, instPath, manifest, hooks, fetcher)
+				mod, mDiags := i.installGoGetterModule(ctx, req, key, instPath, manifest, hooks, fetcher, lock)
 				diags = append(diags, mDiags...)
 				return mod, nil, diags
 
@@ -465,7 +556,85 @@ func (i *ModuleInstaller) installLocalModule(ctx context.Context, req *configs.M
 // public hashicorp/go-version API.
 var versionRegexp = regexp.MustCompile(version.VersionRegexpRaw)
 
-func (i *ModuleInstaller) installRegistryModule(ctx context.Context, req *configs.ModuleRequest, key string, instPath string, addr addrs.ModuleSourceRegistry, manifest modsdir.Manifest, hooks ModuleInstallHooks, fetcher *getmodules.PackageFetcher) (*configs.Module, *version.Version, hcl.Diagnostics) {
+// AcceptsPrerelease returns true if the given pre-release version of a
+// registry module can be selected by the given version constraints, which is
+// only the case if they request it exactly.
+func AcceptsPrerelease(required version.Constraints, v *version.Version) bool {
+	// The prerelease checking will be handled by a different library for
+	// 2 reasons. First, this other library automatically includes the
+	// "prerelease versions must be exactly requested" behaviour that we are
+	// looking for. Second, this other library is used to handle all version
+	// constraints for the provider logic and this is the first step to
+	// making the module and provider version logic match.
+	//
+	// FIXME: Due to a historical implementation error, this is using the
+	// wrong version constraint parser: it's expecting npm/cargo-style
+	// syntax rather than the Ruby-style syntax OpenTofu otherwise
+	// uses. This should have been written to use
+	// versions.MeetingConstraintsStringRuby instead, but changing it
+	// now risks having OpenTofu select a prerelease in more situations
+	// than it did before, and so we need to understand the implications
+	// of that better before we improve this. For now that means that
+	// it's effectively disallowed to use anything other than a single
+	// exact version constraint to select a prerelease version: any attempt
+	// to combine a prerelease selection with another constraint will
+	// cause all prerelease versions to be excluded from the selection.
+	// For more information:
+	//     https://github.com/opentofu/opentofu/issues/2117
+	constraint := required.String()
+	acceptableVersions, err := versions.MeetingConstraintsString(constraint)
+	if err != nil {
+		// apparentlymart/go-versions purposely doesn't accept "v" prefixes.
+		// However, hashicorp/go-version does, which leads to inconsistent
+		// errors when specifying constraints that contain prerelease
+		// versions with "v" prefixes. This creates a semantically equivalent
+		// constraint with all prefixes stripped so it can be checked
+		// against apparentlymart/go-versions. This is definitely a hack but
+		// one we've accepted to minimize the risk of regressing the handling
+		// of any other version constraint input until we have developed a
+		// better understanding of what syntax is currently allowed for version
+		// constraints and how different constraints are handled.
+		//
+		// strippedConstraint should not live beyond this scope.
+		strippedConstraint := string(versionRegexp.ReplaceAllFunc([]byte(constraint), func(match []byte) []byte {
+			if match[0] == 'v' {
+				return match[1:]
+			}
+			return match
+		}))
+		if strippedConstraint != constraint {
+			log.Printf("[WARN] ModuleInstaller: version constraints (while evaluating %q) failed parsing, so will retry with 'v' prefixes removed (%s)\n    before: %s\n    after:  %s", v, err.Error(), constraint, strippedConstraint)
+			acceptableVersions, err = versions.MeetingConstraintsString(strippedConstraint)
+			if err != nil {
+				log.Printf("[WARN] ModuleInstaller: ignoring %q because the stripped version constraints (%q) could not be parsed either: %s", v, strippedConstraint, err.Error())
+				return false
+			}
+		} else {
+			// If the error here is "commas are not needed to separate version selections"
+			// then that's an expected (though highly unfortunate) consequence of the
+			// incorrect use of MeetingConstraintsString above. Refer to the earlier FIXME
+			// comment for more information.
+			log.Printf("[WARN] ModuleInstaller: ignoring %q because the version constraints (%q) could not be parsed: %s", v, strippedConstraint, err.Error())
+			return false
+		}
+	}
+
+	// Validate the version is also readable by the other versions
+	// library.
+	version, err := versions.ParseVersion(v.String())
+	if err != nil {
+		log.Printf("[WARN] ModuleInstaller: ignoring %s because the version (%s) reported by the module could not be parsed: %s", v, v.String(), err.Error())
+		return false
+	}
+
+	// Finally, check if the prerelease is acceptable to version. As
+	// highlighted previously, we go through all of this because the
+	// apparentlymart/go-versions library handles prerelease constraints
+	// in the approach we want to.
+	return acceptableVersions.Has(version)
+}
+
+func (i *ModuleInstaller) installRegistryModule(ctx context.Context, req *configs.ModuleRequest, key string, instPath string, addr addrs.ModuleSourceRegistry, manifest modsdir.Manifest, hooks ModuleInstallHooks, fetcher *getmodules.PackageFetcher, lock *depsfile.ModuleLock) (*configs.Module, *version.Version, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 
 	ctx, span := tracing.Tracer().Start(ctx, "Install Registry Module",
@@ -563,6 +732,7 @@ func (i *ModuleInstaller) installRegistryModule(ctx context.Context, req *config
 
 	var latestMatch *version.Version
 	var latestVersion *version.Version
+	var lockedMatch *version.Version
 	for _, mv := range modMeta.Versions {
 		v, err := version.NewVersion(mv.Version)
 		if err != nil {
@@ -580,84 +750,12 @@ func (i *ModuleInstaller) installRegistryModule(ctx context.Context, req *config
 
 		// If we've found a pre-release version then we'll ignore it unless
 		// it was exactly requested.
-		//
-		// The prerelease checking will be handled by a different library for
-		// 2 reasons. First, this other library automatically includes the
-		// "prerelease versions must be exactly requested" behaviour that we are
-		// looking for. Second, this other library is used to handle all version
-		// constraints for the provider logic and this is the first step to
-		// making the module and provider version logic match.
 		if v.Prerelease() != "" {
 			// At this point all versions published by the module with
 			// prerelease metadata will be checked. Users may not have even
 			// requested this prerelease so don't print lots of unnecessary #
 			// warnings.
-			//
-			// FIXME: Due to a historical implementation error, this is using the
-			// wrong version constraint parser: it's expecting npm/cargo-style
-			// syntax rather than the Ruby-style syntax OpenTofu otherwise
-			// uses. This should have been written to use
-			// versions.MeetingConstraintsStringRuby instead, but changing it
-			// now risks having OpenTofu select a prerelease in more situations
-			// than it did before, and so we need to understand the implications
-			// of that better before we improve this. For now that means that
-			// it's effectively disallowed to use anything other than a single
-			// exact version constraint to select a prerelease version: any attempt
-			// to combine a prerelease selection with another constraint will
-			// cause all prerelease versions to be excluded from the selection.
-			// For more information:
-			//     https://github.com/opentofu/opentofu/issues/2117
-			constraint := req.VersionConstraint.Required.String()
-			acceptableVersions, err := versions.MeetingConstraintsString(constraint)
-			if err != nil {
-				// apparentlymart/go-versions purposely doesn't accept "v" prefixes.
-				// However, hashicorp/go-version does, which leads to inconsistent
-				// errors when specifying constraints that contain prerelease
-				// versions with "v" prefixes. This creates a semantically equivalent
-				// constraint with all prefixes stripped so it can be checked
-				// against apparentlymart/go-versions. This is definitely a hack but
-				// one we've accepted to minimize the risk of regressing the handling
-				// of any other version constraint input until we have developed a
-				// better understanding of what syntax is currently allowed for version
-				// constraints and how different constraints are handled.
-				//
-				// strippedConstraint should not live beyond this scope.
-				strippedConstraint := string(versionRegexp.ReplaceAllFunc([]byte(constraint), func(match []byte) []byte {
-					if match[0] == 'v' {
-						return match[1:]
-					}
-					return match
-				}))
-				if strippedConstraint != constraint {
-					log.Printf("[WARN] ModuleInstaller: %s (while evaluating %q) failed parsing, so will retry with 'v' prefixes removed (%s)\n    before: %s\n    after:  %s", key, v, err.Error(), constraint, strippedConstraint)
-					acceptableVersions, err = versions.MeetingConstraintsString(strippedConstraint)
-					if err != nil {
-						log.Printf("[WARN] ModuleInstaller: %s ignoring %q because the stripped version constraints (%q) could not be parsed either: %s", key, v, strippedConstraint, err.Error())
-						continue
-					}
-				} else {
-					// If the error here is "commas are not needed to separate version selections"
-					// then that's an expected (though highly unfortunate) consequence of the
-					// incorrect use of MeetingConstraintsString above. Refer to the earlier FIXME
-					// comment for more information.
-					log.Printf("[WARN] ModuleInstaller: %s ignoring %q because the version constraints (%q) could not be parsed: %s", key, v, strippedConstraint, err.Error())
-					continue
-				}
-			}
-
-			// Validate the version is also readable by the other versions
-			// library.
-			version, err := versions.ParseVersion(v.String())
-			if err != nil {
-				log.Printf("[WARN] ModuleInstaller: %s ignoring %s because the version (%s) reported by the module could not be parsed: %s", key, v, v.String(), err.Error())
-				continue
-			}
-
-			// Finally, check if the prerelease is acceptable to version. As
-			// highlighted previously, we go through all of this because the
-			// apparentlymart/go-versions library handles prerelease constraints
-			// in the approach we want to.
-			if !acceptableVersions.Has(version) {
+			if !AcceptsPrerelease(req.VersionConstraint.Required, v) {
 				log.Printf("[TRACE] ModuleInstaller: %s ignoring %s because it is a pre-release and was not requested exactly", key, v)
 				continue
 			}
@@ -678,6 +776,10 @@ func (i *ModuleInstaller) installRegistryModule(ctx context.Context, req *config
 				latestMatch = v
 			}
 		}
+
+		if lock != nil && lock.Version() != nil && v.Equal(lock.Version()) {
+			lockedMatch = v
+		}
 	}
 
 	if latestVersion == nil {
@@ -702,6 +804,23 @@ func (i *ModuleInstaller) installRegistryModule(ctx context.Context, req *config
 		return nil, nil, diags
 	}
 
+	// The version recorded in the dependency lock file takes precedence
+	// over the newest matching version, unless we're upgrading.
+	if lock != nil && lock.Version() != nil {
+		if lockedMatch == nil {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Locked module version not available",
+				Detail:   fmt.Sprintf("The dependency lock file selects version %s of module %q (%s:%d), but that version is no longer available on %s. To select another version, run \"tofu init -upgrade\".", lock.Version(), addr, req.CallRange.Filename, req.CallRange.Start.Line, hostname),
+				Subject:  req.CallRange.Ptr(),
+			})
+			tracing.SetSpanError(span, diags)
+			return nil, nil, diags
+		}
+		log.Printf("[TRACE] ModuleInstaller: %s using locked version %s", key, lockedMatch)
+		latestMatch = lockedMatch
+	}
+
 	// Report up to the caller that we're about to start downloading.
 	hooks.Download(key, packageAddr.String(), latestMatch)
 
@@ -758,7 +877,8 @@ func (i *ModuleInstaller) installRegistryModule(ctx context.Context, req *config
 
 	log.Printf("[TRACE] ModuleInstaller: %s %s %s is available at %q", key, packageAddr, latestMatch, dlAddr.Package)
 
-	err := fetcher.FetchPackage(ctx, instPath, dlAddr.Package.String())
+	cacheKey := fmt.Sprintf("%s %s", packageAddr, latestMatch)
+	err := i.fetchPackage(ctx, fetcher, instPath, dlAddr.Package.String(), cacheKey)
 	if errors.Is(err, context.Canceled) {
 		diags = diags.Append(&hcl.Diagnostic{
 			Severity: hcl.DiagError,
@@ -784,6 +904,12 @@ func (i *ModuleInstaller) installRegistryModule(ctx context.Context, req *config
 
 	log.Printf("[TRACE] ModuleInstaller: %s %q was downloaded to %s", key, dlAddr.Package, instPath)
 
+	if lockDiags := i.recordModuleLock(req, key, instPath, latestMatch, dlAddr.Package.String(), lock); lockDiags.HasErrors() {
+		diags = diags.Extend(lockDiags)
+		tracing.SetSpanError(span, diags)
+		return nil, nil, diags
+	}
+
 	// Incorporate any subdir information from the original path into the
 	// address returned by the registry in order to find the final directory
 	// of the target module.
@@ -828,7 +954,7 @@ func (i *ModuleInstaller) installRegistryModule(ctx context.Context, req *config
 	return mod, latestMatch, diags
 }
 
-func (i *ModuleInstaller) installGoGetterModule(ctx context.Context, req *configs.ModuleRequest, key string, instPath string, manifest modsdir.Manifest, hooks ModuleInstallHooks, fetcher *getmodules.PackageFetcher) (*configs.Module, hcl.Diagnostics) {
+func (i *ModuleInstaller) installGoGetterModule(ctx context.Context, req *configs.ModuleRequest, key string, instPath string, manifest modsdir.Manifest, hooks ModuleInstallHooks, fetcher *getmodules.PackageFetcher, lock *depsfile.ModuleLock) (*configs.Module, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 
 	if fetcher == nil {
@@ -858,7 +984,30 @@ func (i *ModuleInstaller) installGoGetterModule(ctx context.Context, req *config
 		return nil, diags
 	}
 
-	err := fetcher.FetchPackage(ctx, instPath, packageAddr.String())
+	// The package recorded in the dependency lock file, such as a git
+	// repository pinned to a commit, takes precedence over the configured
+	// one, unless we're upgrading.
+	fetchAddr := packageAddr.String()
+	if lock != nil && lock.Package() != "" {
+		log.Printf("[TRACE] ModuleInstaller: %s using locked package %q", key, lock.Package())
+		fetchAddr = lock.Package()
+	}
+
+	if i.globalCacheDir != nil && strings.HasPrefix(fetchAddr, "git::") && !immutablePackageAddr(fetchAddr) {
+		// A branch or tag can move at any time, so we can only cache the
+		// package under the commit it refers to right now. We then also
+		// fetch that commit, so that the package we cache is the one its
+		// key describes even if the ref moves in the meantime.
+		resolved, err := fetcher.ResolveGitCommit(ctx, fetchAddr)
+		if err != nil {
+			log.Printf("[WARN] ModuleInstaller: not caching %s, because its ref can't be resolved to a commit: %s", key, err)
+		} else {
+			log.Printf("[TRACE] ModuleInstaller: %s resolved to %q", key, resolved)
+			fetchAddr = resolved
+		}
+	}
+
+	err := i.fetchPackage(ctx, fetcher, instPath, fetchAddr, packageCacheKey(fetchAddr))
 	if err != nil {
 		// go-getter generates a poor error for an invalid relative path, so
 		// we'll detect that case and generate a better one.
@@ -887,13 +1036,23 @@ func (i *ModuleInstaller) installGoGetterModule(ctx context.Context, req *config
 			diags = diags.Append(&hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  "Failed to download module",
-				Detail:   fmt.Sprintf("Could not download module %q (%s:%d) source code from %q: %s", req.Name, req.CallRange.Filename, req.CallRange.Start.Line, packageAddr, err),
+				Detail:   fmt.Sprintf("Could not download module %q (%s:%d) source code from %q: %s", req.Name, req.CallRange.Filename, req.CallRange.Start.Line, fetchAddr, err),
 				Subject:  req.CallRange.Ptr(),
 			})
 		}
 		return nil, diags
 	}
 
+	// An empty package address keeps the one recorded in the lock.
+	var pkg string
+	if lock == nil || lock.Package() == "" {
+		pkg = getmodules.CheckedOutGitCommit(ctx, packageAddr.String(), instPath)
+	}
+	if lockDiags := i.recordModuleLock(req, key, instPath, nil, pkg, lock); lockDiags.HasErrors() {
+		diags = diags.Extend(lockDiags)
+		return nil, diags
+	}
+
 	modDir, err := getmodules.ExpandSubdirGlobs(instPath, addr.Subdir)
 	if err != nil {
 		diags = diags.Append(&hcl.Diagnostic{
@@ -940,6 +1099,51 @@ func (i *ModuleInstaller) installGoGetterModule(ctx context.Context, req *config
 	return mod, diags
 }
 
+// fetchPackage fetches the package at the given address into the given
+// installation directory, via the global module cache if there is one and
+// cacheKey is not empty.
+func (i *ModuleInstaller) fetchPackage(ctx context.Context, fetcher *getmodules.PackageFetcher, instPath, packageAddr, cacheKey string) error {
+	if i.globalCacheDir == nil || cacheKey == "" {
+		return fetcher.FetchPackage(ctx, instPath, packageAddr)
+	}
+	return i.globalCacheDir.Install(ctx, cacheKey, instPath, func(ctx context.Context, dir string) error {
+		return fetcher.FetchPackage(ctx, dir, packageAddr)
+	})
+}
+
+// immutablePackageAddr returns true if the content of the package at the
+// given address can never change, and so it's safe to cache it using the
+// address as the key.
+func immutablePackageAddr(packageAddr string) bool {
+	switch {
+	case strings.HasPrefix(packageAddr, "git::"):
+		u, err := url.Parse(strings.TrimPrefix(packageAddr, "git::"))
+		return err == nil && getmodules.IsGitCommit(u.Query().Get("ref"))
+	case strings.HasPrefix(packageAddr, "oci::"):
+		u, err := url.Parse(strings.TrimPrefix(packageAddr, "oci::"))
+		return err == nil && u.Query().Get("digest") != ""
+	default:
+		return false
+	}
+}
+
+// packageCacheKey returns the key to cache the package at the given address
+// under in the global module cache, or an empty string if the package can't
+// be cached. The key is recorded in the cache, so it doesn't include any
+// credentials given in the address.
+func packageCacheKey(packageAddr string) string {
+	if !immutablePackageAddr(packageAddr) {
+		return ""
+	}
+	prefix, raw, _ := strings.Cut(packageAddr, "::")
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return packageAddr
+	}
+	u.User = nil
+	return prefix + "::" + u.String()
+}
+
 func (i *ModuleInstaller) packageInstallPath(modulePath addrs.Module) string {
 	return filepath.Join(i.modsDir, strings.Join(modulePath, "."))
 }
@@ -0,0 +1,180 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package initwd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"golang.org/x/mod/sumdb/dirhash"
+
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/depsfile"
+	"github.com/opentofu/opentofu/internal/getproviders"
+)
+
+// SetDependencyLocks makes the installer honor the module locks in the given
+// dependency locks: unless upgrading, a module call whose source address and
+// version constraint still match its lock is installed with the locked
+// version or package, and every installed package must match one of the
+// locked hashes.
+//
+// The module locks for the installed modules are recorded in a copy of the
+// given locks, which DependencyLocks returns once InstallModules completes.
+// If SetDependencyLocks is never called, modules are not locked at all.
+func (i *ModuleInstaller) SetDependencyLocks(locks *depsfile.Locks) {
+	i.locks = locks
+}
+
+// DependencyLocks returns a copy of the dependency locks given to
+// SetDependencyLocks whose module locks are those of the modules installed by
+// the most recent call to InstallModules, or nil if SetDependencyLocks was
+// never called.
+func (i *ModuleInstaller) DependencyLocks() *depsfile.Locks {
+	return i.newLocks
+}
+
+// resetDependencyLocks prepares the locks to be returned by DependencyLocks
+// before installing modules, so that locks for modules that are no longer
+// called are discarded.
+func (i *ModuleInstaller) resetDependencyLocks() {
+	if i.locks == nil {
+		return
+	}
+	i.newLocks = i.locks.DeepCopy()
+	for key := range i.newLocks.AllModules() {
+		i.newLocks.RemoveModule(key)
+	}
+}
+
+// moduleLock returns the lock that applies to the given module request, or
+// nil if the module isn't locked or the module call has changed since the
+// lock was recorded.
+func (i *ModuleInstaller) moduleLock(key string, req *configs.ModuleRequest) *depsfile.ModuleLock {
+	if i.locks == nil {
+		return nil
+	}
+	lock := i.locks.Module(key)
+	switch {
+	case lock == nil:
+		return nil
+	case lock.Source() != req.SourceAddr.String():
+		log.Printf("[TRACE] ModuleInstaller: ignoring lock for %s because its source address has changed from %q to %q", key, lock.Source(), req.SourceAddr)
+		return nil
+	case lock.Version() != nil && !req.VersionConstraint.Required.Check(lock.Version()):
+		log.Printf("[TRACE] ModuleInstaller: ignoring lock for %s because version %s no longer matches constraints %s", key, lock.Version(), req.VersionConstraint.Required)
+		return nil
+	}
+	return lock
+}
+
+// recordModuleLock verifies the package installed for the given module
+// request against its lock, if any, and records the lock for the module in
+// the locks returned by DependencyLocks.
+//
+// pkg is the address of the installed package, or an empty string to keep
+// the one recorded in the existing lock.
+func (i *ModuleInstaller) recordModuleLock(req *configs.ModuleRequest, key, instPath string, v *version.Version, pkg string, lock *depsfile.ModuleLock) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	if i.newLocks == nil {
+		return diags
+	}
+
+	hash, err := hashModulePackage(instPath)
+	if err != nil {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Failed to compute module package checksum",
+			Detail:   fmt.Sprintf("OpenTofu could not compute the checksum of the package installed for module %q in %s: %s.", req.Name, instPath, err),
+			Subject:  req.CallRange.Ptr(),
+		})
+		return diags
+	}
+
+	hashes := []getproviders.Hash{hash}
+	if lock != nil && len(lock.AllHashes()) != 0 {
+		if !slices.Contains(lock.AllHashes(), hash) {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Module package doesn't match the dependency lock file",
+				Detail:   fmt.Sprintf("The package installed for module %q (%s:%d) has checksum %s, which doesn't match any of the checksums recorded for it in the dependency lock file. The package may have changed since it was locked, for example because a tag was moved.\n\nIf you trust the new package, run \"tofu init -upgrade\" to select it and record its checksum.", req.Name, req.CallRange.Filename, req.CallRange.Start.Line, hash),
+				Subject:  req.CallRange.Ptr(),
+			})
+			return diags
+		}
+		hashes = slices.Clone(lock.AllHashes())
+	}
+	if pkg == "" && lock != nil {
+		pkg = lock.Package()
+	}
+
+	i.newLocks.SetModule(key, req.SourceAddr.String(), v, pkg, hashes)
+	return diags
+}
+
+// installedPackageMatchesLock returns true if the package installed at the
+// given path matches one of the hashes of the given lock.
+func installedPackageMatchesLock(instPath string, lock *depsfile.ModuleLock) bool {
+	if len(lock.AllHashes()) == 0 {
+		return true
+	}
+	hash, err := hashModulePackage(instPath)
+	if err != nil {
+		log.Printf("[TRACE] ModuleInstaller: failed to compute checksum of %s: %s", instPath, err)
+		return false
+	}
+	return slices.Contains(lock.AllHashes(), hash)
+}
+
+// hashModulePackage computes the "h1:" hash of the contents of the module
+// package installed in the given directory, excluding any version control
+// metadata, which differs between clones of the same commit.
+func hashModulePackage(dir string) (getproviders.Hash, error) {
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var files []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			// Symlinks to files are hashed by their content, but
+			// symlinks to directories are skipped like dirhash.HashDir
+			// would, rather than followed.
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				return nil
+			}
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s, err := dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+	})
+	return getproviders.Hash(s), err
+}
@@ -0,0 +1,314 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package modulecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opentofu/opentofu/internal/flock"
+)
+
+const (
+	// packageDirName is the name of the directory inside each cache entry
+	// that contains the module package itself.
+	packageDirName = "package"
+
+	// keyFileName is the name of the file inside each cache entry that
+	// records the key the entry was created for, for debugging purposes.
+	keyFileName = "key"
+
+	// fetchDirPrefix is the prefix of the temporary directories that
+	// packages are fetched into before being moved into their cache entry.
+	fetchDirPrefix = ".fetch-"
+
+	// lockFileSuffix is the suffix added to the name of an entry directory
+	// to produce the name of the file used to lock that entry.
+	lockFileSuffix = ".lock"
+)
+
+// Dir represents a single directory containing a cache of module packages,
+// shared between any number of working directories.
+//
+// Each entry in the cache is identified by a caller-chosen key, which must
+// uniquely identify the content of a module package. Entries are locked
+// using filesystem locks while they are being populated or used, so that
+// concurrent OpenTofu processes can safely share the same directory.
+// Filesystem locks are held per process, so a particular process must not
+// use the same entry from multiple goroutines concurrently.
+type Dir struct {
+	baseDir string
+}
+
+// NewDir returns a Dir representing the module cache in the given directory,
+// which will be created on first use if it doesn't already exist.
+func NewDir(baseDir string) *Dir {
+	return &Dir{baseDir: baseDir}
+}
+
+// BasePath returns the filesystem path of the base directory of the cache.
+func (d *Dir) BasePath() string {
+	return filepath.Clean(d.baseDir)
+}
+
+// Install makes the module package with the given key available in the
+// given installation directory, which must not already exist.
+//
+// If the cache doesn't already contain the package then Install calls
+// fetch to retrieve it into the given directory, which also doesn't exist
+// yet, and then adds it to the cache. Any error returned by fetch is returned
+// verbatim, so that callers can recognize specific fetch errors.
+//
+// The files are copied from the cache into the installation directory, so
+// that changes to the installed files can never affect the cache, or the
+// other working directories that install the same package from it.
+func (d *Dir) Install(ctx context.Context, key, instPath string, fetch func(ctx context.Context, dir string) error) error {
+	entryDir := d.entryDir(key)
+	unlock, err := d.lock(ctx, entryDir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := unlock(); err != nil {
+			log.Printf("[ERROR] Failed to release module cache lock for %s: %s", entryDir, err)
+		}
+	}()
+
+	pkgDir := filepath.Join(entryDir, packageDirName)
+	if _, err := os.Stat(pkgDir); os.IsNotExist(err) {
+		log.Printf("[TRACE] modulecache: fetching %q into the cache at %s", key, entryDir)
+		err := d.populate(ctx, key, entryDir, fetch)
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to read cache entry %s: %w", entryDir, err)
+	} else {
+		log.Printf("[TRACE] modulecache: using cached %q from %s", key, entryDir)
+	}
+
+	// We update the modification time of the entry on each use so that
+	// Prune can find the entries that haven't been used recently.
+	now := time.Now()
+	if err := os.Chtimes(entryDir, now, now); err != nil {
+		log.Printf("[WARN] Failed to update the last-used time of module cache entry %s: %s", entryDir, err)
+	}
+
+	if err := copyDir(instPath, pkgDir); err != nil {
+		return fmt.Errorf("failed to install cached module package from %s: %w", pkgDir, err)
+	}
+	return nil
+}
+
+// populate fetches the package for the given key into a temporary directory
+// and then moves it into the given entry directory, so that there's never
+// a partially-populated entry in the cache.
+//
+// The caller must hold the lock for the entry.
+func (d *Dir) populate(ctx context.Context, key, entryDir string, fetch func(ctx context.Context, dir string) error) error {
+	tmpDir, err := os.MkdirTemp(d.baseDir, fetchDirPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory in module cache: %w", err)
+	}
+	defer os.RemoveAll(tmpDir) // no-op if we successfully renamed it below
+
+	if err := fetch(ctx, filepath.Join(tmpDir, packageDirName)); err != nil {
+		return err
+	}
+	//nolint: mnd // file permissions
+	if err := os.WriteFile(filepath.Join(tmpDir, keyFileName), []byte(key+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write module cache entry key: %w", err)
+	}
+	if err := os.Rename(tmpDir, entryDir); err != nil {
+		return fmt.Errorf("failed to add module package to cache: %w", err)
+	}
+	return nil
+}
+
+// Prune removes all of the entries from the cache that have not been used
+// for at least the given duration, returning the keys of the entries that
+// were removed.
+//
+// Entries that are currently locked by another process are skipped. If any
+// entries could not be removed then Prune returns an error describing the
+// first failure, along with the keys of any entries that were removed.
+func (d *Dir) Prune(maxAge time.Duration) ([]string, error) {
+	entries, err := os.ReadDir(d.baseDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	var removed []string
+	var errs []error
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue // lock files are retained, as described in lock
+		}
+		entryDir := filepath.Join(d.baseDir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !info.ModTime().Before(cutoff) {
+			continue
+		}
+
+		if strings.HasPrefix(entry.Name(), fetchDirPrefix) {
+			// This is left over from a fetch that was interrupted before
+			// it could clean up after itself.
+			if err := os.RemoveAll(entryDir); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		key, err := d.pruneEntry(entryDir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if key != "" {
+			removed = append(removed, key)
+		}
+	}
+	return removed, errors.Join(errs...)
+}
+
+// pruneEntry removes the given entry directory unless it's currently locked,
+// returning the key it was created for if it was removed.
+func (d *Dir) pruneEntry(entryDir string) (string, error) {
+	f, err := os.OpenFile(entryDir+lockFileSuffix, os.O_RDWR|os.O_CREATE, 0644) //nolint: mnd // file permissions
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := flock.Lock(f); err != nil {
+		log.Printf("[TRACE] modulecache: not pruning %s because it is in use", entryDir)
+		return "", nil
+	}
+	defer flock.Unlock(f) //nolint:errcheck // closing the file releases the lock anyway
+
+	key := filepath.Base(entryDir)
+	if raw, err := os.ReadFile(filepath.Join(entryDir, keyFileName)); err == nil {
+		key = strings.TrimSpace(string(raw))
+	}
+	if err := os.RemoveAll(entryDir); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// entryDir returns the directory of the cache entry for the given key.
+func (d *Dir) entryDir(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.baseDir, hex.EncodeToString(sum[:]))
+}
+
+// lock acquires the filesystem lock for the given entry directory, blocking
+// until it is available, and returns a function to release it.
+//
+// The lock file is beside the entry directory rather than inside it, so that
+// it can remain while the entry is removed or replaced. Lock files are never
+// removed, because another process might have already opened one in
+// preparation for locking it.
+func (d *Dir) lock(ctx context.Context, entryDir string) (func() error, error) {
+	//nolint: mnd // directory permissions
+	if err := os.MkdirAll(d.baseDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create module cache directory: %w", err)
+	}
+	lockFile := entryDir + lockFileSuffix
+	log.Printf("[TRACE] Attempting to acquire module cache lock %s", lockFile)
+	f, err := os.OpenFile(lockFile, os.O_RDWR|os.O_CREATE, 0644) //nolint: mnd // file permissions
+	if err != nil {
+		return nil, err
+	}
+	if err := flock.LockBlocking(ctx, f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("unable to acquire file lock on %q: %w", lockFile, err)
+	}
+
+	return func() error {
+		log.Printf("[TRACE] Releasing module cache lock %s", lockFile)
+
+		unlockErr := flock.Unlock(f)
+
+		// Prefer close error over unlock error
+		err := f.Close()
+		if err != nil {
+			return err
+		}
+		return unlockErr
+	}, nil
+}
+
+// copyDir recreates the directory tree at src in dst, which must not already
+// exist.
+//
+// Unlike copy.CopyDir this includes dot files, such as a ".git" directory,
+// so that the result matches what fetching the package directly would have
+// produced.
+func copyDir(dst, src string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		dstPath := filepath.Join(dst, rel)
+
+		switch {
+		case entry.IsDir():
+			//nolint: mnd // directory permissions
+			return os.MkdirAll(dstPath, 0755)
+		case entry.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(target, dstPath)
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return copyFile(dstPath, path, info.Mode())
+	})
+}
+
+// copyFile copies the contents and mode of the file from src to dst.
+func copyFile(dst, src string, mode fs.FileMode) error {
+	srcF, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcF.Close()
+
+	dstF, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dstF, srcF); err != nil {
+		dstF.Close() // Ignore error from Close since io.Copy already failed
+		return err
+	}
+	return dstF.Close()
+}
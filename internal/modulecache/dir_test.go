@@ -0,0 +1,135 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package modulecache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDirInstall(t *testing.T) {
+	dir := NewDir(filepath.Join(t.TempDir(), "cache"))
+
+	fetches := 0
+	fetch := func(_ context.Context, pkgDir string) error {
+		fetches++
+		if _, err := os.Stat(pkgDir); !os.IsNotExist(err) {
+			t.Errorf("fetch directory %s already exists", pkgDir)
+		}
+		if err := os.MkdirAll(filepath.Join(pkgDir, ".git"), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(pkgDir, "main.tf"), []byte("# main\n"), 0644); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(pkgDir, ".git", "HEAD"), []byte("ref\n"), 0644)
+	}
+
+	for _, name := range []string{"a", "b"} {
+		instPath := filepath.Join(t.TempDir(), name)
+		if err := dir.Install(t.Context(), "example.com/foo 1.0.0", instPath, fetch); err != nil {
+			t.Fatalf("install %s: %s", name, err)
+		}
+		for _, file := range []string{"main.tf", filepath.Join(".git", "HEAD")} {
+			if _, err := os.Stat(filepath.Join(instPath, file)); err != nil {
+				t.Errorf("install %s: %s", name, err)
+			}
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("package was fetched %d times; want 1", fetches)
+	}
+
+	// Installed files are copies, so modifying them doesn't affect the cache.
+	modified := filepath.Join(t.TempDir(), "modified")
+	if err := dir.Install(t.Context(), "example.com/foo 1.0.0", modified, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(modified, "main.tf"), []byte("# modified\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	unmodified := filepath.Join(t.TempDir(), "unmodified")
+	if err := dir.Install(t.Context(), "example.com/foo 1.0.0", unmodified, fetch); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(unmodified, "main.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("# main\n", string(got)); diff != "" {
+		t.Errorf("cached file was modified\n%s", diff)
+	}
+
+	// A different key is a different entry.
+	if err := dir.Install(t.Context(), "example.com/foo 2.0.0", filepath.Join(t.TempDir(), "c"), fetch); err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 2 {
+		t.Errorf("package was fetched %d times; want 2", fetches)
+	}
+}
+
+func TestDirInstall_fetchError(t *testing.T) {
+	dir := NewDir(t.TempDir())
+
+	wantErr := errors.New("fetch failed")
+	err := dir.Install(t.Context(), "example.com/foo 1.0.0", filepath.Join(t.TempDir(), "a"), func(_ context.Context, pkgDir string) error {
+		// A partially-fetched package must not be added to the cache.
+		if err := os.MkdirAll(pkgDir, 0755); err != nil {
+			return err
+		}
+		return wantErr
+	})
+	if err != wantErr {
+		t.Fatalf("wrong error\ngot:  %s\nwant: %s", err, wantErr)
+	}
+
+	fetched := false
+	err = dir.Install(t.Context(), "example.com/foo 1.0.0", filepath.Join(t.TempDir(), "b"), func(_ context.Context, pkgDir string) error {
+		fetched = true
+		return os.MkdirAll(pkgDir, 0755)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fetched {
+		t.Error("package was not fetched again after the failed fetch")
+	}
+}
+
+func TestDirPrune(t *testing.T) {
+	dir := NewDir(t.TempDir())
+	fetch := func(_ context.Context, pkgDir string) error {
+		return os.MkdirAll(pkgDir, 0755)
+	}
+	for _, key := range []string{"old", "new"} {
+		if err := dir.Install(t.Context(), key, filepath.Join(t.TempDir(), key), fetch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(dir.entryDir("old"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := dir.Prune(24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"old"}, removed); diff != "" {
+		t.Errorf("wrong removed entries\n%s", diff)
+	}
+	if _, err := os.Stat(dir.entryDir("old")); !os.IsNotExist(err) {
+		t.Errorf("old entry still exists")
+	}
+	if _, err := os.Stat(dir.entryDir("new")); err != nil {
+		t.Errorf("new entry was removed: %s", err)
+	}
+}
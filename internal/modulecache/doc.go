@@ -0,0 +1,12 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+// Package modulecache implements a global cache of remote module packages
+// that can be shared between many working directories, so that each package
+// is fetched from its origin only once.
+//
+// This is the module counterpart of the global provider plugin cache
+// implemented in package providercache, but module packages have no
+// versioning or checksum scheme of their own and so callers must decide
+// which packages are safe to cache, and under which keys.
+package modulecache
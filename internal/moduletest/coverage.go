@@ -0,0 +1,293 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package moduletest
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/checks"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/plans"
+	"github.com/opentofu/opentofu/internal/states"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// CoverageKind describes the kind of configuration object that a CoverageItem
+// represents.
+type CoverageKind string
+
+const (
+	CoverageResource      CoverageKind = "resource"
+	CoverageDataResource  CoverageKind = "data"
+	CoverageOutput        CoverageKind = "output"
+	CoverageValidation    CoverageKind = "validation"
+	CoveragePrecondition  CoverageKind = "precondition"
+	CoveragePostcondition CoverageKind = "postcondition"
+	CoverageCheckAssert   CoverageKind = "assert"
+)
+
+// CoverageItem is a single object within the configuration under test that
+// the tests may or may not exercise.
+type CoverageItem struct {
+	Module addrs.Module
+	Kind   CoverageKind
+
+	// Address is the address of the object in the configuration. For check
+	// rules, this is the address of the object the rule belongs to and Index
+	// is the index of the rule within that object.
+	Address string
+	Index   int
+
+	Range tfdiags.SourceRange
+
+	// Hits is the number of plan and apply operations that exercised this
+	// object.
+	Hits int
+}
+
+// CoverageSummary describes how much of a single module the tests exercised.
+type CoverageSummary struct {
+	Module  addrs.Module
+	Covered int
+	Total   int
+}
+
+type coverageKey struct {
+	addr  string
+	kind  CoverageKind
+	index int
+}
+
+// Coverage records which objects within the configuration under test were
+// exercised by the tests.
+//
+// Resources and outputs are exercised when they appear in a plan, or in the
+// state after an apply. Check rules are exercised when the check results
+// show that they were evaluated, or when they produced a diagnostic.
+//
+// Coverage is safe for concurrent use.
+type Coverage struct {
+	mu sync.Mutex
+
+	items []*CoverageItem
+	byKey map[coverageKey]*CoverageItem
+
+	// rules maps the configuration address of each checkable object to the
+	// items for all of its check rules.
+	rules map[string][]*CoverageItem
+}
+
+// NewCoverage returns a Coverage that tracks all the coverable objects in the
+// given configuration, none of which have been exercised yet.
+func NewCoverage(config *configs.Config) *Coverage {
+	c := &Coverage{
+		byKey: make(map[coverageKey]*CoverageItem),
+		rules: make(map[string][]*CoverageItem),
+	}
+
+	config.DeepEach(func(cfg *configs.Config) {
+		mod := cfg.Module
+		if mod == nil {
+			return
+		}
+		path := cfg.Path
+
+		for _, r := range mod.ManagedResources {
+			addr := addrs.ConfigResource{Module: path, Resource: r.Addr()}.String()
+			c.add(path, CoverageResource, addr, 0, r.DeclRange)
+			c.addRules(path, CoveragePrecondition, addr, r.Preconditions)
+			c.addRules(path, CoveragePostcondition, addr, r.Postconditions)
+		}
+		for _, r := range mod.DataResources {
+			addr := addrs.ConfigResource{Module: path, Resource: r.Addr()}.String()
+			c.add(path, CoverageDataResource, addr, 0, r.DeclRange)
+			c.addRules(path, CoveragePrecondition, addr, r.Preconditions)
+			c.addRules(path, CoveragePostcondition, addr, r.Postconditions)
+		}
+		for _, o := range mod.Outputs {
+			addr := addrs.ConfigOutputValue{Module: path, OutputValue: o.Addr()}.String()
+			c.add(path, CoverageOutput, addr, 0, o.DeclRange)
+			c.addRules(path, CoveragePrecondition, addr, o.Preconditions)
+		}
+		for _, v := range mod.Variables {
+			addr := addrs.ConfigInputVariable{Module: path, Variable: v.Addr()}.String()
+			c.addRules(path, CoverageValidation, addr, v.Validations)
+		}
+		for _, check := range mod.Checks {
+			addr := addrs.ConfigCheck{Module: path, Check: check.Addr()}.String()
+			c.addRules(path, CoverageCheckAssert, addr, check.Asserts)
+		}
+	})
+
+	sort.SliceStable(c.items, func(i, j int) bool {
+		a, b := c.items[i].Range, c.items[j].Range
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Start.Byte < b.Start.Byte
+	})
+	return c
+}
+
+func (c *Coverage) add(path addrs.Module, kind CoverageKind, addr string, index int, rng hcl.Range) *CoverageItem {
+	item := &CoverageItem{
+		Module:  path,
+		Kind:    kind,
+		Address: addr,
+		Index:   index,
+		Range:   tfdiags.SourceRangeFromHCL(rng),
+	}
+	c.items = append(c.items, item)
+	c.byKey[coverageKey{addr, kind, index}] = item
+	return item
+}
+
+func (c *Coverage) addRules(path addrs.Module, kind CoverageKind, addr string, rules []*configs.CheckRule) {
+	for ix, rule := range rules {
+		c.rules[addr] = append(c.rules[addr], c.add(path, kind, addr, ix, rule.DeclRange))
+	}
+}
+
+// Record marks the objects exercised by a single plan or apply operation as
+// covered. Any of the arguments may be nil.
+//
+// Record must only be given results from the configuration that this
+// Coverage was created for, as objects are matched by their addresses.
+func (c *Coverage) Record(plan *plans.Plan, state *states.State, diags tfdiags.Diagnostics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hit := make(map[*CoverageItem]struct{})
+	mark := func(item *CoverageItem) {
+		if item != nil {
+			hit[item] = struct{}{}
+		}
+	}
+	markResource := func(addr addrs.ConfigResource) {
+		kind := CoverageResource
+		if addr.Resource.Mode == addrs.DataResourceMode {
+			kind = CoverageDataResource
+		}
+		mark(c.byKey[coverageKey{addr.String(), kind, 0}])
+	}
+	markChecks := func(results *states.CheckResults) {
+		if results == nil {
+			return
+		}
+		for _, elem := range results.ConfigResults.Elems {
+			if elem.Value.Status == checks.StatusUnknown {
+				// The rules haven't been evaluated yet, or couldn't be.
+				continue
+			}
+			for _, item := range c.rules[elem.Key.String()] {
+				mark(item)
+			}
+		}
+	}
+
+	if plan != nil {
+		if plan.Changes != nil {
+			for _, change := range plan.Changes.Resources {
+				markResource(change.Addr.ConfigResource())
+			}
+			for _, change := range plan.Changes.Outputs {
+				mark(c.byKey[coverageKey{change.Addr.ConfigCheckable().String(), CoverageOutput, 0}])
+			}
+		}
+		markChecks(plan.Checks)
+	}
+
+	if state != nil {
+		for _, mod := range state.Modules {
+			for _, rs := range mod.Resources {
+				markResource(rs.Addr.Config())
+			}
+			for name := range mod.OutputValues {
+				addr := addrs.ConfigOutputValue{Module: mod.Addr.Module(), OutputValue: addrs.OutputValue{Name: name}}
+				mark(c.byKey[coverageKey{addr.String(), CoverageOutput, 0}])
+			}
+		}
+		markChecks(state.CheckResults)
+	}
+
+	// Check rules that failed, including those that were expected to, might
+	// not be reflected in the check results so we look at the diagnostics too.
+	for _, diag := range diags {
+		rule, ok := addrs.DiagnosticOriginatesFromCheckRule(diag)
+		if !ok {
+			continue
+		}
+		kind, ok := coverageKindForRule(rule.Type)
+		if !ok {
+			continue
+		}
+		mark(c.byKey[coverageKey{rule.Container.ConfigCheckable().String(), kind, rule.Index}])
+	}
+
+	for item := range hit {
+		item.Hits++
+	}
+}
+
+func coverageKindForRule(typ addrs.CheckRuleType) (CoverageKind, bool) {
+	switch typ {
+	case addrs.ResourcePrecondition, addrs.OutputPrecondition:
+		return CoveragePrecondition, true
+	case addrs.ResourcePostcondition:
+		return CoveragePostcondition, true
+	case addrs.InputValidation:
+		return CoverageValidation, true
+	case addrs.CheckAssertion:
+		return CoverageCheckAssert, true
+	default:
+		return "", false
+	}
+}
+
+// Items returns a copy of all the tracked objects, ordered by their
+// position in the configuration.
+func (c *Coverage) Items() []CoverageItem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := make([]CoverageItem, len(c.items))
+	for ix, item := range c.items {
+		ret[ix] = *item
+	}
+	return ret
+}
+
+// Summary returns the coverage of each module in the configuration that
+// contains at least one coverable object, ordered by module address.
+func (c *Coverage) Summary() []CoverageSummary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	byModule := make(map[string]*CoverageSummary)
+	var keys []string
+	for _, item := range c.items {
+		key := item.Module.String()
+		summary, ok := byModule[key]
+		if !ok {
+			summary = &CoverageSummary{Module: item.Module}
+			byModule[key] = summary
+			keys = append(keys, key)
+		}
+		summary.Total++
+		if item.Hits > 0 {
+			summary.Covered++
+		}
+	}
+	sort.Strings(keys)
+
+	ret := make([]CoverageSummary, len(keys))
+	for ix, key := range keys {
+		ret[ix] = *byModule[key]
+	}
+	return ret
+}
@@ -0,0 +1,135 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package moduletest
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/plans"
+	"github.com/opentofu/opentofu/internal/states"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+func TestCoverage(t *testing.T) {
+	coverage := NewCoverage(testCoverageConfig())
+
+	foo := addrs.Resource{
+		Mode: addrs.ManagedResourceMode,
+		Type: "test_resource",
+		Name: "foo",
+	}.Instance(addrs.NoKey).Absolute(addrs.RootModuleInstance)
+
+	var diags tfdiags.Diagnostics
+	diags = diags.Append(&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid value for variable",
+		Extra: &addrs.CheckRuleDiagnosticExtra{
+			CheckRule: addrs.NewCheckRule(addrs.AbsInputVariableInstance{
+				Module:   addrs.RootModuleInstance.Child("child", addrs.NoKey),
+				Variable: addrs.InputVariable{Name: "input"},
+			}, addrs.InputValidation, 0),
+		},
+	})
+
+	coverage.Record(&plans.Plan{
+		Changes: &plans.Changes{
+			Resources: []*plans.ResourceInstanceChangeSrc{
+				{Addr: foo, PrevRunAddr: foo},
+			},
+		},
+	}, nil, diags)
+
+	state := states.NewState()
+	state.RootModule().SetOutputValue("value", cty.StringVal("foo"), false, "")
+	coverage.Record(nil, state, nil)
+
+	want := []CoverageSummary{
+		{Module: addrs.RootModule, Covered: 2, Total: 3},
+		{Module: addrs.Module{"child"}, Covered: 1, Total: 2},
+	}
+	if diff := cmp.Diff(want, coverage.Summary()); len(diff) > 0 {
+		t.Errorf("wrong summary:\n%s", diff)
+	}
+
+	hits := make(map[string]int)
+	for _, item := range coverage.Items() {
+		hits[string(item.Kind)+" "+item.Address] = item.Hits
+	}
+	wantHits := map[string]int{
+		"resource test_resource.foo":              1,
+		"output output.value":                     1,
+		"precondition output.value":               0,
+		"resource module.child.test_resource.bar": 0,
+		"validation module.child.var.input":       1,
+	}
+	if diff := cmp.Diff(wantHits, hits); len(diff) > 0 {
+		t.Errorf("wrong hits:\n%s", diff)
+	}
+}
+
+func testCoverageConfig() *configs.Config {
+	rng := func(line int) hcl.Range {
+		return hcl.Range{
+			Filename: "main.tf",
+			Start:    hcl.Pos{Line: line, Column: 1, Byte: line * 10},
+			End:      hcl.Pos{Line: line, Column: 2, Byte: line*10 + 1},
+		}
+	}
+
+	root := &configs.Config{
+		Path: addrs.RootModule,
+		Module: &configs.Module{
+			ManagedResources: map[string]*configs.Resource{
+				"test_resource.foo": {
+					Mode:      addrs.ManagedResourceMode,
+					Type:      "test_resource",
+					Name:      "foo",
+					DeclRange: rng(1),
+				},
+			},
+			Outputs: map[string]*configs.Output{
+				"value": {
+					Name:      "value",
+					DeclRange: rng(5),
+					Preconditions: []*configs.CheckRule{
+						{DeclRange: rng(6)},
+					},
+				},
+			},
+		},
+		Children: make(map[string]*configs.Config),
+	}
+	root.Root = root
+
+	root.Children["child"] = &configs.Config{
+		Root:   root,
+		Parent: root,
+		Path:   addrs.Module{"child"},
+		Module: &configs.Module{
+			ManagedResources: map[string]*configs.Resource{
+				"test_resource.bar": {
+					Mode:      addrs.ManagedResourceMode,
+					Type:      "test_resource",
+					Name:      "bar",
+					DeclRange: rng(10),
+				},
+			},
+			Variables: map[string]*configs.Variable{
+				"input": {
+					Name: "input",
+					Validations: []*configs.CheckRule{
+						{DeclRange: rng(15)},
+					},
+				},
+			},
+		},
+	}
+	return root
+}
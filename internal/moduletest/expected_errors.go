@@ -0,0 +1,134 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package moduletest
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// ExpectedErrors tracks which of the expect_error blocks of a run block have
+// matched an error while the run block executes.
+type ExpectedErrors struct {
+	expected []*configs.TestRunExpectedError
+	matched  []bool
+
+	// stop is set once an expected error has been matched, as the operation
+	// that reported it has then failed and the run block can't continue.
+	stop bool
+}
+
+// BuildExpectedErrors returns an ExpectedErrors for the expect_error blocks of
+// this run block, none of which have matched yet.
+func (run *Run) BuildExpectedErrors() *ExpectedErrors {
+	return &ExpectedErrors{
+		expected: run.Config.ExpectErrors,
+		matched:  make([]bool, len(run.Config.ExpectErrors)),
+	}
+}
+
+// Filter removes the error diagnostics that match any of the expect_error
+// blocks, and returns the remaining diagnostics.
+//
+// If any error was removed, Stop will report true afterwards.
+func (e *ExpectedErrors) Filter(originals tfdiags.Diagnostics) tfdiags.Diagnostics {
+	if len(e.expected) == 0 {
+		return originals
+	}
+
+	var diags tfdiags.Diagnostics
+	for _, diag := range originals {
+		if diag.Severity() != tfdiags.Error {
+			diags = diags.Append(diag)
+			continue
+		}
+
+		found := false
+		for ix, expected := range e.expected {
+			if expectedErrorMatches(expected, diag) {
+				e.matched[ix] = true
+				found = true
+			}
+		}
+		if !found {
+			diags = diags.Append(diag)
+			continue
+		}
+		e.stop = true
+	}
+	return diags
+}
+
+// Stop returns true if an expected error has been reported, which means the
+// run block can't continue executing.
+func (e *ExpectedErrors) Stop() bool {
+	return e.stop
+}
+
+// Missing returns an error for each expect_error block that has not matched
+// any error.
+func (e *ExpectedErrors) Missing() tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+	for ix, expected := range e.expected {
+		if e.matched[ix] {
+			continue
+		}
+
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing expected error",
+			Detail:   fmt.Sprintf("The run block was expected to report an error with %s, but did not.", describeExpectedError(expected)),
+			Subject:  expected.DeclRange.Ptr(),
+		})
+	}
+	return diags
+}
+
+func expectedErrorMatches(expected *configs.TestRunExpectedError, diag tfdiags.Diagnostic) bool {
+	desc := diag.Description()
+	if expected.Summary != nil && !expected.Summary.MatchString(desc.Summary) {
+		return false
+	}
+	if expected.Detail != nil && !expected.Detail.MatchString(desc.Detail) {
+		return false
+	}
+
+	if expected.Filename != "" {
+		subject := diag.Source().Subject
+		if subject == nil {
+			return false
+		}
+		if filepath.ToSlash(filepath.Clean(subject.Filename)) != expected.Filename {
+			return false
+		}
+		if expected.Line != 0 && subject.Start.Line != expected.Line {
+			return false
+		}
+	}
+	return true
+}
+
+func describeExpectedError(expected *configs.TestRunExpectedError) string {
+	var parts []string
+	if expected.Summary != nil {
+		parts = append(parts, fmt.Sprintf("a summary matching %q", expected.Summary))
+	}
+	if expected.Detail != nil {
+		parts = append(parts, fmt.Sprintf("a detail matching %q", expected.Detail))
+	}
+	if expected.Filename != "" {
+		location := expected.Filename
+		if expected.Line != 0 {
+			location = fmt.Sprintf("%s:%d", location, expected.Line)
+		}
+		parts = append(parts, fmt.Sprintf("a source location of %s", location))
+	}
+	return strings.Join(parts, " and ")
+}
@@ -6,6 +6,8 @@
 package moduletest
 
 import (
+	"time"
+
 	"github.com/opentofu/opentofu/internal/configs"
 	"github.com/opentofu/opentofu/internal/tfdiags"
 )
@@ -16,7 +18,14 @@ type File struct {
 	Name   string
 	Status Status
 
+	// Duration is how long it took to execute all the run blocks in the
+	// file, including cleaning up the resources they created.
+	Duration time.Duration
+
 	Runs []*Run
 
+	// Properties are checked after the run blocks have executed.
+	Properties []*Property
+
 	Diagnostics tfdiags.Diagnostics
 }
@@ -0,0 +1,203 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package moduletest
+
+import (
+	"math/big"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/tfdiags"
+)
+
+// Property is a property block within a test file, checked with values
+// generated for an input variable of the module under test.
+type Property struct {
+	Config *configs.TestProperty
+
+	Name   string
+	Status Status
+
+	// Iterations is the number of values that were checked, including the
+	// counterexample if one was found.
+	Iterations int
+
+	// Duration is how long it took to check the property.
+	Duration time.Duration
+
+	Diagnostics tfdiags.Diagnostics
+}
+
+// ValueGenerator generates random values that conform to the type constraint
+// of an input variable. A generator created with a given seed always
+// generates the same sequence of values for the same type constraints.
+type ValueGenerator struct {
+	rand *rand.Rand
+}
+
+func NewValueGenerator(seed int64) *ValueGenerator {
+	return &ValueGenerator{
+		rand: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// maxGeneratedElements is the maximum number of elements in the collections
+// generated by a ValueGenerator.
+const maxGeneratedElements = 4
+
+// Interesting strings and numbers are generated more often than others, as
+// they are the most likely to expose mistakes in validation rules.
+var (
+	generatedStrings = []string{"", " ", "a", "A", "0", "-1", "true", "null", "a b", " a", "a ", "a-b_c.d", "ü", "日本", "\n", "${a}", "*"}
+	generatedNumbers = []string{"0", "1", "-1", "2", "0.5", "-0.5", "10", "100", "255", "256", "1024", "65535", "65536", "2147483647", "-2147483648", "1e10"}
+)
+
+const generatedStringChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./: @"
+
+// Generate returns a random value that conforms to the given type constraint.
+// Optional object attributes are sometimes omitted, and nested values and, if
+// nullable is true, the value itself are sometimes null, so the result must
+// still have any type defaults applied and be converted to the constraint.
+func (g *ValueGenerator) Generate(ty cty.Type, nullable bool) cty.Value {
+	if nullable && g.rand.Intn(10) == 0 {
+		return cty.NullVal(ty)
+	}
+	return g.generate(g.concrete(ty, 0))
+}
+
+// concrete replaces any dynamic types within the given type constraint with
+// randomly chosen types, so that every element of a collection is generated
+// with the same type.
+func (g *ValueGenerator) concrete(ty cty.Type, depth int) cty.Type {
+	switch {
+	case ty == cty.DynamicPseudoType:
+		types := []cty.Type{cty.String, cty.Number, cty.Bool}
+		if depth < 2 {
+			types = append(types, cty.List(cty.String), cty.Map(cty.Number), cty.Object(map[string]cty.Type{"name": cty.String}))
+		}
+		return types[g.rand.Intn(len(types))]
+	case ty.IsListType():
+		return cty.List(g.concrete(ty.ElementType(), depth+1))
+	case ty.IsSetType():
+		return cty.Set(g.concrete(ty.ElementType(), depth+1))
+	case ty.IsMapType():
+		return cty.Map(g.concrete(ty.ElementType(), depth+1))
+	case ty.IsObjectType():
+		attrs := make(map[string]cty.Type)
+		var optional []string
+		for _, name := range sortedAttributeNames(ty) {
+			attrs[name] = g.concrete(ty.AttributeType(name), depth+1)
+			if ty.AttributeOptional(name) {
+				optional = append(optional, name)
+			}
+		}
+		return cty.ObjectWithOptionalAttrs(attrs, optional)
+	case ty.IsTupleType():
+		elems := make([]cty.Type, len(ty.TupleElementTypes()))
+		for ix, elem := range ty.TupleElementTypes() {
+			elems[ix] = g.concrete(elem, depth+1)
+		}
+		return cty.Tuple(elems)
+	default:
+		return ty
+	}
+}
+
+func (g *ValueGenerator) generate(ty cty.Type) cty.Value {
+	switch {
+	case ty == cty.String:
+		return cty.StringVal(g.generateString())
+	case ty == cty.Number:
+		return cty.NumberVal(g.generateNumber())
+	case ty == cty.Bool:
+		return cty.BoolVal(g.rand.Intn(2) == 0)
+	case ty.IsListType() || ty.IsSetType():
+		count := g.rand.Intn(maxGeneratedElements + 1)
+		if count == 0 {
+			if ty.IsSetType() {
+				return cty.SetValEmpty(ty.ElementType())
+			}
+			return cty.ListValEmpty(ty.ElementType())
+		}
+		elems := make([]cty.Value, count)
+		for ix := range elems {
+			elems[ix] = g.generate(ty.ElementType())
+		}
+		if ty.IsSetType() {
+			return cty.SetVal(elems)
+		}
+		return cty.ListVal(elems)
+	case ty.IsMapType():
+		count := g.rand.Intn(maxGeneratedElements + 1)
+		if count == 0 {
+			return cty.MapValEmpty(ty.ElementType())
+		}
+		elems := make(map[string]cty.Value, count)
+		for len(elems) < count {
+			elems[g.generateString()] = g.generate(ty.ElementType())
+		}
+		return cty.MapVal(elems)
+	case ty.IsObjectType():
+		attrs := make(map[string]cty.Value)
+		for _, name := range sortedAttributeNames(ty) {
+			attr := ty.AttributeType(name)
+			switch {
+			case ty.AttributeOptional(name) && g.rand.Intn(3) == 0:
+				attrs[name] = cty.NullVal(attr)
+			case g.rand.Intn(20) == 0:
+				attrs[name] = cty.NullVal(attr)
+			default:
+				attrs[name] = g.generate(attr)
+			}
+		}
+		return cty.ObjectVal(attrs)
+	case ty.IsTupleType():
+		elems := make([]cty.Value, len(ty.TupleElementTypes()))
+		for ix, elem := range ty.TupleElementTypes() {
+			elems[ix] = g.generate(elem)
+		}
+		return cty.TupleVal(elems)
+	default:
+		return cty.NullVal(ty)
+	}
+}
+
+func (g *ValueGenerator) generateString() string {
+	if g.rand.Intn(3) == 0 {
+		return generatedStrings[g.rand.Intn(len(generatedStrings))]
+	}
+
+	var b strings.Builder
+	length := g.rand.Intn(32) + 1
+	for ix := 0; ix < length; ix++ {
+		b.WriteByte(generatedStringChars[g.rand.Intn(len(generatedStringChars))])
+	}
+	return b.String()
+}
+
+func (g *ValueGenerator) generateNumber() *big.Float {
+	switch g.rand.Intn(4) {
+	case 0:
+		n, _, _ := big.ParseFloat(generatedNumbers[g.rand.Intn(len(generatedNumbers))], 10, 512, big.ToNearestEven)
+		return n
+	case 1:
+		return big.NewFloat((g.rand.Float64() - 0.5) * 2000)
+	default:
+		return new(big.Float).SetInt64(g.rand.Int63n(200001) - 100000)
+	}
+}
+
+func sortedAttributeNames(ty cty.Type) []string {
+	var names []string
+	for name := range ty.AttributeTypes() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,69 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package moduletest
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+func TestValueGenerator(t *testing.T) {
+	tcs := map[string]cty.Type{
+		"string":      cty.String,
+		"number":      cty.Number,
+		"bool":        cty.Bool,
+		"any":         cty.DynamicPseudoType,
+		"list":        cty.List(cty.String),
+		"set":         cty.Set(cty.Number),
+		"map":         cty.Map(cty.Bool),
+		"list of any": cty.List(cty.DynamicPseudoType),
+		"object": cty.ObjectWithOptionalAttrs(map[string]cty.Type{
+			"name":  cty.String,
+			"ports": cty.List(cty.Number),
+			"tags":  cty.Map(cty.String),
+		}, []string{"tags"}),
+		"tuple": cty.Tuple([]cty.Type{cty.String, cty.Number}),
+	}
+
+	for name, ty := range tcs {
+		t.Run(name, func(t *testing.T) {
+			first := NewValueGenerator(42)
+			second := NewValueGenerator(42)
+
+			var nulls int
+			for ix := 0; ix < 200; ix++ {
+				val := first.Generate(ty, true)
+				if other := second.Generate(ty, true); !val.RawEquals(other) {
+					t.Fatalf("generated different values with the same seed: %#v and %#v", val, other)
+				}
+
+				if val.IsNull() {
+					nulls++
+					continue
+				}
+				if !val.IsWhollyKnown() {
+					t.Fatalf("generated an unknown value: %#v", val)
+				}
+				if _, err := convert.Convert(val, ty); err != nil {
+					t.Fatalf("generated a value that doesn't conform to %s: %#v: %s", ty.FriendlyName(), val, err)
+				}
+			}
+
+			if nulls == 0 {
+				t.Errorf("expected some null values for a nullable variable")
+			}
+		})
+	}
+}
+
+func TestValueGenerator_notNullable(t *testing.T) {
+	generator := NewValueGenerator(0)
+	for ix := 0; ix < 200; ix++ {
+		if val := generator.Generate(cty.String, false); val.IsNull() {
+			t.Fatalf("generated a null value for a variable that isn't nullable")
+		}
+	}
+}
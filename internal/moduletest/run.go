@@ -7,6 +7,7 @@ package moduletest
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 
@@ -28,6 +29,10 @@ type Run struct {
 	Index  int
 	Status Status
 
+	// Duration is how long it took to execute the run block, excluding
+	// any cleanup of the resources it created.
+	Duration time.Duration
+
 	Diagnostics tfdiags.Diagnostics
 }
 
@@ -33,11 +33,26 @@ func (d *Dir) InstallPackage(ctx context.Context, meta getproviders.PackageMeta,
 		return nil, err
 	}
 
-	install, installErr := d.installPackageWithLock(ctx, meta, allowedHashes, allowSkippingInstallWithoutHashes)
+	install, installErr := d.installPackageWithLock(ctx, meta, allowedHashes, allowSkippingInstallWithoutHashes, false)
 
 	return install, errors.Join(installErr, unlock())
 }
-func (d *Dir) installPackageWithLock(ctx context.Context, meta getproviders.PackageMeta, allowedHashes []getproviders.Hash, allowSkippingInstallWithoutHashes bool) (*getproviders.PackageAuthenticationResult, error) {
+
+// reinstallPackage is like InstallPackage, except that it always fetches and
+// authenticates the package, even if a package matching the allowed hashes
+// is already installed.
+func (d *Dir) reinstallPackage(ctx context.Context, meta getproviders.PackageMeta, allowedHashes []getproviders.Hash) (*getproviders.PackageAuthenticationResult, error) {
+	unlock, err := d.lock(ctx, meta.Provider, meta.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	install, installErr := d.installPackageWithLock(ctx, meta, allowedHashes, false, true)
+
+	return install, errors.Join(installErr, unlock())
+}
+
+func (d *Dir) installPackageWithLock(ctx context.Context, meta getproviders.PackageMeta, allowedHashes []getproviders.Hash, allowSkippingInstallWithoutHashes bool, reinstall bool) (*getproviders.PackageAuthenticationResult, error) {
 	if meta.TargetPlatform != d.targetPlatform {
 		return nil, fmt.Errorf("can't install %s package into cache directory expecting %s", meta.TargetPlatform, d.targetPlatform)
 	}
@@ -48,7 +63,7 @@ func (d *Dir) installPackageWithLock(ctx context.Context, meta getproviders.Pack
 	log.Printf("[TRACE] providercache.Dir.InstallPackage: installing %s v%s from %s", meta.Provider, meta.Version, meta.Location)
 
 	// Check to see if it is already installed
-	if entry := d.ProviderVersion(meta.Provider, meta.Version); entry != nil {
+	if entry := d.ProviderVersion(meta.Provider, meta.Version); entry != nil && !reinstall {
 		if allowSkippingInstallWithoutHashes && len(allowedHashes) == 0 {
 			// Ensure that the provider exists
 			if _, err := entry.ExecutableFile(); err == nil {
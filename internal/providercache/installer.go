@@ -64,6 +64,10 @@ type Installer struct {
 	// lifecycle for, and therefore does not need to worry about the
 	// installation of.
 	unmanagedProviderTypes map[addrs.Provider]struct{}
+
+	// policy is an optional set of additional restrictions on which
+	// providers and provider versions may be installed.
+	policy *ProviderPolicy
 }
 
 // NewInstaller constructs and returns a new installer with the given target
@@ -167,6 +171,18 @@ func (i *Installer) SetUnmanagedProviderTypes(types map[addrs.Provider]struct{})
 	i.unmanagedProviderTypes = types
 }
 
+// SetProviderPolicy tells the receiver to enforce the given policy for all
+// providers other than built-in and unmanaged providers, in addition to the
+// requirements passed to EnsureProviderVersions.
+//
+// The default, if this method isn't called or if the given policy is nil,
+// is to permit any provider that the provider source can offer.
+//
+// Do not modify the given policy after passing it to this method.
+func (i *Installer) SetProviderPolicy(policy *ProviderPolicy) {
+	i.policy = policy
+}
+
 // EnsureProviderVersions compares the given provider requirements with what
 // is already available in the installer's target directory and then takes
 // appropriate installation actions to ensure that suitable packages
@@ -322,6 +338,19 @@ func (i *Installer) ensureProviderVersionsMightNeed(
 			// unmanaged providers do not require installation
 			continue
 		}
+		if err := i.policy.checkProvider(provider); err != nil {
+			errs[provider] = err
+			// As with the locked version mismatch below, we emit an
+			// artificial QueryPackagesBegin so that the event stream
+			// remains consistent.
+			if cb := evts.QueryPackagesBegin; cb != nil {
+				cb(provider, versionConstraints, false)
+			}
+			if cb := evts.QueryPackagesFailure; cb != nil {
+				cb(provider, err)
+			}
+			continue
+		}
 		acceptableVersions := versions.MeetingConstraints(versionConstraints)
 		if !mode.forceQueryAllProviders() {
 			// If we're not forcing potential changes of version then an
@@ -348,11 +377,28 @@ func (i *Installer) ensureProviderVersionsMightNeed(
 					}
 					continue
 				}
+				if !i.policy.acceptableVersions(provider, acceptableVersions).Has(lock.Version()) {
+					err := ErrProviderPolicy{
+						Provider: provider,
+						Reason: fmt.Sprintf(
+							"locked version %s does not match the version limit %s; must use tofu init -upgrade to allow selection of a permitted version",
+							lock.Version(), getproviders.VersionConstraintsString(i.policy.versionLimit(provider)),
+						),
+					}
+					errs[provider] = err
+					if cb := evts.QueryPackagesBegin; cb != nil {
+						cb(provider, versionConstraints, true)
+					}
+					if cb := evts.QueryPackagesFailure; cb != nil {
+						cb(provider, err)
+					}
+					continue
+				}
 				acceptableVersions = versions.Only(lock.Version())
 				locked[provider] = true
 			}
 		}
-		mightNeed[provider] = acceptableVersions
+		mightNeed[provider] = i.policy.acceptableVersions(provider, acceptableVersions)
 	}
 
 	return mightNeed, locked
@@ -421,6 +467,10 @@ NeedProvider:
 			// reason.
 			lock := locks.Provider(provider)
 			err = fmt.Errorf("the previously-selected version %s is no longer available", lock.Version())
+		} else if limit := i.policy.versionLimit(provider); len(limit) != 0 {
+			err = fmt.Errorf("no available releases match both the given constraints %s and the provider policy version limit %s", getproviders.VersionConstraintsString(reqs[provider]), getproviders.VersionConstraintsString(limit))
+			log.Printf("[DEBUG] %s", err.Error())
+			log.Printf("[DEBUG] Available releases: %s", available)
 		} else {
 			err = fmt.Errorf("no available releases match the given constraints %s", getproviders.VersionConstraintsString(reqs[provider]))
 			log.Printf("[DEBUG] %s", err.Error())
@@ -494,8 +544,9 @@ func (i *Installer) ensureProviderVersionInstalled(
 		preferredHashes = lock.PreferredHashes()
 	}
 
-	// If our target directory already has the provider version that fulfills the lock file, carry on
-	if installed := i.targetDir.ProviderVersion(provider, version); installed != nil {
+	// If our target directory already has the provider version that fulfills the lock file, carry on,
+	// unless the provider policy requires us to authenticate the package again.
+	if installed := i.targetDir.ProviderVersion(provider, version); installed != nil && !i.policy.requiresPackageAuthentication() {
 		if len(preferredHashes) > 0 {
 			if matches, _ := installed.MatchesAnyHash(preferredHashes); matches {
 				if cb := evts.ProviderAlreadyInstalled; cb != nil {
@@ -589,8 +640,9 @@ func (i *Installer) ensureProviderVersionInDirectory(
 
 	isGlobalCache := installTo == i.globalCacheDir
 
-	// If our target directory already has the provider version that fulfills the lock file, carry on
-	if installed := installTo.ProviderVersion(provider, version); installed != nil {
+	// If our target directory already has the provider version that fulfills the lock file, carry on,
+	// unless the provider policy requires us to authenticate the package again.
+	if installed := installTo.ProviderVersion(provider, version); installed != nil && !i.policy.requiresPackageAuthentication() {
 		if len(preferredHashes) > 0 {
 			if matches, _ := installed.MatchesAnyHash(preferredHashes); matches {
 				if cb := evts.ProviderAlreadyInstalled; cb != nil {
@@ -620,6 +672,7 @@ func (i *Installer) ensureProviderVersionInDirectory(
 		}
 		return nil, err
 	}
+	meta.Authentication = i.policy.packageAuthentication(provider, meta.Authentication)
 
 	// Step 3c: Retrieve the package indicated by the metadata we received,
 	// either directly into our target directory or via the global cache
@@ -634,7 +687,12 @@ func (i *Installer) ensureProviderVersionInDirectory(
 	}
 
 	allowSkippingInstallWithoutHashes := i.globalCacheDirMayBreakDependencyLockFile && isGlobalCache
-	authResult, err := installTo.InstallPackage(ctx, meta, allowedHashes, allowSkippingInstallWithoutHashes)
+	var authResult *getproviders.PackageAuthenticationResult
+	if i.policy.requiresPackageAuthentication() {
+		authResult, err = installTo.reinstallPackage(ctx, meta, allowedHashes)
+	} else {
+		authResult, err = installTo.InstallPackage(ctx, meta, allowedHashes, allowSkippingInstallWithoutHashes)
+	}
 	if err != nil {
 		// TODO: Consider retrying for certain kinds of error that seem
 		// likely to be transient. For now, we just treat all errors equally.
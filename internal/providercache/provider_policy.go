@@ -0,0 +1,176 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package providercache
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/apparentlymart/go-versions/versions"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/getproviders"
+)
+
+// ProviderPolicy describes operator-defined restrictions on which providers
+// an [Installer] is permitted to install, which apply in addition to the
+// requirements declared in the configuration being installed for.
+//
+// A nil *ProviderPolicy permits everything.
+type ProviderPolicy struct {
+	// AllowedProviders, if non-empty, are patterns that every provider must
+	// match to be installed at all.
+	AllowedProviders getproviders.MultiSourceMatchingPatterns
+
+	// DeniedProviders are patterns that a provider must not match to be
+	// installed. This takes priority over AllowedProviders.
+	DeniedProviders getproviders.MultiSourceMatchingPatterns
+
+	// VersionLimits are additional version constraints for particular
+	// providers, which the selected version must meet along with the
+	// version constraints declared in the configuration.
+	VersionLimits map[addrs.Provider]getproviders.VersionConstraints
+
+	// RequiredSigningKeys, if non-empty, are the IDs of GPG keys that are
+	// acceptable for signing provider packages. Every package must be signed
+	// by at least one of these keys to be installed, including packages that
+	// are already installed, which are fetched and authenticated again.
+	//
+	// Only provider registries sign packages with GPG keys, so packages from
+	// filesystem mirrors, network mirrors and OCI registries can never meet
+	// this requirement, even if they are signed with cosign.
+	//
+	// Key IDs are in the uppercase hexadecimal format used by
+	// [getproviders.HashDisposition.SignedByGPGKeyIDs].
+	RequiredSigningKeys []string
+}
+
+// ErrProviderPolicy is an error type used to indicate that a provider or
+// provider package is not permitted by the installer's [ProviderPolicy].
+type ErrProviderPolicy struct {
+	Provider addrs.Provider
+
+	// Reason is a short, lowercase description of which part of the policy
+	// the provider doesn't meet, suitable for inclusion in a sentence.
+	Reason string
+}
+
+func (err ErrProviderPolicy) Error() string {
+	return fmt.Sprintf("not permitted by the provider policy: %s", err.Reason)
+}
+
+// checkProvider returns an error if the given provider is not permitted
+// to be installed at all.
+func (p *ProviderPolicy) checkProvider(provider addrs.Provider) error {
+	if p == nil {
+		return nil
+	}
+	if p.DeniedProviders.MatchesProvider(provider) {
+		return ErrProviderPolicy{
+			Provider: provider,
+			Reason:   "this provider is listed in denied_providers",
+		}
+	}
+	if len(p.AllowedProviders) != 0 && !p.AllowedProviders.MatchesProvider(provider) {
+		return ErrProviderPolicy{
+			Provider: provider,
+			Reason:   fmt.Sprintf("namespace %s/%s is not listed in allowed_namespaces", provider.Hostname.ForDisplay(), provider.Namespace),
+		}
+	}
+	return nil
+}
+
+// versionLimit returns the additional version constraints for the given
+// provider, or nil if there are none.
+func (p *ProviderPolicy) versionLimit(provider addrs.Provider) getproviders.VersionConstraints {
+	if p == nil {
+		return nil
+	}
+	return p.VersionLimits[provider]
+}
+
+// acceptableVersions returns the subset of the given acceptable versions
+// that are also permitted by any version limit for the given provider.
+func (p *ProviderPolicy) acceptableVersions(provider addrs.Provider, acceptable getproviders.VersionSet) getproviders.VersionSet {
+	limit := p.versionLimit(provider)
+	if len(limit) == 0 {
+		return acceptable
+	}
+	return versions.Intersection(acceptable, versions.MeetingConstraints(limit))
+}
+
+// requiresPackageAuthentication returns true if every package must be
+// authenticated by the installer, rather than trusting packages that are
+// already installed because they match the dependency lock file.
+//
+// The checksums in the lock file only tell us that a package is the one that
+// was previously installed, not who signed it, so they can't be used to
+// enforce RequiredSigningKeys.
+func (p *ProviderPolicy) requiresPackageAuthentication() bool {
+	return p != nil && len(p.RequiredSigningKeys) != 0
+}
+
+// packageAuthentication wraps the given package authentication so that it
+// also enforces RequiredSigningKeys, if set.
+func (p *ProviderPolicy) packageAuthentication(provider addrs.Provider, auth getproviders.PackageAuthentication) getproviders.PackageAuthentication {
+	if p == nil || len(p.RequiredSigningKeys) == 0 {
+		return auth
+	}
+	return requiredSigningKeysAuthentication{
+		provider: provider,
+		wrapped:  auth,
+		keyIDs:   p.RequiredSigningKeys,
+	}
+}
+
+// requiredSigningKeysAuthentication is a [getproviders.PackageAuthentication]
+// that runs another authentication and then additionally requires that at
+// least one of the hashes it reported was signed by one of a set of GPG keys.
+//
+// This runs before the package is unpacked into its final location, so a
+// package that fails this check is never installed.
+type requiredSigningKeysAuthentication struct {
+	provider addrs.Provider
+	wrapped  getproviders.PackageAuthentication
+	keyIDs   []string
+}
+
+func (a requiredSigningKeysAuthentication) AuthenticatePackage(localLocation getproviders.PackageLocation) (*getproviders.PackageAuthenticationResult, error) {
+	var result *getproviders.PackageAuthenticationResult
+	if a.wrapped != nil {
+		var err error
+		result, err = a.wrapped.AuthenticatePackage(localLocation)
+		if err != nil {
+			return nil, err
+		}
+	}
+	matched := false
+	for range result.HashesWithDisposition(func(hd *getproviders.HashDisposition) bool {
+		for keyID := range hd.SignedByGPGKeyIDs {
+			if slices.Contains(a.keyIDs, strings.ToUpper(keyID)) {
+				return true
+			}
+		}
+		return false
+	}) {
+		matched = true
+		break
+	}
+	if !matched {
+		reason := "the package is not signed by any GPG key listed in required_signing_keys"
+		if signers := result.GPGKeyIDsString(); signers != "" {
+			reason += fmt.Sprintf(" (it is signed by %s)", signers)
+		} else if signers := result.OCISignersString(); signers != "" {
+			reason += fmt.Sprintf(" (it is only signed with cosign, by %s, and only GPG signatures from a provider registry can meet this requirement)", signers)
+		} else {
+			reason += " (it has no GPG signature, as is always the case for packages from filesystem mirrors, network mirrors and OCI registries)"
+		}
+		return nil, ErrProviderPolicy{
+			Provider: a.provider,
+			Reason:   reason,
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,320 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package providercache
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/opentofu/opentofu/internal/addrs"
+	"github.com/opentofu/opentofu/internal/collections"
+	"github.com/opentofu/opentofu/internal/depsfile"
+	"github.com/opentofu/opentofu/internal/getproviders"
+)
+
+func TestEnsureProviderVersions_providerPolicy(t *testing.T) {
+	platform := getproviders.Platform{OS: "linux", Arch: "amd64"}
+	beepProvider := addrs.MustParseProviderSourceString("example.com/foo/beep")
+	boopProvider := addrs.MustParseProviderSourceString("example.com/bar/boop")
+
+	var packages []getproviders.PackageMeta
+	for _, provider := range []addrs.Provider{beepProvider, boopProvider} {
+		for _, version := range []string{"1.0.0", "2.0.0"} {
+			meta, close, err := getproviders.FakeInstallablePackageMeta(provider, getproviders.MustParseVersion(version), nil, platform, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(close)
+			if provider == beepProvider {
+				// The beep provider packages claim to be signed by a
+				// fake GPG key, whereas the boop provider packages are
+				// not signed at all.
+				meta.Authentication = getproviders.PackageAuthenticationAll(
+					meta.Authentication,
+					fakeGPGSignatureAuthentication("34365D9472D7468F"),
+				)
+			}
+			packages = append(packages, meta)
+		}
+	}
+
+	tests := map[string]struct {
+		policy      *ProviderPolicy
+		locks       *depsfile.Locks
+		reqs        getproviders.Requirements
+		wantVersion map[addrs.Provider]string
+		wantErr     map[addrs.Provider]string
+	}{
+		"no policy": {
+			policy: nil,
+			reqs: getproviders.Requirements{
+				beepProvider: nil,
+				boopProvider: nil,
+			},
+			wantVersion: map[addrs.Provider]string{
+				beepProvider: "2.0.0",
+				boopProvider: "2.0.0",
+			},
+		},
+		"denied provider": {
+			policy: &ProviderPolicy{
+				DeniedProviders: getproviders.MultiSourceMatchingPatterns{boopProvider},
+			},
+			reqs: getproviders.Requirements{
+				beepProvider: nil,
+				boopProvider: nil,
+			},
+			wantVersion: map[addrs.Provider]string{
+				beepProvider: "2.0.0",
+			},
+			wantErr: map[addrs.Provider]string{
+				boopProvider: "this provider is listed in denied_providers",
+			},
+		},
+		"namespace not allowed": {
+			policy: &ProviderPolicy{
+				AllowedProviders: mustParseMultiSourceMatchingPatterns(t, "example.com/foo/*"),
+			},
+			reqs: getproviders.Requirements{
+				beepProvider: nil,
+				boopProvider: nil,
+			},
+			wantVersion: map[addrs.Provider]string{
+				beepProvider: "2.0.0",
+			},
+			wantErr: map[addrs.Provider]string{
+				boopProvider: "namespace example.com/bar is not listed in allowed_namespaces",
+			},
+		},
+		"version limit": {
+			policy: &ProviderPolicy{
+				VersionLimits: map[addrs.Provider]getproviders.VersionConstraints{
+					beepProvider: getproviders.MustParseVersionConstraints("<= 1.5.0"),
+				},
+			},
+			reqs: getproviders.Requirements{
+				beepProvider: getproviders.MustParseVersionConstraints(">= 1.0.0"),
+				boopProvider: nil,
+			},
+			wantVersion: map[addrs.Provider]string{
+				beepProvider: "1.0.0",
+				boopProvider: "2.0.0",
+			},
+		},
+		"version limit unmet": {
+			policy: &ProviderPolicy{
+				VersionLimits: map[addrs.Provider]getproviders.VersionConstraints{
+					beepProvider: getproviders.MustParseVersionConstraints("<= 1.5.0"),
+				},
+			},
+			reqs: getproviders.Requirements{
+				beepProvider: getproviders.MustParseVersionConstraints(">= 2.0.0"),
+			},
+			wantErr: map[addrs.Provider]string{
+				beepProvider: "no available releases match both the given constraints >= 2.0.0 and the provider policy version limit <= 1.5.0",
+			},
+		},
+		"locked version outside version limit": {
+			policy: &ProviderPolicy{
+				VersionLimits: map[addrs.Provider]getproviders.VersionConstraints{
+					beepProvider: getproviders.MustParseVersionConstraints("<= 1.5.0"),
+				},
+			},
+			locks: func() *depsfile.Locks {
+				locks := depsfile.NewLocks()
+				locks.SetProvider(beepProvider, getproviders.MustParseVersion("2.0.0"), nil, nil)
+				return locks
+			}(),
+			reqs: getproviders.Requirements{
+				beepProvider: nil,
+			},
+			wantErr: map[addrs.Provider]string{
+				beepProvider: "locked version 2.0.0 does not match the version limit <= 1.5.0",
+			},
+		},
+		"required signing key": {
+			policy: &ProviderPolicy{
+				RequiredSigningKeys: []string{"34365D9472D7468F"},
+			},
+			reqs: getproviders.Requirements{
+				beepProvider: nil,
+				boopProvider: nil,
+			},
+			wantVersion: map[addrs.Provider]string{
+				beepProvider: "2.0.0",
+			},
+			wantErr: map[addrs.Provider]string{
+				boopProvider: "the package is not signed by any GPG key listed in required_signing_keys",
+			},
+		},
+		"required signing key mismatch": {
+			policy: &ProviderPolicy{
+				RequiredSigningKeys: []string{"0123456789ABCDEF"},
+			},
+			reqs: getproviders.Requirements{
+				beepProvider: nil,
+			},
+			wantErr: map[addrs.Provider]string{
+				beepProvider: "the package is not signed by any GPG key listed in required_signing_keys (it is signed by 34365D9472D7468F)",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := NewDirWithPlatform(t.TempDir(), platform)
+			installer := NewInstaller(dir, getproviders.NewMockSource(packages, nil))
+			installer.SetProviderPolicy(test.policy)
+
+			locks := test.locks
+			if locks == nil {
+				locks = depsfile.NewLocks()
+			}
+			newLocks, err := installer.EnsureProviderVersions(t.Context(), locks, test.reqs, InstallNewProvidersOnly)
+
+			gotErrs := map[addrs.Provider]error{}
+			if err != nil {
+				var installerErr InstallerError
+				if !errors.As(err, &installerErr) {
+					t.Fatalf("wrong error type %T; want InstallerError", err)
+				}
+				gotErrs = installerErr.ProviderErrors
+			}
+			if len(gotErrs) != len(test.wantErr) {
+				t.Errorf("wrong number of errors\ngot:  %s\nwant: %d errors", err, len(test.wantErr))
+			}
+			for provider, want := range test.wantErr {
+				got, ok := gotErrs[provider]
+				if !ok {
+					t.Errorf("no error for %s", provider)
+					continue
+				}
+				if !strings.Contains(got.Error(), want) {
+					t.Errorf("wrong error for %s\ngot:  %s\nwant substring: %s", provider, got, want)
+				}
+				if dir.ProviderLatestVersion(provider) != nil {
+					t.Errorf("%s was installed despite the error", provider)
+				}
+			}
+			for provider, want := range test.wantVersion {
+				lock := newLocks.Provider(provider)
+				if lock == nil {
+					t.Errorf("no lock for %s", provider)
+					continue
+				}
+				if got := lock.Version().String(); got != want {
+					t.Errorf("wrong version selected for %s\ngot:  %s\nwant: %s", provider, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEnsureProviderVersions_providerPolicyInstalled(t *testing.T) {
+	// Packages that are already installed and match the dependency lock file
+	// must still meet required_signing_keys, as the lock file doesn't record
+	// who signed them.
+	platform := getproviders.Platform{OS: "linux", Arch: "amd64"}
+	beepProvider := addrs.MustParseProviderSourceString("example.com/foo/beep")
+	boopProvider := addrs.MustParseProviderSourceString("example.com/bar/boop")
+
+	var packages []getproviders.PackageMeta
+	for _, provider := range []addrs.Provider{beepProvider, boopProvider} {
+		meta, close, err := getproviders.FakeInstallablePackageMeta(provider, getproviders.MustParseVersion("1.0.0"), nil, platform, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(close)
+		if provider == beepProvider {
+			meta.Authentication = getproviders.PackageAuthenticationAll(
+				meta.Authentication,
+				fakeGPGSignatureAuthentication("34365D9472D7468F"),
+			)
+		}
+		packages = append(packages, meta)
+	}
+	source := getproviders.NewMockSource(packages, nil)
+	reqs := getproviders.Requirements{
+		beepProvider: nil,
+		boopProvider: nil,
+	}
+
+	for _, withGlobalCache := range []bool{false, true} {
+		dir := NewDirWithPlatform(t.TempDir(), platform)
+		var globalCache *Dir
+		if withGlobalCache {
+			globalCache = NewDirWithPlatform(t.TempDir(), platform)
+		}
+
+		installer := NewInstaller(dir, source)
+		if globalCache != nil {
+			installer.SetGlobalCacheDir(globalCache)
+		}
+		locks, err := installer.EnsureProviderVersions(t.Context(), depsfile.NewLocks(), reqs, InstallNewProvidersOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		installer = NewInstaller(dir, source)
+		if globalCache != nil {
+			installer.SetGlobalCacheDir(globalCache)
+		}
+		installer.SetProviderPolicy(&ProviderPolicy{
+			RequiredSigningKeys: []string{"34365D9472D7468F"},
+		})
+		_, err = installer.EnsureProviderVersions(t.Context(), locks, reqs, InstallNewProvidersOnly)
+
+		var installerErr InstallerError
+		if !errors.As(err, &installerErr) {
+			t.Fatalf("wrong error %#v; want InstallerError", err)
+		}
+		if len(installerErr.ProviderErrors) != 1 {
+			t.Errorf("wrong errors: %s", err)
+		}
+		want := "the package is not signed by any GPG key listed in required_signing_keys (it has no GPG signature"
+		if got := installerErr.ProviderErrors[boopProvider]; got == nil || !strings.Contains(got.Error(), want) {
+			t.Errorf("wrong error for %s\ngot:  %v\nwant substring: %s", boopProvider, got, want)
+		}
+	}
+}
+
+func TestProviderPolicy_nil(t *testing.T) {
+	// A nil policy must permit everything, so that callers can pass through
+	// an unset policy without checking for it.
+	var policy *ProviderPolicy
+	provider := addrs.MustParseProviderSourceString("example.com/foo/beep")
+	if err := policy.checkProvider(provider); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	acceptable := getproviders.MeetingConstraints(getproviders.MustParseVersionConstraints(">= 1.0.0"))
+	if got := policy.acceptableVersions(provider, acceptable); !got.Has(getproviders.MustParseVersion("2.0.0")) {
+		t.Errorf("nil policy excluded version 2.0.0")
+	}
+	if got := policy.packageAuthentication(provider, nil); got != nil {
+		t.Errorf("nil policy added authentication %#v", got)
+	}
+}
+
+func mustParseMultiSourceMatchingPatterns(t *testing.T, strs ...string) getproviders.MultiSourceMatchingPatterns {
+	t.Helper()
+	ret, err := getproviders.ParseMultiSourceMatchingPatterns(strs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
+
+// fakeGPGSignatureAuthentication is a [getproviders.PackageAuthentication]
+// that reports a fake hash as having been signed by the GPG key with the
+// given ID, without actually checking anything.
+type fakeGPGSignatureAuthentication string
+
+func (a fakeGPGSignatureAuthentication) AuthenticatePackage(_ getproviders.PackageLocation) (*getproviders.PackageAuthenticationResult, error) {
+	return getproviders.NewPackageAuthenticationResult(getproviders.HashDispositions{
+		getproviders.HashSchemeZip.New("0000000000000000000000000000000000000000000000000000000000000000"): {
+			SignedByGPGKeyIDs: collections.NewSet(string(a)),
+		},
+	}), nil
+}
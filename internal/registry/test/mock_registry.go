@@ -94,6 +94,10 @@ var testMods = map[string][]testMod{
 		{version: "1.2.2"},
 		{version: "1.2.1"},
 	},
+	"test-prereleases/name/provider": {
+		{version: "1.3.0-beta1"},
+		{version: "1.2.0"},
+	},
 	"private/name/provider": {
 		{version: "1.0.0"},
 	},
@@ -0,0 +1,206 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package remote
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+)
+
+// ObjectStore is the storage interface used by ChunkedClient. Backends
+// implement it over whatever objects they store state in, such as keys in a
+// key/value store or Kubernetes secrets.
+//
+// The empty key refers to the primary object for the state, which is what
+// older OpenTofu versions read directly. Other keys are chunk names chosen
+// by ChunkedClient and are valid path segments and DNS labels, so a store
+// can usually derive the object name by appending them to the primary name.
+type ObjectStore interface {
+	// GetObject returns the content of the object with the given key, or
+	// nil if no such object exists.
+	GetObject(ctx context.Context, key string) ([]byte, error)
+	PutObject(ctx context.Context, key string, data []byte) error
+	// DeleteObject deletes the object with the given key. Deleting an
+	// object that does not exist is not an error.
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// ChunkedClient is a Client that compresses state payloads and, when the
+// result is larger than the store's object size limit, splits them across
+// several objects referenced from a small manifest stored in the primary
+// object.
+type ChunkedClient struct {
+	Store ObjectStore
+
+	// Compression is used for all new snapshots. Snapshots written with a
+	// different setting remain readable.
+	Compression Compression
+
+	// MaxObjectSize is the largest payload that will be stored in a single
+	// object. Zero disables chunking.
+	MaxObjectSize int
+}
+
+var _ Client = (*ChunkedClient)(nil)
+
+// ChunkManifest is stored in the primary object in place of the state when
+// the payload has been split into chunks.
+//
+// Its format matches the one historically used by the consul backend, so
+// snapshots that backend wrote in chunked mode can be read by this helper.
+type ChunkManifest struct {
+	// Hash is the hex-encoded MD5 checksum of the state data, after
+	// reassembling and decompressing the chunks.
+	Hash string `json:"current-hash"`
+	// Chunks are the keys of the objects that make up the payload, in order.
+	Chunks []string `json:"chunks"`
+}
+
+// ParseChunkManifest returns the manifest stored in the given primary object,
+// or nil if the object contains a payload rather than a manifest.
+func ParseChunkManifest(data []byte) *ChunkManifest {
+	if len(data) == 0 || data[0] != '{' {
+		return nil
+	}
+	var m ChunkManifest
+	if err := json.Unmarshal(data, &m); err != nil || m.Hash == "" {
+		return nil
+	}
+	return &m
+}
+
+// SplitChunks splits payload into pieces of at most limit bytes.
+func SplitChunks(payload []byte, limit int) [][]byte {
+	var chunk []byte
+	chunks := make([][]byte, 0, len(payload)/limit+1)
+	for len(payload) >= limit {
+		chunk, payload = payload[:limit], payload[limit:]
+		chunks = append(chunks, chunk)
+	}
+	if len(payload) > 0 {
+		chunks = append(chunks, payload)
+	}
+	return chunks
+}
+
+func (c *ChunkedClient) Get(ctx context.Context) (*Payload, error) {
+	raw, err := c.Store.GetObject(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	m := ParseChunkManifest(raw)
+	if m != nil {
+		raw = nil
+		for _, key := range m.Chunks {
+			chunk, err := c.Store.GetObject(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			if chunk == nil {
+				return nil, fmt.Errorf("state chunk %q could not be found", key)
+			}
+			raw = append(raw, chunk...)
+		}
+	}
+
+	data, err := Decompress(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := md5.Sum(data)
+	if m != nil && fmt.Sprintf("%x", sum) != m.Hash {
+		return nil, fmt.Errorf("the remote state does not match the expected hash")
+	}
+	return &Payload{
+		Data: data,
+		MD5:  sum[:],
+	}, nil
+}
+
+func (c *ChunkedClient) Put(ctx context.Context, data []byte) error {
+	payload, err := Compress(data, c.Compression)
+	if err != nil {
+		return fmt.Errorf("failed to compress state: %w", err)
+	}
+
+	old, err := c.manifest(ctx)
+	if err != nil {
+		return err
+	}
+
+	var keep map[string]bool
+	if c.MaxObjectSize <= 0 || len(payload) <= c.MaxObjectSize {
+		if err := c.Store.PutObject(ctx, "", payload); err != nil {
+			return err
+		}
+	} else {
+		// We write the chunks under names derived from the state checksum
+		// before switching the manifest over, so that an interrupted Put
+		// leaves the previous snapshot intact.
+		m := &ChunkManifest{Hash: fmt.Sprintf("%x", md5.Sum(data))}
+		keep = make(map[string]bool)
+		for i, chunk := range SplitChunks(payload, c.MaxObjectSize) {
+			key := fmt.Sprintf("%s-%d", m.Hash, i)
+			if err := c.Store.PutObject(ctx, key, chunk); err != nil {
+				return err
+			}
+			m.Chunks = append(m.Chunks, key)
+			keep[key] = true
+		}
+		raw, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if err := c.Store.PutObject(ctx, "", raw); err != nil {
+			return err
+		}
+	}
+
+	if old != nil {
+		for _, key := range old.Chunks {
+			if keep[key] {
+				continue
+			}
+			if err := c.Store.DeleteObject(ctx, key); err != nil {
+				return fmt.Errorf("failed to delete old state chunk %q: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *ChunkedClient) Delete(ctx context.Context) error {
+	m, err := c.manifest(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.Store.DeleteObject(ctx, ""); err != nil {
+		return err
+	}
+	if m != nil {
+		for _, key := range m.Chunks {
+			if err := c.Store.DeleteObject(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// manifest returns the chunk manifest currently stored in the primary
+// object, if any.
+func (c *ChunkedClient) manifest(ctx context.Context) (*ChunkManifest, error) {
+	raw, err := c.Store.GetObject(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return ParseChunkManifest(raw), nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"testing"
+)
+
+func TestChunkedClient(t *testing.T) {
+	store := &mapObjectStore{objects: map[string][]byte{}}
+	client := &ChunkedClient{
+		Store:         store,
+		Compression:   CompressionGzip,
+		MaxObjectSize: 64,
+	}
+
+	TestClient(t, client)
+	if len(store.objects) != 0 {
+		t.Fatalf("objects left behind after Delete: %d", len(store.objects))
+	}
+
+	// Random data doesn't compress, so this must be split into chunks.
+	large := make([]byte, 300)
+	if _, err := rand.Read(large); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Put(t.Context(), large); err != nil {
+		t.Fatal(err)
+	}
+	if m := ParseChunkManifest(store.objects[""]); m == nil || len(m.Chunks) < 5 {
+		t.Fatalf("expected a manifest with at least five chunks, got %#v", m)
+	}
+
+	p, err := client.Get(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(p.Data, large) {
+		t.Fatal("wrong data after reassembling chunks")
+	}
+
+	// Writing a small state afterwards must clean up the old chunks.
+	if err := client.Put(t.Context(), []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.objects) != 1 {
+		t.Fatalf("expected only the primary object to remain, got %d objects", len(store.objects))
+	}
+
+	// Snapshots written without compression remain readable.
+	store.objects[""] = []byte(`{"plain": true}`)
+	p, err = client.Get(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(p.Data), `{"plain": true}`; got != want {
+		t.Fatalf("wrong data %q; want %q", got, want)
+	}
+}
+
+type mapObjectStore struct {
+	objects map[string][]byte
+}
+
+func (s *mapObjectStore) GetObject(_ context.Context, key string) ([]byte, error) {
+	return s.objects[key], nil
+}
+
+func (s *mapObjectStore) PutObject(_ context.Context, key string, data []byte) error {
+	s.objects[key] = data
+	return nil
+}
+
+func (s *mapObjectStore) DeleteObject(_ context.Context, key string) error {
+	delete(s.objects, key)
+	return nil
+}
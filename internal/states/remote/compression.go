@@ -0,0 +1,104 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package remote
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression selects the algorithm used to compress state payloads before
+// they are sent to remote storage.
+type Compression string
+
+const (
+	CompressionNone Compression = "none"
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ParseCompression returns the Compression with the given name, as it would
+// appear in a backend configuration. The empty string selects
+// CompressionNone.
+func ParseCompression(name string) (Compression, error) {
+	switch c := Compression(name); c {
+	case "":
+		return CompressionNone, nil
+	case CompressionNone, CompressionGzip, CompressionZstd:
+		return c, nil
+	default:
+		return "", fmt.Errorf("unsupported state compression %q; must be one of %q, %q or %q", name, CompressionNone, CompressionGzip, CompressionZstd)
+	}
+}
+
+// Compress compresses the given state payload using the given algorithm.
+//
+// The result can be decoded with Decompress, which detects the algorithm
+// from the payload itself, so the chosen algorithm doesn't need to be stored
+// alongside it.
+func Compress(data []byte, c Compression) ([]byte, error) {
+	switch c {
+	case "", CompressionNone:
+		return data, nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		w, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer w.Close()
+		return w.EncodeAll(data, nil), nil
+	default:
+		return nil, fmt.Errorf("unsupported state compression %q", c)
+	}
+}
+
+// Decompress reverses Compress, detecting the algorithm from the payload.
+//
+// State snapshots are always JSON objects, which cannot begin with any of the
+// compression formats' magic numbers, so a payload that isn't recognized as
+// compressed is returned unchanged. This allows backends to switch between
+// compression settings without rewriting existing snapshots first.
+func Decompress(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip state payload: %w", err)
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case bytes.HasPrefix(data, zstdMagic):
+		r, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		ret, err := r.DecodeAll(data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd state payload: %w", err)
+		}
+		return ret, nil
+	default:
+		return data, nil
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package remote
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"version": 4, "serial": 1}`), 100)
+
+	for _, c := range []Compression{CompressionNone, CompressionGzip, CompressionZstd} {
+		t.Run(string(c), func(t *testing.T) {
+			compressed, err := Compress(data, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c != CompressionNone && len(compressed) >= len(data) {
+				t.Errorf("compressed payload is not smaller: %d >= %d", len(compressed), len(data))
+			}
+
+			got, err := Decompress(compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("wrong result after round trip\ngot:  %q\nwant: %q", got, data)
+			}
+		})
+	}
+}
+
+func TestParseCompression(t *testing.T) {
+	tests := map[string]struct {
+		want    Compression
+		wantErr bool
+	}{
+		"":     {want: CompressionNone},
+		"none": {want: CompressionNone},
+		"gzip": {want: CompressionGzip},
+		"zstd": {want: CompressionZstd},
+		"lz4":  {wantErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseCompression(name)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("unexpected error result: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("wrong result %q; want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -43,6 +43,20 @@ type OptionalClientLocker interface {
 	IsLockingEnabled() bool
 }
 
+// ClientHistory is an optional interface that allows a remote state
+// backend to expose the previous snapshots it has retained.
+type ClientHistory interface {
+	Client
+
+	// Versions returns descriptions of the retained snapshots, ordered from
+	// newest to oldest.
+	Versions(context.Context) ([]statemgr.StateVersion, error)
+
+	// GetVersion returns the payload of the snapshot with the given id, or
+	// nil if there is no such snapshot.
+	GetVersion(context.Context, string) (*Payload, error)
+}
+
 // Payload is the return value from the remote state storage.
 type Payload struct {
 	MD5  []byte
@@ -9,7 +9,10 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/json"
+	"strconv"
 	"testing"
+
+	"github.com/opentofu/opentofu/internal/states/statemgr"
 )
 
 func TestRemoteClient_noPayload(t *testing.T) {
@@ -84,6 +87,34 @@ func (c *mockClient) appendLog(method string, content []byte) {
 	c.log = append(c.log, mockClientRequest{method, contentVal})
 }
 
+// mockHistoryClient is like mockClient, but also retains every snapshot
+// written with Put so that it implements ClientHistory.
+type mockHistoryClient struct {
+	mockClient
+	history [][]byte
+}
+
+func (c *mockHistoryClient) Put(ctx context.Context, data []byte) error {
+	c.history = append(c.history, data)
+	return c.mockClient.Put(ctx, data)
+}
+
+func (c *mockHistoryClient) Versions(_ context.Context) ([]statemgr.StateVersion, error) {
+	var ret []statemgr.StateVersion
+	for i := len(c.history) - 1; i >= 0; i-- {
+		ret = append(ret, statemgr.StateVersion{ID: strconv.Itoa(i)})
+	}
+	return ret, nil
+}
+
+func (c *mockHistoryClient) GetVersion(_ context.Context, id string) (*Payload, error) {
+	idx, err := strconv.Atoi(id)
+	if err != nil || idx < 0 || idx >= len(c.history) {
+		return nil, nil
+	}
+	return &Payload{Data: c.history[idx]}, nil
+}
+
 // mockClientForcePusher is like mockClient, but also implements
 // EnableForcePush, allowing testing for this behavior
 type mockClientForcePusher struct {
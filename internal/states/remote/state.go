@@ -57,6 +57,7 @@ type State struct {
 var _ statemgr.Full = (*State)(nil)
 var _ statemgr.Migrator = (*State)(nil)
 var _ statemgr.PersistentMeta = (*State)(nil)
+var _ statemgr.History = (*State)(nil)
 var _ local.IntermediateStateConditionalPersister = (*State)(nil)
 
 func NewState(client Client, enc encryption.StateEncryption) *State {
@@ -320,3 +321,33 @@ func (s *State) StateSnapshotMeta() statemgr.SnapshotMeta {
 		Serial:  s.serial,
 	}
 }
+
+// StateVersions returns the snapshots retained by the client, if it
+// implements ClientHistory.
+//
+// This is an implementation of statemgr.History.
+func (s *State) StateVersions(ctx context.Context) ([]statemgr.StateVersion, error) {
+	c, ok := s.Client.(ClientHistory)
+	if !ok {
+		return nil, fmt.Errorf("the selected backend does not retain previous state snapshots")
+	}
+	return c.Versions(ctx)
+}
+
+// StateVersion reads and decodes the retained snapshot with the given id.
+//
+// This is an implementation of statemgr.History.
+func (s *State) StateVersion(ctx context.Context, id string) (*statefile.File, error) {
+	c, ok := s.Client.(ClientHistory)
+	if !ok {
+		return nil, fmt.Errorf("the selected backend does not retain previous state snapshots")
+	}
+	payload, err := c.GetVersion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payload == nil {
+		return nil, fmt.Errorf("no state snapshot with id %q", id)
+	}
+	return statefile.Read(bytes.NewReader(payload.Data), s.encryption)
+}
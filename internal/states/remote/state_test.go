@@ -7,6 +7,7 @@ package remote
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"sync"
 	"testing"
@@ -31,6 +32,7 @@ func TestState_impl(t *testing.T) {
 	var _ statemgr.Refresher = new(State)
 	var _ statemgr.OutputReader = new(State)
 	var _ statemgr.Locker = new(State)
+	var _ statemgr.History = new(State)
 }
 
 func TestStateRace(t *testing.T) {
@@ -423,6 +425,46 @@ func TestState_GetRootOutputValues(t *testing.T) {
 	}
 }
 
+func TestState_StateVersions(t *testing.T) {
+	t.Run("unsupported", func(t *testing.T) {
+		mgr := NewState(&mockClient{}, encryption.StateEncryptionDisabled())
+		if _, err := mgr.StateVersions(t.Context()); err == nil {
+			t.Fatal("expected an error for a client without history")
+		}
+	})
+
+	t.Run("supported", func(t *testing.T) {
+		client := &mockHistoryClient{}
+		mgr := NewState(client, encryption.StateEncryptionDisabled())
+
+		for serial := 1; serial <= 2; serial++ {
+			if err := client.Put(t.Context(), []byte(fmt.Sprintf(`{"version": 4, "lineage": "mock-lineage", "serial": %d, "terraform_version": "0.0.0"}`, serial))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		versions, err := mgr.StateVersions(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(versions) != 2 {
+			t.Fatalf("expected 2 versions, got %d", len(versions))
+		}
+
+		f, err := mgr.StateVersion(t.Context(), versions[1].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Serial != 1 {
+			t.Errorf("expected oldest version to have serial 1, got %d", f.Serial)
+		}
+
+		if _, err := mgr.StateVersion(t.Context(), "missing"); err == nil {
+			t.Error("expected an error for an unknown version")
+		}
+	})
+}
+
 type migrationTestCase struct {
 	name string
 	// A function to generate a statefile
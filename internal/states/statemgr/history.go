@@ -0,0 +1,38 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package statemgr
+
+import (
+	"context"
+	"time"
+
+	"github.com/opentofu/opentofu/internal/states/statefile"
+)
+
+// History is an optional extension to Persistent for state managers whose
+// storage retains earlier persistent snapshots alongside the latest one.
+//
+// Implementations may only be able to return history when the underlying
+// storage was configured to retain it, in which case the methods return
+// an error explaining that no history is available.
+type History interface {
+	// StateVersions returns descriptions of all of the retained snapshots,
+	// ordered from newest to oldest.
+	StateVersions(ctx context.Context) ([]StateVersion, error)
+
+	// StateVersion returns the snapshot with the given id, as previously
+	// returned in a StateVersion object from StateVersions.
+	StateVersion(ctx context.Context, id string) (*statefile.File, error)
+}
+
+// StateVersion describes a single historical snapshot retained by a
+// state manager that implements History.
+type StateVersion struct {
+	// ID is an opaque identifier that can be passed to History.StateVersion
+	// to retrieve the snapshot.
+	ID string
+
+	// Created is the time at which the snapshot was persisted.
+	Created time.Time
+}
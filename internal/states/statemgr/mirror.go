@@ -0,0 +1,210 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package statemgr
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/opentofu/opentofu/internal/states/statefile"
+	"github.com/opentofu/opentofu/internal/tofu"
+)
+
+// Mirrored is a Full state manager that wraps another one, additionally
+// writing each snapshot that is successfully persisted to a secondary
+// "mirror" state manager, such as one belonging to a backend in another
+// region, for disaster recovery.
+//
+// The mirror is write-only from the perspective of Mirrored: all reads and
+// locking are handled by the primary manager alone. Failures to write to the
+// mirror never cause PersistState to fail. They are instead reported to the
+// callback given to NewMirrored, so that the caller can warn about them. The
+// callback is only ever called from the goroutine calling PersistState,
+// Flush or Unlock, even when the mirror is written in the background.
+type Mirrored struct {
+	Full
+
+	mirror  Storage
+	async   bool
+	onError func(error)
+
+	// The fields below coordinate the background writer used in async mode.
+	// Only the most recent pending snapshot is retained, since writing any
+	// older ones would be immediately superseded. Errors from the background
+	// writer are retained until they can be reported on the caller's
+	// goroutine.
+	mu      sync.Mutex
+	pending *mirrorWrite
+	done    chan struct{}
+	errs    []error
+}
+
+var _ Full = (*Mirrored)(nil)
+var _ Migrator = (*Mirrored)(nil)
+var _ PersistentMeta = (*Mirrored)(nil)
+var _ Flusher = (*Mirrored)(nil)
+
+type mirrorWrite struct {
+	file    *statefile.File
+	schemas *tofu.Schemas
+}
+
+// NewMirrored returns a state manager that delegates to primary and also
+// writes all persisted snapshots to mirror.
+//
+// If async is true then the writes to the mirror happen in the background,
+// and callers must call Flush (which Unlock also does) to wait for them to
+// complete. onError may be nil, in which case mirror failures are only
+// logged.
+func NewMirrored(primary Full, mirror Storage, async bool, onError func(error)) *Mirrored {
+	return &Mirrored{
+		Full:    primary,
+		mirror:  mirror,
+		async:   async,
+		onError: onError,
+	}
+}
+
+// PersistState persists the current state using the primary manager and
+// then, if successful, copies the resulting snapshot to the mirror.
+func (m *Mirrored) PersistState(ctx context.Context, schemas *tofu.Schemas) error {
+	if err := m.Full.PersistState(ctx, schemas); err != nil {
+		return err
+	}
+
+	w := &mirrorWrite{
+		file:    Export(m.Full),
+		schemas: schemas,
+	}
+	if !m.async {
+		if err := m.write(ctx, w); err != nil {
+			m.reportErrors([]error{err})
+		}
+		return nil
+	}
+
+	m.mu.Lock()
+	m.pending = w
+	if m.done == nil {
+		m.done = make(chan struct{})
+		go m.drain(context.WithoutCancel(ctx), m.done)
+	}
+	errs := m.errs
+	m.errs = nil
+	m.mu.Unlock()
+
+	m.reportErrors(errs)
+	return nil
+}
+
+// Flush blocks until any snapshots being written to the mirror in the
+// background have been written.
+//
+// This is an implementation of Flusher.
+func (m *Mirrored) Flush() {
+	m.mu.Lock()
+	done := m.done
+	m.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+
+	m.mu.Lock()
+	errs := m.errs
+	m.errs = nil
+	m.mu.Unlock()
+	m.reportErrors(errs)
+}
+
+// Unlock waits for any pending mirror writes and then releases the lock
+// held on the primary manager, so that the mirror is up to date by the time
+// another process can modify the state.
+func (m *Mirrored) Unlock(ctx context.Context, id string) error {
+	m.Flush()
+	return m.Full.Unlock(ctx, id)
+}
+
+// StateSnapshotMeta returns the metadata of the primary manager's most
+// recently persisted or refreshed snapshot, so that callers checking whether
+// a saved plan is stale see the same result as without a mirror.
+//
+// This is an implementation of PersistentMeta.
+func (m *Mirrored) StateSnapshotMeta() SnapshotMeta {
+	if pm, ok := m.Full.(PersistentMeta); ok {
+		return pm.StateSnapshotMeta()
+	}
+	f := Export(m.Full)
+	return SnapshotMeta{
+		Lineage:          f.Lineage,
+		Serial:           f.Serial,
+		TerraformVersion: f.TerraformVersion,
+	}
+}
+
+// StateForMigration is part of our implementation of Migrator.
+func (m *Mirrored) StateForMigration() *statefile.File {
+	return Export(m.Full)
+}
+
+// WriteStateForMigration is part of our implementation of Migrator.
+func (m *Mirrored) WriteStateForMigration(f *statefile.File, force bool) error {
+	return Import(f, m.Full, force)
+}
+
+func (m *Mirrored) drain(ctx context.Context, done chan struct{}) {
+	for {
+		m.mu.Lock()
+		w := m.pending
+		m.pending = nil
+		if w == nil {
+			m.done = nil
+			close(done)
+			m.mu.Unlock()
+			return
+		}
+		m.mu.Unlock()
+
+		if err := m.write(ctx, w); err != nil {
+			m.mu.Lock()
+			m.errs = append(m.errs, err)
+			m.mu.Unlock()
+		}
+	}
+}
+
+// reportErrors passes the given errors to the callback given to NewMirrored,
+// if any. It must only be called from the goroutine using the manager.
+func (m *Mirrored) reportErrors(errs []error) {
+	if m.onError == nil {
+		return
+	}
+	for _, err := range errs {
+		m.onError(err)
+	}
+}
+
+// write copies the given snapshot to the mirror, returning an error if that
+// fails.
+func (m *Mirrored) write(ctx context.Context, w *mirrorWrite) error {
+	// Persistent managers expect to have read the latest snapshot before
+	// a new one is written over it.
+	err := m.mirror.RefreshState(ctx)
+	if err == nil {
+		err = Import(w.file, m.mirror, true)
+	}
+	if err == nil {
+		err = m.mirror.PersistState(ctx, w.schemas)
+	}
+	if err == nil {
+		log.Printf("[TRACE] statemgr.Mirrored: wrote snapshot with serial %d to mirror", w.file.Serial)
+		return nil
+	}
+
+	err = fmt.Errorf("failed to write state snapshot to mirror: %w", err)
+	log.Printf("[WARN] statemgr.Mirrored: %s", err)
+	return err
+}
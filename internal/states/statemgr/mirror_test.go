@@ -0,0 +1,74 @@
+// Copyright (c) The OpenTofu Authors
+// SPDX-License-Identifier: MPL-2.0
+
+package statemgr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opentofu/opentofu/internal/encryption"
+	"github.com/opentofu/opentofu/internal/states/statefile"
+)
+
+func TestMirrored(t *testing.T) {
+	for name, async := range map[string]bool{"sync": false, "async": true} {
+		t.Run(name, func(t *testing.T) {
+			defer testOverrideVersion(t, "1.2.3")()
+			primary := testFilesystem(t)
+			defer os.Remove(primary.readPath)
+			mirror := NewFilesystem(filepath.Join(t.TempDir(), "mirror.tfstate"), encryption.StateEncryptionDisabled())
+
+			var errs []error
+			mgr := NewMirrored(primary, mirror, async, func(err error) {
+				errs = append(errs, err)
+			})
+			TestFull(t, mgr)
+			mgr.Flush()
+
+			if len(errs) != 0 {
+				t.Fatalf("unexpected mirror errors: %v", errs)
+			}
+
+			if err := mirror.RefreshState(t.Context()); err != nil {
+				t.Fatal(err)
+			}
+			want := Export(primary)
+			got := Export(mirror)
+			if got.Lineage != want.Lineage {
+				t.Errorf("mirror has lineage %q; want %q", got.Lineage, want.Lineage)
+			}
+			if !statefile.StatesMarshalEqual(got.State, want.State) {
+				t.Error("mirror state does not match primary state")
+			}
+		})
+	}
+}
+
+func TestMirrored_mirrorError(t *testing.T) {
+	for name, async := range map[string]bool{"sync": false, "async": true} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			primary := NewFilesystem(filepath.Join(dir, "primary.tfstate"), encryption.StateEncryptionDisabled())
+
+			// The callback is only called on this goroutine, so it doesn't
+			// need any synchronization even in async mode.
+			var errs []error
+			mgr := NewMirrored(primary, NewUnlockErrorFull(nil, nil), async, func(err error) {
+				errs = append(errs, err)
+			})
+
+			if err := mgr.WriteState(TestFullInitialState()); err != nil {
+				t.Fatal(err)
+			}
+			if err := mgr.PersistState(t.Context(), nil); err != nil {
+				t.Fatalf("mirror failure must not fail PersistState: %s", err)
+			}
+			mgr.Flush()
+			if len(errs) != 1 {
+				t.Fatalf("expected one mirror error, got %d", len(errs))
+			}
+		})
+	}
+}
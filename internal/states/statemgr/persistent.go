@@ -88,6 +88,26 @@ type Persister interface {
 	PersistState(context.Context, *tofu.Schemas) error
 }
 
+// Flusher is an optional extension to Persister for managers that may still
+// be writing snapshots in the background after PersistState returns.
+type Flusher interface {
+	// Flush blocks until all snapshots persisted so far have been written,
+	// including any that are being written in the background.
+	Flush()
+}
+
+// Flush calls the Flush method of the given manager if it implements
+// Flusher, and otherwise does nothing.
+//
+// Callers that persist snapshots must call this once they have finished
+// with the manager, regardless of whether they hold a lock on it, so that
+// no snapshots are lost when the process exits.
+func Flush(mgr Persister) {
+	if f, ok := mgr.(Flusher); ok {
+		f.Flush()
+	}
+}
+
 // PersistentMeta is an optional extension to Persistent that allows inspecting
 // the metadata associated with the snapshot that was most recently either
 // read by RefreshState or written by PersistState.
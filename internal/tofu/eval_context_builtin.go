@@ -159,6 +159,7 @@ func (c *BuiltinEvalContext) InitProvider(ctx context.Context, addr addrs.AbsPro
 			}
 
 			p = testP.
+				withMockSeed(pc.MockSeed).
 				withMockResources(pc.MockResources).
 				withOverrideResources(pc.OverrideResources)
 		}
@@ -9,10 +9,14 @@ import (
 	"context"
 	"fmt"
 	"hash/fnv"
+	"strconv"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/opentofu/opentofu/internal/addrs"
 	"github.com/opentofu/opentofu/internal/configs"
+	"github.com/opentofu/opentofu/internal/configs/configschema"
 	"github.com/opentofu/opentofu/internal/configs/hcl2shim"
+	"github.com/opentofu/opentofu/internal/lang"
 	"github.com/opentofu/opentofu/internal/providers"
 	"github.com/opentofu/opentofu/internal/tfdiags"
 	"github.com/zclconf/go-cty/cty"
@@ -28,6 +32,7 @@ type providerForTest struct {
 	internal providers.Interface
 	schema   providers.ProviderSchema
 
+	mockSeed          int64
 	mockResources     mockResourcesForTest
 	overrideResources overrideResourcesForTest
 
@@ -84,12 +89,18 @@ func (p providerForTest) PlanResourceChange(_ context.Context, r providers.PlanR
 
 	resSchema, _ := p.schema.SchemaForResourceType(addrs.ManagedResourceMode, r.TypeName)
 
-	mockValues := p.getMockValuesForManagedResource(r.TypeName)
-
 	var resp providers.PlanResourceChangeResponse
 
-	resp.PlannedState, resp.Diagnostics = newMockValueComposer(r.TypeName).
-		ComposeBySchema(resSchema, r.Config, mockValues)
+	composer := p.newMockValueComposer(r.TypeName)
+
+	mockValues, diags := p.getMockValuesForManagedResource(r.TypeName).evaluate(composer, resSchema, r.Config)
+	if diags.HasErrors() {
+		resp.Diagnostics = diags
+		return resp
+	}
+
+	resp.PlannedState, resp.Diagnostics = composer.ComposeBySchema(resSchema, r.Config, mockValues)
+	resp.Diagnostics = diags.Append(resp.Diagnostics)
 
 	return resp
 }
@@ -105,10 +116,16 @@ func (p providerForTest) ReadDataSource(_ context.Context, r providers.ReadDataS
 
 	var resp providers.ReadDataSourceResponse
 
-	mockValues := p.getMockValuesForDataResource(r.TypeName)
+	composer := p.newMockValueComposer(r.TypeName)
 
-	resp.State, resp.Diagnostics = newMockValueComposer(r.TypeName).
-		ComposeBySchema(resSchema, r.Config, mockValues)
+	mockValues, diags := p.getMockValuesForDataResource(r.TypeName).evaluate(composer, resSchema, r.Config)
+	if diags.HasErrors() {
+		resp.Diagnostics = diags
+		return resp
+	}
+
+	resp.State, resp.Diagnostics = composer.ComposeBySchema(resSchema, r.Config, mockValues)
+	resp.Diagnostics = diags.Append(resp.Diagnostics)
 
 	return resp
 }
@@ -212,12 +229,18 @@ func (p providerForTest) withMockResources(mockResources []*configs.MockResource
 
 		resources[res.Type] = resourceForTest{
 			values: res.Defaults,
+			expr:   res.DefaultsExpr,
 		}
 	}
 
 	return p
 }
 
+func (p providerForTest) withMockSeed(seed int64) providerForTest {
+	p.mockSeed = seed
+	return p
+}
+
 func (p providerForTest) withCopiedOverrideResources() providerForTest {
 	p.overrideResources = p.overrideResources.copy()
 	return p
@@ -259,6 +282,77 @@ func (p providerForTest) linkWithCurrentResource(addr addrs.ConfigResource) prov
 
 type resourceForTest struct {
 	values map[string]cty.Value
+
+	// expr is set instead of values if the values must be evaluated for each
+	// resource, because they refer to its planned values or call functions.
+	expr hcl.Expression
+}
+
+// evaluate returns the values to use for the resource with the given
+// configuration. The expression, if any, can refer to the configuration as
+// self, and generate values with the pattern function, which draws from the
+// same random sequence as the composer.
+//
+// Only pure functions are available, so that the values are the same every
+// time the tests run.
+func (res resourceForTest) evaluate(composer hcl2shim.MockValueComposer, schema *configschema.Block, config cty.Value) (map[string]cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	if res.expr == nil {
+		return res.values, diags
+	}
+
+	funcs := (&lang.Scope{BaseDir: ".", PureOnly: true}).Functions()
+	funcs["pattern"] = composer.PatternFunc()
+
+	val, hclDiags := res.expr.Value(&hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"self": config,
+		},
+		Functions: funcs,
+	})
+	diags = diags.Append(hclDiags)
+	if hclDiags.HasErrors() {
+		return nil, diags
+	}
+
+	if !val.Type().IsObjectType() && val.Type() != cty.DynamicPseudoType {
+		return nil, diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Object expected",
+			Detail:   "The attribute `defaults` must be an object.",
+			Subject:  res.expr.Range().Ptr(),
+		})
+	}
+
+	if !val.IsWhollyKnown() && config.IsWhollyKnown() {
+		// Impure functions return unknown values when PureOnly is set, and
+		// that's the only way to get one when self is known.
+		return nil, diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Impure function in mock defaults",
+			Detail:   "The attribute `defaults` must produce the same values every time the tests run, so it can't call functions such as timestamp or uuid. Use the pattern function to generate values instead.",
+			Subject:  res.expr.Range().Ptr(),
+		})
+	}
+
+	if !val.IsKnown() {
+		// The defaults depend on planned values that are not known yet, so
+		// none of the computed attributes are known either.
+		values := make(map[string]cty.Value)
+		for name, attr := range schema.Attributes {
+			if attr.Computed && (config.IsNull() || config.GetAttr(name).IsNull()) {
+				values[name] = cty.DynamicVal
+			}
+		}
+		return values, diags
+	}
+
+	if val.IsNull() {
+		return nil, diags
+	}
+
+	return val.AsValueMap(), diags
 }
 
 type mockResourceType = string
@@ -292,30 +386,36 @@ func (res overrideResourcesForTest) copy() overrideResourcesForTest {
 	return resCopy
 }
 
-func (p providerForTest) getMockValuesForManagedResource(typeName string) map[string]cty.Value {
+func (p providerForTest) getMockValuesForManagedResource(typeName string) resourceForTest {
 	if p.currentResourceAddress != "" {
 		res, ok := p.overrideResources.managed[p.currentResourceAddress]
 		if ok {
-			return res.values
+			return res
 		}
 	}
 
-	return p.mockResources.managed[typeName].values
+	return p.mockResources.managed[typeName]
 }
 
-func (p providerForTest) getMockValuesForDataResource(typeName string) map[string]cty.Value {
+func (p providerForTest) getMockValuesForDataResource(typeName string) resourceForTest {
 	if p.currentResourceAddress != "" {
 		res, ok := p.overrideResources.data[p.currentResourceAddress]
 		if ok {
-			return res.values
+			return res
 		}
 	}
 
-	return p.mockResources.data[typeName].values
+	return p.mockResources.data[typeName]
 }
 
-func newMockValueComposer(typeName string) hcl2shim.MockValueComposer {
+// newMockValueComposer returns a composer seeded by the resource type, and
+// by the seed of the mock provider if one is set, so the values generated
+// for a resource type are the same between runs.
+func (p providerForTest) newMockValueComposer(typeName string) hcl2shim.MockValueComposer {
 	hash := fnv.New32()
 	hash.Write([]byte(typeName))
+	if p.mockSeed != 0 {
+		hash.Write([]byte(strconv.FormatInt(p.mockSeed, 10)))
+	}
 	return hcl2shim.NewMockValueComposer(int64(hash.Sum32()))
 }